@@ -5,12 +5,22 @@ import (
 	"os"
 
 	"github.com/fernandoocampo/kb-store/apps/kbs/internal/application"
+	// registers the pgx driver of the sql kbs store.
+	_ "github.com/jackc/pgx/v5/stdlib"
 )
 
 func main() {
 	app := application.NewServer()
 
-	if err := app.Run(); err != nil {
+	var err error
+
+	if len(os.Args) > 1 {
+		err = app.RunCommand(os.Args[1], os.Args[2:])
+	} else {
+		err = app.Run()
+	}
+
+	if err != nil {
 		log.Printf("unable to start service: %s", err)
 		os.Exit(-1)
 	}
@@ -0,0 +1,119 @@
+// Package blobs contains stores of content too large to be kept in the
+// items of the kbs store.
+package blobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	// ErrBlobNotFound is returned when there is no blob with the given key,
+	// it is an fs.ErrNotExist too, so callers don't depend on the store.
+	ErrBlobNotFound error = notFoundError{}
+	errInvalidKey         = errors.New("invalid blob key")
+)
+
+type notFoundError struct{}
+
+func (notFoundError) Error() string {
+	return "blob not found"
+}
+
+func (notFoundError) Is(target error) bool {
+	return target == fs.ErrNotExist
+}
+
+// FileStore keeps each blob in a file of a directory, it is meant for
+// local environments and tests.
+type FileStore struct {
+	dir string
+}
+
+// NewFileStore creates a store that keeps the blobs in the given
+// directory, it is created if it does not exist.
+func NewFileStore(dir string) (*FileStore, error) {
+	err := os.MkdirAll(dir, 0o750)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create blobs directory: %w", err)
+	}
+
+	return &FileStore{dir: dir}, nil
+}
+
+func (f *FileStore) PutBlob(ctx context.Context, key string, data []byte) error {
+	path, err := f.path(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), 0o750)
+	if err != nil {
+		return err
+	}
+
+	// the blob is written aside and renamed, so readers never see half of it.
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".blob-*")
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(data)
+	if err != nil {
+		tmp.Close()
+
+		return err
+	}
+
+	err = tmp.Close()
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+func (f *FileStore) GetBlob(ctx context.Context, key string) ([]byte, error) {
+	path, err := f.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %q", ErrBlobNotFound, key)
+	}
+
+	return data, err
+}
+
+func (f *FileStore) DeleteBlob(ctx context.Context, key string) error {
+	path, err := f.path(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	return err
+}
+
+// path returns the file of the blob, keys cannot point outside the directory.
+func (f *FileStore) path(key string) (string, error) {
+	cleaned := filepath.Clean(filepath.FromSlash(key))
+	if key == "" || filepath.IsAbs(cleaned) || cleaned == ".." || strings.HasPrefix(cleaned, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%w: %q", errInvalidKey, key)
+	}
+
+	return filepath.Join(f.dir, cleaned), nil
+}
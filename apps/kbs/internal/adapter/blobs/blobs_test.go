@@ -0,0 +1,113 @@
+package blobs_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/fs"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/adapter/blobs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileStoreKeepsBlobs(t *testing.T) {
+	// Given
+	ctx := context.TODO()
+	store, err := blobs.NewFileStore(t.TempDir())
+	require.NoError(t, err)
+
+	// When
+	err = store.PutBlob(ctx, "kbs/kb-1/a1", []byte("large content"))
+	require.NoError(t, err)
+	got, getErr := store.GetBlob(ctx, "kbs/kb-1/a1")
+	deleteErr := store.DeleteBlob(ctx, "kbs/kb-1/a1")
+	_, deletedErr := store.GetBlob(ctx, "kbs/kb-1/a1")
+
+	// Then
+	assert.NoError(t, getErr)
+	assert.Equal(t, []byte("large content"), got)
+	assert.NoError(t, deleteErr)
+	assert.ErrorIs(t, deletedErr, blobs.ErrBlobNotFound)
+	assert.ErrorIs(t, deletedErr, fs.ErrNotExist)
+	assert.NoError(t, store.DeleteBlob(ctx, "kbs/kb-1/a1"))
+}
+
+func TestFileStoreKeysStayInsideItsDirectory(t *testing.T) {
+	// Given
+	ctx := context.TODO()
+	store, err := blobs.NewFileStore(t.TempDir())
+	require.NoError(t, err)
+
+	for _, key := range []string{"", "../outside", "/etc/passwd", "kbs/../../outside"} {
+		// When
+		err := store.PutBlob(ctx, key, []byte("content"))
+
+		// Then
+		assert.Error(t, err, key)
+	}
+}
+
+func TestS3StoreKeepsBlobsUnderItsPrefix(t *testing.T) {
+	// Given
+	ctx := context.TODO()
+	client := newFakeS3()
+	store, err := blobs.NewS3Store(ctx, blobs.S3Setup{Client: client, Bucket: "kbs-content", Prefix: "staging/"})
+	require.NoError(t, err)
+
+	// When
+	err = store.PutBlob(ctx, "kbs/kb-1/a1", []byte("large content"))
+	require.NoError(t, err)
+	got, getErr := store.GetBlob(ctx, "kbs/kb-1/a1")
+	deleteErr := store.DeleteBlob(ctx, "kbs/kb-1/a1")
+	_, deletedErr := store.GetBlob(ctx, "kbs/kb-1/a1")
+
+	// Then
+	assert.NoError(t, getErr)
+	assert.Equal(t, []byte("large content"), got)
+	assert.NoError(t, deleteErr)
+	assert.ErrorIs(t, deletedErr, blobs.ErrBlobNotFound)
+	assert.ErrorIs(t, deletedErr, fs.ErrNotExist)
+	assert.Equal(t, []string{"kbs-content/staging/kbs/kb-1/a1"}, client.written)
+}
+
+// fakeS3 keeps the objects in memory.
+type fakeS3 struct {
+	objects map[string][]byte
+	written []string
+}
+
+func newFakeS3() *fakeS3 {
+	return &fakeS3{objects: make(map[string][]byte)}
+}
+
+func (f *fakeS3) PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	data, err := io.ReadAll(params.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	path := *params.Bucket + "/" + *params.Key
+	f.objects[path] = data
+	f.written = append(f.written, path)
+
+	return &s3.PutObjectOutput{}, nil
+}
+
+func (f *fakeS3) GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	data, ok := f.objects[*params.Bucket+"/"+*params.Key]
+	if !ok {
+		return nil, &types.NoSuchKey{}
+	}
+
+	return &s3.GetObjectOutput{Body: io.NopCloser(bytes.NewReader(data))}, nil
+}
+
+func (f *fakeS3) DeleteObject(ctx context.Context, params *s3.DeleteObjectInput, optFns ...func(*s3.Options)) (*s3.DeleteObjectOutput, error) {
+	delete(f.objects, *params.Bucket+"/"+*params.Key)
+
+	return &s3.DeleteObjectOutput{}, nil
+}
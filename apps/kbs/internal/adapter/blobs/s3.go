@@ -0,0 +1,117 @@
+package blobs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// S3Client contains the operations of s3 the store uses, any service with
+// the same api, like localstack or minio, works.
+type S3Client interface {
+	PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error)
+	GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error)
+	DeleteObject(ctx context.Context, params *s3.DeleteObjectInput, optFns ...func(*s3.Options)) (*s3.DeleteObjectOutput, error)
+}
+
+// S3Setup contains the settings of the s3 blob store.
+type S3Setup struct {
+	// Client is created from Region and Endpoint if it is nil.
+	Client   S3Client
+	Region   string
+	Endpoint string
+	Bucket   string
+	// Prefix is added to the key of every blob, optional.
+	Prefix string
+}
+
+// S3Store keeps the blobs as objects of a s3 bucket.
+type S3Store struct {
+	client S3Client
+	bucket string
+	prefix string
+}
+
+// NewS3Store creates a blob store that uses the given bucket.
+func NewS3Store(ctx context.Context, setup S3Setup) (*S3Store, error) {
+	newStore := S3Store{
+		client: setup.Client,
+		bucket: setup.Bucket,
+		prefix: setup.Prefix,
+	}
+
+	if newStore.client != nil {
+		return &newStore, nil
+	}
+
+	var optFns []func(*config.LoadOptions) error
+
+	if setup.Region != "" {
+		optFns = append(optFns, config.WithRegion(setup.Region))
+	}
+
+	if setup.Endpoint != "" {
+		optFns = append(optFns, config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider("d", "d", "")))
+	}
+
+	awsConfig, err := config.LoadDefaultConfig(ctx, optFns...)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load aws config: %w", err)
+	}
+
+	newStore.client = s3.NewFromConfig(awsConfig, func(o *s3.Options) {
+		if setup.Endpoint != "" {
+			o.BaseEndpoint = aws.String(setup.Endpoint)
+			// local s3 services don't resolve bucket subdomains.
+			o.UsePathStyle = true
+		}
+	})
+
+	return &newStore, nil
+}
+
+func (s *S3Store) PutBlob(ctx context.Context, key string, data []byte) error {
+	_, err := s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.prefix + key),
+		Body:   bytes.NewReader(data),
+	})
+
+	return err
+}
+
+func (s *S3Store) GetBlob(ctx context.Context, key string) ([]byte, error) {
+	output, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.prefix + key),
+	})
+	if err != nil {
+		var notFound *types.NoSuchKey
+		if errors.As(err, &notFound) {
+			return nil, fmt.Errorf("%w: %q", ErrBlobNotFound, key)
+		}
+
+		return nil, err
+	}
+
+	defer output.Body.Close()
+
+	return io.ReadAll(output.Body)
+}
+
+func (s *S3Store) DeleteBlob(ctx context.Context, key string) error {
+	_, err := s.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.prefix + key),
+	})
+
+	return err
+}
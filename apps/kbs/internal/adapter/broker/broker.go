@@ -0,0 +1,150 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/kbs"
+)
+
+// Handler processes a kb state change event.
+type Handler func(ctx context.Context, event kbs.StateChanged) error
+
+// Setup contains broker settings.
+type Setup struct {
+	Logger *slog.Logger
+	// BufferSize is the number of events that can wait to be delivered.
+	BufferSize int
+}
+
+// Broker delivers kb lifecycle events to the subscribed handlers in
+// background, so publishers don't wait for them.
+type Broker struct {
+	logger   *slog.Logger
+	queue    chan kbs.StateChanged
+	mu       sync.RWMutex
+	handlers []Handler
+	done     chan struct{}
+	closed   bool
+}
+
+const defaultBufferSize = 100
+
+var (
+	errBrokerClosed = errors.New("broker is closed")
+	errQueueFull    = errors.New("event queue is full")
+)
+
+// New creates a broker and starts delivering events.
+func New(setup Setup) *Broker {
+	bufferSize := setup.BufferSize
+	if bufferSize <= 0 {
+		bufferSize = defaultBufferSize
+	}
+
+	newBroker := Broker{
+		logger: setup.Logger,
+		queue:  make(chan kbs.StateChanged, bufferSize),
+		done:   make(chan struct{}),
+	}
+
+	go newBroker.deliver()
+
+	return &newBroker
+}
+
+// Subscribe adds a handler that receives every event published after it.
+func (b *Broker) Subscribe(handler Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.handlers = append(b.handlers, handler)
+}
+
+// Publish queues the event to be delivered. It doesn't block, if the queue
+// is full the event is rejected.
+func (b *Broker) Publish(ctx context.Context, event kbs.StateChanged) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if b.closed {
+		return errBrokerClosed
+	}
+
+	select {
+	case b.queue <- event:
+		return nil
+	default:
+		return errQueueFull
+	}
+}
+
+// Close stops accepting events and waits until the queued ones are delivered.
+func (b *Broker) Close() {
+	b.stop()
+
+	<-b.done
+}
+
+// Shutdown stops accepting events and waits until the queued ones are
+// delivered or the context is done, whatever happens first.
+func (b *Broker) Shutdown(ctx context.Context) error {
+	b.stop()
+
+	select {
+	case <-b.done:
+		return nil
+	case <-ctx.Done():
+		b.logger.Warn("events were not delivered before shutdown", slog.Int("pending", len(b.queue)))
+
+		return ctx.Err()
+	}
+}
+
+// stop closes the queue, events already queued are still delivered.
+func (b *Broker) stop() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return
+	}
+
+	b.closed = true
+	close(b.queue)
+}
+
+func (b *Broker) deliver() {
+	defer close(b.done)
+
+	for event := range b.queue {
+		b.mu.RLock()
+		handlers := b.handlers
+		b.mu.RUnlock()
+
+		for _, handler := range handlers {
+			err := handler(context.Background(), event)
+			if err != nil {
+				b.logger.Error("unable to handle kb state change",
+					slog.String("kb_id", event.KBID.String()),
+					slog.String("to", event.To.String()),
+					slog.String("error", err.Error()))
+			}
+		}
+	}
+}
+
+// LogHandler returns a handler that writes every event to the given logger.
+func LogHandler(logger *slog.Logger) Handler {
+	return func(ctx context.Context, event kbs.StateChanged) error {
+		logger.Info("kb state changed",
+			slog.String("kb_id", event.KBID.String()),
+			slog.String("from", event.From.String()),
+			slog.String("to", event.To.String()),
+			slog.String("actor_id", event.ActorID.String()))
+
+		return nil
+	}
+}
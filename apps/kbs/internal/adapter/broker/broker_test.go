@@ -0,0 +1,80 @@
+package broker_test
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/adapter/broker"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/kbs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPublishDeliversToSubscribers(t *testing.T) {
+	// Given
+	ctx := context.TODO()
+	newBroker := broker.New(broker.Setup{Logger: newDummyLogger()})
+
+	var mu sync.Mutex
+	var got []kbs.StateChanged
+
+	newBroker.Subscribe(func(ctx context.Context, event kbs.StateChanged) error {
+		mu.Lock()
+		defer mu.Unlock()
+		got = append(got, event)
+		return nil
+	})
+
+	event := kbs.StateChanged{KBID: "kb-1", From: kbs.Draft, To: kbs.InReview}
+
+	// When
+	err := newBroker.Publish(ctx, event)
+	newBroker.Close()
+
+	// Then
+	assert.NoError(t, err)
+	assert.Equal(t, []kbs.StateChanged{event}, got)
+}
+
+func TestPublishAfterClose(t *testing.T) {
+	// Given
+	newBroker := broker.New(broker.Setup{Logger: newDummyLogger()})
+	newBroker.Close()
+
+	// When
+	err := newBroker.Publish(context.TODO(), kbs.StateChanged{KBID: "kb-1"})
+
+	// Then
+	assert.Error(t, err)
+}
+
+func TestShutdownWithDeadline(t *testing.T) {
+	// Given
+	newBroker := broker.New(broker.Setup{Logger: newDummyLogger()})
+	release := make(chan struct{})
+	defer close(release)
+
+	newBroker.Subscribe(func(ctx context.Context, event kbs.StateChanged) error {
+		<-release
+		return nil
+	})
+
+	err := newBroker.Publish(context.TODO(), kbs.StateChanged{KBID: "kb-1"})
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.TODO(), 20*time.Millisecond)
+	defer cancel()
+
+	// When
+	err = newBroker.Shutdown(ctx)
+
+	// Then
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func newDummyLogger() *slog.Logger {
+	return slog.New(slog.NewJSONHandler(os.Stdout, nil))
+}
@@ -0,0 +1,366 @@
+// Package cache keeps the kbs read from the store in memory.
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/kbs"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/requests"
+)
+
+// caches reported to the recorder.
+const (
+	kbCache     = "kb"
+	queryCache  = "query"
+	sharedCache = "shared"
+)
+
+// events reported to the recorder.
+const (
+	hitEvent      = "hit"
+	missEvent     = "miss"
+	evictionEvent = "eviction"
+)
+
+// sharedKeyPrefix is the prefix of the kb keys in the shared backend.
+const sharedKeyPrefix = "kbs:kb:"
+
+// defaultLoadTimeout bounds the store reads shared by concurrent misses if
+// the setup doesn't.
+const defaultLoadTimeout = 10 * time.Second
+
+// Backend is a cache shared by every instance of the service, so a kb
+// loaded by one of them is not loaded again by the others.
+type Backend interface {
+	// Get returns the value of the key, ok is false if it is not cached.
+	Get(ctx context.Context, key string) (value []byte, ok bool, err error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, key string) error
+}
+
+// Recorder receives the hits, misses and evictions of every cache.
+type Recorder interface {
+	RecordCacheEvent(cache, event string)
+}
+
+// Setup contains the cache settings.
+type Setup struct {
+	Storer kbs.Storer
+	// Size is the maximum number of kbs and of query results kept in
+	// memory. Zero disables the in-memory cache.
+	Size int
+	// TTL is how long a kb or a query result is kept.
+	TTL time.Duration
+	// NegativeTTL is how long a missing kb is remembered.
+	NegativeTTL time.Duration
+	// LoadTimeout bounds a store read shared by concurrent misses, it
+	// doesn't end with the request that started it.
+	LoadTimeout time.Duration
+	// Shared is optional, it is consulted before the store on a miss.
+	Shared   Backend
+	Recorder Recorder
+	Logger   *slog.Logger
+	// Clock returns the current time, time.Now is used if it is nil.
+	Clock func() time.Time
+}
+
+// Store answers kb reads from memory and invalidates them on every write.
+// Query results are only kept in memory, because any write can change
+// them and they are all dropped when it happens.
+type Store struct {
+	storer      kbs.Storer
+	kbs         *lru
+	queries     *lru
+	loads       *group
+	shared      Backend
+	ttl         time.Duration
+	negativeTTL time.Duration
+	loadTimeout time.Duration
+	recorder    Recorder
+	logger      *slog.Logger
+	clock       func() time.Time
+}
+
+// sharedEntry is the representation of a cached kb in the shared backend.
+type sharedEntry struct {
+	Found bool    `json:"found"`
+	KB    *kbs.KB `json:"kb,omitempty"`
+}
+
+// New creates a store that caches the reads of the given one.
+func New(setup Setup) *Store {
+	clock := setup.Clock
+	if clock == nil {
+		clock = time.Now
+	}
+
+	loadTimeout := setup.LoadTimeout
+	if loadTimeout <= 0 {
+		loadTimeout = defaultLoadTimeout
+	}
+
+	return &Store{
+		storer:      setup.Storer,
+		kbs:         newLRU(setup.Size),
+		queries:     newLRU(setup.Size),
+		loads:       newGroup(),
+		shared:      setup.Shared,
+		ttl:         setup.TTL,
+		negativeTTL: setup.NegativeTTL,
+		loadTimeout: loadTimeout,
+		recorder:    setup.Recorder,
+		logger:      setup.Logger,
+		clock:       clock,
+	}
+}
+
+func (s *Store) Save(ctx context.Context, newKB kbs.KB) error {
+	err := s.storer.Save(ctx, newKB)
+	s.invalidate(ctx, newKB.ID)
+
+	return err
+}
+
+func (s *Store) Update(ctx context.Context, kb kbs.UpdateKB) error {
+	err := s.storer.Update(ctx, kb)
+	s.invalidate(ctx, kb.ID)
+
+	return err
+}
+
+func (s *Store) Delete(ctx context.Context, kb kbs.KB) error {
+	err := s.storer.Delete(ctx, kb)
+	s.invalidate(ctx, kb.ID)
+
+	return err
+}
+
+func (s *Store) ChangeState(ctx context.Context, change kbs.StateChange) error {
+	err := s.storer.ChangeState(ctx, change)
+	s.invalidate(ctx, change.ID)
+
+	return err
+}
+
+// QueryByID returns the cached kb, concurrent misses of the same kb wait
+// for a single store read.
+func (s *Store) QueryByID(ctx context.Context, id kbs.KBID) (*kbs.KB, error) {
+	key := id.String()
+
+	cached, ok := s.kbs.get(key, s.clock())
+	if ok {
+		s.record(kbCache, hitEvent)
+
+		return copyKB(cached), nil
+	}
+
+	s.record(kbCache, missEvent)
+
+	version := s.kbs.currentVersion()
+
+	loaded, err, _ := s.loads.do(ctx, kbCache+":"+key, func() (entry, error) {
+		loadCtx, cancel := s.loadContext(ctx)
+		defer cancel()
+
+		return s.loadKB(loadCtx, id)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if s.kbs.set(key, s.expiring(loaded), version) {
+		s.record(kbCache, evictionEvent)
+	}
+
+	return copyKB(loaded), nil
+}
+
+// Query returns the cached result of the filter, concurrent misses of the
+// same filter wait for a single store read.
+func (s *Store) Query(ctx context.Context, filter kbs.QueryFilter) (kbs.SearchKBsResult, error) {
+	key := queryKey(filter)
+
+	cached, ok := s.queries.get(key, s.clock())
+	if ok {
+		s.record(queryCache, hitEvent)
+
+		return copyResult(cached.result), nil
+	}
+
+	s.record(queryCache, missEvent)
+
+	version := s.queries.currentVersion()
+
+	loaded, err, _ := s.loads.do(ctx, queryCache+":"+key, func() (entry, error) {
+		loadCtx, cancel := s.loadContext(ctx)
+		defer cancel()
+
+		result, err := s.storer.Query(loadCtx, filter)
+		if err != nil {
+			return entry{}, err
+		}
+
+		return entry{result: result, found: true}, nil
+	})
+	if err != nil {
+		return kbs.SearchKBsResult{}, err
+	}
+
+	if s.queries.set(key, s.expiring(loaded), version) {
+		s.record(queryCache, evictionEvent)
+	}
+
+	return copyResult(loaded.result), nil
+}
+
+// loadContext returns the context of a shared read. The read must not fail
+// because the caller that started it went away, so it keeps the values of
+// the caller's context but not its cancellation, and it has its own
+// deadline instead.
+func (s *Store) loadContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.WithoutCancel(ctx), s.loadTimeout)
+}
+
+// loadKB reads the kb from the shared backend or from the store, a kb read
+// from the store is shared with the other instances.
+func (s *Store) loadKB(ctx context.Context, id kbs.KBID) (entry, error) {
+	cached, ok := s.getShared(ctx, id)
+	if ok {
+		return cached, nil
+	}
+
+	kb, err := s.storer.QueryByID(ctx, id)
+	if err != nil {
+		return entry{}, err
+	}
+
+	loaded := entry{kb: kb, found: kb != nil}
+	s.setShared(ctx, id, loaded)
+
+	return loaded, nil
+}
+
+func (s *Store) getShared(ctx context.Context, id kbs.KBID) (entry, bool) {
+	if s.shared == nil {
+		return entry{}, false
+	}
+
+	logger := requests.Logger(ctx, s.logger)
+
+	value, ok, err := s.shared.Get(ctx, sharedKeyPrefix+id.String())
+	if err != nil {
+		logger.Warn("unable to read kb from shared cache", slog.String("kb_id", id.String()), slog.String("error", err.Error()))
+
+		return entry{}, false
+	}
+
+	if !ok {
+		s.record(sharedCache, missEvent)
+
+		return entry{}, false
+	}
+
+	var cached sharedEntry
+
+	err = json.Unmarshal(value, &cached)
+	if err != nil {
+		logger.Warn("unable to decode kb from shared cache", slog.String("kb_id", id.String()), slog.String("error", err.Error()))
+
+		return entry{}, false
+	}
+
+	s.record(sharedCache, hitEvent)
+
+	return entry{kb: cached.KB, found: cached.Found}, true
+}
+
+func (s *Store) setShared(ctx context.Context, id kbs.KBID, loaded entry) {
+	if s.shared == nil {
+		return
+	}
+
+	logger := requests.Logger(ctx, s.logger)
+
+	value, err := json.Marshal(sharedEntry{Found: loaded.found, KB: loaded.kb})
+	if err != nil {
+		logger.Warn("unable to encode kb for shared cache", slog.String("kb_id", id.String()), slog.String("error", err.Error()))
+
+		return
+	}
+
+	err = s.shared.Set(ctx, sharedKeyPrefix+id.String(), value, s.lifetime(loaded))
+	if err != nil {
+		logger.Warn("unable to write kb to shared cache", slog.String("kb_id", id.String()), slog.String("error", err.Error()))
+	}
+}
+
+// invalidate drops the cached kb and every cached query result, because
+// the write could have changed any of them.
+func (s *Store) invalidate(ctx context.Context, id kbs.KBID) {
+	s.kbs.remove(id.String())
+	s.queries.purge()
+
+	if s.shared == nil {
+		return
+	}
+
+	err := s.shared.Delete(ctx, sharedKeyPrefix+id.String())
+	if err != nil {
+		logger := requests.Logger(ctx, s.logger)
+		logger.Warn("unable to invalidate kb in shared cache", slog.String("kb_id", id.String()), slog.String("error", err.Error()))
+	}
+}
+
+// expiring sets the expiration of the entry according to its kind.
+func (s *Store) expiring(loaded entry) entry {
+	loaded.expires = s.clock().Add(s.lifetime(loaded))
+
+	return loaded
+}
+
+func (s *Store) lifetime(loaded entry) time.Duration {
+	if !loaded.found {
+		return s.negativeTTL
+	}
+
+	return s.ttl
+}
+
+func (s *Store) record(cache, event string) {
+	if s.recorder == nil {
+		return
+	}
+
+	s.recorder.RecordCacheEvent(cache, event)
+}
+
+// queryKey identifies the result of a filter.
+func queryKey(filter kbs.QueryFilter) string {
+	return fmt.Sprintf("%s|%s|%s|%s|%s|%d|%d", filter.EventID, filter.UserID, filter.State,
+		filter.OrderBy, filter.Order, filter.PageNumber, filter.RowsPerPage)
+}
+
+// copyKB returns a copy of the cached kb so callers cannot change it.
+func copyKB(cached entry) *kbs.KB {
+	if !cached.found || cached.kb == nil {
+		return nil
+	}
+
+	kb := *cached.kb
+	kb.Reviews = append([]kbs.Review(nil), cached.kb.Reviews...)
+	kb.Path = append([]kbs.Breadcrumb(nil), cached.kb.Path...)
+
+	return &kb
+}
+
+// copyResult returns a copy of the cached result so callers cannot change it.
+func copyResult(cached kbs.SearchKBsResult) kbs.SearchKBsResult {
+	result := cached
+	result.KBs = append([]kbs.KB(nil), cached.KBs...)
+
+	return result
+}
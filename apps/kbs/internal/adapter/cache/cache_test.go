@@ -0,0 +1,406 @@
+package cache_test
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/adapter/cache"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/kbs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryByIDIsCachedUntilWrite(t *testing.T) {
+	// Given
+	ctx := context.TODO()
+	store := newMemoryStore(kbs.KB{ID: "kb-1", Content: "first"})
+	recorder := newRecorder()
+	cachedStore := cache.New(cache.Setup{
+		Storer:   store,
+		Size:     10,
+		TTL:      time.Minute,
+		Recorder: recorder,
+		Logger:   newDummyLogger(),
+	})
+
+	// When
+	first, err := cachedStore.QueryByID(ctx, "kb-1")
+	require.NoError(t, err)
+	first.Content = "changed by caller"
+	second, err := cachedStore.QueryByID(ctx, "kb-1")
+	require.NoError(t, err)
+	err = cachedStore.Update(ctx, kbs.UpdateKB{ID: "kb-1", Content: "second"})
+	require.NoError(t, err)
+	third, err := cachedStore.QueryByID(ctx, "kb-1")
+	require.NoError(t, err)
+
+	// Then
+	assert.Equal(t, "first", second.Content)
+	assert.Equal(t, "second", third.Content)
+	assert.Equal(t, 2, store.reads("kb-1"))
+	assert.Equal(t, 1, recorder.count("kb", "hit"))
+	assert.Equal(t, 2, recorder.count("kb", "miss"))
+}
+
+func TestQueryByIDCachesMissingKBs(t *testing.T) {
+	// Given
+	ctx := context.TODO()
+	now := time.Unix(1700000000, 0)
+	store := newMemoryStore()
+	cachedStore := cache.New(cache.Setup{
+		Storer:      store,
+		Size:        10,
+		TTL:         time.Minute,
+		NegativeTTL: 10 * time.Second,
+		Logger:      newDummyLogger(),
+		Clock:       func() time.Time { return now },
+	})
+
+	// When
+	missing, err := cachedStore.QueryByID(ctx, "kb-1")
+	require.NoError(t, err)
+	_, err = cachedStore.QueryByID(ctx, "kb-1")
+	require.NoError(t, err)
+	now = now.Add(11 * time.Second)
+	_, err = cachedStore.QueryByID(ctx, "kb-1")
+	require.NoError(t, err)
+	err = cachedStore.Save(ctx, kbs.KB{ID: "kb-1", Content: "new"})
+	require.NoError(t, err)
+	saved, err := cachedStore.QueryByID(ctx, "kb-1")
+	require.NoError(t, err)
+
+	// Then
+	assert.Nil(t, missing)
+	require.NotNil(t, saved)
+	assert.Equal(t, "new", saved.Content)
+	assert.Equal(t, 3, store.reads("kb-1"))
+}
+
+func TestQueryByIDLoadsConcurrentMissesOnce(t *testing.T) {
+	// Given
+	ctx := context.TODO()
+	store := newMemoryStore(kbs.KB{ID: "kb-1", Content: "first"})
+	store.release = make(chan struct{})
+	cachedStore := cache.New(cache.Setup{
+		Storer: store,
+		Size:   10,
+		TTL:    time.Minute,
+		Logger: newDummyLogger(),
+	})
+
+	var wg sync.WaitGroup
+	results := make([]*kbs.KB, 5)
+
+	// When
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], _ = cachedStore.QueryByID(ctx, "kb-1")
+		}(i)
+	}
+
+	store.waitForRead()
+	time.Sleep(20 * time.Millisecond)
+	close(store.release)
+	wg.Wait()
+
+	// Then
+	assert.Equal(t, 1, store.reads("kb-1"))
+	for _, result := range results {
+		require.NotNil(t, result)
+		assert.Equal(t, "first", result.Content)
+	}
+}
+
+func TestQueryByIDStopsWaitingForSlowLoads(t *testing.T) {
+	// Given
+	store := newMemoryStore(kbs.KB{ID: "kb-1", Content: "first"})
+	store.release = make(chan struct{})
+	cachedStore := cache.New(cache.Setup{
+		Storer:      store,
+		Size:        10,
+		TTL:         time.Minute,
+		LoadTimeout: 50 * time.Millisecond,
+		Logger:      newDummyLogger(),
+	})
+	ctx, cancel := context.WithTimeout(context.TODO(), 10*time.Millisecond)
+	defer cancel()
+
+	// When
+	_, errCaller := cachedStore.QueryByID(ctx, "kb-1")
+	_, errLoad := cachedStore.QueryByID(context.TODO(), "kb-1")
+
+	// Then
+	assert.ErrorIs(t, errCaller, context.DeadlineExceeded)
+	assert.ErrorIs(t, errLoad, context.DeadlineExceeded)
+	assert.Equal(t, 1, store.reads("kb-1"))
+}
+
+func TestQueryResultsAreDroppedOnWrite(t *testing.T) {
+	// Given
+	ctx := context.TODO()
+	store := newMemoryStore(kbs.KB{ID: "kb-1", EventID: "event-1"})
+	cachedStore := cache.New(cache.Setup{
+		Storer: store,
+		Size:   10,
+		TTL:    time.Minute,
+		Logger: newDummyLogger(),
+	})
+	filter := kbs.QueryFilter{EventID: "event-1", PageNumber: 1, RowsPerPage: 10}
+
+	// When
+	first, err := cachedStore.Query(ctx, filter)
+	require.NoError(t, err)
+	_, err = cachedStore.Query(ctx, filter)
+	require.NoError(t, err)
+	err = cachedStore.Save(ctx, kbs.KB{ID: "kb-2", EventID: "event-1"})
+	require.NoError(t, err)
+	afterSave, err := cachedStore.Query(ctx, filter)
+	require.NoError(t, err)
+
+	// Then
+	assert.Len(t, first.KBs, 1)
+	assert.Len(t, afterSave.KBs, 2)
+	assert.Equal(t, 2, store.queries)
+}
+
+func TestLeastRecentlyUsedKBIsEvicted(t *testing.T) {
+	// Given
+	ctx := context.TODO()
+	store := newMemoryStore(kbs.KB{ID: "kb-1"}, kbs.KB{ID: "kb-2"}, kbs.KB{ID: "kb-3"})
+	recorder := newRecorder()
+	cachedStore := cache.New(cache.Setup{
+		Storer:   store,
+		Size:     2,
+		TTL:      time.Minute,
+		Recorder: recorder,
+		Logger:   newDummyLogger(),
+	})
+
+	// When
+	for _, id := range []kbs.KBID{"kb-1", "kb-2", "kb-1", "kb-3", "kb-1", "kb-2"} {
+		_, err := cachedStore.QueryByID(ctx, id)
+		require.NoError(t, err)
+	}
+
+	// Then
+	assert.Equal(t, 1, store.reads("kb-1"))
+	assert.Equal(t, 2, store.reads("kb-2"))
+	assert.Equal(t, 1, store.reads("kb-3"))
+	assert.Equal(t, 2, recorder.count("kb", "eviction"))
+}
+
+func TestQueryByIDUsesSharedBackend(t *testing.T) {
+	// Given
+	ctx := context.TODO()
+	shared := newSharedBackend()
+	store := newMemoryStore(kbs.KB{ID: "kb-1", Content: "first"})
+	newInstance := func() *cache.Store {
+		return cache.New(cache.Setup{
+			Storer: store,
+			Size:   10,
+			TTL:    time.Minute,
+			Shared: shared,
+			Logger: newDummyLogger(),
+		})
+	}
+	instanceA := newInstance()
+	instanceB := newInstance()
+
+	// When
+	_, err := instanceA.QueryByID(ctx, "kb-1")
+	require.NoError(t, err)
+	fromShared, err := instanceB.QueryByID(ctx, "kb-1")
+	require.NoError(t, err)
+	err = instanceA.Delete(ctx, kbs.KB{ID: "kb-1"})
+	require.NoError(t, err)
+
+	// Then
+	require.NotNil(t, fromShared)
+	assert.Equal(t, "first", fromShared.Content)
+	assert.Equal(t, 1, store.reads("kb-1"))
+	assert.Empty(t, shared.values)
+}
+
+type memoryStore struct {
+	mu      sync.Mutex
+	kbs     map[kbs.KBID]kbs.KB
+	read    map[kbs.KBID]int
+	queries int
+	// release blocks reads until it is closed if it is not nil.
+	release chan struct{}
+	started chan struct{}
+}
+
+func newMemoryStore(initial ...kbs.KB) *memoryStore {
+	store := memoryStore{
+		kbs:     make(map[kbs.KBID]kbs.KB),
+		read:    make(map[kbs.KBID]int),
+		started: make(chan struct{}, 100),
+	}
+
+	for _, kb := range initial {
+		store.kbs[kb.ID] = kb
+	}
+
+	return &store
+}
+
+func (m *memoryStore) Save(ctx context.Context, newKB kbs.KB) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.kbs[newKB.ID] = newKB
+
+	return nil
+}
+
+func (m *memoryStore) Update(ctx context.Context, kb kbs.UpdateKB) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	current := m.kbs[kb.ID]
+	current.Content = kb.Content
+	m.kbs[kb.ID] = current
+
+	return nil
+}
+
+func (m *memoryStore) Delete(ctx context.Context, kb kbs.KB) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.kbs, kb.ID)
+
+	return nil
+}
+
+func (m *memoryStore) ChangeState(ctx context.Context, change kbs.StateChange) error {
+	return nil
+}
+
+func (m *memoryStore) Query(ctx context.Context, filter kbs.QueryFilter) (kbs.SearchKBsResult, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.queries++
+
+	var result kbs.SearchKBsResult
+	for _, kb := range m.kbs {
+		if kb.EventID.String() == filter.EventID {
+			result.KBs = append(result.KBs, kb)
+		}
+	}
+
+	result.Total = len(result.KBs)
+
+	return result, nil
+}
+
+func (m *memoryStore) QueryByID(ctx context.Context, id kbs.KBID) (*kbs.KB, error) {
+	m.mu.Lock()
+	m.read[id]++
+	m.mu.Unlock()
+
+	m.started <- struct{}{}
+	if m.release != nil {
+		select {
+		case <-m.release:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	kb, ok := m.kbs[id]
+	if !ok {
+		return nil, nil
+	}
+
+	return &kb, nil
+}
+
+func (m *memoryStore) reads(id kbs.KBID) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.read[id]
+}
+
+func (m *memoryStore) waitForRead() {
+	<-m.started
+}
+
+type sharedBackend struct {
+	mu     sync.Mutex
+	values map[string][]byte
+}
+
+func newSharedBackend() *sharedBackend {
+	return &sharedBackend{
+		values: make(map[string][]byte),
+	}
+}
+
+func (s *sharedBackend) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	value, ok := s.values[key]
+
+	return value, ok, nil
+}
+
+func (s *sharedBackend) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.values[key] = value
+
+	return nil
+}
+
+func (s *sharedBackend) Delete(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.values, key)
+
+	return nil
+}
+
+type recorder struct {
+	mu     sync.Mutex
+	events map[string]int
+}
+
+func newRecorder() *recorder {
+	return &recorder{
+		events: make(map[string]int),
+	}
+}
+
+func (r *recorder) RecordCacheEvent(cache, event string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.events[cache+"/"+event]++
+}
+
+func (r *recorder) count(cache, event string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.events[cache+"/"+event]
+}
+
+func newDummyLogger() *slog.Logger {
+	return slog.New(slog.NewJSONHandler(os.Stdout, nil))
+}
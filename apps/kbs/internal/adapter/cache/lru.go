@@ -0,0 +1,133 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/kbs"
+)
+
+// entry is a cached store answer. Missing kbs are cached too, with found
+// set to false, so unknown ids don't hit the store on every read.
+type entry struct {
+	kb      *kbs.KB
+	found   bool
+	result  kbs.SearchKBsResult
+	expires time.Time
+}
+
+// element is the value of every item of the recency list.
+type element struct {
+	key   string
+	entry entry
+}
+
+// lru keeps a bounded number of entries in memory, the least recently used
+// ones are evicted first. Every removal bumps the version, so answers
+// loaded before a write are not stored after the write invalidated them.
+type lru struct {
+	size    int
+	mu      sync.Mutex
+	items   map[string]*list.Element
+	recency *list.List
+	version uint64
+}
+
+func newLRU(size int) *lru {
+	return &lru{
+		size:    size,
+		items:   make(map[string]*list.Element),
+		recency: list.New(),
+	}
+}
+
+// get returns the entry of the given key if it has not expired.
+func (c *lru) get(key string, now time.Time) (entry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	item, ok := c.items[key]
+	if !ok {
+		return entry{}, false
+	}
+
+	cached := item.Value.(*element).entry
+	if now.After(cached.expires) {
+		c.recency.Remove(item)
+		delete(c.items, key)
+
+		return entry{}, false
+	}
+
+	c.recency.MoveToFront(item)
+
+	return cached, true
+}
+
+// currentVersion returns the version a load must present to store its answer.
+func (c *lru) currentVersion() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.version
+}
+
+// set stores the entry only if nothing was removed since the given version
+// was read. It returns true if the oldest entry was evicted to make room.
+func (c *lru) set(key string, value entry, version uint64) bool {
+	if c.size <= 0 {
+		return false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if version != c.version {
+		return false
+	}
+
+	if item, ok := c.items[key]; ok {
+		item.Value.(*element).entry = value
+		c.recency.MoveToFront(item)
+
+		return false
+	}
+
+	c.items[key] = c.recency.PushFront(&element{key: key, entry: value})
+
+	if c.recency.Len() <= c.size {
+		return false
+	}
+
+	oldest := c.recency.Back()
+	c.recency.Remove(oldest)
+	delete(c.items, oldest.Value.(*element).key)
+
+	return true
+}
+
+func (c *lru) remove(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.version++
+
+	item, ok := c.items[key]
+	if !ok {
+		return
+	}
+
+	c.recency.Remove(item)
+	delete(c.items, key)
+}
+
+// purge removes every entry.
+func (c *lru) purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.version++
+	c.items = make(map[string]*list.Element)
+	c.recency.Init()
+}
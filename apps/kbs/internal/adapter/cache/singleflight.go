@@ -0,0 +1,61 @@
+package cache
+
+import (
+	"context"
+	"sync"
+)
+
+// call is a load in progress or already finished.
+type call struct {
+	done  chan struct{}
+	value entry
+	err   error
+}
+
+// group makes concurrent loads of the same key wait for the first one
+// instead of reaching the store several times.
+type group struct {
+	mu    sync.Mutex
+	calls map[string]*call
+}
+
+func newGroup() *group {
+	return &group{
+		calls: make(map[string]*call),
+	}
+}
+
+// do runs load once for all the callers that ask for the key while it is
+// running. shared is true for the callers that reused the answer. The load
+// runs on its own, so every caller stops waiting when its context is done
+// while the load goes on for the others.
+func (g *group) do(ctx context.Context, key string, load func() (entry, error)) (value entry, err error, shared bool) {
+	g.mu.Lock()
+	current, shared := g.calls[key]
+	if !shared {
+		current = &call{done: make(chan struct{})}
+		g.calls[key] = current
+
+		go g.run(key, current, load)
+	}
+	g.mu.Unlock()
+
+	select {
+	case <-current.done:
+		return current.value, current.err, shared
+	case <-ctx.Done():
+		return entry{}, ctx.Err(), shared
+	}
+}
+
+// run loads the value of the call and wakes up its callers.
+func (g *group) run(key string, current *call, load func() (entry, error)) {
+	defer func() {
+		g.mu.Lock()
+		delete(g.calls, key)
+		g.mu.Unlock()
+		close(current.done)
+	}()
+
+	current.value, current.err = load()
+}
@@ -0,0 +1,58 @@
+package dryrun
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/kbs"
+)
+
+// KBStore skips the writes of dry run requests to a store that only keeps
+// kbs, e.g. the sql store kbs are migrated to. It wraps the encryption of
+// the content, so plans record the kbs as they were written.
+type KBStore struct {
+	kbs.Storer
+	logger *slog.Logger
+}
+
+// NewKBStore creates a dry run store that wraps the given kbs store.
+func NewKBStore(storer kbs.Storer, logger *slog.Logger) *KBStore {
+	newStore := KBStore{
+		Storer: storer,
+		logger: logger,
+	}
+
+	return &newStore
+}
+
+func (s *KBStore) Save(ctx context.Context, newKB kbs.KB) error {
+	if skip(ctx, s.logger, "save_kb", newKB) {
+		return nil
+	}
+
+	return s.Storer.Save(ctx, newKB)
+}
+
+func (s *KBStore) Update(ctx context.Context, kb kbs.UpdateKB) error {
+	if skip(ctx, s.logger, "update_kb", kb) {
+		return nil
+	}
+
+	return s.Storer.Update(ctx, kb)
+}
+
+func (s *KBStore) Delete(ctx context.Context, kb kbs.KB) error {
+	if skip(ctx, s.logger, "delete_kb", kb) {
+		return nil
+	}
+
+	return s.Storer.Delete(ctx, kb)
+}
+
+func (s *KBStore) ChangeState(ctx context.Context, change kbs.StateChange) error {
+	if skip(ctx, s.logger, "change_kb_state", change) {
+		return nil
+	}
+
+	return s.Storer.ChangeState(ctx, change)
+}
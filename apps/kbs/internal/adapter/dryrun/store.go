@@ -0,0 +1,230 @@
+// Package dryrun contains a store that skips the writes of dry run requests.
+package dryrun
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/comments"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/events"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/kbs"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/requests"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/spaces"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/users"
+)
+
+// Storer contains the persistence behavior of every service that changes data.
+type Storer interface {
+	kbs.Storer
+	spaces.Storer
+	events.Storer
+	users.Storer
+	comments.Storer
+}
+
+// Store delegates every call to the wrapped store, except the writes of
+// dry run requests, which are logged and added to the request plan instead.
+type Store struct {
+	Storer
+	logger *slog.Logger
+}
+
+// New creates a dry run store that wraps the given one.
+func New(storer Storer, logger *slog.Logger) *Store {
+	newStore := Store{
+		Storer: storer,
+		logger: logger,
+	}
+
+	return &newStore
+}
+
+// skip returns true if the write must not reach the store, in that case the
+// write is recorded in the plan of the request.
+func (s *Store) skip(ctx context.Context, operation string, data any) bool {
+	return skip(ctx, s.logger, operation, data)
+}
+
+func skip(ctx context.Context, logger *slog.Logger, operation string, data any) bool {
+	metadata := requests.FromContext(ctx)
+	if !metadata.DryRun {
+		return false
+	}
+
+	// the request-scoped logger already carries the request id.
+	requests.Logger(ctx, logger).Info("dry run, write was not stored",
+		slog.Bool("dry_run", true),
+		slog.String("operation", operation))
+
+	metadata.Plan.Add(operation, data)
+
+	return true
+}
+
+func (s *Store) Save(ctx context.Context, newKB kbs.KB) error {
+	if s.skip(ctx, "save_kb", newKB) {
+		return nil
+	}
+
+	return s.Storer.Save(ctx, newKB)
+}
+
+func (s *Store) Update(ctx context.Context, kb kbs.UpdateKB) error {
+	if s.skip(ctx, "update_kb", kb) {
+		return nil
+	}
+
+	return s.Storer.Update(ctx, kb)
+}
+
+func (s *Store) Delete(ctx context.Context, kb kbs.KB) error {
+	if s.skip(ctx, "delete_kb", kb) {
+		return nil
+	}
+
+	return s.Storer.Delete(ctx, kb)
+}
+
+func (s *Store) ChangeState(ctx context.Context, change kbs.StateChange) error {
+	if s.skip(ctx, "change_kb_state", change) {
+		return nil
+	}
+
+	return s.Storer.ChangeState(ctx, change)
+}
+
+func (s *Store) SaveSpace(ctx context.Context, space spaces.Space) error {
+	if s.skip(ctx, "save_space", space) {
+		return nil
+	}
+
+	return s.Storer.SaveSpace(ctx, space)
+}
+
+func (s *Store) UpdateSpace(ctx context.Context, space spaces.Space) error {
+	if s.skip(ctx, "update_space", space) {
+		return nil
+	}
+
+	return s.Storer.UpdateSpace(ctx, space)
+}
+
+func (s *Store) DeleteSpace(ctx context.Context, id spaces.SpaceID) error {
+	if s.skip(ctx, "delete_space", id) {
+		return nil
+	}
+
+	return s.Storer.DeleteSpace(ctx, id)
+}
+
+func (s *Store) SaveCollection(ctx context.Context, collection spaces.Collection) error {
+	if s.skip(ctx, "save_collection", collection) {
+		return nil
+	}
+
+	return s.Storer.SaveCollection(ctx, collection)
+}
+
+func (s *Store) UpdateCollection(ctx context.Context, collection spaces.Collection) error {
+	if s.skip(ctx, "update_collection", collection) {
+		return nil
+	}
+
+	return s.Storer.UpdateCollection(ctx, collection)
+}
+
+func (s *Store) DeleteCollection(ctx context.Context, id spaces.CollectionID) error {
+	if s.skip(ctx, "delete_collection", id) {
+		return nil
+	}
+
+	return s.Storer.DeleteCollection(ctx, id)
+}
+
+func (s *Store) SavePlacement(ctx context.Context, placement spaces.Placement) error {
+	if s.skip(ctx, "save_placement", placement) {
+		return nil
+	}
+
+	return s.Storer.SavePlacement(ctx, placement)
+}
+
+func (s *Store) DeletePlacement(ctx context.Context, kbID kbs.KBID) error {
+	if s.skip(ctx, "delete_placement", kbID) {
+		return nil
+	}
+
+	return s.Storer.DeletePlacement(ctx, kbID)
+}
+
+func (s *Store) SaveEvent(ctx context.Context, event events.Event) error {
+	if s.skip(ctx, "save_event", event) {
+		return nil
+	}
+
+	return s.Storer.SaveEvent(ctx, event)
+}
+
+func (s *Store) UpdateEvent(ctx context.Context, event events.Event) error {
+	if s.skip(ctx, "update_event", event) {
+		return nil
+	}
+
+	return s.Storer.UpdateEvent(ctx, event)
+}
+
+func (s *Store) DeleteEvent(ctx context.Context, id kbs.EventID) error {
+	if s.skip(ctx, "delete_event", id) {
+		return nil
+	}
+
+	return s.Storer.DeleteEvent(ctx, id)
+}
+
+func (s *Store) SaveProfile(ctx context.Context, profile users.Profile) error {
+	if s.skip(ctx, "save_profile", profile) {
+		return nil
+	}
+
+	return s.Storer.SaveProfile(ctx, profile)
+}
+
+func (s *Store) UpdateProfile(ctx context.Context, profile users.Profile) error {
+	if s.skip(ctx, "update_profile", profile) {
+		return nil
+	}
+
+	return s.Storer.UpdateProfile(ctx, profile)
+}
+
+func (s *Store) DeleteProfile(ctx context.Context, id kbs.UserID) error {
+	if s.skip(ctx, "delete_profile", id) {
+		return nil
+	}
+
+	return s.Storer.DeleteProfile(ctx, id)
+}
+
+func (s *Store) SaveComment(ctx context.Context, comment comments.Comment) error {
+	if s.skip(ctx, "save_comment", comment) {
+		return nil
+	}
+
+	return s.Storer.SaveComment(ctx, comment)
+}
+
+func (s *Store) UpdateComment(ctx context.Context, comment comments.Comment) error {
+	if s.skip(ctx, "update_comment", comment) {
+		return nil
+	}
+
+	return s.Storer.UpdateComment(ctx, comment)
+}
+
+func (s *Store) DeleteComment(ctx context.Context, id comments.CommentID) error {
+	if s.skip(ctx, "delete_comment", id) {
+		return nil
+	}
+
+	return s.Storer.DeleteComment(ctx, id)
+}
@@ -0,0 +1,99 @@
+package dryrun_test
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/adapter/dryrun"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/kbs"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/requests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDryRunWritesAreNotStored(t *testing.T) {
+	// Given
+	ctx := requests.NewDryRunContext(context.TODO())
+	storer := &memoryStore{kbs: make(map[kbs.KBID]kbs.KB)}
+	store := dryrun.New(storer, newDummyLogger())
+	kb := kbs.KB{ID: "kb-1", UserID: "drila", CreationDate: 1700000000}
+
+	// When
+	errSave := store.Save(ctx, kb)
+	errDelete := store.Delete(ctx, kb)
+
+	// Then
+	assert.NoError(t, errSave)
+	assert.NoError(t, errDelete)
+	assert.Empty(t, storer.kbs)
+
+	writes := requests.FromContext(ctx).Plan.Writes()
+	require.Len(t, writes, 2)
+	assert.Equal(t, requests.Write{Operation: "save_kb", Data: kb}, writes[0])
+	assert.Equal(t, "delete_kb", writes[1].Operation)
+}
+
+func TestWritesAreStored(t *testing.T) {
+	// Given
+	ctx := context.TODO()
+	storer := &memoryStore{kbs: make(map[kbs.KBID]kbs.KB)}
+	store := dryrun.New(storer, newDummyLogger())
+	kb := kbs.KB{ID: "kb-1", UserID: "drila"}
+
+	// When
+	err := store.Save(ctx, kb)
+
+	// Then
+	assert.NoError(t, err)
+	assert.Equal(t, kb, storer.kbs["kb-1"])
+
+	got, err := store.QueryByID(ctx, "kb-1")
+	assert.NoError(t, err)
+	assert.Equal(t, &kb, got)
+}
+
+func TestDryRunWritesToKBStoresAreNotStored(t *testing.T) {
+	// Given
+	ctx := requests.NewDryRunContext(context.TODO())
+	storer := &memoryStore{kbs: make(map[kbs.KBID]kbs.KB)}
+	store := dryrun.NewKBStore(storer, newDummyLogger())
+	kb := kbs.KB{ID: "kb-1", UserID: "drila", CreationDate: 1700000000}
+
+	// When
+	err := store.Save(ctx, kb)
+
+	// Then
+	assert.NoError(t, err)
+	assert.Empty(t, storer.kbs)
+	assert.Equal(t, []requests.Write{{Operation: "save_kb", Data: kb}}, requests.FromContext(ctx).Plan.Writes())
+}
+
+// memoryStore only implements the kb methods the tests use.
+type memoryStore struct {
+	dryrun.Storer
+	kbs map[kbs.KBID]kbs.KB
+}
+
+func (m *memoryStore) Save(ctx context.Context, newKB kbs.KB) error {
+	m.kbs[newKB.ID] = newKB
+	return nil
+}
+
+func (m *memoryStore) Delete(ctx context.Context, kb kbs.KB) error {
+	delete(m.kbs, kb.ID)
+	return nil
+}
+
+func (m *memoryStore) QueryByID(ctx context.Context, id kbs.KBID) (*kbs.KB, error) {
+	kb, ok := m.kbs[id]
+	if !ok {
+		return nil, nil
+	}
+	return &kb, nil
+}
+
+func newDummyLogger() *slog.Logger {
+	return slog.New(slog.NewJSONHandler(os.Stdout, nil))
+}
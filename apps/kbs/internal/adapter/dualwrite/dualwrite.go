@@ -0,0 +1,114 @@
+// Package dualwrite contains a kbs store that writes to two stores, so kbs
+// can move from one store to another while the service keeps running.
+package dualwrite
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/kbs"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/requests"
+)
+
+// Setup contains the dual write settings.
+type Setup struct {
+	// Primary is written first, its failures fail the write.
+	Primary kbs.Storer
+	// Secondary is written after the primary, its failures are logged so
+	// a new copy of the kbs can fix them.
+	Secondary kbs.Storer
+	// ReadSecondary reads the kbs from the secondary instead of the
+	// primary, it switches reads over before the stores are swapped.
+	ReadSecondary bool
+	Logger        *slog.Logger
+}
+
+// Store writes every kb to the primary and to the secondary store and reads
+// them from one of them.
+type Store struct {
+	primary   kbs.Storer
+	secondary kbs.Storer
+	reader    kbs.Storer
+	logger    *slog.Logger
+}
+
+// New creates a dual write store.
+func New(setup Setup) *Store {
+	newStore := Store{
+		primary:   setup.Primary,
+		secondary: setup.Secondary,
+		reader:    setup.Primary,
+		logger:    setup.Logger,
+	}
+
+	if setup.ReadSecondary {
+		newStore.reader = setup.Secondary
+	}
+
+	return &newStore
+}
+
+func (s *Store) Save(ctx context.Context, newKB kbs.KB) error {
+	err := s.primary.Save(ctx, newKB)
+	if err != nil {
+		return err
+	}
+
+	s.mirror(ctx, "save_kb", newKB.ID, s.secondary.Save(ctx, newKB))
+
+	return nil
+}
+
+func (s *Store) Update(ctx context.Context, kb kbs.UpdateKB) error {
+	err := s.primary.Update(ctx, kb)
+	if err != nil {
+		return err
+	}
+
+	s.mirror(ctx, "update_kb", kb.ID, s.secondary.Update(ctx, kb))
+
+	return nil
+}
+
+func (s *Store) Delete(ctx context.Context, kb kbs.KB) error {
+	err := s.primary.Delete(ctx, kb)
+	if err != nil {
+		return err
+	}
+
+	s.mirror(ctx, "delete_kb", kb.ID, s.secondary.Delete(ctx, kb))
+
+	return nil
+}
+
+func (s *Store) ChangeState(ctx context.Context, change kbs.StateChange) error {
+	err := s.primary.ChangeState(ctx, change)
+	if err != nil {
+		return err
+	}
+
+	s.mirror(ctx, "change_kb_state", change.ID, s.secondary.ChangeState(ctx, change))
+
+	return nil
+}
+
+func (s *Store) Query(ctx context.Context, filter kbs.QueryFilter) (kbs.SearchKBsResult, error) {
+	return s.reader.Query(ctx, filter)
+}
+
+func (s *Store) QueryByID(ctx context.Context, id kbs.KBID) (*kbs.KB, error) {
+	return s.reader.QueryByID(ctx, id)
+}
+
+// mirror logs the write of the secondary store if it failed, the stores
+// differ until the kb is copied again.
+func (s *Store) mirror(ctx context.Context, operation string, id kbs.KBID, err error) {
+	if err == nil {
+		return
+	}
+
+	requests.Logger(ctx, s.logger).Error("unable to write kb to the secondary store",
+		slog.String("operation", operation),
+		slog.String("id", id.String()),
+		slog.String("error", err.Error()))
+}
@@ -0,0 +1,132 @@
+package dualwrite_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/adapter/dualwrite"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/kbs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errUnavailable = errors.New("store is unavailable")
+
+func TestWritesReachBothStores(t *testing.T) {
+	// Given
+	ctx := context.TODO()
+	primary := newMemoryStore()
+	secondary := newMemoryStore()
+	store := dualwrite.New(dualwrite.Setup{Primary: primary, Secondary: secondary, Logger: newDummyLogger()})
+	kb := kbs.KB{ID: "kb-1", UserID: "drila", Content: "runbook", CreationDate: 1700000000}
+
+	// When
+	errSave := store.Save(ctx, kb)
+	errUpdate := store.Update(ctx, kbs.UpdateKB{ID: "kb-1", UserID: "drila", Content: "updated runbook", UpdateDate: 1700000100})
+
+	// Then
+	require.NoError(t, errSave)
+	require.NoError(t, errUpdate)
+	assert.Equal(t, "updated runbook", primary.kbs["kb-1"].Content)
+	assert.Equal(t, primary.kbs, secondary.kbs)
+}
+
+func TestSecondaryFailuresDontFailWrites(t *testing.T) {
+	// Given
+	ctx := context.TODO()
+	primary := newMemoryStore()
+	secondary := newMemoryStore()
+	secondary.err = errUnavailable
+	store := dualwrite.New(dualwrite.Setup{Primary: primary, Secondary: secondary, Logger: newDummyLogger()})
+
+	// When
+	err := store.Save(ctx, kbs.KB{ID: "kb-1"})
+
+	// Then
+	assert.NoError(t, err)
+	assert.Contains(t, primary.kbs, kbs.KBID("kb-1"))
+}
+
+func TestPrimaryFailuresAreNotMirrored(t *testing.T) {
+	// Given
+	ctx := context.TODO()
+	primary := newMemoryStore()
+	primary.err = errUnavailable
+	secondary := newMemoryStore()
+	store := dualwrite.New(dualwrite.Setup{Primary: primary, Secondary: secondary, Logger: newDummyLogger()})
+
+	// When
+	err := store.Save(ctx, kbs.KB{ID: "kb-1"})
+
+	// Then
+	assert.ErrorIs(t, err, errUnavailable)
+	assert.Empty(t, secondary.kbs)
+}
+
+func TestReadsSwitchToTheSecondary(t *testing.T) {
+	// Given
+	ctx := context.TODO()
+	primary := newMemoryStore()
+	primary.kbs["kb-1"] = kbs.KB{ID: "kb-1", Content: "primary"}
+	secondary := newMemoryStore()
+	secondary.kbs["kb-1"] = kbs.KB{ID: "kb-1", Content: "secondary"}
+	store := dualwrite.New(dualwrite.Setup{Primary: primary, Secondary: secondary, ReadSecondary: true, Logger: newDummyLogger()})
+
+	// When
+	got, err := store.QueryByID(ctx, "kb-1")
+
+	// Then
+	require.NoError(t, err)
+	assert.Equal(t, "secondary", got.Content)
+}
+
+// memoryStore only implements the kb methods the tests use, every write
+// fails with err if it is set.
+type memoryStore struct {
+	kbs.Storer
+	kbs map[kbs.KBID]kbs.KB
+	err error
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{kbs: make(map[kbs.KBID]kbs.KB)}
+}
+
+func (m *memoryStore) Save(ctx context.Context, newKB kbs.KB) error {
+	if m.err != nil {
+		return m.err
+	}
+
+	m.kbs[newKB.ID] = newKB
+
+	return nil
+}
+
+func (m *memoryStore) Update(ctx context.Context, kb kbs.UpdateKB) error {
+	if m.err != nil {
+		return m.err
+	}
+
+	current := m.kbs[kb.ID]
+	current.Content = kb.Content
+	current.UpdateDate = kb.UpdateDate
+	m.kbs[kb.ID] = current
+
+	return nil
+}
+
+func (m *memoryStore) QueryByID(ctx context.Context, id kbs.KBID) (*kbs.KB, error) {
+	kb, ok := m.kbs[id]
+	if !ok {
+		return nil, nil
+	}
+
+	return &kb, nil
+}
+
+func newDummyLogger() *slog.Logger {
+	return slog.New(slog.NewJSONHandler(os.Stdout, nil))
+}
@@ -0,0 +1,268 @@
+package dynamodb
+
+import (
+	"context"
+	"errors"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/audit"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/requests"
+)
+
+// The audit log is a single partition ordered by sequence, so the last
+// entry and the ordered chain can be read with a query. Queries by kb or
+// actor read their own index instead of the whole chain.
+const (
+	auditTable        = "audit_log"
+	auditChain        = "kbs"
+	auditByKBIndex    = "kb_id-sequence-index"
+	auditByActorIndex = "actor_id-sequence-index"
+)
+
+// errStopReading stops reading the audit log once a page is full.
+var errStopReading = errors.New("stop reading")
+
+var (
+	errAppendingAuditEntry = errors.New("unable to append audit entry")
+	errGettingAuditEntry   = errors.New("unable to get audit entries")
+)
+
+// AppendEntry stores the entry only if its sequence is not taken yet.
+func (c *Client) AppendEntry(ctx context.Context, entry audit.Entry) error {
+	logger := requests.Logger(ctx, c.logger)
+
+	data, err := attributevalue.MarshalMap(transformAuditEntry(entry))
+	if err != nil {
+		logger.Error("unable to marshal audit entry", "error", err)
+
+		return errAppendingAuditEntry
+	}
+
+	_, err = c.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(c.table(auditTable)),
+		Item:                data,
+		ConditionExpression: aws.String("attribute_not_exists(#sequence)"),
+		ExpressionAttributeNames: map[string]string{
+			"#sequence": "sequence",
+		},
+	})
+
+	var conditionFailed *types.ConditionalCheckFailedException
+	if errors.As(err, &conditionFailed) {
+		return audit.ErrConflict
+	}
+
+	if err != nil {
+		logger.Error("unable to persist audit entry", "error", err)
+
+		return errAppendingAuditEntry
+	}
+
+	return nil
+}
+
+func (c *Client) LastEntry(ctx context.Context) (*audit.Entry, error) {
+	logger := requests.Logger(ctx, c.logger)
+
+	expr, err := expression.NewBuilder().
+		WithKeyCondition(expression.Key("chain").Equal(expression.Value(auditChain))).
+		Build()
+	if err != nil {
+		return nil, errGettingAuditEntry
+	}
+
+	data, err := c.client.Query(ctx, &dynamodb.QueryInput{
+		TableName:                 aws.String(c.table(auditTable)),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		KeyConditionExpression:    expr.KeyCondition(),
+		ScanIndexForward:          aws.Bool(false),
+		Limit:                     aws.Int32(1),
+	})
+	if err != nil {
+		logger.Error("unable to get last audit entry", "error", err)
+
+		return nil, errGettingAuditEntry
+	}
+
+	if len(data.Items) == 0 {
+		return nil, nil
+	}
+
+	var item AuditEntry
+
+	err = attributevalue.UnmarshalMap(data.Items[0], &item)
+	if err != nil {
+		logger.Error("unable to unmarshal audit entry", "error", err)
+
+		return nil, errGettingAuditEntry
+	}
+
+	entry := item.toRepositoryEntry()
+
+	return &entry, nil
+}
+
+func (c *Client) QueryEntries(ctx context.Context, filter audit.QueryFilter) ([]audit.Entry, error) {
+	var result []audit.Entry
+
+	err := c.readAuditLog(ctx, newAuditQuery(filter), func(entry audit.Entry) error {
+		result = append(result, entry)
+
+		if filter.PageSize > 0 && len(result) >= filter.PageSize {
+			return errStopReading
+		}
+
+		return nil
+	})
+	if err != nil && !errors.Is(err, errStopReading) {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (c *Client) ScanEntries(ctx context.Context, fn func(entry audit.Entry) error) error {
+	return c.readAuditLog(ctx, auditQuery{key: "chain", value: auditChain}, fn)
+}
+
+// auditQuery is the partition of the audit log or of one of its indexes
+// that is read, and the condition the entries must meet.
+type auditQuery struct {
+	index     string
+	key       string
+	value     string
+	after     int64
+	pageSize  int
+	condition *expression.ConditionBuilder
+}
+
+// newAuditQuery reads the kb index if the filter has a kb, the actor index
+// if it has an actor, or the whole chain otherwise.
+func newAuditQuery(filter audit.QueryFilter) auditQuery {
+	query := auditQuery{
+		key:      "chain",
+		value:    auditChain,
+		after:    filter.After,
+		pageSize: filter.PageSize,
+	}
+
+	switch {
+	case filter.KBID != "":
+		query.index, query.key, query.value = auditByKBIndex, "kb_id", filter.KBID.String()
+		filter.KBID = ""
+	case filter.ActorID != "":
+		query.index, query.key, query.value = auditByActorIndex, "actor_id", filter.ActorID.String()
+		filter.ActorID = ""
+	}
+
+	query.condition = auditFilter(filter)
+
+	return query
+}
+
+// readAuditLog reads the given partition in sequence order and calls fn for
+// each entry that meets the query condition, if any.
+func (c *Client) readAuditLog(ctx context.Context, query auditQuery, fn func(entry audit.Entry) error) error {
+	logger := requests.Logger(ctx, c.logger)
+
+	keyCondition := expression.Key(query.key).Equal(expression.Value(query.value))
+	if query.after > 0 {
+		keyCondition = keyCondition.And(expression.Key("sequence").GreaterThan(expression.Value(query.after)))
+	}
+
+	builder := expression.NewBuilder().WithKeyCondition(keyCondition)
+
+	if query.condition != nil {
+		builder = builder.WithFilter(*query.condition)
+	}
+
+	expr, err := builder.Build()
+	if err != nil {
+		return errGettingAuditEntry
+	}
+
+	input := dynamodb.QueryInput{
+		TableName:                 aws.String(c.table(auditTable)),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		KeyConditionExpression:    expr.KeyCondition(),
+		FilterExpression:          expr.Filter(),
+		ScanIndexForward:          aws.Bool(true),
+	}
+
+	if query.index != "" {
+		input.IndexName = aws.String(query.index)
+	}
+
+	// without filters a dynamodb page is a page of entries.
+	if query.pageSize > 0 && query.condition == nil {
+		input.Limit = aws.Int32(int32(query.pageSize))
+	}
+
+	paginator := dynamodb.NewQueryPaginator(c.client, &input)
+
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			logger.Error("unable to read audit log", "error", err)
+
+			return errGettingAuditEntry
+		}
+
+		var items []AuditEntry
+
+		err = attributevalue.UnmarshalListOfMaps(page.Items, &items)
+		if err != nil {
+			logger.Error("unable to unmarshal audit entries", "error", err)
+
+			return errGettingAuditEntry
+		}
+
+		for _, item := range items {
+			err = fn(item.toRepositoryEntry())
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// auditFilter builds the filter expression for the given audit filter, nil
+// if there is nothing to filter.
+func auditFilter(filter audit.QueryFilter) *expression.ConditionBuilder {
+	var conditions []expression.ConditionBuilder
+
+	if filter.KBID != "" {
+		conditions = append(conditions, expression.Name("kb_id").Equal(expression.Value(filter.KBID.String())))
+	}
+
+	if filter.ActorID != "" {
+		conditions = append(conditions, expression.Name("actor_id").Equal(expression.Value(filter.ActorID.String())))
+	}
+
+	if filter.From != 0 {
+		conditions = append(conditions, expression.Name("timestamp").GreaterThanEqual(expression.Value(filter.From)))
+	}
+
+	if filter.To != 0 {
+		conditions = append(conditions, expression.Name("timestamp").LessThanEqual(expression.Value(filter.To)))
+	}
+
+	switch len(conditions) {
+	case 0:
+		return nil
+	case 1:
+		return &conditions[0]
+	}
+
+	condition := expression.And(conditions[0], conditions[1], conditions[2:]...)
+
+	return &condition
+}
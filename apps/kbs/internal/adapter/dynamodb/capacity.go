@@ -0,0 +1,96 @@
+package dynamodb
+
+import (
+	"context"
+
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/smithy-go/middleware"
+)
+
+// CapacityRecorder receives the capacity units consumed by every operation.
+type CapacityRecorder interface {
+	RecordConsumedCapacity(table, operation string, units float64)
+}
+
+// consumedCapacity asks dynamodb to return the capacity consumed by every
+// operation and hands it to the recorder.
+func (c *Client) consumedCapacity(stack *middleware.Stack) error {
+	return stack.Initialize.Add(middleware.InitializeMiddlewareFunc("ConsumedCapacity",
+		func(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+			requestConsumedCapacity(in.Parameters)
+
+			out, metadata, err := next.HandleInitialize(ctx, in)
+			if err != nil {
+				return out, metadata, err
+			}
+
+			operation := awsmiddleware.GetOperationName(ctx)
+
+			for _, capacity := range consumedCapacityOf(out.Result) {
+				if capacity.TableName == nil || capacity.CapacityUnits == nil {
+					continue
+				}
+
+				c.capacityRecorder.RecordConsumedCapacity(*capacity.TableName, operation, *capacity.CapacityUnits)
+			}
+
+			return out, metadata, err
+		}), middleware.After)
+}
+
+// requestConsumedCapacity sets the inputs that support it to return the
+// total capacity consumed.
+func requestConsumedCapacity(input any) {
+	total := types.ReturnConsumedCapacityTotal
+
+	switch v := input.(type) {
+	case *dynamodb.PutItemInput:
+		v.ReturnConsumedCapacity = total
+	case *dynamodb.GetItemInput:
+		v.ReturnConsumedCapacity = total
+	case *dynamodb.UpdateItemInput:
+		v.ReturnConsumedCapacity = total
+	case *dynamodb.DeleteItemInput:
+		v.ReturnConsumedCapacity = total
+	case *dynamodb.QueryInput:
+		v.ReturnConsumedCapacity = total
+	case *dynamodb.ScanInput:
+		v.ReturnConsumedCapacity = total
+	case *dynamodb.BatchGetItemInput:
+		v.ReturnConsumedCapacity = total
+	case *dynamodb.BatchWriteItemInput:
+		v.ReturnConsumedCapacity = total
+	}
+}
+
+// consumedCapacityOf returns the capacity reported in the output of an operation.
+func consumedCapacityOf(output any) []types.ConsumedCapacity {
+	var capacity *types.ConsumedCapacity
+
+	switch v := output.(type) {
+	case *dynamodb.PutItemOutput:
+		capacity = v.ConsumedCapacity
+	case *dynamodb.GetItemOutput:
+		capacity = v.ConsumedCapacity
+	case *dynamodb.UpdateItemOutput:
+		capacity = v.ConsumedCapacity
+	case *dynamodb.DeleteItemOutput:
+		capacity = v.ConsumedCapacity
+	case *dynamodb.QueryOutput:
+		capacity = v.ConsumedCapacity
+	case *dynamodb.ScanOutput:
+		capacity = v.ConsumedCapacity
+	case *dynamodb.BatchGetItemOutput:
+		return v.ConsumedCapacity
+	case *dynamodb.BatchWriteItemOutput:
+		return v.ConsumedCapacity
+	}
+
+	if capacity == nil {
+		return nil
+	}
+
+	return []types.ConsumedCapacity{*capacity}
+}
@@ -0,0 +1,156 @@
+package dynamodb
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/comments"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/kbs"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/requests"
+)
+
+const (
+	commentsTable     = "comments"
+	commentsByKBIndex = "kb_id-index"
+)
+
+var (
+	errSavingComment   = errors.New("unable to save comment")
+	errDeletingComment = errors.New("unable to delete comment")
+	errGettingComment  = errors.New("unable to get comment")
+	errCountingComment = errors.New("unable to count comments")
+)
+
+func (c *Client) SaveComment(ctx context.Context, comment comments.Comment) error {
+	logger := requests.Logger(ctx, c.logger)
+
+	err := c.putItem(ctx, commentsTable, transformComment(comment))
+	if err != nil {
+		logger.Error("unable to persist comment", "error", err)
+
+		return errSavingComment
+	}
+
+	return nil
+}
+
+func (c *Client) UpdateComment(ctx context.Context, comment comments.Comment) error {
+	return c.SaveComment(ctx, comment)
+}
+
+func (c *Client) DeleteComment(ctx context.Context, id comments.CommentID) error {
+	logger := requests.Logger(ctx, c.logger)
+
+	err := c.deleteItem(ctx, commentsTable, "id", id.String())
+	if err != nil {
+		logger.Error("unable to delete comment from store", "error", err)
+
+		return errDeletingComment
+	}
+
+	return nil
+}
+
+func (c *Client) QueryCommentByID(ctx context.Context, id comments.CommentID) (*comments.Comment, error) {
+	logger := requests.Logger(ctx, c.logger)
+
+	var item Comment
+
+	found, err := c.getItem(ctx, commentsTable, "id", id.String(), &item)
+	if err != nil {
+		logger.Error("unable to get comment", slog.String("id", id.String()), "error", err)
+
+		return nil, errGettingComment
+	}
+
+	if !found {
+		return nil, nil
+	}
+
+	comment := item.toRepositoryComment()
+
+	return &comment, nil
+}
+
+func (c *Client) QueryComments(ctx context.Context, kbID kbs.KBID) ([]comments.Comment, error) {
+	logger := requests.Logger(ctx, c.logger)
+
+	var items []Comment
+
+	err := c.queryIndex(ctx, commentsTable, commentsByKBIndex, "kb_id", kbID.String(), &items)
+	if err != nil {
+		logger.Error("unable to query comments", slog.String("kb_id", kbID.String()), "error", err)
+
+		return nil, errGettingComment
+	}
+
+	result := make([]comments.Comment, len(items))
+	for i, item := range items {
+		result[i] = item.toRepositoryComment()
+	}
+
+	return result, nil
+}
+
+// CountComments counts the comments of each kb on the kb id index without
+// reading them.
+func (c *Client) CountComments(ctx context.Context, kbIDs []kbs.KBID) (map[kbs.KBID]int, error) {
+	logger := requests.Logger(ctx, c.logger)
+
+	result := make(map[kbs.KBID]int, len(kbIDs))
+
+	for _, kbID := range kbIDs {
+		if _, ok := result[kbID]; ok {
+			continue
+		}
+
+		count, err := c.countIndex(ctx, commentsTable, commentsByKBIndex, "kb_id", kbID.String())
+		if err != nil {
+			logger.Error("unable to count comments", slog.String("kb_id", kbID.String()), "error", err)
+
+			return nil, errCountingComment
+		}
+
+		result[kbID] = count
+	}
+
+	return result, nil
+}
+
+// countIndex returns the number of items of the index whose partition key
+// matches the given value.
+func (c *Client) countIndex(ctx context.Context, table, index, fieldKey, value string) (int, error) {
+	keyEx := expression.Key(fieldKey).Equal(expression.Value(value))
+
+	expr, err := expression.NewBuilder().WithKeyCondition(keyEx).Build()
+	if err != nil {
+		return 0, err
+	}
+
+	paginator := dynamodb.NewQueryPaginator(c.client, &dynamodb.QueryInput{
+		TableName:                 aws.String(c.table(table)),
+		IndexName:                 aws.String(index),
+		Select:                    types.SelectCount,
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		KeyConditionExpression:    expr.KeyCondition(),
+	})
+
+	var count int
+
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return 0, err
+		}
+
+		count += int(page.Count)
+	}
+
+	return count, nil
+}
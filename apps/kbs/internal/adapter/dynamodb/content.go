@@ -0,0 +1,221 @@
+package dynamodb
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"unicode/utf8"
+
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/requests"
+)
+
+// gzipEncoding is the encoding of compressed content.
+const gzipEncoding = "gzip"
+
+// defaultExcerptLength is the number of characters of the excerpt of
+// offloaded content if none was configured.
+const defaultExcerptLength = 280
+
+var (
+	errUnknownContentEncoding = errors.New("unknown content encoding")
+	errMissingContent         = errors.New("kb content is missing")
+)
+
+// BlobStore keeps the content of the kbs too large for a dynamodb item,
+// which can't be larger than 400KB.
+type BlobStore interface {
+	PutBlob(ctx context.Context, key string, data []byte) error
+	// GetBlob returns an error if the blob does not exist.
+	GetBlob(ctx context.Context, key string) ([]byte, error)
+	// DeleteBlob does nothing if the blob does not exist.
+	DeleteBlob(ctx context.Context, key string) error
+}
+
+// ContentSetup defines how the content of large kbs is stored.
+type ContentSetup struct {
+	// CompressAbove is the size in bytes above which content is
+	// compressed, zero disables compression.
+	CompressAbove int
+	// OffloadAbove is the size in bytes, once compressed, above which
+	// content is moved to Blobs. Zero disables offloading.
+	OffloadAbove int
+	// ExcerptLength is the number of characters of the content kept in
+	// the item when it is offloaded, queries return them instead of the
+	// content.
+	ExcerptLength int
+	// Blobs keeps the offloaded content, offloading is disabled if nil.
+	Blobs BlobStore
+}
+
+// writeContent compresses the content of the item if it is large and moves
+// it to the blob store if it is still too large to be kept in the item.
+func (c *Client) writeContent(ctx context.Context, item *KB) error {
+	plain := []byte(item.Content)
+	stored := plain
+
+	if c.content.CompressAbove > 0 && len(plain) > c.content.CompressAbove {
+		compressed, err := compress(plain)
+		if err != nil {
+			return err
+		}
+
+		// content that doesn't shrink, e.g. encrypted, is kept as it is.
+		if len(compressed) < len(plain) {
+			item.ContentEncoding = gzipEncoding
+			stored = compressed
+		}
+	}
+
+	if c.content.Blobs == nil || c.content.OffloadAbove <= 0 || len(stored) <= c.content.OffloadAbove {
+		if item.ContentEncoding != "" {
+			item.Content = ""
+			item.CompressedContent = stored
+		}
+
+		return nil
+	}
+
+	ref, err := newContentRef(item.ID)
+	if err != nil {
+		return err
+	}
+
+	err = c.content.Blobs.PutBlob(ctx, ref, stored)
+	if err != nil {
+		return err
+	}
+
+	item.Content = ""
+	item.ContentRef = ref
+	item.Excerpt = excerpt(plain, c.excerptLength())
+
+	return nil
+}
+
+// readContent puts the plain content back into the item. Offloaded content
+// is only read from the blob store if full is true, otherwise the content
+// is the excerpt and true is returned.
+func (c *Client) readContent(ctx context.Context, item *KB, full bool) (bool, error) {
+	stored := item.CompressedContent
+
+	if item.ContentRef != "" {
+		if !full {
+			item.Content = item.Excerpt
+
+			return true, nil
+		}
+
+		if c.content.Blobs == nil {
+			return false, fmt.Errorf("%w: there is no blob store for %q", errMissingContent, item.ContentRef)
+		}
+
+		blob, err := c.content.Blobs.GetBlob(ctx, item.ContentRef)
+		if err != nil {
+			return false, err
+		}
+
+		stored = blob
+	}
+
+	switch item.ContentEncoding {
+	case "":
+		if item.ContentRef != "" {
+			item.Content = string(stored)
+		}
+	case gzipEncoding:
+		plain, err := decompress(stored)
+		if err != nil {
+			return false, err
+		}
+
+		item.Content = string(plain)
+	default:
+		return false, fmt.Errorf("%w: %q", errUnknownContentEncoding, item.ContentEncoding)
+	}
+
+	return false, nil
+}
+
+// dropContent deletes the blob that is not referenced by the kb anymore.
+// The kb was already written, so failures are only logged.
+func (c *Client) dropContent(ctx context.Context, oldRef, newRef string) {
+	if oldRef == "" || oldRef == newRef || c.content.Blobs == nil {
+		return
+	}
+
+	err := c.content.Blobs.DeleteBlob(ctx, oldRef)
+	if err != nil {
+		requests.Logger(ctx, c.logger).Warn("unable to delete kb content blob",
+			slog.String("content_ref", oldRef),
+			slog.String("error", err.Error()))
+	}
+}
+
+func (c *Client) excerptLength() int {
+	if c.content.ExcerptLength > 0 {
+		return c.content.ExcerptLength
+	}
+
+	return defaultExcerptLength
+}
+
+// newContentRef returns a new blob key for the content of the kb. Each
+// write uses its own key, so the blob of the current content is never
+// replaced before the item points to the new one.
+func newContentRef(id string) (string, error) {
+	suffix := make([]byte, 8)
+
+	_, err := io.ReadFull(rand.Reader, suffix)
+	if err != nil {
+		return "", err
+	}
+
+	return "kbs/" + id + "/" + hex.EncodeToString(suffix), nil
+}
+
+// excerpt returns the first characters of the content.
+func excerpt(content []byte, length int) string {
+	end := 0
+
+	for i := 0; i < length && end < len(content); i++ {
+		_, size := utf8.DecodeRune(content[end:])
+		end += size
+	}
+
+	return string(content[:end])
+}
+
+func compress(content []byte) ([]byte, error) {
+	var buf bytes.Buffer
+
+	writer := gzip.NewWriter(&buf)
+
+	_, err := writer.Write(content)
+	if err != nil {
+		return nil, err
+	}
+
+	err = writer.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func decompress(content []byte) ([]byte, error) {
+	reader, err := gzip.NewReader(bytes.NewReader(content))
+	if err != nil {
+		return nil, err
+	}
+
+	defer reader.Close()
+
+	return io.ReadAll(reader)
+}
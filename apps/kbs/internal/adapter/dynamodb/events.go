@@ -0,0 +1,125 @@
+package dynamodb
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/events"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/kbs"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/requests"
+)
+
+const eventsTable = "events"
+
+var (
+	errSavingEvent   = errors.New("unable to save event")
+	errDeletingEvent = errors.New("unable to delete event")
+	errGettingEvent  = errors.New("unable to get event")
+)
+
+func (c *Client) SaveEvent(ctx context.Context, event events.Event) error {
+	logger := requests.Logger(ctx, c.logger)
+
+	err := c.putItem(ctx, eventsTable, transformEvent(event))
+	if err != nil {
+		logger.Error("unable to persist event", "error", err)
+
+		return errSavingEvent
+	}
+
+	return nil
+}
+
+func (c *Client) UpdateEvent(ctx context.Context, event events.Event) error {
+	return c.SaveEvent(ctx, event)
+}
+
+func (c *Client) DeleteEvent(ctx context.Context, id kbs.EventID) error {
+	logger := requests.Logger(ctx, c.logger)
+
+	err := c.deleteItem(ctx, eventsTable, "id", id.String())
+	if err != nil {
+		logger.Error("unable to delete event from store", "error", err)
+
+		return errDeletingEvent
+	}
+
+	return nil
+}
+
+func (c *Client) QueryEventByID(ctx context.Context, id kbs.EventID) (*events.Event, error) {
+	logger := requests.Logger(ctx, c.logger)
+
+	var item Event
+
+	found, err := c.getItem(ctx, eventsTable, "id", id.String(), &item)
+	if err != nil {
+		logger.Error("unable to get event", slog.String("id", id.String()), "error", err)
+
+		return nil, errGettingEvent
+	}
+
+	if !found {
+		return nil, nil
+	}
+
+	event := item.toRepositoryEvent()
+
+	return &event, nil
+}
+
+func (c *Client) QueryEvents(ctx context.Context, filter events.QueryFilter) ([]events.Event, error) {
+	logger := requests.Logger(ctx, c.logger)
+
+	scanInput := dynamodb.ScanInput{
+		TableName: aws.String(c.table(eventsTable)),
+	}
+
+	if filter.Status != events.EmptyStatus {
+		filterEx := expression.Name("status").Equal(expression.Value(filter.Status.String()))
+
+		expr, err := expression.NewBuilder().WithFilter(filterEx).Build()
+		if err != nil {
+			return nil, errGettingEvent
+		}
+
+		scanInput.ExpressionAttributeNames = expr.Names()
+		scanInput.ExpressionAttributeValues = expr.Values()
+		scanInput.FilterExpression = expr.Filter()
+	}
+
+	var items []Event
+
+	paginator := dynamodb.NewScanPaginator(c.client, &scanInput)
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			logger.Error("unable to scan events", "error", err)
+
+			return nil, errGettingEvent
+		}
+
+		var pageItems []Event
+
+		err = attributevalue.UnmarshalListOfMaps(page.Items, &pageItems)
+		if err != nil {
+			logger.Error("unable to unmarshal events", "error", err)
+
+			return nil, errGettingEvent
+		}
+
+		items = append(items, pageItems...)
+	}
+
+	result := make([]events.Event, len(items))
+	for i, item := range items {
+		result[i] = item.toRepositoryEvent()
+	}
+
+	return result, nil
+}
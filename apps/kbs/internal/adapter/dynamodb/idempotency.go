@@ -0,0 +1,178 @@
+package dynamodb
+
+import (
+	"context"
+	"errors"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/idempotency"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/requests"
+)
+
+// idempotencyTable removes the expired records with its time to live, but
+// the removal is not immediate so expiration is also checked on writes.
+const idempotencyTable = "idempotency_keys"
+
+var (
+	errSavingIdempotencyRecord   = errors.New("unable to save idempotency record")
+	errGettingIdempotencyRecord  = errors.New("unable to get idempotency record")
+	errDeletingIdempotencyRecord = errors.New("unable to delete idempotency record")
+)
+
+// CreateRecord stores the record if there is no record with the same id,
+// or the existing one expired or was abandoned while in progress.
+func (c *Client) CreateRecord(ctx context.Context, record idempotency.Record, now int64) error {
+	logger := requests.Logger(ctx, c.logger)
+
+	data, err := attributevalue.MarshalMap(transformIdempotencyRecord(record))
+	if err != nil {
+		logger.Error("unable to marshal idempotency record", "error", err)
+
+		return errSavingIdempotencyRecord
+	}
+
+	abandoned := expression.Name("state").Equal(expression.Value(string(idempotency.InProgress))).
+		And(expression.Name("locked_until").LessThanEqual(expression.Value(now)))
+
+	condition := expression.AttributeNotExists(expression.Name("id")).
+		Or(expression.Name("expires_at").LessThanEqual(expression.Value(now)), abandoned)
+
+	expr, err := expression.NewBuilder().WithCondition(condition).Build()
+	if err != nil {
+		logger.Error("unable to build idempotency record condition", "error", err)
+
+		return errSavingIdempotencyRecord
+	}
+
+	_, err = c.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:                 aws.String(c.table(idempotencyTable)),
+		Item:                      data,
+		ConditionExpression:       expr.Condition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+	})
+
+	var conditionFailed *types.ConditionalCheckFailedException
+	if errors.As(err, &conditionFailed) {
+		return idempotency.ErrConflict
+	}
+
+	if err != nil {
+		logger.Error("unable to persist idempotency record", "error", err)
+
+		return errSavingIdempotencyRecord
+	}
+
+	return nil
+}
+
+func (c *Client) QueryRecord(ctx context.Context, id string) (*idempotency.Record, error) {
+	logger := requests.Logger(ctx, c.logger)
+
+	var item IdempotencyRecord
+
+	found, err := c.getItem(ctx, idempotencyTable, "id", id, &item)
+	if err != nil {
+		logger.Error("unable to get idempotency record", "error", err)
+
+		return nil, errGettingIdempotencyRecord
+	}
+
+	if !found {
+		return nil, nil
+	}
+
+	record := item.toRepositoryRecord()
+
+	return &record, nil
+}
+
+// UpdateRecord replaces the record only if it is still in progress and held
+// by the given token.
+func (c *Client) UpdateRecord(ctx context.Context, record idempotency.Record, token string) error {
+	logger := requests.Logger(ctx, c.logger)
+
+	data, err := attributevalue.MarshalMap(transformIdempotencyRecord(record))
+	if err != nil {
+		logger.Error("unable to marshal idempotency record", "error", err)
+
+		return errSavingIdempotencyRecord
+	}
+
+	expr, err := expression.NewBuilder().WithCondition(heldBy(token)).Build()
+	if err != nil {
+		logger.Error("unable to build idempotency record condition", "error", err)
+
+		return errSavingIdempotencyRecord
+	}
+
+	_, err = c.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:                 aws.String(c.table(idempotencyTable)),
+		Item:                      data,
+		ConditionExpression:       expr.Condition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+	})
+
+	var conditionFailed *types.ConditionalCheckFailedException
+	if errors.As(err, &conditionFailed) {
+		return idempotency.ErrLockLost
+	}
+
+	if err != nil {
+		logger.Error("unable to persist idempotency record", "error", err)
+
+		return errSavingIdempotencyRecord
+	}
+
+	return nil
+}
+
+// DeleteRecord removes the record only if it is still in progress and held
+// by the given token.
+func (c *Client) DeleteRecord(ctx context.Context, id, token string) error {
+	logger := requests.Logger(ctx, c.logger)
+
+	key, err := c.buildTableKey("id", id)
+	if err != nil {
+		return errDeletingIdempotencyRecord
+	}
+
+	expr, err := expression.NewBuilder().WithCondition(heldBy(token)).Build()
+	if err != nil {
+		logger.Error("unable to build idempotency record condition", "error", err)
+
+		return errDeletingIdempotencyRecord
+	}
+
+	_, err = c.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName:                 aws.String(c.table(idempotencyTable)),
+		Key:                       key,
+		ConditionExpression:       expr.Condition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+	})
+
+	var conditionFailed *types.ConditionalCheckFailedException
+	if errors.As(err, &conditionFailed) {
+		return idempotency.ErrLockLost
+	}
+
+	if err != nil {
+		logger.Error("unable to delete idempotency record", "error", err)
+
+		return errDeletingIdempotencyRecord
+	}
+
+	return nil
+}
+
+// heldBy is the condition of a record in progress taken with the given token.
+func heldBy(token string) expression.ConditionBuilder {
+	return expression.Name("token").Equal(expression.Value(token)).
+		And(expression.Name("state").Equal(expression.Value(string(idempotency.InProgress))))
+}
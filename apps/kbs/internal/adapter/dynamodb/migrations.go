@@ -0,0 +1,463 @@
+package dynamodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/requests"
+)
+
+// migrationsTable records the schema versions applied to the tables.
+const migrationsTable = "schema_migrations"
+
+// defaults of the schema management.
+const (
+	defaultSchemaTimeout = 5 * time.Minute
+	schemaPollInterval   = 2 * time.Second
+)
+
+// migration brings the given tables to their declared schema. Migrations
+// are applied once, in version order, and new versions are appended when
+// the declared schema changes.
+type migration struct {
+	version     int
+	description string
+	tables      []string
+}
+
+var migrations = []migration{
+	{
+		version:     1,
+		description: "create the tables, indexes and time to live of the service",
+		tables: []string{
+			kbsTable, spacesTable, collectionsTable, placementsTable, eventsTable,
+			usersTable, commentsTable, auditTable, idempotencyTable,
+		},
+	},
+	{
+		version:     2,
+		description: "index kbs by user and event ordered by creation and update date",
+		tables:      []string{kbsTable},
+	},
+	{
+		version:     3,
+		description: "index the audit log by kb and by actor ordered by sequence",
+		tables:      []string{auditTable},
+	},
+}
+
+// Migration is a schema version applied to the tables.
+type Migration struct {
+	Version     int    `dynamodbav:"version"`
+	Description string `dynamodbav:"description"`
+	AppliedAt   int64  `dynamodbav:"applied_at"`
+}
+
+// MigrationReport contains the migrations applied by a run.
+type MigrationReport struct {
+	Applied []Migration
+	// Version is the latest schema version applied to the tables.
+	Version int
+}
+
+// schemaChange is a change that must be made for a table to match its schema.
+type schemaChange struct {
+	operation string
+	table     string
+	apply     func(ctx context.Context) error
+}
+
+var (
+	errMigratingSchema = errors.New("unable to migrate schema")
+	errUnknownTable    = errors.New("table is not declared in the schema")
+	errSchemaTimeout   = errors.New("table did not become active in time")
+)
+
+// Migrate applies the pending migrations. Tables and indexes are created
+// if they are missing and existing ones are updated, so it can run again
+// safely, even from several instances at the same time. In dry run the
+// changes are only added to the plan of the context.
+func (c *Client) Migrate(ctx context.Context) (MigrationReport, error) {
+	logger := requests.Logger(ctx, c.logger)
+
+	var report MigrationReport
+
+	err := c.reconcile(ctx, migrationsSchema)
+	if err != nil {
+		return report, err
+	}
+
+	applied, err := c.appliedMigrations(ctx)
+	if err != nil {
+		logger.Error("unable to read applied migrations", "error", err)
+
+		return report, errMigratingSchema
+	}
+
+	for _, done := range applied {
+		report.Version = max(report.Version, done.Version)
+	}
+
+	for _, pending := range migrations {
+		if slices.ContainsFunc(applied, func(done Migration) bool { return done.Version == pending.version }) {
+			continue
+		}
+
+		logger.Info("applying schema migration", slog.Int("version", pending.version), slog.String("description", pending.description))
+
+		for _, table := range pending.tables {
+			declared, ok := declaredTable(table)
+			if !ok {
+				logger.Error("unable to apply schema migration", slog.String("table", table), "error", errUnknownTable)
+
+				return report, errMigratingSchema
+			}
+
+			err := c.reconcile(ctx, declared)
+			if err != nil {
+				return report, err
+			}
+		}
+
+		done := Migration{
+			Version:     pending.version,
+			Description: pending.description,
+			AppliedAt:   time.Now().UTC().Unix(),
+		}
+
+		err := c.recordMigration(ctx, done)
+		if err != nil {
+			return report, err
+		}
+
+		report.Applied = append(report.Applied, done)
+		report.Version = max(report.Version, done.Version)
+	}
+
+	return report, nil
+}
+
+// reconcile makes the changes the table needs to match its schema.
+func (c *Client) reconcile(ctx context.Context, table tableSchema) error {
+	logger := requests.Logger(ctx, c.logger)
+
+	table.name = c.table(table.name)
+
+	changes, err := c.schemaChanges(ctx, table)
+	if err != nil {
+		logger.Error("unable to compare table with its schema", slog.String("table", table.name), "error", err)
+
+		return errMigratingSchema
+	}
+
+	metadata := requests.FromContext(ctx)
+
+	for _, change := range changes {
+		if metadata.DryRun {
+			metadata.Plan.Add(change.operation, change.table)
+
+			continue
+		}
+
+		logger.Info("changing table schema", slog.String("table", change.table), slog.String("operation", change.operation))
+
+		err := change.apply(ctx)
+		if err != nil {
+			logger.Error("unable to change table schema",
+				slog.String("table", change.table),
+				slog.String("operation", change.operation),
+				"error", err)
+
+			return errMigratingSchema
+		}
+	}
+
+	return nil
+}
+
+// schemaChanges compares the table with its schema and returns the changes
+// it needs, the whole table is created if it does not exist.
+func (c *Client) schemaChanges(ctx context.Context, table tableSchema) ([]schemaChange, error) {
+	output, err := c.client.DescribeTable(ctx, &dynamodb.DescribeTableInput{
+		TableName: aws.String(table.name),
+	})
+
+	var notFound *types.ResourceNotFoundException
+	if errors.As(err, &notFound) {
+		changes := []schemaChange{{operation: "create_table", table: table.name, apply: func(ctx context.Context) error {
+			return c.createTable(ctx, table)
+		}}}
+
+		return append(changes, c.ttlChange(table)...), nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []schemaChange
+
+	current := output.Table
+
+	if billingModeOf(current) != c.capacity.billingMode() {
+		changes = append(changes, schemaChange{operation: "update_billing_mode", table: table.name, apply: func(ctx context.Context) error {
+			return c.updateBillingMode(ctx, table, current)
+		}})
+	}
+
+	for _, index := range table.indexes {
+		exists := slices.ContainsFunc(current.GlobalSecondaryIndexes, func(existing types.GlobalSecondaryIndexDescription) bool {
+			return aws.ToString(existing.IndexName) == index.name
+		})
+		if exists {
+			continue
+		}
+
+		index := index
+		changes = append(changes, schemaChange{operation: "create_index", table: table.name + "." + index.name, apply: func(ctx context.Context) error {
+			return c.createIndex(ctx, table, index)
+		}})
+	}
+
+	if table.ttlAttribute == "" {
+		return changes, nil
+	}
+
+	ttl, err := c.client.DescribeTimeToLive(ctx, &dynamodb.DescribeTimeToLiveInput{
+		TableName: aws.String(table.name),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	description := ttl.TimeToLiveDescription
+	if description != nil && aws.ToString(description.AttributeName) == table.ttlAttribute &&
+		(description.TimeToLiveStatus == types.TimeToLiveStatusEnabled || description.TimeToLiveStatus == types.TimeToLiveStatusEnabling) {
+		return changes, nil
+	}
+
+	return append(changes, c.ttlChange(table)...), nil
+}
+
+func (c *Client) ttlChange(table tableSchema) []schemaChange {
+	if table.ttlAttribute == "" {
+		return nil
+	}
+
+	return []schemaChange{{operation: "enable_time_to_live", table: table.name, apply: func(ctx context.Context) error {
+		_, err := c.client.UpdateTimeToLive(ctx, &dynamodb.UpdateTimeToLiveInput{
+			TableName: aws.String(table.name),
+			TimeToLiveSpecification: &types.TimeToLiveSpecification{
+				AttributeName: aws.String(table.ttlAttribute),
+				Enabled:       aws.Bool(true),
+			},
+		})
+
+		return err
+	}}}
+}
+
+func (c *Client) createTable(ctx context.Context, table tableSchema) error {
+	_, err := c.client.CreateTable(ctx, table.createInput(c.capacity))
+
+	// another instance is creating the table.
+	var inUse *types.ResourceInUseException
+	if err != nil && !errors.As(err, &inUse) {
+		return err
+	}
+
+	return c.waitUntilActive(ctx, table.name)
+}
+
+// createIndex adds the index to the table, dynamodb only accepts one new
+// index per update.
+func (c *Client) createIndex(ctx context.Context, table tableSchema, index indexSchema) error {
+	_, err := c.client.UpdateTable(ctx, &dynamodb.UpdateTableInput{
+		TableName:                   aws.String(table.name),
+		AttributeDefinitions:        table.attributeDefinitions(),
+		GlobalSecondaryIndexUpdates: []types.GlobalSecondaryIndexUpdate{index.createAction(c.capacity)},
+	})
+
+	var inUse *types.ResourceInUseException
+	if err != nil && !errors.As(err, &inUse) {
+		return err
+	}
+
+	return c.waitUntilActive(ctx, table.name)
+}
+
+func (c *Client) updateBillingMode(ctx context.Context, table tableSchema, current *types.TableDescription) error {
+	input := dynamodb.UpdateTableInput{
+		TableName:   aws.String(table.name),
+		BillingMode: c.capacity.billingMode(),
+	}
+
+	// provisioned tables need the throughput of the table and its indexes.
+	if c.capacity.provisioned() {
+		input.ProvisionedThroughput = c.capacity.throughput()
+
+		for _, index := range current.GlobalSecondaryIndexes {
+			input.GlobalSecondaryIndexUpdates = append(input.GlobalSecondaryIndexUpdates, types.GlobalSecondaryIndexUpdate{
+				Update: &types.UpdateGlobalSecondaryIndexAction{
+					IndexName:             index.IndexName,
+					ProvisionedThroughput: c.capacity.throughput(),
+				},
+			})
+		}
+	}
+
+	_, err := c.client.UpdateTable(ctx, &input)
+	if err != nil {
+		return err
+	}
+
+	return c.waitUntilActive(ctx, table.name)
+}
+
+// waitUntilActive waits for the table and all its indexes to be active.
+func (c *Client) waitUntilActive(ctx context.Context, table string) error {
+	ctx, cancel := context.WithTimeout(ctx, c.schemaTimeout)
+	defer cancel()
+
+	ticker := time.NewTicker(schemaPollInterval)
+	defer ticker.Stop()
+
+	for {
+		output, err := c.client.DescribeTable(ctx, &dynamodb.DescribeTableInput{
+			TableName: aws.String(table),
+		})
+		if err != nil && ctx.Err() == nil {
+			return err
+		}
+
+		if err == nil && tableActive(output.Table) {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w: %s", errSchemaTimeout, table)
+		case <-ticker.C:
+		}
+	}
+}
+
+// billingModeOf returns the billing mode of the table, tables that never
+// changed it don't report it and are provisioned.
+func billingModeOf(table *types.TableDescription) types.BillingMode {
+	if table.BillingModeSummary == nil || table.BillingModeSummary.BillingMode == "" {
+		return types.BillingModeProvisioned
+	}
+
+	return table.BillingModeSummary.BillingMode
+}
+
+func tableActive(table *types.TableDescription) bool {
+	if table.TableStatus != types.TableStatusActive {
+		return false
+	}
+
+	for _, index := range table.GlobalSecondaryIndexes {
+		if index.IndexStatus != types.IndexStatusActive {
+			return false
+		}
+	}
+
+	return true
+}
+
+// appliedMigrations returns the migrations recorded in the migrations
+// table, none if it does not exist yet.
+func (c *Client) appliedMigrations(ctx context.Context) ([]Migration, error) {
+	paginator := dynamodb.NewScanPaginator(c.client, &dynamodb.ScanInput{
+		TableName: aws.String(c.table(migrationsTable)),
+	})
+
+	var applied []Migration
+
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+
+		var notFound *types.ResourceNotFoundException
+		if errors.As(err, &notFound) {
+			return nil, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		var items []Migration
+
+		err = attributevalue.UnmarshalListOfMaps(page.Items, &items)
+		if err != nil {
+			return nil, err
+		}
+
+		applied = append(applied, items...)
+	}
+
+	return applied, nil
+}
+
+// recordMigration stores the migration unless another instance did it first.
+func (c *Client) recordMigration(ctx context.Context, done Migration) error {
+	logger := requests.Logger(ctx, c.logger)
+
+	if metadata := requests.FromContext(ctx); metadata.DryRun {
+		metadata.Plan.Add("record_migration", done)
+
+		return nil
+	}
+
+	data, err := attributevalue.MarshalMap(done)
+	if err != nil {
+		logger.Error("unable to marshal migration", "error", err)
+
+		return errMigratingSchema
+	}
+
+	expr, err := expression.NewBuilder().
+		WithCondition(expression.AttributeNotExists(expression.Name("version"))).
+		Build()
+	if err != nil {
+		logger.Error("unable to build migration condition", "error", err)
+
+		return errMigratingSchema
+	}
+
+	_, err = c.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:                aws.String(c.table(migrationsTable)),
+		Item:                     data,
+		ConditionExpression:      expr.Condition(),
+		ExpressionAttributeNames: expr.Names(),
+	})
+
+	var conditionFailed *types.ConditionalCheckFailedException
+	if err != nil && !errors.As(err, &conditionFailed) {
+		logger.Error("unable to record migration", slog.Int("version", done.Version), "error", err)
+
+		return errMigratingSchema
+	}
+
+	return nil
+}
+
+func declaredTable(name string) (tableSchema, bool) {
+	for _, table := range schema {
+		if table.name == name {
+			return table, true
+		}
+	}
+
+	return tableSchema{}, false
+}
@@ -1,20 +1,49 @@
 package dynamodb
 
-import "github.com/fernandoocampo/kb-store/apps/kbs/internal/kbs"
+import (
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/audit"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/comments"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/events"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/idempotency"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/kbs"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/spaces"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/users"
+)
 
 type KB struct {
-	ID           string `json:"id" dynamodbav:"id"`
-	UserID       string `json:"user_id" dynamodbav:"user_id"`
-	UserName     string `json:"username" dynamodbav:"username"`
-	Content      string `json:"content" dynamodbav:"content"`
-	EventID      string `json:"event_id" dynamodbav:"event_id"`
-	CreationDate int64  `json:"creation_date" dynamodbav:"creation_date"`
-	UpdateDate   int64  `json:"update_date" dynamodbav:"update_date"`
+	ID           string   `json:"id" dynamodbav:"id"`
+	UserID       string   `json:"user_id" dynamodbav:"user_id"`
+	UserName     string   `json:"username" dynamodbav:"username"`
+	Content      string   `json:"content" dynamodbav:"content"`
+	EventID      string   `json:"event_id" dynamodbav:"event_id"`
+	CreationDate int64    `json:"creation_date" dynamodbav:"creation_date"`
+	UpdateDate   int64    `json:"update_date" dynamodbav:"update_date"`
+	State        string   `json:"state" dynamodbav:"state,omitempty"`
+	ReviewerID   string   `json:"reviewer_id" dynamodbav:"reviewer_id,omitempty"`
+	Reviews      []Review `json:"reviews" dynamodbav:"reviews,omitempty"`
+	// ContentEncoding is gzip if the content was compressed, compressed
+	// content is kept in CompressedContent or in the blob ContentRef.
+	ContentEncoding   string `json:"content_encoding" dynamodbav:"content_encoding,omitempty"`
+	CompressedContent []byte `json:"compressed_content" dynamodbav:"compressed_content,omitempty"`
+	ContentRef        string `json:"content_ref" dynamodbav:"content_ref,omitempty"`
+	// Excerpt is the beginning of the content of offloaded kbs.
+	Excerpt string `json:"excerpt" dynamodbav:"excerpt,omitempty"`
 }
 
+type Review struct {
+	ReviewerID string `json:"reviewer_id" dynamodbav:"reviewer_id"`
+	Decision   string `json:"decision" dynamodbav:"decision"`
+	Comment    string `json:"comment" dynamodbav:"comment"`
+	Date       int64  `json:"date" dynamodbav:"date"`
+}
+
+// legacyKBState is the state of kbs stored before the review workflow
+// existed, they were visible to everyone.
+const legacyKBState = kbs.Published
+
 // transformKB transforms new kb to a repository kb.
 func (u KB) toRepositoryKB() kbs.KB {
-	return kbs.KB{
+	kb := kbs.KB{
 		ID:           kbs.KBID(u.ID),
 		UserID:       kbs.UserID(u.UserID),
 		UserName:     u.UserName,
@@ -22,12 +51,24 @@ func (u KB) toRepositoryKB() kbs.KB {
 		EventID:      kbs.EventID(u.EventID),
 		CreationDate: u.CreationDate,
 		UpdateDate:   u.UpdateDate,
+		State:        kbs.State(u.State),
+		ReviewerID:   kbs.UserID(u.ReviewerID),
 	}
+
+	if kb.State == kbs.EmptyState {
+		kb.State = legacyKBState
+	}
+
+	for _, v := range u.Reviews {
+		kb.Reviews = append(kb.Reviews, v.toRepositoryReview())
+	}
+
+	return kb
 }
 
 // transformKB transforms new kb to a kb.
 func transformKB(kb kbs.KB) KB {
-	return KB{
+	newKB := KB{
 		ID:           kb.ID.String(),
 		UserID:       kb.UserID.String(),
 		UserName:     kb.UserName,
@@ -35,5 +76,365 @@ func transformKB(kb kbs.KB) KB {
 		EventID:      kb.EventID.String(),
 		CreationDate: kb.CreationDate,
 		UpdateDate:   kb.UpdateDate,
+		State:        kb.State.String(),
+		ReviewerID:   kb.ReviewerID.String(),
+	}
+
+	for _, v := range kb.Reviews {
+		newKB.Reviews = append(newKB.Reviews, transformReview(v))
+	}
+
+	return newKB
+}
+
+func (r Review) toRepositoryReview() kbs.Review {
+	return kbs.Review{
+		ReviewerID: kbs.UserID(r.ReviewerID),
+		Decision:   kbs.Decision(r.Decision),
+		Comment:    r.Comment,
+		Date:       r.Date,
+	}
+}
+
+func transformReview(review kbs.Review) Review {
+	return Review{
+		ReviewerID: review.ReviewerID.String(),
+		Decision:   string(review.Decision),
+		Comment:    review.Comment,
+		Date:       review.Date,
+	}
+}
+
+type Space struct {
+	ID           string `json:"id" dynamodbav:"id"`
+	Name         string `json:"name" dynamodbav:"name"`
+	Description  string `json:"description" dynamodbav:"description"`
+	CreationDate int64  `json:"creation_date" dynamodbav:"creation_date"`
+	UpdateDate   int64  `json:"update_date" dynamodbav:"update_date"`
+}
+
+type Collection struct {
+	ID           string `json:"id" dynamodbav:"id"`
+	SpaceID      string `json:"space_id" dynamodbav:"space_id"`
+	ParentID     string `json:"parent_id" dynamodbav:"parent_id"`
+	Name         string `json:"name" dynamodbav:"name"`
+	Position     int    `json:"position" dynamodbav:"position"`
+	CreationDate int64  `json:"creation_date" dynamodbav:"creation_date"`
+	UpdateDate   int64  `json:"update_date" dynamodbav:"update_date"`
+}
+
+type Placement struct {
+	KBID         string `json:"kb_id" dynamodbav:"kb_id"`
+	CollectionID string `json:"collection_id" dynamodbav:"collection_id"`
+	Position     int    `json:"position" dynamodbav:"position"`
+}
+
+// toRepositorySpace transforms a dynamodb space to a space.
+func (s Space) toRepositorySpace() spaces.Space {
+	return spaces.Space{
+		ID:           spaces.SpaceID(s.ID),
+		Name:         s.Name,
+		Description:  s.Description,
+		CreationDate: s.CreationDate,
+		UpdateDate:   s.UpdateDate,
+	}
+}
+
+// transformSpace transforms a space to a dynamodb space.
+func transformSpace(space spaces.Space) Space {
+	return Space{
+		ID:           space.ID.String(),
+		Name:         space.Name,
+		Description:  space.Description,
+		CreationDate: space.CreationDate,
+		UpdateDate:   space.UpdateDate,
+	}
+}
+
+// toRepositoryCollection transforms a dynamodb collection to a collection.
+func (c Collection) toRepositoryCollection() spaces.Collection {
+	return spaces.Collection{
+		ID:           spaces.CollectionID(c.ID),
+		SpaceID:      spaces.SpaceID(c.SpaceID),
+		ParentID:     spaces.CollectionID(c.ParentID),
+		Name:         c.Name,
+		Position:     c.Position,
+		CreationDate: c.CreationDate,
+		UpdateDate:   c.UpdateDate,
+	}
+}
+
+// transformCollection transforms a collection to a dynamodb collection.
+func transformCollection(collection spaces.Collection) Collection {
+	return Collection{
+		ID:           collection.ID.String(),
+		SpaceID:      collection.SpaceID.String(),
+		ParentID:     collection.ParentID.String(),
+		Name:         collection.Name,
+		Position:     collection.Position,
+		CreationDate: collection.CreationDate,
+		UpdateDate:   collection.UpdateDate,
+	}
+}
+
+// toRepositoryPlacement transforms a dynamodb placement to a placement.
+func (p Placement) toRepositoryPlacement() spaces.Placement {
+	return spaces.Placement{
+		KBID:         kbs.KBID(p.KBID),
+		CollectionID: spaces.CollectionID(p.CollectionID),
+		Position:     p.Position,
+	}
+}
+
+// transformPlacement transforms a placement to a dynamodb placement.
+func transformPlacement(placement spaces.Placement) Placement {
+	return Placement{
+		KBID:         placement.KBID.String(),
+		CollectionID: placement.CollectionID.String(),
+		Position:     placement.Position,
+	}
+}
+
+type Event struct {
+	ID           string   `json:"id" dynamodbav:"id"`
+	Name         string   `json:"name" dynamodbav:"name"`
+	Description  string   `json:"description" dynamodbav:"description"`
+	StartDate    int64    `json:"start_date" dynamodbav:"start_date"`
+	EndDate      int64    `json:"end_date" dynamodbav:"end_date"`
+	Status       string   `json:"status" dynamodbav:"status"`
+	Organizers   []string `json:"organizers" dynamodbav:"organizers"`
+	CreationDate int64    `json:"creation_date" dynamodbav:"creation_date"`
+	UpdateDate   int64    `json:"update_date" dynamodbav:"update_date"`
+}
+
+// toRepositoryEvent transforms a dynamodb event to an event.
+func (e Event) toRepositoryEvent() events.Event {
+	organizers := make([]kbs.UserID, len(e.Organizers))
+	for i, v := range e.Organizers {
+		organizers[i] = kbs.UserID(v)
+	}
+
+	return events.Event{
+		ID:           kbs.EventID(e.ID),
+		Name:         e.Name,
+		Description:  e.Description,
+		StartDate:    e.StartDate,
+		EndDate:      e.EndDate,
+		Status:       events.Status(e.Status),
+		Organizers:   organizers,
+		CreationDate: e.CreationDate,
+		UpdateDate:   e.UpdateDate,
+	}
+}
+
+// transformEvent transforms an event to a dynamodb event.
+func transformEvent(event events.Event) Event {
+	organizers := make([]string, len(event.Organizers))
+	for i, v := range event.Organizers {
+		organizers[i] = v.String()
+	}
+
+	return Event{
+		ID:           event.ID.String(),
+		Name:         event.Name,
+		Description:  event.Description,
+		StartDate:    event.StartDate,
+		EndDate:      event.EndDate,
+		Status:       event.Status.String(),
+		Organizers:   organizers,
+		CreationDate: event.CreationDate,
+		UpdateDate:   event.UpdateDate,
+	}
+}
+
+type Profile struct {
+	ID           string `json:"id" dynamodbav:"id"`
+	DisplayName  string `json:"display_name" dynamodbav:"display_name"`
+	Email        string `json:"email" dynamodbav:"email"`
+	AvatarURL    string `json:"avatar_url" dynamodbav:"avatar_url"`
+	Status       string `json:"status" dynamodbav:"status"`
+	CreationDate int64  `json:"creation_date" dynamodbav:"creation_date"`
+	UpdateDate   int64  `json:"update_date" dynamodbav:"update_date"`
+}
+
+// toRepositoryProfile transforms a dynamodb profile to a profile.
+func (p Profile) toRepositoryProfile() users.Profile {
+	return users.Profile{
+		ID:           kbs.UserID(p.ID),
+		DisplayName:  p.DisplayName,
+		Email:        p.Email,
+		AvatarURL:    p.AvatarURL,
+		Status:       users.Status(p.Status),
+		CreationDate: p.CreationDate,
+		UpdateDate:   p.UpdateDate,
+	}
+}
+
+// transformProfile transforms a profile to a dynamodb profile.
+func transformProfile(profile users.Profile) Profile {
+	return Profile{
+		ID:           profile.ID.String(),
+		DisplayName:  profile.DisplayName,
+		Email:        profile.Email,
+		AvatarURL:    profile.AvatarURL,
+		Status:       profile.Status.String(),
+		CreationDate: profile.CreationDate,
+		UpdateDate:   profile.UpdateDate,
+	}
+}
+
+type Comment struct {
+	ID           string   `json:"id" dynamodbav:"id"`
+	KBID         string   `json:"kb_id" dynamodbav:"kb_id"`
+	ParentID     string   `json:"parent_id" dynamodbav:"parent_id"`
+	AuthorID     string   `json:"author_id" dynamodbav:"author_id"`
+	Content      string   `json:"content" dynamodbav:"content"`
+	Mentions     []string `json:"mentions" dynamodbav:"mentions"`
+	Deleted      bool     `json:"deleted" dynamodbav:"deleted"`
+	CreationDate int64    `json:"creation_date" dynamodbav:"creation_date"`
+	UpdateDate   int64    `json:"update_date" dynamodbav:"update_date"`
+}
+
+// toRepositoryComment transforms a dynamodb comment to a comment.
+func (c Comment) toRepositoryComment() comments.Comment {
+	var mentions []kbs.UserID
+	for _, v := range c.Mentions {
+		mentions = append(mentions, kbs.UserID(v))
+	}
+
+	return comments.Comment{
+		ID:           comments.CommentID(c.ID),
+		KBID:         kbs.KBID(c.KBID),
+		ParentID:     comments.CommentID(c.ParentID),
+		AuthorID:     kbs.UserID(c.AuthorID),
+		Content:      c.Content,
+		Mentions:     mentions,
+		Deleted:      c.Deleted,
+		CreationDate: c.CreationDate,
+		UpdateDate:   c.UpdateDate,
+	}
+}
+
+// transformComment transforms a comment to a dynamodb comment.
+func transformComment(comment comments.Comment) Comment {
+	mentions := make([]string, len(comment.Mentions))
+	for i, v := range comment.Mentions {
+		mentions[i] = v.String()
+	}
+
+	return Comment{
+		ID:           comment.ID.String(),
+		KBID:         comment.KBID.String(),
+		ParentID:     comment.ParentID.String(),
+		AuthorID:     comment.AuthorID.String(),
+		Content:      comment.Content,
+		Mentions:     mentions,
+		Deleted:      comment.Deleted,
+		CreationDate: comment.CreationDate,
+		UpdateDate:   comment.UpdateDate,
+	}
+}
+
+// AuditEntry is an audit log entry item, the actor is omitted when it is
+// empty because index keys can't be empty strings.
+type AuditEntry struct {
+	Chain        string `json:"chain" dynamodbav:"chain"`
+	Sequence     int64  `json:"sequence" dynamodbav:"sequence"`
+	Timestamp    int64  `json:"timestamp" dynamodbav:"timestamp"`
+	ActorID      string `json:"actor_id" dynamodbav:"actor_id,omitempty"`
+	Action       string `json:"action" dynamodbav:"action"`
+	KBID         string `json:"kb_id" dynamodbav:"kb_id"`
+	EventID      string `json:"event_id" dynamodbav:"event_id"`
+	BeforeDigest string `json:"before_digest" dynamodbav:"before_digest"`
+	AfterDigest  string `json:"after_digest" dynamodbav:"after_digest"`
+	RequestID    string `json:"request_id" dynamodbav:"request_id"`
+	ClientIP     string `json:"client_ip" dynamodbav:"client_ip"`
+	PreviousHash string `json:"previous_hash" dynamodbav:"previous_hash"`
+	Hash         string `json:"hash" dynamodbav:"hash"`
+}
+
+// toRepositoryEntry transforms a dynamodb audit entry to an audit entry.
+func (a AuditEntry) toRepositoryEntry() audit.Entry {
+	return audit.Entry{
+		Sequence:     a.Sequence,
+		Timestamp:    a.Timestamp,
+		ActorID:      kbs.UserID(a.ActorID),
+		Action:       kbs.Action(a.Action),
+		KBID:         kbs.KBID(a.KBID),
+		EventID:      kbs.EventID(a.EventID),
+		BeforeDigest: a.BeforeDigest,
+		AfterDigest:  a.AfterDigest,
+		RequestID:    a.RequestID,
+		ClientIP:     a.ClientIP,
+		PreviousHash: a.PreviousHash,
+		Hash:         a.Hash,
+	}
+}
+
+// transformAuditEntry transforms an audit entry to a dynamodb audit entry.
+func transformAuditEntry(entry audit.Entry) AuditEntry {
+	return AuditEntry{
+		Chain:        auditChain,
+		Sequence:     entry.Sequence,
+		Timestamp:    entry.Timestamp,
+		ActorID:      entry.ActorID.String(),
+		Action:       entry.Action.String(),
+		KBID:         entry.KBID.String(),
+		EventID:      entry.EventID.String(),
+		BeforeDigest: entry.BeforeDigest,
+		AfterDigest:  entry.AfterDigest,
+		RequestID:    entry.RequestID,
+		ClientIP:     entry.ClientIP,
+		PreviousHash: entry.PreviousHash,
+		Hash:         entry.Hash,
+	}
+}
+
+// IdempotencyRecord is an idempotency record in dynamodb, expires_at is the
+// time to live attribute of the table.
+type IdempotencyRecord struct {
+	ID          string `json:"id" dynamodbav:"id"`
+	Fingerprint string `json:"fingerprint" dynamodbav:"fingerprint"`
+	State       string `json:"state" dynamodbav:"state"`
+	StatusCode  int    `json:"status_code" dynamodbav:"status_code"`
+	ContentType string `json:"content_type" dynamodbav:"content_type"`
+	Body        []byte `json:"body" dynamodbav:"body"`
+	CreatedAt   int64  `json:"created_at" dynamodbav:"created_at"`
+	LockedUntil int64  `json:"locked_until" dynamodbav:"locked_until"`
+	ExpiresAt   int64  `json:"expires_at" dynamodbav:"expires_at"`
+	Token       string `json:"token" dynamodbav:"token"`
+}
+
+// toRepositoryRecord transforms a dynamodb idempotency record to an idempotency record.
+func (i IdempotencyRecord) toRepositoryRecord() idempotency.Record {
+	return idempotency.Record{
+		ID:          i.ID,
+		Fingerprint: i.Fingerprint,
+		State:       idempotency.State(i.State),
+		Response: idempotency.Response{
+			StatusCode:  i.StatusCode,
+			ContentType: i.ContentType,
+			Body:        i.Body,
+		},
+		CreatedAt:   i.CreatedAt,
+		LockedUntil: i.LockedUntil,
+		ExpiresAt:   i.ExpiresAt,
+		Token:       i.Token,
+	}
+}
+
+// transformIdempotencyRecord transforms an idempotency record to a dynamodb idempotency record.
+func transformIdempotencyRecord(record idempotency.Record) IdempotencyRecord {
+	return IdempotencyRecord{
+		ID:          record.ID,
+		Fingerprint: record.Fingerprint,
+		State:       string(record.State),
+		StatusCode:  record.Response.StatusCode,
+		ContentType: record.Response.ContentType,
+		Body:        record.Response.Body,
+		CreatedAt:   record.CreatedAt,
+		LockedUntil: record.LockedUntil,
+		ExpiresAt:   record.ExpiresAt,
+		Token:       record.Token,
 	}
 }
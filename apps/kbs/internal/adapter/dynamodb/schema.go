@@ -0,0 +1,243 @@
+package dynamodb
+
+import (
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// billing modes of the tables.
+const (
+	PayPerRequestBilling = "PAY_PER_REQUEST"
+	ProvisionedBilling   = "PROVISIONED"
+)
+
+// attribute types of the keys.
+const (
+	stringAttribute = types.ScalarAttributeTypeS
+	numberAttribute = types.ScalarAttributeTypeN
+)
+
+// Capacity defines how tables are billed. Read and write units are only
+// used by provisioned tables and their indexes.
+type Capacity struct {
+	BillingMode string
+	ReadUnits   int64
+	WriteUnits  int64
+}
+
+// keySchema is the partition key and optional sort key of a table or index.
+type keySchema struct {
+	hash    string
+	rangeBy string
+}
+
+// indexSchema declares a global secondary index, every attribute is projected.
+type indexSchema struct {
+	name string
+	key  keySchema
+}
+
+// tableSchema declares a table the client uses.
+type tableSchema struct {
+	name    string
+	key     keySchema
+	indexes []indexSchema
+	// ttlAttribute is the attribute that expires items, optional.
+	ttlAttribute string
+}
+
+// attributeTypes contains the type of every attribute used as a key.
+var attributeTypes = map[string]types.ScalarAttributeType{
+	"id":            stringAttribute,
+	"user_id":       stringAttribute,
+	"event_id":      stringAttribute,
+	"creation_date": numberAttribute,
+	"update_date":   numberAttribute,
+	"space_id":      stringAttribute,
+	"kb_id":         stringAttribute,
+	"collection_id": stringAttribute,
+	"chain":         stringAttribute,
+	"actor_id":      stringAttribute,
+	"sequence":      numberAttribute,
+	"version":       numberAttribute,
+}
+
+// schema declares every table the client reads or writes.
+var schema = []tableSchema{
+	{
+		name: kbsTable,
+		key:  keySchema{hash: "id"},
+		indexes: []indexSchema{
+			{name: kbsByEventCreationIndex, key: keySchema{hash: "event_id", rangeBy: "creation_date"}},
+			{name: kbsByEventUpdateIndex, key: keySchema{hash: "event_id", rangeBy: "update_date"}},
+			{name: kbsByUserCreationIndex, key: keySchema{hash: "user_id", rangeBy: "creation_date"}},
+			{name: kbsByUserUpdateIndex, key: keySchema{hash: "user_id", rangeBy: "update_date"}},
+		},
+	},
+	{
+		name: spacesTable,
+		key:  keySchema{hash: "id"},
+	},
+	{
+		name: collectionsTable,
+		key:  keySchema{hash: "id"},
+		indexes: []indexSchema{
+			{name: collectionsBySpaceIndex, key: keySchema{hash: "space_id"}},
+		},
+	},
+	{
+		name: placementsTable,
+		key:  keySchema{hash: "kb_id"},
+		indexes: []indexSchema{
+			{name: placementsByCollectionIdx, key: keySchema{hash: "collection_id"}},
+		},
+	},
+	{
+		name: eventsTable,
+		key:  keySchema{hash: "id"},
+	},
+	{
+		name: usersTable,
+		key:  keySchema{hash: "id"},
+	},
+	{
+		name: commentsTable,
+		key:  keySchema{hash: "id"},
+		indexes: []indexSchema{
+			{name: commentsByKBIndex, key: keySchema{hash: "kb_id"}},
+		},
+	},
+	{
+		name: auditTable,
+		key:  keySchema{hash: "chain", rangeBy: "sequence"},
+		indexes: []indexSchema{
+			{name: auditByKBIndex, key: keySchema{hash: "kb_id", rangeBy: "sequence"}},
+			{name: auditByActorIndex, key: keySchema{hash: "actor_id", rangeBy: "sequence"}},
+		},
+	},
+	{
+		name:         idempotencyTable,
+		key:          keySchema{hash: "id"},
+		ttlAttribute: "expires_at",
+	},
+}
+
+// migrationsSchema declares the table that records the applied migrations.
+var migrationsSchema = tableSchema{
+	name: migrationsTable,
+	key:  keySchema{hash: "version"},
+}
+
+func (k keySchema) elements() []types.KeySchemaElement {
+	elements := []types.KeySchemaElement{
+		{AttributeName: aws.String(k.hash), KeyType: types.KeyTypeHash},
+	}
+
+	if k.rangeBy != "" {
+		elements = append(elements, types.KeySchemaElement{AttributeName: aws.String(k.rangeBy), KeyType: types.KeyTypeRange})
+	}
+
+	return elements
+}
+
+func (k keySchema) attributes() []string {
+	if k.rangeBy == "" {
+		return []string{k.hash}
+	}
+
+	return []string{k.hash, k.rangeBy}
+}
+
+// attributeDefinitions returns the definitions of the keys of the table
+// and of all its indexes.
+func (t tableSchema) attributeDefinitions() []types.AttributeDefinition {
+	names := t.key.attributes()
+	for _, index := range t.indexes {
+		names = append(names, index.key.attributes()...)
+	}
+
+	seen := make(map[string]bool)
+
+	var definitions []types.AttributeDefinition
+
+	for _, name := range names {
+		if seen[name] {
+			continue
+		}
+
+		seen[name] = true
+
+		definitions = append(definitions, types.AttributeDefinition{
+			AttributeName: aws.String(name),
+			AttributeType: attributeTypes[name],
+		})
+	}
+
+	return definitions
+}
+
+func (t tableSchema) createInput(capacity Capacity) *dynamodb.CreateTableInput {
+	input := dynamodb.CreateTableInput{
+		TableName:            aws.String(t.name),
+		AttributeDefinitions: t.attributeDefinitions(),
+		KeySchema:            t.key.elements(),
+		BillingMode:          capacity.billingMode(),
+	}
+
+	if capacity.provisioned() {
+		input.ProvisionedThroughput = capacity.throughput()
+	}
+
+	for _, index := range t.indexes {
+		input.GlobalSecondaryIndexes = append(input.GlobalSecondaryIndexes, index.definition(capacity))
+	}
+
+	return &input
+}
+
+func (i indexSchema) definition(capacity Capacity) types.GlobalSecondaryIndex {
+	index := types.GlobalSecondaryIndex{
+		IndexName:  aws.String(i.name),
+		KeySchema:  i.key.elements(),
+		Projection: &types.Projection{ProjectionType: types.ProjectionTypeAll},
+	}
+
+	if capacity.provisioned() {
+		index.ProvisionedThroughput = capacity.throughput()
+	}
+
+	return index
+}
+
+func (i indexSchema) createAction(capacity Capacity) types.GlobalSecondaryIndexUpdate {
+	definition := i.definition(capacity)
+
+	return types.GlobalSecondaryIndexUpdate{
+		Create: &types.CreateGlobalSecondaryIndexAction{
+			IndexName:             definition.IndexName,
+			KeySchema:             definition.KeySchema,
+			Projection:            definition.Projection,
+			ProvisionedThroughput: definition.ProvisionedThroughput,
+		},
+	}
+}
+
+func (c Capacity) provisioned() bool {
+	return c.BillingMode == ProvisionedBilling
+}
+
+func (c Capacity) billingMode() types.BillingMode {
+	if c.provisioned() {
+		return types.BillingModeProvisioned
+	}
+
+	return types.BillingModePayPerRequest
+}
+
+func (c Capacity) throughput() *types.ProvisionedThroughput {
+	return &types.ProvisionedThroughput{
+		ReadCapacityUnits:  aws.Int64(c.ReadUnits),
+		WriteCapacityUnits: aws.Int64(c.WriteUnits),
+	}
+}
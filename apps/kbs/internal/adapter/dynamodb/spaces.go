@@ -0,0 +1,408 @@
+package dynamodb
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/kbs"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/requests"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/spaces"
+)
+
+const (
+	spacesTable               = "spaces"
+	collectionsTable          = "collections"
+	placementsTable           = "kb_placements"
+	collectionsBySpaceIndex   = "space_id-index"
+	placementsByCollectionIdx = "collection_id-index"
+)
+
+var (
+	errSavingSpace        = errors.New("unable to save space")
+	errDeletingSpace      = errors.New("unable to delete space")
+	errGettingSpace       = errors.New("unable to get space")
+	errSavingCollection   = errors.New("unable to save collection")
+	errDeletingCollection = errors.New("unable to delete collection")
+	errGettingCollection  = errors.New("unable to get collection")
+	errSavingPlacement    = errors.New("unable to save kb placement")
+	errGettingPlacement   = errors.New("unable to get kb placement")
+	errDeletingPlacement  = errors.New("unable to delete kb placement")
+)
+
+func (c *Client) SaveSpace(ctx context.Context, space spaces.Space) error {
+	logger := requests.Logger(ctx, c.logger)
+
+	err := c.putItem(ctx, spacesTable, transformSpace(space))
+	if err != nil {
+		logger.Error("unable to persist space", "error", err)
+
+		return errSavingSpace
+	}
+
+	return nil
+}
+
+func (c *Client) UpdateSpace(ctx context.Context, space spaces.Space) error {
+	return c.SaveSpace(ctx, space)
+}
+
+func (c *Client) DeleteSpace(ctx context.Context, id spaces.SpaceID) error {
+	logger := requests.Logger(ctx, c.logger)
+
+	err := c.deleteItem(ctx, spacesTable, "id", id.String())
+	if err != nil {
+		logger.Error("unable to delete space from store", "error", err)
+
+		return errDeletingSpace
+	}
+
+	return nil
+}
+
+func (c *Client) QuerySpaceByID(ctx context.Context, id spaces.SpaceID) (*spaces.Space, error) {
+	logger := requests.Logger(ctx, c.logger)
+
+	var item Space
+
+	found, err := c.getItem(ctx, spacesTable, "id", id.String(), &item)
+	if err != nil {
+		logger.Error("unable to get space", slog.String("id", id.String()), "error", err)
+
+		return nil, errGettingSpace
+	}
+
+	if !found {
+		return nil, nil
+	}
+
+	space := item.toRepositorySpace()
+
+	return &space, nil
+}
+
+func (c *Client) QuerySpaces(ctx context.Context) ([]spaces.Space, error) {
+	logger := requests.Logger(ctx, c.logger)
+
+	var items []Space
+
+	paginator := dynamodb.NewScanPaginator(c.client, &dynamodb.ScanInput{
+		TableName: aws.String(c.table(spacesTable)),
+	})
+
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			logger.Error("unable to scan spaces", "error", err)
+
+			return nil, errGettingSpace
+		}
+
+		var pageItems []Space
+
+		err = attributevalue.UnmarshalListOfMaps(page.Items, &pageItems)
+		if err != nil {
+			logger.Error("unable to unmarshal spaces", "error", err)
+
+			return nil, errGettingSpace
+		}
+
+		items = append(items, pageItems...)
+	}
+
+	result := make([]spaces.Space, len(items))
+	for i, item := range items {
+		result[i] = item.toRepositorySpace()
+	}
+
+	return result, nil
+}
+
+func (c *Client) SaveCollection(ctx context.Context, collection spaces.Collection) error {
+	logger := requests.Logger(ctx, c.logger)
+
+	err := c.putItem(ctx, collectionsTable, transformCollection(collection))
+	if err != nil {
+		logger.Error("unable to persist collection", "error", err)
+
+		return errSavingCollection
+	}
+
+	return nil
+}
+
+func (c *Client) UpdateCollection(ctx context.Context, collection spaces.Collection) error {
+	return c.SaveCollection(ctx, collection)
+}
+
+func (c *Client) DeleteCollection(ctx context.Context, id spaces.CollectionID) error {
+	logger := requests.Logger(ctx, c.logger)
+
+	err := c.deleteItem(ctx, collectionsTable, "id", id.String())
+	if err != nil {
+		logger.Error("unable to delete collection from store", "error", err)
+
+		return errDeletingCollection
+	}
+
+	return nil
+}
+
+func (c *Client) QueryCollectionByID(ctx context.Context, id spaces.CollectionID) (*spaces.Collection, error) {
+	logger := requests.Logger(ctx, c.logger)
+
+	var item Collection
+
+	found, err := c.getItem(ctx, collectionsTable, "id", id.String(), &item)
+	if err != nil {
+		logger.Error("unable to get collection", slog.String("id", id.String()), "error", err)
+
+		return nil, errGettingCollection
+	}
+
+	if !found {
+		return nil, nil
+	}
+
+	collection := item.toRepositoryCollection()
+
+	return &collection, nil
+}
+
+func (c *Client) QueryCollections(ctx context.Context, spaceID spaces.SpaceID) ([]spaces.Collection, error) {
+	logger := requests.Logger(ctx, c.logger)
+
+	var items []Collection
+
+	err := c.queryIndex(ctx, collectionsTable, collectionsBySpaceIndex, "space_id", spaceID.String(), &items)
+	if err != nil {
+		logger.Error("unable to query collections", slog.String("space_id", spaceID.String()), "error", err)
+
+		return nil, errGettingCollection
+	}
+
+	result := make([]spaces.Collection, len(items))
+	for i, item := range items {
+		result[i] = item.toRepositoryCollection()
+	}
+
+	return result, nil
+}
+
+func (c *Client) SavePlacement(ctx context.Context, placement spaces.Placement) error {
+	logger := requests.Logger(ctx, c.logger)
+
+	err := c.putItem(ctx, placementsTable, transformPlacement(placement))
+	if err != nil {
+		logger.Error("unable to persist kb placement", "error", err)
+
+		return errSavingPlacement
+	}
+
+	return nil
+}
+
+func (c *Client) DeletePlacement(ctx context.Context, kbID kbs.KBID) error {
+	logger := requests.Logger(ctx, c.logger)
+
+	err := c.deleteItem(ctx, placementsTable, "kb_id", kbID.String())
+	if err != nil {
+		logger.Error("unable to delete kb placement", slog.String("kb_id", kbID.String()), "error", err)
+
+		return errDeletingPlacement
+	}
+
+	return nil
+}
+
+// QueryPlacementsByKB reads the placements of the given kbs with as few
+// BatchGetItem calls as possible.
+func (c *Client) QueryPlacementsByKB(ctx context.Context, kbIDs []kbs.KBID) (map[kbs.KBID]spaces.Placement, error) {
+	logger := requests.Logger(ctx, c.logger)
+
+	result := make(map[kbs.KBID]spaces.Placement, len(kbIDs))
+
+	for start := 0; start < len(kbIDs); start += batchGetLimit {
+		end := start + batchGetLimit
+		if end > len(kbIDs) {
+			end = len(kbIDs)
+		}
+
+		err := c.batchGetPlacements(ctx, kbIDs[start:end], result)
+		if err != nil {
+			logger.Error("unable to get kb placements", "error", err)
+
+			return nil, errGettingPlacement
+		}
+	}
+
+	return result, nil
+}
+
+// batchGetPlacements reads up to batchGetLimit placements into result,
+// retrying the keys DynamoDB reports as unprocessed.
+func (c *Client) batchGetPlacements(ctx context.Context, kbIDs []kbs.KBID, result map[kbs.KBID]spaces.Placement) error {
+	table := c.table(placementsTable)
+	keys := make([]map[string]types.AttributeValue, 0, len(kbIDs))
+	// a BatchGetItem fails if it contains the same key twice.
+	seen := make(map[kbs.KBID]bool, len(kbIDs))
+
+	for _, id := range kbIDs {
+		if seen[id] {
+			continue
+		}
+
+		seen[id] = true
+
+		key, err := c.buildTableKey("kb_id", id.String())
+		if err != nil {
+			return err
+		}
+
+		keys = append(keys, key)
+	}
+
+	requestItems := map[string]types.KeysAndAttributes{
+		table: {Keys: keys},
+	}
+
+	for len(requestItems) > 0 {
+		output, err := c.client.BatchGetItem(ctx, &dynamodb.BatchGetItemInput{
+			RequestItems: requestItems,
+		})
+		if err != nil {
+			return err
+		}
+
+		var items []Placement
+
+		err = attributevalue.UnmarshalListOfMaps(output.Responses[table], &items)
+		if err != nil {
+			return err
+		}
+
+		for _, item := range items {
+			placement := item.toRepositoryPlacement()
+			result[placement.KBID] = placement
+		}
+
+		requestItems = output.UnprocessedKeys
+	}
+
+	return nil
+}
+
+func (c *Client) QueryPlacements(ctx context.Context, collectionID spaces.CollectionID) ([]spaces.Placement, error) {
+	logger := requests.Logger(ctx, c.logger)
+
+	var items []Placement
+
+	err := c.queryIndex(ctx, placementsTable, placementsByCollectionIdx, "collection_id", collectionID.String(), &items)
+	if err != nil {
+		logger.Error("unable to query kb placements", slog.String("collection_id", collectionID.String()), "error", err)
+
+		return nil, errGettingPlacement
+	}
+
+	result := make([]spaces.Placement, len(items))
+	for i, item := range items {
+		result[i] = item.toRepositoryPlacement()
+	}
+
+	return result, nil
+}
+
+// putItem marshals the given item and stores it in the table.
+func (c *Client) putItem(ctx context.Context, table string, item any) error {
+	data, err := attributevalue.MarshalMap(item)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(c.table(table)),
+		Item:      data,
+	})
+
+	return err
+}
+
+// getItem reads the item with the given key and unmarshals it into out.
+// It returns false if the item does not exist.
+func (c *Client) getItem(ctx context.Context, table, fieldKey, value string, out any) (bool, error) {
+	key, err := c.buildTableKey(fieldKey, value)
+	if err != nil {
+		return false, err
+	}
+
+	data, err := c.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(c.table(table)),
+		Key:       key,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	if data.Item == nil {
+		return false, nil
+	}
+
+	err = attributevalue.UnmarshalMap(data.Item, out)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// deleteItem removes the item with the given key from the table.
+func (c *Client) deleteItem(ctx context.Context, table, fieldKey, value string) error {
+	key, err := c.buildTableKey(fieldKey, value)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(c.table(table)),
+		Key:       key,
+	})
+
+	return err
+}
+
+// queryIndex reads all the items of the index whose partition key matches the
+// given value and unmarshals them into out, which must be a pointer to a slice.
+func (c *Client) queryIndex(ctx context.Context, table, index, fieldKey, value string, out any) error {
+	keyEx := expression.Key(fieldKey).Equal(expression.Value(value))
+
+	expr, err := expression.NewBuilder().WithKeyCondition(keyEx).Build()
+	if err != nil {
+		return err
+	}
+
+	paginator := dynamodb.NewQueryPaginator(c.client, &dynamodb.QueryInput{
+		TableName:                 aws.String(c.table(table)),
+		IndexName:                 aws.String(index),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		KeyConditionExpression:    expr.KeyCondition(),
+	})
+
+	var items []map[string]types.AttributeValue
+
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return err
+		}
+
+		items = append(items, page.Items...)
+	}
+
+	return attributevalue.UnmarshalListOfMaps(items, out)
+}
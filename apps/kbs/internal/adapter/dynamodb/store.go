@@ -3,9 +3,15 @@ package dynamodb
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log/slog"
+	"net/http"
+	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
@@ -13,22 +19,45 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	"github.com/fernandoocampo/kb-store/apps/kbs/internal/kbs"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/requests"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/aws/aws-sdk-go-v2/otelaws"
 )
 
-const kbsTable = "kbs"
-
-var (
-	updateKBExpression = aws.String("set user_id = :userid, username = :username, event_id = :eventid, content = :content, update_date = :updatedate")
+// kbs are queried by user or by event, ordered by creation or update date,
+// each combination has its own index.
+const (
+	kbsTable                = "kbs"
+	kbsByUserCreationIndex  = "user_id-creation_date-index"
+	kbsByUserUpdateIndex    = "user_id-update_date-index"
+	kbsByEventCreationIndex = "event_id-creation_date-index"
+	kbsByEventUpdateIndex   = "event_id-update_date-index"
 )
 
+// tables contains every table the client reads or writes.
+var tables = []string{
+	kbsTable,
+	spacesTable,
+	collectionsTable,
+	placementsTable,
+	eventsTable,
+	usersTable,
+	commentsTable,
+	auditTable,
+	idempotencyTable,
+}
+
+const updateKBExpression = "set user_id = :userid, username = :username, event_id = :eventid, content = :content, update_date = :updatedate"
+
 var (
-	errLoadingAWSConfig = errors.New("unable to load aws config")
-	errCreatingDynamodb = errors.New("unable to connect to DynamoDB")
-	errSavingKB         = errors.New("unable to save kb")
-	errUpdatingKB       = errors.New("unable to update kb")
-	errDeletingKB       = errors.New("unable to delete kb")
-	errGettingKB        = errors.New("unable to get kb")
-	errBuildingKBKey    = errors.New("unable to build kb key")
+	errLoadingAWSConfig  = errors.New("unable to load aws config")
+	errCreatingDynamodb  = errors.New("unable to connect to DynamoDB")
+	errSavingKB          = errors.New("unable to save kb")
+	errUpdatingKB        = errors.New("unable to update kb")
+	errDeletingKB        = errors.New("unable to delete kb")
+	errGettingKB         = errors.New("unable to get kb")
+	errBuildingKBKey     = errors.New("unable to build kb key")
+	errChangingKBState   = errors.New("unable to change kb state")
+	errTableNotAvailable = errors.New("table is not available")
 )
 
 // Setup contains dynamodb settings.
@@ -36,32 +65,92 @@ type Setup struct {
 	Logger   *slog.Logger
 	Region   string
 	Endpoint string
+	// CapacityRecorder receives the capacity consumed by each operation, optional.
+	CapacityRecorder CapacityRecorder
+	// Capacity is the billing of the tables created or updated by Migrate.
+	Capacity Capacity
+	// SchemaTimeout is how long Migrate waits for a table to be active.
+	SchemaTimeout time.Duration
+	// Environment prefixes the name of every table, optional.
+	Environment string
+	// KBsTable replaces the name of the kbs table, optional.
+	KBsTable string
+	// KeyPrefix is added to the keys of the kbs followed by #, so
+	// environments can share the kbs table, optional. It cannot
+	// contain #.
+	KeyPrefix string
+	// Content defines how the content of large kbs is stored.
+	Content ContentSetup
 }
 
 // Client defines logic for dynamodb repository.
 type Client struct {
 	client *dynamodb.Client
 	logger *slog.Logger
+	// transport keeps the connections to dynamodb, it is closed with the client.
+	transport        *http.Transport
+	capacityRecorder CapacityRecorder
+	capacity         Capacity
+	schemaTimeout    time.Duration
+	tableNames       map[string]string
+	keyPrefix        string
+	content          ContentSetup
 }
 
 func NewClient(ctx context.Context, setup Setup) (*Client, error) {
 	newDynamodb := new(Client)
 	newDynamodb.logger = setup.Logger
 
+	tableNames, err := newTableNames(setup)
+	if err != nil {
+		setup.Logger.Error("unable to name dynamodb tables", "error", err)
+
+		return nil, err
+	}
+
+	newDynamodb.tableNames = tableNames
+
+	keyPrefix, err := newKeyPrefix(setup.KeyPrefix)
+	if err != nil {
+		setup.Logger.Error("unable to prefix dynamodb keys", "error", err)
+
+		return nil, err
+	}
+
+	newDynamodb.keyPrefix = keyPrefix
+	newDynamodb.content = setup.Content
+
 	awsconfig, err := newDynamodb.getConfig(ctx, setup.Region, setup.Endpoint)
 	if err != nil {
 		return nil, errCreatingDynamodb
 	}
 
-	newDynamodb.client = dynamodb.NewFromConfig(awsconfig)
+	newDynamodb.capacityRecorder = setup.CapacityRecorder
+	newDynamodb.capacity = setup.Capacity
+
+	newDynamodb.schemaTimeout = setup.SchemaTimeout
+	if newDynamodb.schemaTimeout <= 0 {
+		newDynamodb.schemaTimeout = defaultSchemaTimeout
+	}
+
+	newDynamodb.client = dynamodb.NewFromConfig(awsconfig, func(o *dynamodb.Options) {
+		// every operation is traced as a child of the span in the caller context.
+		otelaws.AppendMiddlewares(&o.APIOptions)
+
+		if newDynamodb.capacityRecorder != nil {
+			o.APIOptions = append(o.APIOptions, newDynamodb.consumedCapacity)
+		}
+	})
 
 	return newDynamodb, nil
 }
 
 func (c *Client) getConfig(ctx context.Context, region, endpoint string) (aws.Config, error) {
+	logger := requests.Logger(ctx, c.logger)
+
 	cfg, err := c.loadAWSConfig(ctx, region, endpoint)
 	if err != nil {
-		c.logger.Error("unable to load aws config", "error", err)
+		logger.Error("unable to load aws config", "error", err)
 
 		return cfg, errLoadingAWSConfig
 	}
@@ -72,6 +161,9 @@ func (c *Client) getConfig(ctx context.Context, region, endpoint string) (aws.Co
 func (c *Client) loadAWSConfig(ctx context.Context, region, endpoint string) (aws.Config, error) {
 	optFns := make([]func(*config.LoadOptions) error, 0)
 
+	c.transport = awshttp.NewBuildableClient().GetTransport()
+	optFns = append(optFns, config.WithHTTPClient(&http.Client{Transport: c.transport}))
+
 	if region != "" {
 		optFns = append(optFns, config.WithRegion(region))
 	}
@@ -104,19 +196,21 @@ func newEndpointResolver(endpoint string) aws.EndpointResolverWithOptionsFunc {
 }
 
 func (c *Client) QueryByID(ctx context.Context, kbID kbs.KBID) (*kbs.KB, error) {
-	kbKey, err := c.buildTableKey("id", kbID.String())
+	logger := requests.Logger(ctx, c.logger)
+
+	kbKey, err := c.buildTableKey("id", c.kbKey(kbID.String()))
 	if err != nil {
 		return nil, errGettingKB
 	}
 
-	data, err := c.client.GetItem(context.TODO(), &dynamodb.GetItemInput{
-		TableName: aws.String(kbsTable),
+	data, err := c.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(c.table(kbsTable)),
 		Key:       kbKey,
 	})
 	if err != nil {
-		c.logger.Error("unable to get kb", "error", err)
+		logger.Error("unable to get kb", "error", err)
 
-		return nil, errGettingKB
+		return nil, storeError(errGettingKB, err)
 	}
 
 	if data.Item == nil {
@@ -127,142 +221,503 @@ func (c *Client) QueryByID(ctx context.Context, kbID kbs.KBID) (*kbs.KB, error)
 
 	err = attributevalue.UnmarshalMap(data.Item, &item)
 	if err != nil {
-		c.logger.Error("unable to unmarshal kb", "error", err)
+		logger.Error("unable to unmarshal kb", "error", err)
 
 		return nil, errGettingKB
 	}
 
-	kb := item.toRepositoryKB()
+	_, err = c.readContent(ctx, &item, true)
+	if err != nil {
+		logger.Error("unable to read kb content", "error", err)
+
+		return nil, storeError(errGettingKB, err)
+	}
+
+	kb, ok := c.loadedKB(item)
+	if !ok {
+		return nil, nil
+	}
 
 	return &kb, nil
 }
 
 func (c *Client) Save(ctx context.Context, newKB kbs.KB) error {
-	akb := transformKB(newKB)
+	logger := requests.Logger(ctx, c.logger)
+
+	akb := c.storedKB(transformKB(newKB))
+
+	err := c.writeContent(ctx, &akb)
+	if err != nil {
+		logger.Error("unable to write kb content", "error", err)
+
+		return storeError(errSavingKB, err)
+	}
 
 	data, err := attributevalue.MarshalMap(akb)
 	if err != nil {
-		c.logger.Error("unable to marshal new kb", "error", err)
+		logger.Error("unable to marshal new kb", "error", err)
+		c.dropContent(ctx, akb.ContentRef, "")
 
 		return errSavingKB
 	}
 
-	_, err = c.client.PutItem(context.TODO(), &dynamodb.PutItemInput{
-		TableName: aws.String(kbsTable),
-		Item:      data,
+	output, err := c.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:    aws.String(c.table(kbsTable)),
+		Item:         data,
+		ReturnValues: types.ReturnValueAllOld,
 	})
 	if err != nil {
-		c.logger.Error("unable to persist kb", "error", err)
+		logger.Error("unable to persist kb", "error", err)
+		c.dropContent(ctx, akb.ContentRef, "")
 
-		return errSavingKB
+		return storeError(errSavingKB, err)
 	}
 
+	c.dropContent(ctx, oldContentRef(output.Attributes), akb.ContentRef)
+
 	return nil
 }
 
 func (c *Client) Update(ctx context.Context, kb kbs.UpdateKB) error {
-	kbKey, err := c.buildTableKey("id", kb.ID.String())
+	logger := requests.Logger(ctx, c.logger)
+
+	kbKey, err := c.buildTableKey("id", c.kbKey(kb.ID.String()))
 	if err != nil {
 		return errDeletingKB
 	}
 
-	_, err = c.client.UpdateItem(context.TODO(), &dynamodb.UpdateItemInput{
-		TableName:        aws.String(kbsTable),
-		Key:              kbKey,
-		UpdateExpression: updateKBExpression,
-		ExpressionAttributeValues: map[string]types.AttributeValue{
-			":userid":     &types.AttributeValueMemberS{Value: kb.UserID.String()},
-			":username":   &types.AttributeValueMemberS{Value: kb.UserName},
-			":eventid":    &types.AttributeValueMemberS{Value: kb.EventID.String()},
-			":content":    &types.AttributeValueMemberS{Value: kb.Content},
-			":updatedate": &types.AttributeValueMemberN{Value: kb.UpdateDateString()},
-		},
+	item := KB{ID: c.kbKey(kb.ID.String()), Content: kb.Content}
+
+	err = c.writeContent(ctx, &item)
+	if err != nil {
+		logger.Error("unable to write kb content",
+			slog.String("id", kb.ID.String()),
+			"error", err)
+
+		return storeError(errUpdatingKB, err)
+	}
+
+	updateExpression, values := contentUpdate(updateKBExpression, item)
+	values[":userid"] = &types.AttributeValueMemberS{Value: c.kbKey(kb.UserID.String())}
+	values[":username"] = &types.AttributeValueMemberS{Value: kb.UserName}
+	values[":eventid"] = &types.AttributeValueMemberS{Value: c.kbKey(kb.EventID.String())}
+	values[":updatedate"] = &types.AttributeValueMemberN{Value: kb.UpdateDateString()}
+
+	output, err := c.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                 aws.String(c.table(kbsTable)),
+		Key:                       kbKey,
+		UpdateExpression:          aws.String(updateExpression),
+		ExpressionAttributeValues: values,
+		ReturnValues:              types.ReturnValueUpdatedOld,
 	})
 	if err != nil {
-		c.logger.Error("unable to update kb",
+		logger.Error("unable to update kb",
 			slog.String("id", kb.ID.String()),
 			"error", err)
+		c.dropContent(ctx, item.ContentRef, "")
+
+		return storeError(errUpdatingKB, err)
+	}
+
+	c.dropContent(ctx, oldContentRef(output.Attributes), item.ContentRef)
+
+	return nil
+}
+
+// contentUpdate adds the content of the item to the update expression and
+// removes the content attributes it does not use.
+func contentUpdate(expression string, item KB) (string, map[string]types.AttributeValue) {
+	values := map[string]types.AttributeValue{
+		":content": &types.AttributeValueMemberS{Value: item.Content},
+	}
+
+	optional := []struct {
+		name  string
+		value types.AttributeValue
+		used  bool
+	}{
+		{"content_encoding", &types.AttributeValueMemberS{Value: item.ContentEncoding}, item.ContentEncoding != ""},
+		{"compressed_content", &types.AttributeValueMemberB{Value: item.CompressedContent}, len(item.CompressedContent) > 0},
+		{"content_ref", &types.AttributeValueMemberS{Value: item.ContentRef}, item.ContentRef != ""},
+		{"excerpt", &types.AttributeValueMemberS{Value: item.Excerpt}, item.Excerpt != ""},
+	}
+
+	var removed []string
+
+	for _, attribute := range optional {
+		if !attribute.used {
+			removed = append(removed, attribute.name)
+
+			continue
+		}
+
+		expression += fmt.Sprintf(", %s = :%s", attribute.name, attribute.name)
+		values[":"+attribute.name] = attribute.value
+	}
+
+	if len(removed) > 0 {
+		expression += " remove " + strings.Join(removed, ", ")
+	}
+
+	return expression, values
+}
+
+// oldContentRef returns the blob of the content a write replaced, if any.
+func oldContentRef(attributes map[string]types.AttributeValue) string {
+	ref, ok := attributes["content_ref"].(*types.AttributeValueMemberS)
+	if !ok {
+		return ""
+	}
+
+	return ref.Value
+}
+
+// ChangeState moves the kb to the new state, it fails if the kb is not in
+// the state the change comes from anymore.
+func (c *Client) ChangeState(ctx context.Context, change kbs.StateChange) error {
+	logger := requests.Logger(ctx, c.logger)
+
+	kbKey, err := c.buildTableKey("id", c.kbKey(change.ID.String()))
+	if err != nil {
+		return errChangingKBState
+	}
+
+	update := expression.Set(expression.Name("state"), expression.Value(change.To.String())).
+		Set(expression.Name("update_date"), expression.Value(change.UpdateDate))
+
+	if change.ReviewerID != "" {
+		update = update.Set(expression.Name("reviewer_id"), expression.Value(change.ReviewerID.String()))
+	}
+
+	if change.Review != nil {
+		reviews := expression.Name("reviews")
+		newReview := []Review{transformReview(*change.Review)}
+		update = update.Set(reviews, expression.ListAppend(
+			expression.IfNotExists(reviews, expression.Value([]Review{})),
+			expression.Value(newReview),
+		))
+	}
+
+	expr, err := expression.NewBuilder().
+		WithUpdate(update).
+		WithCondition(stateCondition(change.From)).
+		Build()
+	if err != nil {
+		logger.Error("unable to build kb state change", "error", err)
+
+		return errChangingKBState
+	}
+
+	_, err = c.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                 aws.String(c.table(kbsTable)),
+		Key:                       kbKey,
+		UpdateExpression:          expr.Update(),
+		ConditionExpression:       expr.Condition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+	})
+	if err != nil {
+		logger.Error("unable to change kb state",
+			slog.String("id", change.ID.String()),
+			"error", err)
 
-		return errUpdatingKB
+		return storeError(errChangingKBState, err)
 	}
 
 	return nil
 }
 
+// stateCondition matches kbs in the given state, kbs stored without
+// state are in the legacy state.
+func stateCondition(state kbs.State) expression.ConditionBuilder {
+	condition := expression.Name("state").Equal(expression.Value(state.String()))
+
+	if state == legacyKBState {
+		condition = condition.Or(expression.AttributeNotExists(expression.Name("state")))
+	}
+
+	return condition
+}
+
 func (c *Client) Delete(ctx context.Context, kb kbs.KB) error {
-	kbKey, err := c.buildTableKey("id", kb.ID.String())
+	logger := requests.Logger(ctx, c.logger)
+
+	kbKey, err := c.buildTableKey("id", c.kbKey(kb.ID.String()))
 	if err != nil {
 		return errDeletingKB
 	}
 
-	_, err = c.client.DeleteItem(context.TODO(), &dynamodb.DeleteItemInput{
-		TableName: aws.String(kbsTable),
-		Key:       kbKey,
+	output, err := c.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName:    aws.String(c.table(kbsTable)),
+		Key:          kbKey,
+		ReturnValues: types.ReturnValueAllOld,
 	})
 	if err != nil {
-		c.logger.Error("unable to delete kb from store", "error", err)
+		logger.Error("unable to delete kb from store", "error", err)
 
-		return errDeletingKB
+		return storeError(errDeletingKB, err)
 	}
 
+	c.dropContent(ctx, oldContentRef(output.Attributes), "")
+
 	return nil
 }
 
 // https://stackoverflow.com/questions/70019358/how-do-i-get-pagination-working-with-exclusivestartkey-for-dynamodb-aws-sdk-go-v
 // https://github.com/aws/aws-sdk-go-v2/issues/1724
 // https://docs.aws.amazon.com/code-library/latest/ug/go_2_dynamodb_code_examples.html
+// Query returns the page of kbs of the filter. DynamoDB has no offset and
+// applies the filter expression after the limit of every read, so the index
+// is read page by page until the kbs of the previous pages are skipped and
+// the requested page is full.
 func (c *Client) Query(ctx context.Context, filter kbs.QueryFilter) (kbs.SearchKBsResult, error) {
-	var result kbs.SearchKBsResult
+	logger := requests.Logger(ctx, c.logger)
+
+	result := kbs.SearchKBsResult{
+		Page:        filter.PageNumber,
+		RowsPerPage: filter.RowsPerPage,
+	}
 
-	keyEx := expression.Key("event_id").Equal(expression.Value(filter.EventID))
-	expr, err := expression.NewBuilder().WithKeyCondition(keyEx).Build()
+	if filter.EventID == "" && filter.UserID == "" {
+		return result, kbs.ErrMissingQueryFilter
+	}
+
+	queryInput, err := c.newKBsQueryInput(filter)
 	if err != nil {
 		return result, errGettingKB
 	}
 
+	rows := int(filter.RowsPerPage)
+	skip := (int(max(filter.PageNumber, 1)) - 1) * rows
+
+	for {
+		data, err := c.client.Query(ctx, queryInput)
+		if err != nil {
+			logger.Error("unable to get kb", "error", err)
+
+			return result, storeError(errGettingKB, err)
+		}
+
+		var items []KB
+
+		err = attributevalue.UnmarshalListOfMaps(data.Items, &items)
+		if err != nil {
+			logger.Error("unable to unmarshal kbs", "error", err)
+
+			return result, errGettingKB
+		}
+
+		for _, item := range items {
+			if skip > 0 {
+				skip--
+
+				continue
+			}
+
+			// offloaded content is not read, queries return its excerpt.
+			truncated, err := c.readContent(ctx, &item, false)
+			if err != nil {
+				logger.Error("unable to read kb content", "error", err)
+
+				return result, errGettingKB
+			}
+
+			kb, ok := c.loadedKB(item)
+			if !ok {
+				continue
+			}
+
+			kb.ContentTruncated = truncated
+			result.KBs = append(result.KBs, kb)
+
+			if rows > 0 && len(result.KBs) == rows {
+				return result, nil
+			}
+		}
+
+		if len(data.LastEvaluatedKey) == 0 {
+			return result, nil
+		}
+
+		queryInput.ExclusiveStartKey = data.LastEvaluatedKey
+	}
+}
+
+// newKBsQueryInput builds the query for the given filter, it needs an event
+// or a user. KBs of a user are read from a user index, filtered by event if
+// one was given, and kbs of an event are read from an event index. The index is sorted by the date the
+// kbs are ordered by.
+func (c *Client) newKBsQueryInput(filter kbs.QueryFilter) (*dynamodb.QueryInput, error) {
+	builder := expression.NewBuilder()
+
+	var filters []expression.ConditionBuilder
+
+	if filter.UserID != "" {
+		builder = builder.WithKeyCondition(expression.Key("user_id").Equal(expression.Value(c.kbKey(filter.UserID))))
+
+		if filter.EventID != "" {
+			filters = append(filters, expression.Name("event_id").Equal(expression.Value(c.kbKey(filter.EventID))))
+		}
+	} else {
+		builder = builder.WithKeyCondition(expression.Key("event_id").Equal(expression.Value(c.kbKey(filter.EventID))))
+	}
+
+	if filter.State != kbs.EmptyState {
+		filters = append(filters, stateCondition(filter.State))
+	}
+
+	switch len(filters) {
+	case 0:
+	case 1:
+		builder = builder.WithFilter(filters[0])
+	default:
+		builder = builder.WithFilter(expression.And(filters[0], filters[1], filters[2:]...))
+	}
+
+	expr, err := builder.Build()
+	if err != nil {
+		return nil, err
+	}
+
 	queryInput := dynamodb.QueryInput{
-		TableName:                 aws.String(kbsTable),
-		Limit:                     aws.Int32(int32(filter.RowsPerPage)),
+		TableName:                 aws.String(c.table(kbsTable)),
+		IndexName:                 aws.String(kbsIndexFor(filter)),
+		ScanIndexForward:          aws.Bool(filter.Order == kbs.Ascending),
 		ExpressionAttributeNames:  expr.Names(),
 		ExpressionAttributeValues: expr.Values(),
 		KeyConditionExpression:    expr.KeyCondition(),
+		FilterExpression:          expr.Filter(),
 	}
 
-	data, err := c.client.Query(ctx, &queryInput)
-	if err != nil {
-		c.logger.Error("unable to get kb", "error", err)
-
-		return result, errGettingKB
+	// the limit is the number of items read by each call, before they are
+	// filtered.
+	if filter.RowsPerPage > 0 {
+		queryInput.Limit = aws.Int32(int32(filter.RowsPerPage))
 	}
 
-	if len(data.Items) == 0 {
-		return result, nil
+	return &queryInput, nil
+}
+
+// kbsIndexFor returns the index that finds and orders the kbs of the filter.
+func kbsIndexFor(filter kbs.QueryFilter) string {
+	byUpdate := filter.OrderBy == kbs.UpdateDateField
+
+	switch {
+	case filter.UserID != "" && byUpdate:
+		return kbsByUserUpdateIndex
+	case filter.UserID != "":
+		return kbsByUserCreationIndex
+	case byUpdate:
+		return kbsByEventUpdateIndex
+	default:
+		return kbsByEventCreationIndex
 	}
+}
 
-	items := make([]KB, len(data.Items))
+// ScanKBs reads every kb of the table page by page and calls fn for each
+// one of the key prefix of the client.
+func (c *Client) ScanKBs(ctx context.Context, fn func(kb kbs.KB) error) error {
+	return c.scanKBs(ctx, false, fn)
+}
 
-	err = attributevalue.UnmarshalListOfMaps(data.Items, &items)
-	if err != nil {
-		c.logger.Error("unable to unmarshal kbs", "error", err)
+// SnapshotKBs reads every kb like ScanKBs, but each page is a strongly
+// consistent read, so it sees every write finished before the page was
+// read. It is not a point-in-time snapshot, kbs written while the scan
+// runs may or may not be read, the point-in-time recovery of the table
+// must be used for that.
+func (c *Client) SnapshotKBs(ctx context.Context, fn func(kb kbs.KB) error) error {
+	return c.scanKBs(ctx, true, fn)
+}
 
-		return result, errGettingKB
+func (c *Client) scanKBs(ctx context.Context, consistent bool, fn func(kb kbs.KB) error) error {
+	logger := requests.Logger(ctx, c.logger)
+
+	paginator := dynamodb.NewScanPaginator(c.client, &dynamodb.ScanInput{
+		TableName:      aws.String(c.table(kbsTable)),
+		ConsistentRead: aws.Bool(consistent),
+	})
+
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			logger.Error("unable to scan kbs", "error", err)
+
+			return errGettingKB
+		}
+
+		var items []KB
+
+		err = attributevalue.UnmarshalListOfMaps(page.Items, &items)
+		if err != nil {
+			logger.Error("unable to unmarshal kbs", "error", err)
+
+			return errGettingKB
+		}
+
+		for _, item := range items {
+			// kbs of other key prefixes belong to other environments.
+			if !c.ownKey(item.ID) {
+				continue
+			}
+
+			_, err = c.readContent(ctx, &item, true)
+			if err != nil {
+				logger.Error("unable to read kb content", "error", err)
+
+				return errGettingKB
+			}
+
+			kb, _ := c.loadedKB(item)
+
+			err = fn(kb)
+			if err != nil {
+				return err
+			}
+		}
 	}
 
-	result.KBs = make([]kbs.KB, len(items))
+	return nil
+}
 
-	for indx, item := range items {
-		result.KBs[indx] = item.toRepositoryKB()
+// Close releases the connections to dynamodb. It must be called once no
+// more requests are made with the client.
+func (c *Client) Close(ctx context.Context) error {
+	if c.transport != nil {
+		c.transport.CloseIdleConnections()
 	}
 
-	return result, nil
+	return nil
 }
 
+// DatasetStatus returns an error if any table the client uses is not
+// reachable or cannot be used.
 func (c *Client) DatasetStatus(ctx context.Context) error {
+	logger := requests.Logger(ctx, c.logger)
+
+	for _, name := range tables {
+		table := c.table(name)
+
+		output, err := c.client.DescribeTable(ctx, &dynamodb.DescribeTableInput{
+			TableName: aws.String(table),
+		})
+		if err != nil {
+			logger.Error("unable to describe table", "table", table, "error", err)
+
+			return fmt.Errorf("%w: %s", errTableNotAvailable, table)
+		}
+
+		status := output.Table.TableStatus
+		if status != types.TableStatusActive && status != types.TableStatusUpdating {
+			return fmt.Errorf("%w: %s is %s", errTableNotAvailable, table, status)
+		}
+	}
+
 	return nil
 }
 
+// Count returns the number of tables the client uses.
 func (c *Client) Count() int {
-	return 1
+	return len(tables)
 }
 
 func (c *Client) buildTableKey(fieldKey, value string) (map[string]types.AttributeValue, error) {
@@ -279,3 +734,17 @@ func (c *Client) buildTableKey(fieldKey, value string) (map[string]types.Attribu
 
 	return key, nil
 }
+
+// storeError returns the given error of the store, marked as unavailable
+// if dynamodb failed for a reason that may go away, like throttling, once
+// the sdk ran out of attempts.
+func storeError(storeErr, cause error) error {
+	throttled := retry.IsErrorThrottles(retry.DefaultThrottles).IsErrorThrottle(cause) == aws.TrueTernary
+	retryable := retry.IsErrorRetryables(retry.DefaultRetryables).IsErrorRetryable(cause) == aws.TrueTernary
+
+	if throttled || retryable {
+		return fmt.Errorf("%w: %w", storeErr, kbs.ErrStoreUnavailable)
+	}
+
+	return storeErr
+}
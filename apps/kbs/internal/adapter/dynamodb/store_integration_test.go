@@ -5,8 +5,10 @@ import (
 	"flag"
 	"log/slog"
 	"os"
+	"strings"
 	"testing"
 
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/adapter/blobs"
 	"github.com/fernandoocampo/kb-store/apps/kbs/internal/adapter/dynamodb"
 	"github.com/fernandoocampo/kb-store/apps/kbs/internal/kbs"
 	"github.com/google/uuid"
@@ -227,3 +229,218 @@ func newLogger() *slog.Logger {
 func newKBID() kbs.KBID {
 	return kbs.KBID(uuid.New().String())
 }
+
+func TestMigrateIsIdempotent(t *testing.T) {
+	skipNonIntegrationTest(t)
+
+	// Given
+	ctx := context.Background()
+
+	store := newStore(ctx, t)
+
+	_, err := store.Migrate(ctx)
+	assert.NoError(t, err)
+
+	// When
+	report, err := store.Migrate(ctx)
+
+	// Then
+	assert.NoError(t, err)
+	assert.Empty(t, report.Applied)
+	assert.Equal(t, 3, report.Version)
+	assert.NoError(t, store.DatasetStatus(ctx))
+}
+
+func TestEnvironmentsShareKBsTableWithKeyPrefixes(t *testing.T) {
+	skipNonIntegrationTest(t)
+
+	// Given
+	ctx := context.Background()
+	kbID := newKBID()
+	eventID := "6763fe1b-9391-49f2-acf1-5069e2a9cb21"
+
+	staging := newEnvironmentStore(ctx, t, "stg")
+	staging2 := newEnvironmentStore(ctx, t, "stg2")
+	unprefixed := newEnvironmentStore(ctx, t, "")
+
+	_, err := staging.Migrate(ctx)
+	assert.NoError(t, err)
+	assert.NoError(t, staging.ValidateSchema(ctx))
+
+	saveKB(t, staging, kbs.KB{
+		ID:      kbID,
+		UserID:  "cb5c9d13-daf8-4720-87eb-80f034b7528f",
+		EventID: kbs.EventID(eventID),
+	})
+	saveKB(t, staging2, kbs.KB{
+		ID:      newKBID(),
+		UserID:  "cb5c9d13-daf8-4720-87eb-80f034b7528f",
+		EventID: kbs.EventID(eventID),
+	})
+
+	// When
+	fromStaging, err := staging.QueryByID(ctx, kbID)
+	assert.NoError(t, err)
+	fromStaging2, err := staging2.QueryByID(ctx, kbID)
+	assert.NoError(t, err)
+	byEvent, err := staging.Query(ctx, kbs.QueryFilter{EventID: eventID})
+	assert.NoError(t, err)
+
+	var scanned []kbs.KBID
+	err = unprefixed.ScanKBs(ctx, func(kb kbs.KB) error {
+		scanned = append(scanned, kb.ID)
+		return nil
+	})
+	assert.NoError(t, err)
+
+	// Then
+	assert.NotNil(t, fromStaging)
+	assert.Equal(t, kbs.EventID(eventID), fromStaging.EventID)
+	assert.Nil(t, fromStaging2)
+	assert.Len(t, byEvent.KBs, 1)
+	assert.NotContains(t, scanned, kbID)
+}
+
+func TestKeyPrefixCannotContainSeparator(t *testing.T) {
+	// Given
+	setup := dynamodb.Setup{
+		Logger:    newLogger(),
+		Region:    "us-east-1",
+		Endpoint:  "http://localhost:4566",
+		KeyPrefix: "stg#2",
+	}
+
+	// When
+	store, err := dynamodb.NewClient(context.Background(), setup)
+
+	// Then
+	assert.Error(t, err)
+	assert.Nil(t, store)
+}
+
+func newEnvironmentStore(ctx context.Context, t *testing.T, keyPrefix string) *dynamodb.Client {
+	t.Helper()
+
+	setup := dynamodb.Setup{
+		Logger:      newLogger(),
+		Region:      "us-east-1",
+		Endpoint:    "http://localhost:4566",
+		Environment: "it",
+		KBsTable:    "it-shared-kbs",
+		KeyPrefix:   keyPrefix,
+	}
+
+	store, err := dynamodb.NewClient(ctx, setup)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	return store
+}
+
+func TestOffloadsLargeContent(t *testing.T) {
+	skipNonIntegrationTest(t)
+
+	// Given
+	ctx := context.Background()
+	blobStore, err := blobs.NewFileStore(t.TempDir())
+	assert.NoError(t, err)
+
+	setup := dynamodb.Setup{
+		Logger:   newLogger(),
+		Region:   "us-east-1",
+		Endpoint: "http://localhost:4566",
+		Content: dynamodb.ContentSetup{
+			CompressAbove: 1024,
+			OffloadAbove:  4096,
+			ExcerptLength: 10,
+			Blobs:         blobStore,
+		},
+	}
+
+	store, err := dynamodb.NewClient(ctx, setup)
+	assert.NoError(t, err)
+
+	kbID := newKBID()
+	eventID := kbs.EventID(uuid.New().String())
+	// random content doesn't compress below the offload size.
+	content := strings.Repeat(uuid.New().String(), 1000)
+
+	saveKB(t, store, kbs.KB{
+		ID:      kbID,
+		UserID:  "cb5c9d13-daf8-4720-87eb-80f034b7528f",
+		EventID: eventID,
+		Content: content,
+	})
+
+	// When
+	byID, err := store.QueryByID(ctx, kbID)
+	assert.NoError(t, err)
+	byEvent, err := store.Query(ctx, kbs.QueryFilter{EventID: eventID.String(), RowsPerPage: 10})
+	assert.NoError(t, err)
+
+	// Then
+	assert.Equal(t, content, byID.Content)
+	assert.False(t, byID.ContentTruncated)
+	assert.Len(t, byEvent.KBs, 1)
+	assert.Equal(t, content[:10], byEvent.KBs[0].Content)
+	assert.True(t, byEvent.KBs[0].ContentTruncated)
+}
+
+func TestQueryPagesFilteredKBs(t *testing.T) {
+	skipNonIntegrationTest(t)
+
+	// Given
+	ctx := context.Background()
+	store := newStore(ctx, t)
+	eventID := kbs.EventID(uuid.New().String())
+
+	var published []kbs.KBID
+	for i := 0; i < 6; i++ {
+		newKB := kbs.KB{
+			ID:           newKBID(),
+			UserID:       "cb5c9d13-daf8-4720-87eb-80f034b7528f",
+			EventID:      eventID,
+			Content:      "page me",
+			State:        kbs.Draft,
+			CreationDate: int64(1700000000 + i),
+		}
+		// drafts in between make dynamodb filter out part of every read.
+		if i%2 == 0 {
+			newKB.State = kbs.Published
+			published = append(published, newKB.ID)
+		}
+		saveKB(t, store, newKB)
+	}
+
+	filter := kbs.QueryFilter{
+		EventID:     eventID.String(),
+		State:       kbs.Published,
+		OrderBy:     kbs.CreationDateField,
+		Order:       kbs.Ascending,
+		RowsPerPage: 2,
+	}
+
+	// When
+	filter.PageNumber = 1
+	first, errFirst := store.Query(ctx, filter)
+	filter.PageNumber = 2
+	second, errSecond := store.Query(ctx, filter)
+	_, errMissing := store.Query(ctx, kbs.QueryFilter{RowsPerPage: 2})
+
+	// Then
+	assert.NoError(t, errFirst)
+	assert.NoError(t, errSecond)
+	assert.ErrorIs(t, errMissing, kbs.ErrMissingQueryFilter)
+	assert.Equal(t, published[:2], idsOf(first.KBs))
+	assert.Equal(t, published[2:], idsOf(second.KBs))
+}
+
+func idsOf(found []kbs.KB) []kbs.KBID {
+	var ids []kbs.KBID
+	for _, kb := range found {
+		ids = append(ids, kb.ID)
+	}
+
+	return ids
+}
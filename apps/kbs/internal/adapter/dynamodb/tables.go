@@ -0,0 +1,216 @@
+package dynamodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/kbs"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/requests"
+)
+
+// validTableName matches the names dynamodb accepts for tables.
+var validTableName = regexp.MustCompile(`^[a-zA-Z0-9_.-]{3,255}$`)
+
+// keySeparator separates the key prefix from the value of the kb keys, so
+// a key prefix never matches the keys of a longer one, e.g. stg and stg2.
+const keySeparator = "#"
+
+var (
+	errInvalidKeyPrefix = errors.New("key prefix cannot contain " + keySeparator)
+	errInvalidTableName = errors.New("invalid table name")
+	errSchemaMismatch   = errors.New("table does not match the expected schema")
+)
+
+// newTableNames returns the name of every table the client uses. Tables
+// of an environment are prefixed with its name, so several environments
+// can live in the same account, and the kbs table can be named explicitly.
+func newTableNames(setup Setup) (map[string]string, error) {
+	names := make(map[string]string, len(tables)+1)
+
+	for _, table := range append([]string{migrationsTable}, tables...) {
+		names[table] = table
+		if setup.Environment != "" {
+			names[table] = setup.Environment + "-" + table
+		}
+	}
+
+	if setup.KBsTable != "" {
+		names[kbsTable] = setup.KBsTable
+	}
+
+	for _, name := range names {
+		if !validTableName.MatchString(name) {
+			return nil, fmt.Errorf("%w: %q", errInvalidTableName, name)
+		}
+	}
+
+	return names, nil
+}
+
+// table returns the name the given table has in this environment.
+func (c *Client) table(name string) string {
+	if configured, ok := c.tableNames[name]; ok {
+		return configured
+	}
+
+	return name
+}
+
+// newKeyPrefix returns the prefix of the kb keys of the given key prefix.
+func newKeyPrefix(keyPrefix string) (string, error) {
+	if strings.Contains(keyPrefix, keySeparator) {
+		return "", fmt.Errorf("%w: %q", errInvalidKeyPrefix, keyPrefix)
+	}
+
+	if keyPrefix == "" {
+		return "", nil
+	}
+
+	return keyPrefix + keySeparator, nil
+}
+
+// kbKey returns the value stored in the key attributes of the kbs table,
+// the key prefix keeps the kbs of environments sharing the table apart.
+// Empty values are not prefixed so they keep meaning no value.
+func (c *Client) kbKey(value string) string {
+	if value == "" {
+		return value
+	}
+
+	return c.keyPrefix + value
+}
+
+// ownKey returns true if the stored id belongs to the key prefix of the
+// client. Ids of a client without key prefix have no separator at all.
+func (c *Client) ownKey(id string) bool {
+	value, ok := strings.CutPrefix(id, c.keyPrefix)
+
+	return ok && !strings.Contains(value, keySeparator)
+}
+
+// storedKB adds the key prefix to the key attributes of the kb.
+func (c *Client) storedKB(kb KB) KB {
+	kb.ID = c.kbKey(kb.ID)
+	kb.UserID = c.kbKey(kb.UserID)
+	kb.EventID = c.kbKey(kb.EventID)
+
+	return kb
+}
+
+// loadedKB removes the key prefix from the key attributes of the kb. It
+// returns false if the kb belongs to another key prefix.
+func (c *Client) loadedKB(item KB) (kbs.KB, bool) {
+	if !c.ownKey(item.ID) {
+		return kbs.KB{}, false
+	}
+
+	item.ID = strings.TrimPrefix(item.ID, c.keyPrefix)
+	item.UserID = strings.TrimPrefix(item.UserID, c.keyPrefix)
+	item.EventID = strings.TrimPrefix(item.EventID, c.keyPrefix)
+
+	return item.toRepositoryKB(), true
+}
+
+// ValidateSchema checks that the configured kbs table exists and that its
+// key schema and indexes are the ones the client queries, so a wrong table
+// name fails at startup instead of in the first request.
+func (c *Client) ValidateSchema(ctx context.Context) error {
+	logger := requests.Logger(ctx, c.logger)
+
+	declared, ok := declaredTable(kbsTable)
+	if !ok {
+		return errUnknownTable
+	}
+
+	declared.name = c.table(kbsTable)
+
+	output, err := c.client.DescribeTable(ctx, &dynamodb.DescribeTableInput{
+		TableName: aws.String(declared.name),
+	})
+	if err != nil {
+		logger.Error("unable to describe table", slog.String("table", declared.name), "error", err)
+
+		return fmt.Errorf("%w: %s", errTableNotAvailable, declared.name)
+	}
+
+	err = schemaMismatch(declared, output.Table)
+	if err != nil {
+		logger.Error("table does not match the expected schema", slog.String("table", declared.name), "error", err)
+
+		return err
+	}
+
+	return nil
+}
+
+// schemaMismatch compares the keys of the table and of its indexes with
+// the declared ones. Extra indexes and attributes are allowed.
+func schemaMismatch(declared tableSchema, current *types.TableDescription) error {
+	attributes := attributeTypesOf(current.AttributeDefinitions)
+
+	err := keyMismatch(declared.key, current.KeySchema, attributes)
+	if err != nil {
+		return fmt.Errorf("%w: %s key %s", errSchemaMismatch, declared.name, err)
+	}
+
+	for _, index := range declared.indexes {
+		i := slices.IndexFunc(current.GlobalSecondaryIndexes, func(existing types.GlobalSecondaryIndexDescription) bool {
+			return aws.ToString(existing.IndexName) == index.name
+		})
+		if i < 0 {
+			return fmt.Errorf("%w: %s has no index %s", errSchemaMismatch, declared.name, index.name)
+		}
+
+		err := keyMismatch(index.key, current.GlobalSecondaryIndexes[i].KeySchema, attributes)
+		if err != nil {
+			return fmt.Errorf("%w: %s index %s key %s", errSchemaMismatch, declared.name, index.name, err)
+		}
+	}
+
+	return nil
+}
+
+// keyMismatch returns an error describing how the key differs from the
+// declared one.
+func keyMismatch(declared keySchema, elements []types.KeySchemaElement, attributes map[string]types.ScalarAttributeType) error {
+	var hash, rangeBy string
+
+	for _, element := range elements {
+		switch element.KeyType {
+		case types.KeyTypeHash:
+			hash = aws.ToString(element.AttributeName)
+		case types.KeyTypeRange:
+			rangeBy = aws.ToString(element.AttributeName)
+		}
+	}
+
+	if hash != declared.hash || rangeBy != declared.rangeBy {
+		return fmt.Errorf("is (%s, %s), expected (%s, %s)", hash, rangeBy, declared.hash, declared.rangeBy)
+	}
+
+	for _, name := range declared.attributes() {
+		if attributes[name] != attributeTypes[name] {
+			return fmt.Errorf("attribute %s is %s, expected %s", name, attributes[name], attributeTypes[name])
+		}
+	}
+
+	return nil
+}
+
+func attributeTypesOf(definitions []types.AttributeDefinition) map[string]types.ScalarAttributeType {
+	attributes := make(map[string]types.ScalarAttributeType, len(definitions))
+
+	for _, definition := range definitions {
+		attributes[aws.ToString(definition.AttributeName)] = definition.AttributeType
+	}
+
+	return attributes
+}
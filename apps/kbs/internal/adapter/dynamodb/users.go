@@ -0,0 +1,182 @@
+package dynamodb
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/kbs"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/requests"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/users"
+)
+
+const (
+	usersTable = "users"
+	// batchGetLimit is the maximum number of keys DynamoDB accepts in a BatchGetItem.
+	batchGetLimit = 100
+)
+
+var (
+	errSavingProfile   = errors.New("unable to save profile")
+	errDeletingProfile = errors.New("unable to delete profile")
+	errGettingProfile  = errors.New("unable to get profile")
+)
+
+func (c *Client) SaveProfile(ctx context.Context, profile users.Profile) error {
+	logger := requests.Logger(ctx, c.logger)
+
+	err := c.putItem(ctx, usersTable, transformProfile(profile))
+	if err != nil {
+		logger.Error("unable to persist profile", "error", err)
+
+		return errSavingProfile
+	}
+
+	return nil
+}
+
+func (c *Client) UpdateProfile(ctx context.Context, profile users.Profile) error {
+	return c.SaveProfile(ctx, profile)
+}
+
+func (c *Client) DeleteProfile(ctx context.Context, id kbs.UserID) error {
+	logger := requests.Logger(ctx, c.logger)
+
+	err := c.deleteItem(ctx, usersTable, "id", id.String())
+	if err != nil {
+		logger.Error("unable to delete profile from store", "error", err)
+
+		return errDeletingProfile
+	}
+
+	return nil
+}
+
+func (c *Client) QueryProfileByID(ctx context.Context, id kbs.UserID) (*users.Profile, error) {
+	logger := requests.Logger(ctx, c.logger)
+
+	var item Profile
+
+	found, err := c.getItem(ctx, usersTable, "id", id.String(), &item)
+	if err != nil {
+		logger.Error("unable to get profile", slog.String("id", id.String()), "error", err)
+
+		return nil, errGettingProfile
+	}
+
+	if !found {
+		return nil, nil
+	}
+
+	profile := item.toRepositoryProfile()
+
+	return &profile, nil
+}
+
+func (c *Client) QueryProfilesByIDs(ctx context.Context, ids []kbs.UserID) ([]users.Profile, error) {
+	logger := requests.Logger(ctx, c.logger)
+
+	var result []users.Profile
+
+	for start := 0; start < len(ids); start += batchGetLimit {
+		end := start + batchGetLimit
+		if end > len(ids) {
+			end = len(ids)
+		}
+
+		profiles, err := c.batchGetProfiles(ctx, ids[start:end])
+		if err != nil {
+			logger.Error("unable to get profiles", "error", err)
+
+			return nil, errGettingProfile
+		}
+
+		result = append(result, profiles...)
+	}
+
+	return result, nil
+}
+
+// batchGetProfiles reads up to batchGetLimit profiles, retrying the keys
+// DynamoDB reports as unprocessed.
+func (c *Client) batchGetProfiles(ctx context.Context, ids []kbs.UserID) ([]users.Profile, error) {
+	keys := make([]map[string]types.AttributeValue, 0, len(ids))
+
+	for _, id := range ids {
+		key, err := c.buildTableKey("id", id.String())
+		if err != nil {
+			return nil, err
+		}
+
+		keys = append(keys, key)
+	}
+
+	var result []users.Profile
+
+	table := c.table(usersTable)
+	requestItems := map[string]types.KeysAndAttributes{
+		table: {Keys: keys},
+	}
+
+	for len(requestItems) > 0 {
+		output, err := c.client.BatchGetItem(ctx, &dynamodb.BatchGetItemInput{
+			RequestItems: requestItems,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		var items []Profile
+
+		err = attributevalue.UnmarshalListOfMaps(output.Responses[table], &items)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range items {
+			result = append(result, item.toRepositoryProfile())
+		}
+
+		requestItems = output.UnprocessedKeys
+	}
+
+	return result, nil
+}
+
+func (c *Client) QueryProfiles(ctx context.Context) ([]users.Profile, error) {
+	logger := requests.Logger(ctx, c.logger)
+
+	var result []users.Profile
+
+	paginator := dynamodb.NewScanPaginator(c.client, &dynamodb.ScanInput{
+		TableName: aws.String(c.table(usersTable)),
+	})
+
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			logger.Error("unable to scan profiles", "error", err)
+
+			return nil, errGettingProfile
+		}
+
+		var items []Profile
+
+		err = attributevalue.UnmarshalListOfMaps(page.Items, &items)
+		if err != nil {
+			logger.Error("unable to unmarshal profiles", "error", err)
+
+			return nil, errGettingProfile
+		}
+
+		for _, item := range items {
+			result = append(result, item.toRepositoryProfile())
+		}
+	}
+
+	return result, nil
+}
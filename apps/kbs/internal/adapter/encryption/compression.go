@@ -0,0 +1,62 @@
+package encryption
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// gzipEncoding is the encoding of plaintext compressed before it was sealed.
+const gzipEncoding = "gzip"
+
+var errUnknownEncoding = errors.New("unknown encoding of encrypted content")
+
+// encodePlaintext compresses the plaintext if it is larger than the given
+// size and it shrinks, ciphertext can't be compressed so it must happen
+// before encryption. It returns the encoding used, empty if none.
+func encodePlaintext(plaintext []byte, compressAbove int) ([]byte, string, error) {
+	if compressAbove <= 0 || len(plaintext) <= compressAbove {
+		return plaintext, "", nil
+	}
+
+	var buf bytes.Buffer
+
+	writer := gzip.NewWriter(&buf)
+
+	_, err := writer.Write(plaintext)
+	if err != nil {
+		return nil, "", err
+	}
+
+	err = writer.Close()
+	if err != nil {
+		return nil, "", err
+	}
+
+	if buf.Len() >= len(plaintext) {
+		return plaintext, "", nil
+	}
+
+	return buf.Bytes(), gzipEncoding, nil
+}
+
+// decodePlaintext reverts encodePlaintext.
+func decodePlaintext(encoded []byte, encoding string) ([]byte, error) {
+	switch encoding {
+	case "":
+		return encoded, nil
+	case gzipEncoding:
+		reader, err := gzip.NewReader(bytes.NewReader(encoded))
+		if err != nil {
+			return nil, err
+		}
+
+		defer reader.Close()
+
+		return io.ReadAll(reader)
+	default:
+		return nil, fmt.Errorf("%w: %q", errUnknownEncoding, encoding)
+	}
+}
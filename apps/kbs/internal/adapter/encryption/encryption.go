@@ -0,0 +1,442 @@
+// Package encryption contains a store that encrypts the content of kbs
+// before it reaches the wrapped store, using envelope encryption.
+package encryption
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/kbs"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/requests"
+)
+
+// defaultTenant owns the data keys of the kbs that are not written for an
+// event.
+const defaultTenant = "default"
+
+// maxUnwrappedKeys is the number of unwrapped data keys kept in memory.
+const maxUnwrappedKeys = 1024
+
+var (
+	errEncryptingContent = errors.New("unable to encrypt kb content")
+	errDecryptingContent = errors.New("unable to decrypt kb content")
+	errReencrypting      = errors.New("unable to re-encrypt kbs")
+)
+
+// KBScanner reads every kb of a store as it is stored.
+type KBScanner interface {
+	// ScanKBs calls fn for each kb in the store until all kbs were read
+	// or fn returns an error.
+	ScanKBs(ctx context.Context, fn func(kb kbs.KB) error) error
+}
+
+// Setup contains the settings of the encrypted store.
+type Setup struct {
+	Storer kbs.Storer
+	Keys   KeyProvider
+	Logger *slog.Logger
+	// DataKeyTTL is how long a data key encrypts the content of a tenant
+	// before a new one is generated.
+	DataKeyTTL time.Duration
+	// CompressAbove is the size in bytes above which content is compressed
+	// before it is encrypted, zero disables compression.
+	CompressAbove int
+	// Clock returns the current time, time.Now if nil.
+	Clock func() time.Time
+}
+
+// Store encrypts the content of the kbs it saves and decrypts the content
+// of the kbs it reads. Each tenant has its own data keys, they are stored
+// wrapped by a master key next to the content they encrypted.
+type Store struct {
+	kbs.Storer
+	keys          KeyProvider
+	logger        *slog.Logger
+	dataKeyTTL    time.Duration
+	compressAbove int
+	clock         func() time.Time
+
+	mu        sync.Mutex
+	dataKeys  map[string]cachedDataKey
+	unwrapped map[string][]byte
+}
+
+// cachedDataKey is the data key a tenant encrypts with until it expires.
+type cachedDataKey struct {
+	key       DataKey
+	expiresAt time.Time
+}
+
+// ReencryptReport contains the result of re-encrypting the kbs.
+type ReencryptReport struct {
+	KBsRead int `json:"kbs_read"`
+	// KBsEncrypted were stored before encryption was enabled.
+	KBsEncrypted int `json:"kbs_encrypted"`
+	// KBsRotated were encrypted with a key wrapped by an old master key.
+	KBsRotated int `json:"kbs_rotated"`
+	// KBsSkipped changed after they were read, they are left as they are
+	// for the next run.
+	KBsSkipped int `json:"kbs_skipped"`
+}
+
+// New creates a store that encrypts the content of the kbs of the given one.
+func New(setup Setup) *Store {
+	newStore := Store{
+		Storer:        setup.Storer,
+		keys:          setup.Keys,
+		logger:        setup.Logger,
+		dataKeyTTL:    setup.DataKeyTTL,
+		compressAbove: setup.CompressAbove,
+		clock:         setup.Clock,
+		dataKeys:      make(map[string]cachedDataKey),
+		unwrapped:     make(map[string][]byte),
+	}
+
+	if newStore.clock == nil {
+		newStore.clock = time.Now
+	}
+
+	return &newStore
+}
+
+func (s *Store) Save(ctx context.Context, newKB kbs.KB) error {
+	content, err := s.encrypt(ctx, newKB.ID, newKB.EventID, newKB.Content)
+	if err != nil {
+		return err
+	}
+
+	newKB.Content = content
+
+	return s.Storer.Save(ctx, newKB)
+}
+
+func (s *Store) Update(ctx context.Context, kb kbs.UpdateKB) error {
+	content, err := s.encrypt(ctx, kb.ID, kb.EventID, kb.Content)
+	if err != nil {
+		return err
+	}
+
+	kb.Content = content
+
+	return s.Storer.Update(ctx, kb)
+}
+
+func (s *Store) Query(ctx context.Context, filter kbs.QueryFilter) (kbs.SearchKBsResult, error) {
+	result, err := s.Storer.Query(ctx, filter)
+	if err != nil {
+		return result, err
+	}
+
+	for i := range result.KBs {
+		// the excerpt of encrypted content can't be decrypted, readers get
+		// the whole content by id.
+		if result.KBs[i].ContentTruncated && isEnvelope(result.KBs[i].Content) {
+			result.KBs[i].Content = ""
+
+			continue
+		}
+
+		content, err := s.decrypt(ctx, result.KBs[i].ID, result.KBs[i].Content)
+		if err != nil {
+			return kbs.SearchKBsResult{}, err
+		}
+
+		result.KBs[i].Content = content
+	}
+
+	return result, nil
+}
+
+func (s *Store) QueryByID(ctx context.Context, id kbs.KBID) (*kbs.KB, error) {
+	kb, err := s.Storer.QueryByID(ctx, id)
+	if err != nil || kb == nil {
+		return kb, err
+	}
+
+	content, err := s.decrypt(ctx, kb.ID, kb.Content)
+	if err != nil {
+		return nil, err
+	}
+
+	kb.Content = content
+
+	return kb, nil
+}
+
+// Reencrypt encrypts the content of the kbs stored before encryption was
+// enabled and re-encrypts the content whose data key is wrapped by a master
+// key that is not the current one, so old master keys can be retired.
+// A kb is only written back if it is still stored as it was read, so a
+// concurrent update is never overwritten with the old content.
+func (s *Store) Reencrypt(ctx context.Context, scanner KBScanner) (ReencryptReport, error) {
+	var report ReencryptReport
+
+	currentKeyID := s.keys.CurrentKeyID()
+
+	err := scanner.ScanKBs(ctx, func(kb kbs.KB) error {
+		report.KBsRead++
+
+		encrypted := isEnvelope(kb.Content)
+		if encrypted {
+			current, err := parseEnvelope(kb.Content)
+			if err != nil {
+				return err
+			}
+
+			if current.masterKeyID == currentKeyID {
+				return nil
+			}
+		}
+
+		content, err := s.decrypt(ctx, kb.ID, kb.Content)
+		if err != nil {
+			return err
+		}
+
+		unchanged, err := s.unchanged(ctx, kb)
+		if err != nil {
+			return err
+		}
+
+		if !unchanged {
+			report.KBsSkipped++
+
+			return nil
+		}
+
+		// the update date is kept, the kb did not change for its readers.
+		err = s.Update(ctx, kbs.UpdateKB{
+			ID:         kb.ID,
+			UserID:     kb.UserID,
+			UserName:   kb.UserName,
+			Content:    content,
+			EventID:    kb.EventID,
+			UpdateDate: kb.UpdateDate,
+		})
+		if err != nil {
+			return err
+		}
+
+		if encrypted {
+			report.KBsRotated++
+		} else {
+			report.KBsEncrypted++
+		}
+
+		return nil
+	})
+	if err != nil {
+		s.logger.Error("unable to re-encrypt kbs",
+			slog.Any("report", report),
+			slog.String("error", err.Error()))
+
+		return report, errReencrypting
+	}
+
+	s.logger.Info("kbs re-encryption finished", slog.Any("report", report))
+
+	return report, nil
+}
+
+// unchanged returns true if the kb is still stored as it was read, the
+// content is compared as it is stored.
+func (s *Store) unchanged(ctx context.Context, read kbs.KB) (bool, error) {
+	stored, err := s.Storer.QueryByID(ctx, read.ID)
+	if err != nil {
+		return false, err
+	}
+
+	if stored == nil {
+		return false, nil
+	}
+
+	return stored.UpdateDate == read.UpdateDate && stored.Content == read.Content, nil
+}
+
+// Scanner returns a scanner that reads the kbs of the given one with their
+// content decrypted, e.g. to export them.
+func (s *Store) Scanner(scanner KBScanner) KBScanner {
+	return decryptingScanner{store: s, scanner: scanner}
+}
+
+// decryptingScanner decrypts the kbs it reads.
+type decryptingScanner struct {
+	store   *Store
+	scanner KBScanner
+}
+
+func (d decryptingScanner) ScanKBs(ctx context.Context, fn func(kb kbs.KB) error) error {
+	return d.scanner.ScanKBs(ctx, func(kb kbs.KB) error {
+		content, err := d.store.decrypt(ctx, kb.ID, kb.Content)
+		if err != nil {
+			return err
+		}
+
+		kb.Content = content
+
+		return fn(kb)
+	})
+}
+
+// encrypt returns the content encrypted with the current data key of the
+// tenant of the kb, large content is compressed first.
+func (s *Store) encrypt(ctx context.Context, id kbs.KBID, eventID kbs.EventID, content string) (string, error) {
+	tenant := tenantOf(eventID)
+
+	key, err := s.dataKey(ctx, tenant)
+	if err != nil {
+		requests.Logger(ctx, s.logger).Error("unable to get data key",
+			slog.String("id", id.String()),
+			slog.String("tenant", tenant),
+			slog.String("error", err.Error()))
+
+		return "", errEncryptingContent
+	}
+
+	plaintext, encoding, err := encodePlaintext([]byte(content), s.compressAbove)
+	if err != nil {
+		requests.Logger(ctx, s.logger).Error("unable to compress kb content",
+			slog.String("id", id.String()),
+			slog.String("error", err.Error()))
+
+		return "", errEncryptingContent
+	}
+
+	sealed, err := sealEnvelope(tenant, key, plaintext, []byte(id), encoding)
+	if err != nil {
+		requests.Logger(ctx, s.logger).Error("unable to encrypt kb content",
+			slog.String("id", id.String()),
+			slog.String("error", err.Error()))
+
+		return "", errEncryptingContent
+	}
+
+	return sealed.String(), nil
+}
+
+// decrypt returns the plaintext of the content of the kb, content that was
+// not encrypted is returned as it is.
+func (s *Store) decrypt(ctx context.Context, id kbs.KBID, content string) (string, error) {
+	if !isEnvelope(content) {
+		return content, nil
+	}
+
+	logger := requests.Logger(ctx, s.logger)
+
+	sealed, err := parseEnvelope(content)
+	if err != nil {
+		logger.Error("unable to read encrypted kb content",
+			slog.String("id", id.String()),
+			slog.String("error", err.Error()))
+
+		return "", errDecryptingContent
+	}
+
+	key, err := s.unwrap(ctx, sealed)
+	if err != nil {
+		logger.Error("unable to unwrap data key",
+			slog.String("id", id.String()),
+			slog.String("master_key_id", sealed.masterKeyID),
+			slog.String("error", err.Error()))
+
+		return "", errDecryptingContent
+	}
+
+	encoded, err := sealed.open(key, []byte(id))
+	if err != nil {
+		logger.Error("unable to decrypt kb content",
+			slog.String("id", id.String()),
+			slog.String("error", err.Error()))
+
+		return "", errDecryptingContent
+	}
+
+	plaintext, err := decodePlaintext(encoded, sealed.encoding)
+	if err != nil {
+		logger.Error("unable to decompress kb content",
+			slog.String("id", id.String()),
+			slog.String("error", err.Error()))
+
+		return "", errDecryptingContent
+	}
+
+	return string(plaintext), nil
+}
+
+// dataKey returns the data key the tenant encrypts with, a new one is
+// generated when it expires or the master key was rotated.
+func (s *Store) dataKey(ctx context.Context, tenant string) (DataKey, error) {
+	now := s.clock()
+
+	s.mu.Lock()
+	cached, ok := s.dataKeys[tenant]
+	s.mu.Unlock()
+
+	if ok && now.Before(cached.expiresAt) && cached.key.MasterKeyID == s.keys.CurrentKeyID() {
+		return cached.key, nil
+	}
+
+	key, err := s.keys.GenerateDataKey(ctx, tenant)
+	if err != nil {
+		return DataKey{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.dataKeys[tenant] = cachedDataKey{key: key, expiresAt: now.Add(s.dataKeyTTL)}
+	s.remember(unwrappedKey(key.MasterKeyID, tenant, key.Wrapped), key.Plaintext)
+
+	return key, nil
+}
+
+// unwrap returns the plaintext of the data key of the envelope, asking the
+// key provider only for keys that were not unwrapped before.
+func (s *Store) unwrap(ctx context.Context, sealed envelope) ([]byte, error) {
+	cacheKey := unwrappedKey(sealed.masterKeyID, sealed.tenant, sealed.wrappedKey)
+
+	s.mu.Lock()
+	key, ok := s.unwrapped[cacheKey]
+	s.mu.Unlock()
+
+	if ok {
+		return key, nil
+	}
+
+	key, err := s.keys.UnwrapDataKey(ctx, sealed.masterKeyID, sealed.tenant, sealed.wrappedKey)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.remember(cacheKey, key)
+
+	return key, nil
+}
+
+// remember keeps an unwrapped data key, s.mu must be held. The keys are
+// forgotten all at once when there are too many.
+func (s *Store) remember(cacheKey string, key []byte) {
+	if len(s.unwrapped) >= maxUnwrappedKeys {
+		clear(s.unwrapped)
+	}
+
+	s.unwrapped[cacheKey] = key
+}
+
+func unwrappedKey(masterKeyID, tenant string, wrapped []byte) string {
+	return masterKeyID + "\x00" + tenant + "\x00" + string(wrapped)
+}
+
+func tenantOf(eventID kbs.EventID) string {
+	if eventID == "" {
+		return defaultTenant
+	}
+
+	return eventID.String()
+}
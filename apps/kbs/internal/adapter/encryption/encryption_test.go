@@ -0,0 +1,409 @@
+package encryption_test
+
+import (
+	"context"
+	"encoding/base64"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/adapter/encryption"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/kbs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncryptsContentAtRest(t *testing.T) {
+	// Given
+	ctx := context.TODO()
+	store := newMemoryStore()
+	encryptedStore := encryption.New(encryption.Setup{
+		Storer: store,
+		Keys:   newKeys(t, "key-1"),
+		Logger: newDummyLogger(),
+	})
+	newKB := kbs.KB{ID: "kb-1", EventID: "event-1", Content: "the root password is hunter2"}
+
+	// When
+	err := encryptedStore.Save(ctx, newKB)
+	require.NoError(t, err)
+	kb, err := encryptedStore.QueryByID(ctx, "kb-1")
+	require.NoError(t, err)
+	result, err := encryptedStore.Query(ctx, kbs.QueryFilter{})
+	require.NoError(t, err)
+
+	// Then
+	stored := store.kbs["kb-1"].Content
+	assert.NotContains(t, stored, "hunter2")
+	assert.Equal(t, newKB.Content, kb.Content)
+	require.Len(t, result.KBs, 1)
+	assert.Equal(t, newKB.Content, result.KBs[0].Content)
+}
+
+func TestCompressesContentBeforeEncryption(t *testing.T) {
+	// Given
+	ctx := context.TODO()
+	store := newMemoryStore()
+	keys := newKeys(t, "key-1")
+	content := strings.Repeat("restart the primary, then the replicas. ", 500)
+	uncompressed := encryption.New(encryption.Setup{Storer: store, Keys: keys, Logger: newDummyLogger()})
+	compressing := encryption.New(encryption.Setup{Storer: store, Keys: keys, Logger: newDummyLogger(), CompressAbove: 1024})
+	require.NoError(t, uncompressed.Save(ctx, kbs.KB{ID: "kb-1", Content: content}))
+
+	// When
+	err := compressing.Save(ctx, kbs.KB{ID: "kb-2", Content: content})
+	require.NoError(t, err)
+	compressed, errCompressed := uncompressed.QueryByID(ctx, "kb-2")
+	sealed, errSealed := compressing.QueryByID(ctx, "kb-1")
+
+	// Then
+	require.NoError(t, errCompressed)
+	require.NoError(t, errSealed)
+	assert.Less(t, len(store.kbs["kb-2"].Content), len(content)/10)
+	assert.Equal(t, content, compressed.Content)
+	assert.Equal(t, content, sealed.Content)
+}
+
+func TestReadsContentStoredBeforeEncryption(t *testing.T) {
+	// Given
+	ctx := context.TODO()
+	store := newMemoryStore()
+	store.kbs["kb-1"] = kbs.KB{ID: "kb-1", Content: "plain content"}
+	encryptedStore := encryption.New(encryption.Setup{
+		Storer: store,
+		Keys:   newKeys(t, "key-1"),
+		Logger: newDummyLogger(),
+	})
+
+	// When
+	kb, err := encryptedStore.QueryByID(ctx, "kb-1")
+
+	// Then
+	require.NoError(t, err)
+	assert.Equal(t, "plain content", kb.Content)
+}
+
+func TestContentCannotBeMovedToAnotherKB(t *testing.T) {
+	// Given
+	ctx := context.TODO()
+	store := newMemoryStore()
+	encryptedStore := encryption.New(encryption.Setup{
+		Storer: store,
+		Keys:   newKeys(t, "key-1"),
+		Logger: newDummyLogger(),
+	})
+	require.NoError(t, encryptedStore.Save(ctx, kbs.KB{ID: "kb-1", Content: "secret"}))
+	store.kbs["kb-2"] = kbs.KB{ID: "kb-2", Content: store.kbs["kb-1"].Content}
+
+	// When
+	kb, err := encryptedStore.QueryByID(ctx, "kb-2")
+
+	// Then
+	assert.Error(t, err)
+	assert.Nil(t, kb)
+}
+
+func TestDataKeysAreBoundToTheirTenant(t *testing.T) {
+	// Given
+	ctx := context.TODO()
+	keys := newKeys(t, "key-1")
+	dataKey, err := keys.GenerateDataKey(ctx, "event-1")
+	require.NoError(t, err)
+
+	// When
+	sameTenant, sameTenantErr := keys.UnwrapDataKey(ctx, "key-1", "event-1", dataKey.Wrapped)
+	_, otherTenantErr := keys.UnwrapDataKey(ctx, "key-1", "event-2", dataKey.Wrapped)
+
+	// Then
+	assert.NoError(t, sameTenantErr)
+	assert.Equal(t, dataKey.Plaintext, sameTenant)
+	assert.Error(t, otherTenantErr)
+}
+
+func TestReencryptsWithTheCurrentMasterKey(t *testing.T) {
+	// Given
+	ctx := context.TODO()
+	store := newMemoryStore()
+	oldStore := encryption.New(encryption.Setup{
+		Storer: store,
+		Keys:   newKeys(t, "key-1"),
+		Logger: newDummyLogger(),
+	})
+	require.NoError(t, oldStore.Save(ctx, kbs.KB{ID: "kb-1", EventID: "event-1", Content: "rotated", UpdateDate: 42}))
+	store.kbs["kb-2"] = kbs.KB{ID: "kb-2", Content: "legacy", UpdateDate: 43}
+
+	rotatedKeys := newKeys(t, "key-1", "key-2")
+	rotatedStore := encryption.New(encryption.Setup{
+		Storer: store,
+		Keys:   rotatedKeys,
+		Logger: newDummyLogger(),
+	})
+
+	// When
+	report, err := rotatedStore.Reencrypt(ctx, store)
+	require.NoError(t, err)
+	again, err := rotatedStore.Reencrypt(ctx, store)
+	require.NoError(t, err)
+
+	// Then
+	assert.Equal(t, encryption.ReencryptReport{KBsRead: 2, KBsEncrypted: 1, KBsRotated: 1}, report)
+	assert.Equal(t, encryption.ReencryptReport{KBsRead: 2}, again)
+	assert.Equal(t, int64(42), store.kbs["kb-1"].UpdateDate)
+	assert.Equal(t, int64(43), store.kbs["kb-2"].UpdateDate)
+
+	// the old master key can be retired.
+	newStore := encryption.New(encryption.Setup{
+		Storer: store,
+		Keys:   newKeys(t, "key-2"),
+		Logger: newDummyLogger(),
+	})
+	for id, want := range map[kbs.KBID]string{"kb-1": "rotated", "kb-2": "legacy"} {
+		kb, err := newStore.QueryByID(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, want, kb.Content)
+		assert.NotContains(t, store.kbs[id].Content, want)
+	}
+}
+
+func TestReencryptSkipsKBsUpdatedAfterTheyWereRead(t *testing.T) {
+	// Given
+	ctx := context.TODO()
+	store := newMemoryStore()
+	store.kbs["kb-1"] = kbs.KB{ID: "kb-1", Content: "legacy", UpdateDate: 42}
+
+	encryptedStore := encryption.New(encryption.Setup{
+		Storer: store,
+		Keys:   newKeys(t, "key-1"),
+		Logger: newDummyLogger(),
+	})
+
+	// the kb is updated between the scan and the write back.
+	scanner := scanFunc(func(ctx context.Context, fn func(kb kbs.KB) error) error {
+		read := store.kbs["kb-1"]
+
+		err := encryptedStore.Update(ctx, kbs.UpdateKB{ID: "kb-1", Content: "edited", UpdateDate: 50})
+		if err != nil {
+			return err
+		}
+
+		return fn(read)
+	})
+
+	// When
+	report, err := encryptedStore.Reencrypt(ctx, scanner)
+
+	// Then
+	require.NoError(t, err)
+	assert.Equal(t, encryption.ReencryptReport{KBsRead: 1, KBsSkipped: 1}, report)
+	kb, err := encryptedStore.QueryByID(ctx, "kb-1")
+	require.NoError(t, err)
+	assert.Equal(t, "edited", kb.Content)
+	assert.Equal(t, int64(50), kb.UpdateDate)
+}
+
+func TestParseKeyFile(t *testing.T) {
+	cases := map[string]struct {
+		content   string
+		currentID string
+		wantErr   bool
+	}{
+		"last key is the current one": {
+			content:   "# rotated on 2026-10-01\nkey-1:" + testKey("key-1") + "\n\nkey-2:" + testKey("key-2") + "\n",
+			currentID: "key-2",
+		},
+		"no keys": {
+			content: "# nothing here\n",
+			wantErr: true,
+		},
+		"missing id": {
+			content: testKey("key-1"),
+			wantErr: true,
+		},
+		"short key": {
+			content: "key-1:" + base64.StdEncoding.EncodeToString([]byte("short")),
+			wantErr: true,
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			// When
+			keys, err := encryption.ParseKeyFile([]byte(c.content))
+
+			// Then
+			if c.wantErr {
+				assert.Error(t, err)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, c.currentID, keys.CurrentKeyID())
+		})
+	}
+}
+
+func TestKMSKeyProviderBindsKeysToTheirTenant(t *testing.T) {
+	// Given
+	ctx := context.TODO()
+	client := &fakeKMS{}
+	keys, err := encryption.NewKMSKeyProvider(ctx, encryption.KMSSetup{Client: client, KeyID: "alias/kbs"})
+	require.NoError(t, err)
+
+	// When
+	dataKey, err := keys.GenerateDataKey(ctx, "event-1")
+	require.NoError(t, err)
+	plaintext, err := keys.UnwrapDataKey(ctx, dataKey.MasterKeyID, "event-1", dataKey.Wrapped)
+	require.NoError(t, err)
+
+	// Then
+	assert.Equal(t, "alias/kbs", dataKey.MasterKeyID)
+	assert.Equal(t, dataKey.Plaintext, plaintext)
+	assert.Equal(t, map[string]string{"tenant": "event-1"}, client.generateContext)
+	assert.Equal(t, map[string]string{"tenant": "event-1"}, client.decryptContext)
+}
+
+// newKeys returns a local key provider with the given keys, the last one
+// is the current key.
+func newKeys(t *testing.T, ids ...string) *encryption.LocalKeyProvider {
+	t.Helper()
+
+	var content strings.Builder
+
+	for _, id := range ids {
+		content.WriteString(id + ":" + testKey(id) + "\n")
+	}
+
+	keys, err := encryption.ParseKeyFile([]byte(content.String()))
+	require.NoError(t, err)
+
+	return keys
+}
+
+// testKey returns a 32 bytes key derived from the id, so the same id is
+// always the same key.
+func testKey(id string) string {
+	key := make([]byte, 32)
+	copy(key, id)
+
+	return base64.StdEncoding.EncodeToString(key)
+}
+
+// memoryStore keeps the kbs as they are stored.
+type memoryStore struct {
+	mu  sync.Mutex
+	kbs map[kbs.KBID]kbs.KB
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{kbs: make(map[kbs.KBID]kbs.KB)}
+}
+
+func (m *memoryStore) Save(ctx context.Context, newKB kbs.KB) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.kbs[newKB.ID] = newKB
+
+	return nil
+}
+
+func (m *memoryStore) Update(ctx context.Context, kb kbs.UpdateKB) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	current := m.kbs[kb.ID]
+	current.Content = kb.Content
+	current.EventID = kb.EventID
+	current.UpdateDate = kb.UpdateDate
+	m.kbs[kb.ID] = current
+
+	return nil
+}
+
+func (m *memoryStore) Delete(ctx context.Context, kb kbs.KB) error {
+	return nil
+}
+
+func (m *memoryStore) ChangeState(ctx context.Context, change kbs.StateChange) error {
+	return nil
+}
+
+func (m *memoryStore) Query(ctx context.Context, filter kbs.QueryFilter) (kbs.SearchKBsResult, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var result kbs.SearchKBsResult
+
+	for _, kb := range m.kbs {
+		result.KBs = append(result.KBs, kb)
+	}
+
+	result.Total = len(result.KBs)
+
+	return result, nil
+}
+
+func (m *memoryStore) QueryByID(ctx context.Context, id kbs.KBID) (*kbs.KB, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	kb, ok := m.kbs[id]
+	if !ok {
+		return nil, nil
+	}
+
+	return &kb, nil
+}
+
+func (m *memoryStore) ScanKBs(ctx context.Context, fn func(kb kbs.KB) error) error {
+	m.mu.Lock()
+	scanned := make([]kbs.KB, 0, len(m.kbs))
+	for _, kb := range m.kbs {
+		scanned = append(scanned, kb)
+	}
+	m.mu.Unlock()
+
+	for _, kb := range scanned {
+		err := fn(kb)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// scanFunc scans kbs with a function.
+type scanFunc func(ctx context.Context, fn func(kb kbs.KB) error) error
+
+func (s scanFunc) ScanKBs(ctx context.Context, fn func(kb kbs.KB) error) error {
+	return s(ctx, fn)
+}
+
+// fakeKMS "wraps" data keys by keeping them, it records the encryption
+// context of the calls.
+type fakeKMS struct {
+	generateContext map[string]string
+	decryptContext  map[string]string
+}
+
+func (f *fakeKMS) GenerateDataKey(ctx context.Context, params *kms.GenerateDataKeyInput, optFns ...func(*kms.Options)) (*kms.GenerateDataKeyOutput, error) {
+	f.generateContext = params.EncryptionContext
+	plaintext := []byte(strings.Repeat("k", 32))
+
+	return &kms.GenerateDataKeyOutput{Plaintext: plaintext, CiphertextBlob: append([]byte("wrapped:"), plaintext...)}, nil
+}
+
+func (f *fakeKMS) Decrypt(ctx context.Context, params *kms.DecryptInput, optFns ...func(*kms.Options)) (*kms.DecryptOutput, error) {
+	f.decryptContext = params.EncryptionContext
+
+	return &kms.DecryptOutput{Plaintext: []byte(strings.TrimPrefix(string(params.CiphertextBlob), "wrapped:"))}, nil
+}
+
+func newDummyLogger() *slog.Logger {
+	return slog.New(slog.NewJSONHandler(os.Stdout, nil))
+}
@@ -0,0 +1,115 @@
+package encryption
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+)
+
+// envelopePrefix starts every encrypted content, content without it was
+// stored before encryption was enabled and is read as it is.
+const envelopePrefix = "kbenc:1:"
+
+// envelopeSeparator separates the parts of an envelope, it is not part of
+// the base64 url alphabet.
+const envelopeSeparator = "."
+
+var errInvalidEnvelope = errors.New("invalid encrypted content")
+
+// envelope is encrypted data together with what is needed to decrypt it:
+// the data key that encrypted it, wrapped, and who that key belongs to.
+type envelope struct {
+	tenant      string
+	masterKeyID string
+	wrappedKey  []byte
+	sealed      []byte
+	// encoding is how the plaintext was encoded before it was sealed,
+	// empty if it was sealed as it is.
+	encoding string
+}
+
+// isEnvelope returns true if the value was encrypted.
+func isEnvelope(value string) bool {
+	return strings.HasPrefix(value, envelopePrefix)
+}
+
+// sealEnvelope encrypts the plaintext with the data key, binding it to the
+// given additional data, e.g. the id of the kb it belongs to.
+func sealEnvelope(tenant string, key DataKey, plaintext, additionalData []byte, encoding string) (envelope, error) {
+	sealed, err := seal(key.Plaintext, plaintext, additionalData)
+	if err != nil {
+		return envelope{}, err
+	}
+
+	return envelope{
+		tenant:      tenant,
+		masterKeyID: key.MasterKeyID,
+		wrappedKey:  key.Wrapped,
+		sealed:      sealed,
+		encoding:    encoding,
+	}, nil
+}
+
+// open decrypts the envelope with the plaintext of its data key.
+func (e envelope) open(dataKey, additionalData []byte) ([]byte, error) {
+	return open(dataKey, e.sealed, additionalData)
+}
+
+// String encodes the envelope so it can be stored as text. The encoding is
+// only added if there is one, so envelopes of plaintext sealed as it is
+// keep their original format.
+func (e envelope) String() string {
+	parts := []string{
+		encode([]byte(e.tenant)),
+		encode([]byte(e.masterKeyID)),
+		encode(e.wrappedKey),
+		encode(e.sealed),
+	}
+
+	if e.encoding != "" {
+		parts = append(parts, encode([]byte(e.encoding)))
+	}
+
+	return envelopePrefix + strings.Join(parts, envelopeSeparator)
+}
+
+// parseEnvelope decodes an envelope encoded by String.
+func parseEnvelope(value string) (envelope, error) {
+	encoded, ok := strings.CutPrefix(value, envelopePrefix)
+	if !ok {
+		return envelope{}, errInvalidEnvelope
+	}
+
+	parts := strings.Split(encoded, envelopeSeparator)
+	if len(parts) != 4 && len(parts) != 5 {
+		return envelope{}, errInvalidEnvelope
+	}
+
+	decoded := make([][]byte, len(parts))
+
+	for i, part := range parts {
+		value, err := base64.RawURLEncoding.DecodeString(part)
+		if err != nil {
+			return envelope{}, errInvalidEnvelope
+		}
+
+		decoded[i] = value
+	}
+
+	sealed := envelope{
+		tenant:      string(decoded[0]),
+		masterKeyID: string(decoded[1]),
+		wrappedKey:  decoded[2],
+		sealed:      decoded[3],
+	}
+
+	if len(decoded) == 5 {
+		sealed.encoding = string(decoded[4])
+	}
+
+	return sealed, nil
+}
+
+func encode(value []byte) string {
+	return base64.RawURLEncoding.EncodeToString(value)
+}
@@ -0,0 +1,179 @@
+package encryption
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// dataKeySize is the size of the data keys, they are AES-256 keys.
+const dataKeySize = 32
+
+// DataKey is a key that encrypts content. Wrapped is its copy encrypted by
+// the master key with the given id, the only copy that is stored.
+type DataKey struct {
+	MasterKeyID string
+	Plaintext   []byte
+	Wrapped     []byte
+}
+
+// KeyProvider keeps the master keys that wrap the data keys. Wrapped data
+// keys are bound to their tenant, they cannot be unwrapped for another one.
+type KeyProvider interface {
+	// GenerateDataKey returns a new data key for the tenant wrapped by the
+	// current master key.
+	GenerateDataKey(ctx context.Context, tenant string) (DataKey, error)
+	// UnwrapDataKey returns the plaintext of a data key of the tenant
+	// wrapped by the given master key.
+	UnwrapDataKey(ctx context.Context, masterKeyID, tenant string, wrapped []byte) ([]byte, error)
+	// CurrentKeyID returns the id of the master key new data keys are
+	// wrapped with.
+	CurrentKeyID() string
+}
+
+var (
+	errInvalidKeyFile   = errors.New("invalid key file")
+	errUnknownMasterKey = errors.New("unknown master key")
+	errUnwrappingKey    = errors.New("unable to unwrap data key")
+)
+
+// LocalKeyProvider wraps data keys with master keys read from a file.
+type LocalKeyProvider struct {
+	keys    map[string][]byte
+	current string
+}
+
+// NewLocalKeyProvider reads the master keys from the given file, see
+// ParseKeyFile.
+func NewLocalKeyProvider(path string) (*LocalKeyProvider, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read key file: %w", err)
+	}
+
+	return ParseKeyFile(content)
+}
+
+// ParseKeyFile reads master keys from lines that look like id:key, where
+// key is a base64 encoded 32 bytes key. The last key is the current one,
+// so keys are rotated by adding a new line. Empty lines and lines that
+// start with # are ignored.
+func ParseKeyFile(content []byte) (*LocalKeyProvider, error) {
+	provider := LocalKeyProvider{
+		keys: make(map[string][]byte),
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		id, encoded, ok := strings.Cut(line, ":")
+		if !ok || id == "" {
+			return nil, fmt.Errorf("%w: line must look like id:key", errInvalidKeyFile)
+		}
+
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil || len(key) != dataKeySize {
+			return nil, fmt.Errorf("%w: key %q must be %d base64 encoded bytes", errInvalidKeyFile, id, dataKeySize)
+		}
+
+		provider.keys[id] = key
+		provider.current = id
+	}
+
+	if provider.current == "" {
+		return nil, fmt.Errorf("%w: there are no keys", errInvalidKeyFile)
+	}
+
+	return &provider, nil
+}
+
+func (l *LocalKeyProvider) GenerateDataKey(ctx context.Context, tenant string) (DataKey, error) {
+	plaintext := make([]byte, dataKeySize)
+
+	_, err := io.ReadFull(rand.Reader, plaintext)
+	if err != nil {
+		return DataKey{}, err
+	}
+
+	wrapped, err := seal(l.keys[l.current], plaintext, []byte(tenant))
+	if err != nil {
+		return DataKey{}, err
+	}
+
+	return DataKey{MasterKeyID: l.current, Plaintext: plaintext, Wrapped: wrapped}, nil
+}
+
+func (l *LocalKeyProvider) UnwrapDataKey(ctx context.Context, masterKeyID, tenant string, wrapped []byte) ([]byte, error) {
+	key, ok := l.keys[masterKeyID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", errUnknownMasterKey, masterKeyID)
+	}
+
+	plaintext, err := open(key, wrapped, []byte(tenant))
+	if err != nil {
+		return nil, errUnwrappingKey
+	}
+
+	return plaintext, nil
+}
+
+func (l *LocalKeyProvider) CurrentKeyID() string {
+	return l.current
+}
+
+// seal encrypts the plaintext with AES-GCM, the nonce is prepended to the
+// ciphertext. The additional data must be given again to open it.
+func seal(key, plaintext, additionalData []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+
+	_, err = io.ReadFull(rand.Reader, nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	return aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+// open decrypts what seal encrypted.
+func open(key, sealed, additionalData []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("sealed data is too short")
+	}
+
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+
+	return aead.Open(nil, nonce, ciphertext, additionalData)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,110 @@
+package encryption
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/aws/aws-sdk-go-v2/service/kms/types"
+)
+
+// tenantContext is the key of the tenant in the encryption context of the
+// data keys, kms only unwraps them if the same context is given.
+const tenantContext = "tenant"
+
+// KMSClient contains the operations of aws kms the provider uses, any
+// service with the same api, like localstack, works.
+type KMSClient interface {
+	GenerateDataKey(ctx context.Context, params *kms.GenerateDataKeyInput, optFns ...func(*kms.Options)) (*kms.GenerateDataKeyOutput, error)
+	Decrypt(ctx context.Context, params *kms.DecryptInput, optFns ...func(*kms.Options)) (*kms.DecryptOutput, error)
+}
+
+// KMSSetup contains the settings of the kms key provider.
+type KMSSetup struct {
+	// Client is created from Region and Endpoint if it is nil.
+	Client   KMSClient
+	Region   string
+	Endpoint string
+	// KeyID is the id, arn or alias of the master key new data keys are
+	// wrapped with.
+	KeyID string
+}
+
+// KMSKeyProvider generates and unwraps data keys with kms, master keys
+// never leave it. Master keys are rotated by kms, or by changing KeyID.
+type KMSKeyProvider struct {
+	client KMSClient
+	keyID  string
+}
+
+// NewKMSKeyProvider creates a key provider that uses kms.
+func NewKMSKeyProvider(ctx context.Context, setup KMSSetup) (*KMSKeyProvider, error) {
+	provider := KMSKeyProvider{
+		client: setup.Client,
+		keyID:  setup.KeyID,
+	}
+
+	if provider.client != nil {
+		return &provider, nil
+	}
+
+	var optFns []func(*config.LoadOptions) error
+
+	if setup.Region != "" {
+		optFns = append(optFns, config.WithRegion(setup.Region))
+	}
+
+	if setup.Endpoint != "" {
+		optFns = append(optFns, config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider("d", "d", "")))
+	}
+
+	awsConfig, err := config.LoadDefaultConfig(ctx, optFns...)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load aws config: %w", err)
+	}
+
+	provider.client = kms.NewFromConfig(awsConfig, func(o *kms.Options) {
+		if setup.Endpoint != "" {
+			o.BaseEndpoint = aws.String(setup.Endpoint)
+		}
+	})
+
+	return &provider, nil
+}
+
+func (k *KMSKeyProvider) GenerateDataKey(ctx context.Context, tenant string) (DataKey, error) {
+	output, err := k.client.GenerateDataKey(ctx, &kms.GenerateDataKeyInput{
+		KeyId:             aws.String(k.keyID),
+		KeySpec:           types.DataKeySpecAes256,
+		EncryptionContext: map[string]string{tenantContext: tenant},
+	})
+	if err != nil {
+		return DataKey{}, err
+	}
+
+	return DataKey{
+		MasterKeyID: k.keyID,
+		Plaintext:   output.Plaintext,
+		Wrapped:     output.CiphertextBlob,
+	}, nil
+}
+
+func (k *KMSKeyProvider) UnwrapDataKey(ctx context.Context, masterKeyID, tenant string, wrapped []byte) ([]byte, error) {
+	output, err := k.client.Decrypt(ctx, &kms.DecryptInput{
+		KeyId:             aws.String(masterKeyID),
+		CiphertextBlob:    wrapped,
+		EncryptionContext: map[string]string{tenantContext: tenant},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errUnwrappingKey, err)
+	}
+
+	return output.Plaintext, nil
+}
+
+func (k *KMSKeyProvider) CurrentKeyID() string {
+	return k.keyID
+}
@@ -0,0 +1,45 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/adapter/web"
+)
+
+// failer is implemented by endpoint results that carry their own error.
+type failer interface {
+	Failed() bool
+}
+
+// Endpoint records the duration and outcome of the calls to an endpoint.
+type Endpoint struct {
+	name     string
+	endpoint web.Endpoint
+	metrics  *Metrics
+}
+
+// InstrumentEndpoint returns the given endpoint recording its calls with
+// the given name.
+func (m *Metrics) InstrumentEndpoint(name string, endpoint web.Endpoint) *Endpoint {
+	return &Endpoint{
+		name:     name,
+		endpoint: endpoint,
+		metrics:  m,
+	}
+}
+
+func (e *Endpoint) Do(ctx context.Context, request any) (any, error) {
+	started := time.Now()
+
+	response, err := e.endpoint.Do(ctx, request)
+
+	failed := err != nil
+	if result, ok := response.(failer); ok && result.Failed() {
+		failed = true
+	}
+
+	e.metrics.observeEndpoint(e.name, started, failed)
+
+	return response, err
+}
@@ -0,0 +1,54 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// unknownRoute labels requests that didn't match any route, so paths
+// don't become labels.
+const unknownRoute = "unknown"
+
+// statusRecorder keeps the status code written by the handler.
+type statusRecorder struct {
+	http.ResponseWriter
+	code int
+}
+
+// HTTPMiddleware records the rate, errors and duration of the requests
+// by route template.
+func (m *Metrics) HTTPMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w, code: http.StatusOK}
+
+		next.ServeHTTP(recorder, r)
+
+		labels := []string{routeOf(r), r.Method, strconv.Itoa(recorder.code)}
+
+		m.httpRequests.WithLabelValues(labels...).Inc()
+		m.httpDuration.WithLabelValues(labels...).Observe(time.Since(started).Seconds())
+	})
+}
+
+func (s *statusRecorder) WriteHeader(code int) {
+	s.code = code
+	s.ResponseWriter.WriteHeader(code)
+}
+
+func routeOf(r *http.Request) string {
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return unknownRoute
+	}
+
+	template, err := route.GetPathTemplate()
+	if err != nil {
+		return unknownRoute
+	}
+
+	return template
+}
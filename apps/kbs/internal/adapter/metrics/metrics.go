@@ -0,0 +1,137 @@
+// Package metrics exposes the prometheus metrics of the service.
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "kbs"
+
+// metric outcomes.
+const (
+	successOutcome = "success"
+	errorOutcome   = "error"
+)
+
+// Setup contains metrics settings.
+type Setup struct {
+	Version    string
+	CommitHash string
+	BuildDate  string
+}
+
+// Metrics contains the collectors of the service.
+type Metrics struct {
+	registry         *prometheus.Registry
+	httpRequests     *prometheus.CounterVec
+	httpDuration     *prometheus.HistogramVec
+	endpointDuration *prometheus.HistogramVec
+	storeDuration    *prometheus.HistogramVec
+	consumedCapacity *prometheus.CounterVec
+	cacheEvents      *prometheus.CounterVec
+}
+
+// New creates the collectors and registers them with the build info of
+// the service and the go runtime collectors.
+func New(setup Setup) *Metrics {
+	newMetrics := Metrics{
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "requests_total",
+			Help:      "Number of http requests by route, method and status code.",
+		}, []string{"route", "method", "code"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "request_duration_seconds",
+			Help:      "Duration of http requests by route, method and status code.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"route", "method", "code"}),
+		endpointDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "endpoint",
+			Name:      "duration_seconds",
+			Help:      "Duration of endpoint calls by endpoint and outcome.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"endpoint", "outcome"}),
+		storeDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "store",
+			Name:      "duration_seconds",
+			Help:      "Duration of store calls by method and outcome.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "outcome"}),
+		consumedCapacity: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "dynamodb",
+			Name:      "consumed_capacity_units_total",
+			Help:      "Capacity units consumed in dynamodb by table and operation.",
+		}, []string{"table", "operation"}),
+		cacheEvents: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "cache",
+			Name:      "events_total",
+			Help:      "Hits, misses and evictions of the kbs caches.",
+		}, []string{"cache", "event"}),
+	}
+
+	buildInfo := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "build_info",
+		Help:      "Build information of the running service.",
+	}, []string{"version", "commit", "build_date"})
+	buildInfo.WithLabelValues(setup.Version, setup.CommitHash, setup.BuildDate).Set(1)
+
+	newMetrics.registry.MustRegister(
+		newMetrics.httpRequests,
+		newMetrics.httpDuration,
+		newMetrics.endpointDuration,
+		newMetrics.storeDuration,
+		newMetrics.consumedCapacity,
+		newMetrics.cacheEvents,
+		buildInfo,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+
+	return &newMetrics
+}
+
+// Handler returns the handler that exposes the metrics.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// RecordConsumedCapacity adds the capacity units an operation consumed in
+// a dynamodb table.
+func (m *Metrics) RecordConsumedCapacity(table, operation string, units float64) {
+	m.consumedCapacity.WithLabelValues(table, operation).Add(units)
+}
+
+// RecordCacheEvent counts a hit, miss or eviction of a cache.
+func (m *Metrics) RecordCacheEvent(cache, event string) {
+	m.cacheEvents.WithLabelValues(cache, event).Inc()
+}
+
+func (m *Metrics) observeEndpoint(name string, started time.Time, failed bool) {
+	m.endpointDuration.WithLabelValues(name, outcome(failed)).Observe(time.Since(started).Seconds())
+}
+
+func (m *Metrics) observeStore(method string, started time.Time, err error) {
+	m.storeDuration.WithLabelValues(method, outcome(err != nil)).Observe(time.Since(started).Seconds())
+}
+
+func outcome(failed bool) string {
+	if failed {
+		return errorOutcome
+	}
+
+	return successOutcome
+}
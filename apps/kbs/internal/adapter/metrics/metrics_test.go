@@ -0,0 +1,70 @@
+package metrics_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/adapter/metrics"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/kbs"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricsAreExposed(t *testing.T) {
+	// Given
+	ctx := context.TODO()
+	newMetrics := metrics.New(metrics.Setup{Version: "1.2.3", CommitHash: "abc123", BuildDate: "2024-01-01"})
+
+	router := mux.NewRouter()
+	router.Use(newMetrics.HTTPMiddleware)
+	router.Methods(http.MethodGet).Path("/kbs/{id}").HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	router.Methods(http.MethodGet).Path("/metrics").Handler(newMetrics.Handler())
+
+	endpoint := newMetrics.InstrumentEndpoint("create_kb", endpointFunc(func(ctx context.Context, request any) (any, error) {
+		return kbs.CreateKBResult{Err: "unable to save kb in the repository"}, nil
+	}))
+	store := newMetrics.NewStore(failingStore{})
+
+	// When
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/kbs/kb-1", nil))
+	_, err := endpoint.Do(ctx, nil)
+	require.NoError(t, err)
+	_, err = store.QueryByID(ctx, "kb-1")
+	require.Error(t, err)
+	newMetrics.RecordConsumedCapacity("kbs", "GetItem", 0.5)
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	// Then
+	body, err := io.ReadAll(recorder.Body)
+	require.NoError(t, err)
+	got := string(body)
+	assert.Contains(t, got, `kbs_build_info{build_date="2024-01-01",commit="abc123",version="1.2.3"} 1`)
+	assert.Contains(t, got, `kbs_http_requests_total{code="404",method="GET",route="/kbs/{id}"} 1`)
+	assert.Contains(t, got, `kbs_endpoint_duration_seconds_count{endpoint="create_kb",outcome="error"} 1`)
+	assert.Contains(t, got, `kbs_store_duration_seconds_count{method="query_by_id",outcome="error"} 1`)
+	assert.Contains(t, got, `kbs_dynamodb_consumed_capacity_units_total{operation="GetItem",table="kbs"} 0.5`)
+}
+
+type endpointFunc func(ctx context.Context, request any) (any, error)
+
+func (f endpointFunc) Do(ctx context.Context, request any) (any, error) {
+	return f(ctx, request)
+}
+
+// failingStore fails every kb query.
+type failingStore struct {
+	kbs.Storer
+}
+
+func (f failingStore) QueryByID(ctx context.Context, id kbs.KBID) (*kbs.KB, error) {
+	return nil, errors.New("connection refused")
+}
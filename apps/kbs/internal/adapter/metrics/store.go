@@ -0,0 +1,76 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/kbs"
+)
+
+// Store records the latency and errors of every call to the kbs store.
+type Store struct {
+	storer  kbs.Storer
+	metrics *Metrics
+}
+
+// NewStore creates a store that records the calls made to the given one.
+func (m *Metrics) NewStore(storer kbs.Storer) *Store {
+	return &Store{
+		storer:  storer,
+		metrics: m,
+	}
+}
+
+func (s *Store) Save(ctx context.Context, newKB kbs.KB) error {
+	started := time.Now()
+
+	err := s.storer.Save(ctx, newKB)
+	s.metrics.observeStore("save", started, err)
+
+	return err
+}
+
+func (s *Store) Update(ctx context.Context, kb kbs.UpdateKB) error {
+	started := time.Now()
+
+	err := s.storer.Update(ctx, kb)
+	s.metrics.observeStore("update", started, err)
+
+	return err
+}
+
+func (s *Store) Delete(ctx context.Context, kb kbs.KB) error {
+	started := time.Now()
+
+	err := s.storer.Delete(ctx, kb)
+	s.metrics.observeStore("delete", started, err)
+
+	return err
+}
+
+func (s *Store) Query(ctx context.Context, filter kbs.QueryFilter) (kbs.SearchKBsResult, error) {
+	started := time.Now()
+
+	result, err := s.storer.Query(ctx, filter)
+	s.metrics.observeStore("query", started, err)
+
+	return result, err
+}
+
+func (s *Store) QueryByID(ctx context.Context, id kbs.KBID) (*kbs.KB, error) {
+	started := time.Now()
+
+	kb, err := s.storer.QueryByID(ctx, id)
+	s.metrics.observeStore("query_by_id", started, err)
+
+	return kb, err
+}
+
+func (s *Store) ChangeState(ctx context.Context, change kbs.StateChange) error {
+	started := time.Now()
+
+	err := s.storer.ChangeState(ctx, change)
+	s.metrics.observeStore("change_state", started, err)
+
+	return err
+}
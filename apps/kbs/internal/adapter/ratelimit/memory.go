@@ -0,0 +1,109 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// MemoryBackend is the name of the in-memory counter backend.
+const MemoryBackend = "memory"
+
+// sweepInterval is how often the buckets that are full again are removed.
+const sweepInterval = time.Minute
+
+// overflowKey is the bucket shared by the clients that don't fit in the
+// counter.
+const overflowKey = "overflow"
+
+// MemoryCounter keeps the buckets in memory, limits are enforced by each
+// instance of the service. At most maxBuckets clients have their own
+// bucket, once it is full new clients share the overflow bucket until the
+// sweep makes room.
+type MemoryCounter struct {
+	mu         sync.Mutex
+	buckets    map[string]*bucket
+	maxBuckets int
+	lastSweep  time.Time
+}
+
+type bucket struct {
+	tokens  float64
+	limit   Limit
+	updated time.Time
+}
+
+// NewMemoryCounter creates an empty in-memory counter that keeps at most
+// the given number of buckets.
+func NewMemoryCounter(maxBuckets int) *MemoryCounter {
+	return &MemoryCounter{
+		buckets:    make(map[string]*bucket),
+		maxBuckets: maxBuckets,
+	}
+}
+
+func (m *MemoryCounter) Take(ctx context.Context, key string, limit Limit, now time.Time) (Decision, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.sweep(now)
+
+	b, ok := m.buckets[key]
+	if !ok && len(m.buckets) >= m.maxBuckets {
+		key = overflowKey
+		b, ok = m.buckets[key]
+	}
+
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), limit: limit, updated: now}
+		m.buckets[key] = b
+	}
+
+	b.refill(limit, now)
+
+	decision := Decision{Allowed: b.tokens >= 1}
+	if decision.Allowed {
+		b.tokens--
+	} else {
+		decision.RetryAfter = secondsToDuration((1 - b.tokens) / limit.Rate)
+	}
+
+	decision.Remaining = int(math.Floor(b.tokens))
+	decision.Reset = secondsToDuration((float64(limit.Burst) - b.tokens) / limit.Rate)
+
+	return decision, nil
+}
+
+// refill adds the tokens earned since the last update, a bucket never has
+// more tokens than its burst.
+func (b *bucket) refill(limit Limit, now time.Time) {
+	elapsed := now.Sub(b.updated).Seconds()
+	if elapsed > 0 {
+		b.tokens = math.Min(float64(limit.Burst), b.tokens+elapsed*limit.Rate)
+		b.updated = now
+	}
+
+	b.limit = limit
+}
+
+// sweep removes the buckets that are full again, they are the same as a
+// new one, so memory doesn't grow with every client ever seen.
+func (m *MemoryCounter) sweep(now time.Time) {
+	if now.Sub(m.lastSweep) < sweepInterval {
+		return
+	}
+
+	m.lastSweep = now
+
+	for key, b := range m.buckets {
+		refillTime := (float64(b.limit.Burst) - b.tokens) / b.limit.Rate
+		if now.Sub(b.updated).Seconds() >= refillTime {
+			delete(m.buckets, key)
+		}
+	}
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(math.Ceil(seconds * float64(time.Second)))
+}
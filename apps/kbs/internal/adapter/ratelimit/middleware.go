@@ -0,0 +1,166 @@
+package ratelimit
+
+import (
+	"encoding/json"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/adapter/web"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/requests"
+)
+
+// rate limit response headers.
+const (
+	limitHeader      = "RateLimit-Limit"
+	remainingHeader  = "RateLimit-Remaining"
+	resetHeader      = "RateLimit-Reset"
+	retryAfterHeader = "Retry-After"
+)
+
+// Setup contains rate limiter settings.
+type Setup struct {
+	Counter Counter
+	// Limits are the limits of every client.
+	Limits Limits
+	// Quotas replace the limits of specific clients.
+	Quotas map[string]Limits
+	// Exempt contains the paths that are never limited, e.g. probes.
+	Exempt []string
+	Logger *slog.Logger
+	// Clock returns the current time, time.Now if nil.
+	Clock func() time.Time
+}
+
+// Limiter rejects the requests of clients that exceeded their limit.
+type Limiter struct {
+	counter Counter
+	limits  Limits
+	quotas  map[string]Limits
+	exempt  map[string]bool
+	logger  *slog.Logger
+	clock   func() time.Time
+}
+
+// New creates a limiter with the given settings.
+func New(setup Setup) *Limiter {
+	newLimiter := Limiter{
+		counter: setup.Counter,
+		limits:  setup.Limits,
+		quotas:  setup.Quotas,
+		exempt:  make(map[string]bool, len(setup.Exempt)),
+		logger:  setup.Logger,
+		clock:   setup.Clock,
+	}
+
+	for _, path := range setup.Exempt {
+		newLimiter.exempt[path] = true
+	}
+
+	if newLimiter.clock == nil {
+		newLimiter.clock = time.Now
+	}
+
+	return &newLimiter
+}
+
+// HTTPMiddleware takes a token from the bucket of the client for the class
+// of the route, reads or writes, and rejects the request with 429 if there
+// are no tokens left. The client is identified by its verified principal or
+// its ip address, so it must run after web.RequestMetadata. If the counter
+// fails the request is let through.
+func (l *Limiter) HTTPMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if l.exempt[r.URL.Path] {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		kind, client := clientOf(r)
+		class := classOf(r)
+		limit, ok := l.limitOf(client, class)
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		key := kind + ":" + client + ":" + string(class)
+
+		decision, err := l.counter.Take(r.Context(), key, limit, l.clock())
+		if err != nil {
+			requests.Logger(r.Context(), l.logger).Error("unable to check rate limit, request is let through",
+				slog.String("class", string(class)),
+				slog.String("error", err.Error()))
+
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set(limitHeader, strconv.Itoa(limit.Burst))
+		w.Header().Set(remainingHeader, strconv.Itoa(decision.Remaining))
+		w.Header().Set(resetHeader, strconv.Itoa(seconds(decision.Reset)))
+
+		if !decision.Allowed {
+			requests.Logger(r.Context(), l.logger).Warn("rate limit exceeded",
+				slog.String("client_kind", kind),
+				slog.String("class", string(class)))
+
+			w.Header().Set(retryAfterHeader, strconv.Itoa(max(seconds(decision.RetryAfter), 1)))
+			writeTooManyRequests(w)
+
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// limitOf returns the limit of the client for the given class, the quota
+// of the client if it has one.
+func (l *Limiter) limitOf(client string, class Class) (Limit, bool) {
+	if quota, ok := l.quotas[client][class]; ok {
+		return quota, true
+	}
+
+	limit, ok := l.limits[class]
+
+	return limit, ok && limit.Rate > 0
+}
+
+// clientOf returns the kind and id of the client that made the request. Only
+// the metadata verified by web.RequestMetadata is used, headers sent by the
+// client can't choose its bucket.
+func clientOf(r *http.Request) (string, string) {
+	metadata := requests.FromContext(r.Context())
+	if metadata.Principal != "" {
+		return "principal", metadata.Principal
+	}
+
+	return "ip", metadata.ClientIP
+}
+
+// classOf returns the class of the route, requests that don't change
+// anything are reads.
+func classOf(r *http.Request) Class {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return ReadClass
+	}
+
+	return WriteClass
+}
+
+func writeTooManyRequests(w http.ResponseWriter) {
+	content, _ := json.Marshal(web.ErrorResponse{KB: "too many requests, retry later"})
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusTooManyRequests)
+	w.Write(content)
+}
+
+// seconds rounds the duration up to whole seconds.
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,122 @@
+// Package ratelimit limits the rate of requests each client can make.
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Class groups routes that share a limit.
+type Class string
+
+// route classes.
+const (
+	ReadClass  Class = "read"
+	WriteClass Class = "write"
+)
+
+// Limit is a token bucket, clients can make Burst requests at once and the
+// bucket is refilled at Rate requests per second.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Limits contains the limit of every route class.
+type Limits map[Class]Limit
+
+// Decision is the result of taking a token from a bucket.
+type Decision struct {
+	Allowed bool
+	// Remaining is the number of requests the client can make right now.
+	Remaining int
+	// RetryAfter is how long the client must wait to make a request, it is
+	// zero if the request was allowed.
+	RetryAfter time.Duration
+	// Reset is how long it takes to refill the bucket completely.
+	Reset time.Duration
+}
+
+// Counter keeps the buckets of the clients. Implementations must be safe
+// for concurrent use, and shared by every instance of the service if the
+// limits are meant to be global, e.g. a redis backed counter.
+type Counter interface {
+	// Take takes a token from the bucket with the given key at the given
+	// time, the bucket is created full if it doesn't exist.
+	Take(ctx context.Context, key string, limit Limit, now time.Time) (Decision, error)
+}
+
+var errInvalidQuota = errors.New("invalid rate limit quota")
+
+// ParseQuotas parses the limits of specific clients, the quotas are
+// separated by commas and each one looks like client:class=rate/burst, e.g.
+// "importer:write=50/100,10.0.0.7:read=100/200". The client is a principal,
+// e.g. the one of an api key, or an ip address.
+func ParseQuotas(value string) (map[string]Limits, error) {
+	quotas := make(map[string]Limits)
+
+	for _, quota := range strings.Split(value, ",") {
+		quota = strings.TrimSpace(quota)
+		if quota == "" {
+			continue
+		}
+
+		target, limitValue, ok := cutLast(quota, "=")
+		if !ok {
+			return nil, fmt.Errorf("%w: %q", errInvalidQuota, quota)
+		}
+
+		client, classValue, ok := cutLast(target, ":")
+		class := Class(classValue)
+		if !ok || client == "" || (class != ReadClass && class != WriteClass) {
+			return nil, fmt.Errorf("%w: %q", errInvalidQuota, quota)
+		}
+
+		limit, err := parseLimit(limitValue)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q: %w", errInvalidQuota, quota, err)
+		}
+
+		if quotas[client] == nil {
+			quotas[client] = make(Limits)
+		}
+
+		quotas[client][class] = limit
+	}
+
+	return quotas, nil
+}
+
+// parseLimit parses a limit that looks like rate/burst.
+func parseLimit(value string) (Limit, error) {
+	rateValue, burstValue, ok := strings.Cut(value, "/")
+	if !ok {
+		return Limit{}, errors.New("limit must look like rate/burst")
+	}
+
+	rate, err := strconv.ParseFloat(rateValue, 64)
+	if err != nil || rate <= 0 {
+		return Limit{}, errors.New("rate must be a positive number")
+	}
+
+	burst, err := strconv.Atoi(burstValue)
+	if err != nil || burst < 1 {
+		return Limit{}, errors.New("burst must be a positive integer")
+	}
+
+	return Limit{Rate: rate, Burst: burst}, nil
+}
+
+// cutLast slices s around the last instance of sep.
+func cutLast(s, sep string) (string, string, bool) {
+	i := strings.LastIndex(s, sep)
+	if i < 0 {
+		return s, "", false
+	}
+
+	return s[:i], s[i+len(sep):], true
+}
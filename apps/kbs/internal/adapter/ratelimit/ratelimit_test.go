@@ -0,0 +1,206 @@
+package ratelimit_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/adapter/ratelimit"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/adapter/web"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMiddlewareLimitsWritesPerClient(t *testing.T) {
+	// Given
+	now := time.Unix(1700000000, 0)
+	handler := newHandler(ratelimit.Setup{
+		Counter: ratelimit.NewMemoryCounter(100),
+		Limits: ratelimit.Limits{
+			ratelimit.ReadClass:  {Rate: 10, Burst: 10},
+			ratelimit.WriteClass: {Rate: 0.5, Burst: 2},
+		},
+		Clock: func() time.Time { return now },
+	})
+
+	// When
+	first := serve(handler, http.MethodPost, "drila")
+	second := serve(handler, http.MethodPost, "drila")
+	rejected := serve(handler, http.MethodPost, "drila")
+	read := serve(handler, http.MethodGet, "drila")
+	otherUser := serve(handler, http.MethodPost, "mono")
+	now = now.Add(2 * time.Second)
+	afterRefill := serve(handler, http.MethodPost, "drila")
+
+	// Then
+	assert.Equal(t, http.StatusCreated, first.Code)
+	assert.Equal(t, "2", first.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "1", first.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, http.StatusCreated, second.Code)
+	assert.Equal(t, "0", second.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "4", second.Header().Get("RateLimit-Reset"))
+
+	assert.Equal(t, http.StatusTooManyRequests, rejected.Code)
+	assert.Equal(t, "2", rejected.Header().Get("Retry-After"))
+	assert.JSONEq(t, `{"kb": "too many requests, retry later"}`, rejected.Body.String())
+
+	assert.Equal(t, http.StatusCreated, read.Code)
+	assert.Equal(t, http.StatusCreated, otherUser.Code)
+	assert.Equal(t, http.StatusCreated, afterRefill.Code)
+}
+
+func TestMiddlewareUsesClientQuota(t *testing.T) {
+	// Given
+	quotas, err := ratelimit.ParseQuotas("importer:write=100/3, 10.0.0.7:read=1/1")
+	require.NoError(t, err)
+	handler := newHandler(ratelimit.Setup{
+		Counter: ratelimit.NewMemoryCounter(100),
+		Limits:  ratelimit.Limits{ratelimit.WriteClass: {Rate: 1, Burst: 1}},
+		Quotas:  quotas,
+	})
+
+	// When
+	var codes []int
+	for i := 0; i < 4; i++ {
+		codes = append(codes, serve(handler, http.MethodPut, "importer").Code)
+	}
+
+	// Then
+	assert.Equal(t, []int{http.StatusCreated, http.StatusCreated, http.StatusCreated, http.StatusTooManyRequests}, codes)
+	assert.Equal(t, ratelimit.Limit{Rate: 1, Burst: 1}, quotas["10.0.0.7"][ratelimit.ReadClass])
+}
+
+func TestMiddlewareIgnoresUnverifiedIdentity(t *testing.T) {
+	// Given
+	limiter := ratelimit.New(ratelimit.Setup{
+		Counter: ratelimit.NewMemoryCounter(100),
+		Limits:  ratelimit.Limits{ratelimit.WriteClass: {Rate: 0.1, Burst: 2}},
+		Logger:  slog.New(slog.NewJSONHandler(os.Stdout, nil)),
+	})
+	// no proxies are trusted, so the headers of the requests are ignored.
+	handler := web.RequestMetadata(web.MetadataSetup{})(limiter.HTTPMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	})))
+
+	// When
+	var codes []int
+	for _, client := range []string{"drila", "mono", "importer"} {
+		request := httptest.NewRequest(http.MethodPost, "/kbs", nil)
+		request.Header.Set(web.UserIDHeader, client)
+		request.Header.Set(web.APIKeyHeader, client)
+		request.Header.Set("X-Forwarded-For", "198.51.100."+strconv.Itoa(len(codes)))
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		codes = append(codes, recorder.Code)
+	}
+
+	// Then
+	assert.Equal(t, []int{http.StatusCreated, http.StatusCreated, http.StatusTooManyRequests}, codes)
+}
+
+func TestMemoryCounterSharesBucketWhenFull(t *testing.T) {
+	// Given
+	ctx := context.TODO()
+	now := time.Unix(1700000000, 0)
+	limit := ratelimit.Limit{Rate: 0.1, Burst: 1}
+	counter := ratelimit.NewMemoryCounter(1)
+
+	// When
+	first, errFirst := counter.Take(ctx, "drila", limit, now)
+	overflow, errOverflow := counter.Take(ctx, "mono", limit, now)
+	shared, errShared := counter.Take(ctx, "importer", limit, now)
+
+	// Then
+	require.NoError(t, errFirst)
+	require.NoError(t, errOverflow)
+	require.NoError(t, errShared)
+	assert.True(t, first.Allowed)
+	assert.True(t, overflow.Allowed)
+	assert.False(t, shared.Allowed)
+}
+
+func TestMiddlewareLetsRequestsThrough(t *testing.T) {
+	cases := map[string]struct {
+		setup ratelimit.Setup
+		path  string
+	}{
+		"exempt_path": {
+			setup: ratelimit.Setup{
+				Counter: ratelimit.NewMemoryCounter(100),
+				Limits:  ratelimit.Limits{ratelimit.ReadClass: {Rate: 1, Burst: 1}},
+				Exempt:  []string{"/healthz"},
+			},
+			path: "/healthz",
+		},
+		"counter_failure": {
+			setup: ratelimit.Setup{
+				Counter: failingCounter{},
+				Limits:  ratelimit.Limits{ratelimit.ReadClass: {Rate: 1, Burst: 1}},
+			},
+			path: "/kbs",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			// Given
+			handler := newHandler(tc.setup)
+
+			// When
+			var codes []int
+			for i := 0; i < 3; i++ {
+				request := httptest.NewRequest(http.MethodGet, tc.path, nil)
+				recorder := httptest.NewRecorder()
+				handler.ServeHTTP(recorder, request)
+				codes = append(codes, recorder.Code)
+			}
+
+			// Then
+			assert.Equal(t, []int{http.StatusCreated, http.StatusCreated, http.StatusCreated}, codes)
+		})
+	}
+}
+
+func TestParseInvalidQuotas(t *testing.T) {
+	for _, quota := range []string{"importer=1/1", "importer:delete=1/1", "importer:read=fast/1", "importer:read=1/0", "importer:read"} {
+		t.Run(quota, func(t *testing.T) {
+			_, err := ratelimit.ParseQuotas(quota)
+
+			assert.Error(t, err)
+		})
+	}
+}
+
+func newHandler(setup ratelimit.Setup) http.Handler {
+	setup.Logger = slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	limiter := ratelimit.New(setup)
+
+	// the user id header of the test requests comes from a trusted gateway.
+	metadata := web.MetadataSetup{TrustedProxies: []netip.Prefix{netip.MustParsePrefix("192.0.2.0/24")}}
+
+	return web.RequestMetadata(metadata)(limiter.HTTPMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	})))
+}
+
+func serve(handler http.Handler, method, userID string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(method, "/kbs", nil)
+	request.Header.Set(web.UserIDHeader, userID)
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+
+	return recorder
+}
+
+type failingCounter struct{}
+
+func (failingCounter) Take(ctx context.Context, key string, limit ratelimit.Limit, now time.Time) (ratelimit.Decision, error) {
+	return ratelimit.Decision{}, errors.New("connection refused")
+}
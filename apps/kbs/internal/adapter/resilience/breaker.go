@@ -0,0 +1,205 @@
+package resilience
+
+import (
+	"encoding/json"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/adapter/web"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/requests"
+)
+
+const retryAfterHeader = "Retry-After"
+
+// states of the circuit.
+const (
+	closed   = "closed"
+	open     = "open"
+	halfOpen = "half_open"
+)
+
+// outcomes of a call to the store.
+type outcome int
+
+const (
+	succeeded outcome = iota
+	failed
+	// ignored calls were abandoned by the caller.
+	ignored
+)
+
+// BreakerSetup contains the settings of the circuit breaker.
+type BreakerSetup struct {
+	// Failures is the number of consecutive failed calls that opens the circuit.
+	Failures int
+	// Cooldown is how long the circuit stays open before one call is let
+	// through to probe the store.
+	Cooldown time.Duration
+	// Exempt contains the paths the middleware never rejects, e.g. probes.
+	Exempt []string
+	Logger *slog.Logger
+	// Clock returns the current time, time.Now if nil.
+	Clock func() time.Time
+}
+
+// Breaker counts the consecutive failed calls to the store and rejects the
+// calls for a while once there are too many.
+type Breaker struct {
+	mu       sync.Mutex
+	failures int
+	cooldown time.Duration
+	exempt   map[string]bool
+	logger   *slog.Logger
+	clock    func() time.Time
+
+	state       string
+	consecutive int
+	openUntil   time.Time
+	// probing is true while the call that probes the store is in progress.
+	probing bool
+}
+
+// NewBreaker creates a closed breaker with the given settings.
+func NewBreaker(setup BreakerSetup) *Breaker {
+	newBreaker := Breaker{
+		failures: max(setup.Failures, 1),
+		cooldown: setup.Cooldown,
+		exempt:   make(map[string]bool, len(setup.Exempt)),
+		logger:   setup.Logger,
+		clock:    setup.Clock,
+		state:    closed,
+	}
+
+	for _, path := range setup.Exempt {
+		newBreaker.exempt[path] = true
+	}
+
+	if newBreaker.clock == nil {
+		newBreaker.clock = time.Now
+	}
+
+	return &newBreaker
+}
+
+// allow returns true if a call can be made. Once the cooldown is over only
+// one call at a time is let through until one of them succeeds.
+func (b *Breaker) allow() bool {
+	if b == nil {
+		return true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case closed:
+		return true
+	case open:
+		if b.clock().Before(b.openUntil) {
+			return false
+		}
+
+		b.state = halfOpen
+	}
+
+	if b.probing {
+		return false
+	}
+
+	b.probing = true
+
+	return true
+}
+
+// record updates the circuit with the outcome of a call that was allowed.
+func (b *Breaker) record(result outcome) {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == halfOpen {
+		b.probing = false
+	}
+
+	switch result {
+	case succeeded:
+		if b.state != closed {
+			b.logger.Info("kb store circuit is closed")
+		}
+
+		b.state = closed
+		b.consecutive = 0
+	case failed:
+		b.consecutive++
+
+		if b.state == halfOpen || b.consecutive >= b.failures {
+			b.trip()
+		}
+	}
+}
+
+// trip opens the circuit, b.mu must be held.
+func (b *Breaker) trip() {
+	if b.state != open {
+		b.logger.Warn("kb store circuit is open",
+			slog.Int("consecutive_failures", b.consecutive),
+			slog.Duration("cooldown", b.cooldown))
+	}
+
+	b.state = open
+	b.openUntil = b.clock().Add(b.cooldown)
+}
+
+// RetryAfter returns how long clients should wait before making requests,
+// zero if the circuit is closed.
+func (b *Breaker) RetryAfter() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch {
+	case b.state == open && b.clock().Before(b.openUntil):
+		return b.openUntil.Sub(b.clock())
+	case b.state == halfOpen && b.probing:
+		return time.Second
+	default:
+		return 0
+	}
+}
+
+// HTTPMiddleware rejects the requests with 503 while the circuit is open,
+// telling clients when to retry, instead of letting them wait for a store
+// that is failing.
+func (b *Breaker) HTTPMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if b.exempt[r.URL.Path] {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		retryAfter := b.RetryAfter()
+		if retryAfter <= 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		requests.Logger(r.Context(), b.logger).Warn("request rejected, kb store circuit is open")
+
+		w.Header().Set(retryAfterHeader, strconv.Itoa(max(int(math.Ceil(retryAfter.Seconds())), 1)))
+		writeServiceUnavailable(w)
+	})
+}
+
+func writeServiceUnavailable(w http.ResponseWriter) {
+	content, _ := json.Marshal(web.ErrorResponse{KB: "kb store is unavailable, retry later"})
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusServiceUnavailable)
+	w.Write(content)
+}
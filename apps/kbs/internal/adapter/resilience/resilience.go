@@ -0,0 +1,214 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math/rand"
+	"time"
+
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/kbs"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/requests"
+)
+
+// Policy defines how the calls of a kind are retried.
+type Policy struct {
+	// Attempts is the maximum number of calls, including the first one.
+	Attempts int
+	// BaseDelay is the wait before the first retry, it doubles on every
+	// retry up to MaxDelay. The actual wait is a random part of it.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// Timeout of each call, the deadline of the request is kept if it is
+	// sooner. Zero means no timeout.
+	Timeout time.Duration
+}
+
+// Setup contains the settings of the resilient store.
+type Setup struct {
+	Storer kbs.Storer
+	// Reads is the policy of Query and QueryByID.
+	Reads Policy
+	// Writes is the policy of the calls that change kbs.
+	Writes Policy
+	// Breaker stops the calls while the store is unhealthy, optional.
+	Breaker *Breaker
+	Logger  *slog.Logger
+	// Jitter returns a random number in [0, 1), rand.Float64 if nil.
+	Jitter func() float64
+}
+
+// Store retries the calls to the kbs store that fail for a reason that may
+// go away and fails fast while the breaker is open.
+type Store struct {
+	storer  kbs.Storer
+	reads   Policy
+	writes  Policy
+	breaker *Breaker
+	logger  *slog.Logger
+	jitter  func() float64
+}
+
+// ErrCircuitOpen is returned without calling the store while the breaker is open.
+var ErrCircuitOpen = errors.New("kb store circuit is open")
+
+// New creates a store that makes the calls to the given one resilient.
+func New(setup Setup) *Store {
+	newStore := Store{
+		storer:  setup.Storer,
+		reads:   setup.Reads,
+		writes:  setup.Writes,
+		breaker: setup.Breaker,
+		logger:  setup.Logger,
+		jitter:  setup.Jitter,
+	}
+
+	if newStore.jitter == nil {
+		newStore.jitter = rand.Float64
+	}
+
+	return &newStore
+}
+
+func (s *Store) Save(ctx context.Context, newKB kbs.KB) error {
+	_, err := call(ctx, s, "Save", s.writes, func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, s.storer.Save(ctx, newKB)
+	})
+
+	return err
+}
+
+func (s *Store) Update(ctx context.Context, kb kbs.UpdateKB) error {
+	_, err := call(ctx, s, "Update", s.writes, func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, s.storer.Update(ctx, kb)
+	})
+
+	return err
+}
+
+func (s *Store) Delete(ctx context.Context, kb kbs.KB) error {
+	_, err := call(ctx, s, "Delete", s.writes, func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, s.storer.Delete(ctx, kb)
+	})
+
+	return err
+}
+
+func (s *Store) ChangeState(ctx context.Context, change kbs.StateChange) error {
+	_, err := call(ctx, s, "ChangeState", s.writes, func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, s.storer.ChangeState(ctx, change)
+	})
+
+	return err
+}
+
+func (s *Store) Query(ctx context.Context, filter kbs.QueryFilter) (kbs.SearchKBsResult, error) {
+	return call(ctx, s, "Query", s.reads, func(ctx context.Context) (kbs.SearchKBsResult, error) {
+		return s.storer.Query(ctx, filter)
+	})
+}
+
+func (s *Store) QueryByID(ctx context.Context, id kbs.KBID) (*kbs.KB, error) {
+	return call(ctx, s, "QueryByID", s.reads, func(ctx context.Context) (*kbs.KB, error) {
+		return s.storer.QueryByID(ctx, id)
+	})
+}
+
+// call calls fn until it succeeds, fails with an error that is not worth
+// retrying or runs out of attempts. It stops early if the request is done
+// or would be done before the next attempt.
+func call[T any](ctx context.Context, s *Store, operation string, policy Policy, fn func(ctx context.Context) (T, error)) (T, error) {
+	logger := requests.Logger(ctx, s.logger)
+
+	var result T
+	var err error
+
+	for attempt := 1; ; attempt++ {
+		if !s.breaker.allow() {
+			return result, ErrCircuitOpen
+		}
+
+		var retryable bool
+
+		result, retryable, err = try(ctx, policy.Timeout, fn)
+
+		switch {
+		case ctx.Err() != nil:
+			// the caller gave up, it says nothing about the store.
+			s.breaker.record(ignored)
+
+			return result, err
+		case retryable:
+			s.breaker.record(failed)
+		default:
+			s.breaker.record(succeeded)
+
+			return result, err
+		}
+
+		if attempt >= policy.Attempts {
+			return result, err
+		}
+
+		delay := s.backoff(policy, attempt)
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			return result, err
+		}
+
+		logger.Warn("kb store call failed, retrying",
+			slog.String("operation", operation),
+			slog.Int("attempt", attempt),
+			slog.Duration("delay", delay),
+			slog.String("error", err.Error()))
+
+		err = sleep(ctx, delay)
+		if err != nil {
+			return result, err
+		}
+	}
+}
+
+// try makes one call with its own timeout. The error is retryable if the
+// store marked it as unavailable or if the call timed out.
+func try[T any](ctx context.Context, timeout time.Duration, fn func(ctx context.Context) (T, error)) (T, bool, error) {
+	callCtx := ctx
+
+	if timeout > 0 {
+		var cancel context.CancelFunc
+
+		callCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	result, err := fn(callCtx)
+	if err == nil {
+		return result, false, nil
+	}
+
+	timedOut := errors.Is(callCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil
+
+	return result, timedOut || errors.Is(err, kbs.ErrStoreUnavailable), err
+}
+
+// backoff returns the wait before the retry after the given attempt, a
+// random part of the exponential delay, so clients don't retry in sync.
+func (s *Store) backoff(policy Policy, attempt int) time.Duration {
+	delay := policy.BaseDelay << (attempt - 1)
+	if policy.MaxDelay > 0 && (delay <= 0 || delay > policy.MaxDelay) {
+		delay = policy.MaxDelay
+	}
+
+	return time.Duration(s.jitter() * float64(delay))
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,276 @@
+package resilience_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/adapter/resilience"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/kbs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	errThrottled = fmt.Errorf("unable to save kb: %w", kbs.ErrStoreUnavailable)
+	errRejected  = errors.New("unable to change kb state")
+)
+
+func TestRetriesUnavailableStore(t *testing.T) {
+	// Given
+	ctx := context.TODO()
+	store := newFailingStore(errThrottled, errThrottled)
+	resilientStore := resilience.New(resilience.Setup{
+		Storer: store,
+		Writes: resilience.Policy{Attempts: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond},
+		Logger: newDummyLogger(),
+	})
+
+	// When
+	err := resilientStore.Save(ctx, kbs.KB{ID: "kb-1"})
+
+	// Then
+	assert.NoError(t, err)
+	assert.Equal(t, 3, store.count())
+}
+
+func TestGivesUpAfterLastAttempt(t *testing.T) {
+	// Given
+	ctx := context.TODO()
+	store := newFailingStore(errThrottled, errThrottled, errThrottled)
+	resilientStore := resilience.New(resilience.Setup{
+		Storer: store,
+		Writes: resilience.Policy{Attempts: 2, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond},
+		Logger: newDummyLogger(),
+	})
+
+	// When
+	err := resilientStore.Save(ctx, kbs.KB{ID: "kb-1"})
+
+	// Then
+	assert.ErrorIs(t, err, kbs.ErrStoreUnavailable)
+	assert.Equal(t, 2, store.count())
+}
+
+func TestDoesNotRetryErrorsThatWontGoAway(t *testing.T) {
+	// Given
+	ctx := context.TODO()
+	store := newFailingStore(errRejected)
+	resilientStore := resilience.New(resilience.Setup{
+		Storer: store,
+		Writes: resilience.Policy{Attempts: 3, BaseDelay: time.Millisecond},
+		Logger: newDummyLogger(),
+	})
+
+	// When
+	err := resilientStore.ChangeState(ctx, kbs.StateChange{ID: "kb-1"})
+
+	// Then
+	assert.ErrorIs(t, err, errRejected)
+	assert.Equal(t, 1, store.count())
+}
+
+func TestRetriesCallsThatTimeOut(t *testing.T) {
+	// Given
+	ctx := context.TODO()
+	store := newFailingStore()
+	store.slowCalls = 1
+	resilientStore := resilience.New(resilience.Setup{
+		Storer: store,
+		Reads:  resilience.Policy{Attempts: 2, BaseDelay: time.Millisecond, Timeout: 20 * time.Millisecond},
+		Logger: newDummyLogger(),
+	})
+
+	// When
+	kb, err := resilientStore.QueryByID(ctx, "kb-1")
+
+	// Then
+	require.NoError(t, err)
+	assert.Equal(t, kbs.KBID("kb-1"), kb.ID)
+	assert.Equal(t, 2, store.count())
+}
+
+func TestDoesNotWaitLongerThanTheRequest(t *testing.T) {
+	// Given
+	ctx, cancel := context.WithTimeout(context.TODO(), 50*time.Millisecond)
+	defer cancel()
+
+	store := newFailingStore(errThrottled, errThrottled)
+	resilientStore := resilience.New(resilience.Setup{
+		Storer: store,
+		Reads:  resilience.Policy{Attempts: 3, BaseDelay: time.Second, MaxDelay: time.Second},
+		Logger: newDummyLogger(),
+		Jitter: func() float64 { return 1 },
+	})
+
+	// When
+	_, err := resilientStore.Query(ctx, kbs.QueryFilter{})
+
+	// Then
+	assert.ErrorIs(t, err, kbs.ErrStoreUnavailable)
+	assert.Equal(t, 1, store.count())
+}
+
+func TestBreakerFailsFastWhileStoreIsUnhealthy(t *testing.T) {
+	// Given
+	ctx := context.TODO()
+	now := time.Unix(1700000000, 0)
+	store := newFailingStore(errThrottled, errThrottled, errThrottled)
+	breaker := resilience.NewBreaker(resilience.BreakerSetup{
+		Failures: 2,
+		Cooldown: 30 * time.Second,
+		Exempt:   []string{"/readyz"},
+		Logger:   newDummyLogger(),
+		Clock:    func() time.Time { return now },
+	})
+	resilientStore := resilience.New(resilience.Setup{
+		Storer:  store,
+		Reads:   resilience.Policy{Attempts: 1},
+		Breaker: breaker,
+		Logger:  newDummyLogger(),
+	})
+	handler := breaker.HTTPMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	// When
+	_, firstErr := resilientStore.QueryByID(ctx, "kb-1")
+	_, secondErr := resilientStore.QueryByID(ctx, "kb-1")
+	_, openErr := resilientStore.QueryByID(ctx, "kb-1")
+	rejected := serve(handler, "/kbs/kb-1")
+	probe := serve(handler, "/readyz")
+	now = now.Add(31 * time.Second)
+	_, probeErr := resilientStore.QueryByID(ctx, "kb-1")
+	reopened := serve(handler, "/kbs/kb-1")
+	now = now.Add(31 * time.Second)
+	_, recoveredErr := resilientStore.QueryByID(ctx, "kb-1")
+	recovered := serve(handler, "/kbs/kb-1")
+
+	// Then
+	assert.ErrorIs(t, firstErr, kbs.ErrStoreUnavailable)
+	assert.ErrorIs(t, secondErr, kbs.ErrStoreUnavailable)
+	assert.ErrorIs(t, openErr, resilience.ErrCircuitOpen)
+	assert.Equal(t, http.StatusServiceUnavailable, rejected.Code)
+	assert.Equal(t, "30", rejected.Header().Get("Retry-After"))
+	assert.JSONEq(t, `{"kb": "kb store is unavailable, retry later"}`, rejected.Body.String())
+	assert.Equal(t, http.StatusOK, probe.Code)
+	assert.ErrorIs(t, probeErr, kbs.ErrStoreUnavailable)
+	assert.Equal(t, http.StatusServiceUnavailable, reopened.Code)
+	assert.NoError(t, recoveredErr)
+	assert.Equal(t, http.StatusOK, recovered.Code)
+	assert.Equal(t, 4, store.count())
+}
+
+func TestBreakerIgnoresErrorsThatWontGoAway(t *testing.T) {
+	// Given
+	ctx := context.TODO()
+	store := newFailingStore(errRejected, errRejected, errRejected)
+	breaker := resilience.NewBreaker(resilience.BreakerSetup{
+		Failures: 2,
+		Cooldown: time.Minute,
+		Logger:   newDummyLogger(),
+	})
+	resilientStore := resilience.New(resilience.Setup{
+		Storer:  store,
+		Writes:  resilience.Policy{Attempts: 1},
+		Breaker: breaker,
+		Logger:  newDummyLogger(),
+	})
+
+	// When
+	for i := 0; i < 3; i++ {
+		_ = resilientStore.ChangeState(ctx, kbs.StateChange{ID: "kb-1"})
+	}
+
+	// Then
+	assert.Equal(t, 3, store.count())
+	assert.Zero(t, breaker.RetryAfter())
+}
+
+// failingStore fails its calls with the given errors, in order, and
+// succeeds once there are no errors left.
+type failingStore struct {
+	mu    sync.Mutex
+	errs  []error
+	calls int
+	// slowCalls are the first calls that wait until their context is done.
+	slowCalls int
+}
+
+func newFailingStore(errs ...error) *failingStore {
+	return &failingStore{errs: errs}
+}
+
+func (f *failingStore) next(ctx context.Context) error {
+	f.mu.Lock()
+	f.calls++
+	slow := f.calls <= f.slowCalls
+
+	var err error
+	if !slow && len(f.errs) > 0 {
+		err, f.errs = f.errs[0], f.errs[1:]
+	}
+	f.mu.Unlock()
+
+	if slow {
+		<-ctx.Done()
+
+		return ctx.Err()
+	}
+
+	return err
+}
+
+func (f *failingStore) count() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.calls
+}
+
+func (f *failingStore) Save(ctx context.Context, newKB kbs.KB) error {
+	return f.next(ctx)
+}
+
+func (f *failingStore) Update(ctx context.Context, kb kbs.UpdateKB) error {
+	return f.next(ctx)
+}
+
+func (f *failingStore) Delete(ctx context.Context, kb kbs.KB) error {
+	return f.next(ctx)
+}
+
+func (f *failingStore) ChangeState(ctx context.Context, change kbs.StateChange) error {
+	return f.next(ctx)
+}
+
+func (f *failingStore) Query(ctx context.Context, filter kbs.QueryFilter) (kbs.SearchKBsResult, error) {
+	return kbs.SearchKBsResult{}, f.next(ctx)
+}
+
+func (f *failingStore) QueryByID(ctx context.Context, id kbs.KBID) (*kbs.KB, error) {
+	err := f.next(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &kbs.KB{ID: id}, nil
+}
+
+func serve(handler http.Handler, path string) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+
+	return recorder
+}
+
+func newDummyLogger() *slog.Logger {
+	return slog.New(slog.NewJSONHandler(os.Stdout, nil))
+}
@@ -0,0 +1,192 @@
+package stores
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/audit"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/kbs"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/requests"
+)
+
+const (
+	auditColumns = "sequence, timestamp, actor_id, action, kb_id, event_id, before_digest, after_digest, request_id, client_ip, previous_hash, hash"
+
+	insertAuditEntrySQL = "INSERT INTO audit_log (" + auditColumns + ") VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) ON CONFLICT (sequence) DO NOTHING"
+	selectLastEntrySQL  = "SELECT " + auditColumns + " FROM audit_log ORDER BY sequence DESC LIMIT 1"
+	selectEntriesSQL    = "SELECT " + auditColumns + " FROM audit_log"
+)
+
+var (
+	errAppendingAuditEntry = errors.New("unable to append audit entry")
+	errGettingAuditEntry   = errors.New("unable to get audit entries")
+)
+
+// AppendEntry stores the entry only if its sequence is not taken yet.
+func (s *Store) AppendEntry(ctx context.Context, entry audit.Entry) error {
+	logger := requests.Logger(ctx, s.logger)
+
+	result, err := s.db.ExecContext(ctx, insertAuditEntrySQL,
+		entry.Sequence, entry.Timestamp, entry.ActorID.String(), entry.Action.String(),
+		entry.KBID.String(), entry.EventID.String(), entry.BeforeDigest, entry.AfterDigest,
+		entry.RequestID, entry.ClientIP, entry.PreviousHash, entry.Hash)
+	if err != nil {
+		logger.Error("unable to persist audit entry", "error", err)
+
+		return errAppendingAuditEntry
+	}
+
+	inserted, err := result.RowsAffected()
+	if err != nil {
+		logger.Error("unable to read persisted audit entries", "error", err)
+
+		return errAppendingAuditEntry
+	}
+
+	if inserted == 0 {
+		return audit.ErrConflict
+	}
+
+	return nil
+}
+
+func (s *Store) LastEntry(ctx context.Context) (*audit.Entry, error) {
+	logger := requests.Logger(ctx, s.logger)
+
+	entry, err := scanAuditEntry(s.db.QueryRowContext(ctx, selectLastEntrySQL))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+
+	if err != nil {
+		logger.Error("unable to get last audit entry", "error", err)
+
+		return nil, errGettingAuditEntry
+	}
+
+	return &entry, nil
+}
+
+func (s *Store) QueryEntries(ctx context.Context, filter audit.QueryFilter) ([]audit.Entry, error) {
+	var result []audit.Entry
+
+	query, args := auditQuery(filter)
+
+	err := s.readAuditLog(ctx, query, args, func(entry audit.Entry) error {
+		result = append(result, entry)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (s *Store) ScanEntries(ctx context.Context, fn func(entry audit.Entry) error) error {
+	query, args := auditQuery(audit.QueryFilter{})
+
+	return s.readAuditLog(ctx, query, args, fn)
+}
+
+func (s *Store) readAuditLog(ctx context.Context, query string, args []any, fn func(entry audit.Entry) error) error {
+	logger := requests.Logger(ctx, s.logger)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		logger.Error("unable to query audit log", "error", err)
+
+		return errGettingAuditEntry
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		entry, err := scanAuditEntry(rows)
+		if err != nil {
+			logger.Error("unable to read audit entry", "error", err)
+
+			return errGettingAuditEntry
+		}
+
+		err = fn(entry)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		logger.Error("unable to read audit entries", "error", err)
+
+		return errGettingAuditEntry
+	}
+
+	return nil
+}
+
+// auditQuery builds the select statement for the given filter.
+func auditQuery(filter audit.QueryFilter) (string, []any) {
+	var conditions []string
+	var args []any
+
+	add := func(condition string, value any) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.KBID != "" {
+		add("kb_id = $%d", filter.KBID.String())
+	}
+
+	if filter.ActorID != "" {
+		add("actor_id = $%d", filter.ActorID.String())
+	}
+
+	if filter.From != 0 {
+		add("timestamp >= $%d", filter.From)
+	}
+
+	if filter.To != 0 {
+		add("timestamp <= $%d", filter.To)
+	}
+
+	if filter.After != 0 {
+		add("sequence > $%d", filter.After)
+	}
+
+	query := selectEntriesSQL
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	query += " ORDER BY sequence"
+
+	if filter.PageSize > 0 {
+		args = append(args, filter.PageSize)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+
+	return query, args
+}
+
+func scanAuditEntry(row rowScanner) (audit.Entry, error) {
+	var entry audit.Entry
+	var actorID, action, kbID, eventID string
+
+	err := row.Scan(&entry.Sequence, &entry.Timestamp, &actorID, &action, &kbID, &eventID,
+		&entry.BeforeDigest, &entry.AfterDigest, &entry.RequestID, &entry.ClientIP,
+		&entry.PreviousHash, &entry.Hash)
+	if err != nil {
+		return entry, err
+	}
+
+	entry.ActorID = kbs.UserID(actorID)
+	entry.Action = kbs.Action(action)
+	entry.KBID = kbs.KBID(kbID)
+	entry.EventID = kbs.EventID(eventID)
+
+	return entry, nil
+}
@@ -0,0 +1,213 @@
+package stores
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/comments"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/kbs"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/requests"
+)
+
+const (
+	commentColumns = "id, kb_id, parent_id, author_id, content, mentions, deleted, creation_date, update_date"
+
+	insertCommentSQL  = "INSERT INTO comments (" + commentColumns + ") VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)"
+	updateCommentSQL  = "UPDATE comments SET content = $2, mentions = $3, deleted = $4, update_date = $5 WHERE id = $1"
+	deleteCommentSQL  = "DELETE FROM comments WHERE id = $1"
+	selectCommentSQL  = "SELECT " + commentColumns + " FROM comments WHERE id = $1"
+	selectCommentsSQL = "SELECT " + commentColumns + " FROM comments WHERE kb_id = $1 ORDER BY creation_date"
+	countCommentsSQL  = "SELECT kb_id, COUNT(*) FROM comments WHERE kb_id IN (%s) GROUP BY kb_id"
+)
+
+var (
+	errSavingComment   = errors.New("unable to save comment")
+	errUpdatingComment = errors.New("unable to update comment")
+	errDeletingComment = errors.New("unable to delete comment")
+	errGettingComment  = errors.New("unable to get comment")
+	errCountingComment = errors.New("unable to count comments")
+)
+
+func (s *Store) SaveComment(ctx context.Context, comment comments.Comment) error {
+	logger := requests.Logger(ctx, s.logger)
+
+	mentions, err := json.Marshal(comment.Mentions)
+	if err != nil {
+		logger.Error("unable to marshal comment mentions", "error", err)
+
+		return errSavingComment
+	}
+
+	_, err = s.db.ExecContext(ctx, insertCommentSQL,
+		comment.ID.String(), comment.KBID.String(), comment.ParentID.String(), comment.AuthorID.String(),
+		comment.Content, string(mentions), comment.Deleted, comment.CreationDate, comment.UpdateDate)
+	if err != nil {
+		logger.Error("unable to persist comment", "error", err)
+
+		return errSavingComment
+	}
+
+	return nil
+}
+
+func (s *Store) UpdateComment(ctx context.Context, comment comments.Comment) error {
+	logger := requests.Logger(ctx, s.logger)
+
+	mentions, err := json.Marshal(comment.Mentions)
+	if err != nil {
+		logger.Error("unable to marshal comment mentions", "error", err)
+
+		return errUpdatingComment
+	}
+
+	_, err = s.db.ExecContext(ctx, updateCommentSQL,
+		comment.ID.String(), comment.Content, string(mentions), comment.Deleted, comment.UpdateDate)
+	if err != nil {
+		logger.Error("unable to update comment", slog.String("id", comment.ID.String()), "error", err)
+
+		return errUpdatingComment
+	}
+
+	return nil
+}
+
+func (s *Store) DeleteComment(ctx context.Context, id comments.CommentID) error {
+	logger := requests.Logger(ctx, s.logger)
+
+	_, err := s.db.ExecContext(ctx, deleteCommentSQL, id.String())
+	if err != nil {
+		logger.Error("unable to delete comment", slog.String("id", id.String()), "error", err)
+
+		return errDeletingComment
+	}
+
+	return nil
+}
+
+func (s *Store) QueryCommentByID(ctx context.Context, id comments.CommentID) (*comments.Comment, error) {
+	logger := requests.Logger(ctx, s.logger)
+
+	comment, err := scanComment(s.db.QueryRowContext(ctx, selectCommentSQL, id.String()))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+
+	if err != nil {
+		logger.Error("unable to get comment", slog.String("id", id.String()), "error", err)
+
+		return nil, errGettingComment
+	}
+
+	return &comment, nil
+}
+
+func (s *Store) QueryComments(ctx context.Context, kbID kbs.KBID) ([]comments.Comment, error) {
+	logger := requests.Logger(ctx, s.logger)
+
+	rows, err := s.db.QueryContext(ctx, selectCommentsSQL, kbID.String())
+	if err != nil {
+		logger.Error("unable to query comments", slog.String("kb_id", kbID.String()), "error", err)
+
+		return nil, errGettingComment
+	}
+	defer rows.Close()
+
+	var result []comments.Comment
+
+	for rows.Next() {
+		comment, err := scanComment(rows)
+		if err != nil {
+			logger.Error("unable to read comment", "error", err)
+
+			return nil, errGettingComment
+		}
+
+		result = append(result, comment)
+	}
+
+	if err := rows.Err(); err != nil {
+		logger.Error("unable to read comments", "error", err)
+
+		return nil, errGettingComment
+	}
+
+	return result, nil
+}
+
+func (s *Store) CountComments(ctx context.Context, kbIDs []kbs.KBID) (map[kbs.KBID]int, error) {
+	logger := requests.Logger(ctx, s.logger)
+
+	result := make(map[kbs.KBID]int, len(kbIDs))
+
+	if len(kbIDs) == 0 {
+		return result, nil
+	}
+
+	placeholders := make([]string, len(kbIDs))
+	args := make([]any, len(kbIDs))
+
+	for i, v := range kbIDs {
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+		args[i] = v.String()
+	}
+
+	query := fmt.Sprintf(countCommentsSQL, strings.Join(placeholders, ", "))
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		logger.Error("unable to count comments", "error", err)
+
+		return nil, errCountingComment
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var kbID string
+		var count int
+
+		err := rows.Scan(&kbID, &count)
+		if err != nil {
+			logger.Error("unable to read comment count", "error", err)
+
+			return nil, errCountingComment
+		}
+
+		result[kbs.KBID(kbID)] = count
+	}
+
+	if err := rows.Err(); err != nil {
+		logger.Error("unable to read comment counts", "error", err)
+
+		return nil, errCountingComment
+	}
+
+	return result, nil
+}
+
+func scanComment(row rowScanner) (comments.Comment, error) {
+	var comment comments.Comment
+	var id, kbID, parentID, authorID, mentions string
+
+	err := row.Scan(&id, &kbID, &parentID, &authorID, &comment.Content, &mentions,
+		&comment.Deleted, &comment.CreationDate, &comment.UpdateDate)
+	if err != nil {
+		return comment, err
+	}
+
+	comment.ID = comments.CommentID(id)
+	comment.KBID = kbs.KBID(kbID)
+	comment.ParentID = comments.CommentID(parentID)
+	comment.AuthorID = kbs.UserID(authorID)
+
+	err = json.Unmarshal([]byte(mentions), &comment.Mentions)
+	if err != nil {
+		return comment, err
+	}
+
+	return comment, nil
+}
@@ -0,0 +1,172 @@
+package stores
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log/slog"
+
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/events"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/kbs"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/requests"
+)
+
+const (
+	eventColumns = "id, name, description, start_date, end_date, status, organizers, creation_date, update_date"
+
+	insertEventSQL      = "INSERT INTO events (" + eventColumns + ") VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)"
+	updateEventSQL      = "UPDATE events SET name = $2, description = $3, start_date = $4, end_date = $5, status = $6, organizers = $7, update_date = $8 WHERE id = $1"
+	deleteEventSQL      = "DELETE FROM events WHERE id = $1"
+	selectEventSQL      = "SELECT " + eventColumns + " FROM events WHERE id = $1"
+	selectEventsSQL     = "SELECT " + eventColumns + " FROM events ORDER BY start_date DESC"
+	selectEventsByState = "SELECT " + eventColumns + " FROM events WHERE status = $1 ORDER BY start_date DESC"
+)
+
+var (
+	errSavingEvent   = errors.New("unable to save event")
+	errUpdatingEvent = errors.New("unable to update event")
+	errDeletingEvent = errors.New("unable to delete event")
+	errGettingEvent  = errors.New("unable to get event")
+)
+
+// rowScanner is implemented by *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func (s *Store) SaveEvent(ctx context.Context, event events.Event) error {
+	logger := requests.Logger(ctx, s.logger)
+
+	organizers, err := json.Marshal(event.Organizers)
+	if err != nil {
+		logger.Error("unable to marshal event organizers", "error", err)
+
+		return errSavingEvent
+	}
+
+	_, err = s.db.ExecContext(ctx, insertEventSQL,
+		event.ID.String(), event.Name, event.Description, event.StartDate, event.EndDate,
+		event.Status.String(), string(organizers), event.CreationDate, event.UpdateDate)
+	if err != nil {
+		logger.Error("unable to persist event", "error", err)
+
+		return errSavingEvent
+	}
+
+	return nil
+}
+
+func (s *Store) UpdateEvent(ctx context.Context, event events.Event) error {
+	logger := requests.Logger(ctx, s.logger)
+
+	organizers, err := json.Marshal(event.Organizers)
+	if err != nil {
+		logger.Error("unable to marshal event organizers", "error", err)
+
+		return errUpdatingEvent
+	}
+
+	_, err = s.db.ExecContext(ctx, updateEventSQL,
+		event.ID.String(), event.Name, event.Description, event.StartDate, event.EndDate,
+		event.Status.String(), string(organizers), event.UpdateDate)
+	if err != nil {
+		logger.Error("unable to update event", slog.String("id", event.ID.String()), "error", err)
+
+		return errUpdatingEvent
+	}
+
+	return nil
+}
+
+func (s *Store) DeleteEvent(ctx context.Context, id kbs.EventID) error {
+	logger := requests.Logger(ctx, s.logger)
+
+	_, err := s.db.ExecContext(ctx, deleteEventSQL, id.String())
+	if err != nil {
+		logger.Error("unable to delete event", slog.String("id", id.String()), "error", err)
+
+		return errDeletingEvent
+	}
+
+	return nil
+}
+
+func (s *Store) QueryEventByID(ctx context.Context, id kbs.EventID) (*events.Event, error) {
+	logger := requests.Logger(ctx, s.logger)
+
+	event, err := scanEvent(s.db.QueryRowContext(ctx, selectEventSQL, id.String()))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+
+	if err != nil {
+		logger.Error("unable to get event", slog.String("id", id.String()), "error", err)
+
+		return nil, errGettingEvent
+	}
+
+	return &event, nil
+}
+
+func (s *Store) QueryEvents(ctx context.Context, filter events.QueryFilter) ([]events.Event, error) {
+	logger := requests.Logger(ctx, s.logger)
+
+	var rows *sql.Rows
+	var err error
+
+	if filter.Status == events.EmptyStatus {
+		rows, err = s.db.QueryContext(ctx, selectEventsSQL)
+	} else {
+		rows, err = s.db.QueryContext(ctx, selectEventsByState, filter.Status.String())
+	}
+
+	if err != nil {
+		logger.Error("unable to query events", "error", err)
+
+		return nil, errGettingEvent
+	}
+	defer rows.Close()
+
+	var result []events.Event
+
+	for rows.Next() {
+		event, err := scanEvent(rows)
+		if err != nil {
+			logger.Error("unable to read event", "error", err)
+
+			return nil, errGettingEvent
+		}
+
+		result = append(result, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		logger.Error("unable to read events", "error", err)
+
+		return nil, errGettingEvent
+	}
+
+	return result, nil
+}
+
+func scanEvent(row rowScanner) (events.Event, error) {
+	var event events.Event
+	var id, status, organizers string
+
+	err := row.Scan(&id, &event.Name, &event.Description, &event.StartDate, &event.EndDate,
+		&status, &organizers, &event.CreationDate, &event.UpdateDate)
+	if err != nil {
+		return event, err
+	}
+
+	event.ID = kbs.EventID(id)
+	event.Status = events.Status(status)
+
+	err = json.Unmarshal([]byte(organizers), &event.Organizers)
+	if err != nil {
+		return event, err
+	}
+
+	return event, nil
+}
@@ -0,0 +1,144 @@
+package stores
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/idempotency"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/requests"
+)
+
+const (
+	idempotencyColumns = "id, fingerprint, state, status_code, content_type, body, created_at, locked_until, expires_at, token"
+
+	// insertIdempotencyRecordSQL replaces the existing record only if it
+	// expired or it was abandoned while in progress.
+	insertIdempotencyRecordSQL = "INSERT INTO idempotency_keys (" + idempotencyColumns + ") VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) " +
+		"ON CONFLICT (id) DO UPDATE SET fingerprint = EXCLUDED.fingerprint, state = EXCLUDED.state, status_code = EXCLUDED.status_code, " +
+		"content_type = EXCLUDED.content_type, body = EXCLUDED.body, created_at = EXCLUDED.created_at, " +
+		"locked_until = EXCLUDED.locked_until, expires_at = EXCLUDED.expires_at, token = EXCLUDED.token " +
+		"WHERE idempotency_keys.expires_at <= $11 OR (idempotency_keys.state = $12 AND idempotency_keys.locked_until <= $11)"
+	// updates and deletes only change a record in progress held by the token.
+	updateIdempotencyRecordSQL = "UPDATE idempotency_keys SET fingerprint = $2, state = $3, status_code = $4, content_type = $5, body = $6, created_at = $7, locked_until = $8, expires_at = $9, token = $10 " +
+		"WHERE id = $1 AND token = $11 AND state = $12"
+	selectIdempotencyRecordSQL = "SELECT " + idempotencyColumns + " FROM idempotency_keys WHERE id = $1"
+	deleteIdempotencyRecordSQL = "DELETE FROM idempotency_keys WHERE id = $1 AND token = $2 AND state = $3"
+)
+
+var (
+	errSavingIdempotencyRecord   = errors.New("unable to save idempotency record")
+	errGettingIdempotencyRecord  = errors.New("unable to get idempotency record")
+	errDeletingIdempotencyRecord = errors.New("unable to delete idempotency record")
+)
+
+// CreateRecord stores the record if there is no record with the same id,
+// or the existing one expired or was abandoned while in progress.
+func (s *Store) CreateRecord(ctx context.Context, record idempotency.Record, now int64) error {
+	logger := requests.Logger(ctx, s.logger)
+
+	args := append(idempotencyArgs(record), now, string(idempotency.InProgress))
+
+	result, err := s.db.ExecContext(ctx, insertIdempotencyRecordSQL, args...)
+	if err != nil {
+		logger.Error("unable to persist idempotency record", "error", err)
+
+		return errSavingIdempotencyRecord
+	}
+
+	inserted, err := result.RowsAffected()
+	if err != nil {
+		logger.Error("unable to read persisted idempotency records", "error", err)
+
+		return errSavingIdempotencyRecord
+	}
+
+	if inserted == 0 {
+		return idempotency.ErrConflict
+	}
+
+	return nil
+}
+
+func (s *Store) QueryRecord(ctx context.Context, id string) (*idempotency.Record, error) {
+	logger := requests.Logger(ctx, s.logger)
+
+	var record idempotency.Record
+	var state string
+
+	err := s.db.QueryRowContext(ctx, selectIdempotencyRecordSQL, id).Scan(
+		&record.ID, &record.Fingerprint, &state, &record.Response.StatusCode,
+		&record.Response.ContentType, &record.Response.Body, &record.CreatedAt,
+		&record.LockedUntil, &record.ExpiresAt, &record.Token)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+
+	if err != nil {
+		logger.Error("unable to get idempotency record", "error", err)
+
+		return nil, errGettingIdempotencyRecord
+	}
+
+	record.State = idempotency.State(state)
+
+	return &record, nil
+}
+
+// UpdateRecord replaces the record only if it is still in progress and held
+// by the given token.
+func (s *Store) UpdateRecord(ctx context.Context, record idempotency.Record, token string) error {
+	logger := requests.Logger(ctx, s.logger)
+
+	args := append(idempotencyArgs(record), token, string(idempotency.InProgress))
+
+	result, err := s.db.ExecContext(ctx, updateIdempotencyRecordSQL, args...)
+	if err != nil {
+		logger.Error("unable to update idempotency record", "error", err)
+
+		return errSavingIdempotencyRecord
+	}
+
+	return lockHeld(result, logger, errSavingIdempotencyRecord)
+}
+
+// DeleteRecord removes the record only if it is still in progress and held
+// by the given token.
+func (s *Store) DeleteRecord(ctx context.Context, id, token string) error {
+	logger := requests.Logger(ctx, s.logger)
+
+	result, err := s.db.ExecContext(ctx, deleteIdempotencyRecordSQL, id, token, string(idempotency.InProgress))
+	if err != nil {
+		logger.Error("unable to delete idempotency record", "error", err)
+
+		return errDeletingIdempotencyRecord
+	}
+
+	return lockHeld(result, logger, errDeletingIdempotencyRecord)
+}
+
+// lockHeld returns ErrLockLost if the statement didn't change any record.
+func lockHeld(result sql.Result, logger *slog.Logger, storeErr error) error {
+	changed, err := result.RowsAffected()
+	if err != nil {
+		logger.Error("unable to read changed idempotency records", "error", err)
+
+		return storeErr
+	}
+
+	if changed == 0 {
+		return idempotency.ErrLockLost
+	}
+
+	return nil
+}
+
+// idempotencyArgs returns the values of the idempotency columns in order.
+func idempotencyArgs(record idempotency.Record) []any {
+	return []any{
+		record.ID, record.Fingerprint, string(record.State), record.Response.StatusCode,
+		record.Response.ContentType, record.Response.Body, record.CreatedAt,
+		record.LockedUntil, record.ExpiresAt, record.Token,
+	}
+}
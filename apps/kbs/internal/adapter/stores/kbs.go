@@ -0,0 +1,387 @@
+package stores
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/kbs"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/requests"
+)
+
+const (
+	kbColumns = "id, user_id, username, content, event_id, creation_date, update_date, state, reviewer_id, reviews"
+
+	// insertKBSQL replaces the kb if it exists, like a dynamodb put.
+	insertKBSQL = "INSERT INTO kbs (" + kbColumns + ") VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) " +
+		"ON CONFLICT (id) DO UPDATE SET user_id = EXCLUDED.user_id, username = EXCLUDED.username, content = EXCLUDED.content, " +
+		"event_id = EXCLUDED.event_id, creation_date = EXCLUDED.creation_date, update_date = EXCLUDED.update_date, " +
+		"state = EXCLUDED.state, reviewer_id = EXCLUDED.reviewer_id, reviews = EXCLUDED.reviews"
+	updateKBSQL       = "UPDATE kbs SET user_id = $2, username = $3, event_id = $4, content = $5, update_date = $6 WHERE id = $1"
+	deleteKBSQL       = "DELETE FROM kbs WHERE id = $1"
+	selectKBSQL       = "SELECT " + kbColumns + " FROM kbs WHERE id = $1"
+	selectKBsSQL      = "SELECT " + kbColumns + ", COUNT(*) OVER () FROM kbs"
+	scanKBsSQL        = "SELECT " + kbColumns + " FROM kbs WHERE id > $1 ORDER BY id LIMIT $2"
+	lockKBReviewsSQL  = "SELECT reviews FROM kbs WHERE id = $1 AND state = $2 FOR UPDATE"
+	changeKBStateSQL  = "UPDATE kbs SET state = $2, update_date = $3, reviewer_id = COALESCE(NULLIF($4, ''), reviewer_id), reviews = $5 WHERE id = $1"
+	defaultKBOrderSQL = "creation_date"
+)
+
+// scanPageSize is the number of kbs each query of a scan reads.
+const scanPageSize = 500
+
+// queryer is implemented by *sql.DB and *sql.Tx.
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// kbOrderColumns are the columns kbs can be ordered by, each one is indexed
+// together with the event and the user of the kbs.
+var kbOrderColumns = map[kbs.OrderByField]string{
+	kbs.CreationDateField: "creation_date",
+	kbs.UpdateDateField:   "update_date",
+}
+
+var (
+	errSavingKB        = errors.New("unable to save kb")
+	errUpdatingKB      = errors.New("unable to update kb")
+	errDeletingKB      = errors.New("unable to delete kb")
+	errGettingKB       = errors.New("unable to get kb")
+	errChangingKBState = errors.New("unable to change kb state")
+)
+
+// Save stores the kb, it replaces the kb with the same id if there is one.
+func (s *Store) Save(ctx context.Context, newKB kbs.KB) error {
+	logger := requests.Logger(ctx, s.logger)
+
+	reviews, err := json.Marshal(reviewsOf(newKB.Reviews))
+	if err != nil {
+		logger.Error("unable to marshal kb reviews", "error", err)
+
+		return errSavingKB
+	}
+
+	_, err = s.db.ExecContext(ctx, insertKBSQL,
+		newKB.ID.String(), newKB.UserID.String(), newKB.UserName, newKB.Content, newKB.EventID.String(),
+		newKB.CreationDate, newKB.UpdateDate, newKB.State.String(), newKB.ReviewerID.String(), string(reviews))
+	if err != nil {
+		logger.Error("unable to persist kb", "error", err)
+
+		return errSavingKB
+	}
+
+	return nil
+}
+
+func (s *Store) Update(ctx context.Context, kb kbs.UpdateKB) error {
+	logger := requests.Logger(ctx, s.logger)
+
+	_, err := s.db.ExecContext(ctx, updateKBSQL,
+		kb.ID.String(), kb.UserID.String(), kb.UserName, kb.EventID.String(), kb.Content, kb.UpdateDate)
+	if err != nil {
+		logger.Error("unable to update kb", slog.String("id", kb.ID.String()), "error", err)
+
+		return errUpdatingKB
+	}
+
+	return nil
+}
+
+func (s *Store) Delete(ctx context.Context, kb kbs.KB) error {
+	logger := requests.Logger(ctx, s.logger)
+
+	_, err := s.db.ExecContext(ctx, deleteKBSQL, kb.ID.String())
+	if err != nil {
+		logger.Error("unable to delete kb", slog.String("id", kb.ID.String()), "error", err)
+
+		return errDeletingKB
+	}
+
+	return nil
+}
+
+// ChangeState moves the kb to the new state, it fails if the kb is not in
+// the state the change comes from anymore. The row is locked while the
+// review is appended so concurrent changes don't lose reviews.
+func (s *Store) ChangeState(ctx context.Context, change kbs.StateChange) error {
+	logger := requests.Logger(ctx, s.logger)
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.Error("unable to begin kb state change", "error", err)
+
+		return errChangingKBState
+	}
+	defer tx.Rollback()
+
+	var storedReviews string
+
+	err = tx.QueryRowContext(ctx, lockKBReviewsSQL, change.ID.String(), change.From.String()).Scan(&storedReviews)
+	if err != nil {
+		logger.Error("unable to lock kb to change its state",
+			slog.String("id", change.ID.String()),
+			slog.String("from", change.From.String()),
+			"error", err)
+
+		return errChangingKBState
+	}
+
+	var reviews []kbs.Review
+
+	err = json.Unmarshal([]byte(storedReviews), &reviews)
+	if err != nil {
+		logger.Error("unable to unmarshal kb reviews", "error", err)
+
+		return errChangingKBState
+	}
+
+	if change.Review != nil {
+		reviews = append(reviews, *change.Review)
+	}
+
+	updatedReviews, err := json.Marshal(reviewsOf(reviews))
+	if err != nil {
+		logger.Error("unable to marshal kb reviews", "error", err)
+
+		return errChangingKBState
+	}
+
+	_, err = tx.ExecContext(ctx, changeKBStateSQL,
+		change.ID.String(), change.To.String(), change.UpdateDate, change.ReviewerID.String(), string(updatedReviews))
+	if err != nil {
+		logger.Error("unable to change kb state", slog.String("id", change.ID.String()), "error", err)
+
+		return errChangingKBState
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		logger.Error("unable to commit kb state change", slog.String("id", change.ID.String()), "error", err)
+
+		return errChangingKBState
+	}
+
+	return nil
+}
+
+func (s *Store) QueryByID(ctx context.Context, id kbs.KBID) (*kbs.KB, error) {
+	logger := requests.Logger(ctx, s.logger)
+
+	kb, _, err := scanKB(s.db.QueryRowContext(ctx, selectKBSQL, id.String()), false)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+
+	if err != nil {
+		logger.Error("unable to get kb", slog.String("id", id.String()), "error", err)
+
+		return nil, errGettingKB
+	}
+
+	return &kb, nil
+}
+
+func (s *Store) Query(ctx context.Context, filter kbs.QueryFilter) (kbs.SearchKBsResult, error) {
+	logger := requests.Logger(ctx, s.logger)
+
+	result := kbs.SearchKBsResult{
+		Page:        filter.PageNumber,
+		RowsPerPage: filter.RowsPerPage,
+	}
+
+	query, args := kbsQuery(filter)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		logger.Error("unable to query kbs", "error", err)
+
+		return result, errGettingKB
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		kb, total, err := scanKB(rows, true)
+		if err != nil {
+			logger.Error("unable to read kb", "error", err)
+
+			return result, errGettingKB
+		}
+
+		result.KBs = append(result.KBs, kb)
+		result.Total = total
+	}
+
+	if err := rows.Err(); err != nil {
+		logger.Error("unable to read kbs", "error", err)
+
+		return result, errGettingKB
+	}
+
+	return result, nil
+}
+
+// ScanKBs calls fn for each kb ordered by id until all kbs were read or fn
+// returns an error. KBs are read in pages, so no connection is held while
+// fn runs.
+func (s *Store) ScanKBs(ctx context.Context, fn func(kb kbs.KB) error) error {
+	return s.scanKBs(ctx, s.db, fn)
+}
+
+// SnapshotKBs reads every kb like ScanKBs inside a read only transaction,
+// so every page sees the kbs as they were when the snapshot started.
+func (s *Store) SnapshotKBs(ctx context.Context, fn func(kb kbs.KB) error) error {
+	logger := requests.Logger(ctx, s.logger)
+
+	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		logger.Error("unable to begin kbs snapshot", "error", err)
+
+		return errGettingKB
+	}
+	defer tx.Rollback()
+
+	return s.scanKBs(ctx, tx, fn)
+}
+
+func (s *Store) scanKBs(ctx context.Context, db queryer, fn func(kb kbs.KB) error) error {
+	logger := requests.Logger(ctx, s.logger)
+
+	var lastID string
+
+	for {
+		page, err := scanPage(ctx, db, lastID)
+		if err != nil {
+			logger.Error("unable to scan kbs", slog.String("after", lastID), "error", err)
+
+			return errGettingKB
+		}
+
+		for _, kb := range page {
+			err = fn(kb)
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(page) < scanPageSize {
+			return nil
+		}
+
+		lastID = page[len(page)-1].ID.String()
+	}
+}
+
+// scanPage reads the page of kbs that follows the given id.
+func scanPage(ctx context.Context, db queryer, lastID string) ([]kbs.KB, error) {
+	rows, err := db.QueryContext(ctx, scanKBsSQL, lastID, scanPageSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	page := make([]kbs.KB, 0, scanPageSize)
+
+	for rows.Next() {
+		kb, _, err := scanKB(rows, false)
+		if err != nil {
+			return nil, err
+		}
+
+		page = append(page, kb)
+	}
+
+	return page, rows.Err()
+}
+
+// kbsQuery builds the select statement of a page of the given filter.
+func kbsQuery(filter kbs.QueryFilter) (string, []any) {
+	var conditions []string
+	var args []any
+
+	add := func(condition string, value any) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.EventID != "" {
+		add("event_id = $%d", filter.EventID)
+	}
+
+	if filter.UserID != "" {
+		add("user_id = $%d", filter.UserID)
+	}
+
+	if filter.State != kbs.EmptyState {
+		add("state = $%d", filter.State.String())
+	}
+
+	query := selectKBsSQL
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	column, ok := kbOrderColumns[filter.OrderBy]
+	if !ok {
+		column = defaultKBOrderSQL
+	}
+
+	direction := "DESC"
+	if filter.Order == kbs.Ascending {
+		direction = "ASC"
+	}
+
+	// the id breaks ties so pages don't overlap.
+	query += fmt.Sprintf(" ORDER BY %s %s, id %s", column, direction, direction)
+
+	if filter.RowsPerPage > 0 {
+		args = append(args, int(filter.RowsPerPage), int(max(filter.PageNumber, 1)-1)*int(filter.RowsPerPage))
+		query += fmt.Sprintf(" LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+	}
+
+	return query, args
+}
+
+// scanKB reads a kb row, withTotal reads the total of rows of the query
+// that follows the kb columns.
+func scanKB(row rowScanner, withTotal bool) (kbs.KB, int, error) {
+	var kb kbs.KB
+	var id, userID, eventID, state, reviewerID, reviews string
+	var total int
+
+	dest := []any{&id, &userID, &kb.UserName, &kb.Content, &eventID,
+		&kb.CreationDate, &kb.UpdateDate, &state, &reviewerID, &reviews}
+	if withTotal {
+		dest = append(dest, &total)
+	}
+
+	err := row.Scan(dest...)
+	if err != nil {
+		return kb, 0, err
+	}
+
+	kb.ID = kbs.KBID(id)
+	kb.UserID = kbs.UserID(userID)
+	kb.EventID = kbs.EventID(eventID)
+	kb.State = kbs.State(state)
+	kb.ReviewerID = kbs.UserID(reviewerID)
+
+	err = json.Unmarshal([]byte(reviews), &kb.Reviews)
+	if err != nil {
+		return kb, 0, err
+	}
+
+	return kb, total, nil
+}
+
+// reviewsOf returns an empty list instead of nil, so it is stored as [].
+func reviewsOf(reviews []kbs.Review) []kbs.Review {
+	if reviews == nil {
+		return []kbs.Review{}
+	}
+
+	return reviews
+}
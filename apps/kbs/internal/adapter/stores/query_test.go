@@ -0,0 +1,88 @@
+package stores
+
+import (
+	"testing"
+
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/audit"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/kbs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKBsQuery(t *testing.T) {
+	cases := map[string]struct {
+		filter    kbs.QueryFilter
+		wantQuery string
+		wantArgs  []any
+	}{
+		"event": {
+			filter:    kbs.QueryFilter{EventID: "event-1"},
+			wantQuery: selectKBsSQL + " WHERE event_id = $1 ORDER BY creation_date DESC, id DESC",
+			wantArgs:  []any{"event-1"},
+		},
+		"user_and_state": {
+			filter:    kbs.QueryFilter{UserID: "drila", State: kbs.Published, OrderBy: kbs.UpdateDateField, Order: kbs.Ascending},
+			wantQuery: selectKBsSQL + " WHERE user_id = $1 AND state = $2 ORDER BY update_date ASC, id ASC",
+			wantArgs:  []any{"drila", "published"},
+		},
+		"first_page": {
+			filter:    kbs.QueryFilter{EventID: "event-1", RowsPerPage: 10},
+			wantQuery: selectKBsSQL + " WHERE event_id = $1 ORDER BY creation_date DESC, id DESC LIMIT $2 OFFSET $3",
+			wantArgs:  []any{"event-1", 10, 0},
+		},
+		"third_page": {
+			filter:    kbs.QueryFilter{EventID: "event-1", UserID: "drila", State: kbs.Draft, PageNumber: 3, RowsPerPage: 10},
+			wantQuery: selectKBsSQL + " WHERE event_id = $1 AND user_id = $2 AND state = $3 ORDER BY creation_date DESC, id DESC LIMIT $4 OFFSET $5",
+			wantArgs:  []any{"event-1", "drila", "draft", 10, 20},
+		},
+		"unknown_order": {
+			filter:    kbs.QueryFilter{UserID: "drila", OrderBy: "content"},
+			wantQuery: selectKBsSQL + " WHERE user_id = $1 ORDER BY creation_date DESC, id DESC",
+			wantArgs:  []any{"drila"},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			// When
+			query, args := kbsQuery(tc.filter)
+
+			// Then
+			assert.Equal(t, tc.wantQuery, query)
+			assert.Equal(t, tc.wantArgs, args)
+		})
+	}
+}
+
+func TestAuditQuery(t *testing.T) {
+	cases := map[string]struct {
+		filter    audit.QueryFilter
+		wantQuery string
+		wantArgs  []any
+	}{
+		"whole_log": {
+			filter:    audit.QueryFilter{},
+			wantQuery: selectEntriesSQL + " ORDER BY sequence",
+		},
+		"kb_page": {
+			filter:    audit.QueryFilter{KBID: "kb-1", PageSize: 100},
+			wantQuery: selectEntriesSQL + " WHERE kb_id = $1 ORDER BY sequence LIMIT $2",
+			wantArgs:  []any{"kb-1", 100},
+		},
+		"next_page": {
+			filter:    audit.QueryFilter{ActorID: "drila", From: 1700000000, To: 1800000000, After: 42, PageSize: 10},
+			wantQuery: selectEntriesSQL + " WHERE actor_id = $1 AND timestamp >= $2 AND timestamp <= $3 AND sequence > $4 ORDER BY sequence LIMIT $5",
+			wantArgs:  []any{"drila", int64(1700000000), int64(1800000000), int64(42), 10},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			// When
+			query, args := auditQuery(tc.filter)
+
+			// Then
+			assert.Equal(t, tc.wantQuery, query)
+			assert.Equal(t, tc.wantArgs, args)
+		})
+	}
+}
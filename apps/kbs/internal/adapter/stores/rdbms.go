@@ -2,69 +2,48 @@ package stores
 
 import (
 	"context"
+	"database/sql"
 	"log/slog"
 
-	"github.com/fernandoocampo/kb-store/apps/kbs/internal/kbs"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/requests"
 )
 
 type Setup struct {
 	Logger *slog.Logger
+	// DB is the connection pool to the relational database.
+	DB *sql.DB
 }
 
 // Store handles logic to persist data from this microservice.
 type Store struct {
 	logger *slog.Logger
+	db     *sql.DB
 }
 
 func NewStore(setup Setup) *Store {
 	newStore := Store{
 		logger: setup.Logger,
+		db:     setup.DB,
 	}
 
 	return &newStore
 }
 
-func (s *Store) Save(ctx context.Context, newKB kbs.KB) error {
-	s.logger.Info("Saving new kb in database")
-	return nil
-}
+// DatasetStatus returns an error if the database cannot be reached.
+func (s *Store) DatasetStatus(ctx context.Context) error {
+	logger := requests.Logger(ctx, s.logger)
 
-func (s *Store) Update(ctx context.Context, kb kbs.UpdateKB) error {
-	s.logger.Info("Updating new kb in database")
-	return nil
-}
+	err := s.db.PingContext(ctx)
+	if err != nil {
+		logger.Error("unable to ping database", "error", err)
 
-func (s *Store) Delete(ctx context.Context, kb kbs.KB) error {
-	s.logger.Info("Deleting new kb in database")
-	return nil
-}
-
-func (s *Store) Query(ctx context.Context, filter kbs.QueryFilter) (kbs.SearchKBsResult, error) {
-	s.logger.Info("Querying kbs in database")
-	result := kbs.SearchKBsResult{
-		KBs: []kbs.KB{
-			{
-				ID:     kbs.KBID("56016eaf-5e15-44db-839c-ef4f7f9df437"),
-				UserID: "Drila",
-			},
-			{
-				ID:     kbs.KBID("ec665f5e-da4e-4f51-bc4c-310dd7cc9590"),
-				UserID: "Michael",
-			},
-		},
-		Total:       2,
-		Page:        filter.PageNumber,
-		RowsPerPage: filter.RowsPerPage,
+		return err
 	}
-	return result, nil
-}
 
-func (s *Store) QueryByID(ctx context.Context, id kbs.KBID) (*kbs.KB, error) {
-	s.logger.Info("Querying kb by id in database")
-	kb := kbs.KB{
-		ID:     kbs.KBID("56016eaf-5e15-44db-839c-ef4f7f9df437"),
-		UserID: "Drila",
-	}
+	return nil
+}
 
-	return &kb, nil
+// Close closes the connection pool to the database.
+func (s *Store) Close(ctx context.Context) error {
+	return s.db.Close()
 }
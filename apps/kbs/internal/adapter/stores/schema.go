@@ -0,0 +1,94 @@
+package stores
+
+import (
+	"context"
+	"fmt"
+)
+
+// schema contains the statements to create the tables used by the store.
+// Every statement must be idempotent.
+var schema = []string{
+	`CREATE TABLE IF NOT EXISTS kbs (
+		id            VARCHAR(36) PRIMARY KEY,
+		user_id       VARCHAR(255) NOT NULL,
+		username      VARCHAR(255) NOT NULL DEFAULT '',
+		content       TEXT NOT NULL DEFAULT '',
+		event_id      VARCHAR(36) NOT NULL DEFAULT '',
+		creation_date BIGINT NOT NULL,
+		update_date   BIGINT NOT NULL DEFAULT 0,
+		state         VARCHAR(20) NOT NULL,
+		reviewer_id   VARCHAR(255) NOT NULL DEFAULT '',
+		reviews       TEXT NOT NULL DEFAULT '[]'
+	)`,
+	`CREATE INDEX IF NOT EXISTS kbs_event_id_creation_date_idx ON kbs (event_id, creation_date)`,
+	`CREATE INDEX IF NOT EXISTS kbs_event_id_update_date_idx ON kbs (event_id, update_date)`,
+	`CREATE INDEX IF NOT EXISTS kbs_user_id_creation_date_idx ON kbs (user_id, creation_date)`,
+	`CREATE INDEX IF NOT EXISTS kbs_user_id_update_date_idx ON kbs (user_id, update_date)`,
+	`CREATE TABLE IF NOT EXISTS events (
+		id            VARCHAR(36) PRIMARY KEY,
+		name          VARCHAR(255) NOT NULL,
+		description   TEXT NOT NULL DEFAULT '',
+		start_date    BIGINT NOT NULL DEFAULT 0,
+		end_date      BIGINT NOT NULL DEFAULT 0,
+		status        VARCHAR(20) NOT NULL,
+		organizers    TEXT NOT NULL DEFAULT '[]',
+		creation_date BIGINT NOT NULL,
+		update_date   BIGINT NOT NULL DEFAULT 0
+	)`,
+	`CREATE INDEX IF NOT EXISTS events_status_idx ON events (status)`,
+	`CREATE TABLE IF NOT EXISTS comments (
+		id            VARCHAR(36) PRIMARY KEY,
+		kb_id         VARCHAR(36) NOT NULL,
+		parent_id     VARCHAR(36) NOT NULL DEFAULT '',
+		author_id     VARCHAR(255) NOT NULL,
+		content       TEXT NOT NULL DEFAULT '',
+		mentions      TEXT NOT NULL DEFAULT '[]',
+		deleted       BOOLEAN NOT NULL DEFAULT FALSE,
+		creation_date BIGINT NOT NULL,
+		update_date   BIGINT NOT NULL DEFAULT 0
+	)`,
+	`CREATE INDEX IF NOT EXISTS comments_kb_id_idx ON comments (kb_id, creation_date)`,
+	`CREATE TABLE IF NOT EXISTS audit_log (
+		sequence      BIGINT PRIMARY KEY,
+		timestamp     BIGINT NOT NULL,
+		actor_id      VARCHAR(255) NOT NULL DEFAULT '',
+		action        VARCHAR(20) NOT NULL,
+		kb_id         VARCHAR(36) NOT NULL,
+		event_id      VARCHAR(36) NOT NULL DEFAULT '',
+		before_digest VARCHAR(64) NOT NULL DEFAULT '',
+		after_digest  VARCHAR(64) NOT NULL DEFAULT '',
+		request_id    VARCHAR(64) NOT NULL DEFAULT '',
+		client_ip     VARCHAR(45) NOT NULL DEFAULT '',
+		previous_hash VARCHAR(64) NOT NULL DEFAULT '',
+		hash          VARCHAR(64) NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS audit_log_kb_id_sequence_idx ON audit_log (kb_id, sequence)`,
+	`CREATE INDEX IF NOT EXISTS audit_log_actor_id_sequence_idx ON audit_log (actor_id, sequence)`,
+	`CREATE TABLE IF NOT EXISTS idempotency_keys (
+		id           VARCHAR(64) PRIMARY KEY,
+		fingerprint  VARCHAR(64) NOT NULL,
+		state        VARCHAR(20) NOT NULL,
+		status_code  INTEGER NOT NULL DEFAULT 0,
+		content_type VARCHAR(255) NOT NULL DEFAULT '',
+		body         BYTEA,
+		created_at   BIGINT NOT NULL,
+		locked_until BIGINT NOT NULL DEFAULT 0,
+		expires_at   BIGINT NOT NULL,
+		token        VARCHAR(36) NOT NULL DEFAULT ''
+	)`,
+	`CREATE INDEX IF NOT EXISTS idempotency_keys_expires_at_idx ON idempotency_keys (expires_at)`,
+}
+
+// CreateSchema creates the tables and indexes the store needs if they don't exist.
+func (s *Store) CreateSchema(ctx context.Context) error {
+	for _, statement := range schema {
+		_, err := s.db.ExecContext(ctx, statement)
+		if err != nil {
+			s.logger.Error("unable to apply schema statement", "error", err)
+
+			return fmt.Errorf("unable to create schema: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,189 @@
+package stores_test
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/adapter/stores"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/audit"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/idempotency"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/kbs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var kbColumns = []string{"id", "user_id", "username", "content", "event_id", "creation_date", "update_date", "state", "reviewer_id", "reviews"}
+
+func TestSaveKB(t *testing.T) {
+	// Given
+	ctx := context.TODO()
+	store, mock := newStore(t)
+	kb := kbs.KB{ID: "kb-1", UserID: "drila", Content: "runbook", EventID: "event-1", CreationDate: 1700000000, UpdateDate: 1700000000, State: kbs.Draft}
+
+	mock.ExpectExec("INSERT INTO kbs .+ ON CONFLICT \\(id\\) DO UPDATE").
+		WithArgs("kb-1", "drila", "", "runbook", "event-1", int64(1700000000), int64(1700000000), "draft", "", "[]").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	// When
+	err := store.Save(ctx, kb)
+
+	// Then
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestQueryKBByID(t *testing.T) {
+	// Given
+	ctx := context.TODO()
+	store, mock := newStore(t)
+
+	mock.ExpectQuery("SELECT .+ FROM kbs WHERE id = \\$1").
+		WithArgs("kb-1").
+		WillReturnRows(sqlmock.NewRows(kbColumns).
+			AddRow("kb-1", "drila", "Drila", "runbook", "event-1", 1700000000, 1700000100, "in_review", "ana", "[]"))
+	mock.ExpectQuery("SELECT .+ FROM kbs WHERE id = \\$1").
+		WithArgs("kb-2").
+		WillReturnError(sql.ErrNoRows)
+
+	// When
+	found, errFound := store.QueryByID(ctx, "kb-1")
+	missing, errMissing := store.QueryByID(ctx, "kb-2")
+
+	// Then
+	require.NoError(t, errFound)
+	require.NoError(t, errMissing)
+	assert.Nil(t, missing)
+	assert.Equal(t, &kbs.KB{
+		ID: "kb-1", UserID: "drila", UserName: "Drila", Content: "runbook", EventID: "event-1",
+		CreationDate: 1700000000, UpdateDate: 1700000100, State: kbs.InReview, ReviewerID: "ana", Reviews: []kbs.Review{},
+	}, found)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestQueryKBsPage(t *testing.T) {
+	// Given
+	ctx := context.TODO()
+	store, mock := newStore(t)
+
+	mock.ExpectQuery("SELECT .+ FROM kbs WHERE event_id = \\$1 ORDER BY creation_date DESC, id DESC LIMIT \\$2 OFFSET \\$3").
+		WithArgs("event-1", 2, 2).
+		WillReturnRows(sqlmock.NewRows(append(kbColumns, "count")).
+			AddRow("kb-3", "drila", "", "c", "event-1", 1700000003, 1700000003, "draft", "", "[]", 5).
+			AddRow("kb-4", "drila", "", "d", "event-1", 1700000004, 1700000004, "draft", "", "[]", 5))
+
+	// When
+	result, err := store.Query(ctx, kbs.QueryFilter{EventID: "event-1", PageNumber: 2, RowsPerPage: 2})
+
+	// Then
+	require.NoError(t, err)
+	assert.Equal(t, 5, result.Total)
+	require.Len(t, result.KBs, 2)
+	assert.Equal(t, kbs.KBID("kb-3"), result.KBs[0].ID)
+	assert.Equal(t, kbs.KBID("kb-4"), result.KBs[1].ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestChangeKBStateAppendsReview(t *testing.T) {
+	// Given
+	ctx := context.TODO()
+	store, mock := newStore(t)
+	change := kbs.StateChange{
+		ID:         "kb-1",
+		From:       kbs.InReview,
+		To:         kbs.Draft,
+		UpdateDate: 1700000200,
+		Review:     &kbs.Review{ReviewerID: "ana", Decision: kbs.Rejected, Comment: "too short", Date: 1700000200},
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT reviews FROM kbs WHERE id = \\$1 AND state = \\$2 FOR UPDATE").
+		WithArgs("kb-1", "in_review").
+		WillReturnRows(sqlmock.NewRows([]string{"reviews"}).AddRow("[]"))
+	mock.ExpectExec("UPDATE kbs SET state").
+		WithArgs("kb-1", "draft", int64(1700000200), "",
+			`[{"reviewer_id":"ana","decision":"rejected","comment":"too short","date":1700000200}]`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	// When
+	err := store.ChangeState(ctx, change)
+
+	// Then
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestChangeKBStateFailsIfStateChanged(t *testing.T) {
+	// Given
+	ctx := context.TODO()
+	store, mock := newStore(t)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT reviews FROM kbs").
+		WithArgs("kb-1", "in_review").
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
+
+	// When
+	err := store.ChangeState(ctx, kbs.StateChange{ID: "kb-1", From: kbs.InReview, To: kbs.Published})
+
+	// Then
+	assert.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAppendAuditEntryConflict(t *testing.T) {
+	// Given
+	ctx := context.TODO()
+	store, mock := newStore(t)
+
+	mock.ExpectExec("INSERT INTO audit_log .+ ON CONFLICT \\(sequence\\) DO NOTHING").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	// When
+	err := store.AppendEntry(ctx, audit.Entry{Sequence: 7, KBID: "kb-1", Action: kbs.CreateAction})
+
+	// Then
+	assert.ErrorIs(t, err, audit.ErrConflict)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestIdempotencyRecordLockLost(t *testing.T) {
+	// Given
+	ctx := context.TODO()
+	store, mock := newStore(t)
+
+	mock.ExpectExec("UPDATE idempotency_keys .+ WHERE id = \\$1 AND token = \\$11 AND state = \\$12").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("DELETE FROM idempotency_keys WHERE id = \\$1 AND token = \\$2 AND state = \\$3").
+		WithArgs("key-1", "token-1", string(idempotency.InProgress)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	// When
+	errUpdate := store.UpdateRecord(ctx, idempotency.Record{ID: "key-1", State: idempotency.Completed}, "token-0")
+	errDelete := store.DeleteRecord(ctx, "key-1", "token-1")
+
+	// Then
+	assert.ErrorIs(t, errUpdate, idempotency.ErrLockLost)
+	assert.NoError(t, errDelete)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func newStore(t *testing.T) (*stores.Store, sqlmock.Sqlmock) {
+	t.Helper()
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	t.Cleanup(func() { db.Close() })
+
+	return stores.NewStore(stores.Setup{Logger: newDummyLogger(), DB: db}), mock
+}
+
+func newDummyLogger() *slog.Logger {
+	return slog.New(slog.NewJSONHandler(os.Stdout, nil))
+}
@@ -0,0 +1,70 @@
+package tracing
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer creates the spans of the tracing adapters.
+var tracer = otel.Tracer("github.com/fernandoocampo/kb-store/apps/kbs/internal/adapter/tracing")
+
+// statusRecorder keeps the status code written by the handler.
+type statusRecorder struct {
+	http.ResponseWriter
+	code int
+}
+
+// HTTPMiddleware continues the trace of the caller given in the w3c
+// traceparent header, or starts a new one, and creates the server span
+// of the request.
+func HTTPMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+
+		route := routeOf(r)
+
+		ctx, span := tracer.Start(ctx, r.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", r.Method),
+				attribute.String("http.route", route),
+			),
+		)
+		defer span.End()
+
+		recorder := &statusRecorder{ResponseWriter: w, code: http.StatusOK}
+
+		next.ServeHTTP(recorder, r.WithContext(ctx))
+
+		span.SetAttributes(attribute.Int("http.response.status_code", recorder.code))
+
+		if recorder.code >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(recorder.code))
+		}
+	})
+}
+
+func (s *statusRecorder) WriteHeader(code int) {
+	s.code = code
+	s.ResponseWriter.WriteHeader(code)
+}
+
+func routeOf(r *http.Request) string {
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return r.URL.Path
+	}
+
+	template, err := route.GetPathTemplate()
+	if err != nil {
+		return r.URL.Path
+	}
+
+	return template
+}
@@ -0,0 +1,50 @@
+package tracing
+
+import (
+	"context"
+	"log/slog"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// traceIDKey is the attribute with the trace id of the record.
+const traceIDKey = "trace_id"
+
+// LogHandler adds the trace and span ids of the context to the records
+// logged with a context.
+type LogHandler struct {
+	slog.Handler
+	// withTrace is true if the logger already carries the trace id, e.g.
+	// request-scoped loggers.
+	withTrace bool
+}
+
+// NewLogHandler wraps the given handler.
+func NewLogHandler(handler slog.Handler) *LogHandler {
+	return &LogHandler{Handler: handler}
+}
+
+func (h *LogHandler) Handle(ctx context.Context, record slog.Record) error {
+	spanContext := trace.SpanContextFromContext(ctx)
+	if spanContext.IsValid() && !h.withTrace {
+		record.AddAttrs(
+			slog.String(traceIDKey, spanContext.TraceID().String()),
+			slog.String("span_id", spanContext.SpanID().String()),
+		)
+	}
+
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h *LogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	withTrace := h.withTrace
+	for _, attr := range attrs {
+		withTrace = withTrace || attr.Key == traceIDKey
+	}
+
+	return &LogHandler{Handler: h.Handler.WithAttrs(attrs), withTrace: withTrace}
+}
+
+func (h *LogHandler) WithGroup(name string) slog.Handler {
+	return &LogHandler{Handler: h.Handler.WithGroup(name), withTrace: h.withTrace}
+}
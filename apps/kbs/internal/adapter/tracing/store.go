@@ -0,0 +1,105 @@
+package tracing
+
+import (
+	"context"
+
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/kbs"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Store creates a span for every call to the kbs store.
+type Store struct {
+	storer kbs.Storer
+}
+
+// NewStore creates a store that traces the calls made to the given one.
+func NewStore(storer kbs.Storer) *Store {
+	return &Store{
+		storer: storer,
+	}
+}
+
+func (s *Store) Save(ctx context.Context, newKB kbs.KB) error {
+	ctx, span := startStoreSpan(ctx, "Save", newKB.ID)
+	defer span.End()
+
+	err := s.storer.Save(ctx, newKB)
+	recordError(span, err)
+
+	return err
+}
+
+func (s *Store) Update(ctx context.Context, kb kbs.UpdateKB) error {
+	ctx, span := startStoreSpan(ctx, "Update", kb.ID)
+	defer span.End()
+
+	err := s.storer.Update(ctx, kb)
+	recordError(span, err)
+
+	return err
+}
+
+func (s *Store) Delete(ctx context.Context, kb kbs.KB) error {
+	ctx, span := startStoreSpan(ctx, "Delete", kb.ID)
+	defer span.End()
+
+	err := s.storer.Delete(ctx, kb)
+	recordError(span, err)
+
+	return err
+}
+
+func (s *Store) Query(ctx context.Context, filter kbs.QueryFilter) (kbs.SearchKBsResult, error) {
+	ctx, span := startStoreSpan(ctx, "Query", kbs.EmptyKBID)
+	defer span.End()
+
+	result, err := s.storer.Query(ctx, filter)
+	recordError(span, err)
+
+	span.SetAttributes(attribute.Int("kbs.count", len(result.KBs)))
+
+	return result, err
+}
+
+func (s *Store) QueryByID(ctx context.Context, id kbs.KBID) (*kbs.KB, error) {
+	ctx, span := startStoreSpan(ctx, "QueryByID", id)
+	defer span.End()
+
+	kb, err := s.storer.QueryByID(ctx, id)
+	recordError(span, err)
+
+	return kb, err
+}
+
+func (s *Store) ChangeState(ctx context.Context, change kbs.StateChange) error {
+	ctx, span := startStoreSpan(ctx, "ChangeState", change.ID)
+	defer span.End()
+
+	err := s.storer.ChangeState(ctx, change)
+	recordError(span, err)
+
+	return err
+}
+
+// startStoreSpan starts the span of a store call, the kb id is added if
+// the call is about a single kb.
+func startStoreSpan(ctx context.Context, method string, id kbs.KBID) (context.Context, trace.Span) {
+	var options []trace.SpanStartOption
+	if id != kbs.EmptyKBID {
+		options = append(options, trace.WithAttributes(attribute.String("kb.id", id.String())))
+	}
+
+	return tracer.Start(ctx, "kbs.Store."+method, options...)
+}
+
+// recordError marks the span as failed if there is an error.
+func recordError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
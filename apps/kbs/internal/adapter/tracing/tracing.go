@@ -0,0 +1,153 @@
+// Package tracing sets up the opentelemetry traces of the service.
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// supported exporters.
+const (
+	// NoneExporter doesn't export spans, trace context is still propagated.
+	NoneExporter   = "none"
+	StdoutExporter = "stdout"
+	FileExporter   = "file"
+	OTLPExporter   = "otlp"
+)
+
+const serviceName = "kbs"
+
+var (
+	errUnknownExporter  = errors.New("unknown tracing exporter")
+	errCreatingExporter = errors.New("unable to create tracing exporter")
+)
+
+// Setup contains tracing settings.
+type Setup struct {
+	Version string
+	// Exporter is where spans are sent: none, stdout, file or otlp.
+	Exporter string
+	// Endpoint is the host:port of the otlp http collector.
+	Endpoint string
+	// Insecure sends spans to the otlp collector without tls.
+	Insecure bool
+	// File is where spans are written with the file exporter.
+	File string
+	// SampleRatio is the fraction of new traces that are sampled, traces
+	// started by the caller follow the caller decision.
+	SampleRatio float64
+}
+
+// Provider creates and exports the spans of the service.
+type Provider struct {
+	provider *sdktrace.TracerProvider
+	// file is closed once the spans are flushed.
+	file io.Closer
+}
+
+// New creates the tracer provider for the configured exporter and makes
+// it and the w3c trace context propagator the global ones.
+func New(ctx context.Context, setup Setup) (*Provider, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	newProvider := new(Provider)
+
+	if setup.Exporter == NoneExporter || setup.Exporter == "" {
+		return newProvider, nil
+	}
+
+	exporter, err := newProvider.newExporter(ctx, setup)
+	if err != nil {
+		return nil, err
+	}
+
+	serviceResource, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(serviceName),
+		semconv.ServiceVersion(setup.Version),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("unable to create tracing resource: %w", err)
+	}
+
+	newProvider.provider = sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(serviceResource),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(setup.SampleRatio))),
+	)
+
+	otel.SetTracerProvider(newProvider.provider)
+
+	return newProvider, nil
+}
+
+func (p *Provider) newExporter(ctx context.Context, setup Setup) (sdktrace.SpanExporter, error) {
+	switch setup.Exporter {
+	case StdoutExporter:
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", errCreatingExporter, err)
+		}
+
+		return exporter, nil
+	case FileExporter:
+		file, err := os.OpenFile(setup.File, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", errCreatingExporter, err)
+		}
+
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(file))
+		if err != nil {
+			file.Close()
+
+			return nil, fmt.Errorf("%w: %w", errCreatingExporter, err)
+		}
+
+		p.file = file
+
+		return exporter, nil
+	case OTLPExporter:
+		options := []otlptracehttp.Option{otlptracehttp.WithEndpoint(setup.Endpoint)}
+		if setup.Insecure {
+			options = append(options, otlptracehttp.WithInsecure())
+		}
+
+		exporter, err := otlptracehttp.New(ctx, options...)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", errCreatingExporter, err)
+		}
+
+		return exporter, nil
+	}
+
+	return nil, fmt.Errorf("%w: %q", errUnknownExporter, setup.Exporter)
+}
+
+// Shutdown flushes the pending spans and stops the exporter.
+func (p *Provider) Shutdown(ctx context.Context) error {
+	if p.provider == nil {
+		return nil
+	}
+
+	err := p.provider.Shutdown(ctx)
+
+	if p.file != nil {
+		err = errors.Join(err, p.file.Close())
+	}
+
+	return err
+}
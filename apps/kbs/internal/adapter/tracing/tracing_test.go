@@ -0,0 +1,150 @@
+package tracing_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/adapter/tracing"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/kbs"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+const (
+	callerTraceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	callerSpanID  = "00f067aa0ba902b7"
+)
+
+func TestHTTPMiddlewareContinuesCallerTrace(t *testing.T) {
+	// Given
+	exporter := newExporter(t)
+	var logs bytes.Buffer
+	logger := slog.New(tracing.NewLogHandler(slog.NewJSONHandler(&logs, nil)))
+
+	router := mux.NewRouter()
+	router.Use(tracing.HTTPMiddleware)
+	router.Methods(http.MethodGet).Path("/kbs/{id}").HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logger.InfoContext(r.Context(), "reading kb")
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/kbs/kb-1", nil)
+	req.Header.Set("traceparent", "00-"+callerTraceID+"-"+callerSpanID+"-01")
+
+	// When
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	// Then
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	span := spans[0]
+	assert.Equal(t, "GET /kbs/{id}", span.Name)
+	assert.Equal(t, callerTraceID, span.SpanContext.TraceID().String())
+	assert.Equal(t, callerSpanID, span.Parent.SpanID().String())
+	assert.Contains(t, span.Attributes, attribute.Int("http.response.status_code", http.StatusNotFound))
+	assert.Equal(t, codes.Unset, span.Status.Code)
+
+	var record map[string]any
+	require.NoError(t, json.Unmarshal(logs.Bytes(), &record))
+	assert.Equal(t, callerTraceID, record["trace_id"])
+	assert.Equal(t, span.SpanContext.SpanID().String(), record["span_id"])
+}
+
+func TestStoreRecordsFailedCalls(t *testing.T) {
+	// Given
+	exporter := newExporter(t)
+	store := tracing.NewStore(&failingStore{err: errors.New("timeout")})
+
+	// When
+	_, err := store.QueryByID(context.TODO(), "kb-1")
+
+	// Then
+	assert.Error(t, err)
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "kbs.Store.QueryByID", spans[0].Name)
+	assert.Equal(t, codes.Error, spans[0].Status.Code)
+	assert.Contains(t, spans[0].Attributes, attribute.String("kb.id", "kb-1"))
+}
+
+func TestNewWithExporter(t *testing.T) {
+	cases := map[string]struct {
+		exporter string
+		wantErr  bool
+	}{
+		"none": {
+			exporter: tracing.NoneExporter,
+		},
+		"file": {
+			exporter: tracing.FileExporter,
+		},
+		"unknown": {
+			exporter: "zipkin",
+			wantErr:  true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			// When
+			provider, err := tracing.New(context.TODO(), tracing.Setup{
+				Exporter:    tc.exporter,
+				File:        t.TempDir() + "/traces.json",
+				SampleRatio: 1,
+			})
+
+			// Then
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.NoError(t, provider.Shutdown(context.TODO()))
+		})
+	}
+}
+
+var (
+	globalExporter *tracetest.InMemoryExporter
+	setGlobal      sync.Once
+)
+
+// newExporter returns the exporter of the global provider without the
+// spans of previous tests. The global provider is set once because the
+// tracers created before keep using the first one.
+func newExporter(t *testing.T) *tracetest.InMemoryExporter {
+	t.Helper()
+
+	setGlobal.Do(func() {
+		globalExporter = tracetest.NewInMemoryExporter()
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(globalExporter)))
+		_, err := tracing.New(context.TODO(), tracing.Setup{Exporter: tracing.NoneExporter})
+		require.NoError(t, err)
+	})
+
+	globalExporter.Reset()
+
+	return globalExporter
+}
+
+type failingStore struct {
+	kbs.Storer
+	err error
+}
+
+func (f *failingStore) QueryByID(ctx context.Context, id kbs.KBID) (*kbs.KB, error) {
+	return nil, f.err
+}
@@ -0,0 +1,96 @@
+package web
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/audit"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/kbs"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/requests"
+)
+
+// SearchAuditDecoder decodes a search of the audit log.
+type SearchAuditDecoder struct {
+	logger *slog.Logger
+}
+
+type AuditDecoders struct {
+	SearchDecoder *SearchAuditDecoder
+}
+
+func NewAuditDecoders(logger *slog.Logger) AuditDecoders {
+	return AuditDecoders{
+		SearchDecoder: &SearchAuditDecoder{logger: logger},
+	}
+}
+
+func (s *SearchAuditDecoder) Decode(ctx context.Context, r *http.Request) (interface{}, error) {
+	logger := requests.Logger(ctx, s.logger)
+
+	filters := r.URL.Query()
+
+	filter := audit.QueryFilter{
+		KBID:    kbs.KBID(filters.Get("kb-id")),
+		ActorID: kbs.UserID(filters.Get("user-id")),
+	}
+
+	var err error
+
+	filter.From, err = parseDate(filters.Get("from"))
+	if err != nil {
+		logger.Error("invalid from parameter", "error", err)
+
+		return nil, fmt.Errorf("invalid from parameter: %w", err)
+	}
+
+	filter.To, err = parseDate(filters.Get("to"))
+	if err != nil {
+		logger.Error("invalid to parameter", "error", err)
+
+		return nil, fmt.Errorf("invalid to parameter: %w", err)
+	}
+
+	if v := filters.Get("cursor"); v != "" {
+		filter.After, err = strconv.ParseInt(v, 10, 64)
+		if err != nil || filter.After < 0 {
+			logger.Error("invalid cursor parameter", "cursor", v)
+
+			return nil, fmt.Errorf("invalid cursor parameter: %q", v)
+		}
+	}
+
+	if v := filters.Get("pagesize"); v != "" {
+		filter.PageSize, err = strconv.Atoi(v)
+		if err != nil || filter.PageSize < 1 || filter.PageSize > audit.PageSizeMax {
+			logger.Error("invalid page size parameter", "pagesize", v)
+
+			return nil, fmt.Errorf("page size must be between 1 and %d", audit.PageSizeMax)
+		}
+	}
+
+	return filter, nil
+}
+
+// parseDate returns the unix seconds of the given date, it accepts unix
+// seconds or RFC3339 dates. An empty value is zero.
+func parseDate(value string) (int64, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err == nil {
+		return seconds, nil
+	}
+
+	date, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return 0, fmt.Errorf("date must be unix seconds or RFC3339: %w", err)
+	}
+
+	return date.Unix(), nil
+}
@@ -0,0 +1,43 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/audit"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/requests"
+)
+
+type SearchAuditEncoder struct {
+	logger *slog.Logger
+}
+
+type AuditEncoders struct {
+	SearchEncoder *SearchAuditEncoder
+}
+
+func NewAuditEncoders(logger *slog.Logger) AuditEncoders {
+	return AuditEncoders{
+		SearchEncoder: &SearchAuditEncoder{logger: logger},
+	}
+}
+
+func (s *SearchAuditEncoder) Encode(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	logger := requests.Logger(ctx, s.logger)
+
+	result, ok := response.(audit.SearchEntriesResult)
+	if !ok {
+		logger.Error("cannot transform to audit.SearchEntriesResult", "received", fmt.Sprintf("%T", response))
+		return errors.New("cannot build search audit response")
+	}
+
+	err := encodeResultWithJSON(ctx, w, toSearchEntriesResponse(result))
+	if err != nil {
+		return fmt.Errorf("unable to encode search audit result: %w", err)
+	}
+
+	return nil
+}
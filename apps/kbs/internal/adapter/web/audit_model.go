@@ -0,0 +1,69 @@
+package web
+
+import (
+	"strconv"
+
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/audit"
+)
+
+// AuditEntry contains audit log entry data.
+type AuditEntry struct {
+	Sequence     int64  `json:"sequence"`
+	Timestamp    int64  `json:"timestamp"`
+	ActorID      string `json:"actor_id"`
+	Action       string `json:"action"`
+	KBID         string `json:"kb_id"`
+	EventID      string `json:"event_id,omitempty"`
+	BeforeDigest string `json:"before_digest,omitempty"`
+	AfterDigest  string `json:"after_digest,omitempty"`
+	RequestID    string `json:"request_id,omitempty"`
+	ClientIP     string `json:"client_ip,omitempty"`
+	PreviousHash string `json:"previous_hash"`
+	Hash         string `json:"hash"`
+}
+
+// AuditEntriesPage contains a page of audit log entries.
+type AuditEntriesPage struct {
+	Entries []AuditEntry `json:"entries"`
+	// NextCursor is the cursor parameter of the next page, empty if this is
+	// the last one.
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// toAuditEntry transforms a domain audit entry to a web audit entry.
+func toAuditEntry(entry audit.Entry) AuditEntry {
+	return AuditEntry{
+		Sequence:     entry.Sequence,
+		Timestamp:    entry.Timestamp,
+		ActorID:      entry.ActorID.String(),
+		Action:       entry.Action.String(),
+		KBID:         entry.KBID.String(),
+		EventID:      entry.EventID.String(),
+		BeforeDigest: entry.BeforeDigest,
+		AfterDigest:  entry.AfterDigest,
+		RequestID:    entry.RequestID,
+		ClientIP:     entry.ClientIP,
+		PreviousHash: entry.PreviousHash,
+		Hash:         entry.Hash,
+	}
+}
+
+func toSearchEntriesResponse(auditResult audit.SearchEntriesResult) Result {
+	var result Result
+	if auditResult.Err == "" {
+		entries := make([]AuditEntry, 0, len(auditResult.Entries))
+		for _, v := range auditResult.Entries {
+			entries = append(entries, toAuditEntry(v))
+		}
+		page := AuditEntriesPage{Entries: entries}
+		if auditResult.Next != 0 {
+			page.NextCursor = strconv.FormatInt(auditResult.Next, 10)
+		}
+		result.Success = true
+		result.Data = page
+	}
+	if auditResult.Err != "" {
+		result.Errors = []string{auditResult.Err}
+	}
+	return result
+}
@@ -0,0 +1,157 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/comments"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/kbs"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/requests"
+)
+
+type CreateCommentDecoder struct {
+	logger *slog.Logger
+}
+
+type UpdateCommentDecoder struct {
+	logger *slog.Logger
+}
+
+type DeleteCommentDecoder struct {
+	logger *slog.Logger
+}
+
+type GetCommentWithIDDecoder struct {
+	logger *slog.Logger
+}
+
+// SearchCommentsDecoder decodes a search of the comments of the kb in the path.
+type SearchCommentsDecoder struct {
+	logger *slog.Logger
+}
+
+type CommentDecoders struct {
+	CreateDecoder  *CreateCommentDecoder
+	UpdateDecoder  *UpdateCommentDecoder
+	DeleteDecoder  *DeleteCommentDecoder
+	GetByIDDecoder *GetCommentWithIDDecoder
+	SearchDecoder  *SearchCommentsDecoder
+}
+
+var errCommentIDNotProvided = errors.New("comment ID was not provided")
+
+func NewCommentDecoders(logger *slog.Logger) CommentDecoders {
+	return CommentDecoders{
+		CreateDecoder:  &CreateCommentDecoder{logger: logger},
+		UpdateDecoder:  &UpdateCommentDecoder{logger: logger},
+		DeleteDecoder:  &DeleteCommentDecoder{logger: logger},
+		GetByIDDecoder: &GetCommentWithIDDecoder{logger: logger},
+		SearchDecoder:  NewSearchCommentsDecoder(logger),
+	}
+}
+
+func NewSearchCommentsDecoder(logger *slog.Logger) *SearchCommentsDecoder {
+	return &SearchCommentsDecoder{logger: logger}
+}
+
+func (c *CreateCommentDecoder) Decode(ctx context.Context, r *http.Request) (interface{}, error) {
+	logger := requests.Logger(ctx, c.logger)
+
+	kbID, ok := pathID(r)
+	if !ok {
+		return nil, errKBIDNotProvided
+	}
+
+	var req NewComment
+
+	err := decodeJSONBody(r, &req)
+	if err != nil {
+		logger.Error("new comment request could not be decoded", slog.String("error", err.Error()))
+
+		return nil, err
+	}
+
+	return req.toComment(kbID), nil
+}
+
+func (u *UpdateCommentDecoder) Decode(ctx context.Context, r *http.Request) (interface{}, error) {
+	logger := requests.Logger(ctx, u.logger)
+
+	commentID, ok := pathID(r)
+	if !ok {
+		return nil, errCommentIDNotProvided
+	}
+
+	var req UpdateComment
+
+	err := decodeJSONBody(r, &req)
+	if err != nil {
+		logger.Error("update comment request could not be decoded", slog.String("error", err.Error()))
+
+		return nil, err
+	}
+
+	return req.toComment(commentID), nil
+}
+
+func (d *DeleteCommentDecoder) Decode(ctx context.Context, r *http.Request) (interface{}, error) {
+	commentID, ok := pathID(r)
+	if !ok {
+		return nil, errCommentIDNotProvided
+	}
+
+	deleteComment := comments.DeleteComment{
+		ID: comments.CommentID(commentID),
+	}
+
+	return deleteComment, nil
+}
+
+func (g *GetCommentWithIDDecoder) Decode(ctx context.Context, r *http.Request) (interface{}, error) {
+	commentID, ok := pathID(r)
+	if !ok {
+		return nil, errCommentIDNotProvided
+	}
+
+	return comments.CommentID(commentID), nil
+}
+
+func (s *SearchCommentsDecoder) Decode(ctx context.Context, r *http.Request) (interface{}, error) {
+	logger := requests.Logger(ctx, s.logger)
+
+	kbID, ok := pathID(r)
+	if !ok {
+		return nil, errKBIDNotProvided
+	}
+
+	filter := comments.QueryFilter{
+		KBID:        kbs.KBID(kbID),
+		PageNumber:  comments.PageNumberDefault,
+		RowsPerPage: comments.RowsPerPageDefault,
+	}
+
+	filters := r.URL.Query()
+
+	if v, ok := filters["page"]; ok {
+		page, err := strconv.Atoi(v[0])
+		if err != nil {
+			logger.Error("invalid page parameter, it must be an integer", "error", err)
+			page = int(comments.PageNumberDefault)
+		}
+		filter.PageNumber = uint8(page)
+	}
+
+	if v, ok := filters["pagesize"]; ok {
+		pageSize, err := strconv.Atoi(v[0])
+		if err != nil {
+			logger.Error("invalid page size parameter, it must be an integer", "error", err)
+			pageSize = int(comments.RowsPerPageDefault)
+		}
+		filter.RowsPerPage = uint8(pageSize)
+	}
+
+	return filter, nil
+}
@@ -0,0 +1,135 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/comments"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/requests"
+)
+
+type CreateCommentEncoder struct {
+	logger *slog.Logger
+}
+
+type UpdateCommentEncoder struct {
+	logger *slog.Logger
+}
+
+type DeleteCommentEncoder struct {
+	logger *slog.Logger
+}
+
+type GetCommentWithIDEncoder struct {
+	logger *slog.Logger
+}
+
+type SearchCommentsEncoder struct {
+	logger *slog.Logger
+}
+
+type CommentEncoders struct {
+	CreateEncoder  *CreateCommentEncoder
+	UpdateEncoder  *UpdateCommentEncoder
+	DeleteEncoder  *DeleteCommentEncoder
+	GetByIDEncoder *GetCommentWithIDEncoder
+	SearchEncoder  *SearchCommentsEncoder
+}
+
+func NewCommentEncoders(logger *slog.Logger) CommentEncoders {
+	return CommentEncoders{
+		CreateEncoder:  &CreateCommentEncoder{logger: logger},
+		UpdateEncoder:  &UpdateCommentEncoder{logger: logger},
+		DeleteEncoder:  &DeleteCommentEncoder{logger: logger},
+		GetByIDEncoder: &GetCommentWithIDEncoder{logger: logger},
+		SearchEncoder:  &SearchCommentsEncoder{logger: logger},
+	}
+}
+
+func (c *CreateCommentEncoder) Encode(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	logger := requests.Logger(ctx, c.logger)
+
+	result, ok := response.(comments.CreateCommentResult)
+	if !ok {
+		logger.Error("cannot transform to comments.CreateCommentResult", "received", fmt.Sprintf("%T", response))
+		return errors.New("cannot build create comment response")
+	}
+
+	err := encodeResultWithJSON(ctx, w, toCreateCommentResponse(result))
+	if err != nil {
+		return fmt.Errorf("unable to encode create comment result: %w", err)
+	}
+
+	return nil
+}
+
+func (u *UpdateCommentEncoder) Encode(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	logger := requests.Logger(ctx, u.logger)
+
+	result, ok := response.(comments.UpdateCommentResult)
+	if !ok {
+		logger.Error("cannot transform to comments.UpdateCommentResult", "received", fmt.Sprintf("%T", response))
+		return errors.New("cannot build update comment response")
+	}
+
+	err := encodeResultWithJSON(ctx, w, toUpdateCommentResponse(result))
+	if err != nil {
+		return fmt.Errorf("unable to encode update comment result: %w", err)
+	}
+
+	return nil
+}
+
+func (d *DeleteCommentEncoder) Encode(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	logger := requests.Logger(ctx, d.logger)
+
+	result, ok := response.(comments.DeleteCommentResult)
+	if !ok {
+		logger.Error("cannot transform to comments.DeleteCommentResult", "received", fmt.Sprintf("%T", response))
+		return errors.New("cannot build delete comment response")
+	}
+
+	err := encodeResultWithJSON(ctx, w, toDeleteCommentResponse(result))
+	if err != nil {
+		return fmt.Errorf("unable to encode delete comment result: %w", err)
+	}
+
+	return nil
+}
+
+func (g *GetCommentWithIDEncoder) Encode(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	logger := requests.Logger(ctx, g.logger)
+
+	result, ok := response.(comments.GetCommentWithIDResult)
+	if !ok {
+		logger.Error("cannot transform to comments.GetCommentWithIDResult", "received", fmt.Sprintf("%T", response))
+		return errors.New("cannot build get comment response")
+	}
+
+	err := encodeResultWithJSON(ctx, w, toGetCommentWithIDResponse(result))
+	if err != nil {
+		return fmt.Errorf("unable to encode get comment result: %w", err)
+	}
+
+	return nil
+}
+
+func (s *SearchCommentsEncoder) Encode(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	logger := requests.Logger(ctx, s.logger)
+
+	result, ok := response.(comments.SearchCommentsDataResult)
+	if !ok {
+		logger.Error("cannot transform to comments.SearchCommentsDataResult", "received", fmt.Sprintf("%T", response))
+		return errors.New("cannot build search comments response")
+	}
+
+	err := encodeResultWithJSON(ctx, w, toSearchCommentsResponse(result))
+	if err != nil {
+		return fmt.Errorf("unable to encode search comments result: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,161 @@
+package web
+
+import (
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/comments"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/kbs"
+)
+
+// Comment contains comment data.
+type Comment struct {
+	ID           string   `json:"id"`
+	KBID         string   `json:"kb_id"`
+	ParentID     string   `json:"parent_id,omitempty"`
+	AuthorID     string   `json:"author_id"`
+	Content      string   `json:"content"`
+	Mentions     []string `json:"mentions"`
+	Deleted      bool     `json:"deleted"`
+	CreationDate int64    `json:"creation_date"`
+	UpdateDate   int64    `json:"update_date"`
+}
+
+// Thread contains a top level comment and its replies.
+type Thread struct {
+	Comment
+	Replies []Comment `json:"replies"`
+}
+
+// NewComment contains the expected data for a new comment.
+type NewComment struct {
+	ParentID string `json:"parent_id"`
+	AuthorID string `json:"author_id"`
+	Content  string `json:"content"`
+}
+
+// UpdateComment contains the expected data to update a comment.
+type UpdateComment struct {
+	Content string `json:"content"`
+}
+
+// SearchCommentsResult contains a page of comment threads.
+type SearchCommentsResult struct {
+	Threads  []Thread `json:"threads"`
+	Total    int      `json:"total"`
+	Page     uint8    `json:"page"`
+	PageSize uint8    `json:"page_size"`
+}
+
+// toComment transforms a domain comment to a web comment.
+func toComment(comment *comments.Comment) *Comment {
+	if comment == nil {
+		return nil
+	}
+	webComment := Comment{
+		ID:           comment.ID.String(),
+		KBID:         comment.KBID.String(),
+		ParentID:     comment.ParentID.String(),
+		AuthorID:     comment.AuthorID.String(),
+		Content:      comment.Content,
+		Mentions:     fromUserIDs(comment.Mentions),
+		Deleted:      comment.Deleted,
+		CreationDate: comment.CreationDate,
+		UpdateDate:   comment.UpdateDate,
+	}
+	return &webComment
+}
+
+// toThread transforms a domain thread to a web thread.
+func toThread(thread comments.Thread) Thread {
+	replies := make([]Comment, 0, len(thread.Replies))
+	for _, v := range thread.Replies {
+		replies = append(replies, *toComment(&v))
+	}
+	return Thread{
+		Comment: *toComment(&thread.Comment),
+		Replies: replies,
+	}
+}
+
+// toComment transforms new comment to a domain object.
+func (n NewComment) toComment(kbID string) comments.NewComment {
+	return comments.NewComment{
+		KBID:     kbs.KBID(kbID),
+		ParentID: comments.CommentID(n.ParentID),
+		AuthorID: kbs.UserID(n.AuthorID),
+		Content:  n.Content,
+	}
+}
+
+// toComment transforms update comment to a domain object.
+func (u UpdateComment) toComment(id string) comments.UpdateComment {
+	return comments.UpdateComment{
+		ID:      comments.CommentID(id),
+		Content: u.Content,
+	}
+}
+
+func toCreateCommentResponse(commentResult comments.CreateCommentResult) Result {
+	var result Result
+	if commentResult.Err == "" {
+		result.Success = true
+		result.Data = commentResult.ID
+	}
+	if commentResult.Err != "" {
+		result.Errors = []string{commentResult.Err}
+	}
+	return result
+}
+
+func toUpdateCommentResponse(commentResult comments.UpdateCommentResult) Result {
+	var result Result
+	if commentResult.Err == "" {
+		result.Success = true
+	}
+	if commentResult.Err != "" {
+		result.Errors = []string{commentResult.Err}
+	}
+	return result
+}
+
+func toDeleteCommentResponse(commentResult comments.DeleteCommentResult) Result {
+	var result Result
+	if commentResult.Err == "" {
+		result.Success = true
+	}
+	if commentResult.Err != "" {
+		result.Errors = []string{commentResult.Err}
+	}
+	return result
+}
+
+func toGetCommentWithIDResponse(commentResult comments.GetCommentWithIDResult) Result {
+	var result Result
+	if commentResult.Err == "" {
+		result.Success = true
+		result.Data = toComment(commentResult.Comment)
+	}
+	if commentResult.Err != "" {
+		result.Errors = []string{commentResult.Err}
+	}
+	return result
+}
+
+func toSearchCommentsResponse(commentResult comments.SearchCommentsDataResult) Result {
+	var result Result
+	if commentResult.Err == "" {
+		threads := make([]Thread, 0, len(commentResult.SearchResult.Threads))
+		for _, v := range commentResult.SearchResult.Threads {
+			threads = append(threads, toThread(v))
+		}
+		result.Success = true
+		result.Data = SearchCommentsResult{
+			Threads:  threads,
+			Total:    commentResult.SearchResult.Total,
+			Page:     commentResult.SearchResult.Page,
+			PageSize: commentResult.SearchResult.RowsPerPage,
+		}
+	}
+	if commentResult.Err != "" {
+		result.Errors = []string{commentResult.Err}
+	}
+	return result
+}
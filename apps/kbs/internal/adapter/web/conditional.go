@@ -0,0 +1,157 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/kbs"
+)
+
+// validator headers.
+const (
+	ETagHeader            = "ETag"
+	LastModifiedHeader    = "Last-Modified"
+	IfNoneMatchHeader     = "If-None-Match"
+	IfModifiedSinceHeader = "If-Modified-Since"
+)
+
+// encodeCacheableResultWithJSON encodes the result like encodeResultWithJSON
+// and adds a strong ETag with the hash of the body. The Last-Modified
+// header is added if lastModified, in unix seconds, is not zero.
+func encodeCacheableResultWithJSON(ctx context.Context, w http.ResponseWriter, kb Result, lastModified int64) error {
+	if kb.Failed() {
+		return encodeResultWithJSON(ctx, w, kb)
+	}
+
+	var body bytes.Buffer
+
+	err := json.NewEncoder(&body).Encode(kb)
+	if err != nil {
+		return errUnableToEncodeResult
+	}
+
+	w.Header().Set(ETagHeader, entityTag(body.Bytes()))
+
+	if lastModified > 0 {
+		w.Header().Set(LastModifiedHeader, time.Unix(lastModified, 0).UTC().Format(http.TimeFormat))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	_, err = w.Write(body.Bytes())
+	if err != nil {
+		return errUnableToEncodeResult
+	}
+
+	return nil
+}
+
+// entityTag returns the strong validator of the given body.
+func entityTag(body []byte) string {
+	sum := sha256.Sum256(body)
+
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// lastModifiedOf returns the last time the given kb changed.
+func lastModifiedOf(kb kbs.KB) int64 {
+	return max(kb.CreationDate, kb.UpdateDate)
+}
+
+// conditionalWriter answers with 304 Not Modified instead of the response
+// if the validators the encoder set match the conditions of the request.
+type conditionalWriter struct {
+	http.ResponseWriter
+	request     *http.Request
+	wroteHeader bool
+	notModified bool
+}
+
+// notModifiedWriter returns a writer that handles the conditional headers
+// of GET and HEAD requests.
+func notModifiedWriter(w http.ResponseWriter, r *http.Request) http.ResponseWriter {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return w
+	}
+
+	if r.Header.Get(IfNoneMatchHeader) == "" && r.Header.Get(IfModifiedSinceHeader) == "" {
+		return w
+	}
+
+	return &conditionalWriter{ResponseWriter: w, request: r}
+}
+
+func (c *conditionalWriter) WriteHeader(code int) {
+	if c.wroteHeader {
+		return
+	}
+
+	c.wroteHeader = true
+
+	if code == http.StatusOK && !modified(c.request, c.Header()) {
+		c.notModified = true
+
+		c.Header().Del("Content-Type")
+		c.Header().Del("Content-Length")
+		c.ResponseWriter.WriteHeader(http.StatusNotModified)
+
+		return
+	}
+
+	c.ResponseWriter.WriteHeader(code)
+}
+
+func (c *conditionalWriter) Write(body []byte) (int, error) {
+	c.WriteHeader(http.StatusOK)
+
+	if c.notModified {
+		return len(body), nil
+	}
+
+	return c.ResponseWriter.Write(body)
+}
+
+// modified evaluates the conditions of the request against the validators
+// of the response. If-Modified-Since is ignored if If-None-Match is sent.
+func modified(r *http.Request, header http.Header) bool {
+	if ifNoneMatch := r.Header.Get(IfNoneMatchHeader); ifNoneMatch != "" {
+		etag := header.Get(ETagHeader)
+		if etag == "" {
+			return true
+		}
+
+		return !matchesAny(ifNoneMatch, etag)
+	}
+
+	lastModified, err := http.ParseTime(header.Get(LastModifiedHeader))
+	if err != nil {
+		return true
+	}
+
+	since, err := http.ParseTime(r.Header.Get(IfModifiedSinceHeader))
+	if err != nil {
+		return true
+	}
+
+	return lastModified.After(since)
+}
+
+// matchesAny uses the weak comparison, as GET requests must.
+func matchesAny(ifNoneMatch, etag string) bool {
+	etag = strings.TrimPrefix(etag, "W/")
+
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+
+	return false
+}
@@ -4,13 +4,14 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
-	"log"
 	"log/slog"
 	"net/http"
 	"strconv"
 
 	"github.com/fernandoocampo/kb-store/apps/kbs/internal/kbs"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/requests"
 	"github.com/gorilla/mux"
 )
 
@@ -40,8 +41,28 @@ type KBDecoders struct {
 	CreateDecoder  *CreateKBDecoder
 	UpdateDecoder  *UpdateKBDecoder
 	DeleteDecoder  *DeleteKBDecoder
+	SubmitDecoder  *SubmitKBDecoder
+	ReviewDecoder  *ReviewKBDecoder
+	ArchiveDecoder *ArchiveKBDecoder
 }
 
+// searchOrderByFields are the values accepted by the orderby parameter.
+var searchOrderByFields = map[string]kbs.OrderByField{
+	"creation_date": kbs.CreationDateField,
+	"update_date":   kbs.UpdateDateField,
+}
+
+// searchOrders are the values accepted by the order parameter.
+var searchOrders = map[string]kbs.SortOrder{
+	"asc":  kbs.Ascending,
+	"desc": kbs.Descending,
+}
+
+var (
+	errInvalidOrderBy = errors.New("invalid orderby parameter, allowed values are creation_date and update_date")
+	errInvalidOrder   = errors.New("invalid order parameter, allowed values are asc and desc")
+)
+
 func NewKBDecoders(logger *slog.Logger) KBDecoders {
 	newDecoders := KBDecoders{
 		GetByIDDecoder: NewGetKBWithIDDecoder(logger),
@@ -49,6 +70,9 @@ func NewKBDecoders(logger *slog.Logger) KBDecoders {
 		CreateDecoder:  NewCreateKBDecoder(logger),
 		UpdateDecoder:  NewUpdateKBDecoder(logger),
 		DeleteDecoder:  NewDeleteKBDecoder(logger),
+		SubmitDecoder:  NewSubmitKBDecoder(logger),
+		ReviewDecoder:  NewReviewKBDecoder(logger),
+		ArchiveDecoder: NewArchiveKBDecoder(logger),
 	}
 
 	return newDecoders
@@ -113,6 +137,8 @@ func (g *DeleteKBDecoder) Decode(ctx context.Context, r *http.Request) (interfac
 }
 
 func (s *SearchKBsDecoder) Decode(ctx context.Context, r *http.Request) (interface{}, error) {
+	logger := requests.Logger(ctx, s.logger)
+
 	filterRequest := SearchKBFilter{
 		Page:     1,
 		PageSize: 10,
@@ -124,10 +150,18 @@ func (s *SearchKBsDecoder) Decode(ctx context.Context, r *http.Request) (interfa
 		filterRequest.EventID = v[0]
 	}
 
+	if v, ok := filters["user-id"]; ok {
+		filterRequest.UserID = v[0]
+	}
+
+	if v, ok := filters["state"]; ok {
+		filterRequest.State = v[0]
+	}
+
 	if v, ok := filters["page"]; ok {
 		page, err := strconv.Atoi(v[0])
 		if err != nil {
-			s.logger.Error("invalid page parameter, it must be an integer", "error", err)
+			logger.Error("invalid page parameter, it must be an integer", "error", err)
 			page = 1
 		}
 		filterRequest.Page = uint8(page)
@@ -135,14 +169,32 @@ func (s *SearchKBsDecoder) Decode(ctx context.Context, r *http.Request) (interfa
 	if v, ok := filters["pagesize"]; ok {
 		pageSize, err := strconv.Atoi(v[0])
 		if err != nil {
-			s.logger.Error("level", "ERROR", "invalid page size parameter, it must be an integer", "error", err)
+			logger.Error("level", "ERROR", "invalid page size parameter, it must be an integer", "error", err)
 			pageSize = 10
 		}
 		filterRequest.PageSize = uint8(pageSize)
 	}
 
 	if v, ok := filters["orderby"]; ok {
-		filterRequest.OrderBy = v[0]
+		orderBy, allowed := searchOrderByFields[v[0]]
+		if !allowed {
+			logger.Debug("search kbs request has an invalid orderby parameter", slog.String("orderby", v[0]))
+
+			return nil, fmt.Errorf("%w: %q", errInvalidOrderBy, v[0])
+		}
+
+		filterRequest.OrderBy = orderBy
+	}
+
+	if v, ok := filters["order"]; ok {
+		order, allowed := searchOrders[v[0]]
+		if !allowed {
+			logger.Debug("search kbs request has an invalid order parameter", slog.String("order", v[0]))
+
+			return nil, fmt.Errorf("%w: %q", errInvalidOrder, v[0])
+		}
+
+		filterRequest.Order = order
 	}
 
 	filter := filterRequest.toSearchKBFilter()
@@ -151,7 +203,9 @@ func (s *SearchKBsDecoder) Decode(ctx context.Context, r *http.Request) (interfa
 }
 
 func (c *CreateKBDecoder) Decode(ctx context.Context, r *http.Request) (interface{}, error) {
-	log.Println("level", "DEBUG", "msg", "decoding new kb request")
+	logger := requests.Logger(ctx, c.logger)
+
+	logger.Debug("decoding new kb request")
 	var req NewKB
 	defer r.Body.Close()
 
@@ -162,19 +216,24 @@ func (c *CreateKBDecoder) Decode(ctx context.Context, r *http.Request) (interfac
 
 	err = json.Unmarshal(body, &req)
 	if err != nil {
-		log.Println("level", "ERROR", "new kb request could not be decoded. Request: %q because of: %s", string(body), err.Error())
+		// the body is not logged, it contains the kb content.
+		logger.Error("new kb request could not be decoded",
+			slog.Int("body_length", len(body)),
+			slog.String("error", err.Error()))
 		return nil, err
 	}
 
-	log.Println("level", "DEBUG", "msg", "kb request was decoded", "request", req)
-
 	domainKB := req.toKB()
 
+	logger.Debug("kb request was decoded", slog.Any("request", domainKB))
+
 	return domainKB, nil
 }
 
 func (u *UpdateKBDecoder) Decode(ctx context.Context, r *http.Request) (interface{}, error) {
-	log.Println("level", "DEBUG", "msg", "decoding update kb request")
+	logger := requests.Logger(ctx, u.logger)
+
+	logger.Debug("decoding update kb request")
 	var req UpdateKB
 	defer r.Body.Close()
 
@@ -185,13 +244,35 @@ func (u *UpdateKBDecoder) Decode(ctx context.Context, r *http.Request) (interfac
 
 	err = json.Unmarshal(body, &req)
 	if err != nil {
-		log.Println("level", "ERROR", "update kb request could not be decoded. Request: %q because of: %s", string(body), err.Error())
+		// the body is not logged, it contains the kb content.
+		logger.Error("update kb request could not be decoded",
+			slog.Int("body_length", len(body)),
+			slog.String("error", err.Error()))
 		return nil, err
 	}
 
-	log.Println("level", "DEBUG", "msg", "kb request was decoded", "request", req)
-
 	domainKB := req.toKB()
 
+	logger.Debug("kb request was decoded", slog.Any("request", domainKB))
+
 	return domainKB, nil
 }
+
+// decodeJSONBody reads the request body and decodes it into the given value.
+func decodeJSONBody(r *http.Request, value any) error {
+	defer r.Body.Close()
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(body, value)
+}
+
+// pathID returns the id path variable of the request.
+func pathID(r *http.Request) (string, bool) {
+	id, ok := mux.Vars(r)["id"]
+
+	return id, ok
+}
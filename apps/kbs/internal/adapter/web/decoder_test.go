@@ -45,7 +45,7 @@ func TestSearchKBsDecoder(t *testing.T) {
 	logger := newDummyLogger()
 	pageSize := "15"
 	pageNumber := "1"
-	orderBy := "name"
+	orderBy := "update_date"
 	givenEventID := "drila"
 	decoder := web.NewSearchKBsDecoder(logger)
 
@@ -54,14 +54,18 @@ func TestSearchKBsDecoder(t *testing.T) {
 	requestQuery.Add("page", pageNumber)
 	requestQuery.Add("pagesize", pageSize)
 	requestQuery.Add("event-id", givenEventID)
+	requestQuery.Add("user-id", "mono")
 	requestQuery.Add("orderby", orderBy)
+	requestQuery.Add("order", "asc")
 	searchKBsRequest.URL.RawQuery = requestQuery.Encode()
 
 	expectedFilter := kbs.QueryFilter{
 		EventID:     "drila",
+		UserID:      "mono",
 		PageNumber:  1,
 		RowsPerPage: 15,
-		OrderBy:     "name",
+		OrderBy:     kbs.UpdateDateField,
+		Order:       kbs.Ascending,
 	}
 
 	// When
@@ -72,6 +76,29 @@ func TestSearchKBsDecoder(t *testing.T) {
 	assert.Equal(t, expectedFilter, got)
 }
 
+func TestSearchKBsDecoderRejectsUnknownOrder(t *testing.T) {
+	cases := map[string]url.Values{
+		"orderby": {"orderby": {"name"}},
+		"order":   {"orderby": {"creation_date"}, "order": {"random"}},
+	}
+
+	for name, query := range cases {
+		t.Run(name, func(t *testing.T) {
+			// Given
+			decoder := web.NewSearchKBsDecoder(newDummyLogger())
+			searchKBsRequest := createHTTPRequest(t, nil, http.MethodGet, "http://anyhost/kbs")
+			searchKBsRequest.URL.RawQuery = query.Encode()
+
+			// When
+			got, err := decoder.Decode(context.TODO(), searchKBsRequest)
+
+			// Then
+			assert.Error(t, err)
+			assert.Nil(t, got)
+		})
+	}
+}
+
 func TestCreateKBDecoder(t *testing.T) {
 	// Given
 	givenCreateBody := []byte(`{"user_id":"drila","username":"alird","content":"drila.alird","event_id":"drila.alird@lemail.com"}`)
@@ -9,6 +9,7 @@ import (
 	"net/http"
 
 	"github.com/fernandoocampo/kb-store/apps/kbs/internal/kbs"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/requests"
 )
 
 type GetKBWithIDEncoder struct {
@@ -37,6 +38,7 @@ type KBEncoders struct {
 	CreateEncoder  *CreateKBEncoder
 	UpdateEncoder  *UpdateKBEncoder
 	DeleteEncoder  *DeleteKBEncoder
+	StateEncoder   *ChangeStateEncoder
 }
 
 var (
@@ -50,6 +52,7 @@ func NewKBEncoders(logger *slog.Logger) KBEncoders {
 		CreateEncoder:  NewCreateKBEncoder(logger),
 		UpdateEncoder:  NewUpdateKBEncoder(logger),
 		DeleteEncoder:  NewDeleteKBEncoder(logger),
+		StateEncoder:   NewChangeStateEncoder(logger),
 	}
 
 	return newEncoders
@@ -96,13 +99,15 @@ func NewDeleteKBEncoder(logger *slog.Logger) *DeleteKBEncoder {
 }
 
 func (c *CreateKBEncoder) Encode(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	logger := requests.Logger(ctx, c.logger)
+
 	result, ok := response.(kbs.CreateKBResult)
 	if !ok {
-		c.logger.Error("cannot transform to kbs.CreateKBResult", "received", fmt.Sprintf("%+v", response))
+		logger.Error("cannot transform to kbs.CreateKBResult", "received", fmt.Sprintf("%T", response))
 		return errors.New("cannot build create kb response")
 	}
 
-	err := encodeResultWithJSON(w, toCreateKBResponse(result))
+	err := encodeResultWithJSON(ctx, w, toCreateKBResponse(result))
 	if err != nil {
 		return fmt.Errorf("unable to encode create kb result: %w", err)
 	}
@@ -111,13 +116,15 @@ func (c *CreateKBEncoder) Encode(ctx context.Context, w http.ResponseWriter, res
 }
 
 func (u *UpdateKBEncoder) Encode(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	logger := requests.Logger(ctx, u.logger)
+
 	result, ok := response.(kbs.UpdateKBResult)
 	if !ok {
-		u.logger.Error("cannot transform to kbs.UpdateKBResult", "received", fmt.Sprintf("%+v", response))
+		logger.Error("cannot transform to kbs.UpdateKBResult", "received", fmt.Sprintf("%T", response))
 		return errors.New("cannot build update kb response")
 	}
 
-	err := encodeResultWithJSON(w, toUpdateKBResponse(result))
+	err := encodeResultWithJSON(ctx, w, toUpdateKBResponse(result))
 	if err != nil {
 		return fmt.Errorf("unable to encode update kb result: %w", err)
 	}
@@ -126,13 +133,15 @@ func (u *UpdateKBEncoder) Encode(ctx context.Context, w http.ResponseWriter, res
 }
 
 func (u *DeleteKBEncoder) Encode(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	logger := requests.Logger(ctx, u.logger)
+
 	result, ok := response.(kbs.DeleteKBResult)
 	if !ok {
-		u.logger.Error("cannot transform to kbs.DeleteKBResult", "received", fmt.Sprintf("%+v", response))
+		logger.Error("cannot transform to kbs.DeleteKBResult", "received", fmt.Sprintf("%T", response))
 		return errors.New("cannot build delete kb response")
 	}
 
-	err := encodeResultWithJSON(w, toDeleteKBResponse(result))
+	err := encodeResultWithJSON(ctx, w, toDeleteKBResponse(result))
 	if err != nil {
 		return fmt.Errorf("unable to encode delete kb result: %w", err)
 	}
@@ -141,13 +150,20 @@ func (u *DeleteKBEncoder) Encode(ctx context.Context, w http.ResponseWriter, res
 }
 
 func (g *GetKBWithIDEncoder) Encode(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	logger := requests.Logger(ctx, g.logger)
+
 	result, ok := response.(kbs.GetKBWithIDResult)
 	if !ok {
-		g.logger.Error("cannot transform to kbs.GetKBWithIDResult", "received", fmt.Sprintf("%+v", response))
+		logger.Error("cannot transform to kbs.GetKBWithIDResult", "received", fmt.Sprintf("%T", response))
 		return errors.New("cannot build get kb response")
 	}
 
-	err := encodeResultWithJSON(w, toGetKBWithIDResponse(result))
+	var lastModified int64
+	if result.KB != nil {
+		lastModified = lastModifiedOf(*result.KB)
+	}
+
+	err := encodeCacheableResultWithJSON(ctx, w, toGetKBWithIDResponse(result), lastModified)
 	if err != nil {
 		return fmt.Errorf("unable to encode get kb by id result: %w", err)
 	}
@@ -156,13 +172,18 @@ func (g *GetKBWithIDEncoder) Encode(ctx context.Context, w http.ResponseWriter,
 }
 
 func (s *SearchKBsEncoder) Encode(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	logger := requests.Logger(ctx, s.logger)
+
 	result, ok := response.(kbs.SearchKBsDataResult)
 	if !ok {
-		s.logger.Error("cannot transform to kbs.SearchKBsDataResult", "received", fmt.Sprintf("%T", response))
+		logger.Error("cannot transform to kbs.SearchKBsDataResult", "received", fmt.Sprintf("%T", response))
 		return errors.New("cannot build search kbs response")
 	}
 
-	err := encodeResultWithJSON(w, toSearchKBsResponse(result))
+	// the etag of the body covers the whole page, so it changes if any kb
+	// of the page changes. There is no Last-Modified, because the dates of
+	// the kbs don't change when one of them is deleted.
+	err := encodeCacheableResultWithJSON(ctx, w, toSearchKBsResponse(result), 0)
 	if err != nil {
 		return fmt.Errorf("unable to encode search kbs result: %w", err)
 	}
@@ -170,9 +191,13 @@ func (s *SearchKBsEncoder) Encode(ctx context.Context, w http.ResponseWriter, re
 	return nil
 }
 
-func encodeResultWithJSON(w http.ResponseWriter, kb Result) error {
+func encodeResultWithJSON(ctx context.Context, w http.ResponseWriter, kb Result) error {
 	w.Header().Set("Content-Type", "application/json")
 
+	if metadata := requests.FromContext(ctx); metadata.DryRun {
+		kb.DryRun = &DryRun{Writes: metadata.Plan.Writes()}
+	}
+
 	if kb.Failed() {
 		w.WriteHeader(http.StatusInternalServerError)
 	}
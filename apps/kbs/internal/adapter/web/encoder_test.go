@@ -93,3 +93,94 @@ func createWebResult(t *testing.T, body io.Reader, data any) web.Result {
 
 	return result
 }
+
+func TestConditionalGetKB(t *testing.T) {
+	// Given
+	kb := kbs.KB{ID: "kb-1", Content: "first", CreationDate: 1700000000, UpdateDate: 1700000600}
+	handler := web.NewHandler().
+		WithEndpoint(endpointFunc(func(ctx context.Context, request interface{}) (interface{}, error) {
+			return kbs.GetKBWithIDResult{KB: &kb}, nil
+		})).
+		WithDecoder(decoderFunc(func(ctx context.Context, r *http.Request) (interface{}, error) {
+			return kb.ID, nil
+		})).
+		WithEncoder(web.NewGetKBWithIDEncoder(newDummyLogger()))
+
+	get := func(header, value string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodGet, "/kbs/kb-1", nil)
+		if header != "" {
+			request.Header.Set(header, value)
+		}
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+
+		return recorder
+	}
+
+	// When
+	first := get("", "")
+	etag := first.Header().Get(web.ETagHeader)
+	sameETag := get(web.IfNoneMatchHeader, `"other", `+etag)
+	notModifiedSince := get(web.IfModifiedSinceHeader, "Tue, 14 Nov 2023 22:23:20 GMT")
+	modifiedSince := get(web.IfModifiedSinceHeader, "Tue, 14 Nov 2023 22:13:20 GMT")
+	kb.Content = "second"
+	changed := get(web.IfNoneMatchHeader, etag)
+
+	// Then
+	assert.Equal(t, http.StatusOK, first.Code)
+	assert.Regexp(t, `^"[0-9a-f]{32}"$`, etag)
+	assert.Equal(t, "Tue, 14 Nov 2023 22:23:20 GMT", first.Header().Get(web.LastModifiedHeader))
+
+	assert.Equal(t, http.StatusNotModified, sameETag.Code)
+	assert.Empty(t, sameETag.Body.String())
+	assert.Equal(t, etag, sameETag.Header().Get(web.ETagHeader))
+
+	assert.Equal(t, http.StatusNotModified, notModifiedSince.Code)
+	assert.Equal(t, http.StatusOK, modifiedSince.Code)
+
+	assert.Equal(t, http.StatusOK, changed.Code)
+	assert.NotEqual(t, etag, changed.Header().Get(web.ETagHeader))
+	assert.Contains(t, changed.Body.String(), "second")
+}
+
+func TestEncodeSearchKBsAddsCollectionETag(t *testing.T) {
+	// Given
+	encoder := web.NewSearchKBsEncoder(newDummyLogger())
+	page := kbs.SearchKBsDataResult{
+		SearchResult: kbs.SearchKBsResult{
+			KBs:         []kbs.KB{{ID: "kb-1", CreationDate: 1700000000}},
+			Total:       1,
+			Page:        1,
+			RowsPerPage: 10,
+		},
+	}
+	changedPage := page
+	changedPage.SearchResult.KBs = []kbs.KB{{ID: "kb-1", CreationDate: 1700000000}, {ID: "kb-2", CreationDate: 1700000000}}
+
+	first := httptest.NewRecorder()
+	again := httptest.NewRecorder()
+	changed := httptest.NewRecorder()
+
+	// When
+	assert.NoError(t, encoder.Encode(context.TODO(), first, page))
+	assert.NoError(t, encoder.Encode(context.TODO(), again, page))
+	assert.NoError(t, encoder.Encode(context.TODO(), changed, changedPage))
+
+	// Then
+	assert.NotEmpty(t, first.Header().Get(web.ETagHeader))
+	assert.Equal(t, first.Header().Get(web.ETagHeader), again.Header().Get(web.ETagHeader))
+	assert.NotEqual(t, first.Header().Get(web.ETagHeader), changed.Header().Get(web.ETagHeader))
+	assert.Empty(t, first.Header().Get(web.LastModifiedHeader))
+}
+
+type endpointFunc func(ctx context.Context, request interface{}) (interface{}, error)
+
+func (e endpointFunc) Do(ctx context.Context, request interface{}) (interface{}, error) {
+	return e(ctx, request)
+}
+
+type decoderFunc func(ctx context.Context, r *http.Request) (interface{}, error)
+
+func (d decoderFunc) Decode(ctx context.Context, r *http.Request) (interface{}, error) {
+	return d(ctx, r)
+}
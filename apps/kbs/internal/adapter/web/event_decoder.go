@@ -0,0 +1,175 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/events"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/kbs"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/requests"
+)
+
+type GetEventWithIDDecoder struct {
+	logger *slog.Logger
+}
+
+type SearchEventsDecoder struct {
+	logger *slog.Logger
+}
+
+type CreateEventDecoder struct {
+	logger *slog.Logger
+}
+
+type UpdateEventDecoder struct {
+	logger *slog.Logger
+}
+
+type DeleteEventDecoder struct {
+	logger *slog.Logger
+}
+
+// SearchEventKBsDecoder decodes a search of the kbs of the event in the path.
+type SearchEventKBsDecoder struct {
+	logger        *slog.Logger
+	searchDecoder *SearchKBsDecoder
+}
+
+type EventDecoders struct {
+	GetByIDDecoder   *GetEventWithIDDecoder
+	SearchDecoder    *SearchEventsDecoder
+	CreateDecoder    *CreateEventDecoder
+	UpdateDecoder    *UpdateEventDecoder
+	DeleteDecoder    *DeleteEventDecoder
+	SearchKBsDecoder *SearchEventKBsDecoder
+}
+
+var errEventIDNotProvided = errors.New("event ID was not provided")
+
+func NewEventDecoders(logger *slog.Logger) EventDecoders {
+	return EventDecoders{
+		GetByIDDecoder:   NewGetEventWithIDDecoder(logger),
+		SearchDecoder:    NewSearchEventsDecoder(logger),
+		CreateDecoder:    NewCreateEventDecoder(logger),
+		UpdateDecoder:    NewUpdateEventDecoder(logger),
+		DeleteDecoder:    NewDeleteEventDecoder(logger),
+		SearchKBsDecoder: NewSearchEventKBsDecoder(logger),
+	}
+}
+
+func NewGetEventWithIDDecoder(logger *slog.Logger) *GetEventWithIDDecoder {
+	return &GetEventWithIDDecoder{
+		logger: logger,
+	}
+}
+
+func NewSearchEventsDecoder(logger *slog.Logger) *SearchEventsDecoder {
+	return &SearchEventsDecoder{
+		logger: logger,
+	}
+}
+
+func NewCreateEventDecoder(logger *slog.Logger) *CreateEventDecoder {
+	return &CreateEventDecoder{
+		logger: logger,
+	}
+}
+
+func NewUpdateEventDecoder(logger *slog.Logger) *UpdateEventDecoder {
+	return &UpdateEventDecoder{
+		logger: logger,
+	}
+}
+
+func NewDeleteEventDecoder(logger *slog.Logger) *DeleteEventDecoder {
+	return &DeleteEventDecoder{
+		logger: logger,
+	}
+}
+
+func NewSearchEventKBsDecoder(logger *slog.Logger) *SearchEventKBsDecoder {
+	return &SearchEventKBsDecoder{
+		logger:        logger,
+		searchDecoder: NewSearchKBsDecoder(logger),
+	}
+}
+
+func (g *GetEventWithIDDecoder) Decode(ctx context.Context, r *http.Request) (interface{}, error) {
+	eventID, ok := pathID(r)
+	if !ok {
+		return nil, errEventIDNotProvided
+	}
+
+	return kbs.EventID(eventID), nil
+}
+
+func (d *DeleteEventDecoder) Decode(ctx context.Context, r *http.Request) (interface{}, error) {
+	eventID, ok := pathID(r)
+	if !ok {
+		return nil, errEventIDNotProvided
+	}
+
+	return kbs.EventID(eventID), nil
+}
+
+func (s *SearchEventsDecoder) Decode(ctx context.Context, r *http.Request) (interface{}, error) {
+	filter := events.QueryFilter{
+		Status: events.Status(r.URL.Query().Get("status")),
+	}
+
+	return filter, nil
+}
+
+func (c *CreateEventDecoder) Decode(ctx context.Context, r *http.Request) (interface{}, error) {
+	logger := requests.Logger(ctx, c.logger)
+
+	var req NewEvent
+
+	err := decodeJSONBody(r, &req)
+	if err != nil {
+		logger.Error("new event request could not be decoded", slog.String("error", err.Error()))
+
+		return nil, err
+	}
+
+	return req.toEvent(), nil
+}
+
+func (u *UpdateEventDecoder) Decode(ctx context.Context, r *http.Request) (interface{}, error) {
+	logger := requests.Logger(ctx, u.logger)
+
+	var req UpdateEvent
+
+	err := decodeJSONBody(r, &req)
+	if err != nil {
+		logger.Error("update event request could not be decoded", slog.String("error", err.Error()))
+
+		return nil, err
+	}
+
+	return req.toEvent(), nil
+}
+
+// Decode decodes the kbs search filters and takes the event id from the path.
+func (s *SearchEventKBsDecoder) Decode(ctx context.Context, r *http.Request) (interface{}, error) {
+	eventID, ok := pathID(r)
+	if !ok {
+		return nil, errEventIDNotProvided
+	}
+
+	request, err := s.searchDecoder.Decode(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	filter, ok := request.(kbs.QueryFilter)
+	if !ok {
+		return nil, errors.New("unable to decode kbs filters")
+	}
+
+	filter.EventID = eventID
+
+	return filter, nil
+}
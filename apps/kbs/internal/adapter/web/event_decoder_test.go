@@ -0,0 +1,44 @@
+package web_test
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/adapter/web"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/kbs"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearchEventKBsDecoder(t *testing.T) {
+	// Given
+	var emptyBody []byte
+	ctx := context.TODO()
+	givenEventID := "6763fe1b-9391-49f2-acf1-5069e2a9cb21"
+	decoder := web.NewSearchEventKBsDecoder(newDummyLogger())
+
+	searchRequest := createHTTPRequest(t, emptyBody, http.MethodGet, "http://anyhost/events/"+givenEventID+"/kbs")
+	requestQuery := url.Values{}
+	requestQuery.Add("page", "2")
+	requestQuery.Add("pagesize", "5")
+	requestQuery.Add("event-id", "ignored")
+	searchRequest.URL.RawQuery = requestQuery.Encode()
+	searchRequest = mux.SetURLVars(searchRequest, map[string]string{
+		"id": givenEventID,
+	})
+
+	expectedFilter := kbs.QueryFilter{
+		EventID:     givenEventID,
+		PageNumber:  2,
+		RowsPerPage: 5,
+	}
+
+	// When
+	got, err := decoder.Decode(ctx, searchRequest)
+
+	// Then
+	assert.NoError(t, err)
+	assert.Equal(t, expectedFilter, got)
+}
@@ -0,0 +1,165 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/events"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/requests"
+)
+
+type GetEventWithIDEncoder struct {
+	logger *slog.Logger
+}
+
+type SearchEventsEncoder struct {
+	logger *slog.Logger
+}
+
+type CreateEventEncoder struct {
+	logger *slog.Logger
+}
+
+type UpdateEventEncoder struct {
+	logger *slog.Logger
+}
+
+type DeleteEventEncoder struct {
+	logger *slog.Logger
+}
+
+type EventEncoders struct {
+	GetByIDEncoder *GetEventWithIDEncoder
+	SearchEncoder  *SearchEventsEncoder
+	CreateEncoder  *CreateEventEncoder
+	UpdateEncoder  *UpdateEventEncoder
+	DeleteEncoder  *DeleteEventEncoder
+}
+
+func NewEventEncoders(logger *slog.Logger) EventEncoders {
+	return EventEncoders{
+		GetByIDEncoder: NewGetEventWithIDEncoder(logger),
+		SearchEncoder:  NewSearchEventsEncoder(logger),
+		CreateEncoder:  NewCreateEventEncoder(logger),
+		UpdateEncoder:  NewUpdateEventEncoder(logger),
+		DeleteEncoder:  NewDeleteEventEncoder(logger),
+	}
+}
+
+func NewGetEventWithIDEncoder(logger *slog.Logger) *GetEventWithIDEncoder {
+	return &GetEventWithIDEncoder{
+		logger: logger,
+	}
+}
+
+func NewSearchEventsEncoder(logger *slog.Logger) *SearchEventsEncoder {
+	return &SearchEventsEncoder{
+		logger: logger,
+	}
+}
+
+func NewCreateEventEncoder(logger *slog.Logger) *CreateEventEncoder {
+	return &CreateEventEncoder{
+		logger: logger,
+	}
+}
+
+func NewUpdateEventEncoder(logger *slog.Logger) *UpdateEventEncoder {
+	return &UpdateEventEncoder{
+		logger: logger,
+	}
+}
+
+func NewDeleteEventEncoder(logger *slog.Logger) *DeleteEventEncoder {
+	return &DeleteEventEncoder{
+		logger: logger,
+	}
+}
+
+func (c *CreateEventEncoder) Encode(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	logger := requests.Logger(ctx, c.logger)
+
+	result, ok := response.(events.CreateEventResult)
+	if !ok {
+		logger.Error("cannot transform to events.CreateEventResult", "received", fmt.Sprintf("%T", response))
+		return errors.New("cannot build create event response")
+	}
+
+	err := encodeResultWithJSON(ctx, w, toCreateEventResponse(result))
+	if err != nil {
+		return fmt.Errorf("unable to encode create event result: %w", err)
+	}
+
+	return nil
+}
+
+func (u *UpdateEventEncoder) Encode(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	logger := requests.Logger(ctx, u.logger)
+
+	result, ok := response.(events.UpdateEventResult)
+	if !ok {
+		logger.Error("cannot transform to events.UpdateEventResult", "received", fmt.Sprintf("%T", response))
+		return errors.New("cannot build update event response")
+	}
+
+	err := encodeResultWithJSON(ctx, w, toUpdateEventResponse(result))
+	if err != nil {
+		return fmt.Errorf("unable to encode update event result: %w", err)
+	}
+
+	return nil
+}
+
+func (d *DeleteEventEncoder) Encode(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	logger := requests.Logger(ctx, d.logger)
+
+	result, ok := response.(events.DeleteEventResult)
+	if !ok {
+		logger.Error("cannot transform to events.DeleteEventResult", "received", fmt.Sprintf("%T", response))
+		return errors.New("cannot build delete event response")
+	}
+
+	err := encodeResultWithJSON(ctx, w, toDeleteEventResponse(result))
+	if err != nil {
+		return fmt.Errorf("unable to encode delete event result: %w", err)
+	}
+
+	return nil
+}
+
+func (g *GetEventWithIDEncoder) Encode(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	logger := requests.Logger(ctx, g.logger)
+
+	result, ok := response.(events.GetEventWithIDResult)
+	if !ok {
+		logger.Error("cannot transform to events.GetEventWithIDResult", "received", fmt.Sprintf("%T", response))
+		return errors.New("cannot build get event response")
+	}
+
+	err := encodeResultWithJSON(ctx, w, toGetEventWithIDResponse(result))
+	if err != nil {
+		return fmt.Errorf("unable to encode get event by id result: %w", err)
+	}
+
+	return nil
+}
+
+func (s *SearchEventsEncoder) Encode(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	logger := requests.Logger(ctx, s.logger)
+
+	result, ok := response.(events.SearchEventsResult)
+	if !ok {
+		logger.Error("cannot transform to events.SearchEventsResult", "received", fmt.Sprintf("%T", response))
+		return errors.New("cannot build search events response")
+	}
+
+	err := encodeResultWithJSON(ctx, w, toSearchEventsResponse(result))
+	if err != nil {
+		return fmt.Errorf("unable to encode search events result: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,168 @@
+package web
+
+import (
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/events"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/kbs"
+)
+
+// Event contains event data.
+type Event struct {
+	ID           string   `json:"id"`
+	Name         string   `json:"name"`
+	Description  string   `json:"description"`
+	StartDate    int64    `json:"start_date"`
+	EndDate      int64    `json:"end_date"`
+	Status       string   `json:"status"`
+	Organizers   []string `json:"organizers"`
+	CreationDate int64    `json:"creation_date"`
+	UpdateDate   int64    `json:"update_date"`
+}
+
+// NewEvent contains the expected data for a new event.
+type NewEvent struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	StartDate   int64    `json:"start_date"`
+	EndDate     int64    `json:"end_date"`
+	Status      string   `json:"status"`
+	Organizers  []string `json:"organizers"`
+}
+
+// UpdateEvent contains the expected data to update an event.
+type UpdateEvent struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	StartDate   int64    `json:"start_date"`
+	EndDate     int64    `json:"end_date"`
+	Status      string   `json:"status"`
+	Organizers  []string `json:"organizers"`
+}
+
+// toEvent transforms a domain event to a web event.
+func toEvent(event *events.Event) *Event {
+	if event == nil {
+		return nil
+	}
+	webEvent := Event{
+		ID:           event.ID.String(),
+		Name:         event.Name,
+		Description:  event.Description,
+		StartDate:    event.StartDate,
+		EndDate:      event.EndDate,
+		Status:       event.Status.String(),
+		Organizers:   fromUserIDs(event.Organizers),
+		CreationDate: event.CreationDate,
+		UpdateDate:   event.UpdateDate,
+	}
+	return &webEvent
+}
+
+// toEvent transforms new event to a domain object.
+func (n *NewEvent) toEvent() *events.NewEvent {
+	if n == nil {
+		return nil
+	}
+	return &events.NewEvent{
+		Name:        n.Name,
+		Description: n.Description,
+		StartDate:   n.StartDate,
+		EndDate:     n.EndDate,
+		Status:      events.Status(n.Status),
+		Organizers:  toUserIDs(n.Organizers),
+	}
+}
+
+// toEvent transforms update event to a domain object.
+func (u *UpdateEvent) toEvent() *events.UpdateEvent {
+	if u == nil {
+		return nil
+	}
+	return &events.UpdateEvent{
+		ID:          kbs.EventID(u.ID),
+		Name:        u.Name,
+		Description: u.Description,
+		StartDate:   u.StartDate,
+		EndDate:     u.EndDate,
+		Status:      events.Status(u.Status),
+		Organizers:  toUserIDs(u.Organizers),
+	}
+}
+
+func toUserIDs(ids []string) []kbs.UserID {
+	userIDs := make([]kbs.UserID, 0, len(ids))
+	for _, v := range ids {
+		userIDs = append(userIDs, kbs.UserID(v))
+	}
+	return userIDs
+}
+
+func fromUserIDs(userIDs []kbs.UserID) []string {
+	ids := make([]string, 0, len(userIDs))
+	for _, v := range userIDs {
+		ids = append(ids, v.String())
+	}
+	return ids
+}
+
+func toCreateEventResponse(eventResult events.CreateEventResult) Result {
+	var result Result
+	if eventResult.Err == "" {
+		result.Success = true
+		result.Data = eventResult.ID
+	}
+	if eventResult.Err != "" {
+		result.Errors = []string{eventResult.Err}
+	}
+	return result
+}
+
+func toUpdateEventResponse(eventResult events.UpdateEventResult) Result {
+	var result Result
+	if eventResult.Err == "" {
+		result.Success = true
+	}
+	if eventResult.Err != "" {
+		result.Errors = []string{eventResult.Err}
+	}
+	return result
+}
+
+func toDeleteEventResponse(eventResult events.DeleteEventResult) Result {
+	var result Result
+	if eventResult.Err == "" {
+		result.Success = true
+	}
+	if eventResult.Err != "" {
+		result.Errors = []string{eventResult.Err}
+	}
+	return result
+}
+
+func toGetEventWithIDResponse(eventResult events.GetEventWithIDResult) Result {
+	var result Result
+	if eventResult.Err == "" {
+		result.Success = true
+		result.Data = toEvent(eventResult.Event)
+	}
+	if eventResult.Err != "" {
+		result.Errors = []string{eventResult.Err}
+	}
+	return result
+}
+
+func toSearchEventsResponse(eventResult events.SearchEventsResult) Result {
+	var result Result
+	if eventResult.Err == "" {
+		eventsFound := make([]Event, 0, len(eventResult.Events))
+		for _, v := range eventResult.Events {
+			eventsFound = append(eventsFound, *toEvent(&v))
+		}
+		result.Success = true
+		result.Data = eventsFound
+	}
+	if eventResult.Err != "" {
+		result.Errors = []string{eventResult.Err}
+	}
+	return result
+}
@@ -0,0 +1,26 @@
+package web
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+)
+
+// HealthCheckDecoder decodes health check requests, they don't have parameters.
+type HealthCheckDecoder struct {
+	logger *slog.Logger
+}
+
+type HealthDecoders struct {
+	CheckDecoder *HealthCheckDecoder
+}
+
+func NewHealthDecoders(logger *slog.Logger) HealthDecoders {
+	return HealthDecoders{
+		CheckDecoder: &HealthCheckDecoder{logger: logger},
+	}
+}
+
+func (h *HealthCheckDecoder) Decode(ctx context.Context, r *http.Request) (interface{}, error) {
+	return nil, nil
+}
@@ -0,0 +1,53 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/health"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/requests"
+)
+
+// HealthReportEncoder writes the health report as it is, so operators get
+// the detail of every check. Unhealthy reports are answered with 503.
+type HealthReportEncoder struct {
+	logger *slog.Logger
+}
+
+type HealthEncoders struct {
+	ReportEncoder *HealthReportEncoder
+}
+
+func NewHealthEncoders(logger *slog.Logger) HealthEncoders {
+	return HealthEncoders{
+		ReportEncoder: &HealthReportEncoder{logger: logger},
+	}
+}
+
+func (h *HealthReportEncoder) Encode(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	logger := requests.Logger(ctx, h.logger)
+
+	report, ok := response.(health.Report)
+	if !ok {
+		logger.Error("cannot transform to health.Report", "received", fmt.Sprintf("%T", response))
+		return errors.New("cannot build health report response")
+	}
+
+	w.Header().Set("Content-Type", jsonContentType)
+	w.Header().Set("Cache-Control", "no-store")
+
+	if !report.IsUp() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+
+	err := json.NewEncoder(w).Encode(report)
+	if err != nil {
+		return fmt.Errorf("unable to encode health report: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,165 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/idempotency"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/requests"
+)
+
+// idempotency headers.
+const (
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// ReplayedHeader tells the client the response is the stored one.
+	ReplayedHeader = "Idempotent-Replayed"
+)
+
+// maxIdempotencyKeyLength is the longest idempotency key accepted.
+const maxIdempotencyKeyLength = 255
+
+// idempotencyReleaseTimeout bounds the write that completes or aborts a
+// key, it doesn't end with the request.
+const idempotencyReleaseTimeout = 5 * time.Second
+
+// maxIdempotentBodyBytes is the largest body of a request with an
+// idempotency key, the body is read in memory to fingerprint it.
+const maxIdempotentBodyBytes = 16 << 20
+
+// IdempotencyKeeper keeps the responses of the requests made with an
+// idempotency key.
+type IdempotencyKeeper interface {
+	Begin(ctx context.Context, id, fingerprint string) (idempotency.Lock, *idempotency.Response, error)
+	Complete(ctx context.Context, lock idempotency.Lock, fingerprint string, response idempotency.Response) error
+	Abort(ctx context.Context, lock idempotency.Lock) error
+}
+
+// responseRecorder keeps a copy of the response written by the handler.
+type responseRecorder struct {
+	http.ResponseWriter
+	code int
+	body bytes.Buffer
+}
+
+// Idempotency returns a middleware that makes POST requests sent with the
+// Idempotency-Key header safe to retry. The first response of a key is
+// stored and replayed to retries, concurrent requests with the same key
+// get 409 and requests that reuse a key with a different payload get 422.
+// Failed requests, 5xx, release the key so they can be retried. Dry runs
+// are not stored. It must run after RequestMetadata.
+func Idempotency(keeper IdempotencyKeeper, logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyKeyHeader)
+			if key == "" || r.Method != http.MethodPost || requests.IsDryRun(r.Context()) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if len(key) > maxIdempotencyKeyLength {
+				writeError(w, http.StatusBadRequest, "idempotency key is too long")
+				return
+			}
+
+			body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxIdempotentBodyBytes))
+			r.Body.Close()
+
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				writeError(w, http.StatusRequestEntityTooLarge, "request body is too large")
+				return
+			}
+
+			if err != nil {
+				writeError(w, http.StatusBadRequest, "unable to read request body")
+				return
+			}
+
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			ctx := r.Context()
+			id := idempotency.NewID(requests.FromContext(ctx).Principal, key)
+			fingerprint := idempotency.Fingerprint(r.Method, r.URL.Path, body)
+
+			lock, stored, err := keeper.Begin(ctx, id, fingerprint)
+			switch {
+			case errors.Is(err, idempotency.ErrInProgress):
+				writeError(w, http.StatusConflict, err.Error())
+				return
+			case errors.Is(err, idempotency.ErrMismatch):
+				writeError(w, http.StatusUnprocessableEntity, err.Error())
+				return
+			case err != nil:
+				writeError(w, http.StatusServiceUnavailable, err.Error())
+				return
+			case stored != nil:
+				writeStoredResponse(w, *stored)
+				return
+			}
+
+			recorder := &responseRecorder{ResponseWriter: w, code: http.StatusOK}
+
+			next.ServeHTTP(recorder, r)
+
+			// the response was already sent, store failures only mean
+			// retries will be served again. The key is released even if
+			// the client is gone, it is likely to retry.
+			releaseCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), idempotencyReleaseTimeout)
+			defer cancel()
+
+			if recorder.code >= http.StatusInternalServerError {
+				err = keeper.Abort(releaseCtx, lock)
+			} else {
+				err = keeper.Complete(releaseCtx, lock, fingerprint, idempotency.Response{
+					StatusCode:  recorder.code,
+					ContentType: recorder.Header().Get("Content-Type"),
+					Body:        recorder.body.Bytes(),
+				})
+			}
+
+			if err != nil {
+				requests.Logger(ctx, logger).Warn("idempotent response was not kept", slog.String("error", err.Error()))
+			}
+		})
+	}
+}
+
+func (r *responseRecorder) WriteHeader(code int) {
+	r.code = code
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *responseRecorder) Write(content []byte) (int, error) {
+	r.body.Write(content)
+
+	return r.ResponseWriter.Write(content)
+}
+
+func writeStoredResponse(w http.ResponseWriter, response idempotency.Response) {
+	if response.ContentType != "" {
+		w.Header().Set("Content-Type", response.ContentType)
+	}
+
+	w.Header().Set(ReplayedHeader, strconv.FormatBool(true))
+	w.WriteHeader(response.StatusCode)
+	w.Write(response.Body)
+}
+
+// writeError writes an error response with the given status code.
+func writeError(w http.ResponseWriter, code int, message string) {
+	content, err := json.Marshal(ErrorResponse{KB: message})
+	if err != nil {
+		content = defaultErrorResponse
+	}
+
+	w.Header().Set("Content-Type", jsonContentType)
+	w.WriteHeader(code)
+	w.Write(content)
+}
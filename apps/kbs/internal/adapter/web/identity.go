@@ -0,0 +1,202 @@
+package web
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/requests"
+)
+
+// APIKeyHeader carries the api key that authenticates the client.
+const APIKeyHeader = "X-API-Key"
+
+// MetadataSetup contains the settings of the request metadata middleware.
+type MetadataSetup struct {
+	// DryRun makes every request a dry run.
+	DryRun bool
+	// APIKeys maps each accepted api key to the principal it authenticates.
+	APIKeys map[string]string
+	// TrustedProxies are the networks of the proxies whose X-User-ID and
+	// X-Forwarded-For headers are trusted, e.g. an authenticating gateway.
+	TrustedProxies []netip.Prefix
+}
+
+var errInvalidIdentitySetup = errors.New("invalid identity setting")
+
+// ParseAPIKeys parses the accepted api keys, they are separated by commas
+// and each one looks like principal:key, e.g. "importer:s3cr3t".
+func ParseAPIKeys(value string) (map[string]string, error) {
+	apiKeys := make(map[string]string)
+
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		principal, key, ok := strings.Cut(entry, ":")
+		if !ok || principal == "" || key == "" {
+			return nil, fmt.Errorf("%w: api key of %q must look like principal:key", errInvalidIdentitySetup, principal)
+		}
+
+		apiKeys[key] = principal
+	}
+
+	return apiKeys, nil
+}
+
+// ParseTrustedProxies parses the networks of the trusted proxies, they are
+// separated by commas and each one is an ip address or a cidr, e.g.
+// "10.0.0.0/8,192.168.1.7".
+func ParseTrustedProxies(value string) ([]netip.Prefix, error) {
+	var proxies []netip.Prefix
+
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		if !strings.Contains(entry, "/") {
+			addr, err := netip.ParseAddr(entry)
+			if err != nil {
+				return nil, fmt.Errorf("%w: trusted proxy %q: %w", errInvalidIdentitySetup, entry, err)
+			}
+
+			proxies = append(proxies, netip.PrefixFrom(addr, addr.BitLen()))
+
+			continue
+		}
+
+		prefix, err := netip.ParsePrefix(entry)
+		if err != nil {
+			return nil, fmt.Errorf("%w: trusted proxy %q: %w", errInvalidIdentitySetup, entry, err)
+		}
+
+		proxies = append(proxies, prefix.Masked())
+	}
+
+	return proxies, nil
+}
+
+// ParsePrincipals parses a list of principals separated by commas, e.g.
+// "ops,importer".
+func ParsePrincipals(value string) map[string]bool {
+	principals := make(map[string]bool)
+
+	for _, principal := range strings.Split(value, ",") {
+		principal = strings.TrimSpace(principal)
+		if principal != "" {
+			principals[principal] = true
+		}
+	}
+
+	return principals
+}
+
+// RequireAdmin returns a middleware that only lets through the requests of
+// the given admin principals. Anonymous requests get a 401 and the requests
+// of other principals a 403, so without admins nobody gets through. It
+// must run after RequestMetadata.
+func RequireAdmin(admins map[string]bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal := requests.FromContext(r.Context()).Principal
+
+			switch {
+			case principal == "":
+				writeError(w, http.StatusUnauthorized, "authentication is required")
+			case !admins[principal]:
+				writeError(w, http.StatusForbidden, "only admins can use this route")
+			default:
+				next.ServeHTTP(w, r)
+			}
+		})
+	}
+}
+
+// principalOf returns the principal authenticated by the api key of the
+// request or, if the request came through a trusted proxy, the user id the
+// proxy forwarded. Other requests are anonymous.
+func (m MetadataSetup) principalOf(r *http.Request) string {
+	if principal, ok := m.APIKeys[r.Header.Get(APIKeyHeader)]; ok {
+		return principal
+	}
+
+	if m.trusted(remoteAddr(r)) {
+		return r.Header.Get(UserIDHeader)
+	}
+
+	return ""
+}
+
+// clientIP returns the address of the client. The forwarded header is only
+// read if the request came through a trusted proxy, the client is the last
+// address of the header that is not a trusted proxy.
+func (m MetadataSetup) clientIP(r *http.Request) string {
+	remote := remoteAddr(r)
+	if !m.trusted(remote) {
+		return remoteHost(r)
+	}
+
+	var hops []string
+	for _, forwarded := range r.Header.Values(forwardedHeader) {
+		hops = append(hops, strings.Split(forwarded, ",")...)
+	}
+
+	client := remoteHost(r)
+
+	for i := len(hops) - 1; i >= 0; i-- {
+		addr, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			break
+		}
+
+		client = addr.String()
+
+		if !m.trusted(addr) {
+			break
+		}
+	}
+
+	return client
+}
+
+// trusted returns true if the address belongs to a trusted proxy.
+func (m MetadataSetup) trusted(addr netip.Addr) bool {
+	if !addr.IsValid() {
+		return false
+	}
+
+	addr = addr.Unmap()
+
+	for _, proxy := range m.TrustedProxies {
+		if proxy.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// remoteAddr returns the address of the peer, it is invalid if the remote
+// address of the request can't be parsed.
+func remoteAddr(r *http.Request) netip.Addr {
+	addr, _ := netip.ParseAddr(remoteHost(r))
+
+	return addr
+}
+
+// remoteHost returns the host of the peer that sent the request.
+func remoteHost(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
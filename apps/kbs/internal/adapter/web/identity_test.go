@@ -0,0 +1,141 @@
+package web_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/adapter/web"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/requests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestMetadataIdentity(t *testing.T) {
+	setup := web.MetadataSetup{
+		APIKeys: map[string]string{"s3cr3t": "importer"},
+		TrustedProxies: []netip.Prefix{
+			netip.MustParsePrefix("10.0.0.0/8"),
+		},
+	}
+
+	cases := map[string]struct {
+		remoteAddr    string
+		headers       map[string]string
+		wantPrincipal string
+		wantClientIP  string
+	}{
+		"api_key": {
+			remoteAddr:    "203.0.113.9:4321",
+			headers:       map[string]string{web.APIKeyHeader: "s3cr3t"},
+			wantPrincipal: "importer",
+			wantClientIP:  "203.0.113.9",
+		},
+		"unknown_api_key": {
+			remoteAddr:   "203.0.113.9:4321",
+			headers:      map[string]string{web.APIKeyHeader: "guess"},
+			wantClientIP: "203.0.113.9",
+		},
+		"headers_of_untrusted_client": {
+			remoteAddr:   "203.0.113.9:4321",
+			headers:      map[string]string{web.UserIDHeader: "admin", "X-Forwarded-For": "198.51.100.1"},
+			wantClientIP: "203.0.113.9",
+		},
+		"headers_of_trusted_proxy": {
+			remoteAddr:    "10.0.0.2:4321",
+			headers:       map[string]string{web.UserIDHeader: "drila", "X-Forwarded-For": "203.0.113.9, 10.0.0.7"},
+			wantPrincipal: "drila",
+			wantClientIP:  "203.0.113.9",
+		},
+		"spoofed_forwarded_address": {
+			remoteAddr:   "10.0.0.2:4321",
+			headers:      map[string]string{"X-Forwarded-For": "198.51.100.1, 203.0.113.9"},
+			wantClientIP: "203.0.113.9",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			// Given
+			var got requests.Metadata
+			handler := web.RequestMetadata(setup)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = requests.FromContext(r.Context())
+			}))
+
+			request := httptest.NewRequest(http.MethodPost, "/kbs", nil)
+			request.RemoteAddr = tc.remoteAddr
+			for key, value := range tc.headers {
+				request.Header.Set(key, value)
+			}
+
+			// When
+			handler.ServeHTTP(httptest.NewRecorder(), request)
+
+			// Then
+			assert.Equal(t, tc.wantPrincipal, got.Principal)
+			assert.Equal(t, tc.wantClientIP, got.ClientIP)
+		})
+	}
+}
+
+func TestParseIdentitySettings(t *testing.T) {
+	// When
+	apiKeys, errKeys := web.ParseAPIKeys("importer:s3cr3t, exporter:t0ps3cr3t")
+	proxies, errProxies := web.ParseTrustedProxies("10.0.0.0/8, 192.168.1.7")
+	_, errInvalidKey := web.ParseAPIKeys("s3cr3t")
+	_, errInvalidProxy := web.ParseTrustedProxies("10.0.0.0/33")
+
+	// Then
+	require.NoError(t, errKeys)
+	require.NoError(t, errProxies)
+	assert.Equal(t, map[string]string{"s3cr3t": "importer", "t0ps3cr3t": "exporter"}, apiKeys)
+	assert.Equal(t, []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8"), netip.MustParsePrefix("192.168.1.7/32")}, proxies)
+	assert.Error(t, errInvalidKey)
+	assert.Error(t, errInvalidProxy)
+}
+
+func TestRequireAdmin(t *testing.T) {
+	cases := map[string]struct {
+		headers  map[string]string
+		wantCode int
+	}{
+		"anonymous": {
+			wantCode: http.StatusUnauthorized,
+		},
+		"not_an_admin": {
+			headers:  map[string]string{web.APIKeyHeader: "r3ad3r"},
+			wantCode: http.StatusForbidden,
+		},
+		"admin": {
+			headers:  map[string]string{web.APIKeyHeader: "s3cr3t"},
+			wantCode: http.StatusOK,
+		},
+		"unverified_admin": {
+			headers:  map[string]string{web.UserIDHeader: "importer"},
+			wantCode: http.StatusUnauthorized,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			// Given
+			setup := web.MetadataSetup{APIKeys: map[string]string{"s3cr3t": "importer", "r3ad3r": "reader"}}
+			handler := web.RequestMetadata(setup)(web.RequireAdmin(web.ParsePrincipals("importer, ops"))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			})))
+
+			request := httptest.NewRequest(http.MethodGet, "/admin/kbs/export", nil)
+			for key, value := range tc.headers {
+				request.Header.Set(key, value)
+			}
+			recorder := httptest.NewRecorder()
+
+			// When
+			handler.ServeHTTP(recorder, request)
+
+			// Then
+			assert.Equal(t, tc.wantCode, recorder.Code)
+		})
+	}
+}
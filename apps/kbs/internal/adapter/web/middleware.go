@@ -0,0 +1,194 @@
+package web
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/requests"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// request headers the server understands.
+const (
+	RequestIDHeader = "X-Request-ID"
+	UserIDHeader    = "X-User-ID"
+	DryRunHeader    = "X-Dry-Run"
+	forwardedHeader = "X-Forwarded-For"
+)
+
+// access log formats.
+const (
+	// JSONAccessLog logs every request as a record of the server logger.
+	JSONAccessLog = "json"
+	// CombinedAccessLog writes every request in the apache combined format.
+	CombinedAccessLog = "combined"
+	NoAccessLog       = "none"
+)
+
+// kbRoutePrefix is the path template of the routes about a single kb.
+const kbRoutePrefix = "/kbs/{id}"
+
+// combinedTimeFormat is the time format of the apache combined log.
+const combinedTimeFormat = "02/Jan/2006:15:04:05 -0700"
+
+// AccessLogSetup contains access log settings.
+type AccessLogSetup struct {
+	// Format is the format of the access log: json, combined or none.
+	Format string
+	// Writer receives the lines of the combined format.
+	Writer io.Writer
+}
+
+// accessRecorder keeps the status code and the size of the response.
+type accessRecorder struct {
+	http.ResponseWriter
+	code  int
+	bytes int
+}
+
+// RequestMetadata returns a middleware that adds the request metadata to
+// the context of every request. The request id is taken from the request or
+// generated, and it is returned in the response. The principal and the
+// client ip only come from sources the setup trusts. Requests are dry runs
+// if the setup says so or if they ask for it with the dry run header.
+func RequestMetadata(setup MetadataSetup) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestID := r.Header.Get(RequestIDHeader)
+			if requestID == "" {
+				requestID = uuid.New().String()
+			}
+
+			metadata := requests.Metadata{
+				RequestID: requestID,
+				Principal: setup.principalOf(r),
+				ClientIP:  setup.clientIP(r),
+			}
+
+			ctx := requests.NewContext(r.Context(), metadata)
+
+			w.Header().Set(RequestIDHeader, requestID)
+
+			if setup.DryRun || dryRunRequested(r) {
+				ctx = requests.NewDryRunContext(ctx)
+
+				w.Header().Set(DryRunHeader, "true")
+			}
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// RequestLogger returns a middleware that adds to the context of every
+// request a logger carrying the request id, route, principal and kb id, so
+// every log line of a request can be correlated. Once the request is
+// served it is written to the access log. It must run after RequestMetadata.
+func RequestLogger(logger *slog.Logger, accessLog AccessLogSetup) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			started := time.Now()
+			metadata := requests.FromContext(r.Context())
+			route := routeOf(r)
+
+			attrs := []any{
+				slog.String("request_id", metadata.RequestID),
+				slog.String("route", route),
+			}
+
+			if metadata.Principal != "" {
+				attrs = append(attrs, slog.String("principal", metadata.Principal))
+			}
+
+			if strings.HasPrefix(route, kbRoutePrefix) {
+				attrs = append(attrs, slog.String("kb_id", mux.Vars(r)["id"]))
+			}
+
+			if spanContext := trace.SpanContextFromContext(r.Context()); spanContext.IsValid() {
+				attrs = append(attrs, slog.String("trace_id", spanContext.TraceID().String()))
+			}
+
+			requestLogger := logger.With(attrs...)
+			recorder := &accessRecorder{ResponseWriter: w, code: http.StatusOK}
+
+			next.ServeHTTP(recorder, r.WithContext(requests.NewLoggerContext(r.Context(), requestLogger)))
+
+			switch accessLog.Format {
+			case JSONAccessLog:
+				requestLogger.Info("request served",
+					slog.String("method", r.Method),
+					slog.String("path", r.URL.Path),
+					slog.Int("status", recorder.code),
+					slog.Int("bytes", recorder.bytes),
+					slog.Int64("duration_ms", time.Since(started).Milliseconds()),
+					slog.String("client_ip", metadata.ClientIP),
+					slog.String("user_agent", r.UserAgent()))
+			case CombinedAccessLog:
+				writeCombinedLog(accessLog.Writer, r, metadata, recorder, started)
+			}
+		})
+	}
+}
+
+// writeCombinedLog writes the request in the apache combined log format.
+func writeCombinedLog(w io.Writer, r *http.Request, metadata requests.Metadata, recorder *accessRecorder, started time.Time) {
+	fmt.Fprintf(w, "%s - %s [%s] %q %d %d %q %q\n",
+		metadata.ClientIP,
+		orDash(metadata.Principal),
+		started.Format(combinedTimeFormat),
+		r.Method+" "+r.URL.RequestURI()+" "+r.Proto,
+		recorder.code,
+		recorder.bytes,
+		orDash(r.Referer()),
+		orDash(r.UserAgent()),
+	)
+}
+
+func (a *accessRecorder) WriteHeader(code int) {
+	a.code = code
+	a.ResponseWriter.WriteHeader(code)
+}
+
+func (a *accessRecorder) Write(content []byte) (int, error) {
+	written, err := a.ResponseWriter.Write(content)
+	a.bytes += written
+
+	return written, err
+}
+
+// routeOf returns the path template of the route the request matched.
+func routeOf(r *http.Request) string {
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return r.URL.Path
+	}
+
+	template, err := route.GetPathTemplate()
+	if err != nil {
+		return r.URL.Path
+	}
+
+	return template
+}
+
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+
+	return value
+}
+
+// dryRunRequested returns true if the request asks to be a dry run.
+func dryRunRequested(r *http.Request) bool {
+	dryRun, err := strconv.ParseBool(r.Header.Get(DryRunHeader))
+
+	return err == nil && dryRun
+}
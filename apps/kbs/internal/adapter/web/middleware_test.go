@@ -0,0 +1,354 @@
+package web_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/adapter/web"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/idempotency"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/kbs"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/requests"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// behindGateway trusts the address of the test requests, like the
+// gateway that authenticates the users in front of the server.
+var behindGateway = web.MetadataSetup{
+	TrustedProxies: []netip.Prefix{netip.MustParsePrefix("192.0.2.0/24")},
+}
+
+func TestRequestMetadataDryRun(t *testing.T) {
+	cases := map[string]struct {
+		globalDryRun bool
+		header       string
+		want         bool
+	}{
+		"disabled":          {globalDryRun: false, header: "", want: false},
+		"requested":         {globalDryRun: false, header: "true", want: true},
+		"invalid_header":    {globalDryRun: false, header: "yes please", want: false},
+		"enabled_by_config": {globalDryRun: true, header: "false", want: true},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			// Given
+			encoder := web.NewCreateKBEncoder(newDummyLogger())
+			handler := web.RequestMetadata(web.MetadataSetup{DryRun: tc.globalDryRun})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests.FromContext(r.Context()).Plan.Add("save_kb", kbs.KB{ID: "kb-1"})
+				err := encoder.Encode(r.Context(), w, kbs.CreateKBResult{ID: "kb-1"})
+				require.NoError(t, err)
+			}))
+
+			request := httptest.NewRequest(http.MethodPost, "/kbs", nil)
+			request.Header.Set(web.DryRunHeader, tc.header)
+			recorder := httptest.NewRecorder()
+
+			// When
+			handler.ServeHTTP(recorder, request)
+
+			// Then
+			var got map[string]any
+			require.NoError(t, json.NewDecoder(recorder.Body).Decode(&got))
+			assert.NotEmpty(t, recorder.Header().Get(web.RequestIDHeader))
+			assert.Equal(t, tc.want, recorder.Header().Get(web.DryRunHeader) == "true")
+
+			dryRun, ok := got["dry_run"].(map[string]any)
+			assert.Equal(t, tc.want, ok)
+			if tc.want {
+				writes, _ := dryRun["writes"].([]any)
+				require.Len(t, writes, 1)
+				assert.Equal(t, "save_kb", writes[0].(map[string]any)["operation"])
+			}
+		})
+	}
+}
+
+func TestRequestLoggerCorrelatesRequestLogs(t *testing.T) {
+	// Given
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&logs, &slog.HandlerOptions{ReplaceAttr: requests.RedactSensitive}))
+
+	router := mux.NewRouter()
+	router.Use(web.RequestMetadata(behindGateway))
+	router.Use(web.RequestLogger(logger, web.AccessLogSetup{Format: web.JSONAccessLog}))
+	router.Methods(http.MethodPut).Path("/kbs/{id}").HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Logger(r.Context(), nil).Info("updating kb",
+			slog.String("content", "my secret"),
+			slog.Any("kb", kbs.KB{ID: "kb-1", Content: "my secret"}))
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte("{}"))
+	})
+
+	request := httptest.NewRequest(http.MethodPut, "/kbs/kb-1", nil)
+	request.Header.Set(web.RequestIDHeader, "req-1")
+	request.Header.Set(web.UserIDHeader, "drila")
+
+	// When
+	router.ServeHTTP(httptest.NewRecorder(), request)
+
+	// Then
+	assert.NotContains(t, logs.String(), "my secret")
+
+	lines := bytes.Split(bytes.TrimSpace(logs.Bytes()), []byte("\n"))
+	require.Len(t, lines, 2)
+
+	for _, line := range lines {
+		var record map[string]any
+		require.NoError(t, json.Unmarshal(line, &record))
+		assert.Equal(t, "req-1", record["request_id"])
+		assert.Equal(t, "/kbs/{id}", record["route"])
+		assert.Equal(t, "drila", record["principal"])
+		assert.Equal(t, "kb-1", record["kb_id"])
+	}
+
+	var access map[string]any
+	require.NoError(t, json.Unmarshal(lines[1], &access))
+	assert.Equal(t, "request served", access["msg"])
+	assert.Equal(t, float64(http.StatusAccepted), access["status"])
+	assert.Equal(t, float64(2), access["bytes"])
+}
+
+func TestRequestLoggerCombinedAccessLog(t *testing.T) {
+	// Given
+	var accessLog bytes.Buffer
+	handler := web.RequestMetadata(web.MetadataSetup{})(web.RequestLogger(newDummyLogger(), web.AccessLogSetup{
+		Format: web.CombinedAccessLog,
+		Writer: &accessLog,
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})))
+
+	request := httptest.NewRequest(http.MethodGet, "/kbs?user-id=drila", nil)
+	request.RemoteAddr = "10.0.0.1:4321"
+	request.Header.Set("User-Agent", "curl/8.0")
+
+	// When
+	handler.ServeHTTP(httptest.NewRecorder(), request)
+
+	// Then
+	assert.Regexp(t, `^10\.0\.0\.1 - - \[.+\] "GET /kbs\?user-id=drila HTTP/1\.1" 404 0 "-" "curl/8\.0"\n$`, accessLog.String())
+}
+
+func TestIdempotency(t *testing.T) {
+	cases := map[string]struct {
+		begin        func() (*idempotency.Response, error)
+		handlerCode  int
+		wantCode     int
+		wantBody     string
+		wantReplayed bool
+		wantCalls    int
+		wantKept     bool
+		wantAborted  bool
+	}{
+		"first_request": {
+			begin:       func() (*idempotency.Response, error) { return nil, nil },
+			handlerCode: http.StatusOK,
+			wantCode:    http.StatusOK,
+			wantBody:    `{"id":"kb-1"}`,
+			wantCalls:   1,
+			wantKept:    true,
+		},
+		"replay": {
+			begin: func() (*idempotency.Response, error) {
+				return &idempotency.Response{StatusCode: http.StatusOK, ContentType: "application/json", Body: []byte(`{"id":"kb-0"}`)}, nil
+			},
+			wantCode:     http.StatusOK,
+			wantBody:     `{"id":"kb-0"}`,
+			wantReplayed: true,
+		},
+		"in_progress": {
+			begin:    func() (*idempotency.Response, error) { return nil, idempotency.ErrInProgress },
+			wantCode: http.StatusConflict,
+		},
+		"different_payload": {
+			begin:    func() (*idempotency.Response, error) { return nil, idempotency.ErrMismatch },
+			wantCode: http.StatusUnprocessableEntity,
+		},
+		"store_failure": {
+			begin:    func() (*idempotency.Response, error) { return nil, errors.New("unable to check idempotency key") },
+			wantCode: http.StatusServiceUnavailable,
+		},
+		"failed_request": {
+			begin:       func() (*idempotency.Response, error) { return nil, nil },
+			handlerCode: http.StatusInternalServerError,
+			wantCode:    http.StatusInternalServerError,
+			wantBody:    `{"id":"kb-1"}`,
+			wantCalls:   1,
+			wantAborted: true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			// Given
+			keeper := &fakeKeeper{begin: tc.begin}
+			calls := 0
+			handler := web.RequestMetadata(behindGateway)(web.Idempotency(keeper, newDummyLogger())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				w.WriteHeader(tc.handlerCode)
+				w.Write([]byte(`{"id":"kb-1"}`))
+			})))
+
+			request := httptest.NewRequest(http.MethodPost, "/kbs", strings.NewReader(`{"content":"a"}`))
+			request.Header.Set(web.IdempotencyKeyHeader, "key-1")
+			request.Header.Set(web.UserIDHeader, "drila")
+			recorder := httptest.NewRecorder()
+
+			// When
+			handler.ServeHTTP(recorder, request)
+
+			// Then
+			assert.Equal(t, tc.wantCode, recorder.Code)
+			if tc.wantBody != "" {
+				assert.JSONEq(t, tc.wantBody, recorder.Body.String())
+			}
+			assert.Equal(t, tc.wantReplayed, recorder.Header().Get(web.ReplayedHeader) == "true")
+			assert.Equal(t, tc.wantCalls, calls)
+			assert.Equal(t, idempotency.NewID("drila", "key-1"), keeper.id)
+			assert.Equal(t, idempotency.Fingerprint(http.MethodPost, "/kbs", []byte(`{"content":"a"}`)), keeper.fingerprint)
+			assert.Equal(t, tc.wantKept, keeper.kept != nil)
+			if tc.wantKept {
+				assert.Equal(t, `{"id":"kb-1"}`, string(keeper.kept.Body))
+			}
+			assert.Equal(t, tc.wantAborted, keeper.aborted)
+			if tc.wantKept || tc.wantAborted {
+				assert.Equal(t, idempotency.Lock{ID: keeper.id, Token: "token-1"}, keeper.released)
+			}
+		})
+	}
+}
+
+func TestIdempotencyRejectsLargeBodies(t *testing.T) {
+	// Given
+	keeper := &fakeKeeper{begin: func() (*idempotency.Response, error) { return nil, nil }}
+	handler := web.RequestMetadata(behindGateway)(web.Idempotency(keeper, newDummyLogger())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})))
+
+	request := httptest.NewRequest(http.MethodPost, "/kbs", strings.NewReader(strings.Repeat("a", 17<<20)))
+	request.Header.Set(web.IdempotencyKeyHeader, "key-1")
+	recorder := httptest.NewRecorder()
+
+	// When
+	handler.ServeHTTP(recorder, request)
+
+	// Then
+	assert.Equal(t, http.StatusRequestEntityTooLarge, recorder.Code)
+	assert.Empty(t, keeper.id)
+}
+
+func TestIdempotencyKeepsResponseOfDisconnectedClient(t *testing.T) {
+	// Given
+	keeper := newMemoryKeeper()
+	calls := 0
+	handler := web.RequestMetadata(behindGateway)(web.Idempotency(keeper, newDummyLogger())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":"kb-1"}`))
+	})))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	first := httptest.NewRequest(http.MethodPost, "/kbs", strings.NewReader(`{"content":"a"}`)).WithContext(ctx)
+	first.Header.Set(web.IdempotencyKeyHeader, "key-1")
+	// the client times out once the response was written.
+	keeper.beforeComplete = cancel
+
+	retry := httptest.NewRequest(http.MethodPost, "/kbs", strings.NewReader(`{"content":"a"}`))
+	retry.Header.Set(web.IdempotencyKeyHeader, "key-1")
+	recorder := httptest.NewRecorder()
+
+	// When
+	handler.ServeHTTP(httptest.NewRecorder(), first)
+	handler.ServeHTTP(recorder, retry)
+
+	// Then
+	require.Error(t, ctx.Err())
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+	assert.JSONEq(t, `{"id":"kb-1"}`, recorder.Body.String())
+	assert.Equal(t, "true", recorder.Header().Get(web.ReplayedHeader))
+}
+
+type fakeKeeper struct {
+	begin       func() (*idempotency.Response, error)
+	id          string
+	fingerprint string
+	kept        *idempotency.Response
+	aborted     bool
+	// released is the lock given to Complete or Abort.
+	released idempotency.Lock
+}
+
+func (f *fakeKeeper) Begin(ctx context.Context, id, fingerprint string) (idempotency.Lock, *idempotency.Response, error) {
+	f.id = id
+	f.fingerprint = fingerprint
+
+	stored, err := f.begin()
+	if stored != nil || err != nil {
+		return idempotency.Lock{}, stored, err
+	}
+
+	return idempotency.Lock{ID: id, Token: "token-1"}, nil, nil
+}
+
+func (f *fakeKeeper) Complete(ctx context.Context, lock idempotency.Lock, fingerprint string, response idempotency.Response) error {
+	f.kept = &response
+	f.released = lock
+
+	return nil
+}
+
+func (f *fakeKeeper) Abort(ctx context.Context, lock idempotency.Lock) error {
+	f.aborted = true
+	f.released = lock
+
+	return nil
+}
+
+// memoryKeeper keeps the responses in memory, its writes fail once their
+// context is done.
+type memoryKeeper struct {
+	responses map[string]idempotency.Response
+	// beforeComplete runs before a response is kept, optional.
+	beforeComplete func()
+}
+
+func newMemoryKeeper() *memoryKeeper {
+	return &memoryKeeper{responses: make(map[string]idempotency.Response)}
+}
+
+func (m *memoryKeeper) Begin(ctx context.Context, id, fingerprint string) (idempotency.Lock, *idempotency.Response, error) {
+	if stored, ok := m.responses[id]; ok {
+		return idempotency.Lock{}, &stored, nil
+	}
+
+	return idempotency.Lock{ID: id, Token: "token-1"}, nil, nil
+}
+
+func (m *memoryKeeper) Complete(ctx context.Context, lock idempotency.Lock, fingerprint string, response idempotency.Response) error {
+	if m.beforeComplete != nil {
+		m.beforeComplete()
+	}
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	m.responses[lock.ID] = response
+
+	return nil
+}
+
+func (m *memoryKeeper) Abort(ctx context.Context, lock idempotency.Lock) error {
+	return ctx.Err()
+}
@@ -1,12 +1,22 @@
 package web
 
-import "github.com/fernandoocampo/kb-store/apps/kbs/internal/kbs"
+import (
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/kbs"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/requests"
+)
 
 // Result standard result for the service
 type Result struct {
 	Success bool        `json:"success"`
 	Data    interface{} `json:"data"`
 	Errors  []string    `json:"errors"`
+	// DryRun is only present in dry run responses, nothing was stored.
+	DryRun *DryRun `json:"dry_run,omitempty"`
+}
+
+// DryRun contains the writes a dry run request would have made.
+type DryRun struct {
+	Writes []requests.Write `json:"writes"`
 }
 
 // KB contains kb data.
@@ -19,6 +29,40 @@ type KB struct {
 	EventID      string `json:"event_id"`
 	CreationDate int64  `json:"creation_date"`
 	UpdateDate   int64  `json:"update_date"`
+	// State stage of the kb lifecycle.
+	State      string   `json:"state"`
+	ReviewerID string   `json:"reviewer_id,omitempty"`
+	Reviews    []Review `json:"reviews,omitempty"`
+	// CommentCount number of comments of the kb.
+	CommentCount int `json:"comment_count"`
+	// Path location of the kb inside the spaces hierarchy.
+	Path []Breadcrumb `json:"path,omitempty"`
+}
+
+// Review contains the decision a reviewer took about a kb.
+type Review struct {
+	ReviewerID string `json:"reviewer_id"`
+	Decision   string `json:"decision"`
+	Comment    string `json:"comment"`
+	Date       int64  `json:"date"`
+}
+
+// SubmitKB contains the expected data to send a kb to review.
+type SubmitKB struct {
+	ReviewerID string `json:"reviewer_id"`
+}
+
+// ReviewKB contains the expected data to approve or reject a kb, the
+// reviewer is the authenticated principal of the request.
+type ReviewKB struct {
+	Comment string `json:"comment"`
+}
+
+// Breadcrumb contains one step of the path that leads to a kb.
+type Breadcrumb struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Kind string `json:"kind"`
 }
 
 // NewKB contains the expected data for a new kb.
@@ -60,8 +104,14 @@ type SearchKBsResponse struct {
 type SearchKBFilter struct {
 	// EventID kb's name.
 	EventID string
+	// UserID author of the kbs.
+	UserID string
+	// State kb's lifecycle state.
+	State string
 	// Order by field
-	OrderBy string
+	OrderBy kbs.OrderByField
+	// Order direction of the order, ascending or descending.
+	Order kbs.SortOrder
 	// Page page to query
 	Page uint8
 	// rows per page
@@ -89,10 +139,48 @@ func toKB(kb *kbs.KB) *KB {
 		EventID:      kb.EventID.String(),
 		CreationDate: kb.CreationDate,
 		UpdateDate:   kb.UpdateDate,
+		State:        kb.State.String(),
+		ReviewerID:   kb.ReviewerID.String(),
+		Reviews:      toReviews(kb.Reviews),
+		CommentCount: kb.CommentCount,
+		Path:         toBreadcrumbs(kb.Path),
 	}
 	return &webKB
 }
 
+// toReviews transforms kb reviews to their web representation.
+func toReviews(reviews []kbs.Review) []Review {
+	if len(reviews) == 0 {
+		return nil
+	}
+	webReviews := make([]Review, len(reviews))
+	for i, v := range reviews {
+		webReviews[i] = Review{
+			ReviewerID: v.ReviewerID.String(),
+			Decision:   string(v.Decision),
+			Comment:    v.Comment,
+			Date:       v.Date,
+		}
+	}
+	return webReviews
+}
+
+// toBreadcrumbs transforms a kb path to its web representation.
+func toBreadcrumbs(path []kbs.Breadcrumb) []Breadcrumb {
+	if len(path) == 0 {
+		return nil
+	}
+	breadcrumbs := make([]Breadcrumb, len(path))
+	for i, v := range path {
+		breadcrumbs[i] = Breadcrumb{
+			ID:   v.ID,
+			Name: v.Name,
+			Kind: v.Kind,
+		}
+	}
+	return breadcrumbs
+}
+
 // toSearchKBResult transforms new kb to a kb object.
 func toSearchKBResult(result *kbs.SearchKBsResult) *SearchKBsResult {
 	if result == nil {
@@ -213,11 +301,39 @@ func toSearchKBsResponse(kbResult kbs.SearchKBsDataResult) Result {
 	return kb
 }
 
+func toChangeStateResponse(kbResult kbs.ChangeStateResult) Result {
+	var kb Result
+	if kbResult.Err == "" {
+		kb.Success = true
+	}
+	if kbResult.Err != "" {
+		kb.Errors = []string{kbResult.Err}
+	}
+	return kb
+}
+
+func (s SubmitKB) toSubmitKB(id string) kbs.SubmitKB {
+	return kbs.SubmitKB{
+		ID:         kbs.KBID(id),
+		ReviewerID: kbs.UserID(s.ReviewerID),
+	}
+}
+
+func (r ReviewKB) toReviewKB(id string) kbs.ReviewKB {
+	return kbs.ReviewKB{
+		ID:      kbs.KBID(id),
+		Comment: r.Comment,
+	}
+}
+
 func (s SearchKBFilter) toSearchKBFilter() kbs.QueryFilter {
 	return kbs.QueryFilter{
 		EventID:     s.EventID,
+		UserID:      s.UserID,
+		State:       kbs.State(s.State),
 		PageNumber:  s.Page,
 		RowsPerPage: s.PageSize,
-		OrderBy:     kbs.OrderByField(s.OrderBy),
+		OrderBy:     s.OrderBy,
+		Order:       s.Order,
 	}
 }
@@ -0,0 +1,252 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/requests"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/spaces"
+)
+
+type CreateSpaceDecoder struct {
+	logger *slog.Logger
+}
+
+type UpdateSpaceDecoder struct {
+	logger *slog.Logger
+}
+
+type SpaceIDDecoder struct {
+	logger *slog.Logger
+}
+
+type SearchSpacesDecoder struct {
+	logger *slog.Logger
+}
+
+type CreateCollectionDecoder struct {
+	logger *slog.Logger
+}
+
+type UpdateCollectionDecoder struct {
+	logger *slog.Logger
+}
+
+type MoveCollectionDecoder struct {
+	logger *slog.Logger
+}
+
+type CollectionIDDecoder struct {
+	logger *slog.Logger
+}
+
+type MoveKBDecoder struct {
+	logger *slog.Logger
+}
+
+type CopyKBDecoder struct {
+	logger *slog.Logger
+}
+
+type SpaceDecoders struct {
+	CreateSpaceDecoder      *CreateSpaceDecoder
+	UpdateSpaceDecoder      *UpdateSpaceDecoder
+	SpaceIDDecoder          *SpaceIDDecoder
+	SearchSpacesDecoder     *SearchSpacesDecoder
+	CreateCollectionDecoder *CreateCollectionDecoder
+	UpdateCollectionDecoder *UpdateCollectionDecoder
+	MoveCollectionDecoder   *MoveCollectionDecoder
+	CollectionIDDecoder     *CollectionIDDecoder
+	MoveKBDecoder           *MoveKBDecoder
+	CopyKBDecoder           *CopyKBDecoder
+}
+
+var (
+	errSpaceIDNotProvided      = errors.New("space ID was not provided")
+	errCollectionIDNotProvided = errors.New("collection ID was not provided")
+	errKBIDNotProvided         = errors.New("kb ID was not provided")
+)
+
+func NewSpaceDecoders(logger *slog.Logger) SpaceDecoders {
+	return SpaceDecoders{
+		CreateSpaceDecoder:      &CreateSpaceDecoder{logger: logger},
+		UpdateSpaceDecoder:      &UpdateSpaceDecoder{logger: logger},
+		SpaceIDDecoder:          NewSpaceIDDecoder(logger),
+		SearchSpacesDecoder:     &SearchSpacesDecoder{logger: logger},
+		CreateCollectionDecoder: NewCreateCollectionDecoder(logger),
+		UpdateCollectionDecoder: &UpdateCollectionDecoder{logger: logger},
+		MoveCollectionDecoder:   NewMoveCollectionDecoder(logger),
+		CollectionIDDecoder:     NewCollectionIDDecoder(logger),
+		MoveKBDecoder:           NewMoveKBDecoder(logger),
+		CopyKBDecoder:           &CopyKBDecoder{logger: logger},
+	}
+}
+
+func NewSpaceIDDecoder(logger *slog.Logger) *SpaceIDDecoder {
+	return &SpaceIDDecoder{logger: logger}
+}
+
+func NewCreateCollectionDecoder(logger *slog.Logger) *CreateCollectionDecoder {
+	return &CreateCollectionDecoder{logger: logger}
+}
+
+func NewMoveCollectionDecoder(logger *slog.Logger) *MoveCollectionDecoder {
+	return &MoveCollectionDecoder{logger: logger}
+}
+
+func NewCollectionIDDecoder(logger *slog.Logger) *CollectionIDDecoder {
+	return &CollectionIDDecoder{logger: logger}
+}
+
+func NewMoveKBDecoder(logger *slog.Logger) *MoveKBDecoder {
+	return &MoveKBDecoder{logger: logger}
+}
+
+func (c *CreateSpaceDecoder) Decode(ctx context.Context, r *http.Request) (interface{}, error) {
+	logger := requests.Logger(ctx, c.logger)
+
+	var req NewSpace
+
+	err := decodeJSONBody(r, &req)
+	if err != nil {
+		logger.Error("new space request could not be decoded", slog.String("error", err.Error()))
+
+		return nil, err
+	}
+
+	return req.toSpace(), nil
+}
+
+func (u *UpdateSpaceDecoder) Decode(ctx context.Context, r *http.Request) (interface{}, error) {
+	logger := requests.Logger(ctx, u.logger)
+
+	var req UpdateSpace
+
+	err := decodeJSONBody(r, &req)
+	if err != nil {
+		logger.Error("update space request could not be decoded", slog.String("error", err.Error()))
+
+		return nil, err
+	}
+
+	return req.toSpace(), nil
+}
+
+func (s *SpaceIDDecoder) Decode(ctx context.Context, r *http.Request) (interface{}, error) {
+	spaceID, ok := pathID(r)
+	if !ok {
+		return nil, errSpaceIDNotProvided
+	}
+
+	return spaces.SpaceID(spaceID), nil
+}
+
+func (s *SearchSpacesDecoder) Decode(ctx context.Context, r *http.Request) (interface{}, error) {
+	return nil, nil
+}
+
+func (c *CreateCollectionDecoder) Decode(ctx context.Context, r *http.Request) (interface{}, error) {
+	logger := requests.Logger(ctx, c.logger)
+
+	spaceID, ok := pathID(r)
+	if !ok {
+		return nil, errSpaceIDNotProvided
+	}
+
+	var req NewCollection
+
+	err := decodeJSONBody(r, &req)
+	if err != nil {
+		logger.Error("new collection request could not be decoded", slog.String("error", err.Error()))
+
+		return nil, err
+	}
+
+	return req.toCollection(spaceID), nil
+}
+
+func (u *UpdateCollectionDecoder) Decode(ctx context.Context, r *http.Request) (interface{}, error) {
+	logger := requests.Logger(ctx, u.logger)
+
+	var req UpdateCollection
+
+	err := decodeJSONBody(r, &req)
+	if err != nil {
+		logger.Error("update collection request could not be decoded", slog.String("error", err.Error()))
+
+		return nil, err
+	}
+
+	return req.toCollection(), nil
+}
+
+func (m *MoveCollectionDecoder) Decode(ctx context.Context, r *http.Request) (interface{}, error) {
+	logger := requests.Logger(ctx, m.logger)
+
+	collectionID, ok := pathID(r)
+	if !ok {
+		return nil, errCollectionIDNotProvided
+	}
+
+	var req MoveCollection
+
+	err := decodeJSONBody(r, &req)
+	if err != nil {
+		logger.Error("move collection request could not be decoded", slog.String("error", err.Error()))
+
+		return nil, err
+	}
+
+	return req.toMoveCollection(collectionID), nil
+}
+
+func (c *CollectionIDDecoder) Decode(ctx context.Context, r *http.Request) (interface{}, error) {
+	collectionID, ok := pathID(r)
+	if !ok {
+		return nil, errCollectionIDNotProvided
+	}
+
+	return spaces.CollectionID(collectionID), nil
+}
+
+func (m *MoveKBDecoder) Decode(ctx context.Context, r *http.Request) (interface{}, error) {
+	logger := requests.Logger(ctx, m.logger)
+
+	kbID, ok := pathID(r)
+	if !ok {
+		return nil, errKBIDNotProvided
+	}
+
+	var req KBPlacement
+
+	err := decodeJSONBody(r, &req)
+	if err != nil {
+		logger.Error("move kb request could not be decoded", slog.String("error", err.Error()))
+
+		return nil, err
+	}
+
+	return req.toMoveKB(kbID), nil
+}
+
+func (c *CopyKBDecoder) Decode(ctx context.Context, r *http.Request) (interface{}, error) {
+	logger := requests.Logger(ctx, c.logger)
+
+	kbID, ok := pathID(r)
+	if !ok {
+		return nil, errKBIDNotProvided
+	}
+
+	var req KBPlacement
+
+	err := decodeJSONBody(r, &req)
+	if err != nil {
+		logger.Error("copy kb request could not be decoded", slog.String("error", err.Error()))
+
+		return nil, err
+	}
+
+	return req.toCopyKB(kbID), nil
+}
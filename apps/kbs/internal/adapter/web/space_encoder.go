@@ -0,0 +1,189 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/requests"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/spaces"
+)
+
+type SpacesCreateEncoder struct {
+	logger *slog.Logger
+}
+
+type SpacesOperationEncoder struct {
+	logger *slog.Logger
+}
+
+type GetSpaceEncoder struct {
+	logger *slog.Logger
+}
+
+type SearchSpacesEncoder struct {
+	logger *slog.Logger
+}
+
+type GetCollectionEncoder struct {
+	logger *slog.Logger
+}
+
+type SearchCollectionsEncoder struct {
+	logger *slog.Logger
+}
+
+type SearchCollectionKBsEncoder struct {
+	logger *slog.Logger
+}
+
+type SpaceEncoders struct {
+	CreateEncoder              *SpacesCreateEncoder
+	OperationEncoder           *SpacesOperationEncoder
+	GetSpaceEncoder            *GetSpaceEncoder
+	SearchSpacesEncoder        *SearchSpacesEncoder
+	GetCollectionEncoder       *GetCollectionEncoder
+	SearchCollectionsEncoder   *SearchCollectionsEncoder
+	SearchCollectionKBsEncoder *SearchCollectionKBsEncoder
+}
+
+func NewSpaceEncoders(logger *slog.Logger) SpaceEncoders {
+	return SpaceEncoders{
+		CreateEncoder:              NewSpacesCreateEncoder(logger),
+		OperationEncoder:           &SpacesOperationEncoder{logger: logger},
+		GetSpaceEncoder:            &GetSpaceEncoder{logger: logger},
+		SearchSpacesEncoder:        &SearchSpacesEncoder{logger: logger},
+		GetCollectionEncoder:       &GetCollectionEncoder{logger: logger},
+		SearchCollectionsEncoder:   NewSearchCollectionsEncoder(logger),
+		SearchCollectionKBsEncoder: &SearchCollectionKBsEncoder{logger: logger},
+	}
+}
+
+func NewSpacesCreateEncoder(logger *slog.Logger) *SpacesCreateEncoder {
+	return &SpacesCreateEncoder{logger: logger}
+}
+
+func NewSearchCollectionsEncoder(logger *slog.Logger) *SearchCollectionsEncoder {
+	return &SearchCollectionsEncoder{logger: logger}
+}
+
+func (c *SpacesCreateEncoder) Encode(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	logger := requests.Logger(ctx, c.logger)
+
+	result, ok := response.(spaces.CreateResult)
+	if !ok {
+		logger.Error("cannot transform to spaces.CreateResult", "received", fmt.Sprintf("%T", response))
+		return errors.New("cannot build create response")
+	}
+
+	err := encodeResultWithJSON(ctx, w, toCreateResponse(result))
+	if err != nil {
+		return fmt.Errorf("unable to encode create result: %w", err)
+	}
+
+	return nil
+}
+
+func (o *SpacesOperationEncoder) Encode(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	logger := requests.Logger(ctx, o.logger)
+
+	result, ok := response.(spaces.OperationResult)
+	if !ok {
+		logger.Error("cannot transform to spaces.OperationResult", "received", fmt.Sprintf("%T", response))
+		return errors.New("cannot build operation response")
+	}
+
+	err := encodeResultWithJSON(ctx, w, toOperationResponse(result))
+	if err != nil {
+		return fmt.Errorf("unable to encode operation result: %w", err)
+	}
+
+	return nil
+}
+
+func (g *GetSpaceEncoder) Encode(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	logger := requests.Logger(ctx, g.logger)
+
+	result, ok := response.(spaces.GetSpaceResult)
+	if !ok {
+		logger.Error("cannot transform to spaces.GetSpaceResult", "received", fmt.Sprintf("%T", response))
+		return errors.New("cannot build get space response")
+	}
+
+	err := encodeResultWithJSON(ctx, w, toGetSpaceResponse(result))
+	if err != nil {
+		return fmt.Errorf("unable to encode get space result: %w", err)
+	}
+
+	return nil
+}
+
+func (s *SearchSpacesEncoder) Encode(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	logger := requests.Logger(ctx, s.logger)
+
+	result, ok := response.(spaces.SearchSpacesResult)
+	if !ok {
+		logger.Error("cannot transform to spaces.SearchSpacesResult", "received", fmt.Sprintf("%T", response))
+		return errors.New("cannot build search spaces response")
+	}
+
+	err := encodeResultWithJSON(ctx, w, toSearchSpacesResponse(result))
+	if err != nil {
+		return fmt.Errorf("unable to encode search spaces result: %w", err)
+	}
+
+	return nil
+}
+
+func (g *GetCollectionEncoder) Encode(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	logger := requests.Logger(ctx, g.logger)
+
+	result, ok := response.(spaces.GetCollectionResult)
+	if !ok {
+		logger.Error("cannot transform to spaces.GetCollectionResult", "received", fmt.Sprintf("%T", response))
+		return errors.New("cannot build get collection response")
+	}
+
+	err := encodeResultWithJSON(ctx, w, toGetCollectionResponse(result))
+	if err != nil {
+		return fmt.Errorf("unable to encode get collection result: %w", err)
+	}
+
+	return nil
+}
+
+func (s *SearchCollectionsEncoder) Encode(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	logger := requests.Logger(ctx, s.logger)
+
+	result, ok := response.(spaces.SearchCollectionsResult)
+	if !ok {
+		logger.Error("cannot transform to spaces.SearchCollectionsResult", "received", fmt.Sprintf("%T", response))
+		return errors.New("cannot build search collections response")
+	}
+
+	err := encodeResultWithJSON(ctx, w, toSearchCollectionsResponse(result))
+	if err != nil {
+		return fmt.Errorf("unable to encode search collections result: %w", err)
+	}
+
+	return nil
+}
+
+func (s *SearchCollectionKBsEncoder) Encode(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	logger := requests.Logger(ctx, s.logger)
+
+	result, ok := response.(spaces.SearchPlacementsResult)
+	if !ok {
+		logger.Error("cannot transform to spaces.SearchPlacementsResult", "received", fmt.Sprintf("%T", response))
+		return errors.New("cannot build search collection kbs response")
+	}
+
+	err := encodeResultWithJSON(ctx, w, toSearchPlacementsResponse(result))
+	if err != nil {
+		return fmt.Errorf("unable to encode search collection kbs result: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,271 @@
+package web
+
+import (
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/kbs"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/spaces"
+)
+
+// Space contains space data.
+type Space struct {
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	Description  string `json:"description"`
+	CreationDate int64  `json:"creation_date"`
+	UpdateDate   int64  `json:"update_date"`
+}
+
+// NewSpace contains the expected data for a new space.
+type NewSpace struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// UpdateSpace contains the expected data to update a space.
+type UpdateSpace struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// Collection contains collection data.
+type Collection struct {
+	ID           string `json:"id"`
+	SpaceID      string `json:"space_id"`
+	ParentID     string `json:"parent_id,omitempty"`
+	Name         string `json:"name"`
+	Position     int    `json:"position"`
+	CreationDate int64  `json:"creation_date"`
+	UpdateDate   int64  `json:"update_date"`
+}
+
+// NewCollection contains the expected data for a new collection.
+type NewCollection struct {
+	ParentID string `json:"parent_id"`
+	Name     string `json:"name"`
+	Position int    `json:"position"`
+}
+
+// UpdateCollection contains the expected data to update a collection.
+type UpdateCollection struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// MoveCollection contains the expected data to move a collection.
+type MoveCollection struct {
+	ParentID string `json:"parent_id"`
+	Position int    `json:"position"`
+}
+
+// KBPlacement contains the expected data to move or copy a kb to a
+// collection and the data returned when listing the kbs of a collection.
+type KBPlacement struct {
+	KBID         string `json:"kb_id,omitempty"`
+	CollectionID string `json:"collection_id"`
+	Position     int    `json:"position"`
+}
+
+func toSpace(space *spaces.Space) *Space {
+	if space == nil {
+		return nil
+	}
+	webSpace := Space{
+		ID:           space.ID.String(),
+		Name:         space.Name,
+		Description:  space.Description,
+		CreationDate: space.CreationDate,
+		UpdateDate:   space.UpdateDate,
+	}
+	return &webSpace
+}
+
+func toCollection(collection *spaces.Collection) *Collection {
+	if collection == nil {
+		return nil
+	}
+	webCollection := Collection{
+		ID:           collection.ID.String(),
+		SpaceID:      collection.SpaceID.String(),
+		ParentID:     collection.ParentID.String(),
+		Name:         collection.Name,
+		Position:     collection.Position,
+		CreationDate: collection.CreationDate,
+		UpdateDate:   collection.UpdateDate,
+	}
+	return &webCollection
+}
+
+func (n *NewSpace) toSpace() *spaces.NewSpace {
+	if n == nil {
+		return nil
+	}
+	return &spaces.NewSpace{
+		Name:        n.Name,
+		Description: n.Description,
+	}
+}
+
+func (u *UpdateSpace) toSpace() *spaces.UpdateSpace {
+	if u == nil {
+		return nil
+	}
+	return &spaces.UpdateSpace{
+		ID:          spaces.SpaceID(u.ID),
+		Name:        u.Name,
+		Description: u.Description,
+	}
+}
+
+func (n *NewCollection) toCollection(spaceID string) *spaces.NewCollection {
+	if n == nil {
+		return nil
+	}
+	return &spaces.NewCollection{
+		SpaceID:  spaces.SpaceID(spaceID),
+		ParentID: spaces.CollectionID(n.ParentID),
+		Name:     n.Name,
+		Position: n.Position,
+	}
+}
+
+func (u *UpdateCollection) toCollection() *spaces.UpdateCollection {
+	if u == nil {
+		return nil
+	}
+	return &spaces.UpdateCollection{
+		ID:   spaces.CollectionID(u.ID),
+		Name: u.Name,
+	}
+}
+
+func (m *MoveCollection) toMoveCollection(id string) *spaces.MoveCollection {
+	if m == nil {
+		return nil
+	}
+	return &spaces.MoveCollection{
+		ID:       spaces.CollectionID(id),
+		ParentID: spaces.CollectionID(m.ParentID),
+		Position: m.Position,
+	}
+}
+
+func (p *KBPlacement) toMoveKB(kbID string) *spaces.MoveKB {
+	if p == nil {
+		return nil
+	}
+	return &spaces.MoveKB{
+		KBID:         kbs.KBID(kbID),
+		CollectionID: spaces.CollectionID(p.CollectionID),
+		Position:     p.Position,
+	}
+}
+
+func (p *KBPlacement) toCopyKB(kbID string) *spaces.CopyKB {
+	if p == nil {
+		return nil
+	}
+	return &spaces.CopyKB{
+		KBID:         kbs.KBID(kbID),
+		CollectionID: spaces.CollectionID(p.CollectionID),
+		Position:     p.Position,
+	}
+}
+
+func toCreateResponse(result spaces.CreateResult) Result {
+	var response Result
+	if result.Err == "" {
+		response.Success = true
+		response.Data = result.ID
+	}
+	if result.Err != "" {
+		response.Errors = []string{result.Err}
+	}
+	return response
+}
+
+func toOperationResponse(result spaces.OperationResult) Result {
+	var response Result
+	if result.Err == "" {
+		response.Success = true
+	}
+	if result.Err != "" {
+		response.Errors = []string{result.Err}
+	}
+	return response
+}
+
+func toGetSpaceResponse(result spaces.GetSpaceResult) Result {
+	var response Result
+	if result.Err == "" {
+		response.Success = true
+		response.Data = toSpace(result.Space)
+	}
+	if result.Err != "" {
+		response.Errors = []string{result.Err}
+	}
+	return response
+}
+
+func toSearchSpacesResponse(result spaces.SearchSpacesResult) Result {
+	var response Result
+	if result.Err == "" {
+		spacesFound := make([]Space, 0, len(result.Spaces))
+		for _, v := range result.Spaces {
+			spacesFound = append(spacesFound, *toSpace(&v))
+		}
+		response.Success = true
+		response.Data = spacesFound
+	}
+	if result.Err != "" {
+		response.Errors = []string{result.Err}
+	}
+	return response
+}
+
+func toGetCollectionResponse(result spaces.GetCollectionResult) Result {
+	var response Result
+	if result.Err == "" {
+		response.Success = true
+		response.Data = toCollection(result.Collection)
+	}
+	if result.Err != "" {
+		response.Errors = []string{result.Err}
+	}
+	return response
+}
+
+func toSearchCollectionsResponse(result spaces.SearchCollectionsResult) Result {
+	var response Result
+	if result.Err == "" {
+		collections := make([]Collection, 0, len(result.Collections))
+		for _, v := range result.Collections {
+			collections = append(collections, *toCollection(&v))
+		}
+		response.Success = true
+		response.Data = collections
+	}
+	if result.Err != "" {
+		response.Errors = []string{result.Err}
+	}
+	return response
+}
+
+func toSearchPlacementsResponse(result spaces.SearchPlacementsResult) Result {
+	var response Result
+	if result.Err == "" {
+		placements := make([]KBPlacement, 0, len(result.Placements))
+		for _, v := range result.Placements {
+			placements = append(placements, KBPlacement{
+				KBID:         v.KBID.String(),
+				CollectionID: v.CollectionID.String(),
+				Position:     v.Position,
+			})
+		}
+		response.Success = true
+		response.Data = placements
+	}
+	if result.Err != "" {
+		response.Errors = []string{result.Err}
+	}
+	return response
+}
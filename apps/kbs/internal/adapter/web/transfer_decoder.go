@@ -0,0 +1,94 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/kbs"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/requests"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/transfer"
+)
+
+// maxImportBytes is the largest body an import request can have.
+const maxImportBytes = 64 << 20
+
+var errInvalidTransferFormat = errors.New("invalid format, it must be ndjson or markdown")
+
+// ExportKBsDecoder decodes the filter of an export.
+type ExportKBsDecoder struct {
+	logger *slog.Logger
+}
+
+// ImportKBsDecoder decodes an import, the body is read by the import.
+type ImportKBsDecoder struct {
+	logger *slog.Logger
+}
+
+type TransferDecoders struct {
+	ExportDecoder *ExportKBsDecoder
+	ImportDecoder *ImportKBsDecoder
+}
+
+func NewTransferDecoders(logger *slog.Logger) TransferDecoders {
+	return TransferDecoders{
+		ExportDecoder: &ExportKBsDecoder{logger: logger},
+		ImportDecoder: &ImportKBsDecoder{logger: logger},
+	}
+}
+
+func (e *ExportKBsDecoder) Decode(ctx context.Context, r *http.Request) (interface{}, error) {
+	filters := r.URL.Query()
+
+	format, err := decodeTransferFormat(filters.Get("format"), "")
+	if err != nil {
+		requests.Logger(ctx, e.logger).Debug("export request has an invalid format", slog.String("format", filters.Get("format")))
+
+		return nil, err
+	}
+
+	filter := transfer.ExportFilter{
+		Format:  format,
+		EventID: kbs.EventID(filters.Get("event-id")),
+	}
+
+	return filter, nil
+}
+
+// Decode takes the format from the format parameter or, if there is none,
+// from the content type, zips are markdown.
+func (i *ImportKBsDecoder) Decode(ctx context.Context, r *http.Request) (interface{}, error) {
+	format, err := decodeTransferFormat(r.URL.Query().Get("format"), r.Header.Get("Content-Type"))
+	if err != nil {
+		requests.Logger(ctx, i.logger).Debug("import request has an invalid format", slog.String("format", r.URL.Query().Get("format")))
+
+		return nil, err
+	}
+
+	importRequest := transfer.ImportRequest{
+		Format: format,
+		Body:   http.MaxBytesReader(nil, r.Body, maxImportBytes),
+	}
+
+	return importRequest, nil
+}
+
+func decodeTransferFormat(value, contentType string) (transfer.Format, error) {
+	if value == "" {
+		if strings.HasPrefix(contentType, transfer.Markdown.ContentType()) {
+			return transfer.Markdown, nil
+		}
+
+		return transfer.NDJSON, nil
+	}
+
+	format := transfer.Format(value)
+	if !format.IsValid() {
+		return "", fmt.Errorf("%w: %q", errInvalidTransferFormat, value)
+	}
+
+	return format, nil
+}
@@ -0,0 +1,86 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/requests"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/transfer"
+)
+
+type ExportKBsEncoder struct {
+	logger *slog.Logger
+}
+
+type ImportKBsEncoder struct {
+	logger *slog.Logger
+}
+
+type TransferEncoders struct {
+	ExportEncoder *ExportKBsEncoder
+	ImportEncoder *ImportKBsEncoder
+}
+
+func NewTransferEncoders(logger *slog.Logger) TransferEncoders {
+	return TransferEncoders{
+		ExportEncoder: &ExportKBsEncoder{logger: logger},
+		ImportEncoder: &ImportKBsEncoder{logger: logger},
+	}
+}
+
+// Encode streams the export as the kbs are read. Once the first kb was
+// written the status can't change anymore, so later failures are only
+// logged and the client gets a truncated export.
+func (e *ExportKBsEncoder) Encode(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	logger := requests.Logger(ctx, e.logger)
+
+	result, ok := response.(transfer.ExportKBsResult)
+	if !ok {
+		logger.Error("cannot transform to transfer.ExportKBsResult", "received", fmt.Sprintf("%T", response))
+		return errors.New("cannot build export kbs response")
+	}
+
+	if result.Err != "" {
+		return encodeResultWithJSON(ctx, w, Result{Errors: []string{result.Err}})
+	}
+
+	w.Header().Set("Content-Type", result.Format.ContentType())
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "kbs."+result.Format.Extension()))
+
+	err := result.Write(ctx, w)
+	if err != nil {
+		logger.Error("export kbs response is incomplete", slog.String("error", err.Error()))
+	}
+
+	return nil
+}
+
+func (i *ImportKBsEncoder) Encode(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	logger := requests.Logger(ctx, i.logger)
+
+	result, ok := response.(transfer.ImportKBsResult)
+	if !ok {
+		logger.Error("cannot transform to transfer.ImportKBsResult", "received", fmt.Sprintf("%T", response))
+		return errors.New("cannot build import kbs response")
+	}
+
+	var message Result
+
+	message.Data = result.Report
+
+	if result.Err == "" {
+		message.Success = true
+	} else {
+		message.Errors = []string{result.Err}
+	}
+
+	err := encodeResultWithJSON(ctx, w, message)
+	if err != nil {
+		return fmt.Errorf("unable to encode import kbs result: %w", err)
+	}
+
+	return nil
+}
@@ -7,6 +7,9 @@ import (
 	"net/http"
 
 	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Endpoint defines endpoint logic
@@ -50,6 +53,9 @@ var (
 	defaultErrorResponse = []byte(`{"kb": "unable to process request"}`)
 )
 
+// tracer creates the spans of the http handlers.
+var tracer = otel.Tracer("github.com/fernandoocampo/kb-store/apps/kbs/internal/adapter/web")
+
 func NewRouter() *mux.Router {
 	return mux.NewRouter()
 }
@@ -82,26 +88,60 @@ func (h *Handler) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
 	var err error
 
 	ctx := req.Context()
+	rw = notModifiedWriter(rw, req)
 
-	request, err := h.decoder.Decode(ctx, req)
+	request, err := h.decode(ctx, req)
 	if err != nil {
 		h.encodeError(err, rw)
 		return
 	}
 
-	response, err := h.endpoint.Do(ctx, request)
+	response, err := h.do(ctx, request)
 	if err != nil {
 		h.encodeError(err, rw)
 		return
 	}
 
-	err = h.encoder.Encode(ctx, rw, response)
+	err = h.encode(ctx, rw, response)
 	if err != nil {
 		h.encodeError(err, rw)
 		return
 	}
 }
 
+// decode decodes the request inside its own span.
+func (h *Handler) decode(ctx context.Context, req *http.Request) (interface{}, error) {
+	ctx, span := tracer.Start(ctx, "web.decode")
+	defer span.End()
+
+	request, err := h.decoder.Decode(ctx, req)
+	recordError(span, err)
+
+	return request, err
+}
+
+// do calls the endpoint inside its own span.
+func (h *Handler) do(ctx context.Context, request interface{}) (interface{}, error) {
+	ctx, span := tracer.Start(ctx, "web.endpoint")
+	defer span.End()
+
+	response, err := h.endpoint.Do(ctx, request)
+	recordError(span, err)
+
+	return response, err
+}
+
+// encode encodes the response inside its own span.
+func (h *Handler) encode(ctx context.Context, rw http.ResponseWriter, response interface{}) error {
+	ctx, span := tracer.Start(ctx, "web.encode")
+	defer span.End()
+
+	err := h.encoder.Encode(ctx, rw, response)
+	recordError(span, err)
+
+	return err
+}
+
 func (h *Handler) encodeError(err error, w http.ResponseWriter) {
 	newErrorKB := ErrorResponse{
 		KB: err.Error(),
@@ -119,3 +159,13 @@ func (h *Handler) encodeError(err error, w http.ResponseWriter) {
 	w.WriteHeader(http.StatusInternalServerError)
 	w.Write(content)
 }
+
+// recordError marks the span as failed if there is an error.
+func recordError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
@@ -0,0 +1,170 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/kbs"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/requests"
+)
+
+type GetProfileWithIDDecoder struct {
+	logger *slog.Logger
+}
+
+type SearchProfilesDecoder struct {
+	logger *slog.Logger
+}
+
+type CreateProfileDecoder struct {
+	logger *slog.Logger
+}
+
+type UpdateProfileDecoder struct {
+	logger *slog.Logger
+}
+
+type DeleteProfileDecoder struct {
+	logger *slog.Logger
+}
+
+// SearchUserKBsDecoder decodes a search of the kbs of the user in the path.
+type SearchUserKBsDecoder struct {
+	logger        *slog.Logger
+	searchDecoder *SearchKBsDecoder
+}
+
+type UserDecoders struct {
+	GetByIDDecoder   *GetProfileWithIDDecoder
+	SearchDecoder    *SearchProfilesDecoder
+	CreateDecoder    *CreateProfileDecoder
+	UpdateDecoder    *UpdateProfileDecoder
+	DeleteDecoder    *DeleteProfileDecoder
+	SearchKBsDecoder *SearchUserKBsDecoder
+}
+
+var errUserIDNotProvided = errors.New("user ID was not provided")
+
+func NewUserDecoders(logger *slog.Logger) UserDecoders {
+	return UserDecoders{
+		GetByIDDecoder:   NewGetProfileWithIDDecoder(logger),
+		SearchDecoder:    NewSearchProfilesDecoder(logger),
+		CreateDecoder:    NewCreateProfileDecoder(logger),
+		UpdateDecoder:    NewUpdateProfileDecoder(logger),
+		DeleteDecoder:    NewDeleteProfileDecoder(logger),
+		SearchKBsDecoder: NewSearchUserKBsDecoder(logger),
+	}
+}
+
+func NewGetProfileWithIDDecoder(logger *slog.Logger) *GetProfileWithIDDecoder {
+	return &GetProfileWithIDDecoder{
+		logger: logger,
+	}
+}
+
+func NewSearchProfilesDecoder(logger *slog.Logger) *SearchProfilesDecoder {
+	return &SearchProfilesDecoder{
+		logger: logger,
+	}
+}
+
+func NewCreateProfileDecoder(logger *slog.Logger) *CreateProfileDecoder {
+	return &CreateProfileDecoder{
+		logger: logger,
+	}
+}
+
+func NewUpdateProfileDecoder(logger *slog.Logger) *UpdateProfileDecoder {
+	return &UpdateProfileDecoder{
+		logger: logger,
+	}
+}
+
+func NewDeleteProfileDecoder(logger *slog.Logger) *DeleteProfileDecoder {
+	return &DeleteProfileDecoder{
+		logger: logger,
+	}
+}
+
+func NewSearchUserKBsDecoder(logger *slog.Logger) *SearchUserKBsDecoder {
+	return &SearchUserKBsDecoder{
+		logger:        logger,
+		searchDecoder: NewSearchKBsDecoder(logger),
+	}
+}
+
+func (g *GetProfileWithIDDecoder) Decode(ctx context.Context, r *http.Request) (interface{}, error) {
+	userID, ok := pathID(r)
+	if !ok {
+		return nil, errUserIDNotProvided
+	}
+
+	return kbs.UserID(userID), nil
+}
+
+func (d *DeleteProfileDecoder) Decode(ctx context.Context, r *http.Request) (interface{}, error) {
+	userID, ok := pathID(r)
+	if !ok {
+		return nil, errUserIDNotProvided
+	}
+
+	return kbs.UserID(userID), nil
+}
+
+func (s *SearchProfilesDecoder) Decode(ctx context.Context, r *http.Request) (interface{}, error) {
+	return nil, nil
+}
+
+func (c *CreateProfileDecoder) Decode(ctx context.Context, r *http.Request) (interface{}, error) {
+	logger := requests.Logger(ctx, c.logger)
+
+	var req NewProfile
+
+	err := decodeJSONBody(r, &req)
+	if err != nil {
+		logger.Error("new profile request could not be decoded", slog.String("error", err.Error()))
+
+		return nil, err
+	}
+
+	return req.toProfile(), nil
+}
+
+func (u *UpdateProfileDecoder) Decode(ctx context.Context, r *http.Request) (interface{}, error) {
+	logger := requests.Logger(ctx, u.logger)
+
+	var req UpdateProfile
+
+	err := decodeJSONBody(r, &req)
+	if err != nil {
+		logger.Error("update profile request could not be decoded", slog.String("error", err.Error()))
+
+		return nil, err
+	}
+
+	return req.toProfile(), nil
+}
+
+// Decode decodes the kbs search filters and takes the user id from the path.
+func (s *SearchUserKBsDecoder) Decode(ctx context.Context, r *http.Request) (interface{}, error) {
+	userID, ok := pathID(r)
+	if !ok {
+		return nil, errUserIDNotProvided
+	}
+
+	request, err := s.searchDecoder.Decode(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	filter, ok := request.(kbs.QueryFilter)
+	if !ok {
+		return nil, errors.New("unable to decode kbs filters")
+	}
+
+	filter.UserID = userID
+
+	return filter, nil
+}
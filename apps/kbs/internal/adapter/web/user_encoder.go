@@ -0,0 +1,165 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/requests"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/users"
+)
+
+type GetProfileWithIDEncoder struct {
+	logger *slog.Logger
+}
+
+type SearchProfilesEncoder struct {
+	logger *slog.Logger
+}
+
+type CreateProfileEncoder struct {
+	logger *slog.Logger
+}
+
+type UpdateProfileEncoder struct {
+	logger *slog.Logger
+}
+
+type DeleteProfileEncoder struct {
+	logger *slog.Logger
+}
+
+type UserEncoders struct {
+	GetByIDEncoder *GetProfileWithIDEncoder
+	SearchEncoder  *SearchProfilesEncoder
+	CreateEncoder  *CreateProfileEncoder
+	UpdateEncoder  *UpdateProfileEncoder
+	DeleteEncoder  *DeleteProfileEncoder
+}
+
+func NewUserEncoders(logger *slog.Logger) UserEncoders {
+	return UserEncoders{
+		GetByIDEncoder: NewGetProfileWithIDEncoder(logger),
+		SearchEncoder:  NewSearchProfilesEncoder(logger),
+		CreateEncoder:  NewCreateProfileEncoder(logger),
+		UpdateEncoder:  NewUpdateProfileEncoder(logger),
+		DeleteEncoder:  NewDeleteProfileEncoder(logger),
+	}
+}
+
+func NewGetProfileWithIDEncoder(logger *slog.Logger) *GetProfileWithIDEncoder {
+	return &GetProfileWithIDEncoder{
+		logger: logger,
+	}
+}
+
+func NewSearchProfilesEncoder(logger *slog.Logger) *SearchProfilesEncoder {
+	return &SearchProfilesEncoder{
+		logger: logger,
+	}
+}
+
+func NewCreateProfileEncoder(logger *slog.Logger) *CreateProfileEncoder {
+	return &CreateProfileEncoder{
+		logger: logger,
+	}
+}
+
+func NewUpdateProfileEncoder(logger *slog.Logger) *UpdateProfileEncoder {
+	return &UpdateProfileEncoder{
+		logger: logger,
+	}
+}
+
+func NewDeleteProfileEncoder(logger *slog.Logger) *DeleteProfileEncoder {
+	return &DeleteProfileEncoder{
+		logger: logger,
+	}
+}
+
+func (c *CreateProfileEncoder) Encode(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	logger := requests.Logger(ctx, c.logger)
+
+	result, ok := response.(users.CreateProfileResult)
+	if !ok {
+		logger.Error("cannot transform to users.CreateProfileResult", "received", fmt.Sprintf("%T", response))
+		return errors.New("cannot build create profile response")
+	}
+
+	err := encodeResultWithJSON(ctx, w, toCreateProfileResponse(result))
+	if err != nil {
+		return fmt.Errorf("unable to encode create profile result: %w", err)
+	}
+
+	return nil
+}
+
+func (u *UpdateProfileEncoder) Encode(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	logger := requests.Logger(ctx, u.logger)
+
+	result, ok := response.(users.UpdateProfileResult)
+	if !ok {
+		logger.Error("cannot transform to users.UpdateProfileResult", "received", fmt.Sprintf("%T", response))
+		return errors.New("cannot build update profile response")
+	}
+
+	err := encodeResultWithJSON(ctx, w, toUpdateProfileResponse(result))
+	if err != nil {
+		return fmt.Errorf("unable to encode update profile result: %w", err)
+	}
+
+	return nil
+}
+
+func (d *DeleteProfileEncoder) Encode(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	logger := requests.Logger(ctx, d.logger)
+
+	result, ok := response.(users.DeleteProfileResult)
+	if !ok {
+		logger.Error("cannot transform to users.DeleteProfileResult", "received", fmt.Sprintf("%T", response))
+		return errors.New("cannot build delete profile response")
+	}
+
+	err := encodeResultWithJSON(ctx, w, toDeleteProfileResponse(result))
+	if err != nil {
+		return fmt.Errorf("unable to encode delete profile result: %w", err)
+	}
+
+	return nil
+}
+
+func (g *GetProfileWithIDEncoder) Encode(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	logger := requests.Logger(ctx, g.logger)
+
+	result, ok := response.(users.GetProfileWithIDResult)
+	if !ok {
+		logger.Error("cannot transform to users.GetProfileWithIDResult", "received", fmt.Sprintf("%T", response))
+		return errors.New("cannot build get profile response")
+	}
+
+	err := encodeResultWithJSON(ctx, w, toGetProfileWithIDResponse(result))
+	if err != nil {
+		return fmt.Errorf("unable to encode get profile by id result: %w", err)
+	}
+
+	return nil
+}
+
+func (s *SearchProfilesEncoder) Encode(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	logger := requests.Logger(ctx, s.logger)
+
+	result, ok := response.(users.SearchProfilesResult)
+	if !ok {
+		logger.Error("cannot transform to users.SearchProfilesResult", "received", fmt.Sprintf("%T", response))
+		return errors.New("cannot build search profiles response")
+	}
+
+	err := encodeResultWithJSON(ctx, w, toSearchProfilesResponse(result))
+	if err != nil {
+		return fmt.Errorf("unable to encode search profiles result: %w", err)
+	}
+
+	return nil
+}
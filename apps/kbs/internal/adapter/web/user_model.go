@@ -0,0 +1,142 @@
+package web
+
+import (
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/kbs"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/users"
+)
+
+// Profile contains user profile data.
+type Profile struct {
+	ID           string `json:"id"`
+	DisplayName  string `json:"display_name"`
+	Email        string `json:"email"`
+	AvatarURL    string `json:"avatar_url"`
+	Status       string `json:"status"`
+	CreationDate int64  `json:"creation_date"`
+	UpdateDate   int64  `json:"update_date"`
+}
+
+// NewProfile contains the expected data for a new profile.
+type NewProfile struct {
+	ID          string `json:"id"`
+	DisplayName string `json:"display_name"`
+	Email       string `json:"email"`
+	AvatarURL   string `json:"avatar_url"`
+	Status      string `json:"status"`
+}
+
+// UpdateProfile contains the expected data to update a profile.
+type UpdateProfile struct {
+	ID          string `json:"id"`
+	DisplayName string `json:"display_name"`
+	Email       string `json:"email"`
+	AvatarURL   string `json:"avatar_url"`
+	Status      string `json:"status"`
+}
+
+// toProfile transforms a domain profile to a web profile.
+func toProfile(profile *users.Profile) *Profile {
+	if profile == nil {
+		return nil
+	}
+	webProfile := Profile{
+		ID:           profile.ID.String(),
+		DisplayName:  profile.DisplayName,
+		Email:        profile.Email,
+		AvatarURL:    profile.AvatarURL,
+		Status:       profile.Status.String(),
+		CreationDate: profile.CreationDate,
+		UpdateDate:   profile.UpdateDate,
+	}
+	return &webProfile
+}
+
+// toProfile transforms new profile to a domain object.
+func (n *NewProfile) toProfile() *users.NewProfile {
+	if n == nil {
+		return nil
+	}
+	return &users.NewProfile{
+		ID:          kbs.UserID(n.ID),
+		DisplayName: n.DisplayName,
+		Email:       n.Email,
+		AvatarURL:   n.AvatarURL,
+		Status:      users.Status(n.Status),
+	}
+}
+
+// toProfile transforms update profile to a domain object.
+func (u *UpdateProfile) toProfile() *users.UpdateProfile {
+	if u == nil {
+		return nil
+	}
+	return &users.UpdateProfile{
+		ID:          kbs.UserID(u.ID),
+		DisplayName: u.DisplayName,
+		Email:       u.Email,
+		AvatarURL:   u.AvatarURL,
+		Status:      users.Status(u.Status),
+	}
+}
+
+func toCreateProfileResponse(profileResult users.CreateProfileResult) Result {
+	var result Result
+	if profileResult.Err == "" {
+		result.Success = true
+		result.Data = profileResult.ID
+	}
+	if profileResult.Err != "" {
+		result.Errors = []string{profileResult.Err}
+	}
+	return result
+}
+
+func toUpdateProfileResponse(profileResult users.UpdateProfileResult) Result {
+	var result Result
+	if profileResult.Err == "" {
+		result.Success = true
+	}
+	if profileResult.Err != "" {
+		result.Errors = []string{profileResult.Err}
+	}
+	return result
+}
+
+func toDeleteProfileResponse(profileResult users.DeleteProfileResult) Result {
+	var result Result
+	if profileResult.Err == "" {
+		result.Success = true
+	}
+	if profileResult.Err != "" {
+		result.Errors = []string{profileResult.Err}
+	}
+	return result
+}
+
+func toGetProfileWithIDResponse(profileResult users.GetProfileWithIDResult) Result {
+	var result Result
+	if profileResult.Err == "" {
+		result.Success = true
+		result.Data = toProfile(profileResult.Profile)
+	}
+	if profileResult.Err != "" {
+		result.Errors = []string{profileResult.Err}
+	}
+	return result
+}
+
+func toSearchProfilesResponse(profileResult users.SearchProfilesResult) Result {
+	var result Result
+	if profileResult.Err == "" {
+		profiles := make([]Profile, 0, len(profileResult.Profiles))
+		for _, v := range profileResult.Profiles {
+			profiles = append(profiles, *toProfile(&v))
+		}
+		result.Success = true
+		result.Data = profiles
+	}
+	if profileResult.Err != "" {
+		result.Errors = []string{profileResult.Err}
+	}
+	return result
+}
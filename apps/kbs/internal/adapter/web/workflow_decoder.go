@@ -0,0 +1,84 @@
+package web
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/kbs"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/requests"
+)
+
+type SubmitKBDecoder struct {
+	logger *slog.Logger
+}
+
+// ReviewKBDecoder decodes approvals and rejections of kbs.
+type ReviewKBDecoder struct {
+	logger *slog.Logger
+}
+
+type ArchiveKBDecoder struct {
+	logger *slog.Logger
+}
+
+func NewSubmitKBDecoder(logger *slog.Logger) *SubmitKBDecoder {
+	return &SubmitKBDecoder{logger: logger}
+}
+
+func NewReviewKBDecoder(logger *slog.Logger) *ReviewKBDecoder {
+	return &ReviewKBDecoder{logger: logger}
+}
+
+func NewArchiveKBDecoder(logger *slog.Logger) *ArchiveKBDecoder {
+	return &ArchiveKBDecoder{logger: logger}
+}
+
+func (s *SubmitKBDecoder) Decode(ctx context.Context, r *http.Request) (interface{}, error) {
+	logger := requests.Logger(ctx, s.logger)
+
+	kbID, ok := pathID(r)
+	if !ok {
+		return nil, errKBIDNotProvided
+	}
+
+	var req SubmitKB
+
+	err := decodeJSONBody(r, &req)
+	if err != nil {
+		logger.Error("submit kb request could not be decoded", slog.String("error", err.Error()))
+
+		return nil, err
+	}
+
+	return req.toSubmitKB(kbID), nil
+}
+
+func (rd *ReviewKBDecoder) Decode(ctx context.Context, r *http.Request) (interface{}, error) {
+	logger := requests.Logger(ctx, rd.logger)
+
+	kbID, ok := pathID(r)
+	if !ok {
+		return nil, errKBIDNotProvided
+	}
+
+	var req ReviewKB
+
+	err := decodeJSONBody(r, &req)
+	if err != nil {
+		logger.Error("review kb request could not be decoded", slog.String("error", err.Error()))
+
+		return nil, err
+	}
+
+	return req.toReviewKB(kbID), nil
+}
+
+func (a *ArchiveKBDecoder) Decode(ctx context.Context, r *http.Request) (interface{}, error) {
+	kbID, ok := pathID(r)
+	if !ok {
+		return nil, errKBIDNotProvided
+	}
+
+	return kbs.KBID(kbID), nil
+}
@@ -0,0 +1,38 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/kbs"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/requests"
+)
+
+// ChangeStateEncoder encodes the result of kb lifecycle transitions.
+type ChangeStateEncoder struct {
+	logger *slog.Logger
+}
+
+func NewChangeStateEncoder(logger *slog.Logger) *ChangeStateEncoder {
+	return &ChangeStateEncoder{logger: logger}
+}
+
+func (c *ChangeStateEncoder) Encode(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	logger := requests.Logger(ctx, c.logger)
+
+	result, ok := response.(kbs.ChangeStateResult)
+	if !ok {
+		logger.Error("cannot transform to kbs.ChangeStateResult", "received", fmt.Sprintf("%T", response))
+		return errors.New("cannot build change kb state response")
+	}
+
+	err := encodeResultWithJSON(ctx, w, toChangeStateResponse(result))
+	if err != nil {
+		return fmt.Errorf("unable to encode change kb state result: %w", err)
+	}
+
+	return nil
+}
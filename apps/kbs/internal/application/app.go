@@ -10,11 +10,31 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/adapter/blobs"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/adapter/broker"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/adapter/cache"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/adapter/dryrun"
 	"github.com/fernandoocampo/kb-store/apps/kbs/internal/adapter/dynamodb"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/adapter/encryption"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/adapter/metrics"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/adapter/ratelimit"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/adapter/resilience"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/adapter/stores"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/adapter/tracing"
 	"github.com/fernandoocampo/kb-store/apps/kbs/internal/adapter/web"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/audit"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/comments"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/events"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/health"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/idempotency"
 	"github.com/fernandoocampo/kb-store/apps/kbs/internal/kbs"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/requests"
 	"github.com/fernandoocampo/kb-store/apps/kbs/internal/setups"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/spaces"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/transfer"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/users"
 )
 
 // Event contains an application event.
@@ -30,18 +50,80 @@ type Setup struct {
 	CommitHash string
 }
 
+// serviceEndpoints contains the endpoints of every service exposed by the server.
+type serviceEndpoints struct {
+	kbs      kbs.Endpoints
+	spaces   spaces.Endpoints
+	events   events.Endpoints
+	users    users.Endpoints
+	comments comments.Endpoints
+	audit    audit.Endpoints
+	transfer transfer.Endpoints
+	health   health.Endpoints
+}
+
 // Server is the server of our application.
 type Server struct {
-	logger     *slog.Logger
-	store      kbs.Storer
+	logger        *slog.Logger
+	store         kbs.Storer
+	spacesStore   spaces.Storer
+	eventsStore   events.Storer
+	usersStore    users.Storer
+	commentsStore comments.Storer
+	auditStore    audit.Storer
+	// idempotencyStore keeps the responses of retried requests.
+	idempotencyStore idempotency.Storer
+	kbScanner        users.KBScanner
+	// migrator applies the schema of the dynamodb tables.
+	migrator    *dynamodb.Client
+	health      *health.Service
+	metrics     *metrics.Metrics
+	tracing     *tracing.Provider
+	rateLimiter *ratelimit.Limiter
+	// requestMetadata defines the principals and client addresses the
+	// server trusts.
+	requestMetadata web.MetadataSetup
+	// admins are the principals allowed to use the admin routes.
+	admins map[string]bool
+	// breaker is nil if the resilience of the kbs store is disabled.
+	breaker *resilience.Breaker
+	// encrypter is nil if the encryption of kb content is disabled.
+	encrypter *encryption.Store
+	// keys are the master keys of the encryption, nil if it is disabled.
+	keys encryption.KeyProvider
+	// dynamodbKBs and sqlKBs are the kbs stores migrations copy kbs
+	// between, sqlKBs is nil until the sql store is opened.
+	dynamodbKBs *kbStore
+	sqlKBs      *kbStore
+	// sqlStore is nil until the sql store is opened.
+	sqlStore *stores.Store
+	// blobs keeps offloaded content and backups, nil if it is disabled.
+	blobs      dynamodb.BlobStore
+	httpServer *http.Server
+	// closers release the resources of the server in the order they must
+	// be closed, background workers first and store clients last.
+	closers    []closer
 	setup      setups.Application
 	version    string
 	buildDate  string
 	commitHash string
 }
 
+// closer releases a resource of the server on shutdown.
+type closer struct {
+	name  string
+	close func(ctx context.Context) error
+}
+
 var (
-	errStartingApplication = errors.New("unable to start application")
+	errUnknownRateLimitBackend = errors.New("unknown rate limit backend")
+	errUnknownKeyProvider      = errors.New("unknown encryption key provider")
+	errUnknownBlobBackend      = errors.New("unknown blob backend")
+	errUnknownKBStore          = errors.New("unknown kbs store, it must be dynamodb or sql")
+	errMissingSQLDSN           = errors.New("the sql store requires a dsn")
+	errMissingBlobStore        = errors.New("backups require a blob backend")
+	errStartingApplication     = errors.New("unable to start application")
+	errStoppingApplication     = errors.New("unable to stop application gracefully")
 )
 
 func NewServer() *Server {
@@ -57,51 +139,234 @@ func NewServer() *Server {
 func (s *Server) Run() error {
 	s.notifyStart()
 
-	confError := s.loadConfiguration()
-	if confError != nil {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	err := s.initialize(ctx)
+	if err != nil {
 		return errStartingApplication
 	}
 
-	loggerError := s.initializeLogger()
-	if loggerError != nil {
+	err = s.validateSchema(ctx)
+	if err != nil {
 		return errStartingApplication
 	}
 
-	s.logger.Debug("application configuration", "parameters", fmt.Sprintf("%+v", s.setup))
+	spacesServiceSetup := spaces.ServiceSetup{
+		Storer: s.spacesStore,
+		Logger: s.logger,
+	}
+	spacesService := spaces.NewService(spacesServiceSetup)
 
-	s.logger.Info("starting database connection")
+	eventsServiceSetup := events.ServiceSetup{
+		Storer: s.eventsStore,
+		Logger: s.logger,
+	}
+	eventsService := events.NewService(eventsServiceSetup)
 
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+	usersService := s.newUsersService()
 
-	err := s.createDynamodbStorer(ctx)
+	commentsServiceSetup := comments.ServiceSetup{
+		Storer: s.commentsStore,
+		KBs:    s.store,
+		Logger: s.logger,
+	}
+	commentsService := comments.NewService(commentsServiceSetup)
+
+	auditService := s.newAuditService()
+
+	idempotencyService := idempotency.NewService(idempotency.ServiceSetup{
+		Storer:      s.idempotencyStore,
+		Logger:      s.logger,
+		TTL:         s.setup.IdempotencyTTL,
+		LockTimeout: s.setup.IdempotencyLockTimeout,
+	})
+
+	kbsBroker := broker.New(broker.Setup{
+		Logger:     s.logger,
+		BufferSize: s.setup.EventsBufferSize,
+	})
+	s.addCloser("events broker", kbsBroker.Shutdown)
+
+	kbsBroker.Subscribe(broker.LogHandler(s.logger))
+
+	err = s.startBackupScheduler(ctx)
 	if err != nil {
 		return errStartingApplication
 	}
 
 	kbServiceSetup := kbs.ServiceSetup{
-		Storer: s.store,
-		Logger: s.logger,
+		Storer:           s.store,
+		PathFinder:       spacesService,
+		PlacementRemover: spacesService,
+		EventValidator:   eventsService,
+		NameResolver:     usersService,
+		Publisher:        kbsBroker,
+		CommentCounter:   commentsService,
+		Auditor:          auditService,
+		Logger:           s.logger,
 	}
 	kbService := kbs.NewService(kbServiceSetup)
-	kbEndpoints := kbs.NewEndpoints(kbService, s.logger)
+	spacesService.WithKBService(kbService)
+
+	endpoints := serviceEndpoints{
+		kbs:      kbs.NewEndpoints(kbService, s.logger),
+		spaces:   spaces.NewEndpoints(spacesService, s.logger),
+		events:   events.NewEndpoints(eventsService, s.logger),
+		users:    users.NewEndpoints(usersService, s.logger),
+		comments: comments.NewEndpoints(commentsService, s.logger),
+		audit:    audit.NewEndpoints(auditService, s.logger),
+		transfer: transfer.NewEndpoints(s.newTransferService(kbService), s.logger),
+		health:   health.NewEndpoints(s.health, s.logger),
+	}
+
+	err = s.createRequestMetadata()
+	if err != nil {
+		return errStartingApplication
+	}
+
+	err = s.createRateLimiter()
+	if err != nil {
+		return errStartingApplication
+	}
 
 	eventStream := make(chan Event)
 	s.listenToOSSignal(eventStream)
-	s.startWebServer(kbEndpoints, eventStream)
+	s.startWebServer(endpoints, idempotencyService, eventStream)
 
 	eventKB := <-eventStream
 	s.logger.Info("ending server", "event", eventKB.KB)
 
+	stopErr := s.Stop()
+
 	if eventKB.Error != nil {
 		s.logger.Error("ending server with error", "error", eventKB.Error)
 
 		return errStartingApplication
 	}
 
+	return stopErr
+}
+
+// initialize loads the configuration, the logger and the store clients
+// every server mode needs.
+func (s *Server) initialize(ctx context.Context) error {
+	confError := s.loadConfiguration()
+	if confError != nil {
+		return errStartingApplication
+	}
+
+	loggerError := s.initializeLogger()
+	if loggerError != nil {
+		return errStartingApplication
+	}
+
+	s.logger.Debug("application configuration", "parameters", fmt.Sprintf("%+v", s.setup))
+
+	if s.setup.DryRun {
+		s.logger.Warn("dry run mode is enabled, changes will not be stored", slog.Bool("dry_run", true))
+	}
+
+	err := s.initializeTracing(ctx)
+	if err != nil {
+		return errStartingApplication
+	}
+
+	s.metrics = metrics.New(metrics.Setup{
+		Version:    s.version,
+		CommitHash: s.commitHash,
+		BuildDate:  s.buildDate,
+	})
+
+	s.health = health.NewService(health.ServiceSetup{
+		Logger:   s.logger,
+		Timeout:  s.setup.HealthCheckTimeout,
+		CacheTTL: s.setup.HealthCacheTTL,
+	})
+
+	s.logger.Info("starting database connection")
+
+	err = s.createDynamodbStorer(ctx)
+	if err != nil {
+		return errStartingApplication
+	}
+
+	return nil
+}
+
+// initializeTracing creates the tracer provider of the configured exporter.
+func (s *Server) initializeTracing(ctx context.Context) error {
+	provider, err := tracing.New(ctx, tracing.Setup{
+		Version:     s.version,
+		Exporter:    s.setup.Tracing.Exporter,
+		Endpoint:    s.setup.Tracing.OTLPEndpoint,
+		Insecure:    s.setup.Tracing.OTLPInsecure,
+		File:        s.setup.Tracing.File,
+		SampleRatio: s.setup.Tracing.SampleRatio,
+	})
+	if err != nil {
+		s.logger.Error("unable to initialize tracing", slog.String("error", err.Error()))
+
+		return err
+	}
+
+	s.tracing = provider
+
+	s.logger.Info("tracing was initialized", slog.String("exporter", s.setup.Tracing.Exporter))
+
 	return nil
 }
 
+func (s *Server) newUsersService() *users.Service {
+	usersServiceSetup := users.ServiceSetup{
+		Storer:       s.usersStore,
+		Logger:       s.logger,
+		NameCacheTTL: s.setup.UserNameCacheTTL,
+	}
+
+	return users.NewService(usersServiceSetup)
+}
+
+func (s *Server) newAuditService() *audit.Service {
+	auditServiceSetup := audit.ServiceSetup{
+		Storer: s.auditStore,
+		Logger: s.logger,
+	}
+
+	return audit.NewService(auditServiceSetup)
+}
+
+// newTransferService creates the service that exports and imports kbs.
+// Exports read the kbs with their content decrypted, imports go through
+// the given kbs service so they are validated and audited.
+func (s *Server) newTransferService(importer transfer.Importer) *transfer.Service {
+	transferServiceSetup := transfer.ServiceSetup{
+		Scanner:  s.decryptingScanner(s.kbScanner),
+		Importer: importer,
+		Logger:   s.logger,
+	}
+
+	return transfer.NewService(transferServiceSetup)
+}
+
+// newImporter creates the kbs service the import command stores the kbs
+// with, it validates their events and audits them.
+func (s *Server) newImporter() *kbs.Service {
+	eventsServiceSetup := events.ServiceSetup{
+		Storer: s.eventsStore,
+		Logger: s.logger,
+	}
+
+	kbServiceSetup := kbs.ServiceSetup{
+		Storer:         s.store,
+		EventValidator: events.NewService(eventsServiceSetup),
+		Auditor:        s.newAuditService(),
+		Logger:         s.logger,
+	}
+
+	return kbs.NewService(kbServiceSetup)
+}
+
 func (s *Server) initializeLogger() error {
 	logLevel := slog.LevelDebug
 
@@ -110,10 +375,12 @@ func (s *Server) initializeLogger() error {
 	}
 
 	handlerOptions := &slog.HandlerOptions{
-		Level: logLevel,
+		Level:       logLevel,
+		ReplaceAttr: requests.RedactSensitive,
 	}
 
-	loggerHandler := slog.NewJSONHandler(os.Stdout, handlerOptions)
+	// records logged with a context carry its trace and span ids.
+	loggerHandler := tracing.NewLogHandler(slog.NewJSONHandler(os.Stdout, handlerOptions))
 	logger := slog.New(loggerHandler)
 
 	logger.Info(fmt.Sprintf("using %q log level", handlerOptions.Level.Level().String()))
@@ -134,9 +401,69 @@ func (s *Server) notifyStart() {
 	)
 }
 
-// Stop stop application, take advantage of this to clean resources
-func (s *Server) Stop() {
+// Stop stops the application gracefully. Readiness fails first so no new
+// traffic is routed to the server, then in-flight requests are drained and
+// finally background workers and store clients are closed in order.
+func (s *Server) Stop() error {
 	s.logger.Info("stopping the application")
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.setup.ShutdownDelay+s.setup.ShutdownTimeout)
+	defer cancel()
+
+	var errs []error
+
+	if s.health != nil {
+		s.health.Shutdown()
+	}
+
+	if s.httpServer != nil {
+		s.logger.Info("waiting for the load balancer to stop routing requests", slog.Duration("delay", s.setup.ShutdownDelay))
+		time.Sleep(s.setup.ShutdownDelay)
+
+		err := s.httpServer.Shutdown(ctx)
+		if err != nil {
+			s.logger.Error("unable to drain in-flight requests", slog.String("error", err.Error()))
+
+			errs = append(errs, err)
+		}
+	}
+
+	for _, c := range s.closers {
+		err := c.close(ctx)
+		if err != nil {
+			s.logger.Error("unable to close resource", slog.String("resource", c.name), slog.String("error", err.Error()))
+
+			errs = append(errs, err)
+
+			continue
+		}
+
+		s.logger.Info("resource was closed", slog.String("resource", c.name))
+	}
+
+	// spans are flushed last so the ones of the shutdown are exported too.
+	if s.tracing != nil {
+		err := s.tracing.Shutdown(ctx)
+		if err != nil {
+			s.logger.Error("unable to flush traces", slog.String("error", err.Error()))
+
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%w: %w", errStoppingApplication, errors.Join(errs...))
+	}
+
+	s.logger.Info("application was stopped")
+
+	return nil
+}
+
+// addCloser registers a resource to be closed on shutdown after the ones
+// already registered.
+func (s *Server) addCloser(name string, close func(ctx context.Context) error) {
+	s.closers = append(s.closers, closer{name: name, close: close})
 }
 
 func (s *Server) listenToOSSignal(eventStream chan<- Event) {
@@ -151,18 +478,63 @@ func (s *Server) listenToOSSignal(eventStream chan<- Event) {
 	}()
 }
 
-// startWebServer starts the web server.
-func (s *Server) startWebServer(kbEndpoints kbs.Endpoints, eventStream chan<- Event) {
+// startWebServer starts the web server. The server is owned by the
+// application so it can be drained on shutdown.
+func (s *Server) startWebServer(endpoints serviceEndpoints, idempotencyKeeper web.IdempotencyKeeper, eventStream chan<- Event) {
+	router := kbsRouter{
+		router:   web.NewRouter(),
+		metadata: s.requestMetadata,
+		admins:   s.admins,
+		logger:   s.logger,
+		accessLog: web.AccessLogSetup{
+			Format: s.setup.AccessLogFormat,
+			Writer: os.Stdout,
+		},
+		metrics:           s.metrics,
+		rateLimiter:       s.rateLimiter,
+		breaker:           s.breaker,
+		idempotency:       idempotencyKeeper,
+		endpoints:         endpoints.kbs,
+		decoders:          web.NewKBDecoders(s.logger),
+		encoders:          web.NewKBEncoders(s.logger),
+		spacesEndpoints:   endpoints.spaces,
+		spacesDecoders:    web.NewSpaceDecoders(s.logger),
+		spacesEncoders:    web.NewSpaceEncoders(s.logger),
+		eventsEndpoints:   endpoints.events,
+		eventsDecoders:    web.NewEventDecoders(s.logger),
+		eventsEncoders:    web.NewEventEncoders(s.logger),
+		usersEndpoints:    endpoints.users,
+		usersDecoders:     web.NewUserDecoders(s.logger),
+		usersEncoders:     web.NewUserEncoders(s.logger),
+		commentsEndpoints: endpoints.comments,
+		commentsDecoders:  web.NewCommentDecoders(s.logger),
+		commentsEncoders:  web.NewCommentEncoders(s.logger),
+		auditEndpoints:    endpoints.audit,
+		auditDecoders:     web.NewAuditDecoders(s.logger),
+		auditEncoders:     web.NewAuditEncoders(s.logger),
+		transferEndpoints: endpoints.transfer,
+		transferDecoders:  web.NewTransferDecoders(s.logger),
+		transferEncoders:  web.NewTransferEncoders(s.logger),
+		healthEndpoints:   endpoints.health,
+		healthDecoders:    web.NewHealthDecoders(s.logger),
+		healthEncoders:    web.NewHealthEncoders(s.logger),
+	}
+
+	s.httpServer = &http.Server{
+		Addr:         s.setup.ApplicationPort,
+		Handler:      newKBsRouter(router),
+		ReadTimeout:  s.setup.HTTPReadTimeout,
+		WriteTimeout: s.setup.HTTPWriteTimeout,
+		IdleTimeout:  s.setup.HTTPIdleTimeout,
+	}
+
 	go func() {
 		s.logger.Info("starting http server", slog.String("port", s.setup.ApplicationPort))
-		router := kbsRouter{
-			router:    web.NewRouter(),
-			endpoints: kbEndpoints,
-			decoders:  web.NewKBDecoders(s.logger),
-			encoders:  web.NewKBEncoders(s.logger),
+		err := s.httpServer.ListenAndServe()
+		if errors.Is(err, http.ErrServerClosed) {
+			// the server was shut down on purpose, there is nobody waiting.
+			return
 		}
-		handler := newKBsRouter(router)
-		err := http.ListenAndServe(s.setup.ApplicationPort, handler)
 		if err != nil {
 			eventStream <- Event{
 				KB:    "web server was ended with error",
@@ -176,6 +548,87 @@ func (s *Server) startWebServer(kbEndpoints kbs.Endpoints, eventStream chan<- Ev
 	}()
 }
 
+// createRequestMetadata loads the api keys and the trusted proxies the
+// principal and the address of each request are verified with, and the
+// principals allowed to use the admin routes.
+func (s *Server) createRequestMetadata() error {
+	setup := s.setup.Identity
+
+	apiKeys, err := web.ParseAPIKeys(string(setup.APIKeys))
+	if err != nil {
+		s.logger.Error("unable to parse api keys", slog.String("error", err.Error()))
+
+		return err
+	}
+
+	proxies, err := web.ParseTrustedProxies(setup.TrustedProxies)
+	if err != nil {
+		s.logger.Error("unable to parse trusted proxies", slog.String("error", err.Error()))
+
+		return err
+	}
+
+	if len(apiKeys) == 0 && len(proxies) == 0 {
+		s.logger.Warn("no api keys nor trusted proxies are configured, every request is anonymous")
+	}
+
+	s.admins = web.ParsePrincipals(setup.AdminPrincipals)
+	if len(s.admins) == 0 {
+		s.logger.Warn("no admin principals are configured, the admin routes are disabled")
+	}
+
+	s.requestMetadata = web.MetadataSetup{
+		DryRun:         s.setup.DryRun,
+		APIKeys:        apiKeys,
+		TrustedProxies: proxies,
+	}
+
+	return nil
+}
+
+// createRateLimiter creates the limiter of the requests of each client if
+// rate limiting is enabled.
+func (s *Server) createRateLimiter() error {
+	setup := s.setup.RateLimit
+	if !setup.Enabled {
+		s.logger.Warn("rate limiting is disabled")
+
+		return nil
+	}
+
+	quotas, err := ratelimit.ParseQuotas(setup.Quotas)
+	if err != nil {
+		s.logger.Error("unable to parse rate limit quotas", slog.String("error", err.Error()))
+
+		return err
+	}
+
+	var counter ratelimit.Counter
+
+	switch setup.Backend {
+	case ratelimit.MemoryBackend:
+		counter = ratelimit.NewMemoryCounter(setup.MaxClients)
+	default:
+		s.logger.Error("unable to create rate limit counter", slog.String("backend", setup.Backend))
+
+		return errUnknownRateLimitBackend
+	}
+
+	s.rateLimiter = ratelimit.New(ratelimit.Setup{
+		Counter: counter,
+		Limits: ratelimit.Limits{
+			ratelimit.ReadClass:  {Rate: setup.ReadRate, Burst: setup.ReadBurst},
+			ratelimit.WriteClass: {Rate: setup.WriteRate, Burst: setup.WriteBurst},
+		},
+		Quotas: quotas,
+		// probes and scrapes must never be rejected.
+		Exempt: []string{"/healthz", "/readyz", "/metrics"},
+		Logger: s.logger,
+	})
+
+	return nil
+}
+
 func (s *Server) loadConfiguration() error {
 	applicationSetUp, err := setups.Load()
 	if err != nil {
@@ -188,10 +641,34 @@ func (s *Server) loadConfiguration() error {
 }
 
 func (s *Server) createDynamodbStorer(ctx context.Context) error {
+	blobStore, err := s.createBlobStore(ctx)
+	if err != nil {
+		s.logger.Error("unable to create blob store at startup", slog.String("error", err.Error()))
+
+		return errors.New("application blob storage could not be created")
+	}
+
 	storeSetup := dynamodb.Setup{
 		Logger:   s.logger,
 		Region:   s.setup.Repository.Region,
 		Endpoint: s.setup.Repository.Endpoint,
+		// consumed capacity is reported as a metric.
+		CapacityRecorder: s.metrics,
+		Capacity: dynamodb.Capacity{
+			BillingMode: s.setup.Repository.BillingMode,
+			ReadUnits:   s.setup.Repository.ReadCapacity,
+			WriteUnits:  s.setup.Repository.WriteCapacity,
+		},
+		SchemaTimeout: s.setup.Repository.SchemaTimeout,
+		Environment:   s.setup.Repository.Environment,
+		KBsTable:      s.setup.Repository.TableName,
+		KeyPrefix:     s.setup.Repository.KeyPrefix,
+		Content: dynamodb.ContentSetup{
+			CompressAbove: s.storeCompressAbove(),
+			OffloadAbove:  s.setup.Content.OffloadAbove,
+			ExcerptLength: s.setup.Content.ExcerptLength,
+			Blobs:         blobStore,
+		},
 	}
 
 	storer, err := dynamodb.NewClient(ctx, storeSetup)
@@ -201,7 +678,229 @@ func (s *Server) createDynamodbStorer(ctx context.Context) error {
 		return errors.New("application dynamodb storage could not be created")
 	}
 
-	s.store = storer
+	if s.setup.Repository.MigrateOnStart {
+		report, err := storer.Migrate(ctx)
+		if err != nil {
+			s.logger.Error("unable to migrate dynamodb schema at startup", slog.String("error", err.Error()))
+
+			return errors.New("application dynamodb schema could not be migrated")
+		}
+
+		s.logger.Info("dynamodb schema is up to date",
+			slog.Int("version", report.Version),
+			slog.Int("applied", len(report.Applied)))
+	}
+
+	// writes of dry run requests never reach dynamodb.
+	dryRunStore := dryrun.New(storer, s.logger)
+
+	encryptedStore, err := s.encryptKBs(ctx, s.resilientKBs(storer))
+	if err != nil {
+		s.logger.Error("unable to create kbs encryption at startup", slog.String("error", err.Error()))
+
+		return errors.New("application kbs encryption could not be created")
+	}
+
+	// dry run plans record the kbs before their content is encrypted.
+	dryRunKBs := dryrun.NewKBStore(encryptedStore, s.logger)
+
+	s.dynamodbKBs = &kbStore{Storer: dryRunKBs, KBScanner: s.decryptingScanner(storer)}
+
+	kbsStorer, err := s.dualWriteKBs(ctx, dryRunKBs)
+	if err != nil {
+		s.logger.Error("unable to create kbs dual write at startup", slog.String("error", err.Error()))
+
+		return errors.New("application kbs dual write could not be created")
+	}
+
+	s.store = s.cacheKBs(s.metrics.NewStore(tracing.NewStore(kbsStorer)))
+	s.spacesStore = dryRunStore
+	s.eventsStore = dryRunStore
+	s.usersStore = dryRunStore
+	s.commentsStore = dryRunStore
+	s.auditStore = storer
+	s.idempotencyStore = storer
+	s.kbScanner = storer
+	s.migrator = storer
+	s.blobs = blobStore
+
+	s.health.Register("dynamodb", health.CheckerFunc(storer.DatasetStatus))
+	s.addCloser("dynamodb client", storer.Close)
+
+	return nil
+}
+
+// createBlobStore creates the store of the content too large for dynamodb
+// items, it returns nil if offloading is disabled.
+func (s *Server) createBlobStore(ctx context.Context) (dynamodb.BlobStore, error) {
+	setup := s.setup.Content
+
+	switch setup.BlobBackend {
+	case "none":
+		s.logger.Warn("kb content offloading is disabled")
+
+		return nil, nil
+	case "file":
+		return blobs.NewFileStore(setup.BlobDir)
+	case "s3":
+		return blobs.NewS3Store(ctx, blobs.S3Setup{
+			Region:   s.setup.Repository.Region,
+			Endpoint: s.setup.Repository.Endpoint,
+			Bucket:   setup.BlobBucket,
+		})
+	default:
+		return nil, fmt.Errorf("%w: %q", errUnknownBlobBackend, setup.BlobBackend)
+	}
+}
+
+// validateSchema checks that the kbs table is the one the store expects
+// before serving requests. Commands skip it, so migrate-schema can fix it.
+func (s *Server) validateSchema(ctx context.Context) error {
+	if !s.setup.Repository.ValidateSchema {
+		s.logger.Warn("dynamodb schema validation is disabled")
+
+		return nil
+	}
+
+	err := s.migrator.ValidateSchema(ctx)
+	if err != nil {
+		s.logger.Error("unable to validate dynamodb schema at startup", slog.String("error", err.Error()))
+
+		return err
+	}
 
 	return nil
 }
+
+// resilientKBs retries the failed calls to the given store and stops
+// calling it while it is unhealthy, if resilience is enabled.
+func (s *Server) resilientKBs(storer kbs.Storer) kbs.Storer {
+	setup := s.setup.Resilience
+	if !setup.Enabled {
+		s.logger.Warn("kbs store resilience is disabled")
+
+		return storer
+	}
+
+	s.breaker = resilience.NewBreaker(resilience.BreakerSetup{
+		Failures: setup.BreakerFailures,
+		Cooldown: setup.BreakerCooldown,
+		// probes and scrapes must never be rejected.
+		Exempt: []string{"/healthz", "/readyz", "/metrics"},
+		Logger: s.logger,
+	})
+
+	return resilience.New(resilience.Setup{
+		Storer: storer,
+		Reads: resilience.Policy{
+			Attempts:  setup.ReadAttempts,
+			BaseDelay: setup.BaseDelay,
+			MaxDelay:  setup.MaxDelay,
+			Timeout:   setup.ReadTimeout,
+		},
+		Writes: resilience.Policy{
+			Attempts:  setup.WriteAttempts,
+			BaseDelay: setup.BaseDelay,
+			MaxDelay:  setup.MaxDelay,
+			Timeout:   setup.WriteTimeout,
+		},
+		Breaker: s.breaker,
+		Logger:  s.logger,
+	})
+}
+
+// encryptKBs encrypts the content of the kbs before it reaches the given
+// store if encryption is enabled. Retried writes are not encrypted again.
+func (s *Server) encryptKBs(ctx context.Context, storer kbs.Storer) (kbs.Storer, error) {
+	setup := s.setup.Encryption
+	if !setup.Enabled {
+		s.logger.Warn("kbs content encryption is disabled")
+
+		return storer, nil
+	}
+
+	var keys encryption.KeyProvider
+	var err error
+
+	switch setup.Provider {
+	case "local":
+		keys, err = encryption.NewLocalKeyProvider(setup.KeyFile)
+	case "kms":
+		keys, err = encryption.NewKMSKeyProvider(ctx, encryption.KMSSetup{
+			Region:   s.setup.Repository.Region,
+			Endpoint: s.setup.Repository.Endpoint,
+			KeyID:    setup.KMSKeyID,
+		})
+	default:
+		err = fmt.Errorf("%w: %q", errUnknownKeyProvider, setup.Provider)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	s.keys = keys
+	s.encrypter = s.newEncrypter(storer)
+
+	s.logger.Info("kbs content encryption is enabled",
+		slog.String("provider", setup.Provider),
+		slog.String("master_key_id", keys.CurrentKeyID()))
+
+	return s.encrypter, nil
+}
+
+// newEncrypter encrypts the content of the kbs written to the given store
+// with the master keys of the server.
+func (s *Server) newEncrypter(storer kbs.Storer) *encryption.Store {
+	return encryption.New(encryption.Setup{
+		Storer:     storer,
+		Keys:       s.keys,
+		Logger:     s.logger,
+		DataKeyTTL: s.setup.Encryption.DataKeyTTL,
+		// ciphertext doesn't compress, content is compressed before it is
+		// encrypted.
+		CompressAbove: s.setup.Content.CompressAbove,
+	})
+}
+
+// storeCompressAbove returns the size above which the store compresses
+// content, the store doesn't compress if content is encrypted, because the
+// encrypter compressed it already.
+func (s *Server) storeCompressAbove() int {
+	if s.setup.Encryption.Enabled {
+		return 0
+	}
+
+	return s.setup.Content.CompressAbove
+}
+
+// decryptingScanner decrypts the content of the kbs read by the given
+// scanner if encryption is enabled.
+func (s *Server) decryptingScanner(scanner encryption.KBScanner) encryption.KBScanner {
+	if s.encrypter == nil {
+		return scanner
+	}
+
+	return s.encrypter.Scanner(scanner)
+}
+
+// cacheKBs keeps the kbs read from the given store in memory if the cache
+// is enabled, so only misses are measured and traced as store calls.
+func (s *Server) cacheKBs(storer kbs.Storer) kbs.Storer {
+	setup := s.setup.Cache
+	if !setup.Enabled {
+		s.logger.Warn("kbs cache is disabled")
+
+		return storer
+	}
+
+	return cache.New(cache.Setup{
+		Storer:      storer,
+		Size:        setup.Size,
+		TTL:         setup.TTL,
+		NegativeTTL: setup.NegativeTTL,
+		LoadTimeout: setup.LoadTimeout,
+		Recorder:    s.metrics,
+		Logger:      s.logger,
+	})
+}
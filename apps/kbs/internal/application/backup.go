@@ -0,0 +1,97 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/adapter/dryrun"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/backup"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/kbs"
+)
+
+// backupPrefix is the prefix of the blobs of the backups.
+const backupPrefix = "backups/"
+
+// backupKBs is a kbs store that can be backed up.
+type backupKBs struct {
+	kbs.Storer
+	backup.Snapshotter
+}
+
+// backupStoreNamed returns the kbs store with the given name below the
+// encryption, so snapshots keep the content encrypted like the store does.
+// Restores write through the cache, so it doesn't keep the replaced kbs,
+// the caches of other instances drop them when their ttl expires.
+func (s *Server) backupStoreNamed(ctx context.Context, name string) (backup.Store, error) {
+	switch name {
+	case dynamodbKBStore:
+		return backupKBs{Storer: s.cacheKBs(dryrun.New(s.migrator, s.logger)), Snapshotter: s.migrator}, nil
+	case sqlKBStore:
+		_, err := s.openSQLKBs(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		return backupKBs{Storer: s.cacheKBs(dryrun.NewKBStore(s.sqlStore, s.logger)), Snapshotter: s.sqlStore}, nil
+	default:
+		return nil, fmt.Errorf("%w: %q", errUnknownKBStore, name)
+	}
+}
+
+// newBackupService creates the service that takes and restores the
+// snapshots of the configured kbs store, the kbs restores write or delete
+// are audited.
+func (s *Server) newBackupService(ctx context.Context) (*backup.Service, error) {
+	if s.blobs == nil {
+		return nil, errMissingBlobStore
+	}
+
+	setup := s.setup.Backup
+
+	store, err := s.backupStoreNamed(ctx, setup.Store)
+	if err != nil {
+		return nil, err
+	}
+
+	return backup.NewService(backup.ServiceSetup{
+		Store:   store,
+		Blobs:   s.blobs,
+		Auditor: s.newAuditService(),
+		Prefix:  backupPrefix + s.setup.Repository.KeyPrefix,
+		Retention: backup.Retention{
+			KeepLast: setup.KeepLast,
+			KeepFor:  setup.KeepFor,
+		},
+		PartSize: setup.PartSize,
+		Logger:   s.logger,
+	}), nil
+}
+
+// startBackupScheduler takes snapshots periodically if backups are enabled.
+func (s *Server) startBackupScheduler(ctx context.Context) error {
+	setup := s.setup.Backup
+	if !setup.Enabled {
+		return nil
+	}
+
+	service, err := s.newBackupService(ctx)
+	if err != nil {
+		s.logger.Error("unable to create kbs backups at startup", slog.String("error", err.Error()))
+
+		return err
+	}
+
+	scheduler := backup.NewScheduler(backup.SchedulerSetup{
+		Service:  service,
+		Interval: setup.Interval,
+		Logger:   s.logger,
+	})
+	s.addCloser("backup scheduler", scheduler.Shutdown)
+
+	s.logger.Info("kbs backups are enabled",
+		slog.String("store", setup.Store),
+		slog.Duration("interval", setup.Interval))
+
+	return nil
+}
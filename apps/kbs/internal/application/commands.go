@@ -0,0 +1,489 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/backup"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/kbs"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/migration"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/requests"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/transfer"
+)
+
+// command is an administrative task that runs instead of the web server.
+type command func(ctx context.Context, args []string) error
+
+var (
+	errUnknownCommand  = errors.New("unknown command")
+	errAuditTampered   = errors.New("audit log chain is broken")
+	errNoEncryption    = errors.New("kbs content encryption is disabled")
+	errMissingFile     = errors.New("a file is required")
+	errImportFailures  = errors.New("some kbs were not imported")
+	errSameKBStore     = errors.New("kbs must be migrated between different stores")
+	errCopyFailures    = errors.New("some kbs were not copied")
+	errKBsDiffer       = errors.New("kbs of the stores differ")
+	errRestoreFailures = errors.New("some kbs were not restored")
+)
+
+// commands returns the administrative commands the server knows.
+func (s *Server) commands() map[string]command {
+	return map[string]command{
+		"backfill-users": s.backfillUsers,
+		"verify-audit":   s.verifyAudit,
+		"migrate-schema": s.migrateSchema,
+		"reencrypt-kbs":  s.reencryptKBs,
+		"export-kbs":     s.exportKBs,
+		"import-kbs":     s.importKBs,
+		"migrate-kbs":    s.migrateKBs,
+		"verify-kbs":     s.verifyKBs,
+		"backup-kbs":     s.backupKBs,
+		"list-backups":   s.listBackups,
+		"restore-kbs":    s.restoreKBs,
+	}
+}
+
+// RunCommand runs the administrative command with the given name.
+func (s *Server) RunCommand(name string, args []string) error {
+	s.notifyStart()
+
+	cmd, ok := s.commands()[name]
+	if !ok {
+		return fmt.Errorf("%w: %q", errUnknownCommand, name)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	err := s.initialize(ctx)
+	if err != nil {
+		return errStartingApplication
+	}
+
+	defer s.Stop()
+
+	s.logger.Info("running command", slog.String("command", name))
+
+	if s.setup.DryRun {
+		ctx = requests.NewDryRunContext(ctx)
+	}
+
+	err = cmd(ctx, args)
+	if err != nil {
+		s.logger.Error("command failed", slog.String("command", name), slog.String("error", err.Error()))
+
+		return err
+	}
+
+	if metadata := requests.FromContext(ctx); metadata.DryRun {
+		fmt.Printf("dry run, %d writes were not stored\n", len(metadata.Plan.Writes()))
+	}
+
+	return nil
+}
+
+// backfillUsers creates the profiles of the users that wrote kbs before
+// the users directory existed.
+func (s *Server) backfillUsers(ctx context.Context, args []string) error {
+	report, err := s.newUsersService().Backfill(ctx, s.kbScanner)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("kbs read: %d, profiles created: %d, profiles skipped: %d\n",
+		report.KBsRead, report.ProfilesCreated, report.ProfilesSkipped)
+
+	return nil
+}
+
+// verifyAudit checks that no entry of the audit log was changed or removed.
+func (s *Server) verifyAudit(ctx context.Context, args []string) error {
+	report, err := s.newAuditService().Verify(ctx)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("entries checked: %d, valid: %t\n", report.EntriesChecked, report.Valid)
+
+	if !report.Valid {
+		fmt.Printf("broken entry: %d, reason: %s\n", report.BrokenSequence, report.Reason)
+
+		return errAuditTampered
+	}
+
+	return nil
+}
+
+// migrateSchema creates or updates the dynamodb tables and indexes and
+// records the applied schema versions.
+func (s *Server) migrateSchema(ctx context.Context, args []string) error {
+	report, err := s.migrator.Migrate(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, applied := range report.Applied {
+		fmt.Printf("applied migration %d: %s\n", applied.Version, applied.Description)
+	}
+
+	fmt.Printf("schema version: %d, migrations applied: %d\n", report.Version, len(report.Applied))
+
+	return nil
+}
+
+// reencryptKBs encrypts the kbs stored before encryption was enabled and
+// re-encrypts the ones protected by a master key that was rotated.
+func (s *Server) reencryptKBs(ctx context.Context, args []string) error {
+	if s.encrypter == nil {
+		return errNoEncryption
+	}
+
+	report, err := s.encrypter.Reencrypt(ctx, s.kbScanner)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("kbs read: %d, encrypted: %d, rotated: %d, skipped: %d\n",
+		report.KBsRead, report.KBsEncrypted, report.KBsRotated, report.KBsSkipped)
+
+	return nil
+}
+
+// exportKBs writes every kb, or the kbs of an event, to a file.
+//
+//	export-kbs -out kbs.ndjson [-format ndjson|markdown] [-event-id id]
+func (s *Server) exportKBs(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("export-kbs", flag.ContinueOnError)
+	out := flags.String("out", "", "file the kbs are written to")
+	format := flags.String("format", string(transfer.NDJSON), "ndjson or markdown")
+	eventID := flags.String("event-id", "", "export only the kbs of this event")
+
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	if *out == "" {
+		return fmt.Errorf("%w: -out", errMissingFile)
+	}
+
+	file, err := os.Create(*out)
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	report, err := s.newTransferService(nil).Export(ctx, transfer.ExportFilter{
+		Format:  transfer.Format(*format),
+		EventID: kbs.EventID(*eventID),
+	}, file)
+	if err != nil {
+		return err
+	}
+
+	err = file.Close()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("kbs read: %d, exported: %d\n", report.KBsRead, report.KBsExported)
+
+	return nil
+}
+
+// importKBs stores the kbs of a file written by export-kbs, zips are read
+// as markdown.
+//
+//	import-kbs -in kbs.ndjson [-format ndjson|markdown]
+func (s *Server) importKBs(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("import-kbs", flag.ContinueOnError)
+	in := flags.String("in", "", "file the kbs are read from")
+	format := flags.String("format", "", "ndjson or markdown, taken from the file extension if empty")
+
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	if *in == "" {
+		return fmt.Errorf("%w: -in", errMissingFile)
+	}
+
+	if *format == "" {
+		*format = string(transfer.NDJSON)
+		if filepath.Ext(*in) == "."+transfer.Markdown.Extension() {
+			*format = string(transfer.Markdown)
+		}
+	}
+
+	file, err := os.Open(*in)
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	report, err := s.newTransferService(s.newImporter()).Import(ctx, transfer.ImportRequest{
+		Format: transfer.Format(*format),
+		Body:   file,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, recordErr := range report.Errors {
+		fmt.Printf("record %d (%s): %s\n", recordErr.Record, recordErr.ID, recordErr.Error)
+	}
+
+	fmt.Printf("read: %d, imported: %d, skipped: %d, failed: %d\n",
+		report.Read, report.Imported, report.Skipped, report.Failed)
+
+	if report.Failed > 0 {
+		return errImportFailures
+	}
+
+	return nil
+}
+
+// migrateKBs copies every kb of a store to another one and resumes from the
+// checkpoint of the last copy, -restart ignores it.
+//
+//	migrate-kbs -from dynamodb -to sql [-workers 4] [-checkpoint file] [-restart] [-verify]
+func (s *Server) migrateKBs(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("migrate-kbs", flag.ContinueOnError)
+	from := flags.String("from", dynamodbKBStore, "store the kbs are read from: dynamodb or sql")
+	to := flags.String("to", sqlKBStore, "store the kbs are copied to: dynamodb or sql")
+	workers := flags.Int("workers", 4, "number of kbs copied at the same time")
+	checkpointFile := flags.String("checkpoint", "", "file that keeps the progress, migrate-kbs-<from>-<to>.checkpoint if empty")
+	restart := flags.Bool("restart", false, "copy every kb again instead of resuming")
+	verify := flags.Bool("verify", false, "verify the stores once the kbs were copied")
+
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	if *checkpointFile == "" {
+		*checkpointFile = fmt.Sprintf("migrate-kbs-%s-%s.checkpoint", *from, *to)
+	}
+
+	fileCheckpoints := migration.NewFileCheckpoints(*checkpointFile)
+
+	var checkpoints migration.Checkpointer = fileCheckpoints
+
+	switch {
+	case *restart && requests.FromContext(ctx).DryRun:
+		// dry runs never save checkpoints, so there is no need to remove it.
+		checkpoints = nil
+	case *restart:
+		err = fileCheckpoints.Reset()
+		if err != nil {
+			return err
+		}
+	}
+
+	migrator, err := s.newMigrator(ctx, *from, *to, *workers, checkpoints)
+	if err != nil {
+		return err
+	}
+
+	report, err := migrator.Copy(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, kbErr := range report.Errors {
+		fmt.Printf("kb %s: %s\n", kbErr.ID, kbErr.Error)
+	}
+
+	fmt.Printf("kbs read: %d, resumed: %d, copied: %d, replaced: %d, unchanged: %d, failed: %d\n",
+		report.KBsRead, report.Resumed, report.Copied, report.Replaced, report.Unchanged, report.Failed)
+
+	if report.Failed > 0 {
+		return errCopyFailures
+	}
+
+	if !*verify {
+		return nil
+	}
+
+	return s.printVerification(ctx, migrator)
+}
+
+// verifyKBs compares the number of kbs and the checksum of their data in
+// two stores.
+//
+//	verify-kbs -from dynamodb -to sql
+func (s *Server) verifyKBs(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("verify-kbs", flag.ContinueOnError)
+	from := flags.String("from", dynamodbKBStore, "store the kbs were copied from: dynamodb or sql")
+	to := flags.String("to", sqlKBStore, "store the kbs were copied to: dynamodb or sql")
+
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	migrator, err := s.newMigrator(ctx, *from, *to, 0, nil)
+	if err != nil {
+		return err
+	}
+
+	return s.printVerification(ctx, migrator)
+}
+
+// newMigrator creates a migrator between the stores with the given names,
+// the tables of the sql store are created if it is the target.
+func (s *Server) newMigrator(ctx context.Context, from, to string, workers int, checkpoints migration.Checkpointer) (*migration.Migrator, error) {
+	if from == to {
+		return nil, errSameKBStore
+	}
+
+	source, err := s.kbStoreNamed(ctx, from)
+	if err != nil {
+		return nil, err
+	}
+
+	target, err := s.kbStoreNamed(ctx, to)
+	if err != nil {
+		return nil, err
+	}
+
+	if to == sqlKBStore && !requests.FromContext(ctx).DryRun {
+		err = s.sqlStore.CreateSchema(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return migration.New(migration.Setup{
+		Source:      source,
+		Target:      target,
+		Checkpoints: checkpoints,
+		Workers:     workers,
+		Logger:      s.logger,
+	}), nil
+}
+
+func (s *Server) printVerification(ctx context.Context, migrator *migration.Migrator) error {
+	report, err := migrator.Verify(ctx)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("source kbs: %d, checksum: %s\n", report.SourceKBs, report.SourceChecksum)
+	fmt.Printf("target kbs: %d, checksum: %s\n", report.TargetKBs, report.TargetChecksum)
+	fmt.Printf("match: %t\n", report.Match)
+
+	if !report.Match {
+		return errKBsDiffer
+	}
+
+	return nil
+}
+
+// backupKBs takes a snapshot of the kbs and removes the ones the retention
+// expired.
+//
+//	backup-kbs
+func (s *Server) backupKBs(ctx context.Context, args []string) error {
+	service, err := s.newBackupService(ctx)
+	if err != nil {
+		return err
+	}
+
+	report, err := service.Backup(ctx)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("snapshot: %s, kbs: %d, parts: %d\n",
+		report.Snapshot.ID, report.Snapshot.KBs, len(report.Snapshot.Parts))
+
+	for _, id := range report.Expired {
+		fmt.Printf("expired snapshot: %s\n", id)
+	}
+
+	return nil
+}
+
+// listBackups prints the snapshots that can be restored, oldest first.
+//
+//	list-backups
+func (s *Server) listBackups(ctx context.Context, args []string) error {
+	service, err := s.newBackupService(ctx)
+	if err != nil {
+		return err
+	}
+
+	snapshots, err := service.Snapshots(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, snapshot := range snapshots {
+		fmt.Printf("%s started: %s, kbs: %d\n",
+			snapshot.ID, time.Unix(snapshot.StartedAt, 0).UTC().Format(time.RFC3339), snapshot.KBs)
+	}
+
+	return nil
+}
+
+// restoreKBs restores the kbs, or a single kb, of the last snapshot taken
+// at or before a date, -prune deletes the kbs that are not in it.
+//
+//	restore-kbs [-at 2026-01-02T15:04:05Z] [-id kb] [-prune]
+func (s *Server) restoreKBs(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("restore-kbs", flag.ContinueOnError)
+	at := flags.String("at", "", "rfc3339 date to restore, now if empty")
+	id := flags.String("id", "", "restore only this kb")
+	prune := flags.Bool("prune", false, "delete the kbs that are not in the snapshot")
+
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	date := time.Now()
+
+	if *at != "" {
+		date, err = time.Parse(time.RFC3339, *at)
+		if err != nil {
+			return err
+		}
+	}
+
+	service, err := s.newBackupService(ctx)
+	if err != nil {
+		return err
+	}
+
+	report, err := service.Restore(ctx, backup.RestoreRequest{
+		At:    date.Unix(),
+		KBID:  kbs.KBID(*id),
+		Prune: *prune,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, kbErr := range report.Errors {
+		fmt.Printf("kb %s: %s\n", kbErr.ID, kbErr.Error)
+	}
+
+	fmt.Printf("snapshot: %s, kbs read: %d, restored: %d, unchanged: %d, deleted: %d, failed: %d\n",
+		report.Snapshot, report.KBsRead, report.Restored, report.Unchanged, report.Deleted, report.Failed)
+
+	if report.Failed > 0 {
+		return errRestoreFailures
+	}
+
+	return nil
+}
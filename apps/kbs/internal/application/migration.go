@@ -0,0 +1,120 @@
+package application
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/adapter/dryrun"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/adapter/dualwrite"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/adapter/stores"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/health"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/kbs"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/migration"
+)
+
+// names of the kbs stores.
+const (
+	dynamodbKBStore = "dynamodb"
+	sqlKBStore      = "sql"
+)
+
+// kbStore is a kbs store that can be scanned, the scanner reads the kbs
+// with their content decrypted.
+type kbStore struct {
+	kbs.Storer
+	migration.KBScanner
+}
+
+// kbStoreNamed returns the kbs store with the given name, the sql store is
+// opened the first time it is needed.
+func (s *Server) kbStoreNamed(ctx context.Context, name string) (*kbStore, error) {
+	switch name {
+	case dynamodbKBStore:
+		return s.dynamodbKBs, nil
+	case sqlKBStore:
+		return s.openSQLKBs(ctx)
+	default:
+		return nil, fmt.Errorf("%w: %q", errUnknownKBStore, name)
+	}
+}
+
+// openSQLKBs opens the sql kbs store. Its writes are skipped on dry runs
+// and its content is encrypted like the one of the dynamodb store.
+func (s *Server) openSQLKBs(ctx context.Context) (*kbStore, error) {
+	if s.sqlKBs != nil {
+		return s.sqlKBs, nil
+	}
+
+	setup := s.setup.SQL
+	if setup.DSN == "" {
+		return nil, errMissingSQLDSN
+	}
+
+	db, err := sql.Open(setup.Driver, string(setup.DSN))
+	if err != nil {
+		return nil, err
+	}
+
+	s.sqlStore = stores.NewStore(stores.Setup{
+		Logger: s.logger,
+		DB:     db,
+	})
+
+	s.health.Register("sql", health.CheckerFunc(s.sqlStore.DatasetStatus))
+	s.addCloser("sql client", s.sqlStore.Close)
+
+	var storer kbs.Storer = s.sqlStore
+	var scanner migration.KBScanner = s.sqlStore
+
+	if s.keys != nil {
+		encrypter := s.newEncrypter(storer)
+		storer = encrypter
+		scanner = encrypter.Scanner(s.sqlStore)
+	}
+
+	// dry run plans record the kbs before their content is encrypted.
+	s.sqlKBs = &kbStore{Storer: dryrun.NewKBStore(storer, s.logger), KBScanner: scanner}
+
+	return s.sqlKBs, nil
+}
+
+// dualWriteKBs writes the kbs to the dynamodb and the sql stores if dual
+// write is enabled, the given store is the dynamodb one.
+func (s *Server) dualWriteKBs(ctx context.Context, dynamodbStore kbs.Storer) (kbs.Storer, error) {
+	setup := s.setup.DualWrite
+	if !setup.Enabled {
+		return dynamodbStore, nil
+	}
+
+	sqlKBs, err := s.openSQLKBs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	primary, secondary := kbs.Storer(dynamodbStore), kbs.Storer(sqlKBs)
+
+	switch setup.Primary {
+	case dynamodbKBStore:
+	case sqlKBStore:
+		primary, secondary = secondary, primary
+	default:
+		return nil, fmt.Errorf("%w: %q", errUnknownKBStore, setup.Primary)
+	}
+
+	if setup.ReadFrom != dynamodbKBStore && setup.ReadFrom != sqlKBStore {
+		return nil, fmt.Errorf("%w: %q", errUnknownKBStore, setup.ReadFrom)
+	}
+
+	s.logger.Info("kbs dual write is enabled",
+		slog.String("primary", setup.Primary),
+		slog.String("read_from", setup.ReadFrom))
+
+	return dualwrite.New(dualwrite.Setup{
+		Primary:       primary,
+		Secondary:     secondary,
+		ReadSecondary: setup.ReadFrom != setup.Primary,
+		Logger:        s.logger,
+	}), nil
+}
@@ -1,55 +1,441 @@
 package application
 
 import (
+	"log/slog"
 	"net/http"
 
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/adapter/metrics"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/adapter/ratelimit"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/adapter/resilience"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/adapter/tracing"
 	"github.com/fernandoocampo/kb-store/apps/kbs/internal/adapter/web"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/audit"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/comments"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/events"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/health"
 	"github.com/fernandoocampo/kb-store/apps/kbs/internal/kbs"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/spaces"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/transfer"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/users"
 	"github.com/gorilla/mux"
 )
 
 type kbsRouter struct {
-	router    *mux.Router
-	endpoints kbs.Endpoints
-	decoders  web.KBDecoders
-	encoders  web.KBEncoders
+	router *mux.Router
+	// admin routes the requests under /admin, only admins can use them.
+	admin    *mux.Router
+	metadata web.MetadataSetup
+	// admins are the principals allowed to use the admin routes.
+	admins    map[string]bool
+	logger    *slog.Logger
+	accessLog web.AccessLogSetup
+	metrics   *metrics.Metrics
+	// rateLimiter is nil if rate limiting is disabled.
+	rateLimiter *ratelimit.Limiter
+	// breaker is nil if the resilience of the kbs store is disabled.
+	breaker     *resilience.Breaker
+	idempotency web.IdempotencyKeeper
+	endpoints   kbs.Endpoints
+	decoders    web.KBDecoders
+	encoders    web.KBEncoders
+
+	spacesEndpoints spaces.Endpoints
+	spacesDecoders  web.SpaceDecoders
+	spacesEncoders  web.SpaceEncoders
+
+	eventsEndpoints events.Endpoints
+	eventsDecoders  web.EventDecoders
+	eventsEncoders  web.EventEncoders
+
+	usersEndpoints users.Endpoints
+	usersDecoders  web.UserDecoders
+	usersEncoders  web.UserEncoders
+
+	commentsEndpoints comments.Endpoints
+	commentsDecoders  web.CommentDecoders
+	commentsEncoders  web.CommentEncoders
+
+	auditEndpoints audit.Endpoints
+	auditDecoders  web.AuditDecoders
+	auditEncoders  web.AuditEncoders
+
+	transferEndpoints transfer.Endpoints
+	transferDecoders  web.TransferDecoders
+	transferEncoders  web.TransferEncoders
+
+	healthEndpoints health.Endpoints
+	healthDecoders  web.HealthDecoders
+	healthEncoders  web.HealthEncoders
 }
 
 func newKBsRouter(kbsRouter kbsRouter) http.Handler {
+	kbsRouter.router.Use(tracing.HTTPMiddleware)
+	kbsRouter.router.Use(kbsRouter.metrics.HTTPMiddleware)
+	kbsRouter.router.Use(web.RequestMetadata(kbsRouter.metadata))
+	kbsRouter.router.Use(web.RequestLogger(kbsRouter.logger, kbsRouter.accessLog))
+
+	if kbsRouter.rateLimiter != nil {
+		kbsRouter.router.Use(kbsRouter.rateLimiter.HTTPMiddleware)
+	}
+
+	if kbsRouter.breaker != nil {
+		kbsRouter.router.Use(kbsRouter.breaker.HTTPMiddleware)
+	}
+
+	kbsRouter.router.Use(web.Idempotency(kbsRouter.idempotency, kbsRouter.logger))
+
+	kbsRouter.router.Methods(http.MethodGet).Path("/metrics").Handler(kbsRouter.metrics.Handler())
+
 	kbsRouter.router.Methods(http.MethodPost).Path("/kbs").Handler(
 		web.NewHandler().
-			WithEndpoint(kbsRouter.endpoints.CreateKBEndpoint).
+			WithEndpoint(kbsRouter.instrument("create_kb", kbsRouter.endpoints.CreateKBEndpoint)).
 			WithDecoder(kbsRouter.decoders.CreateDecoder).
 			WithEncoder(kbsRouter.encoders.CreateEncoder),
 	)
 
 	kbsRouter.router.Methods(http.MethodPut).Path("/kbs").Handler(
 		web.NewHandler().
-			WithEndpoint(kbsRouter.endpoints.UpdateKBEndpoint).
+			WithEndpoint(kbsRouter.instrument("update_kb", kbsRouter.endpoints.UpdateKBEndpoint)).
 			WithDecoder(kbsRouter.decoders.UpdateDecoder).
 			WithEncoder(kbsRouter.encoders.UpdateEncoder),
 	)
 
 	kbsRouter.router.Methods(http.MethodDelete).Path("/kbs/{id}").Handler(
 		web.NewHandler().
-			WithEndpoint(kbsRouter.endpoints.DeleteKBEndpoint).
+			WithEndpoint(kbsRouter.instrument("delete_kb", kbsRouter.endpoints.DeleteKBEndpoint)).
 			WithDecoder(kbsRouter.decoders.DeleteDecoder).
 			WithEncoder(kbsRouter.encoders.DeleteEncoder),
 	)
 
 	kbsRouter.router.Methods(http.MethodGet).Path("/kbs/{id}").Handler(
 		web.NewHandler().
-			WithEndpoint(kbsRouter.endpoints.GetKBWithIDEndpoint).
+			WithEndpoint(kbsRouter.instrument("get_kb", kbsRouter.endpoints.GetKBWithIDEndpoint)).
 			WithDecoder(kbsRouter.decoders.GetByIDDecoder).
 			WithEncoder(kbsRouter.encoders.GetByIDEncoder),
 	)
 
 	kbsRouter.router.Methods(http.MethodGet).Path("/kbs").Handler(
 		web.NewHandler().
-			WithEndpoint(kbsRouter.endpoints.SearchKBsEndpoint).
+			WithEndpoint(kbsRouter.instrument("search_kbs", kbsRouter.endpoints.SearchKBsEndpoint)).
 			WithDecoder(kbsRouter.decoders.SearchDecoder).
 			WithEncoder(kbsRouter.encoders.SearchEncoder),
 	)
 
+	kbsRouter.router.Methods(http.MethodPost).Path("/kbs/{id}/move").Handler(
+		web.NewHandler().
+			WithEndpoint(kbsRouter.spacesEndpoints.MoveKBEndpoint).
+			WithDecoder(kbsRouter.spacesDecoders.MoveKBDecoder).
+			WithEncoder(kbsRouter.spacesEncoders.OperationEncoder),
+	)
+
+	kbsRouter.router.Methods(http.MethodPost).Path("/kbs/{id}/copy").Handler(
+		web.NewHandler().
+			WithEndpoint(kbsRouter.spacesEndpoints.CopyKBEndpoint).
+			WithDecoder(kbsRouter.spacesDecoders.CopyKBDecoder).
+			WithEncoder(kbsRouter.spacesEncoders.CreateEncoder),
+	)
+
+	kbsRouter.admin = kbsRouter.router.PathPrefix("/admin").Subrouter()
+	kbsRouter.admin.Use(web.RequireAdmin(kbsRouter.admins))
+
+	newWorkflowRoutes(kbsRouter)
+	newSpacesRoutes(kbsRouter)
+	newEventsRoutes(kbsRouter)
+	newUsersRoutes(kbsRouter)
+	newCommentsRoutes(kbsRouter)
+	newAuditRoutes(kbsRouter)
+	newTransferRoutes(kbsRouter)
+	newHealthRoutes(kbsRouter)
+
 	return kbsRouter.router
 }
+
+// instrument records the calls to the given kbs endpoint with the given name.
+func (k kbsRouter) instrument(name string, endpoint web.Endpoint) web.Endpoint {
+	return k.metrics.InstrumentEndpoint(name, endpoint)
+}
+
+func newWorkflowRoutes(kbsRouter kbsRouter) {
+	kbsRouter.router.Methods(http.MethodPost).Path("/kbs/{id}/submit").Handler(
+		web.NewHandler().
+			WithEndpoint(kbsRouter.instrument("submit_kb", kbsRouter.endpoints.SubmitKBEndpoint)).
+			WithDecoder(kbsRouter.decoders.SubmitDecoder).
+			WithEncoder(kbsRouter.encoders.StateEncoder),
+	)
+
+	kbsRouter.router.Methods(http.MethodPost).Path("/kbs/{id}/approve").Handler(
+		web.NewHandler().
+			WithEndpoint(kbsRouter.instrument("approve_kb", kbsRouter.endpoints.ApproveKBEndpoint)).
+			WithDecoder(kbsRouter.decoders.ReviewDecoder).
+			WithEncoder(kbsRouter.encoders.StateEncoder),
+	)
+
+	kbsRouter.router.Methods(http.MethodPost).Path("/kbs/{id}/reject").Handler(
+		web.NewHandler().
+			WithEndpoint(kbsRouter.instrument("reject_kb", kbsRouter.endpoints.RejectKBEndpoint)).
+			WithDecoder(kbsRouter.decoders.ReviewDecoder).
+			WithEncoder(kbsRouter.encoders.StateEncoder),
+	)
+
+	kbsRouter.router.Methods(http.MethodPost).Path("/kbs/{id}/archive").Handler(
+		web.NewHandler().
+			WithEndpoint(kbsRouter.instrument("archive_kb", kbsRouter.endpoints.ArchiveKBEndpoint)).
+			WithDecoder(kbsRouter.decoders.ArchiveDecoder).
+			WithEncoder(kbsRouter.encoders.StateEncoder),
+	)
+}
+
+func newSpacesRoutes(kbsRouter kbsRouter) {
+	kbsRouter.router.Methods(http.MethodPost).Path("/spaces").Handler(
+		web.NewHandler().
+			WithEndpoint(kbsRouter.spacesEndpoints.CreateSpaceEndpoint).
+			WithDecoder(kbsRouter.spacesDecoders.CreateSpaceDecoder).
+			WithEncoder(kbsRouter.spacesEncoders.CreateEncoder),
+	)
+
+	kbsRouter.router.Methods(http.MethodPut).Path("/spaces").Handler(
+		web.NewHandler().
+			WithEndpoint(kbsRouter.spacesEndpoints.UpdateSpaceEndpoint).
+			WithDecoder(kbsRouter.spacesDecoders.UpdateSpaceDecoder).
+			WithEncoder(kbsRouter.spacesEncoders.OperationEncoder),
+	)
+
+	kbsRouter.router.Methods(http.MethodGet).Path("/spaces").Handler(
+		web.NewHandler().
+			WithEndpoint(kbsRouter.spacesEndpoints.SearchSpacesEndpoint).
+			WithDecoder(kbsRouter.spacesDecoders.SearchSpacesDecoder).
+			WithEncoder(kbsRouter.spacesEncoders.SearchSpacesEncoder),
+	)
+
+	kbsRouter.router.Methods(http.MethodGet).Path("/spaces/{id}").Handler(
+		web.NewHandler().
+			WithEndpoint(kbsRouter.spacesEndpoints.GetSpaceWithIDEndpoint).
+			WithDecoder(kbsRouter.spacesDecoders.SpaceIDDecoder).
+			WithEncoder(kbsRouter.spacesEncoders.GetSpaceEncoder),
+	)
+
+	kbsRouter.router.Methods(http.MethodDelete).Path("/spaces/{id}").Handler(
+		web.NewHandler().
+			WithEndpoint(kbsRouter.spacesEndpoints.DeleteSpaceEndpoint).
+			WithDecoder(kbsRouter.spacesDecoders.SpaceIDDecoder).
+			WithEncoder(kbsRouter.spacesEncoders.OperationEncoder),
+	)
+
+	kbsRouter.router.Methods(http.MethodPost).Path("/spaces/{id}/collections").Handler(
+		web.NewHandler().
+			WithEndpoint(kbsRouter.spacesEndpoints.CreateCollectionEndpoint).
+			WithDecoder(kbsRouter.spacesDecoders.CreateCollectionDecoder).
+			WithEncoder(kbsRouter.spacesEncoders.CreateEncoder),
+	)
+
+	kbsRouter.router.Methods(http.MethodGet).Path("/spaces/{id}/collections").Handler(
+		web.NewHandler().
+			WithEndpoint(kbsRouter.spacesEndpoints.SearchCollectionsEndpoint).
+			WithDecoder(kbsRouter.spacesDecoders.SpaceIDDecoder).
+			WithEncoder(kbsRouter.spacesEncoders.SearchCollectionsEncoder),
+	)
+
+	kbsRouter.router.Methods(http.MethodPut).Path("/collections").Handler(
+		web.NewHandler().
+			WithEndpoint(kbsRouter.spacesEndpoints.UpdateCollectionEndpoint).
+			WithDecoder(kbsRouter.spacesDecoders.UpdateCollectionDecoder).
+			WithEncoder(kbsRouter.spacesEncoders.OperationEncoder),
+	)
+
+	kbsRouter.router.Methods(http.MethodGet).Path("/collections/{id}").Handler(
+		web.NewHandler().
+			WithEndpoint(kbsRouter.spacesEndpoints.GetCollectionWithIDEndpoint).
+			WithDecoder(kbsRouter.spacesDecoders.CollectionIDDecoder).
+			WithEncoder(kbsRouter.spacesEncoders.GetCollectionEncoder),
+	)
+
+	kbsRouter.router.Methods(http.MethodDelete).Path("/collections/{id}").Handler(
+		web.NewHandler().
+			WithEndpoint(kbsRouter.spacesEndpoints.DeleteCollectionEndpoint).
+			WithDecoder(kbsRouter.spacesDecoders.CollectionIDDecoder).
+			WithEncoder(kbsRouter.spacesEncoders.OperationEncoder),
+	)
+
+	kbsRouter.router.Methods(http.MethodPost).Path("/collections/{id}/move").Handler(
+		web.NewHandler().
+			WithEndpoint(kbsRouter.spacesEndpoints.MoveCollectionEndpoint).
+			WithDecoder(kbsRouter.spacesDecoders.MoveCollectionDecoder).
+			WithEncoder(kbsRouter.spacesEncoders.OperationEncoder),
+	)
+
+	kbsRouter.router.Methods(http.MethodGet).Path("/collections/{id}/kbs").Handler(
+		web.NewHandler().
+			WithEndpoint(kbsRouter.spacesEndpoints.SearchCollectionKBsEndpoint).
+			WithDecoder(kbsRouter.spacesDecoders.CollectionIDDecoder).
+			WithEncoder(kbsRouter.spacesEncoders.SearchCollectionKBsEncoder),
+	)
+}
+
+func newEventsRoutes(kbsRouter kbsRouter) {
+	kbsRouter.router.Methods(http.MethodPost).Path("/events").Handler(
+		web.NewHandler().
+			WithEndpoint(kbsRouter.eventsEndpoints.CreateEventEndpoint).
+			WithDecoder(kbsRouter.eventsDecoders.CreateDecoder).
+			WithEncoder(kbsRouter.eventsEncoders.CreateEncoder),
+	)
+
+	kbsRouter.router.Methods(http.MethodPut).Path("/events").Handler(
+		web.NewHandler().
+			WithEndpoint(kbsRouter.eventsEndpoints.UpdateEventEndpoint).
+			WithDecoder(kbsRouter.eventsDecoders.UpdateDecoder).
+			WithEncoder(kbsRouter.eventsEncoders.UpdateEncoder),
+	)
+
+	kbsRouter.router.Methods(http.MethodDelete).Path("/events/{id}").Handler(
+		web.NewHandler().
+			WithEndpoint(kbsRouter.eventsEndpoints.DeleteEventEndpoint).
+			WithDecoder(kbsRouter.eventsDecoders.DeleteDecoder).
+			WithEncoder(kbsRouter.eventsEncoders.DeleteEncoder),
+	)
+
+	kbsRouter.router.Methods(http.MethodGet).Path("/events/{id}").Handler(
+		web.NewHandler().
+			WithEndpoint(kbsRouter.eventsEndpoints.GetEventWithIDEndpoint).
+			WithDecoder(kbsRouter.eventsDecoders.GetByIDDecoder).
+			WithEncoder(kbsRouter.eventsEncoders.GetByIDEncoder),
+	)
+
+	kbsRouter.router.Methods(http.MethodGet).Path("/events").Handler(
+		web.NewHandler().
+			WithEndpoint(kbsRouter.eventsEndpoints.SearchEventsEndpoint).
+			WithDecoder(kbsRouter.eventsDecoders.SearchDecoder).
+			WithEncoder(kbsRouter.eventsEncoders.SearchEncoder),
+	)
+
+	kbsRouter.router.Methods(http.MethodGet).Path("/events/{id}/kbs").Handler(
+		web.NewHandler().
+			WithEndpoint(kbsRouter.instrument("search_kbs", kbsRouter.endpoints.SearchKBsEndpoint)).
+			WithDecoder(kbsRouter.eventsDecoders.SearchKBsDecoder).
+			WithEncoder(kbsRouter.encoders.SearchEncoder),
+	)
+}
+
+func newUsersRoutes(kbsRouter kbsRouter) {
+	kbsRouter.router.Methods(http.MethodPost).Path("/users").Handler(
+		web.NewHandler().
+			WithEndpoint(kbsRouter.usersEndpoints.CreateProfileEndpoint).
+			WithDecoder(kbsRouter.usersDecoders.CreateDecoder).
+			WithEncoder(kbsRouter.usersEncoders.CreateEncoder),
+	)
+
+	kbsRouter.router.Methods(http.MethodPut).Path("/users").Handler(
+		web.NewHandler().
+			WithEndpoint(kbsRouter.usersEndpoints.UpdateProfileEndpoint).
+			WithDecoder(kbsRouter.usersDecoders.UpdateDecoder).
+			WithEncoder(kbsRouter.usersEncoders.UpdateEncoder),
+	)
+
+	kbsRouter.router.Methods(http.MethodDelete).Path("/users/{id}").Handler(
+		web.NewHandler().
+			WithEndpoint(kbsRouter.usersEndpoints.DeleteProfileEndpoint).
+			WithDecoder(kbsRouter.usersDecoders.DeleteDecoder).
+			WithEncoder(kbsRouter.usersEncoders.DeleteEncoder),
+	)
+
+	kbsRouter.router.Methods(http.MethodGet).Path("/users/{id}").Handler(
+		web.NewHandler().
+			WithEndpoint(kbsRouter.usersEndpoints.GetProfileWithIDEndpoint).
+			WithDecoder(kbsRouter.usersDecoders.GetByIDDecoder).
+			WithEncoder(kbsRouter.usersEncoders.GetByIDEncoder),
+	)
+
+	kbsRouter.router.Methods(http.MethodGet).Path("/users").Handler(
+		web.NewHandler().
+			WithEndpoint(kbsRouter.usersEndpoints.SearchProfilesEndpoint).
+			WithDecoder(kbsRouter.usersDecoders.SearchDecoder).
+			WithEncoder(kbsRouter.usersEncoders.SearchEncoder),
+	)
+
+	kbsRouter.router.Methods(http.MethodGet).Path("/users/{id}/kbs").Handler(
+		web.NewHandler().
+			WithEndpoint(kbsRouter.instrument("search_kbs", kbsRouter.endpoints.SearchKBsEndpoint)).
+			WithDecoder(kbsRouter.usersDecoders.SearchKBsDecoder).
+			WithEncoder(kbsRouter.encoders.SearchEncoder),
+	)
+}
+
+func newCommentsRoutes(kbsRouter kbsRouter) {
+	kbsRouter.router.Methods(http.MethodPost).Path("/kbs/{id}/comments").Handler(
+		web.NewHandler().
+			WithEndpoint(kbsRouter.commentsEndpoints.CreateCommentEndpoint).
+			WithDecoder(kbsRouter.commentsDecoders.CreateDecoder).
+			WithEncoder(kbsRouter.commentsEncoders.CreateEncoder),
+	)
+
+	kbsRouter.router.Methods(http.MethodGet).Path("/kbs/{id}/comments").Handler(
+		web.NewHandler().
+			WithEndpoint(kbsRouter.commentsEndpoints.SearchCommentsEndpoint).
+			WithDecoder(kbsRouter.commentsDecoders.SearchDecoder).
+			WithEncoder(kbsRouter.commentsEncoders.SearchEncoder),
+	)
+
+	kbsRouter.router.Methods(http.MethodGet).Path("/comments/{id}").Handler(
+		web.NewHandler().
+			WithEndpoint(kbsRouter.commentsEndpoints.GetCommentWithIDEndpoint).
+			WithDecoder(kbsRouter.commentsDecoders.GetByIDDecoder).
+			WithEncoder(kbsRouter.commentsEncoders.GetByIDEncoder),
+	)
+
+	kbsRouter.router.Methods(http.MethodPut).Path("/comments/{id}").Handler(
+		web.NewHandler().
+			WithEndpoint(kbsRouter.commentsEndpoints.UpdateCommentEndpoint).
+			WithDecoder(kbsRouter.commentsDecoders.UpdateDecoder).
+			WithEncoder(kbsRouter.commentsEncoders.UpdateEncoder),
+	)
+
+	kbsRouter.router.Methods(http.MethodDelete).Path("/comments/{id}").Handler(
+		web.NewHandler().
+			WithEndpoint(kbsRouter.commentsEndpoints.DeleteCommentEndpoint).
+			WithDecoder(kbsRouter.commentsDecoders.DeleteDecoder).
+			WithEncoder(kbsRouter.commentsEncoders.DeleteEncoder),
+	)
+}
+
+// newAuditRoutes registers the search of the audit log, only admins can use it.
+func newAuditRoutes(kbsRouter kbsRouter) {
+	kbsRouter.admin.Methods(http.MethodGet).Path("/audit").Handler(
+		web.NewHandler().
+			WithEndpoint(kbsRouter.auditEndpoints.SearchEntriesEndpoint).
+			WithDecoder(kbsRouter.auditDecoders.SearchDecoder).
+			WithEncoder(kbsRouter.auditEncoders.SearchEncoder),
+	)
+}
+
+// newTransferRoutes registers the export and the import of kbs, only admins
+// can use them, exports carry the decrypted content of every kb.
+func newTransferRoutes(kbsRouter kbsRouter) {
+	kbsRouter.admin.Methods(http.MethodGet).Path("/kbs/export").Handler(
+		web.NewHandler().
+			WithEndpoint(kbsRouter.transferEndpoints.ExportKBsEndpoint).
+			WithDecoder(kbsRouter.transferDecoders.ExportDecoder).
+			WithEncoder(kbsRouter.transferEncoders.ExportEncoder),
+	)
+
+	kbsRouter.admin.Methods(http.MethodPost).Path("/kbs/import").Handler(
+		web.NewHandler().
+			WithEndpoint(kbsRouter.transferEndpoints.ImportKBsEndpoint).
+			WithDecoder(kbsRouter.transferDecoders.ImportDecoder).
+			WithEncoder(kbsRouter.transferEncoders.ImportEncoder),
+	)
+}
+
+func newHealthRoutes(kbsRouter kbsRouter) {
+	kbsRouter.router.Methods(http.MethodGet).Path("/healthz").Handler(
+		web.NewHandler().
+			WithEndpoint(kbsRouter.healthEndpoints.LivenessEndpoint).
+			WithDecoder(kbsRouter.healthDecoders.CheckDecoder).
+			WithEncoder(kbsRouter.healthEncoders.ReportEncoder),
+	)
+
+	kbsRouter.router.Methods(http.MethodGet).Path("/readyz").Handler(
+		web.NewHandler().
+			WithEndpoint(kbsRouter.healthEndpoints.ReadinessEndpoint).
+			WithDecoder(kbsRouter.healthDecoders.CheckDecoder).
+			WithEncoder(kbsRouter.healthEncoders.ReportEncoder),
+	)
+}
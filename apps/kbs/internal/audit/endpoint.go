@@ -0,0 +1,52 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+)
+
+type SearchEntriesEndpoint struct {
+	service *Service
+	logger  *slog.Logger
+}
+
+// Endpoints is a wrapper for audit endpoints
+type Endpoints struct {
+	SearchEntriesEndpoint *SearchEntriesEndpoint
+}
+
+// NewEndpoints Create the endpoints for the audit log.
+func NewEndpoints(service *Service, logger *slog.Logger) Endpoints {
+	return Endpoints{
+		SearchEntriesEndpoint: MakeSearchEntriesEndpoint(service, logger),
+	}
+}
+
+// MakeSearchEntriesEndpoint audit endpoint to search entries with filters.
+func MakeSearchEntriesEndpoint(srv *Service, logger *slog.Logger) *SearchEntriesEndpoint {
+	return &SearchEntriesEndpoint{
+		service: srv,
+		logger:  logger,
+	}
+}
+
+func (s *SearchEntriesEndpoint) Do(ctx context.Context, request any) (any, error) {
+	filter, ok := request.(QueryFilter)
+	if !ok {
+		s.logger.Error("invalid audit filters", slog.String("received", fmt.Sprintf("%T", request)))
+
+		return nil, errors.New("invalid audit filters")
+	}
+
+	page, err := s.service.Query(ctx, filter)
+	if err != nil {
+		s.logger.Error(
+			"something went wrong trying to search audit entries",
+			slog.String("error", err.Error()),
+		)
+	}
+
+	return newSearchEntriesResult(page, err), nil
+}
@@ -0,0 +1,146 @@
+package audit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/kbs"
+)
+
+// Entry is a record of the audit log. Every entry contains the hash of the
+// previous one, so changing or removing an entry breaks the chain.
+type Entry struct {
+	Sequence     int64       `json:"sequence"`
+	Timestamp    int64       `json:"timestamp"`
+	ActorID      kbs.UserID  `json:"actor_id"`
+	Action       kbs.Action  `json:"action"`
+	KBID         kbs.KBID    `json:"kb_id"`
+	EventID      kbs.EventID `json:"event_id"`
+	BeforeDigest string      `json:"before_digest"`
+	AfterDigest  string      `json:"after_digest"`
+	RequestID    string      `json:"request_id"`
+	ClientIP     string      `json:"client_ip"`
+	PreviousHash string      `json:"previous_hash"`
+	Hash         string      `json:"hash"`
+}
+
+// QueryFilter contains data for audit log query filters. Dates are unix
+// seconds, zero means no limit.
+type QueryFilter struct {
+	KBID    kbs.KBID
+	ActorID kbs.UserID
+	From    int64
+	To      int64
+	// After is the cursor of the page, only entries with a greater sequence
+	// are returned. Zero is the first page.
+	After int64
+	// PageSize is the maximum number of entries returned, stores don't
+	// limit the entries if it is zero.
+	PageSize int
+}
+
+// EntriesPage is a page of audit entries ordered by sequence.
+type EntriesPage struct {
+	Entries []Entry
+	// Next is the cursor of the next page, zero if this is the last one.
+	Next int64
+}
+
+// VerifyReport contains the result of checking the audit log chain.
+type VerifyReport struct {
+	EntriesChecked int
+	Valid          bool
+	// BrokenSequence is the first entry that doesn't match the chain.
+	BrokenSequence int64
+	Reason         string
+}
+
+// SearchEntriesResult standard response for searching audit entries.
+type SearchEntriesResult struct {
+	Entries []Entry
+	Next    int64
+	Err     string
+}
+
+// page sizes of audit queries.
+const (
+	PageSizeDefault = 100
+	PageSizeMax     = 1000
+)
+
+// AnonymousActor is the actor of the changes made by requests without a
+// verified principal.
+const AnonymousActor kbs.UserID = "anonymous"
+
+// ErrConflict is returned by stores when an entry with the same sequence
+// was already appended.
+var ErrConflict = errors.New("audit entry sequence already exists")
+
+// genesisHash is the previous hash of the first entry of the chain.
+const genesisHash = ""
+
+// computeHash returns the hash of the entry content chained to the
+// previous hash.
+func (e Entry) computeHash() string {
+	fields := []string{
+		strconv.FormatInt(e.Sequence, 10),
+		strconv.FormatInt(e.Timestamp, 10),
+		e.ActorID.String(),
+		e.Action.String(),
+		e.KBID.String(),
+		e.EventID.String(),
+		e.BeforeDigest,
+		e.AfterDigest,
+		e.RequestID,
+		e.ClientIP,
+		e.PreviousHash,
+	}
+
+	sum := sha256.Sum256([]byte(strings.Join(fields, "|")))
+
+	return hex.EncodeToString(sum[:])
+}
+
+// digest returns the hash of the given kb, or an empty string if there is no kb.
+func digest(kb *kbs.KB) (string, error) {
+	if kb == nil {
+		return "", nil
+	}
+
+	data, err := json.Marshal(kb)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(data)
+
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// newSearchEntriesResult create a new SearchEntriesResult
+func newSearchEntriesResult(page EntriesPage, err error) SearchEntriesResult {
+	var errAudit string
+	if err != nil {
+		errAudit = err.Error()
+	}
+	return SearchEntriesResult{
+		Entries: page.Entries,
+		Next:    page.Next,
+		Err:     errAudit,
+	}
+}
+
+// fillDefaultValues bounds the page size of the filter.
+func (q *QueryFilter) fillDefaultValues() {
+	if q.PageSize <= 0 {
+		q.PageSize = PageSizeDefault
+	}
+
+	if q.PageSize > PageSizeMax {
+		q.PageSize = PageSizeMax
+	}
+}
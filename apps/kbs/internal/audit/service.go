@@ -0,0 +1,254 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/kbs"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/requests"
+)
+
+// Storer defines persistence behavior for the audit log. Entries are never
+// updated or deleted.
+type Storer interface {
+	// AppendEntry stores the entry, it returns ErrConflict if there is
+	// already an entry with the same sequence.
+	AppendEntry(ctx context.Context, entry Entry) error
+	// LastEntry returns the entry with the highest sequence, or nil if
+	// the log is empty.
+	LastEntry(ctx context.Context) (*Entry, error)
+	// QueryEntries returns the entries that match the filter ordered by
+	// sequence, at most PageSize entries after the After sequence.
+	QueryEntries(ctx context.Context, filter QueryFilter) ([]Entry, error)
+	// ScanEntries calls fn for every entry ordered by sequence.
+	ScanEntries(ctx context.Context, fn func(entry Entry) error) error
+}
+
+// ServiceSetup contains service metadata.
+type ServiceSetup struct {
+	Storer Storer
+	Logger *slog.Logger
+}
+
+// Service implements the audit log business logic.
+type Service struct {
+	storer Storer
+	logger *slog.Logger
+	mu     sync.Mutex
+	// last is the last entry appended by this service, nil until the
+	// first append or after a conflict.
+	last *Entry
+}
+
+// appendAttempts is the number of times an append is retried when other
+// instance appended an entry with the same sequence.
+const appendAttempts = 3
+
+var (
+	errAppendEntry  = errors.New("unable to append audit entry")
+	errQueryEntries = errors.New("unable to query audit entries")
+	errVerifyChain  = errors.New("unable to verify audit log")
+)
+
+// NewService create a new audit service.
+func NewService(settings ServiceSetup) *Service {
+	newService := Service{
+		storer: settings.Storer,
+		logger: settings.Logger,
+	}
+
+	return &newService
+}
+
+// Record appends the given kb change to the audit log with the metadata
+// of the request that made it. It implements kbs.Auditor. Changes of dry
+// run requests are not recorded, they were not stored.
+func (s *Service) Record(ctx context.Context, record kbs.AuditRecord) error {
+	if requests.IsDryRun(ctx) {
+		return nil
+	}
+
+	entry, err := newEntry(ctx, record)
+	if err != nil {
+		s.logger.Error("unable to build audit entry", slog.String("error", err.Error()))
+
+		return errAppendEntry
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for attempt := 1; attempt <= appendAttempts; attempt++ {
+		err = s.append(ctx, entry)
+		if !errors.Is(err, ErrConflict) {
+			break
+		}
+
+		s.logger.Debug("audit entry sequence was taken, retrying", slog.Int("attempt", attempt))
+	}
+
+	if err != nil {
+		s.logger.Error("unable to append audit entry",
+			slog.String("kb_id", record.KBID.String()),
+			slog.String("error", err.Error()))
+
+		return errAppendEntry
+	}
+
+	return nil
+}
+
+// Query returns a page of the audit entries that match the given filter.
+func (s *Service) Query(ctx context.Context, filter QueryFilter) (EntriesPage, error) {
+	filter.fillDefaultValues()
+
+	pageSize := filter.PageSize
+	// one more entry tells if there is a next page.
+	filter.PageSize++
+
+	entries, err := s.storer.QueryEntries(ctx, filter)
+	if err != nil {
+		s.logger.Error("unable to query audit entries",
+			slog.String("filter", fmt.Sprintf("%+v", filter)),
+			slog.String("error", err.Error()))
+
+		return EntriesPage{}, errQueryEntries
+	}
+
+	if len(entries) <= pageSize {
+		return EntriesPage{Entries: entries}, nil
+	}
+
+	entries = entries[:pageSize]
+
+	return EntriesPage{
+		Entries: entries,
+		Next:    entries[pageSize-1].Sequence,
+	}, nil
+}
+
+// Verify walks the whole audit log and checks that every entry is chained
+// to the previous one and that its content was not changed.
+func (s *Service) Verify(ctx context.Context) (VerifyReport, error) {
+	report := VerifyReport{Valid: true}
+	previousHash := genesisHash
+	expectedSequence := int64(1)
+
+	err := s.storer.ScanEntries(ctx, func(entry Entry) error {
+		report.EntriesChecked++
+
+		reason := ""
+
+		switch {
+		case entry.Sequence != expectedSequence:
+			reason = fmt.Sprintf("expected sequence %d", expectedSequence)
+		case entry.PreviousHash != previousHash:
+			reason = "previous hash does not match the previous entry"
+		case entry.Hash != entry.computeHash():
+			reason = "entry content does not match its hash"
+		}
+
+		if reason != "" {
+			report.Valid = false
+			report.BrokenSequence = entry.Sequence
+			report.Reason = reason
+
+			return errStopScan
+		}
+
+		previousHash = entry.Hash
+		expectedSequence++
+
+		return nil
+	})
+	if err != nil && !errors.Is(err, errStopScan) {
+		s.logger.Error("unable to scan audit entries", slog.String("error", err.Error()))
+
+		return report, errVerifyChain
+	}
+
+	return report, nil
+}
+
+// errStopScan stops the scan of the log once a broken entry is found.
+var errStopScan = errors.New("stop scan")
+
+// append chains the entry to the last one and stores it.
+func (s *Service) append(ctx context.Context, entry Entry) error {
+	if s.last == nil {
+		last, err := s.storer.LastEntry(ctx)
+		if err != nil {
+			return err
+		}
+
+		s.last = last
+	}
+
+	entry.Sequence = 1
+	entry.PreviousHash = genesisHash
+
+	if s.last != nil {
+		entry.Sequence = s.last.Sequence + 1
+		entry.PreviousHash = s.last.Hash
+	}
+
+	entry.Hash = entry.computeHash()
+
+	err := s.storer.AppendEntry(ctx, entry)
+	if err != nil {
+		s.last = nil
+
+		return err
+	}
+
+	s.last = &entry
+
+	return nil
+}
+
+// newEntry builds an unchained entry for the given record. The actor is the
+// verified principal of the request, or AnonymousActor if there is none.
+func newEntry(ctx context.Context, record kbs.AuditRecord) (Entry, error) {
+	metadata := requests.FromContext(ctx)
+
+	actor := kbs.UserID(metadata.Principal)
+	if actor == "" {
+		actor = AnonymousActor
+	}
+
+	entry := Entry{
+		Timestamp: time.Now().UTC().Unix(),
+		ActorID:   actor,
+		Action:    record.Action,
+		KBID:      record.KBID,
+		RequestID: metadata.RequestID,
+		ClientIP:  metadata.ClientIP,
+	}
+
+	current := record.After
+	if current == nil {
+		current = record.Before
+	}
+
+	if current != nil {
+		entry.EventID = current.EventID
+	}
+
+	var err error
+
+	entry.BeforeDigest, err = digest(record.Before)
+	if err != nil {
+		return entry, err
+	}
+
+	entry.AfterDigest, err = digest(record.After)
+	if err != nil {
+		return entry, err
+	}
+
+	return entry, nil
+}
@@ -0,0 +1,220 @@
+package audit_test
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/audit"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/kbs"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/requests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordChainsEntries(t *testing.T) {
+	// Given
+	ctx := requests.NewContext(context.TODO(), requests.Metadata{
+		RequestID: "req-1",
+		Principal: "mono",
+		ClientIP:  "10.0.0.1",
+	})
+	store := newMemoryStore()
+	service := audit.NewService(audit.ServiceSetup{
+		Storer: store,
+		Logger: newDummyLogger(),
+	})
+	before := kbs.KB{ID: "kb-1", UserID: "drila", EventID: "ev-1", Content: "draft"}
+	after := kbs.KB{ID: "kb-1", UserID: "drila", EventID: "ev-1", Content: "final"}
+
+	// When
+	errCreate := service.Record(ctx, kbs.AuditRecord{Action: kbs.CreateAction, KBID: "kb-1", After: &before})
+	errUpdate := service.Record(ctx, kbs.AuditRecord{Action: kbs.UpdateAction, KBID: "kb-1", Before: &before, After: &after})
+
+	// Then
+	require.NoError(t, errCreate)
+	require.NoError(t, errUpdate)
+	require.Len(t, store.entries, 2)
+	assert.Equal(t, int64(1), store.entries[0].Sequence)
+	assert.Empty(t, store.entries[0].PreviousHash)
+	assert.Empty(t, store.entries[0].BeforeDigest)
+	assert.Equal(t, int64(2), store.entries[1].Sequence)
+	assert.Equal(t, store.entries[0].Hash, store.entries[1].PreviousHash)
+	assert.Equal(t, store.entries[0].AfterDigest, store.entries[1].BeforeDigest)
+	assert.Equal(t, kbs.UserID("mono"), store.entries[1].ActorID)
+	assert.Equal(t, kbs.EventID("ev-1"), store.entries[1].EventID)
+	assert.Equal(t, "req-1", store.entries[1].RequestID)
+	assert.Equal(t, "10.0.0.1", store.entries[1].ClientIP)
+
+	report, err := service.Verify(ctx)
+	assert.NoError(t, err)
+	assert.True(t, report.Valid)
+	assert.Equal(t, 2, report.EntriesChecked)
+}
+
+func TestRecordAfterOtherInstanceAppended(t *testing.T) {
+	// Given
+	ctx := context.TODO()
+	store := newMemoryStore()
+	first := audit.NewService(audit.ServiceSetup{Storer: store, Logger: newDummyLogger()})
+	second := audit.NewService(audit.ServiceSetup{Storer: store, Logger: newDummyLogger()})
+	kb := kbs.KB{ID: "kb-1", UserID: "drila"}
+
+	require.NoError(t, second.Record(ctx, kbs.AuditRecord{Action: kbs.CreateAction, KBID: "kb-1", After: &kb}))
+	require.NoError(t, first.Record(ctx, kbs.AuditRecord{Action: kbs.UpdateAction, KBID: "kb-1", Before: &kb, After: &kb}))
+
+	// When
+	err := second.Record(ctx, kbs.AuditRecord{Action: kbs.DeleteAction, KBID: "kb-1", Before: &kb})
+
+	// Then
+	assert.NoError(t, err)
+	require.Len(t, store.entries, 3)
+	assert.Equal(t, int64(3), store.entries[2].Sequence)
+	assert.Equal(t, audit.AnonymousActor, store.entries[2].ActorID)
+
+	report, err := first.Verify(ctx)
+	assert.NoError(t, err)
+	assert.True(t, report.Valid)
+}
+
+func TestVerifyDetectsTampering(t *testing.T) {
+	cases := map[string]struct {
+		tamper func(entries []audit.Entry) []audit.Entry
+		want   int64
+	}{
+		"changed_entry": {
+			tamper: func(entries []audit.Entry) []audit.Entry {
+				entries[1].ActorID = "intruder"
+				return entries
+			},
+			want: 2,
+		},
+		"removed_entry": {
+			tamper: func(entries []audit.Entry) []audit.Entry {
+				return append(entries[:1], entries[2:]...)
+			},
+			want: 3,
+		},
+		"rehashed_entry": {
+			tamper: func(entries []audit.Entry) []audit.Entry {
+				entries[0].Hash = "0000"
+				return entries
+			},
+			want: 1,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			// Given
+			ctx := context.TODO()
+			store := newMemoryStore()
+			service := audit.NewService(audit.ServiceSetup{Storer: store, Logger: newDummyLogger()})
+			kb := kbs.KB{ID: "kb-1", UserID: "drila"}
+
+			for i := 0; i < 3; i++ {
+				require.NoError(t, service.Record(ctx, kbs.AuditRecord{Action: kbs.UpdateAction, KBID: "kb-1", Before: &kb, After: &kb}))
+			}
+
+			store.entries = tc.tamper(store.entries)
+
+			// When
+			report, err := service.Verify(ctx)
+
+			// Then
+			assert.NoError(t, err)
+			assert.False(t, report.Valid)
+			assert.Equal(t, tc.want, report.BrokenSequence)
+			assert.NotEmpty(t, report.Reason)
+		})
+	}
+}
+
+func TestQueryPagesEntries(t *testing.T) {
+	// Given
+	ctx := context.TODO()
+	store := newMemoryStore()
+	service := audit.NewService(audit.ServiceSetup{Storer: store, Logger: newDummyLogger()})
+	for _, id := range []kbs.KBID{"kb-1", "kb-2", "kb-1", "kb-1", "kb-1"} {
+		err := service.Record(ctx, kbs.AuditRecord{Action: kbs.CreateAction, KBID: id, After: &kbs.KB{ID: id}})
+		require.NoError(t, err)
+	}
+
+	// When
+	first, errFirst := service.Query(ctx, audit.QueryFilter{KBID: "kb-1", PageSize: 2})
+	second, errSecond := service.Query(ctx, audit.QueryFilter{KBID: "kb-1", PageSize: 2, After: first.Next})
+
+	// Then
+	require.NoError(t, errFirst)
+	require.NoError(t, errSecond)
+	assert.Equal(t, []int64{1, 3}, sequencesOf(first.Entries))
+	assert.Equal(t, int64(3), first.Next)
+	assert.Equal(t, []int64{4, 5}, sequencesOf(second.Entries))
+	assert.Zero(t, second.Next)
+}
+
+func sequencesOf(entries []audit.Entry) []int64 {
+	var result []int64
+	for _, v := range entries {
+		result = append(result, v.Sequence)
+	}
+	return result
+}
+
+type memoryStore struct {
+	entries []audit.Entry
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{}
+}
+
+func (m *memoryStore) AppendEntry(ctx context.Context, entry audit.Entry) error {
+	for _, v := range m.entries {
+		if v.Sequence == entry.Sequence {
+			return audit.ErrConflict
+		}
+	}
+	m.entries = append(m.entries, entry)
+	return nil
+}
+
+func (m *memoryStore) LastEntry(ctx context.Context) (*audit.Entry, error) {
+	if len(m.entries) == 0 {
+		return nil, nil
+	}
+	last := m.entries[len(m.entries)-1]
+	return &last, nil
+}
+
+func (m *memoryStore) QueryEntries(ctx context.Context, filter audit.QueryFilter) ([]audit.Entry, error) {
+	var result []audit.Entry
+	for _, v := range m.entries {
+		if filter.KBID != "" && v.KBID != filter.KBID {
+			continue
+		}
+		if v.Sequence <= filter.After {
+			continue
+		}
+		if filter.PageSize > 0 && len(result) == filter.PageSize {
+			break
+		}
+		result = append(result, v)
+	}
+	return result, nil
+}
+
+func (m *memoryStore) ScanEntries(ctx context.Context, fn func(entry audit.Entry) error) error {
+	for _, v := range m.entries {
+		err := fn(v)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func newDummyLogger() *slog.Logger {
+	return slog.New(slog.NewJSONHandler(os.Stdout, nil))
+}
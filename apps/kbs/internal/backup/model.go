@@ -0,0 +1,135 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/kbs"
+)
+
+// Snapshotter reads every kb of a store. The view is only point-in-time
+// if the store reads it in a transaction, otherwise kbs written while it
+// is read may or may not be in it.
+type Snapshotter interface {
+	// SnapshotKBs calls fn for each kb in the store until all kbs were
+	// read or fn returns an error.
+	SnapshotKBs(ctx context.Context, fn func(kb kbs.KB) error) error
+}
+
+// Store is the kbs store that is backed up and restored.
+type Store interface {
+	Snapshotter
+	// Save replaces the kb with the same id if there is one.
+	Save(ctx context.Context, newKB kbs.KB) error
+	Delete(ctx context.Context, kb kbs.KB) error
+	// QueryByID returns a nil kb if it does not exist.
+	QueryByID(ctx context.Context, id kbs.KBID) (*kbs.KB, error)
+}
+
+// BlobStore keeps the snapshots.
+type BlobStore interface {
+	PutBlob(ctx context.Context, key string, data []byte) error
+	// GetBlob returns an error if the blob does not exist.
+	GetBlob(ctx context.Context, key string) ([]byte, error)
+	// DeleteBlob does nothing if the blob does not exist.
+	DeleteBlob(ctx context.Context, key string) error
+}
+
+// Manifest describes a snapshot, it is stored next to its parts and in the
+// catalog of the snapshots.
+type Manifest struct {
+	ID string `json:"id"`
+	// StartedAt and FinishedAt are the unix dates the snapshot was taken
+	// between.
+	StartedAt  int64 `json:"started_at"`
+	FinishedAt int64 `json:"finished_at"`
+	// ScanStartedAt and ScanFinishedAt are the unix dates the kbs were
+	// read from the store between. The snapshot contains every kb written
+	// before the scan started, kbs written during the scan may or may not
+	// be in it unless the store reads them in a transaction, e.g. the
+	// dynamodb scan is not point-in-time.
+	ScanStartedAt  int64  `json:"scan_started_at"`
+	ScanFinishedAt int64  `json:"scan_finished_at"`
+	KBs            int    `json:"kbs"`
+	Parts          []Part `json:"parts"`
+}
+
+// Part is a blob of a snapshot, it contains gzipped ndjson kb records.
+type Part struct {
+	Key string `json:"key"`
+	KBs int    `json:"kbs"`
+	// Size and SHA256 of the blob, restores check them.
+	Size   int    `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// Retention defines the snapshots that are kept, a snapshot is kept if any
+// of the rules keeps it. The newest snapshot is always kept.
+type Retention struct {
+	// KeepLast number of newest snapshots that are kept.
+	KeepLast int
+	// KeepFor how long snapshots are kept.
+	KeepFor time.Duration
+}
+
+// BackupReport contains the result of a backup.
+type BackupReport struct {
+	Snapshot Manifest `json:"snapshot"`
+	// Expired are the ids of the snapshots removed by the retention.
+	Expired []string `json:"expired,omitempty"`
+}
+
+// RestoreRequest contains what is restored.
+type RestoreRequest struct {
+	// At is a unix date, the last snapshot started at or before it is
+	// restored.
+	At int64
+	// KBID restores only this kb, optional.
+	KBID kbs.KBID
+	// Prune deletes the kbs that are not in the snapshot, it is ignored
+	// when a single kb is restored.
+	Prune bool
+}
+
+// RestoreReport contains the result of a restore.
+type RestoreReport struct {
+	Snapshot string `json:"snapshot"`
+	KBsRead  int    `json:"kbs_read"`
+	Restored int    `json:"restored"`
+	// Unchanged kbs are the same in the store and in the snapshot.
+	Unchanged int       `json:"unchanged"`
+	Deleted   int       `json:"deleted"`
+	Failed    int       `json:"failed"`
+	Errors    []KBError `json:"errors,omitempty"`
+}
+
+// KBError is the reason a kb was not restored.
+type KBError struct {
+	ID    kbs.KBID `json:"id"`
+	Error string   `json:"error"`
+}
+
+// catalog lists the snapshots, oldest first.
+type catalog struct {
+	Snapshots []Manifest `json:"snapshots"`
+}
+
+var (
+	// ErrNoSnapshot is returned if no snapshot was started at or before
+	// the date of a restore.
+	ErrNoSnapshot = errors.New("there is no snapshot at that date")
+	// ErrKBNotInSnapshot is returned if the kb to restore is not in the
+	// snapshot.
+	ErrKBNotInSnapshot = errors.New("kb is not in the snapshot")
+	errCorruptPart     = errors.New("snapshot part is corrupt")
+)
+
+// fail counts a kb that was not restored and keeps its error.
+func (r *RestoreReport) fail(id kbs.KBID, err error) {
+	r.Failed++
+
+	if len(r.Errors) < maxKBErrors {
+		r.Errors = append(r.Errors, KBError{ID: id, Error: err.Error()})
+	}
+}
@@ -0,0 +1,88 @@
+package backup
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/transfer"
+)
+
+// partWriter builds the blob of a part in memory.
+type partWriter struct {
+	buffer  bytes.Buffer
+	gzip    *gzip.Writer
+	encoder *json.Encoder
+	kbs     int
+}
+
+func newPartWriter() *partWriter {
+	var part partWriter
+
+	part.gzip = gzip.NewWriter(&part.buffer)
+	part.encoder = json.NewEncoder(part.gzip)
+
+	return &part
+}
+
+func (p *partWriter) write(record transfer.Record) error {
+	p.kbs++
+
+	return p.encoder.Encode(record)
+}
+
+// close finishes the blob, it returns its data and its description.
+func (p *partWriter) close(key string) ([]byte, Part, error) {
+	err := p.gzip.Close()
+	if err != nil {
+		return nil, Part{}, err
+	}
+
+	data := p.buffer.Bytes()
+	sum := sha256.Sum256(data)
+
+	part := Part{
+		Key:    key,
+		KBs:    p.kbs,
+		Size:   len(data),
+		SHA256: hex.EncodeToString(sum[:]),
+	}
+
+	return data, part, nil
+}
+
+// readPart checks the blob of a part and calls fn for each of its records.
+func readPart(part Part, data []byte, fn func(record transfer.Record) error) error {
+	sum := sha256.Sum256(data)
+	if len(data) != part.Size || hex.EncodeToString(sum[:]) != part.SHA256 {
+		return fmt.Errorf("%w: %s", errCorruptPart, part.Key)
+	}
+
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+
+	defer reader.Close()
+
+	decoder := json.NewDecoder(reader)
+
+	for decoder.More() {
+		var record transfer.Record
+
+		err = decoder.Decode(&record)
+		if err != nil {
+			return fmt.Errorf("%w: %s: %w", errCorruptPart, part.Key, err)
+		}
+
+		err = fn(record)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
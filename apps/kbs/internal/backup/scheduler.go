@@ -0,0 +1,83 @@
+package backup
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// SchedulerSetup contains scheduler settings.
+type SchedulerSetup struct {
+	Service *Service
+	// Interval between backups, the first one is taken after it.
+	Interval time.Duration
+	Logger   *slog.Logger
+}
+
+// Scheduler takes backups periodically in background.
+type Scheduler struct {
+	service  *Service
+	interval time.Duration
+	logger   *slog.Logger
+	// ctx is cancelled if a backup doesn't finish before shutdown.
+	ctx      context.Context
+	cancel   context.CancelFunc
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+// NewScheduler creates a scheduler and starts taking backups.
+func NewScheduler(setup SchedulerSetup) *Scheduler {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	newScheduler := Scheduler{
+		service:  setup.Service,
+		interval: setup.Interval,
+		logger:   setup.Logger,
+		ctx:      ctx,
+		cancel:   cancel,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+
+	go newScheduler.run()
+
+	return &newScheduler
+}
+
+// Shutdown stops taking backups and waits until the running one finishes
+// or the context is done, in that case the running backup is cancelled.
+func (s *Scheduler) Shutdown(ctx context.Context) error {
+	s.stopOnce.Do(func() { close(s.stop) })
+
+	select {
+	case <-s.done:
+		s.cancel()
+
+		return nil
+	case <-ctx.Done():
+		s.logger.Warn("kbs backup was cancelled by the shutdown")
+		s.cancel()
+
+		return ctx.Err()
+	}
+}
+
+func (s *Scheduler) run() {
+	defer close(s.done)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			// the service logs the failures, the next tick tries again.
+			_, _ = s.service.Backup(s.ctx)
+		case <-s.stop:
+			return
+		}
+	}
+}
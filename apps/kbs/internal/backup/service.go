@@ -0,0 +1,579 @@
+// Package backup takes snapshots of the kbs, keeps them in the blob store
+// and restores the kbs of a snapshot.
+package backup
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"path"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/kbs"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/requests"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/transfer"
+)
+
+// ServiceSetup contains service metadata.
+type ServiceSetup struct {
+	Store Store
+	Blobs BlobStore
+	// Auditor records the kbs a restore writes or deletes, optional.
+	Auditor kbs.Auditor
+	// Prefix of the keys of the blobs of the snapshots.
+	Prefix    string
+	Retention Retention
+	// PartSize number of kbs of each blob of a snapshot.
+	PartSize int
+	Logger   *slog.Logger
+	// Clock returns the current time, time.Now is used if it is nil.
+	Clock func() time.Time
+}
+
+// Service takes and restores snapshots of the kbs.
+type Service struct {
+	store     Store
+	blobs     BlobStore
+	auditor   kbs.Auditor
+	prefix    string
+	retention Retention
+	partSize  int
+	logger    *slog.Logger
+	clock     func() time.Time
+	// mu serializes the backups, each one rewrites the catalog.
+	mu sync.Mutex
+}
+
+const (
+	defaultPartSize = 1000
+	// maxKBErrors is the number of kb errors a restore report keeps, the
+	// rest are only counted.
+	maxKBErrors      = 100
+	catalogName      = "catalog.json"
+	manifestName     = "manifest.json"
+	snapshotIDLayout = "20060102T150405Z"
+)
+
+var (
+	errBackup        = errors.New("unable to back up kbs")
+	errRestore       = errors.New("unable to restore kbs")
+	errListSnapshots = errors.New("unable to list snapshots")
+	// errFound stops reading a snapshot once the kb to restore was found.
+	errFound = errors.New("kb was found")
+)
+
+// NewService create a new backup service.
+func NewService(settings ServiceSetup) *Service {
+	newService := Service{
+		store:     settings.Store,
+		blobs:     settings.Blobs,
+		auditor:   settings.Auditor,
+		prefix:    settings.Prefix,
+		retention: settings.Retention,
+		partSize:  settings.PartSize,
+		logger:    settings.Logger,
+		clock:     settings.Clock,
+	}
+
+	if newService.partSize <= 0 {
+		newService.partSize = defaultPartSize
+	}
+
+	if newService.clock == nil {
+		newService.clock = time.Now
+	}
+
+	return &newService
+}
+
+// Backup takes a snapshot of every kb, stores it with its manifest and adds
+// it to the catalog. Snapshots the retention doesn't keep anymore are
+// removed. Blobs of dry run backups are not stored.
+func (s *Service) Backup(ctx context.Context) (BackupReport, error) {
+	logger := requests.Logger(ctx, s.logger)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var report BackupReport
+
+	manifest, err := s.takeSnapshot(ctx)
+
+	var expired []Manifest
+	if err == nil {
+		expired, err = s.addToCatalog(ctx, manifest)
+	}
+
+	if err != nil {
+		logger.Error("unable to back up kbs",
+			slog.String("snapshot", manifest.ID),
+			slog.String("error", err.Error()))
+
+		s.deleteSnapshot(ctx, manifest)
+
+		return report, errBackup
+	}
+
+	report.Snapshot = manifest
+
+	// the catalog doesn't list them anymore, so they are removed last.
+	for _, snapshot := range expired {
+		s.deleteSnapshot(ctx, snapshot)
+
+		report.Expired = append(report.Expired, snapshot.ID)
+	}
+
+	logger.Info("kbs backup finished",
+		slog.String("snapshot", manifest.ID),
+		slog.Int("kbs", manifest.KBs),
+		slog.Int("parts", len(manifest.Parts)),
+		slog.Int("expired", len(report.Expired)))
+
+	return report, nil
+}
+
+// Snapshots returns the snapshots of the catalog, oldest first.
+func (s *Service) Snapshots(ctx context.Context) ([]Manifest, error) {
+	current, err := s.loadCatalog(ctx)
+	if err != nil {
+		requests.Logger(ctx, s.logger).Error("unable to load snapshot catalog", slog.String("error", err.Error()))
+
+		return nil, errListSnapshots
+	}
+
+	return current.Snapshots, nil
+}
+
+// Restore writes the kbs of the last snapshot started at or before the
+// date of the request back to the store, kbs that are the same are not
+// written. Every kb written or pruned is audited first. KBs that can't be
+// restored are reported and the restore goes on.
+func (s *Service) Restore(ctx context.Context, request RestoreRequest) (RestoreReport, error) {
+	logger := requests.Logger(ctx, s.logger)
+
+	var report RestoreReport
+
+	snapshot, err := s.snapshotAt(ctx, request.At)
+	if err != nil {
+		if errors.Is(err, ErrNoSnapshot) {
+			return report, err
+		}
+
+		logger.Error("unable to find snapshot to restore", slog.String("error", err.Error()))
+
+		return report, errRestore
+	}
+
+	report.Snapshot = snapshot.ID
+
+	if request.KBID != "" {
+		err = s.restoreKB(ctx, snapshot, request.KBID, &report)
+	} else {
+		err = s.restoreAll(ctx, snapshot, request.Prune, &report)
+	}
+
+	if err != nil {
+		if errors.Is(err, ErrKBNotInSnapshot) {
+			return report, err
+		}
+
+		logger.Error("unable to restore kbs",
+			slog.String("snapshot", snapshot.ID),
+			slog.Any("report", report),
+			slog.String("error", err.Error()))
+
+		return report, errRestore
+	}
+
+	logger.Info("kbs restore finished",
+		slog.String("snapshot", snapshot.ID),
+		slog.Int("read", report.KBsRead),
+		slog.Int("restored", report.Restored),
+		slog.Int("unchanged", report.Unchanged),
+		slog.Int("deleted", report.Deleted),
+		slog.Int("failed", report.Failed))
+
+	return report, nil
+}
+
+// takeSnapshot writes the parts and the manifest of a new snapshot. The
+// manifest contains the parts written so far even if it fails, so they can
+// be removed.
+func (s *Service) takeSnapshot(ctx context.Context) (Manifest, error) {
+	started := s.clock()
+
+	suffix := make([]byte, 4)
+
+	_, err := rand.Read(suffix)
+	if err != nil {
+		return Manifest{}, err
+	}
+
+	manifest := Manifest{
+		ID:        started.UTC().Format(snapshotIDLayout) + "-" + hex.EncodeToString(suffix),
+		StartedAt: started.Unix(),
+	}
+
+	part := newPartWriter()
+
+	manifest.ScanStartedAt = s.clock().Unix()
+
+	err = s.store.SnapshotKBs(ctx, func(kb kbs.KB) error {
+		err := part.write(transfer.NewRecord(kb))
+		if err != nil {
+			return err
+		}
+
+		manifest.KBs++
+
+		if part.kbs < s.partSize {
+			return nil
+		}
+
+		err = s.putPart(ctx, &manifest, part)
+		part = newPartWriter()
+
+		return err
+	})
+	manifest.ScanFinishedAt = s.clock().Unix()
+
+	if err == nil && part.kbs > 0 {
+		err = s.putPart(ctx, &manifest, part)
+	}
+
+	if err != nil {
+		return manifest, err
+	}
+
+	manifest.FinishedAt = s.clock().Unix()
+
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		return manifest, err
+	}
+
+	return manifest, s.putBlob(ctx, s.key(manifest.ID, manifestName), data)
+}
+
+func (s *Service) putPart(ctx context.Context, manifest *Manifest, part *partWriter) error {
+	key := s.key(manifest.ID, fmt.Sprintf("kbs-%05d.ndjson.gz", len(manifest.Parts)+1))
+
+	data, description, err := part.close(key)
+	if err != nil {
+		return err
+	}
+
+	manifest.Parts = append(manifest.Parts, description)
+
+	return s.putBlob(ctx, key, data)
+}
+
+// addToCatalog lists the snapshot in the catalog and returns the snapshots
+// that expired.
+func (s *Service) addToCatalog(ctx context.Context, snapshot Manifest) ([]Manifest, error) {
+	current, err := s.loadCatalog(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	kept, expired := s.retention.apply(append(current.Snapshots, snapshot), s.clock())
+
+	data, err := json.Marshal(catalog{Snapshots: kept})
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.putBlob(ctx, s.key(catalogName), data)
+	if err != nil {
+		return nil, err
+	}
+
+	return expired, nil
+}
+
+// loadCatalog returns an empty catalog if there is none yet.
+func (s *Service) loadCatalog(ctx context.Context) (catalog, error) {
+	var current catalog
+
+	data, err := s.blobs.GetBlob(ctx, s.key(catalogName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return current, nil
+	}
+
+	if err != nil {
+		return current, err
+	}
+
+	err = json.Unmarshal(data, &current)
+	if err != nil {
+		return current, err
+	}
+
+	return current, nil
+}
+
+// snapshotAt returns the last snapshot started at or before the date.
+func (s *Service) snapshotAt(ctx context.Context, at int64) (Manifest, error) {
+	current, err := s.loadCatalog(ctx)
+	if err != nil {
+		return Manifest{}, err
+	}
+
+	for i := len(current.Snapshots) - 1; i >= 0; i-- {
+		if current.Snapshots[i].StartedAt <= at {
+			return current.Snapshots[i], nil
+		}
+	}
+
+	return Manifest{}, ErrNoSnapshot
+}
+
+// readSnapshot calls fn for each record of the snapshot.
+func (s *Service) readSnapshot(ctx context.Context, snapshot Manifest, fn func(record transfer.Record) error) error {
+	for _, part := range snapshot.Parts {
+		data, err := s.blobs.GetBlob(ctx, part.Key)
+		if err != nil {
+			return err
+		}
+
+		err = readPart(part, data, fn)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Service) restoreKB(ctx context.Context, snapshot Manifest, id kbs.KBID, report *RestoreReport) error {
+	err := s.readSnapshot(ctx, snapshot, func(record transfer.Record) error {
+		report.KBsRead++
+
+		if record.ID != id {
+			return nil
+		}
+
+		restored, err := s.restoreRecord(ctx, record)
+		report.add(record.ID, restored, err)
+
+		return errFound
+	})
+
+	switch {
+	case errors.Is(err, errFound):
+		return nil
+	case err != nil:
+		return err
+	default:
+		return ErrKBNotInSnapshot
+	}
+}
+
+// restoreAll restores every kb of the snapshot, prune deletes the kbs
+// created after it. The kbs to delete are collected first, so the store is
+// not changed while it is read.
+func (s *Service) restoreAll(ctx context.Context, snapshot Manifest, prune bool, report *RestoreReport) error {
+	inSnapshot := make(map[kbs.KBID]bool, snapshot.KBs)
+
+	err := s.readSnapshot(ctx, snapshot, func(record transfer.Record) error {
+		report.KBsRead++
+		inSnapshot[record.ID] = true
+
+		restored, err := s.restoreRecord(ctx, record)
+		report.add(record.ID, restored, err)
+
+		return nil
+	})
+	if err != nil || !prune {
+		return err
+	}
+
+	var created []kbs.KB
+
+	err = s.store.SnapshotKBs(ctx, func(kb kbs.KB) error {
+		if !inSnapshot[kb.ID] {
+			created = append(created, kb)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, kb := range created {
+		kb := kb
+
+		err = s.audit(ctx, kbs.AuditRecord{Action: kbs.DeleteAction, KBID: kb.ID, Before: &kb})
+		if err == nil {
+			err = s.store.Delete(ctx, kb)
+		}
+
+		if err != nil {
+			report.fail(kb.ID, err)
+
+			continue
+		}
+
+		report.Deleted++
+	}
+
+	return nil
+}
+
+// restoreRecord writes the kb of the record unless the store has the same
+// kb, it returns false if it was not written. The stored kb is overwritten
+// in a single write, so it is never missing if the restore fails.
+func (s *Service) restoreRecord(ctx context.Context, record transfer.Record) (bool, error) {
+	current, err := s.store.QueryByID(ctx, record.ID)
+	if err != nil {
+		return false, err
+	}
+
+	if current != nil && sameRecord(transfer.NewRecord(*current), record) {
+		return false, nil
+	}
+
+	restored := record.ToKB()
+
+	change := kbs.AuditRecord{Action: kbs.UpdateAction, KBID: restored.ID, Before: current, After: &restored}
+	if current == nil {
+		change.Action = kbs.CreateAction
+	}
+
+	err = s.audit(ctx, change)
+	if err != nil {
+		return false, err
+	}
+
+	err = s.store.Save(ctx, restored)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// audit records the change before it is written if an auditor was given,
+// the change is not written if it fails.
+func (s *Service) audit(ctx context.Context, change kbs.AuditRecord) error {
+	if s.auditor == nil {
+		return nil
+	}
+
+	return s.auditor.Record(ctx, change)
+}
+
+// deleteSnapshot removes the blobs of the snapshot, blobs that can't be
+// removed are only logged.
+func (s *Service) deleteSnapshot(ctx context.Context, snapshot Manifest) {
+	keys := []string{s.key(snapshot.ID, manifestName)}
+	for _, part := range snapshot.Parts {
+		keys = append(keys, part.Key)
+	}
+
+	for _, key := range keys {
+		err := s.deleteBlob(ctx, key)
+		if err != nil {
+			requests.Logger(ctx, s.logger).Warn("unable to delete snapshot blob",
+				slog.String("snapshot", snapshot.ID),
+				slog.String("key", key),
+				slog.String("error", err.Error()))
+		}
+	}
+}
+
+// putBlob stores the blob unless the request is a dry run, in that case it
+// is added to the plan of the request.
+func (s *Service) putBlob(ctx context.Context, key string, data []byte) error {
+	if metadata := requests.FromContext(ctx); metadata.DryRun {
+		metadata.Plan.Add("put_backup_blob", key)
+
+		return nil
+	}
+
+	return s.blobs.PutBlob(ctx, key, data)
+}
+
+func (s *Service) deleteBlob(ctx context.Context, key string) error {
+	if metadata := requests.FromContext(ctx); metadata.DryRun {
+		metadata.Plan.Add("delete_backup_blob", key)
+
+		return nil
+	}
+
+	return s.blobs.DeleteBlob(ctx, key)
+}
+
+// key returns the key of the blob with the given path under the prefix.
+func (s *Service) key(elements ...string) string {
+	return s.prefix + path.Join(elements...)
+}
+
+// apply splits the snapshots, oldest first, into the ones that are kept
+// and the ones that expired.
+func (r Retention) apply(snapshots []Manifest, now time.Time) ([]Manifest, []Manifest) {
+	sort.SliceStable(snapshots, func(i, j int) bool { return snapshots[i].StartedAt < snapshots[j].StartedAt })
+
+	var kept, expired []Manifest
+
+	for i, snapshot := range snapshots {
+		newer := len(snapshots) - 1 - i
+		age := now.Sub(time.Unix(snapshot.StartedAt, 0))
+
+		if newer == 0 || newer < r.KeepLast || (r.KeepFor > 0 && age <= r.KeepFor) {
+			kept = append(kept, snapshot)
+
+			continue
+		}
+
+		expired = append(expired, snapshot)
+	}
+
+	return kept, expired
+}
+
+// sameRecord returns true if both records have the same data, stores differ
+// in how they keep empty reviews.
+func sameRecord(a, b transfer.Record) bool {
+	if len(a.Reviews) == 0 {
+		a.Reviews = nil
+	}
+
+	if len(b.Reviews) == 0 {
+		b.Reviews = nil
+	}
+
+	if a.UpdateDate == 0 {
+		a.UpdateDate = a.CreationDate
+	}
+
+	if b.UpdateDate == 0 {
+		b.UpdateDate = b.CreationDate
+	}
+
+	first, errFirst := json.Marshal(a)
+	second, errSecond := json.Marshal(b)
+
+	return errFirst == nil && errSecond == nil && bytes.Equal(first, second)
+}
+
+// add counts the result of the restore of a kb.
+func (r *RestoreReport) add(id kbs.KBID, restored bool, err error) {
+	switch {
+	case err != nil:
+		r.fail(id, err)
+	case restored:
+		r.Restored++
+	default:
+		r.Unchanged++
+	}
+}
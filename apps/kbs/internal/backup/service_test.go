@@ -0,0 +1,417 @@
+package backup_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/backup"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/kbs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackupAndRestoreKBs(t *testing.T) {
+	// Given
+	ctx := context.TODO()
+	clock := newClock()
+	store := newMemoryStore(newKBs(5)...)
+	original := store.all()
+	blobs := newMemoryBlobs()
+	service := newService(store, blobs, clock, backup.Retention{KeepLast: 7})
+
+	backupReport, err := service.Backup(ctx)
+	require.NoError(t, err)
+
+	changed := store.kbs["kb-1"]
+	changed.Content = "vandalized"
+	store.kbs["kb-1"] = changed
+	delete(store.kbs, "kb-2")
+	store.kbs["kb-6"] = kbs.KB{ID: "kb-6", UserID: "user-1", EventID: "event-1", CreationDate: 1700000600}
+
+	// When
+	restoreReport, err := service.Restore(ctx, backup.RestoreRequest{At: clock.now().Unix(), Prune: true})
+
+	// Then
+	require.NoError(t, err)
+	assert.Equal(t, 5, backupReport.Snapshot.KBs)
+	assert.Len(t, backupReport.Snapshot.Parts, 3)
+	assert.Contains(t, blobs.blobs, "backups/"+backupReport.Snapshot.ID+"/manifest.json")
+	assert.Equal(t, backup.RestoreReport{
+		Snapshot:  backupReport.Snapshot.ID,
+		KBsRead:   5,
+		Restored:  2,
+		Unchanged: 3,
+		Deleted:   1,
+	}, restoreReport)
+	assert.Equal(t, original, store.all())
+}
+
+func TestRestoreOverwritesAndAuditsKBs(t *testing.T) {
+	// Given
+	ctx := context.TODO()
+	clock := newClock()
+	store := newMemoryStore(newKBs(3)...)
+	auditor := new(memoryAuditor)
+	service := backup.NewService(backup.ServiceSetup{
+		Store:   store,
+		Blobs:   newMemoryBlobs(),
+		Auditor: auditor,
+		Logger:  newDummyLogger(),
+		Clock:   clock.now,
+	})
+
+	backupReport, err := service.Backup(ctx)
+	require.NoError(t, err)
+
+	setContent(store, "kb-1", "vandalized")
+	delete(store.kbs, "kb-2")
+	store.kbs["kb-4"] = kbs.KB{ID: "kb-4", UserID: "user-1", EventID: "event-1", CreationDate: 1700000600}
+
+	// When
+	report, err := service.Restore(ctx, backup.RestoreRequest{At: clock.now().Unix(), Prune: true})
+
+	// Then
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.Restored)
+	assert.Equal(t, 1, report.Deleted)
+	assert.Equal(t, []kbs.KBID{"kb-4"}, store.deleted)
+	assert.Equal(t, "runbook 1", store.kbs["kb-1"].Content)
+	assert.Equal(t, []string{"update kb-1", "create kb-2", "delete kb-4"}, auditor.changes())
+	assert.Equal(t, "vandalized", auditor.records[0].Before.Content)
+	assert.Equal(t, clock.now().Unix(), backupReport.Snapshot.ScanStartedAt)
+	assert.Equal(t, clock.now().Unix(), backupReport.Snapshot.ScanFinishedAt)
+}
+
+func TestRestoreSkipsKBsThatAreNotAudited(t *testing.T) {
+	// Given
+	ctx := context.TODO()
+	clock := newClock()
+	store := newMemoryStore(newKBs(2)...)
+	auditor := new(memoryAuditor)
+	service := backup.NewService(backup.ServiceSetup{
+		Store:   store,
+		Blobs:   newMemoryBlobs(),
+		Auditor: auditor,
+		Logger:  newDummyLogger(),
+		Clock:   clock.now,
+	})
+
+	_, err := service.Backup(ctx)
+	require.NoError(t, err)
+
+	setContent(store, "kb-1", "vandalized")
+	auditor.err = errors.New("audit log is down")
+
+	// When
+	report, err := service.Restore(ctx, backup.RestoreRequest{At: clock.now().Unix()})
+
+	// Then
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Failed)
+	assert.Equal(t, "vandalized", store.kbs["kb-1"].Content)
+}
+
+func TestRestoreKBAtDate(t *testing.T) {
+	// Given
+	ctx := context.TODO()
+	clock := newClock()
+	store := newMemoryStore(newKBs(2)...)
+	service := newService(store, newMemoryBlobs(), clock, backup.Retention{KeepLast: 7})
+
+	setContent(store, "kb-1", "first version")
+	first, err := service.Backup(ctx)
+	require.NoError(t, err)
+
+	clock.advance(time.Hour)
+	setContent(store, "kb-1", "second version")
+	_, err = service.Backup(ctx)
+	require.NoError(t, err)
+
+	setContent(store, "kb-1", "third version")
+	setContent(store, "kb-2", "kept version")
+
+	// When
+	report, err := service.Restore(ctx, backup.RestoreRequest{At: first.Snapshot.StartedAt + 60, KBID: "kb-1"})
+
+	// Then
+	require.NoError(t, err)
+	assert.Equal(t, first.Snapshot.ID, report.Snapshot)
+	assert.Equal(t, 1, report.Restored)
+	assert.Equal(t, "first version", store.kbs["kb-1"].Content)
+	assert.Equal(t, "kept version", store.kbs["kb-2"].Content)
+}
+
+func TestRestoreFailsWithoutSnapshotOrKB(t *testing.T) {
+	// Given
+	ctx := context.TODO()
+	clock := newClock()
+	service := newService(newMemoryStore(newKBs(2)...), newMemoryBlobs(), clock, backup.Retention{KeepLast: 7})
+
+	snapshot, err := service.Backup(ctx)
+	require.NoError(t, err)
+
+	// When
+	_, errNoSnapshot := service.Restore(ctx, backup.RestoreRequest{At: snapshot.Snapshot.StartedAt - 1})
+	_, errNoKB := service.Restore(ctx, backup.RestoreRequest{At: snapshot.Snapshot.StartedAt, KBID: "kb-9"})
+
+	// Then
+	assert.ErrorIs(t, errNoSnapshot, backup.ErrNoSnapshot)
+	assert.ErrorIs(t, errNoKB, backup.ErrKBNotInSnapshot)
+}
+
+func TestRestoreRejectsCorruptSnapshots(t *testing.T) {
+	// Given
+	ctx := context.TODO()
+	clock := newClock()
+	blobs := newMemoryBlobs()
+	service := newService(newMemoryStore(newKBs(2)...), blobs, clock, backup.Retention{KeepLast: 7})
+
+	snapshot, err := service.Backup(ctx)
+	require.NoError(t, err)
+
+	part := snapshot.Snapshot.Parts[0].Key
+	blobs.blobs[part] = append(blobs.blobs[part], 0)
+
+	// When
+	_, err = service.Restore(ctx, backup.RestoreRequest{At: snapshot.Snapshot.StartedAt})
+
+	// Then
+	assert.Error(t, err)
+}
+
+func TestRetentionRemovesExpiredSnapshots(t *testing.T) {
+	// Given
+	ctx := context.TODO()
+	clock := newClock()
+	blobs := newMemoryBlobs()
+	service := newService(newMemoryStore(newKBs(1)...), blobs, clock, backup.Retention{KeepLast: 2, KeepFor: 36 * time.Hour})
+
+	var ids []string
+
+	// When
+	for i := 0; i < 4; i++ {
+		report, err := service.Backup(ctx)
+		require.NoError(t, err)
+
+		ids = append(ids, report.Snapshot.ID)
+
+		clock.advance(24 * time.Hour)
+	}
+
+	// Then
+	snapshots, err := service.Snapshots(ctx)
+	require.NoError(t, err)
+	require.Len(t, snapshots, 2)
+	assert.Equal(t, ids[2:], []string{snapshots[0].ID, snapshots[1].ID})
+
+	for _, key := range blobs.keys() {
+		assert.False(t, strings.Contains(key, ids[0]) || strings.Contains(key, ids[1]), key)
+	}
+}
+
+func newService(store *memoryStore, blobs *memoryBlobs, clock *fakeClock, retention backup.Retention) *backup.Service {
+	return backup.NewService(backup.ServiceSetup{
+		Store:     store,
+		Blobs:     blobs,
+		Prefix:    "backups/",
+		Retention: retention,
+		PartSize:  2,
+		Logger:    newDummyLogger(),
+		Clock:     clock.now,
+	})
+}
+
+func newKBs(count int) []kbs.KB {
+	newKBs := make([]kbs.KB, 0, count)
+
+	for i := 1; i <= count; i++ {
+		newKBs = append(newKBs, kbs.KB{
+			ID:           kbs.KBID(fmt.Sprintf("kb-%d", i)),
+			UserID:       "user-1",
+			EventID:      "event-1",
+			CreationDate: 1700000000 + int64(i),
+			UpdateDate:   1700000100 + int64(i),
+			State:        kbs.Published,
+			Reviews:      []kbs.Review{{ReviewerID: "user-2", Decision: kbs.Approved, Date: 1700000050}},
+			Content:      fmt.Sprintf("runbook %d", i),
+		})
+	}
+
+	return newKBs
+}
+
+func setContent(store *memoryStore, id kbs.KBID, content string) {
+	kb := store.kbs[id]
+	kb.Content = content
+	store.kbs[id] = kb
+}
+
+// fakeClock returns a time that only changes when it is advanced.
+type fakeClock struct {
+	current time.Time
+}
+
+func newClock() *fakeClock {
+	return &fakeClock{current: time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)}
+}
+
+func (f *fakeClock) now() time.Time {
+	return f.current
+}
+
+func (f *fakeClock) advance(d time.Duration) {
+	f.current = f.current.Add(d)
+}
+
+// memoryStore keeps the kbs in memory.
+type memoryStore struct {
+	mu  sync.Mutex
+	kbs map[kbs.KBID]kbs.KB
+	// deleted are the ids of the deleted kbs, in order.
+	deleted []kbs.KBID
+}
+
+func newMemoryStore(stored ...kbs.KB) *memoryStore {
+	store := memoryStore{kbs: make(map[kbs.KBID]kbs.KB)}
+
+	for _, kb := range stored {
+		store.kbs[kb.ID] = kb
+	}
+
+	return &store
+}
+
+func (m *memoryStore) Save(ctx context.Context, newKB kbs.KB) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.kbs[newKB.ID] = newKB
+
+	return nil
+}
+
+func (m *memoryStore) Delete(ctx context.Context, kb kbs.KB) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.kbs, kb.ID)
+	m.deleted = append(m.deleted, kb.ID)
+
+	return nil
+}
+
+func (m *memoryStore) QueryByID(ctx context.Context, id kbs.KBID) (*kbs.KB, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	kb, ok := m.kbs[id]
+	if !ok {
+		return nil, nil
+	}
+
+	return &kb, nil
+}
+
+// SnapshotKBs reads the kbs ordered by id.
+func (m *memoryStore) SnapshotKBs(ctx context.Context, fn func(kb kbs.KB) error) error {
+	for _, kb := range m.all() {
+		err := fn(kb)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *memoryStore) all() []kbs.KB {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	all := make([]kbs.KB, 0, len(m.kbs))
+	for _, kb := range m.kbs {
+		all = append(all, kb)
+	}
+
+	sort.Slice(all, func(i, j int) bool { return all[i].ID < all[j].ID })
+
+	return all
+}
+
+// memoryAuditor keeps the audited changes, it fails them all if err is set.
+type memoryAuditor struct {
+	records []kbs.AuditRecord
+	err     error
+}
+
+func (m *memoryAuditor) Record(ctx context.Context, record kbs.AuditRecord) error {
+	if m.err != nil {
+		return m.err
+	}
+
+	m.records = append(m.records, record)
+
+	return nil
+}
+
+// changes returns the action and the kb of each record.
+func (m *memoryAuditor) changes() []string {
+	changes := make([]string, 0, len(m.records))
+	for _, record := range m.records {
+		changes = append(changes, record.Action.String()+" "+record.KBID.String())
+	}
+
+	return changes
+}
+
+// memoryBlobs keeps the blobs in memory.
+type memoryBlobs struct {
+	blobs map[string][]byte
+}
+
+func newMemoryBlobs() *memoryBlobs {
+	return &memoryBlobs{blobs: make(map[string][]byte)}
+}
+
+func (m *memoryBlobs) PutBlob(ctx context.Context, key string, data []byte) error {
+	m.blobs[key] = append([]byte(nil), data...)
+
+	return nil
+}
+
+func (m *memoryBlobs) GetBlob(ctx context.Context, key string) ([]byte, error) {
+	data, ok := m.blobs[key]
+	if !ok {
+		return nil, fmt.Errorf("blob %q: %w", key, fs.ErrNotExist)
+	}
+
+	return data, nil
+}
+
+func (m *memoryBlobs) DeleteBlob(ctx context.Context, key string) error {
+	delete(m.blobs, key)
+
+	return nil
+}
+
+func (m *memoryBlobs) keys() []string {
+	keys := make([]string, 0, len(m.blobs))
+	for key := range m.blobs {
+		keys = append(keys, key)
+	}
+
+	return keys
+}
+
+func newDummyLogger() *slog.Logger {
+	return slog.New(slog.NewJSONHandler(os.Stdout, nil))
+}
@@ -0,0 +1,188 @@
+package comments
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+)
+
+type GetCommentWithIDEndpoint struct {
+	service *Service
+	logger  *slog.Logger
+}
+
+type CreateCommentEndpoint struct {
+	service *Service
+	logger  *slog.Logger
+}
+
+type UpdateCommentEndpoint struct {
+	service *Service
+	logger  *slog.Logger
+}
+
+type DeleteCommentEndpoint struct {
+	service *Service
+	logger  *slog.Logger
+}
+
+type SearchCommentsEndpoint struct {
+	service *Service
+	logger  *slog.Logger
+}
+
+// Endpoints is a wrapper for comments endpoints
+type Endpoints struct {
+	GetCommentWithIDEndpoint *GetCommentWithIDEndpoint
+	CreateCommentEndpoint    *CreateCommentEndpoint
+	UpdateCommentEndpoint    *UpdateCommentEndpoint
+	DeleteCommentEndpoint    *DeleteCommentEndpoint
+	SearchCommentsEndpoint   *SearchCommentsEndpoint
+}
+
+// NewEndpoints Create the endpoints for comments application.
+func NewEndpoints(service *Service, logger *slog.Logger) Endpoints {
+	return Endpoints{
+		CreateCommentEndpoint:    MakeCreateCommentEndpoint(service, logger),
+		UpdateCommentEndpoint:    MakeUpdateCommentEndpoint(service, logger),
+		DeleteCommentEndpoint:    MakeDeleteCommentEndpoint(service, logger),
+		GetCommentWithIDEndpoint: MakeGetCommentWithIDEndpoint(service, logger),
+		SearchCommentsEndpoint:   MakeSearchCommentsEndpoint(service, logger),
+	}
+}
+
+// MakeGetCommentWithIDEndpoint create endpoint for get a comment with ID service.
+func MakeGetCommentWithIDEndpoint(srv *Service, logger *slog.Logger) *GetCommentWithIDEndpoint {
+	return &GetCommentWithIDEndpoint{
+		service: srv,
+		logger:  logger,
+	}
+}
+
+// MakeCreateCommentEndpoint create endpoint for create comment service.
+func MakeCreateCommentEndpoint(srv *Service, logger *slog.Logger) *CreateCommentEndpoint {
+	return &CreateCommentEndpoint{
+		service: srv,
+		logger:  logger,
+	}
+}
+
+// MakeUpdateCommentEndpoint create endpoint for update comment service.
+func MakeUpdateCommentEndpoint(srv *Service, logger *slog.Logger) *UpdateCommentEndpoint {
+	return &UpdateCommentEndpoint{
+		service: srv,
+		logger:  logger,
+	}
+}
+
+// MakeDeleteCommentEndpoint create endpoint for the delete comment service.
+func MakeDeleteCommentEndpoint(srv *Service, logger *slog.Logger) *DeleteCommentEndpoint {
+	return &DeleteCommentEndpoint{
+		service: srv,
+		logger:  logger,
+	}
+}
+
+// MakeSearchCommentsEndpoint comment endpoint to search the comments of a kb.
+func MakeSearchCommentsEndpoint(srv *Service, logger *slog.Logger) *SearchCommentsEndpoint {
+	return &SearchCommentsEndpoint{
+		service: srv,
+		logger:  logger,
+	}
+}
+
+func (g *GetCommentWithIDEndpoint) Do(ctx context.Context, request any) (any, error) {
+	commentID, ok := request.(CommentID)
+	if !ok {
+		g.logger.Error("invalid comment id", slog.String("request", fmt.Sprintf("%T", request)))
+
+		return nil, errors.New("invalid comment id")
+	}
+
+	commentFound, err := g.service.QueryByID(ctx, commentID)
+	if err != nil {
+		g.logger.Error(
+			"something went wrong trying to get a comment with the given id",
+			slog.String("error", err.Error()),
+		)
+	}
+
+	return newGetCommentWithIDResult(commentFound, err), nil
+}
+
+func (c *CreateCommentEndpoint) Do(ctx context.Context, request any) (any, error) {
+	newComment, ok := request.(NewComment)
+	if !ok {
+		c.logger.Error("invalid new comment type", slog.String("request", fmt.Sprintf("%T", request)))
+
+		return nil, errors.New("invalid new comment type")
+	}
+
+	newid, err := c.service.Create(ctx, newComment)
+	if err != nil {
+		c.logger.Error(
+			"something went wrong trying to create a comment",
+			slog.String("error", err.Error()),
+		)
+	}
+
+	return newCreateCommentResult(newid, err), nil
+}
+
+func (u *UpdateCommentEndpoint) Do(ctx context.Context, request any) (any, error) {
+	updateComment, ok := request.(UpdateComment)
+	if !ok {
+		u.logger.Error("invalid update comment type", slog.String("request", fmt.Sprintf("%T", request)))
+
+		return nil, errors.New("invalid update comment type")
+	}
+
+	err := u.service.Update(ctx, updateComment)
+	if err != nil {
+		u.logger.Error(
+			"something went wrong trying to update a comment",
+			slog.String("error", err.Error()),
+		)
+	}
+
+	return newUpdateCommentResult(err), nil
+}
+
+func (d *DeleteCommentEndpoint) Do(ctx context.Context, request any) (any, error) {
+	deleteComment, ok := request.(DeleteComment)
+	if !ok {
+		d.logger.Error("invalid delete comment type", slog.String("received", fmt.Sprintf("%T", request)))
+
+		return nil, errors.New("invalid delete comment type")
+	}
+
+	err := d.service.Delete(ctx, deleteComment)
+	if err != nil {
+		d.logger.Error(
+			"something went wrong trying to delete a comment",
+			slog.String("error", err.Error()),
+		)
+	}
+
+	return newDeleteCommentResult(err), nil
+}
+
+func (s *SearchCommentsEndpoint) Do(ctx context.Context, request any) (any, error) {
+	filter, ok := request.(QueryFilter)
+	if !ok {
+		s.logger.Error("invalid comment filters", slog.String("received", fmt.Sprintf("%T", request)))
+
+		return nil, errors.New("invalid comment filters")
+	}
+
+	result, err := s.service.Query(ctx, filter)
+	if err != nil {
+		s.logger.Error(
+			"something went wrong trying to search comments",
+			slog.String("error", err.Error()),
+		)
+	}
+
+	return newSearchCommentsDataResult(result, err), nil
+}
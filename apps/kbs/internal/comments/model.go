@@ -0,0 +1,328 @@
+package comments
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/kbs"
+	"github.com/google/uuid"
+)
+
+// CommentID defines comment id.
+type CommentID string
+
+// Comment is a message written about a kb. Comments with a parent are
+// replies inside the thread of the parent comment.
+type Comment struct {
+	ID       CommentID    `json:"id"`
+	KBID     kbs.KBID     `json:"kb_id"`
+	ParentID CommentID    `json:"parent_id,omitempty"`
+	AuthorID kbs.UserID   `json:"author_id"`
+	Content  string       `json:"content"`
+	Mentions []kbs.UserID `json:"mentions,omitempty"`
+	// Deleted comments keep their place in the thread without content.
+	Deleted      bool  `json:"deleted"`
+	CreationDate int64 `json:"creation_date"`
+	UpdateDate   int64 `json:"update_date"`
+}
+
+// Thread contains a top level comment and its replies.
+type Thread struct {
+	Comment
+	Replies []Comment `json:"replies"`
+}
+
+// NewComment contains data to request the creation of a new comment.
+type NewComment struct {
+	KBID     kbs.KBID   `json:"kb_id"`
+	ParentID CommentID  `json:"parent_id"`
+	AuthorID kbs.UserID `json:"author_id"`
+	Content  string     `json:"content"`
+}
+
+// UpdateComment contains data to request the update of a comment.
+// The author is the verified principal of the request.
+type UpdateComment struct {
+	ID      CommentID `json:"id"`
+	Content string    `json:"content"`
+}
+
+// DeleteComment contains data to request the deletion of a comment.
+// The author is the verified principal of the request.
+type DeleteComment struct {
+	ID CommentID `json:"id"`
+}
+
+// QueryFilter contains data for comments query filters.
+type QueryFilter struct {
+	KBID        kbs.KBID
+	PageNumber  uint8
+	RowsPerPage uint8
+}
+
+// SearchCommentsResult contains a page of the threads of a kb.
+type SearchCommentsResult struct {
+	Threads     []Thread
+	Total       int
+	Page        uint8
+	RowsPerPage uint8
+}
+
+// ValidationError define comment validation logic.
+type ValidationError struct {
+	Errors []string
+}
+
+// GetCommentWithIDResult standard response for get a comment with an ID.
+type GetCommentWithIDResult struct {
+	Comment *Comment
+	Err     string
+}
+
+// CreateCommentResult standard response for create comment.
+type CreateCommentResult struct {
+	ID  CommentID
+	Err string
+}
+
+// UpdateCommentResult standard response for updating a comment.
+type UpdateCommentResult struct {
+	Err string
+}
+
+// DeleteCommentResult standard response for deleting a comment.
+type DeleteCommentResult struct {
+	Err string
+}
+
+// SearchCommentsDataResult standard response for searching comments.
+type SearchCommentsDataResult struct {
+	SearchResult SearchCommentsResult
+	Err          string
+}
+
+const (
+	// EmptyCommentID is the comment id that empty or nil.
+	EmptyCommentID = CommentID("")
+
+	PageNumberDefault  = uint8(1)
+	RowsPerPageDefault = uint8(10)
+
+	maxContentLength = 5000
+)
+
+// mentionPattern matches user mentions like @drila.
+var mentionPattern = regexp.MustCompile(`(?:^|[^\w@])@([\w.-]*\w)`)
+
+func (e *ValidationError) add(message string) {
+	e.Errors = append(e.Errors, message)
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid comment data: %+v", e.Errors)
+}
+
+func (c CommentID) String() string {
+	return string(c)
+}
+
+func newCommentID() CommentID {
+	return CommentID(uuid.New().String())
+}
+
+// parseMentions returns the users mentioned in the given content without
+// duplicates, in the order they appear.
+func parseMentions(content string) []kbs.UserID {
+	var mentions []kbs.UserID
+
+	seen := make(map[kbs.UserID]bool)
+
+	for _, match := range mentionPattern.FindAllStringSubmatch(content, -1) {
+		userID := kbs.UserID(match[1])
+		if seen[userID] {
+			continue
+		}
+
+		seen[userID] = true
+		mentions = append(mentions, userID)
+	}
+
+	return mentions
+}
+
+func buildNewComment(newComment NewComment, parentID CommentID) Comment {
+	return Comment{
+		ID:           newCommentID(),
+		KBID:         newComment.KBID,
+		ParentID:     parentID,
+		AuthorID:     newComment.AuthorID,
+		Content:      newComment.Content,
+		Mentions:     parseMentions(newComment.Content),
+		CreationDate: time.Now().UTC().Unix(),
+	}
+}
+
+func validNewComment(newComment NewComment) error {
+	err := new(ValidationError)
+
+	if newComment.KBID == kbs.EmptyKBID {
+		err.add("kb id cannot be empty")
+	}
+
+	if newComment.AuthorID == "" {
+		err.add("author id cannot be empty")
+	}
+
+	validContent(err, newComment.Content)
+
+	if len(err.Errors) > 0 {
+		return err
+	}
+
+	return nil
+}
+
+func validCommentToUpdate(comment UpdateComment) error {
+	err := new(ValidationError)
+
+	if comment.ID == EmptyCommentID {
+		err.add("comment id cannot be empty")
+	}
+
+	validContent(err, comment.Content)
+
+	if len(err.Errors) > 0 {
+		return err
+	}
+
+	return nil
+}
+
+func validContent(err *ValidationError, content string) {
+	if content == "" {
+		err.add("content cannot be empty")
+	}
+
+	if len(content) > maxContentLength {
+		err.add(fmt.Sprintf("content cannot be longer than %d characters", maxContentLength))
+	}
+}
+
+func (q *QueryFilter) fillDefaultValues() {
+	if q.PageNumber == 0 {
+		q.PageNumber = PageNumberDefault
+	}
+
+	if q.RowsPerPage == 0 {
+		q.RowsPerPage = RowsPerPageDefault
+	}
+}
+
+// buildThreads groups the given comments of a kb in threads ordered by
+// creation date.
+func buildThreads(commentsFound []Comment) []Thread {
+	sort.SliceStable(commentsFound, func(i, j int) bool {
+		return commentsFound[i].CreationDate < commentsFound[j].CreationDate
+	})
+
+	var threads []Thread
+
+	positions := make(map[CommentID]int)
+
+	for _, comment := range commentsFound {
+		if comment.ParentID == EmptyCommentID {
+			positions[comment.ID] = len(threads)
+			threads = append(threads, Thread{Comment: comment, Replies: []Comment{}})
+		}
+	}
+
+	for _, comment := range commentsFound {
+		if comment.ParentID == EmptyCommentID {
+			continue
+		}
+
+		position, ok := positions[comment.ParentID]
+		if !ok {
+			continue
+		}
+
+		threads[position].Replies = append(threads[position].Replies, comment)
+	}
+
+	return threads
+}
+
+// page returns the threads of the page requested in the filter.
+func page(threads []Thread, filter QueryFilter) []Thread {
+	start := int(filter.PageNumber-1) * int(filter.RowsPerPage)
+	if start >= len(threads) {
+		return []Thread{}
+	}
+
+	end := start + int(filter.RowsPerPage)
+	if end > len(threads) {
+		end = len(threads)
+	}
+
+	return threads[start:end]
+}
+
+// newGetCommentWithIDResult create a new GetCommentWithIDResult
+func newGetCommentWithIDResult(comment *Comment, err error) GetCommentWithIDResult {
+	var errComment string
+	if err != nil {
+		errComment = err.Error()
+	}
+	return GetCommentWithIDResult{
+		Comment: comment,
+		Err:     errComment,
+	}
+}
+
+// newCreateCommentResult create a new CreateCommentResult
+func newCreateCommentResult(id CommentID, err error) CreateCommentResult {
+	var errComment string
+	if err != nil {
+		errComment = err.Error()
+	}
+	return CreateCommentResult{
+		ID:  id,
+		Err: errComment,
+	}
+}
+
+// newUpdateCommentResult create a new UpdateCommentResult
+func newUpdateCommentResult(err error) UpdateCommentResult {
+	var errComment string
+	if err != nil {
+		errComment = err.Error()
+	}
+	return UpdateCommentResult{
+		Err: errComment,
+	}
+}
+
+// newDeleteCommentResult create a new DeleteCommentResult
+func newDeleteCommentResult(err error) DeleteCommentResult {
+	var errComment string
+	if err != nil {
+		errComment = err.Error()
+	}
+	return DeleteCommentResult{
+		Err: errComment,
+	}
+}
+
+// newSearchCommentsDataResult create a new SearchCommentsDataResult
+func newSearchCommentsDataResult(result SearchCommentsResult, err error) SearchCommentsDataResult {
+	var errComment string
+	if err != nil {
+		errComment = err.Error()
+	}
+	return SearchCommentsDataResult{
+		SearchResult: result,
+		Err:          errComment,
+	}
+}
@@ -0,0 +1,331 @@
+package comments
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/kbs"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/requests"
+)
+
+// Storer defines persistence behavior for comments.
+type Storer interface {
+	SaveComment(ctx context.Context, comment Comment) error
+	UpdateComment(ctx context.Context, comment Comment) error
+	DeleteComment(ctx context.Context, id CommentID) error
+	// QueryCommentByID find and return a comment with the given id.
+	// If comment does not exist it returns a nil comment and nil error.
+	QueryCommentByID(ctx context.Context, id CommentID) (*Comment, error)
+	// QueryComments returns every comment of the given kb.
+	QueryComments(ctx context.Context, kbID kbs.KBID) ([]Comment, error)
+	// CountComments returns the number of comments of each given kb.
+	// KBs without comments may not be included in the result.
+	CountComments(ctx context.Context, kbIDs []kbs.KBID) (map[kbs.KBID]int, error)
+}
+
+// KBFinder finds the kbs that are commented.
+type KBFinder interface {
+	// QueryByID find and return a kb with the given id.
+	// If kb does not exist it returns a nil kb and nil error.
+	QueryByID(ctx context.Context, id kbs.KBID) (*kbs.KB, error)
+}
+
+// ServiceSetup contains service metadata.
+type ServiceSetup struct {
+	Storer Storer
+	KBs    KBFinder
+	Logger *slog.Logger
+}
+
+// Service implements comments business logic.
+type Service struct {
+	storer Storer
+	kbs    KBFinder
+	logger *slog.Logger
+}
+
+var (
+	errSaveComment     = errors.New("unable to save comment in the repository")
+	errUpdateComment   = errors.New("unable to update comment in the repository")
+	errDeleteComment   = errors.New("unable to delete comment")
+	errQueryComment    = errors.New("unable to query comment")
+	errQueryComments   = errors.New("unable to query comments")
+	errQueryKB         = errors.New("unable to query the kb of the comment")
+	errCountComments   = errors.New("unable to count comments")
+	errEmptyCommentID  = errors.New("comment id cannot be empty")
+	errEmptyKBID       = errors.New("kb id cannot be empty")
+	errCommentNotFound = errors.New("comment does not exist")
+	errKBNotFound      = errors.New("kb does not exist")
+	errParentNotFound  = errors.New("parent comment does not exist in the kb")
+	errNotAuthor       = errors.New("only the author can change the comment")
+	errUnverifiedActor = errors.New("comments can only be changed by a verified principal")
+	errCommentDeleted  = errors.New("comment was deleted")
+)
+
+// NewService create a new comments service.
+func NewService(settings ServiceSetup) *Service {
+	newService := Service{
+		storer: settings.Storer,
+		kbs:    settings.KBs,
+		logger: settings.Logger,
+	}
+
+	return &newService
+}
+
+// Create create a comment and store it in a database. Replies to a reply
+// are added to the thread of the top level comment.
+func (s *Service) Create(ctx context.Context, newComment NewComment) (CommentID, error) {
+	err := validNewComment(newComment)
+	if err != nil {
+		return EmptyCommentID, fmt.Errorf("unable to create comment: %w", err)
+	}
+
+	err = s.kbExists(ctx, newComment.KBID)
+	if err != nil {
+		return EmptyCommentID, err
+	}
+
+	parentID, err := s.threadOf(ctx, newComment)
+	if err != nil {
+		return EmptyCommentID, err
+	}
+
+	comment := buildNewComment(newComment, parentID)
+
+	err = s.storer.SaveComment(ctx, comment)
+	if err != nil {
+		s.logger.Error("unable to create comment", slog.String("error", err.Error()))
+
+		return EmptyCommentID, errSaveComment
+	}
+
+	s.logger.Debug("comment was created", slog.String("id", comment.ID.String()))
+
+	return comment.ID, nil
+}
+
+// Update changes the content of a comment, only its author can do it.
+func (s *Service) Update(ctx context.Context, updateComment UpdateComment) error {
+	err := validCommentToUpdate(updateComment)
+	if err != nil {
+		return fmt.Errorf("unable to update comment: %w", err)
+	}
+
+	current, err := s.authoredComment(ctx, updateComment.ID)
+	if err != nil {
+		return err
+	}
+
+	if current.Deleted {
+		return errCommentDeleted
+	}
+
+	current.Content = updateComment.Content
+	current.Mentions = parseMentions(updateComment.Content)
+	current.UpdateDate = time.Now().UTC().Unix()
+
+	err = s.storer.UpdateComment(ctx, *current)
+	if err != nil {
+		s.logger.Error("unable to update comment", slog.String("error", err.Error()))
+
+		return errUpdateComment
+	}
+
+	return nil
+}
+
+// Delete removes a comment, only its author can do it. Top level comments
+// with replies are kept without content so the thread is not lost.
+func (s *Service) Delete(ctx context.Context, deleteComment DeleteComment) error {
+	if deleteComment.ID == EmptyCommentID {
+		return errEmptyCommentID
+	}
+
+	current, err := s.authoredComment(ctx, deleteComment.ID)
+	if err != nil {
+		return err
+	}
+
+	hasReplies, err := s.hasReplies(ctx, *current)
+	if err != nil {
+		return errDeleteComment
+	}
+
+	if hasReplies {
+		current.Deleted = true
+		current.Content = ""
+		current.Mentions = nil
+		current.UpdateDate = time.Now().UTC().Unix()
+
+		err = s.storer.UpdateComment(ctx, *current)
+	} else {
+		err = s.storer.DeleteComment(ctx, current.ID)
+	}
+
+	if err != nil {
+		s.logger.Error("unable to delete comment",
+			slog.String("id", current.ID.String()),
+			slog.String("error", err.Error()))
+
+		return errDeleteComment
+	}
+
+	return nil
+}
+
+// QueryByID find a comment with the given id.
+func (s *Service) QueryByID(ctx context.Context, id CommentID) (*Comment, error) {
+	if id == EmptyCommentID {
+		return nil, errEmptyCommentID
+	}
+
+	comment, err := s.storer.QueryCommentByID(ctx, id)
+	if err != nil {
+		s.logger.Error("unable to query comment by id",
+			slog.String("id", id.String()),
+			slog.String("error", err.Error()))
+
+		return nil, errQueryComment
+	}
+
+	return comment, nil
+}
+
+// Query returns a page of the comment threads of a kb.
+func (s *Service) Query(ctx context.Context, filter QueryFilter) (SearchCommentsResult, error) {
+	if filter.KBID == kbs.EmptyKBID {
+		return SearchCommentsResult{}, errEmptyKBID
+	}
+
+	filter.fillDefaultValues()
+
+	commentsFound, err := s.storer.QueryComments(ctx, filter.KBID)
+	if err != nil {
+		s.logger.Error("unable to query comments",
+			slog.String("kb_id", filter.KBID.String()),
+			slog.String("error", err.Error()))
+
+		return SearchCommentsResult{}, errQueryComments
+	}
+
+	threads := buildThreads(commentsFound)
+
+	result := SearchCommentsResult{
+		Threads:     page(threads, filter),
+		Total:       len(threads),
+		Page:        filter.PageNumber,
+		RowsPerPage: filter.RowsPerPage,
+	}
+
+	return result, nil
+}
+
+// CountComments returns the number of comments of each given kb.
+// It implements kbs.CommentCounter.
+func (s *Service) CountComments(ctx context.Context, ids []kbs.KBID) (map[kbs.KBID]int, error) {
+	if len(ids) == 0 {
+		return map[kbs.KBID]int{}, nil
+	}
+
+	counts, err := s.storer.CountComments(ctx, ids)
+	if err != nil {
+		s.logger.Error("unable to count comments", slog.String("error", err.Error()))
+
+		return nil, errCountComments
+	}
+
+	return counts, nil
+}
+
+// threadOf returns the top level comment the new comment replies to, or an
+// empty id if it starts a new thread.
+func (s *Service) threadOf(ctx context.Context, newComment NewComment) (CommentID, error) {
+	if newComment.ParentID == EmptyCommentID {
+		return EmptyCommentID, nil
+	}
+
+	parent, err := s.QueryByID(ctx, newComment.ParentID)
+	if err != nil {
+		return EmptyCommentID, err
+	}
+
+	if parent == nil || parent.KBID != newComment.KBID {
+		return EmptyCommentID, errParentNotFound
+	}
+
+	if parent.ParentID != EmptyCommentID {
+		return parent.ParentID, nil
+	}
+
+	return parent.ID, nil
+}
+
+// kbExists checks that the kb to comment exists.
+func (s *Service) kbExists(ctx context.Context, id kbs.KBID) error {
+	kb, err := s.kbs.QueryByID(ctx, id)
+	if err != nil {
+		s.logger.Error("unable to query the kb of the comment",
+			slog.String("kb_id", id.String()),
+			slog.String("error", err.Error()))
+
+		return errQueryKB
+	}
+
+	if kb == nil {
+		return errKBNotFound
+	}
+
+	return nil
+}
+
+// authoredComment returns the comment with the given id if it was written
+// by the verified principal of the request, the author given in a request
+// can't be trusted.
+func (s *Service) authoredComment(ctx context.Context, id CommentID) (*Comment, error) {
+	authorID := kbs.UserID(requests.FromContext(ctx).Principal)
+	if authorID == "" {
+		return nil, errUnverifiedActor
+	}
+
+	current, err := s.QueryByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if current == nil {
+		return nil, errCommentNotFound
+	}
+
+	if current.AuthorID != authorID {
+		return nil, errNotAuthor
+	}
+
+	return current, nil
+}
+
+func (s *Service) hasReplies(ctx context.Context, comment Comment) (bool, error) {
+	if comment.ParentID != EmptyCommentID {
+		return false, nil
+	}
+
+	commentsFound, err := s.storer.QueryComments(ctx, comment.KBID)
+	if err != nil {
+		s.logger.Error("unable to query comment replies",
+			slog.String("id", comment.ID.String()),
+			slog.String("error", err.Error()))
+
+		return false, err
+	}
+
+	for _, v := range commentsFound {
+		if v.ParentID == comment.ID {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
@@ -0,0 +1,214 @@
+package comments_test
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/comments"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/kbs"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/requests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateReplyToReply(t *testing.T) {
+	// Given
+	ctx := context.TODO()
+	service := comments.NewService(comments.ServiceSetup{
+		Storer: newMemoryStore(),
+		KBs:    knownKBs{"kb-1"},
+		Logger: newDummyLogger(),
+	})
+
+	rootID, err := service.Create(ctx, comments.NewComment{KBID: "kb-1", AuthorID: "drila", Content: "what about @mono and @mono?"})
+	require.NoError(t, err)
+	replyID, err := service.Create(ctx, comments.NewComment{KBID: "kb-1", ParentID: rootID, AuthorID: "mono", Content: "agree"})
+	require.NoError(t, err)
+
+	// When
+	nestedID, err := service.Create(ctx, comments.NewComment{KBID: "kb-1", ParentID: replyID, AuthorID: "drila", Content: "thanks @mono."})
+
+	// Then
+	assert.NoError(t, err)
+	root, err := service.QueryByID(ctx, rootID)
+	require.NoError(t, err)
+	assert.Equal(t, []kbs.UserID{"mono"}, root.Mentions)
+
+	nested, err := service.QueryByID(ctx, nestedID)
+	require.NoError(t, err)
+	assert.Equal(t, rootID, nested.ParentID)
+	assert.Equal(t, []kbs.UserID{"mono"}, nested.Mentions)
+}
+
+func TestQueryThreadsWithPagination(t *testing.T) {
+	// Given
+	ctx := context.TODO()
+	store := newMemoryStore()
+	store.save(comments.Comment{ID: "1", KBID: "kb-1", AuthorID: "drila", Content: "first", CreationDate: 1})
+	store.save(comments.Comment{ID: "2", KBID: "kb-1", AuthorID: "mono", Content: "second", CreationDate: 2})
+	store.save(comments.Comment{ID: "3", KBID: "kb-1", ParentID: "2", AuthorID: "drila", Content: "reply", CreationDate: 3})
+	store.save(comments.Comment{ID: "4", KBID: "kb-2", AuthorID: "drila", Content: "other kb", CreationDate: 4})
+
+	service := comments.NewService(comments.ServiceSetup{
+		Storer: store,
+		Logger: newDummyLogger(),
+	})
+
+	// When
+	got, err := service.Query(ctx, comments.QueryFilter{KBID: "kb-1", PageNumber: 2, RowsPerPage: 1})
+
+	// Then
+	assert.NoError(t, err)
+	assert.Equal(t, 2, got.Total)
+	require.Len(t, got.Threads, 1)
+	assert.Equal(t, comments.CommentID("2"), got.Threads[0].ID)
+	require.Len(t, got.Threads[0].Replies, 1)
+	assert.Equal(t, comments.CommentID("3"), got.Threads[0].Replies[0].ID)
+}
+
+func TestDeleteComment(t *testing.T) {
+	// Given
+	ctx := context.TODO()
+	store := newMemoryStore()
+	store.save(comments.Comment{ID: "1", KBID: "kb-1", AuthorID: "drila", Content: "first", CreationDate: 1})
+	store.save(comments.Comment{ID: "2", KBID: "kb-1", ParentID: "1", AuthorID: "mono", Content: "reply", CreationDate: 2})
+
+	service := comments.NewService(comments.ServiceSetup{
+		Storer: store,
+		Logger: newDummyLogger(),
+	})
+
+	// When
+	errUnverified := service.Delete(ctx, comments.DeleteComment{ID: "1"})
+	errNotAuthor := service.Delete(as(ctx, "mono"), comments.DeleteComment{ID: "1"})
+	errWithReplies := service.Delete(as(ctx, "drila"), comments.DeleteComment{ID: "1"})
+	errReply := service.Delete(as(ctx, "mono"), comments.DeleteComment{ID: "2"})
+
+	// Then
+	assert.Error(t, errUnverified)
+	assert.Error(t, errNotAuthor)
+	assert.NoError(t, errWithReplies)
+	assert.NoError(t, errReply)
+	assert.True(t, store.comments["1"].Deleted)
+	assert.Empty(t, store.comments["1"].Content)
+	assert.NotContains(t, store.comments, comments.CommentID("2"))
+}
+
+func TestCreateCommentOfMissingKB(t *testing.T) {
+	// Given
+	ctx := context.TODO()
+	store := newMemoryStore()
+	service := comments.NewService(comments.ServiceSetup{
+		Storer: store,
+		KBs:    knownKBs{"kb-1"},
+		Logger: newDummyLogger(),
+	})
+
+	// When
+	id, err := service.Create(ctx, comments.NewComment{KBID: "kb-2", AuthorID: "drila", Content: "first"})
+
+	// Then
+	assert.EqualError(t, err, "kb does not exist")
+	assert.Equal(t, comments.EmptyCommentID, id)
+	assert.Empty(t, store.comments)
+}
+
+func TestUpdateCommentAsVerifiedPrincipal(t *testing.T) {
+	// Given
+	ctx := context.TODO()
+	store := newMemoryStore()
+	store.save(comments.Comment{ID: "1", KBID: "kb-1", AuthorID: "drila", Content: "first", CreationDate: 1})
+
+	service := comments.NewService(comments.ServiceSetup{
+		Storer: store,
+		Logger: newDummyLogger(),
+	})
+
+	// When
+	errUnverified := service.Update(ctx, comments.UpdateComment{ID: "1", Content: "by nobody"})
+	errNotAuthor := service.Update(as(ctx, "mono"), comments.UpdateComment{ID: "1", Content: "by mono"})
+	errAuthor := service.Update(as(ctx, "drila"), comments.UpdateComment{ID: "1", Content: "by drila"})
+
+	// Then
+	assert.Error(t, errUnverified)
+	assert.Error(t, errNotAuthor)
+	assert.NoError(t, errAuthor)
+	assert.Equal(t, "by drila", store.comments["1"].Content)
+}
+
+// as returns a context of a request verified as the given principal.
+func as(ctx context.Context, principal string) context.Context {
+	return requests.NewContext(ctx, requests.Metadata{Principal: principal})
+}
+
+type knownKBs []kbs.KBID
+
+func (k knownKBs) QueryByID(ctx context.Context, id kbs.KBID) (*kbs.KB, error) {
+	for _, v := range k {
+		if v == id {
+			return &kbs.KB{ID: id}, nil
+		}
+	}
+	return nil, nil
+}
+
+type memoryStore struct {
+	comments map[comments.CommentID]comments.Comment
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{
+		comments: make(map[comments.CommentID]comments.Comment),
+	}
+}
+
+func (m *memoryStore) save(comment comments.Comment) {
+	m.comments[comment.ID] = comment
+}
+
+func (m *memoryStore) SaveComment(ctx context.Context, comment comments.Comment) error {
+	m.save(comment)
+	return nil
+}
+
+func (m *memoryStore) UpdateComment(ctx context.Context, comment comments.Comment) error {
+	m.save(comment)
+	return nil
+}
+
+func (m *memoryStore) DeleteComment(ctx context.Context, id comments.CommentID) error {
+	delete(m.comments, id)
+	return nil
+}
+
+func (m *memoryStore) QueryCommentByID(ctx context.Context, id comments.CommentID) (*comments.Comment, error) {
+	comment, ok := m.comments[id]
+	if !ok {
+		return nil, nil
+	}
+	return &comment, nil
+}
+
+func (m *memoryStore) QueryComments(ctx context.Context, kbID kbs.KBID) ([]comments.Comment, error) {
+	var result []comments.Comment
+	for _, v := range m.comments {
+		if v.KBID == kbID {
+			result = append(result, v)
+		}
+	}
+	return result, nil
+}
+
+func (m *memoryStore) CountComments(ctx context.Context, kbIDs []kbs.KBID) (map[kbs.KBID]int, error) {
+	result := make(map[kbs.KBID]int)
+	for _, v := range m.comments {
+		result[v.KBID]++
+	}
+	return result, nil
+}
+
+func newDummyLogger() *slog.Logger {
+	return slog.New(slog.NewJSONHandler(os.Stdout, nil))
+}
@@ -0,0 +1,190 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/kbs"
+)
+
+type GetEventWithIDEndpoint struct {
+	service *Service
+	logger  *slog.Logger
+}
+
+type CreateEventEndpoint struct {
+	service *Service
+	logger  *slog.Logger
+}
+
+type UpdateEventEndpoint struct {
+	service *Service
+	logger  *slog.Logger
+}
+
+type DeleteEventEndpoint struct {
+	service *Service
+	logger  *slog.Logger
+}
+
+type SearchEventsEndpoint struct {
+	service *Service
+	logger  *slog.Logger
+}
+
+// Endpoints is a wrapper for events endpoints
+type Endpoints struct {
+	GetEventWithIDEndpoint *GetEventWithIDEndpoint
+	CreateEventEndpoint    *CreateEventEndpoint
+	UpdateEventEndpoint    *UpdateEventEndpoint
+	DeleteEventEndpoint    *DeleteEventEndpoint
+	SearchEventsEndpoint   *SearchEventsEndpoint
+}
+
+// NewEndpoints Create the endpoints for events application.
+func NewEndpoints(service *Service, logger *slog.Logger) Endpoints {
+	return Endpoints{
+		CreateEventEndpoint:    MakeCreateEventEndpoint(service, logger),
+		UpdateEventEndpoint:    MakeUpdateEventEndpoint(service, logger),
+		DeleteEventEndpoint:    MakeDeleteEventEndpoint(service, logger),
+		GetEventWithIDEndpoint: MakeGetEventWithIDEndpoint(service, logger),
+		SearchEventsEndpoint:   MakeSearchEventsEndpoint(service, logger),
+	}
+}
+
+// MakeGetEventWithIDEndpoint create endpoint for get an event with ID service.
+func MakeGetEventWithIDEndpoint(srv *Service, logger *slog.Logger) *GetEventWithIDEndpoint {
+	return &GetEventWithIDEndpoint{
+		service: srv,
+		logger:  logger,
+	}
+}
+
+// MakeCreateEventEndpoint create endpoint for create event service.
+func MakeCreateEventEndpoint(srv *Service, logger *slog.Logger) *CreateEventEndpoint {
+	return &CreateEventEndpoint{
+		service: srv,
+		logger:  logger,
+	}
+}
+
+// MakeUpdateEventEndpoint create endpoint for update event service.
+func MakeUpdateEventEndpoint(srv *Service, logger *slog.Logger) *UpdateEventEndpoint {
+	return &UpdateEventEndpoint{
+		service: srv,
+		logger:  logger,
+	}
+}
+
+// MakeDeleteEventEndpoint create endpoint for the delete event service.
+func MakeDeleteEventEndpoint(srv *Service, logger *slog.Logger) *DeleteEventEndpoint {
+	return &DeleteEventEndpoint{
+		service: srv,
+		logger:  logger,
+	}
+}
+
+// MakeSearchEventsEndpoint event endpoint to search events with filters.
+func MakeSearchEventsEndpoint(srv *Service, logger *slog.Logger) *SearchEventsEndpoint {
+	return &SearchEventsEndpoint{
+		service: srv,
+		logger:  logger,
+	}
+}
+
+func (g *GetEventWithIDEndpoint) Do(ctx context.Context, request any) (any, error) {
+	eventID, ok := request.(kbs.EventID)
+	if !ok {
+		g.logger.Error("invalid event id", slog.String("request", fmt.Sprintf("%T", request)))
+
+		return nil, errors.New("invalid event id")
+	}
+
+	eventFound, err := g.service.QueryByID(ctx, eventID)
+	if err != nil {
+		g.logger.Error(
+			"something went wrong trying to get an event with the given id",
+			slog.String("error", err.Error()),
+		)
+	}
+
+	return newGetEventWithIDResult(eventFound, err), nil
+}
+
+func (c *CreateEventEndpoint) Do(ctx context.Context, request any) (any, error) {
+	newEvent, ok := request.(*NewEvent)
+	if !ok {
+		c.logger.Error("invalid new event type", slog.String("request", fmt.Sprintf("%T", request)))
+
+		return nil, errors.New("invalid new event type")
+	}
+
+	newid, err := c.service.Create(ctx, *newEvent)
+	if err != nil {
+		c.logger.Error(
+			"something went wrong trying to create an event",
+			slog.String("error", err.Error()),
+		)
+	}
+
+	return newCreateEventResult(newid, err), nil
+}
+
+func (u *UpdateEventEndpoint) Do(ctx context.Context, request any) (any, error) {
+	updateEvent, ok := request.(*UpdateEvent)
+	if !ok {
+		u.logger.Error("invalid update event type", slog.String("request", fmt.Sprintf("%T", request)))
+
+		return nil, errors.New("invalid update event type")
+	}
+
+	err := u.service.Update(ctx, *updateEvent)
+	if err != nil {
+		u.logger.Error(
+			"something went wrong trying to update an event",
+			slog.String("error", err.Error()),
+		)
+	}
+
+	return newUpdateEventResult(err), nil
+}
+
+func (d *DeleteEventEndpoint) Do(ctx context.Context, request any) (any, error) {
+	eventID, ok := request.(kbs.EventID)
+	if !ok {
+		d.logger.Error("invalid delete event type", slog.String("received", fmt.Sprintf("%T", request)))
+
+		return nil, errors.New("invalid event id type")
+	}
+
+	err := d.service.Delete(ctx, eventID)
+	if err != nil {
+		d.logger.Error(
+			"something went wrong trying to delete an event",
+			slog.String("error", err.Error()),
+		)
+	}
+
+	return newDeleteEventResult(err), nil
+}
+
+func (s *SearchEventsEndpoint) Do(ctx context.Context, request any) (any, error) {
+	filter, ok := request.(QueryFilter)
+	if !ok {
+		s.logger.Error("invalid event filters", slog.String("received", fmt.Sprintf("%T", request)))
+
+		return nil, errors.New("invalid event filters")
+	}
+
+	eventsFound, err := s.service.Query(ctx, filter)
+	if err != nil {
+		s.logger.Error(
+			"something went wrong trying to search events",
+			slog.String("error", err.Error()),
+		)
+	}
+
+	return newSearchEventsResult(eventsFound, err), nil
+}
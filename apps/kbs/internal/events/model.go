@@ -0,0 +1,273 @@
+package events
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/kbs"
+	"github.com/google/uuid"
+)
+
+// Status defines the state of an event.
+type Status string
+
+// Event describes the event kbs are written for.
+type Event struct {
+	ID           kbs.EventID  `json:"id"`
+	Name         string       `json:"name"`
+	Description  string       `json:"description"`
+	StartDate    int64        `json:"start_date"`
+	EndDate      int64        `json:"end_date"`
+	Status       Status       `json:"status"`
+	Organizers   []kbs.UserID `json:"organizers"`
+	CreationDate int64        `json:"creation_date"`
+	UpdateDate   int64        `json:"update_date"`
+}
+
+// NewEvent contains data to request the creation of a new event.
+type NewEvent struct {
+	Name        string       `json:"name"`
+	Description string       `json:"description"`
+	StartDate   int64        `json:"start_date"`
+	EndDate     int64        `json:"end_date"`
+	Status      Status       `json:"status"`
+	Organizers  []kbs.UserID `json:"organizers"`
+}
+
+// UpdateEvent contains data to request the update of an event.
+type UpdateEvent struct {
+	ID          kbs.EventID  `json:"id"`
+	Name        string       `json:"name"`
+	Description string       `json:"description"`
+	StartDate   int64        `json:"start_date"`
+	EndDate     int64        `json:"end_date"`
+	Status      Status       `json:"status"`
+	Organizers  []kbs.UserID `json:"organizers"`
+}
+
+// QueryFilter contains data for events query filters.
+type QueryFilter struct {
+	Status Status
+}
+
+// ValidationError define event validation logic.
+type ValidationError struct {
+	Errors []string
+}
+
+// GetEventWithIDResult standard response for get an event with an ID.
+type GetEventWithIDResult struct {
+	Event *Event
+	Err   string
+}
+
+// CreateEventResult standard response for create event.
+type CreateEventResult struct {
+	ID  kbs.EventID
+	Err string
+}
+
+// UpdateEventResult standard response for updating an event.
+type UpdateEventResult struct {
+	Err string
+}
+
+// DeleteEventResult standard response for deleting an event.
+type DeleteEventResult struct {
+	Err string
+}
+
+// SearchEventsResult standard response for searching events.
+type SearchEventsResult struct {
+	Events []Event
+	Err    string
+}
+
+// event status possible values
+const (
+	Open      Status = "open"
+	Closed    Status = "closed"
+	Cancelled Status = "cancelled"
+)
+
+const (
+	// EmptyEventID is the event id that empty or nil.
+	EmptyEventID = kbs.EventID("")
+	// EmptyStatus is the status that empty or nil.
+	EmptyStatus = Status("")
+)
+
+func (e *ValidationError) add(message string) {
+	e.Errors = append(e.Errors, message)
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid event data: %+v", e.Errors)
+}
+
+func newEventID() kbs.EventID {
+	return kbs.EventID(uuid.New().String())
+}
+
+func buildNewEvent(newEvent NewEvent) Event {
+	status := newEvent.Status
+	if status == EmptyStatus {
+		status = Open
+	}
+
+	return Event{
+		ID:           newEventID(),
+		Name:         newEvent.Name,
+		Description:  newEvent.Description,
+		StartDate:    newEvent.StartDate,
+		EndDate:      newEvent.EndDate,
+		Status:       status,
+		Organizers:   newEvent.Organizers,
+		CreationDate: time.Now().UTC().Unix(),
+	}
+}
+
+func validNewEvent(newEvent NewEvent) error {
+	err := new(ValidationError)
+
+	validEventData(err, newEvent.Name, newEvent.StartDate, newEvent.EndDate, newEvent.Status)
+
+	if len(err.Errors) > 0 {
+		return err
+	}
+
+	return nil
+}
+
+func validEventToUpdate(event UpdateEvent) error {
+	err := new(ValidationError)
+
+	if event.ID == EmptyEventID {
+		err.add("event id cannot be empty")
+	}
+
+	if event.Status == EmptyStatus {
+		err.add("event status cannot be empty")
+	}
+
+	validEventData(err, event.Name, event.StartDate, event.EndDate, event.Status)
+
+	if len(err.Errors) > 0 {
+		return err
+	}
+
+	return nil
+}
+
+func validEventData(err *ValidationError, name string, startDate, endDate int64, status Status) {
+	if name == "" {
+		err.add("event name cannot be empty")
+	}
+
+	if startDate != 0 && endDate != 0 && endDate < startDate {
+		err.add("event end date cannot be before its start date")
+	}
+
+	if status != EmptyStatus && !status.isValid() {
+		err.add(fmt.Sprintf("event status %q is not valid", status))
+	}
+}
+
+// IsOpen says if kbs can be written for the event. An event is open
+// when its status is open and its end date, if any, has not passed yet.
+func (e Event) IsOpen(now time.Time) bool {
+	if e.Status != Open {
+		return false
+	}
+
+	if e.EndDate != 0 && now.Unix() > e.EndDate {
+		return false
+	}
+
+	return true
+}
+
+func (u *UpdateEvent) toEvent(current Event) Event {
+	return Event{
+		ID:           current.ID,
+		Name:         u.Name,
+		Description:  u.Description,
+		StartDate:    u.StartDate,
+		EndDate:      u.EndDate,
+		Status:       u.Status,
+		Organizers:   u.Organizers,
+		CreationDate: current.CreationDate,
+		UpdateDate:   time.Now().UTC().Unix(),
+	}
+}
+
+func (s Status) isValid() bool {
+	switch s {
+	case Open, Closed, Cancelled:
+		return true
+	}
+
+	return false
+}
+
+func (s Status) String() string {
+	return string(s)
+}
+
+// newGetEventWithIDResult create a new GetEventWithIDResult
+func newGetEventWithIDResult(event *Event, err error) GetEventWithIDResult {
+	var errEvent string
+	if err != nil {
+		errEvent = err.Error()
+	}
+	return GetEventWithIDResult{
+		Event: event,
+		Err:   errEvent,
+	}
+}
+
+// newCreateEventResult create a new CreateEventResult
+func newCreateEventResult(id kbs.EventID, err error) CreateEventResult {
+	var errEvent string
+	if err != nil {
+		errEvent = err.Error()
+	}
+	return CreateEventResult{
+		ID:  id,
+		Err: errEvent,
+	}
+}
+
+// newUpdateEventResult create a new UpdateEventResult
+func newUpdateEventResult(err error) UpdateEventResult {
+	var errEvent string
+	if err != nil {
+		errEvent = err.Error()
+	}
+	return UpdateEventResult{
+		Err: errEvent,
+	}
+}
+
+// newDeleteEventResult create a new DeleteEventResult
+func newDeleteEventResult(err error) DeleteEventResult {
+	var errEvent string
+	if err != nil {
+		errEvent = err.Error()
+	}
+	return DeleteEventResult{
+		Err: errEvent,
+	}
+}
+
+// newSearchEventsResult create a new SearchEventsResult
+func newSearchEventsResult(eventsFound []Event, err error) SearchEventsResult {
+	var errEvent string
+	if err != nil {
+		errEvent = err.Error()
+	}
+	return SearchEventsResult{
+		Events: eventsFound,
+		Err:    errEvent,
+	}
+}
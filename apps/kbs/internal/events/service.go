@@ -0,0 +1,173 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/kbs"
+)
+
+// Storer defines persistence behavior for events.
+type Storer interface {
+	SaveEvent(ctx context.Context, event Event) error
+	UpdateEvent(ctx context.Context, event Event) error
+	DeleteEvent(ctx context.Context, id kbs.EventID) error
+	// QueryEventByID find and return an event with the given id.
+	// If event does not exist it returns a nil event and nil error.
+	QueryEventByID(ctx context.Context, id kbs.EventID) (*Event, error)
+	QueryEvents(ctx context.Context, filter QueryFilter) ([]Event, error)
+}
+
+// ServiceSetup contains service metadata.
+type ServiceSetup struct {
+	Storer Storer
+	Logger *slog.Logger
+}
+
+// Service implements events business logic.
+type Service struct {
+	storer Storer
+	logger *slog.Logger
+}
+
+var (
+	errSaveEvent    = errors.New("unable to save event in the repository")
+	errUpdateEvent  = errors.New("unable to update event in the repository")
+	errDeleteEvent  = errors.New("unable to delete event")
+	errQueryEvent   = errors.New("unable to query event")
+	errQueryEvents  = errors.New("unable to query events")
+	errEmptyEventID = errors.New("event id cannot be empty")
+	// ErrEventNotFound is returned when the given event does not exist.
+	ErrEventNotFound = errors.New("event does not exist")
+	// ErrEventNotOpen is returned when kbs cannot be written for the given event.
+	ErrEventNotOpen = errors.New("event is not open")
+)
+
+// NewService create a new events service.
+func NewService(settings ServiceSetup) *Service {
+	newService := Service{
+		storer: settings.Storer,
+		logger: settings.Logger,
+	}
+
+	return &newService
+}
+
+// Create create an event and store it in a database.
+func (s *Service) Create(ctx context.Context, newEvent NewEvent) (kbs.EventID, error) {
+	err := validNewEvent(newEvent)
+	if err != nil {
+		return EmptyEventID, fmt.Errorf("unable to create event: %w", err)
+	}
+
+	event := buildNewEvent(newEvent)
+
+	err = s.storer.SaveEvent(ctx, event)
+	if err != nil {
+		s.logger.Error("unable to create event", slog.String("error", err.Error()))
+
+		return EmptyEventID, errSaveEvent
+	}
+
+	s.logger.Debug("event was created", slog.String("id", event.ID.String()))
+
+	return event.ID, nil
+}
+
+// Update update an event in a database.
+func (s *Service) Update(ctx context.Context, updateEvent UpdateEvent) error {
+	err := validEventToUpdate(updateEvent)
+	if err != nil {
+		return fmt.Errorf("unable to update event: %w", err)
+	}
+
+	current, err := s.QueryByID(ctx, updateEvent.ID)
+	if err != nil {
+		return errUpdateEvent
+	}
+
+	if current == nil {
+		return ErrEventNotFound
+	}
+
+	err = s.storer.UpdateEvent(ctx, updateEvent.toEvent(*current))
+	if err != nil {
+		s.logger.Error("unable to update event", slog.String("error", err.Error()))
+
+		return errUpdateEvent
+	}
+
+	return nil
+}
+
+// Delete delete an event from database.
+func (s *Service) Delete(ctx context.Context, id kbs.EventID) error {
+	if id == EmptyEventID {
+		return errEmptyEventID
+	}
+
+	err := s.storer.DeleteEvent(ctx, id)
+	if err != nil {
+		s.logger.Error("unable to delete event",
+			slog.String("id", id.String()),
+			slog.String("error", err.Error()))
+
+		return errDeleteEvent
+	}
+
+	return nil
+}
+
+// QueryByID find an event with the given id.
+func (s *Service) QueryByID(ctx context.Context, id kbs.EventID) (*Event, error) {
+	if id == EmptyEventID {
+		return nil, errEmptyEventID
+	}
+
+	event, err := s.storer.QueryEventByID(ctx, id)
+	if err != nil {
+		s.logger.Error("unable to query event by id",
+			slog.String("id", id.String()),
+			slog.String("error", err.Error()))
+
+		return nil, errQueryEvent
+	}
+
+	return event, nil
+}
+
+// Query returns the events that match the given filter.
+func (s *Service) Query(ctx context.Context, filter QueryFilter) ([]Event, error) {
+	eventsFound, err := s.storer.QueryEvents(ctx, filter)
+	if err != nil {
+		s.logger.Error("unable to query events",
+			slog.String("filter", fmt.Sprintf("%+v", filter)),
+			slog.String("error", err.Error()))
+
+		return nil, errQueryEvents
+	}
+
+	return eventsFound, nil
+}
+
+// ValidateEvent verifies that the given event exists and it is open.
+// It implements kbs.EventValidator.
+func (s *Service) ValidateEvent(ctx context.Context, id kbs.EventID) error {
+	event, err := s.QueryByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if event == nil {
+		return ErrEventNotFound
+	}
+
+	if !event.IsOpen(time.Now().UTC()) {
+		return ErrEventNotOpen
+	}
+
+	return nil
+}
@@ -0,0 +1,121 @@
+package events_test
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/events"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/kbs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateEvent(t *testing.T) {
+	yesterday := time.Now().Add(-24 * time.Hour).Unix()
+	tomorrow := time.Now().Add(24 * time.Hour).Unix()
+
+	cases := map[string]struct {
+		eventID kbs.EventID
+		want    error
+	}{
+		"open": {
+			eventID: "open",
+			want:    nil,
+		},
+		"closed": {
+			eventID: "closed",
+			want:    events.ErrEventNotOpen,
+		},
+		"finished": {
+			eventID: "finished",
+			want:    events.ErrEventNotOpen,
+		},
+		"not_found": {
+			eventID: "unknown",
+			want:    events.ErrEventNotFound,
+		},
+	}
+
+	store := memoryStore{
+		"open":     {ID: "open", Status: events.Open, EndDate: tomorrow},
+		"closed":   {ID: "closed", Status: events.Closed},
+		"finished": {ID: "finished", Status: events.Open, EndDate: yesterday},
+	}
+
+	service := events.NewService(events.ServiceSetup{
+		Storer: store,
+		Logger: newDummyLogger(),
+	})
+
+	for name, data := range cases {
+		t.Run(name, func(st *testing.T) {
+			// When
+			got := service.ValidateEvent(context.TODO(), data.eventID)
+
+			// Then
+			assert.Equal(st, data.want, got)
+		})
+	}
+}
+
+func TestCreateEventWithInvalidDates(t *testing.T) {
+	// Given
+	service := events.NewService(events.ServiceSetup{
+		Storer: memoryStore{},
+		Logger: newDummyLogger(),
+	})
+
+	newEvent := events.NewEvent{
+		Name:      "retro",
+		StartDate: 200,
+		EndDate:   100,
+	}
+
+	// When
+	got, err := service.Create(context.TODO(), newEvent)
+
+	// Then
+	assert.Error(t, err)
+	assert.Equal(t, events.EmptyEventID, got)
+}
+
+type memoryStore map[kbs.EventID]events.Event
+
+func (m memoryStore) SaveEvent(ctx context.Context, event events.Event) error {
+	m[event.ID] = event
+	return nil
+}
+
+func (m memoryStore) UpdateEvent(ctx context.Context, event events.Event) error {
+	m[event.ID] = event
+	return nil
+}
+
+func (m memoryStore) DeleteEvent(ctx context.Context, id kbs.EventID) error {
+	delete(m, id)
+	return nil
+}
+
+func (m memoryStore) QueryEventByID(ctx context.Context, id kbs.EventID) (*events.Event, error) {
+	event, ok := m[id]
+	if !ok {
+		return nil, nil
+	}
+	return &event, nil
+}
+
+func (m memoryStore) QueryEvents(ctx context.Context, filter events.QueryFilter) ([]events.Event, error) {
+	var result []events.Event
+	for _, v := range m {
+		if filter.Status == events.EmptyStatus || filter.Status == v.Status {
+			result = append(result, v)
+		}
+	}
+	return result, nil
+}
+
+func newDummyLogger() *slog.Logger {
+	return slog.New(slog.NewJSONHandler(os.Stdout, nil))
+}
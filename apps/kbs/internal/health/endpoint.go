@@ -0,0 +1,59 @@
+package health
+
+import (
+	"context"
+	"log/slog"
+)
+
+type LivenessEndpoint struct {
+	service *Service
+	logger  *slog.Logger
+}
+
+type ReadinessEndpoint struct {
+	service *Service
+	logger  *slog.Logger
+}
+
+// Endpoints is a wrapper for health endpoints
+type Endpoints struct {
+	LivenessEndpoint  *LivenessEndpoint
+	ReadinessEndpoint *ReadinessEndpoint
+}
+
+// NewEndpoints Create the endpoints for the health checks.
+func NewEndpoints(service *Service, logger *slog.Logger) Endpoints {
+	return Endpoints{
+		LivenessEndpoint:  MakeLivenessEndpoint(service, logger),
+		ReadinessEndpoint: MakeReadinessEndpoint(service, logger),
+	}
+}
+
+// MakeLivenessEndpoint create endpoint for the liveness check.
+func MakeLivenessEndpoint(srv *Service, logger *slog.Logger) *LivenessEndpoint {
+	return &LivenessEndpoint{
+		service: srv,
+		logger:  logger,
+	}
+}
+
+// MakeReadinessEndpoint create endpoint for the readiness check.
+func MakeReadinessEndpoint(srv *Service, logger *slog.Logger) *ReadinessEndpoint {
+	return &ReadinessEndpoint{
+		service: srv,
+		logger:  logger,
+	}
+}
+
+func (l *LivenessEndpoint) Do(ctx context.Context, request any) (any, error) {
+	return l.service.Live(ctx), nil
+}
+
+func (r *ReadinessEndpoint) Do(ctx context.Context, request any) (any, error) {
+	report := r.service.Ready(ctx)
+	if !report.IsUp() {
+		r.logger.Warn("service is not ready", slog.String("status", report.Status.String()))
+	}
+
+	return report, nil
+}
@@ -0,0 +1,72 @@
+package health
+
+import (
+	"context"
+	"time"
+)
+
+// Status defines the health of the service or of one of its dependencies.
+type Status string
+
+// health statuses.
+const (
+	Up   = Status("up")
+	Down = Status("down")
+)
+
+// Checker verifies a dependency of the service.
+type Checker interface {
+	// Check returns an error if the dependency is not usable.
+	Check(ctx context.Context) error
+}
+
+// CheckerFunc is a function that can be used as a Checker.
+type CheckerFunc func(ctx context.Context) error
+
+// Report contains the health of the service and of each dependency checked.
+type Report struct {
+	Status    Status        `json:"status"`
+	Checks    []CheckResult `json:"checks"`
+	CheckedAt int64         `json:"checked_at"`
+}
+
+// CheckResult contains the result of checking one dependency.
+type CheckResult struct {
+	Name   string `json:"name"`
+	Status Status `json:"status"`
+	Error  string `json:"error,omitempty"`
+	// Duration is how long the check took in milliseconds.
+	Duration  int64 `json:"duration_ms"`
+	CheckedAt int64 `json:"checked_at"`
+	// Cached is true if the result was reused from a previous check.
+	Cached bool `json:"cached"`
+}
+
+func (f CheckerFunc) Check(ctx context.Context) error {
+	return f(ctx)
+}
+
+func (s Status) String() string {
+	return string(s)
+}
+
+// IsUp returns true if the service is healthy.
+func (r Report) IsUp() bool {
+	return r.Status == Up
+}
+
+func newCheckResult(name string, started time.Time, err error) CheckResult {
+	result := CheckResult{
+		Name:      name,
+		Status:    Up,
+		Duration:  time.Since(started).Milliseconds(),
+		CheckedAt: started.UTC().Unix(),
+	}
+
+	if err != nil {
+		result.Status = Down
+		result.Error = err.Error()
+	}
+
+	return result
+}
@@ -0,0 +1,169 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// ServiceSetup contains service metadata.
+type ServiceSetup struct {
+	Logger *slog.Logger
+	// Timeout is the maximum time a check can take.
+	Timeout time.Duration
+	// CacheTTL is how long a check result is reused, zero disables the cache.
+	CacheTTL time.Duration
+}
+
+// Service keeps the registry of dependency checks and reports the health
+// of the service.
+type Service struct {
+	logger   *slog.Logger
+	timeout  time.Duration
+	cacheTTL time.Duration
+	mu       sync.RWMutex
+	checks   []*check
+	// shuttingDown makes the service not ready so it stops receiving traffic.
+	shuttingDown bool
+}
+
+// check is a registered checker and its last result.
+type check struct {
+	name    string
+	checker Checker
+	mu      sync.Mutex
+	last    *CheckResult
+	expires time.Time
+}
+
+const (
+	defaultTimeout = 2 * time.Second
+	shutdownCheck  = "shutdown"
+)
+
+var errShuttingDown = errors.New("service is shutting down")
+
+// NewService create a new health service.
+func NewService(settings ServiceSetup) *Service {
+	newService := Service{
+		logger:   settings.Logger,
+		timeout:  settings.Timeout,
+		cacheTTL: settings.CacheTTL,
+	}
+
+	if newService.timeout <= 0 {
+		newService.timeout = defaultTimeout
+	}
+
+	return &newService
+}
+
+// Register adds a dependency check to the readiness report.
+func (s *Service) Register(name string, checker Checker) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.checks = append(s.checks, &check{name: name, checker: checker})
+}
+
+// Live reports if the process is able to serve requests. It doesn't check
+// dependencies, a failing dependency must not restart the service.
+func (s *Service) Live(ctx context.Context) Report {
+	return Report{
+		Status:    Up,
+		Checks:    []CheckResult{},
+		CheckedAt: time.Now().UTC().Unix(),
+	}
+}
+
+// Shutdown makes the service report it is not ready from now on.
+func (s *Service) Shutdown() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.shuttingDown = true
+}
+
+// Ready reports if every registered dependency is usable. Checks run
+// concurrently, each one limited by the service timeout. Once the service
+// is shutting down it is never ready.
+func (s *Service) Ready(ctx context.Context) Report {
+	s.mu.RLock()
+	checks := append([]*check{}, s.checks...)
+	shuttingDown := s.shuttingDown
+	s.mu.RUnlock()
+
+	if shuttingDown {
+		return Report{
+			Status:    Down,
+			Checks:    []CheckResult{newCheckResult(shutdownCheck, time.Now(), errShuttingDown)},
+			CheckedAt: time.Now().UTC().Unix(),
+		}
+	}
+
+	report := Report{
+		Status:    Up,
+		Checks:    make([]CheckResult, len(checks)),
+		CheckedAt: time.Now().UTC().Unix(),
+	}
+
+	var wg sync.WaitGroup
+
+	for i, c := range checks {
+		wg.Add(1)
+
+		go func(i int, c *check) {
+			defer wg.Done()
+
+			report.Checks[i] = s.run(ctx, c)
+		}(i, c)
+	}
+
+	wg.Wait()
+
+	for _, result := range report.Checks {
+		if result.Status != Up {
+			report.Status = Down
+		}
+	}
+
+	return report
+}
+
+// run returns the cached result of the check if it didn't expire, otherwise
+// it runs the check.
+func (s *Service) run(ctx context.Context, c *check) CheckResult {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+
+	if c.last != nil && now.Before(c.expires) {
+		result := *c.last
+		result.Cached = true
+
+		return result
+	}
+
+	checkCtx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	err := c.checker.Check(checkCtx)
+	if err == nil && checkCtx.Err() != nil {
+		err = checkCtx.Err()
+	}
+
+	result := newCheckResult(c.name, now, err)
+	if err != nil {
+		s.logger.Warn("dependency check failed",
+			slog.String("check", c.name),
+			slog.String("error", err.Error()))
+	}
+
+	c.last = &result
+	c.expires = now.Add(s.cacheTTL)
+
+	return result
+}
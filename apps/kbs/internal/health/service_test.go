@@ -0,0 +1,96 @@
+package health_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/health"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReady(t *testing.T) {
+	// Given
+	ctx := context.TODO()
+	service := health.NewService(health.ServiceSetup{
+		Logger:  newDummyLogger(),
+		Timeout: 50 * time.Millisecond,
+	})
+	service.Register("dynamodb", health.CheckerFunc(func(ctx context.Context) error {
+		return nil
+	}))
+	service.Register("sql", health.CheckerFunc(func(ctx context.Context) error {
+		return errors.New("connection refused")
+	}))
+	service.Register("slow", health.CheckerFunc(func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}))
+
+	// When
+	got := service.Ready(ctx)
+
+	// Then
+	assert.Equal(t, health.Down, got.Status)
+	require.Len(t, got.Checks, 3)
+	assert.Equal(t, health.Up, got.Checks[0].Status)
+	assert.Equal(t, health.Down, got.Checks[1].Status)
+	assert.Equal(t, "connection refused", got.Checks[1].Error)
+	assert.Equal(t, health.Down, got.Checks[2].Status)
+	assert.Equal(t, context.DeadlineExceeded.Error(), got.Checks[2].Error)
+	assert.True(t, service.Live(ctx).IsUp())
+}
+
+func TestReadyUsesCachedResults(t *testing.T) {
+	// Given
+	ctx := context.TODO()
+	var calls atomic.Int32
+	service := health.NewService(health.ServiceSetup{
+		Logger:   newDummyLogger(),
+		CacheTTL: time.Minute,
+	})
+	service.Register("dynamodb", health.CheckerFunc(func(ctx context.Context) error {
+		calls.Add(1)
+		return nil
+	}))
+
+	// When
+	first := service.Ready(ctx)
+	second := service.Ready(ctx)
+
+	// Then
+	assert.True(t, first.IsUp())
+	assert.True(t, second.IsUp())
+	assert.False(t, first.Checks[0].Cached)
+	assert.True(t, second.Checks[0].Cached)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestReadyAfterShutdown(t *testing.T) {
+	// Given
+	ctx := context.TODO()
+	service := health.NewService(health.ServiceSetup{Logger: newDummyLogger()})
+	service.Register("dynamodb", health.CheckerFunc(func(ctx context.Context) error {
+		return nil
+	}))
+	require.True(t, service.Ready(ctx).IsUp())
+
+	// When
+	service.Shutdown()
+
+	// Then
+	got := service.Ready(ctx)
+	assert.False(t, got.IsUp())
+	require.Len(t, got.Checks, 1)
+	assert.Equal(t, "shutdown", got.Checks[0].Name)
+	assert.True(t, service.Live(ctx).IsUp())
+}
+
+func newDummyLogger() *slog.Logger {
+	return slog.New(slog.NewJSONHandler(os.Stdout, nil))
+}
@@ -0,0 +1,99 @@
+package idempotency
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+// State defines the stage of a request made with an idempotency key.
+type State string
+
+// request states.
+const (
+	InProgress State = "in_progress"
+	Completed  State = "completed"
+)
+
+// Record keeps the response of the first request made with an idempotency
+// key, so retries get the same response instead of repeating the change.
+type Record struct {
+	// ID identifies the key of a principal, it is a digest of both.
+	ID string
+	// Fingerprint is a digest of the request that used the key.
+	Fingerprint string
+	State       State
+	Response    Response
+	CreatedAt   int64
+	// LockedUntil is when a request in progress is considered abandoned,
+	// e.g. the instance serving it crashed, so the key can be used again.
+	LockedUntil int64
+	// ExpiresAt is when the record is removed and the key can be reused.
+	ExpiresAt int64
+	// Token identifies the request that took the key, only that request
+	// can complete or release it.
+	Token string
+}
+
+// Lock is the key taken by a request while it is served.
+type Lock struct {
+	ID    string
+	Token string
+}
+
+// Response is the response of a request made with an idempotency key.
+type Response struct {
+	StatusCode  int
+	ContentType string
+	Body        []byte
+}
+
+var (
+	// ErrConflict is returned by stores when there is already a record
+	// with the same id that didn't expire.
+	ErrConflict = errors.New("idempotency record already exists")
+	// ErrInProgress is returned when a request with the same key is
+	// still being served.
+	ErrInProgress = errors.New("a request with the same idempotency key is in progress")
+	// ErrMismatch is returned when the key was used with a different request.
+	ErrMismatch = errors.New("idempotency key was already used with a different request")
+	// ErrLockLost is returned by stores when the record is no longer held
+	// by the request, e.g. its lock timed out and other request took it.
+	ErrLockLost = errors.New("idempotency key is no longer held by the request")
+)
+
+// NewID returns the record id of the key sent by the given principal, keys
+// of different principals never collide.
+func NewID(principal, key string) string {
+	return digest([]byte(principal), []byte(key))
+}
+
+// newToken returns a random lock token.
+func newToken() string {
+	return uuid.New().String()
+}
+
+// Fingerprint returns the digest of a request, a key can only be reused
+// with the same request.
+func Fingerprint(method, path string, body []byte) string {
+	return digest([]byte(method), []byte(path), body)
+}
+
+// IsExpired returns true if the record can be replaced at the given time.
+func (r Record) IsExpired(now int64) bool {
+	return r.ExpiresAt <= now || (r.State == InProgress && r.LockedUntil <= now)
+}
+
+func digest(parts ...[]byte) string {
+	hash := sha256.New()
+
+	for _, part := range parts {
+		hash.Write(part)
+		// the separator keeps "ab"+"c" and "a"+"bc" apart.
+		hash.Write([]byte{0})
+	}
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
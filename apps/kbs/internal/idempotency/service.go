@@ -0,0 +1,181 @@
+// Package idempotency makes the requests sent with an idempotency key safe
+// to retry.
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/requests"
+)
+
+// Storer defines persistence behavior for idempotency records.
+type Storer interface {
+	// CreateRecord stores the record if there is no record with the same
+	// id or the existing one expired at the given time, it returns
+	// ErrConflict otherwise.
+	CreateRecord(ctx context.Context, record Record, now int64) error
+	// QueryRecord returns the record with the given id, nil if it does not exist.
+	QueryRecord(ctx context.Context, id string) (*Record, error)
+	// UpdateRecord replaces the record with the same id if it is in
+	// progress and held by the given token, it returns ErrLockLost otherwise.
+	UpdateRecord(ctx context.Context, record Record, token string) error
+	// DeleteRecord removes the record if it is in progress and held by
+	// the given token, it returns ErrLockLost otherwise.
+	DeleteRecord(ctx context.Context, id, token string) error
+}
+
+// ServiceSetup contains service metadata.
+type ServiceSetup struct {
+	Storer Storer
+	Logger *slog.Logger
+	// TTL is how long responses are kept.
+	TTL time.Duration
+	// LockTimeout is how long a request in progress blocks its key.
+	LockTimeout time.Duration
+	// Clock returns the current time, time.Now if nil.
+	Clock func() time.Time
+}
+
+// Service implements idempotency keys business logic.
+type Service struct {
+	storer      Storer
+	logger      *slog.Logger
+	ttl         time.Duration
+	lockTimeout time.Duration
+	clock       func() time.Time
+}
+
+var (
+	errBeginRequest    = errors.New("unable to check idempotency key")
+	errCompleteRequest = errors.New("unable to store idempotent response")
+	errAbortRequest    = errors.New("unable to release idempotency key")
+)
+
+// NewService create a new idempotency service.
+func NewService(settings ServiceSetup) *Service {
+	newService := Service{
+		storer:      settings.Storer,
+		logger:      settings.Logger,
+		ttl:         settings.TTL,
+		lockTimeout: settings.LockTimeout,
+		clock:       settings.Clock,
+	}
+
+	if newService.clock == nil {
+		newService.clock = time.Now
+	}
+
+	return &newService
+}
+
+// Begin takes the key for the request with the given fingerprint. It
+// returns the lock of the key if the request must be served, or the stored
+// response if the request was already served. It fails with ErrInProgress
+// if the request is being served and with ErrMismatch if the key was used
+// with other request.
+func (s *Service) Begin(ctx context.Context, id, fingerprint string) (Lock, *Response, error) {
+	logger := requests.Logger(ctx, s.logger)
+
+	now := s.clock()
+	record := Record{
+		ID:          id,
+		Fingerprint: fingerprint,
+		State:       InProgress,
+		CreatedAt:   now.Unix(),
+		LockedUntil: now.Add(s.lockTimeout).Unix(),
+		ExpiresAt:   now.Add(s.ttl).Unix(),
+		Token:       newToken(),
+	}
+
+	err := s.storer.CreateRecord(ctx, record, now.Unix())
+	if err == nil {
+		return Lock{ID: id, Token: record.Token}, nil, nil
+	}
+
+	if !errors.Is(err, ErrConflict) {
+		logger.Error("unable to create idempotency record", slog.String("error", err.Error()))
+
+		return Lock{}, nil, errBeginRequest
+	}
+
+	existing, err := s.storer.QueryRecord(ctx, id)
+	if err != nil {
+		logger.Error("unable to query idempotency record", slog.String("error", err.Error()))
+
+		return Lock{}, nil, errBeginRequest
+	}
+
+	// the record expired or was released since it was created, the
+	// client is told to retry instead of racing for the key.
+	if existing == nil || existing.IsExpired(now.Unix()) {
+		return Lock{}, nil, ErrInProgress
+	}
+
+	if existing.Fingerprint != fingerprint {
+		return Lock{}, nil, ErrMismatch
+	}
+
+	if existing.State == InProgress {
+		return Lock{}, nil, ErrInProgress
+	}
+
+	logger.Debug("replaying idempotent response", slog.Int("status", existing.Response.StatusCode))
+
+	return Lock{}, &existing.Response, nil
+}
+
+// Complete stores the response of the request so it is replayed to
+// retries. Nothing is stored if the request no longer holds the key.
+func (s *Service) Complete(ctx context.Context, lock Lock, fingerprint string, response Response) error {
+	logger := requests.Logger(ctx, s.logger)
+
+	now := s.clock()
+	record := Record{
+		ID:          lock.ID,
+		Fingerprint: fingerprint,
+		State:       Completed,
+		Response:    response,
+		CreatedAt:   now.Unix(),
+		ExpiresAt:   now.Add(s.ttl).Unix(),
+		Token:       lock.Token,
+	}
+
+	err := s.storer.UpdateRecord(ctx, record, lock.Token)
+	if errors.Is(err, ErrLockLost) {
+		logger.Warn("idempotency key was taken by other request, response is not stored")
+
+		return ErrLockLost
+	}
+
+	if err != nil {
+		logger.Error("unable to store idempotent response", slog.String("error", err.Error()))
+
+		return errCompleteRequest
+	}
+
+	return nil
+}
+
+// Abort releases the key of a request that failed, so it can be retried.
+// The key is kept if the request no longer holds it.
+func (s *Service) Abort(ctx context.Context, lock Lock) error {
+	logger := requests.Logger(ctx, s.logger)
+
+	err := s.storer.DeleteRecord(ctx, lock.ID, lock.Token)
+	if errors.Is(err, ErrLockLost) {
+		logger.Warn("idempotency key was taken by other request, it is not released")
+
+		return ErrLockLost
+	}
+
+	if err != nil {
+		logger.Error("unable to release idempotency key", slog.String("error", err.Error()))
+
+		return errAbortRequest
+	}
+
+	return nil
+}
@@ -0,0 +1,214 @@
+package idempotency_test
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/idempotency"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBeginReplaysCompletedRequest(t *testing.T) {
+	// Given
+	ctx := context.TODO()
+	service := newService(newMemoryStore(), time.Now)
+	id := idempotency.NewID("drila", "key-1")
+	fingerprint := idempotency.Fingerprint("POST", "/kbs", []byte(`{"content":"a"}`))
+	response := idempotency.Response{StatusCode: 200, ContentType: "application/json", Body: []byte(`{"id":"kb-1"}`)}
+
+	lock, first, err := service.Begin(ctx, id, fingerprint)
+	require.NoError(t, err)
+	require.Nil(t, first)
+
+	_, _, errInProgress := service.Begin(ctx, id, fingerprint)
+	require.NoError(t, service.Complete(ctx, lock, fingerprint, response))
+
+	// When
+	_, replayed, err := service.Begin(ctx, id, fingerprint)
+
+	// Then
+	assert.ErrorIs(t, errInProgress, idempotency.ErrInProgress)
+	assert.NoError(t, err)
+	require.NotNil(t, replayed)
+	assert.Equal(t, response, *replayed)
+}
+
+func TestBeginRejectsDifferentRequest(t *testing.T) {
+	// Given
+	ctx := context.TODO()
+	service := newService(newMemoryStore(), time.Now)
+	id := idempotency.NewID("drila", "key-1")
+	first := idempotency.Fingerprint("POST", "/kbs", []byte(`{"content":"a"}`))
+	second := idempotency.Fingerprint("POST", "/kbs", []byte(`{"content":"b"}`))
+
+	lock, _, err := service.Begin(ctx, id, first)
+	require.NoError(t, err)
+	require.NoError(t, service.Complete(ctx, lock, first, idempotency.Response{StatusCode: 200}))
+
+	// When
+	_, _, err = service.Begin(ctx, id, second)
+
+	// Then
+	assert.ErrorIs(t, err, idempotency.ErrMismatch)
+	assert.NotEqual(t, id, idempotency.NewID("mono", "key-1"))
+}
+
+func TestBeginTakesExpiredKeys(t *testing.T) {
+	cases := map[string]struct {
+		complete bool
+		elapsed  time.Duration
+	}{
+		"abandoned_in_progress": {
+			elapsed: 2 * time.Minute,
+		},
+		"expired_response": {
+			complete: true,
+			elapsed:  25 * time.Hour,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			// Given
+			ctx := context.TODO()
+			now := time.Unix(1700000000, 0)
+			service := newService(newMemoryStore(), func() time.Time { return now })
+			id := idempotency.NewID("drila", "key-1")
+
+			lock, _, err := service.Begin(ctx, id, "first")
+			require.NoError(t, err)
+			if tc.complete {
+				require.NoError(t, service.Complete(ctx, lock, "first", idempotency.Response{StatusCode: 200}))
+			}
+
+			now = now.Add(tc.elapsed)
+
+			// When
+			_, replayed, err := service.Begin(ctx, id, "second")
+
+			// Then
+			assert.NoError(t, err)
+			assert.Nil(t, replayed)
+		})
+	}
+}
+
+func TestAbortReleasesKey(t *testing.T) {
+	// Given
+	ctx := context.TODO()
+	service := newService(newMemoryStore(), time.Now)
+	id := idempotency.NewID("drila", "key-1")
+
+	lock, _, err := service.Begin(ctx, id, "first")
+	require.NoError(t, err)
+
+	// When
+	errAbort := service.Abort(ctx, lock)
+	_, replayed, err := service.Begin(ctx, id, "first")
+
+	// Then
+	assert.NoError(t, errAbort)
+	assert.NoError(t, err)
+	assert.Nil(t, replayed)
+}
+
+func TestAbandonedRequestCannotReleaseNewLock(t *testing.T) {
+	// Given
+	ctx := context.TODO()
+	now := time.Unix(1700000000, 0)
+	store := newMemoryStore()
+	service := newService(store, func() time.Time { return now })
+	id := idempotency.NewID("drila", "key-1")
+
+	abandoned, _, err := service.Begin(ctx, id, "first")
+	require.NoError(t, err)
+	now = now.Add(2 * time.Minute)
+	current, _, err := service.Begin(ctx, id, "first")
+	require.NoError(t, err)
+
+	// When
+	errComplete := service.Complete(ctx, abandoned, "first", idempotency.Response{StatusCode: 200})
+	errAbort := service.Abort(ctx, abandoned)
+
+	// Then
+	assert.ErrorIs(t, errComplete, idempotency.ErrLockLost)
+	assert.ErrorIs(t, errAbort, idempotency.ErrLockLost)
+	assert.NotEqual(t, abandoned.Token, current.Token)
+	require.Contains(t, store.records, id)
+	assert.Equal(t, idempotency.InProgress, store.records[id].State)
+	assert.Equal(t, current.Token, store.records[id].Token)
+}
+
+func newService(store idempotency.Storer, clock func() time.Time) *idempotency.Service {
+	return idempotency.NewService(idempotency.ServiceSetup{
+		Storer:      store,
+		Logger:      newDummyLogger(),
+		TTL:         24 * time.Hour,
+		LockTimeout: time.Minute,
+		Clock:       clock,
+	})
+}
+
+type memoryStore struct {
+	records map[string]idempotency.Record
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{
+		records: make(map[string]idempotency.Record),
+	}
+}
+
+func (m *memoryStore) CreateRecord(ctx context.Context, record idempotency.Record, now int64) error {
+	existing, ok := m.records[record.ID]
+	if ok && !existing.IsExpired(now) {
+		return idempotency.ErrConflict
+	}
+
+	m.records[record.ID] = record
+
+	return nil
+}
+
+func (m *memoryStore) QueryRecord(ctx context.Context, id string) (*idempotency.Record, error) {
+	record, ok := m.records[id]
+	if !ok {
+		return nil, nil
+	}
+
+	return &record, nil
+}
+
+func (m *memoryStore) UpdateRecord(ctx context.Context, record idempotency.Record, token string) error {
+	if !m.heldBy(record.ID, token) {
+		return idempotency.ErrLockLost
+	}
+
+	m.records[record.ID] = record
+
+	return nil
+}
+
+func (m *memoryStore) DeleteRecord(ctx context.Context, id, token string) error {
+	if !m.heldBy(id, token) {
+		return idempotency.ErrLockLost
+	}
+
+	delete(m.records, id)
+
+	return nil
+}
+
+func (m *memoryStore) heldBy(id, token string) bool {
+	existing, ok := m.records[id]
+
+	return ok && existing.State == idempotency.InProgress && existing.Token == token
+}
+
+func newDummyLogger() *slog.Logger {
+	return slog.New(slog.NewJSONHandler(os.Stdout, nil))
+}
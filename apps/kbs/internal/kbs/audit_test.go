@@ -0,0 +1,55 @@
+package kbs_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/kbs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChangesAreNotStoredWithoutAuditEntry(t *testing.T) {
+	// Given
+	ctx := context.TODO()
+	store := newMemoryStore()
+	auditor := new(memoryAuditor)
+	service := kbs.NewService(kbs.ServiceSetup{
+		Storer:  store,
+		Auditor: auditor,
+		Logger:  newDummyLogger(),
+	})
+
+	kbID, err := service.Create(ctx, kbs.NewKB{UserID: "drila", Content: "runbook", EventID: "1"})
+	require.NoError(t, err)
+
+	auditor.err = errors.New("audit log is down")
+
+	// When
+	_, errCreate := service.Create(ctx, kbs.NewKB{UserID: "drila", Content: "other runbook", EventID: "1"})
+	errDelete := service.Delete(ctx, kbID)
+
+	// Then
+	assert.Error(t, errCreate)
+	assert.Error(t, errDelete)
+	assert.Len(t, store.kbs, 1)
+	assert.Contains(t, store.kbs, kbID)
+	require.Len(t, auditor.records, 1)
+	assert.Equal(t, kbs.CreateAction, auditor.records[0].Action)
+}
+
+type memoryAuditor struct {
+	records []kbs.AuditRecord
+	err     error
+}
+
+func (m *memoryAuditor) Record(ctx context.Context, record kbs.AuditRecord) error {
+	if m.err != nil {
+		return m.err
+	}
+
+	m.records = append(m.records, record)
+
+	return nil
+}
@@ -0,0 +1,70 @@
+package kbs_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/kbs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeleteRemovesPlacement(t *testing.T) {
+	// Given
+	ctx := context.TODO()
+	store := newMemoryStore()
+	remover := new(memoryPlacementRemover)
+	service := kbs.NewService(kbs.ServiceSetup{
+		Storer:           store,
+		PlacementRemover: remover,
+		Logger:           newDummyLogger(),
+	})
+
+	kbID, err := service.Create(ctx, kbs.NewKB{UserID: "drila", Content: "runbook", EventID: "1"})
+	require.NoError(t, err)
+
+	// When
+	err = service.Delete(ctx, kbID)
+
+	// Then
+	assert.NoError(t, err)
+	assert.Equal(t, []kbs.KBID{kbID}, remover.removed)
+	assert.NotContains(t, store.kbs, kbID)
+}
+
+func TestDeleteKeepsKBIfPlacementIsNotRemoved(t *testing.T) {
+	// Given
+	ctx := context.TODO()
+	store := newMemoryStore()
+	service := kbs.NewService(kbs.ServiceSetup{
+		Storer:           store,
+		PlacementRemover: &memoryPlacementRemover{err: errors.New("dynamodb is down")},
+		Logger:           newDummyLogger(),
+	})
+
+	kbID, err := service.Create(ctx, kbs.NewKB{UserID: "drila", Content: "runbook", EventID: "1"})
+	require.NoError(t, err)
+
+	// When
+	err = service.Delete(ctx, kbID)
+
+	// Then
+	assert.Error(t, err)
+	assert.Contains(t, store.kbs, kbID)
+}
+
+type memoryPlacementRemover struct {
+	removed []kbs.KBID
+	err     error
+}
+
+func (m *memoryPlacementRemover) RemovePlacement(ctx context.Context, id kbs.KBID) error {
+	if m.err != nil {
+		return m.err
+	}
+
+	m.removed = append(m.removed, id)
+
+	return nil
+}
@@ -5,6 +5,8 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/requests"
 )
 
 type GetKBWithIDEndpoint struct {
@@ -32,6 +34,26 @@ type SearchKBsEndpoint struct {
 	logger  *slog.Logger
 }
 
+type SubmitKBEndpoint struct {
+	service *Service
+	logger  *slog.Logger
+}
+
+type ApproveKBEndpoint struct {
+	service *Service
+	logger  *slog.Logger
+}
+
+type RejectKBEndpoint struct {
+	service *Service
+	logger  *slog.Logger
+}
+
+type ArchiveKBEndpoint struct {
+	service *Service
+	logger  *slog.Logger
+}
+
 // Endpoints is a wrapper for endpoints
 type Endpoints struct {
 	GetKBWithIDEndpoint *GetKBWithIDEndpoint
@@ -39,6 +61,10 @@ type Endpoints struct {
 	UpdateKBEndpoint    *UpdateKBEndpoint
 	DeleteKBEndpoint    *DeleteKBEndpoint
 	SearchKBsEndpoint   *SearchKBsEndpoint
+	SubmitKBEndpoint    *SubmitKBEndpoint
+	ApproveKBEndpoint   *ApproveKBEndpoint
+	RejectKBEndpoint    *RejectKBEndpoint
+	ArchiveKBEndpoint   *ArchiveKBEndpoint
 }
 
 // NewEndpoints Create the endpoints for kbs application.
@@ -49,6 +75,10 @@ func NewEndpoints(service *Service, logger *slog.Logger) Endpoints {
 		DeleteKBEndpoint:    MakeDeleteKBEndpoint(service, logger),
 		GetKBWithIDEndpoint: MakeGetKBWithIDEndpoint(service, logger),
 		SearchKBsEndpoint:   MakeSearchKBsEndpoint(service, logger),
+		SubmitKBEndpoint:    MakeSubmitKBEndpoint(service, logger),
+		ApproveKBEndpoint:   MakeApproveKBEndpoint(service, logger),
+		RejectKBEndpoint:    MakeRejectKBEndpoint(service, logger),
+		ArchiveKBEndpoint:   MakeArchiveKBEndpoint(service, logger),
 	}
 }
 
@@ -102,38 +132,84 @@ func MakeSearchKBsEndpoint(srv *Service, logger *slog.Logger) *SearchKBsEndpoint
 	return &newNewEndpoint
 }
 
+// MakeSubmitKBEndpoint create endpoint to send a kb to review.
+func MakeSubmitKBEndpoint(srv *Service, logger *slog.Logger) *SubmitKBEndpoint {
+	newNewEndpoint := SubmitKBEndpoint{
+		service: srv,
+		logger:  logger,
+	}
+
+	return &newNewEndpoint
+}
+
+// MakeApproveKBEndpoint create endpoint to approve a kb in review.
+func MakeApproveKBEndpoint(srv *Service, logger *slog.Logger) *ApproveKBEndpoint {
+	newNewEndpoint := ApproveKBEndpoint{
+		service: srv,
+		logger:  logger,
+	}
+
+	return &newNewEndpoint
+}
+
+// MakeRejectKBEndpoint create endpoint to reject a kb in review.
+func MakeRejectKBEndpoint(srv *Service, logger *slog.Logger) *RejectKBEndpoint {
+	newNewEndpoint := RejectKBEndpoint{
+		service: srv,
+		logger:  logger,
+	}
+
+	return &newNewEndpoint
+}
+
+// MakeArchiveKBEndpoint create endpoint to archive a kb.
+func MakeArchiveKBEndpoint(srv *Service, logger *slog.Logger) *ArchiveKBEndpoint {
+	newNewEndpoint := ArchiveKBEndpoint{
+		service: srv,
+		logger:  logger,
+	}
+
+	return &newNewEndpoint
+}
+
 func (g *GetKBWithIDEndpoint) Do(ctx context.Context, request any) (any, error) {
+	logger := requests.Logger(ctx, g.logger)
+
 	kbID, ok := request.(KBID)
 	if !ok {
-		g.logger.Error("invalid kb id", slog.String("request", fmt.Sprintf("%t", request)))
+		logger.Error("invalid kb id", slog.String("request", fmt.Sprintf("%t", request)))
 
 		return nil, errors.New("invalid kb id")
 	}
 
 	kbFound, err := g.service.QueryByID(ctx, kbID)
 	if err != nil {
-		g.logger.Error(
+		logger.Error(
 			"something went wrong trying to get a kb with the given id",
 			slog.String("error", err.Error()),
 		)
 	}
 
-	g.logger.Debug("find kb by id endpoint", slog.String("result", fmt.Sprintf("%+v", kbFound)))
+	if kbFound != nil {
+		logger.Debug("find kb by id endpoint", slog.Any("result", *kbFound))
+	}
 
 	return newGetKBWithIDResult(kbFound, err), nil
 }
 
 func (c *CreateKBEndpoint) Do(ctx context.Context, request any) (any, error) {
+	logger := requests.Logger(ctx, c.logger)
+
 	newKB, ok := request.(*NewKB)
 	if !ok {
-		c.logger.Error("invalid new kb type", slog.String("request", fmt.Sprintf("%t", request)))
+		logger.Error("invalid new kb type", slog.String("request", fmt.Sprintf("%t", request)))
 
 		return nil, errors.New("invalid new kb type")
 	}
 
 	newid, err := c.service.Create(ctx, *newKB)
 	if err != nil {
-		c.logger.Error(
+		logger.Error(
 			"something went wrong trying to create a kb with the given id",
 			slog.String("error", err.Error()),
 		)
@@ -142,16 +218,18 @@ func (c *CreateKBEndpoint) Do(ctx context.Context, request any) (any, error) {
 }
 
 func (u *UpdateKBEndpoint) Do(ctx context.Context, request any) (any, error) {
+	logger := requests.Logger(ctx, u.logger)
+
 	updateKB, ok := request.(*UpdateKB)
 	if !ok {
-		u.logger.Error("invalid update kb type", slog.String("request", fmt.Sprintf("%t", request)))
+		logger.Error("invalid update kb type", slog.String("request", fmt.Sprintf("%t", request)))
 
 		return nil, errors.New("invalid update kb type")
 	}
 
 	err := u.service.Update(ctx, *updateKB)
 	if err != nil {
-		u.logger.Error(
+		logger.Error(
 			"something went wrong trying to update a kb with the given id",
 			slog.String("error", err.Error()),
 		)
@@ -161,16 +239,18 @@ func (u *UpdateKBEndpoint) Do(ctx context.Context, request any) (any, error) {
 }
 
 func (d *DeleteKBEndpoint) Do(ctx context.Context, request any) (any, error) {
+	logger := requests.Logger(ctx, d.logger)
+
 	kbID, ok := request.(KBID)
 	if !ok {
-		d.logger.Error("invalid delete kb type", slog.String("received", fmt.Sprintf("%t", request)))
+		logger.Error("invalid delete kb type", slog.String("received", fmt.Sprintf("%t", request)))
 
 		return nil, errors.New("invalid kb id type")
 	}
 
 	err := d.service.Delete(ctx, kbID)
 	if err != nil {
-		d.logger.Error(
+		logger.Error(
 			"something went wrong trying to delete a kb with the given id",
 			slog.String("error", err.Error()),
 		)
@@ -180,24 +260,110 @@ func (d *DeleteKBEndpoint) Do(ctx context.Context, request any) (any, error) {
 }
 
 func (s *SearchKBsEndpoint) Do(ctx context.Context, request any) (any, error) {
+	logger := requests.Logger(ctx, s.logger)
+
 	kbFilters, ok := request.(QueryFilter)
 	if !ok {
-		s.logger.Error("invalid kb filters", slog.String("received", fmt.Sprintf("%t", request)))
+		logger.Error("invalid kb filters", slog.String("received", fmt.Sprintf("%t", request)))
 
 		return nil, errors.New("invalid kb filters")
 	}
 
-	s.logger.Debug("querying kbs", slog.Any("filters", kbFilters))
+	logger.Debug("querying kbs", slog.Any("filters", kbFilters))
 
 	searchResult, err := s.service.Query(ctx, kbFilters)
 	if err != nil {
-		s.logger.Error(
+		logger.Error(
 			"something went wrong trying to search kbs with the given filter",
 			slog.String("error", err.Error()),
 		)
 	}
 
-	s.logger.Debug("search kbs endpoint", slog.String("result", fmt.Sprintf("%+v", searchResult)))
+	logger.Debug("search kbs endpoint", slog.Any("result", searchResult))
 
 	return newSearchKBsDataResult(searchResult, err), nil
 }
+
+func (s *SubmitKBEndpoint) Do(ctx context.Context, request any) (any, error) {
+	logger := requests.Logger(ctx, s.logger)
+
+	submitKB, ok := request.(SubmitKB)
+	if !ok {
+		logger.Error("invalid submit kb type", slog.String("received", fmt.Sprintf("%t", request)))
+
+		return nil, errors.New("invalid submit kb type")
+	}
+
+	err := s.service.Submit(ctx, submitKB)
+	if err != nil {
+		logger.Error(
+			"something went wrong trying to submit a kb to review",
+			slog.String("error", err.Error()),
+		)
+	}
+
+	return newChangeStateResult(err), nil
+}
+
+func (a *ApproveKBEndpoint) Do(ctx context.Context, request any) (any, error) {
+	logger := requests.Logger(ctx, a.logger)
+
+	reviewKB, ok := request.(ReviewKB)
+	if !ok {
+		logger.Error("invalid review kb type", slog.String("received", fmt.Sprintf("%t", request)))
+
+		return nil, errors.New("invalid review kb type")
+	}
+
+	err := a.service.Approve(ctx, reviewKB)
+	if err != nil {
+		logger.Error(
+			"something went wrong trying to approve a kb",
+			slog.String("error", err.Error()),
+		)
+	}
+
+	return newChangeStateResult(err), nil
+}
+
+func (r *RejectKBEndpoint) Do(ctx context.Context, request any) (any, error) {
+	logger := requests.Logger(ctx, r.logger)
+
+	reviewKB, ok := request.(ReviewKB)
+	if !ok {
+		logger.Error("invalid review kb type", slog.String("received", fmt.Sprintf("%t", request)))
+
+		return nil, errors.New("invalid review kb type")
+	}
+
+	err := r.service.Reject(ctx, reviewKB)
+	if err != nil {
+		logger.Error(
+			"something went wrong trying to reject a kb",
+			slog.String("error", err.Error()),
+		)
+	}
+
+	return newChangeStateResult(err), nil
+}
+
+func (a *ArchiveKBEndpoint) Do(ctx context.Context, request any) (any, error) {
+	logger := requests.Logger(ctx, a.logger)
+
+	kbID, ok := request.(KBID)
+	if !ok {
+		logger.Error("invalid archive kb type", slog.String("received", fmt.Sprintf("%t", request)))
+
+		return nil, errors.New("invalid kb id type")
+	}
+
+	err := a.service.Archive(ctx, kbID)
+	if err != nil {
+		logger.Error(
+			"something went wrong trying to archive a kb",
+			slog.String("error", err.Error()),
+		)
+	}
+
+	return newChangeStateResult(err), nil
+}
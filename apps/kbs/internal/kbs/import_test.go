@@ -0,0 +1,54 @@
+package kbs_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/kbs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportValidatesAndAuditsKBs(t *testing.T) {
+	// Given
+	ctx := context.TODO()
+	store := newMemoryStore()
+	store.kbs["kb-1"] = kbs.KB{ID: "kb-1", EventID: "event-1", Content: "current"}
+	auditor := new(memoryAuditor)
+	service := kbs.NewService(kbs.ServiceSetup{
+		Storer:         store,
+		EventValidator: knownEvents{"event-1": true},
+		Auditor:        auditor,
+		Logger:         newDummyLogger(),
+	})
+
+	// When
+	imported, errImport := service.Import(ctx, kbs.KB{ID: "kb-2", EventID: "event-1", CreationDate: 1700000000})
+	existing, errExisting := service.Import(ctx, kbs.KB{ID: "kb-1", EventID: "event-1", Content: "older"})
+	_, errUnknownEvent := service.Import(ctx, kbs.KB{ID: "kb-3", EventID: "event-2"})
+
+	// Then
+	require.NoError(t, errImport)
+	require.NoError(t, errExisting)
+	assert.Error(t, errUnknownEvent)
+	assert.True(t, imported)
+	assert.False(t, existing)
+	assert.Equal(t, int64(1700000000), store.kbs["kb-2"].CreationDate)
+	assert.Equal(t, "current", store.kbs["kb-1"].Content)
+	assert.NotContains(t, store.kbs, kbs.KBID("kb-3"))
+	require.Len(t, auditor.records, 1)
+	assert.Equal(t, kbs.CreateAction, auditor.records[0].Action)
+	assert.Equal(t, kbs.KBID("kb-2"), auditor.records[0].KBID)
+}
+
+// knownEvents validates the events it contains.
+type knownEvents map[kbs.EventID]bool
+
+func (k knownEvents) ValidateEvent(ctx context.Context, id kbs.EventID) error {
+	if !k[id] {
+		return errors.New("event does not exist")
+	}
+
+	return nil
+}
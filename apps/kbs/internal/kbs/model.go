@@ -2,6 +2,7 @@ package kbs
 
 import (
 	"fmt"
+	"log/slog"
 	"strconv"
 	"time"
 
@@ -20,6 +21,9 @@ type EventID string
 // OrderByField defines fields you can use to order queries.
 type OrderByField string
 
+// SortOrder defines the direction of the order of queries.
+type SortOrder string
+
 // NewKB contains data to request the creation of a new kb.
 type NewKB struct {
 	UserID   UserID  `json:"user_id"`
@@ -47,6 +51,37 @@ type KB struct {
 	EventID      EventID `json:"event_id"`
 	CreationDate int64   `json:"creation_date"`
 	UpdateDate   int64   `json:"update_date"`
+	// State is the stage of the kb lifecycle.
+	State      State    `json:"state"`
+	ReviewerID UserID   `json:"reviewer_id,omitempty"`
+	Reviews    []Review `json:"reviews,omitempty"`
+	// CommentCount is the number of comments written about the kb.
+	CommentCount int `json:"comment_count"`
+	// Path is the location of the kb inside the spaces hierarchy, from the
+	// space down to the collection that contains it.
+	Path []Breadcrumb `json:"path,omitempty"`
+	// ContentTruncated is true if Content is only the beginning of the
+	// content of a large kb, the whole content is read by id.
+	ContentTruncated bool `json:"content_truncated,omitempty"`
+}
+
+// Breadcrumb is one step of the path that leads to a kb.
+type Breadcrumb struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Kind string `json:"kind"`
+}
+
+// Action defines the kind of change made to a kb.
+type Action string
+
+// AuditRecord describes a change made to a kb. Before is nil for created
+// kbs and After is nil for deleted ones.
+type AuditRecord struct {
+	Action Action
+	KBID   KBID
+	Before *KB
+	After  *KB
 }
 
 // ValidationError define kb validation logic.
@@ -57,7 +92,10 @@ type ValidationError struct {
 // QueryFilter contains data for query filters.
 type QueryFilter struct {
 	EventID     string
+	UserID      string
+	State       State
 	OrderBy     OrderByField
+	Order       SortOrder
 	PageNumber  uint8
 	RowsPerPage uint8
 }
@@ -102,14 +140,30 @@ const (
 	// EmptyKBID is the kb id that empty or nil.
 	EmptyKBID         = KBID("")
 	EmptyOrderByField = OrderByField("")
+	EmptySortOrder    = SortOrder("")
 
 	PageNumberDefault  = uint8(1)
 	RowsPerPageDefault = uint8(10)
 )
 
+// audited actions.
+const (
+	CreateAction      Action = "create"
+	UpdateAction      Action = "update"
+	DeleteAction      Action = "delete"
+	ChangeStateAction Action = "change_state"
+)
+
 // order by field possible values
 const (
-	UserIDField OrderByField = "UserID"
+	CreationDateField OrderByField = "CreationDate"
+	UpdateDateField   OrderByField = "UpdateDate"
+)
+
+// sort order possible values
+const (
+	Ascending  SortOrder = "asc"
+	Descending SortOrder = "desc"
 )
 
 func (e *ValidationError) addErrorKB(kb string) {
@@ -133,6 +187,7 @@ func buildNewKB(newKB NewKB) KB {
 		Content:      newKB.Content,
 		EventID:      newKB.EventID,
 		CreationDate: time.Now().UTC().Unix(),
+		State:        Draft,
 	}
 }
 
@@ -167,12 +222,26 @@ func validKBToUpdate(kb UpdateKB) error {
 }
 
 func (q QueryFilter) isInvalid() bool {
-	return false
+	if q.State != EmptyState && !q.State.IsValid() {
+		return true
+	}
+
+	if q.OrderBy != EmptyOrderByField && !q.OrderBy.IsValid() {
+		return true
+	}
+
+	return q.Order != EmptySortOrder && !q.Order.IsValid()
 }
 
+// fillDefaultValues sets the first page of the newest kbs if the filter
+// doesn't say otherwise.
 func (q *QueryFilter) fillDefaultValues() {
 	if q.OrderBy == EmptyOrderByField {
-		q.OrderBy = UserIDField
+		q.OrderBy = CreationDateField
+	}
+
+	if q.Order == EmptySortOrder {
+		q.Order = Descending
 	}
 
 	if q.PageNumber == 0 {
@@ -196,6 +265,31 @@ func newGetKBWithIDResult(kb *KB, err error) GetKBWithIDResult {
 	}
 }
 
+// Failed returns true if the kb could not be read.
+func (r GetKBWithIDResult) Failed() bool {
+	return r.Err != ""
+}
+
+// Failed returns true if the kb could not be created.
+func (r CreateKBResult) Failed() bool {
+	return r.Err != ""
+}
+
+// Failed returns true if the kb could not be updated.
+func (r UpdateKBResult) Failed() bool {
+	return r.Err != ""
+}
+
+// Failed returns true if the kb could not be deleted.
+func (r DeleteKBResult) Failed() bool {
+	return r.Err != ""
+}
+
+// Failed returns true if the kbs could not be searched.
+func (r SearchKBsDataResult) Failed() bool {
+	return r.Err != ""
+}
+
 // newCreateKBResult create a new CreateKBResponse
 func newCreateKBResult(id KBID, err error) CreateKBResult {
 	var errkb string
@@ -261,3 +355,75 @@ func (u UpdateKB) UpdateDateString() string {
 func (u *UpdateKB) fillUpdateTime() {
 	u.UpdateDate = time.Now().UTC().Unix()
 }
+
+// applyTo returns the given kb with the changes of the update.
+func (u UpdateKB) applyTo(kb KB) KB {
+	kb.UserID = u.UserID
+	kb.UserName = u.UserName
+	kb.Content = u.Content
+	kb.EventID = u.EventID
+	kb.UpdateDate = u.UpdateDate
+
+	return kb
+}
+
+func (a Action) String() string {
+	return string(a)
+}
+
+func (o OrderByField) String() string {
+	return string(o)
+}
+
+// IsValid returns true if kbs can be ordered by the field.
+func (o OrderByField) IsValid() bool {
+	return o == CreationDateField || o == UpdateDateField
+}
+
+func (s SortOrder) String() string {
+	return string(s)
+}
+
+func (s SortOrder) IsValid() bool {
+	return s == Ascending || s == Descending
+}
+
+// LogValue logs the kb without its content, only its length.
+func (k KB) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("id", k.ID.String()),
+		slog.String("user_id", k.UserID.String()),
+		slog.String("event_id", k.EventID.String()),
+		slog.String("state", k.State.String()),
+		slog.Int("content_length", len(k.Content)),
+	)
+}
+
+// LogValue logs the new kb without its content, only its length.
+func (n NewKB) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("user_id", n.UserID.String()),
+		slog.String("event_id", n.EventID.String()),
+		slog.Int("content_length", len(n.Content)),
+	)
+}
+
+// LogValue logs the kb update without its content, only its length.
+func (u UpdateKB) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("id", u.ID.String()),
+		slog.String("user_id", u.UserID.String()),
+		slog.String("event_id", u.EventID.String()),
+		slog.Int("content_length", len(u.Content)),
+	)
+}
+
+// LogValue logs the size of the result instead of the kbs found.
+func (s SearchKBsResult) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.Int("kbs", len(s.KBs)),
+		slog.Int("total", s.Total),
+		slog.Int("page", int(s.Page)),
+		slog.Int("rows_per_page", int(s.RowsPerPage)),
+	)
+}
@@ -0,0 +1,25 @@
+package kbs_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/kbs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryRequiresEventOrUser(t *testing.T) {
+	// Given
+	ctx := context.TODO()
+	service := kbs.NewService(kbs.ServiceSetup{
+		Storer: newMemoryStore(),
+		Logger: newDummyLogger(),
+	})
+
+	// When
+	result, err := service.Query(ctx, kbs.QueryFilter{State: kbs.Published})
+
+	// Then
+	assert.ErrorIs(t, err, kbs.ErrMissingQueryFilter)
+	assert.Empty(t, result.KBs)
+}
@@ -5,6 +5,8 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/requests"
 )
 
 // Storer defines persistence behavior
@@ -16,51 +18,139 @@ type Storer interface {
 	// QueryByID find and return a kb with the given id.
 	// If kb does not exist it returns a nil kb and nil error.
 	QueryByID(ctx context.Context, id KBID) (*KB, error)
+	// ChangeState moves the kb to another state only if it is still in
+	// the state the change comes from.
+	ChangeState(ctx context.Context, change StateChange) error
+}
+
+// ErrStoreUnavailable is wrapped by the errors of stores that failed for a
+// reason that may go away, e.g. throttling, so the call can be retried.
+var ErrStoreUnavailable = errors.New("kb store is temporarily unavailable")
+
+// ErrMissingQueryFilter is returned when kbs are queried without an event
+// or a user, every store reads kbs by one of them.
+var ErrMissingQueryFilter = errors.New("kbs must be queried by event or by user")
+
+// PathFinder resolves where kbs are located inside the spaces hierarchy.
+type PathFinder interface {
+	// FindPaths returns the breadcrumb path of each given kb. KBs that
+	// don't belong to any collection are not included in the result.
+	FindPaths(ctx context.Context, ids []KBID) (map[KBID][]Breadcrumb, error)
+}
+
+// PlacementRemover removes kbs from the spaces hierarchy.
+type PlacementRemover interface {
+	// RemovePlacement removes the kb from its collection, it does nothing
+	// if the kb is not placed in any collection.
+	RemovePlacement(ctx context.Context, id KBID) error
+}
+
+// EventValidator verifies the event kbs are written for.
+type EventValidator interface {
+	// ValidateEvent returns an error if the event does not exist or
+	// kbs cannot be written for it anymore.
+	ValidateEvent(ctx context.Context, id EventID) error
+}
+
+// NameResolver resolves the current name of kb authors.
+type NameResolver interface {
+	// ResolveNames returns the name of each given user. Unknown users
+	// are not included in the result.
+	ResolveNames(ctx context.Context, ids []UserID) (map[UserID]string, error)
+}
+
+// CommentCounter counts the comments written about kbs.
+type CommentCounter interface {
+	// CountComments returns the number of comments of each given kb.
+	CountComments(ctx context.Context, ids []KBID) (map[KBID]int, error)
+}
+
+// Auditor records the changes made to kbs.
+type Auditor interface {
+	Record(ctx context.Context, record AuditRecord) error
 }
 
 // ServiceSetup contains service metadata.
 type ServiceSetup struct {
-	Storer Storer
-	Logger *slog.Logger
+	Storer           Storer
+	PathFinder       PathFinder
+	PlacementRemover PlacementRemover
+	EventValidator   EventValidator
+	NameResolver     NameResolver
+	Publisher        Publisher
+	CommentCounter   CommentCounter
+	Auditor          Auditor
+	Logger           *slog.Logger
 }
 
 // Service implements kbs business logic.
 type Service struct {
-	storer Storer
-	logger *slog.Logger
+	storer           Storer
+	pathFinder       PathFinder
+	placementRemover PlacementRemover
+	eventValidator   EventValidator
+	nameResolver     NameResolver
+	publisher        Publisher
+	commentCounter   CommentCounter
+	auditor          Auditor
+	logger           *slog.Logger
 }
 
 var (
-	errSaveKB    = errors.New("unable to save kb in the repository")
-	errQueryKB   = errors.New("unable to query kb")
-	errQueryKBs  = errors.New("unable to query kbs")
-	errDeleteKB  = errors.New("unable to delete kb")
-	errUpdateKB  = errors.New("unable to update kb in the repository")
-	errEmptyKBID = errors.New("kb id cannot be empty")
+	errSaveKB       = errors.New("unable to save kb in the repository")
+	errQueryKB      = errors.New("unable to query kb")
+	errQueryKBs     = errors.New("unable to query kbs")
+	errDeleteKB     = errors.New("unable to delete kb")
+	errAuditKB      = errors.New("unable to record kb change in the audit log")
+	errUpdateKB     = errors.New("unable to update kb in the repository")
+	errEmptyKBID    = errors.New("kb id cannot be empty")
+	errEmptyEventID = errors.New("event id cannot be empty")
 )
 
 // NewService create a new kbs service.
 func NewService(settings ServiceSetup) *Service {
 	newService := Service{
-		logger: settings.Logger,
-		storer: settings.Storer,
+		logger:           settings.Logger,
+		storer:           settings.Storer,
+		pathFinder:       settings.PathFinder,
+		placementRemover: settings.PlacementRemover,
+		eventValidator:   settings.EventValidator,
+		nameResolver:     settings.NameResolver,
+		publisher:        settings.Publisher,
+		commentCounter:   settings.CommentCounter,
+		auditor:          settings.Auditor,
 	}
 
 	return &newService
 }
 
 // Create create a kb and store it in a database.
-func (s *Service) Create(ctx context.Context, newKB NewKB) (KBID, error) {
+func (s *Service) Create(ctx context.Context, newKB NewKB) (_ KBID, err error) {
+	ctx, span := startSpan(ctx, "Create", eventAttribute(newKB.EventID))
+	defer func() { endSpan(span, err) }()
+
+	logger := requests.Logger(ctx, s.logger)
+
+	err = s.validateEvent(ctx, newKB.EventID)
+	if err != nil {
+		return EmptyKBID, fmt.Errorf("unable to create kb: %w", err)
+	}
+
 	kb := buildNewKB(newKB)
 
-	err := s.storer.Save(ctx, kb)
+	err = s.audit(ctx, AuditRecord{Action: CreateAction, KBID: kb.ID, After: &kb})
 	if err != nil {
-		s.logger.Error("unable to create kb", slog.String("error", err.Error()))
+		return EmptyKBID, err
+	}
+
+	err = s.storer.Save(ctx, kb)
+	if err != nil {
+		logger.Error("unable to create kb", slog.String("error", err.Error()))
 
 		return EmptyKBID, errSaveKB
 	}
 
-	s.logger.Debug(
+	logger.Debug(
 		"kb was created",
 		slog.String("id", kb.ID.String()),
 	)
@@ -68,18 +158,82 @@ func (s *Service) Create(ctx context.Context, newKB NewKB) (KBID, error) {
 	return kb.ID, nil
 }
 
+// Import stores a kb that was exported keeping its id and dates. Its event
+// is validated and it is audited as a creation, like the kbs created by
+// the users. It returns false if a kb with the same id already exists.
+func (s *Service) Import(ctx context.Context, kb KB) (_ bool, err error) {
+	ctx, span := startSpan(ctx, "Import", kbAttribute(kb.ID), eventAttribute(kb.EventID))
+	defer func() { endSpan(span, err) }()
+
+	logger := requests.Logger(ctx, s.logger)
+
+	current, err := s.storedKB(ctx, kb.ID)
+	if err != nil {
+		return false, err
+	}
+
+	if current != nil {
+		return false, nil
+	}
+
+	err = s.validateEvent(ctx, kb.EventID)
+	if err != nil {
+		return false, fmt.Errorf("unable to import kb: %w", err)
+	}
+
+	err = s.audit(ctx, AuditRecord{Action: CreateAction, KBID: kb.ID, After: &kb})
+	if err != nil {
+		return false, err
+	}
+
+	err = s.storer.Save(ctx, kb)
+	if err != nil {
+		logger.Error("unable to import kb",
+			slog.String("id", kb.ID.String()),
+			slog.String("error", err.Error()))
+
+		return false, errSaveKB
+	}
+
+	return true, nil
+}
+
 // Update update a kb in a database.
-func (s *Service) Update(ctx context.Context, kb UpdateKB) error {
-	err := validKBToUpdate(kb)
+func (s *Service) Update(ctx context.Context, kb UpdateKB) (err error) {
+	ctx, span := startSpan(ctx, "Update", kbAttribute(kb.ID))
+	defer func() { endSpan(span, err) }()
+
+	logger := requests.Logger(ctx, s.logger)
+
+	err = validKBToUpdate(kb)
 	if err != nil {
 		return fmt.Errorf("unable to update kb: %w", err)
 	}
 
 	kb.fillUpdateTime()
 
+	if s.auditor != nil {
+		before, err := s.storedKB(ctx, kb.ID)
+		if err != nil {
+			return errUpdateKB
+		}
+
+		var after KB
+		if before != nil {
+			after = kb.applyTo(*before)
+		} else {
+			after = kb.applyTo(KB{ID: kb.ID})
+		}
+
+		err = s.audit(ctx, AuditRecord{Action: UpdateAction, KBID: kb.ID, Before: before, After: &after})
+		if err != nil {
+			return err
+		}
+	}
+
 	err = s.storer.Update(ctx, kb)
 	if err != nil {
-		s.logger.Error("unable to update kb", slog.String("error", err.Error()))
+		logger.Error("unable to update kb", slog.String("error", err.Error()))
 
 		return errUpdateKB
 	}
@@ -87,14 +241,34 @@ func (s *Service) Update(ctx context.Context, kb UpdateKB) error {
 	return nil
 }
 
-func (s *Service) QueryByID(ctx context.Context, id KBID) (*KB, error) {
+func (s *Service) QueryByID(ctx context.Context, id KBID) (_ *KB, err error) {
+	ctx, span := startSpan(ctx, "QueryByID", kbAttribute(id))
+	defer func() { endSpan(span, err) }()
+
+	kb, err := s.storedKB(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if kb != nil {
+		s.enrich(ctx, []*KB{kb})
+	}
+
+	return kb, nil
+}
+
+// storedKB returns the kb as it is in the store, without the data owned
+// by other services.
+func (s *Service) storedKB(ctx context.Context, id KBID) (*KB, error) {
+	logger := requests.Logger(ctx, s.logger)
+
 	if id == EmptyKBID {
 		return nil, errEmptyKBID
 	}
 
 	kb, err := s.storer.QueryByID(ctx, id)
 	if err != nil {
-		s.logger.Error(
+		logger.Error(
 			"unable to query kb by id",
 			slog.String("id", fmt.Sprintf("%+v", id)),
 			slog.String("error", err.Error()))
@@ -106,14 +280,19 @@ func (s *Service) QueryByID(ctx context.Context, id KBID) (*KB, error) {
 }
 
 // Delete detele a kb from database.
-func (s *Service) Delete(ctx context.Context, id KBID) error {
-	kb, err := s.QueryByID(ctx, id)
+func (s *Service) Delete(ctx context.Context, id KBID) (err error) {
+	ctx, span := startSpan(ctx, "Delete", kbAttribute(id))
+	defer func() { endSpan(span, err) }()
+
+	logger := requests.Logger(ctx, s.logger)
+
+	kb, err := s.storedKB(ctx, id)
 	if err != nil {
 		return errDeleteKB
 	}
 
 	if kb == nil {
-		s.logger.Info(
+		logger.Info(
 			"unable to delete kb cause it does not exist",
 			slog.String("id", fmt.Sprintf("%+v", id)),
 		)
@@ -121,9 +300,22 @@ func (s *Service) Delete(ctx context.Context, id KBID) error {
 		return nil
 	}
 
+	err = s.audit(ctx, AuditRecord{Action: DeleteAction, KBID: kb.ID, Before: kb})
+	if err != nil {
+		return err
+	}
+
+	// the placement goes first, if the kb can't be deleted afterwards it
+	// only leaves its collection instead of leaving a placement of a
+	// missing kb that keeps the collection from being deleted.
+	err = s.removePlacement(ctx, id)
+	if err != nil {
+		return errDeleteKB
+	}
+
 	err = s.storer.Delete(ctx, *kb)
 	if err != nil {
-		s.logger.Error("unable to delete kb",
+		logger.Error("unable to delete kb",
 			slog.String("id", fmt.Sprintf("%+v", id)),
 			slog.String("error", err.Error()))
 
@@ -133,20 +325,29 @@ func (s *Service) Delete(ctx context.Context, id KBID) error {
 	return nil
 }
 
-func (s *Service) Query(ctx context.Context, filter QueryFilter) (SearchKBsResult, error) {
-	s.logger.Debug("querying kb on kbs.Service")
+func (s *Service) Query(ctx context.Context, filter QueryFilter) (_ SearchKBsResult, err error) {
+	ctx, span := startSpan(ctx, "Query")
+	defer func() { endSpan(span, err) }()
+
+	logger := requests.Logger(ctx, s.logger)
+
+	logger.Debug("querying kb on kbs.Service")
 
 	if filter.isInvalid() {
-		s.logger.Debug("filter is invalid", slog.String("data", fmt.Sprintf("%+v", filter)))
+		logger.Debug("filter is invalid", slog.String("data", fmt.Sprintf("%+v", filter)))
 
 		return SearchKBsResult{}, nil
 	}
 
+	if filter.EventID == "" && filter.UserID == "" {
+		return SearchKBsResult{}, ErrMissingQueryFilter
+	}
+
 	filter.fillDefaultValues()
 
 	result, err := s.storer.Query(ctx, filter)
 	if err != nil {
-		s.logger.Error(
+		logger.Error(
 			"unable to query kbs by filter",
 			slog.String("filter", fmt.Sprintf("%+v", filter)),
 			slog.String("error", err.Error()))
@@ -154,5 +355,169 @@ func (s *Service) Query(ctx context.Context, filter QueryFilter) (SearchKBsResul
 		return SearchKBsResult{}, errQueryKB
 	}
 
+	kbsFound := make([]*KB, len(result.KBs))
+	for i := range result.KBs {
+		kbsFound[i] = &result.KBs[i]
+	}
+
+	s.enrich(ctx, kbsFound)
+
 	return result, nil
 }
+
+// validateEvent verifies the event of a new kb if an event validator was given.
+func (s *Service) validateEvent(ctx context.Context, id EventID) error {
+	logger := requests.Logger(ctx, s.logger)
+
+	if s.eventValidator == nil {
+		return nil
+	}
+
+	if id == "" {
+		return errEmptyEventID
+	}
+
+	err := s.eventValidator.ValidateEvent(ctx, id)
+	if err != nil {
+		logger.Debug("kb event is not valid",
+			slog.String("event_id", id.String()),
+			slog.String("error", err.Error()))
+
+		return err
+	}
+
+	return nil
+}
+
+// removePlacement removes the kb from its collection if a placement remover
+// was given.
+func (s *Service) removePlacement(ctx context.Context, id KBID) error {
+	logger := requests.Logger(ctx, s.logger)
+
+	if s.placementRemover == nil {
+		return nil
+	}
+
+	err := s.placementRemover.RemovePlacement(ctx, id)
+	if err != nil {
+		logger.Error("unable to remove kb from its collection",
+			slog.String("id", id.String()),
+			slog.String("error", err.Error()))
+
+		return err
+	}
+
+	return nil
+}
+
+// audit records the given change if an auditor was given. It is called
+// before the change is stored and the change is not stored if it fails, so
+// no change is stored without its entry. If the store fails afterwards the
+// entry records a change that was attempted.
+func (s *Service) audit(ctx context.Context, record AuditRecord) error {
+	logger := requests.Logger(ctx, s.logger)
+
+	if s.auditor == nil {
+		return nil
+	}
+
+	err := s.auditor.Record(ctx, record)
+	if err != nil {
+		logger.Error("unable to record kb change in the audit log",
+			slog.String("id", record.KBID.String()),
+			slog.String("action", record.Action.String()),
+			slog.String("error", err.Error()))
+
+		return errAuditKB
+	}
+
+	return nil
+}
+
+// enrich completes the kbs read from the store with data owned by other services.
+func (s *Service) enrich(ctx context.Context, kbsFound []*KB) {
+	s.addUserNames(ctx, kbsFound)
+	s.addPaths(ctx, kbsFound)
+	s.addCommentCounts(ctx, kbsFound)
+}
+
+// addUserNames replaces the user name stored with each kb by the current
+// name of its author. A failure resolving names is logged but it doesn't
+// fail the query, kbs keep the stored user name.
+func (s *Service) addUserNames(ctx context.Context, kbsFound []*KB) {
+	logger := requests.Logger(ctx, s.logger)
+
+	if s.nameResolver == nil || len(kbsFound) == 0 {
+		return
+	}
+
+	ids := make([]UserID, len(kbsFound))
+	for i, kb := range kbsFound {
+		ids[i] = kb.UserID
+	}
+
+	names, err := s.nameResolver.ResolveNames(ctx, ids)
+	if err != nil {
+		logger.Error("unable to resolve user names", slog.String("error", err.Error()))
+
+		return
+	}
+
+	for _, kb := range kbsFound {
+		if name, ok := names[kb.UserID]; ok {
+			kb.UserName = name
+		}
+	}
+}
+
+// addPaths fills the breadcrumb path of the given kbs. A failure resolving
+// paths is logged but it doesn't fail the query, kbs are returned without path.
+func (s *Service) addPaths(ctx context.Context, kbsFound []*KB) {
+	logger := requests.Logger(ctx, s.logger)
+
+	if s.pathFinder == nil || len(kbsFound) == 0 {
+		return
+	}
+
+	ids := make([]KBID, len(kbsFound))
+	for i, kb := range kbsFound {
+		ids[i] = kb.ID
+	}
+
+	paths, err := s.pathFinder.FindPaths(ctx, ids)
+	if err != nil {
+		logger.Error("unable to find kb paths", slog.String("error", err.Error()))
+
+		return
+	}
+
+	for _, kb := range kbsFound {
+		kb.Path = paths[kb.ID]
+	}
+}
+
+// addCommentCounts fills the number of comments of the given kbs. A failure
+// counting comments is logged but it doesn't fail the query.
+func (s *Service) addCommentCounts(ctx context.Context, kbsFound []*KB) {
+	logger := requests.Logger(ctx, s.logger)
+
+	if s.commentCounter == nil || len(kbsFound) == 0 {
+		return
+	}
+
+	ids := make([]KBID, len(kbsFound))
+	for i, kb := range kbsFound {
+		ids[i] = kb.ID
+	}
+
+	counts, err := s.commentCounter.CountComments(ctx, ids)
+	if err != nil {
+		logger.Error("unable to count kb comments", slog.String("error", err.Error()))
+
+		return
+	}
+
+	for _, kb := range kbsFound {
+		kb.CommentCount = counts[kb.ID]
+	}
+}
@@ -0,0 +1,37 @@
+package kbs
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer creates the spans of the kbs service operations.
+var tracer = otel.Tracer("github.com/fernandoocampo/kb-store/apps/kbs/internal/kbs")
+
+// startSpan starts the span of the given service operation.
+func startSpan(ctx context.Context, operation string, attributes ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracer.Start(ctx, "kbs.Service."+operation, trace.WithAttributes(attributes...))
+}
+
+// endSpan ends the span and marks it as failed if the operation returned
+// an error.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
+
+func kbAttribute(id KBID) attribute.KeyValue {
+	return attribute.String("kb.id", id.String())
+}
+
+func eventAttribute(id EventID) attribute.KeyValue {
+	return attribute.String("kb.event_id", id.String())
+}
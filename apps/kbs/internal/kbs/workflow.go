@@ -0,0 +1,360 @@
+package kbs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/requests"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// State defines the stage of the kb lifecycle.
+type State string
+
+// Decision defines the outcome of a kb review.
+type Decision string
+
+// kb lifecycle states.
+const (
+	EmptyState = State("")
+	Draft      = State("draft")
+	InReview   = State("in_review")
+	Published  = State("published")
+	Archived   = State("archived")
+)
+
+// review decisions.
+const (
+	Approved = Decision("approved")
+	Rejected = Decision("rejected")
+)
+
+// transitions contains the states a kb can move to from each state.
+var transitions = map[State][]State{
+	Draft:     {InReview, Archived},
+	InReview:  {Published, Draft},
+	Published: {Archived},
+}
+
+// Review contains the decision a reviewer took about a kb.
+type Review struct {
+	ReviewerID UserID   `json:"reviewer_id"`
+	Decision   Decision `json:"decision"`
+	Comment    string   `json:"comment"`
+	Date       int64    `json:"date"`
+}
+
+// SubmitKB contains data to send a kb to review.
+type SubmitKB struct {
+	ID         KBID   `json:"id"`
+	ReviewerID UserID `json:"reviewer_id"`
+}
+
+// ReviewKB contains data to approve or reject a kb in review. The
+// reviewer is the verified principal of the request.
+type ReviewKB struct {
+	ID      KBID   `json:"id"`
+	Comment string `json:"comment"`
+}
+
+// StateChange contains the data to persist when a kb moves to another state.
+// The store must only apply it if the kb is still in the From state.
+type StateChange struct {
+	ID         KBID    `json:"id"`
+	From       State   `json:"from"`
+	To         State   `json:"to"`
+	ReviewerID UserID  `json:"reviewer_id,omitempty"`
+	Review     *Review `json:"review,omitempty"`
+	UpdateDate int64   `json:"update_date"`
+}
+
+// StateChanged is the event emitted every time a kb moves to another state.
+type StateChanged struct {
+	KBID    KBID   `json:"kb_id"`
+	From    State  `json:"from"`
+	To      State  `json:"to"`
+	ActorID UserID `json:"actor_id,omitempty"`
+	Comment string `json:"comment,omitempty"`
+	Date    int64  `json:"date"`
+}
+
+// Publisher emits kb lifecycle events.
+type Publisher interface {
+	Publish(ctx context.Context, event StateChanged) error
+}
+
+// ChangeStateResult standard response for kb state changes.
+type ChangeStateResult struct {
+	Err string
+}
+
+var (
+	errKBNotFound          = errors.New("kb does not exist")
+	errChangeState         = errors.New("unable to change kb state")
+	errEmptyReviewerID     = errors.New("reviewer id cannot be empty")
+	errUnverifiedReviewer  = errors.New("kbs can only be reviewed by a verified principal")
+	errEmptyRejectComment  = errors.New("a comment is required to reject a kb")
+	errNotAssignedReviewer = errors.New("only the assigned reviewer can review the kb")
+)
+
+// InvalidTransitionError is returned when a kb cannot move to the requested state.
+type InvalidTransitionError struct {
+	From State
+	To   State
+}
+
+func (e *InvalidTransitionError) Error() string {
+	return fmt.Sprintf("kb cannot move from %q to %q", e.From, e.To)
+}
+
+// CanMoveTo returns true if a kb in this state can move to the given one.
+func (s State) CanMoveTo(to State) bool {
+	for _, v := range transitions[s] {
+		if v == to {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsValid returns true if the state is one of the lifecycle states.
+func (s State) IsValid() bool {
+	switch s {
+	case Draft, InReview, Published, Archived:
+		return true
+	}
+
+	return false
+}
+
+func (s State) String() string {
+	return string(s)
+}
+
+// Submit sends a draft kb to review and assigns its reviewer.
+func (s *Service) Submit(ctx context.Context, submit SubmitKB) error {
+	if submit.ReviewerID == "" {
+		return fmt.Errorf("unable to submit kb: %w", errEmptyReviewerID)
+	}
+
+	change := StateChange{
+		ID:         submit.ID,
+		To:         InReview,
+		ReviewerID: submit.ReviewerID,
+	}
+
+	return s.changeState(ctx, change, nil)
+}
+
+// Approve publishes a kb in review, only its assigned reviewer can.
+func (s *Service) Approve(ctx context.Context, review ReviewKB) error {
+	reviewerID := reviewerOf(ctx)
+	if reviewerID == "" {
+		return fmt.Errorf("unable to approve kb: %w", errUnverifiedReviewer)
+	}
+
+	change := StateChange{
+		ID: review.ID,
+		To: Published,
+		Review: &Review{
+			ReviewerID: reviewerID,
+			Decision:   Approved,
+			Comment:    review.Comment,
+		},
+	}
+
+	return s.changeState(ctx, change, assignedReviewer(reviewerID))
+}
+
+// Reject sends a kb in review back to draft, only its assigned reviewer
+// can.
+func (s *Service) Reject(ctx context.Context, review ReviewKB) error {
+	reviewerID := reviewerOf(ctx)
+	if reviewerID == "" {
+		return fmt.Errorf("unable to reject kb: %w", errUnverifiedReviewer)
+	}
+
+	if review.Comment == "" {
+		return fmt.Errorf("unable to reject kb: %w", errEmptyRejectComment)
+	}
+
+	change := StateChange{
+		ID: review.ID,
+		To: Draft,
+		Review: &Review{
+			ReviewerID: reviewerID,
+			Decision:   Rejected,
+			Comment:    review.Comment,
+		},
+	}
+
+	return s.changeState(ctx, change, assignedReviewer(reviewerID))
+}
+
+// reviewerOf returns the verified principal of the request, the reviewer
+// given in a request body can't be trusted.
+func reviewerOf(ctx context.Context) UserID {
+	return UserID(requests.FromContext(ctx).Principal)
+}
+
+// Archive retires a kb.
+func (s *Service) Archive(ctx context.Context, id KBID) error {
+	change := StateChange{
+		ID: id,
+		To: Archived,
+	}
+
+	return s.changeState(ctx, change, nil)
+}
+
+// assignedReviewer returns a check that fails if the given user is not the
+// reviewer assigned to the kb.
+func assignedReviewer(reviewerID UserID) func(kb *KB) error {
+	return func(kb *KB) error {
+		if reviewerID == "" {
+			return errEmptyReviewerID
+		}
+
+		if kb.ReviewerID != reviewerID {
+			return errNotAssignedReviewer
+		}
+
+		return nil
+	}
+}
+
+// changeState moves the kb to the state of the given change if the
+// transition is allowed and the check passes, then emits the event.
+func (s *Service) changeState(ctx context.Context, change StateChange, check func(kb *KB) error) (err error) {
+	ctx, span := startSpan(ctx, "ChangeState", kbAttribute(change.ID), attribute.String("kb.state.to", change.To.String()))
+	defer func() { endSpan(span, err) }()
+
+	logger := requests.Logger(ctx, s.logger)
+
+	if change.ID == EmptyKBID {
+		return errEmptyKBID
+	}
+
+	kb, err := s.storer.QueryByID(ctx, change.ID)
+	if err != nil {
+		logger.Error("unable to query kb to change its state",
+			slog.String("id", change.ID.String()),
+			slog.String("error", err.Error()))
+
+		return errChangeState
+	}
+
+	if kb == nil {
+		return errKBNotFound
+	}
+
+	if !kb.State.CanMoveTo(change.To) {
+		return &InvalidTransitionError{From: kb.State, To: change.To}
+	}
+
+	if check != nil {
+		err = check(kb)
+		if err != nil {
+			return err
+		}
+	}
+
+	now := time.Now().UTC().Unix()
+	change.From = kb.State
+	change.UpdateDate = now
+
+	if change.Review != nil {
+		change.Review.Date = now
+	}
+
+	after := change.applyTo(*kb)
+
+	err = s.audit(ctx, AuditRecord{Action: ChangeStateAction, KBID: kb.ID, Before: kb, After: &after})
+	if err != nil {
+		return err
+	}
+
+	err = s.storer.ChangeState(ctx, change)
+	if err != nil {
+		logger.Error("unable to change kb state",
+			slog.String("id", change.ID.String()),
+			slog.String("to", change.To.String()),
+			slog.String("error", err.Error()))
+
+		return errChangeState
+	}
+
+	s.publish(ctx, change.toEvent())
+
+	return nil
+}
+
+// publish emits the given event if a publisher was given. A failure is
+// logged but it doesn't fail the transition, it was already stored. Dry
+// runs don't emit events, the transition didn't happen.
+func (s *Service) publish(ctx context.Context, event StateChanged) {
+	logger := requests.Logger(ctx, s.logger)
+
+	if s.publisher == nil || requests.IsDryRun(ctx) {
+		return
+	}
+
+	err := s.publisher.Publish(ctx, event)
+	if err != nil {
+		logger.Error("unable to publish kb state change",
+			slog.String("id", event.KBID.String()),
+			slog.String("error", err.Error()))
+	}
+}
+
+// applyTo returns the given kb after the state change.
+func (c StateChange) applyTo(kb KB) KB {
+	kb.State = c.To
+	kb.UpdateDate = c.UpdateDate
+
+	if c.ReviewerID != "" {
+		kb.ReviewerID = c.ReviewerID
+	}
+
+	if c.Review != nil {
+		kb.Reviews = append(append([]Review{}, kb.Reviews...), *c.Review)
+	}
+
+	return kb
+}
+
+func (c StateChange) toEvent() StateChanged {
+	event := StateChanged{
+		KBID: c.ID,
+		From: c.From,
+		To:   c.To,
+		Date: c.UpdateDate,
+	}
+
+	if c.Review != nil {
+		event.ActorID = c.Review.ReviewerID
+		event.Comment = c.Review.Comment
+	}
+
+	return event
+}
+
+// Failed returns true if the kb state could not be changed.
+func (r ChangeStateResult) Failed() bool {
+	return r.Err != ""
+}
+
+// newChangeStateResult create a new ChangeStateResult
+func newChangeStateResult(err error) ChangeStateResult {
+	var errkb string
+	if err != nil {
+		errkb = err.Error()
+	}
+	return ChangeStateResult{
+		Err: errkb,
+	}
+}
@@ -0,0 +1,197 @@
+package kbs_test
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/kbs"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/requests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReviewWorkflow(t *testing.T) {
+	// Given
+	ctx := context.TODO()
+	store := newMemoryStore()
+	publisher := new(memoryPublisher)
+	service := kbs.NewService(kbs.ServiceSetup{
+		Storer:    store,
+		Publisher: publisher,
+		Logger:    newDummyLogger(),
+	})
+
+	kbID, err := service.Create(ctx, kbs.NewKB{UserID: "drila", Content: "runbook", EventID: "1"})
+	require.NoError(t, err)
+
+	// When
+	errSubmit := service.Submit(ctx, kbs.SubmitKB{ID: kbID, ReviewerID: "mono"})
+	errReject := service.Reject(as(ctx, "mono"), kbs.ReviewKB{ID: kbID, Comment: "add an example"})
+	errResubmit := service.Submit(ctx, kbs.SubmitKB{ID: kbID, ReviewerID: "mono"})
+	errApprove := service.Approve(as(ctx, "mono"), kbs.ReviewKB{ID: kbID})
+
+	// Then
+	assert.NoError(t, errSubmit)
+	assert.NoError(t, errReject)
+	assert.NoError(t, errResubmit)
+	assert.NoError(t, errApprove)
+
+	got := store.kbs[kbID]
+	assert.Equal(t, kbs.Published, got.State)
+	assert.Len(t, got.Reviews, 2)
+	assert.Equal(t, kbs.Rejected, got.Reviews[0].Decision)
+	assert.Equal(t, kbs.Approved, got.Reviews[1].Decision)
+
+	var transitions [][2]kbs.State
+	for _, event := range publisher.events {
+		transitions = append(transitions, [2]kbs.State{event.From, event.To})
+	}
+	assert.Equal(t, [][2]kbs.State{
+		{kbs.Draft, kbs.InReview},
+		{kbs.InReview, kbs.Draft},
+		{kbs.Draft, kbs.InReview},
+		{kbs.InReview, kbs.Published},
+	}, transitions)
+}
+
+func TestInvalidTransitions(t *testing.T) {
+	cases := map[string]struct {
+		state  kbs.State
+		change func(ctx context.Context, service *kbs.Service, id kbs.KBID) error
+	}{
+		"approve_draft": {
+			state: kbs.Draft,
+			change: func(ctx context.Context, service *kbs.Service, id kbs.KBID) error {
+				return service.Approve(as(ctx, "mono"), kbs.ReviewKB{ID: id})
+			},
+		},
+		"submit_published": {
+			state: kbs.Published,
+			change: func(ctx context.Context, service *kbs.Service, id kbs.KBID) error {
+				return service.Submit(ctx, kbs.SubmitKB{ID: id, ReviewerID: "mono"})
+			},
+		},
+		"archive_archived": {
+			state: kbs.Archived,
+			change: func(ctx context.Context, service *kbs.Service, id kbs.KBID) error {
+				return service.Archive(ctx, id)
+			},
+		},
+		"approve_not_assigned_reviewer": {
+			state: kbs.InReview,
+			change: func(ctx context.Context, service *kbs.Service, id kbs.KBID) error {
+				return service.Approve(as(ctx, "drila"), kbs.ReviewKB{ID: id})
+			},
+		},
+		"reject_without_comment": {
+			state: kbs.InReview,
+			change: func(ctx context.Context, service *kbs.Service, id kbs.KBID) error {
+				return service.Reject(as(ctx, "mono"), kbs.ReviewKB{ID: id})
+			},
+		},
+		"approve_without_principal": {
+			state: kbs.InReview,
+			change: func(ctx context.Context, service *kbs.Service, id kbs.KBID) error {
+				return service.Approve(ctx, kbs.ReviewKB{ID: id})
+			},
+		},
+		"reject_without_principal": {
+			state: kbs.InReview,
+			change: func(ctx context.Context, service *kbs.Service, id kbs.KBID) error {
+				return service.Reject(ctx, kbs.ReviewKB{ID: id, Comment: "add an example"})
+			},
+		},
+	}
+
+	for name, data := range cases {
+		t.Run(name, func(st *testing.T) {
+			// Given
+			ctx := context.TODO()
+			store := newMemoryStore()
+			store.kbs["kb-1"] = kbs.KB{ID: "kb-1", State: data.state, ReviewerID: "mono"}
+			publisher := new(memoryPublisher)
+			service := kbs.NewService(kbs.ServiceSetup{
+				Storer:    store,
+				Publisher: publisher,
+				Logger:    newDummyLogger(),
+			})
+
+			// When
+			err := data.change(ctx, service, "kb-1")
+
+			// Then
+			assert.Error(st, err)
+			assert.Equal(st, data.state, store.kbs["kb-1"].State)
+			assert.Empty(st, publisher.events)
+		})
+	}
+}
+
+// as returns the context of a request made by the given principal.
+func as(ctx context.Context, principal string) context.Context {
+	return requests.NewContext(ctx, requests.Metadata{Principal: principal})
+}
+
+type memoryStore struct {
+	kbs map[kbs.KBID]kbs.KB
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{
+		kbs: make(map[kbs.KBID]kbs.KB),
+	}
+}
+
+func (m *memoryStore) Save(ctx context.Context, newKB kbs.KB) error {
+	m.kbs[newKB.ID] = newKB
+	return nil
+}
+
+func (m *memoryStore) Update(ctx context.Context, kb kbs.UpdateKB) error {
+	return nil
+}
+
+func (m *memoryStore) Delete(ctx context.Context, kb kbs.KB) error {
+	delete(m.kbs, kb.ID)
+	return nil
+}
+
+func (m *memoryStore) Query(ctx context.Context, filter kbs.QueryFilter) (kbs.SearchKBsResult, error) {
+	return kbs.SearchKBsResult{}, nil
+}
+
+func (m *memoryStore) QueryByID(ctx context.Context, id kbs.KBID) (*kbs.KB, error) {
+	kb, ok := m.kbs[id]
+	if !ok {
+		return nil, nil
+	}
+	return &kb, nil
+}
+
+func (m *memoryStore) ChangeState(ctx context.Context, change kbs.StateChange) error {
+	kb := m.kbs[change.ID]
+	kb.State = change.To
+	if change.ReviewerID != "" {
+		kb.ReviewerID = change.ReviewerID
+	}
+	if change.Review != nil {
+		kb.Reviews = append(kb.Reviews, *change.Review)
+	}
+	m.kbs[change.ID] = kb
+	return nil
+}
+
+type memoryPublisher struct {
+	events []kbs.StateChanged
+}
+
+func (m *memoryPublisher) Publish(ctx context.Context, event kbs.StateChanged) error {
+	m.events = append(m.events, event)
+	return nil
+}
+
+func newDummyLogger() *slog.Logger {
+	return slog.New(slog.NewJSONHandler(os.Stdout, nil))
+}
@@ -0,0 +1,81 @@
+package migration
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// FileCheckpoints keeps the checkpoint of a copy in a json file.
+type FileCheckpoints struct {
+	path string
+}
+
+// NewFileCheckpoints keeps the checkpoint in the file with the given path.
+func NewFileCheckpoints(path string) *FileCheckpoints {
+	return &FileCheckpoints{path: path}
+}
+
+// LoadCheckpoint reads the checkpoint of the file, it is empty if the file
+// does not exist.
+func (f *FileCheckpoints) LoadCheckpoint(ctx context.Context) (Checkpoint, error) {
+	var checkpoint Checkpoint
+
+	data, err := os.ReadFile(f.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return checkpoint, nil
+	}
+
+	if err != nil {
+		return checkpoint, err
+	}
+
+	err = json.Unmarshal(data, &checkpoint)
+	if err != nil {
+		return checkpoint, err
+	}
+
+	return checkpoint, nil
+}
+
+// SaveCheckpoint replaces the file, the checkpoint is written to a
+// temporary file first so a crash never leaves half a checkpoint.
+func (f *FileCheckpoints) SaveCheckpoint(ctx context.Context, checkpoint Checkpoint) error {
+	data, err := json.Marshal(checkpoint)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(f.path), filepath.Base(f.path)+".*")
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Close()
+	} else {
+		tmp.Close()
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), f.path)
+}
+
+// Reset removes the checkpoint, the next copy starts over.
+func (f *FileCheckpoints) Reset() error {
+	err := os.Remove(f.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+
+	return err
+}
@@ -0,0 +1,73 @@
+package migration
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/kbs"
+)
+
+// digestedKB contains the fields of a kb that are digested, stores differ
+// in how they keep empty reviews, so they are always nil.
+type digestedKB struct {
+	ID           kbs.KBID     `json:"id"`
+	UserID       kbs.UserID   `json:"user_id"`
+	UserName     string       `json:"username"`
+	EventID      kbs.EventID  `json:"event_id"`
+	CreationDate int64        `json:"creation_date"`
+	UpdateDate   int64        `json:"update_date"`
+	State        kbs.State    `json:"state"`
+	ReviewerID   kbs.UserID   `json:"reviewer_id"`
+	Reviews      []kbs.Review `json:"reviews"`
+	Content      string       `json:"content"`
+}
+
+// digest returns the sha256 of the stored fields of the kb.
+func digest(kb kbs.KB) [sha256.Size]byte {
+	fields := digestedKB{
+		ID:           kb.ID,
+		UserID:       kb.UserID,
+		UserName:     kb.UserName,
+		EventID:      kb.EventID,
+		CreationDate: kb.CreationDate,
+		UpdateDate:   kb.UpdateDate,
+		State:        kb.State,
+		ReviewerID:   kb.ReviewerID,
+		Content:      kb.Content,
+	}
+
+	if len(kb.Reviews) > 0 {
+		fields.Reviews = kb.Reviews
+	}
+
+	// the fields are plain values, they always marshal.
+	data, _ := json.Marshal(fields)
+
+	return sha256.Sum256(data)
+}
+
+// checksum returns the number of kbs of the scanner and the xor of their
+// digests, which doesn't depend on the order of the scan.
+func checksum(ctx context.Context, scanner KBScanner) (int, string, error) {
+	var sum [sha256.Size]byte
+	var count int
+
+	err := scanner.ScanKBs(ctx, func(kb kbs.KB) error {
+		kbDigest := digest(kb)
+
+		for i := range sum {
+			sum[i] ^= kbDigest[i]
+		}
+
+		count++
+
+		return nil
+	})
+	if err != nil {
+		return 0, "", err
+	}
+
+	return count, hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,322 @@
+// Package migration copies the kbs of a store to another one, e.g. to move
+// them from dynamodb to the sql store, and verifies both hold the same kbs.
+package migration
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/kbs"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/requests"
+)
+
+// Setup contains migrator metadata.
+type Setup struct {
+	Source KBScanner
+	Target Target
+	// Checkpoints keeps the progress of the copy, optional. Without it
+	// every copy starts over.
+	Checkpoints Checkpointer
+	// Workers number of kbs copied at the same time.
+	Workers int
+	// CheckpointEvery number of copied kbs between checkpoints.
+	CheckpointEvery int
+	Logger          *slog.Logger
+}
+
+// Migrator copies the kbs of the source to the target.
+type Migrator struct {
+	source          KBScanner
+	target          Target
+	checkpoints     Checkpointer
+	workers         int
+	checkpointEvery int
+	logger          *slog.Logger
+}
+
+// outcome is what the copy of a kb did.
+type outcome int
+
+const (
+	copied outcome = iota
+	replaced
+	unchanged
+)
+
+// job is a kb of the source and its position in the scan.
+type job struct {
+	position int
+	kb       kbs.KB
+}
+
+// progress is the checkpoint of a copy, kbs are copied in any order but the
+// checkpoint only moves past the kbs copied without gaps.
+type progress struct {
+	mu         sync.Mutex
+	checkpoint Checkpoint
+	saved      int
+	done       map[int]kbs.KBID
+}
+
+const (
+	defaultWorkers         = 4
+	defaultCheckpointEvery = 100
+	// maxKBErrors is the number of kb errors a copy report keeps, the
+	// rest are only counted.
+	maxKBErrors = 100
+)
+
+var (
+	errCopy             = errors.New("unable to copy kbs")
+	errVerify           = errors.New("unable to verify kbs")
+	errScanOrderChanged = errors.New("source kbs are not scanned in the order of the checkpoint")
+)
+
+// New creates a migrator.
+func New(settings Setup) *Migrator {
+	newMigrator := Migrator{
+		source:          settings.Source,
+		target:          settings.Target,
+		checkpoints:     settings.Checkpoints,
+		workers:         settings.Workers,
+		checkpointEvery: settings.CheckpointEvery,
+		logger:          settings.Logger,
+	}
+
+	if newMigrator.workers <= 0 {
+		newMigrator.workers = defaultWorkers
+	}
+
+	if newMigrator.checkpointEvery <= 0 {
+		newMigrator.checkpointEvery = defaultCheckpointEvery
+	}
+
+	return &newMigrator
+}
+
+// Copy stores every kb of the source in the target keeping their ids and
+// dates. It resumes from the last checkpoint, kbs of the target with the
+// same or newer data are not written again, so a copy can run as many
+// times as needed. KBs that can't be copied are reported and the copy goes
+// on. Checkpoints of dry run copies are not saved.
+func (m *Migrator) Copy(ctx context.Context) (CopyReport, error) {
+	logger := requests.Logger(ctx, m.logger)
+
+	var from Checkpoint
+	var err error
+
+	if m.checkpoints != nil {
+		from, err = m.checkpoints.LoadCheckpoint(ctx)
+		if err != nil {
+			logger.Error("unable to load migration checkpoint", slog.String("error", err.Error()))
+
+			return CopyReport{}, errCopy
+		}
+	}
+
+	report, err := m.copy(ctx, from)
+	if errors.Is(err, errScanOrderChanged) {
+		logger.Warn("migration checkpoint doesn't match the source, copying every kb again",
+			slog.Int("position", from.Position),
+			slog.String("last_id", from.LastID.String()))
+
+		report, err = m.copy(ctx, Checkpoint{})
+	}
+
+	if err != nil {
+		logger.Error("unable to copy kbs",
+			slog.Any("report", report),
+			slog.String("error", err.Error()))
+
+		return report, errCopy
+	}
+
+	logger.Info("kbs copy finished",
+		slog.Int("read", report.KBsRead),
+		slog.Int("resumed", report.Resumed),
+		slog.Int("copied", report.Copied),
+		slog.Int("replaced", report.Replaced),
+		slog.Int("unchanged", report.Unchanged),
+		slog.Int("failed", report.Failed))
+
+	return report, nil
+}
+
+func (m *Migrator) copy(ctx context.Context, from Checkpoint) (CopyReport, error) {
+	var report CopyReport
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	jobs := make(chan job)
+	state := progress{
+		checkpoint: from,
+		saved:      from.Position,
+		done:       make(map[int]kbs.KBID),
+	}
+
+	for i := 0; i < m.workers; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for next := range jobs {
+				result, err := m.copyKB(ctx, next.kb)
+
+				mu.Lock()
+				report.add(next.kb.ID, result, err)
+				mu.Unlock()
+
+				if err == nil {
+					m.complete(ctx, &state, next)
+				}
+			}
+		}()
+	}
+
+	position := 0
+
+	err := m.source.ScanKBs(ctx, func(kb kbs.KB) error {
+		position++
+
+		mu.Lock()
+		report.KBsRead++
+		mu.Unlock()
+
+		if position <= from.Position {
+			if position == from.Position && kb.ID != from.LastID {
+				return errScanOrderChanged
+			}
+
+			mu.Lock()
+			report.Resumed++
+			mu.Unlock()
+
+			return nil
+		}
+
+		select {
+		case jobs <- job{position: position, kb: kb}:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+
+	close(jobs)
+	wg.Wait()
+
+	if err != nil {
+		return report, err
+	}
+
+	m.saveCheckpoint(ctx, state.checkpoint)
+
+	return report, nil
+}
+
+// copyKB writes the kb to the target unless the target has the same or
+// newer data, older data is replaced in place.
+func (m *Migrator) copyKB(ctx context.Context, kb kbs.KB) (outcome, error) {
+	current, err := m.target.QueryByID(ctx, kb.ID)
+	if err != nil {
+		return copied, err
+	}
+
+	switch {
+	case current == nil:
+		return copied, m.target.Save(ctx, kb)
+	case current.UpdateDate > kb.UpdateDate, digest(*current) == digest(kb):
+		return unchanged, nil
+	}
+
+	return replaced, m.target.Save(ctx, kb)
+}
+
+// complete marks the kb of the job as copied and saves the checkpoint once
+// it moved far enough. The lock is held while saving, so checkpoints are
+// saved in order.
+func (m *Migrator) complete(ctx context.Context, state *progress, copiedKB job) {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	state.done[copiedKB.position] = copiedKB.kb.ID
+
+	for {
+		id, ok := state.done[state.checkpoint.Position+1]
+		if !ok {
+			break
+		}
+
+		delete(state.done, state.checkpoint.Position+1)
+		state.checkpoint.Position++
+		state.checkpoint.LastID = id
+	}
+
+	if state.checkpoint.Position-state.saved < m.checkpointEvery {
+		return
+	}
+
+	state.saved = state.checkpoint.Position
+
+	m.saveCheckpoint(ctx, state.checkpoint)
+}
+
+// saveCheckpoint keeps the progress of the copy. A checkpoint that can't be
+// saved only means more kbs are read again on resume, so it doesn't stop
+// the copy.
+func (m *Migrator) saveCheckpoint(ctx context.Context, checkpoint Checkpoint) {
+	if m.checkpoints == nil || requests.FromContext(ctx).DryRun {
+		return
+	}
+
+	err := m.checkpoints.SaveCheckpoint(ctx, checkpoint)
+	if err != nil {
+		requests.Logger(ctx, m.logger).Warn("unable to save migration checkpoint",
+			slog.Int("position", checkpoint.Position),
+			slog.String("error", err.Error()))
+	}
+}
+
+// Verify counts and digests the kbs of the source and of the target. Kbs
+// written while it runs may make them differ, so it is meant to run once
+// writes stopped or every write reaches both stores.
+func (m *Migrator) Verify(ctx context.Context) (VerifyReport, error) {
+	logger := requests.Logger(ctx, m.logger)
+
+	var report VerifyReport
+	var err error
+
+	report.SourceKBs, report.SourceChecksum, err = checksum(ctx, m.source)
+	if err == nil {
+		report.TargetKBs, report.TargetChecksum, err = checksum(ctx, m.target)
+	}
+
+	if err != nil {
+		logger.Error("unable to verify kbs", slog.String("error", err.Error()))
+
+		return report, errVerify
+	}
+
+	report.Match = report.SourceKBs == report.TargetKBs && report.SourceChecksum == report.TargetChecksum
+
+	logger.Info("kbs verification finished", slog.Any("report", report))
+
+	return report, nil
+}
+
+// add counts the result of the copy of a kb.
+func (r *CopyReport) add(id kbs.KBID, result outcome, err error) {
+	switch {
+	case err != nil:
+		r.fail(id, err)
+	case result == copied:
+		r.Copied++
+	case result == replaced:
+		r.Replaced++
+	default:
+		r.Unchanged++
+	}
+}
@@ -0,0 +1,218 @@
+package migration_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/kbs"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/migration"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCopyAndVerifyKBs(t *testing.T) {
+	// Given
+	ctx := context.TODO()
+	source := newMemoryStore(newKBs(50)...)
+	outdated := source.kbs["kb-001"]
+	outdated.Content = "first draft"
+	outdated.UpdateDate--
+	copied := source.kbs["kb-002"]
+	copied.Reviews = []kbs.Review{}
+	target := newMemoryStore(outdated, copied)
+	checkpoints := migration.NewFileCheckpoints(filepath.Join(t.TempDir(), "migration.checkpoint"))
+	migrator := newMigrator(source, target, checkpoints)
+
+	// When
+	copyReport, err := migrator.Copy(ctx)
+	require.NoError(t, err)
+	verifyReport, err := migrator.Verify(ctx)
+	require.NoError(t, err)
+
+	// Then
+	assert.Equal(t, migration.CopyReport{KBsRead: 50, Copied: 48, Replaced: 1, Unchanged: 1}, copyReport)
+	assert.Len(t, target.kbs, 50)
+	assert.Equal(t, source.kbs["kb-001"], target.kbs["kb-001"])
+	assert.True(t, verifyReport.Match)
+	assert.Equal(t, 50, verifyReport.SourceKBs)
+	assert.Equal(t, 50, verifyReport.TargetKBs)
+	assert.Equal(t, verifyReport.SourceChecksum, verifyReport.TargetChecksum)
+
+	checkpoint, err := checkpoints.LoadCheckpoint(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, migration.Checkpoint{Position: 50, LastID: "kb-050"}, checkpoint)
+}
+
+func TestCopyResumesFromCheckpoint(t *testing.T) {
+	// Given
+	ctx := context.TODO()
+	source := newMemoryStore(newKBs(50)...)
+	target := newMemoryStore()
+	target.failing["kb-045"] = true
+	checkpoints := migration.NewFileCheckpoints(filepath.Join(t.TempDir(), "migration.checkpoint"))
+	require.NoError(t, checkpoints.SaveCheckpoint(ctx, migration.Checkpoint{Position: 30, LastID: "kb-030"}))
+
+	// When
+	report, err := newMigrator(source, target, checkpoints).Copy(ctx)
+
+	// Then
+	require.NoError(t, err)
+	assert.Equal(t, 50, report.KBsRead)
+	assert.Equal(t, 30, report.Resumed)
+	assert.Equal(t, 19, report.Copied)
+	assert.Equal(t, 1, report.Failed)
+	assert.Equal(t, []migration.KBError{{ID: "kb-045", Error: "unable to save kb"}}, report.Errors)
+	assert.Len(t, target.kbs, 19)
+	assert.NotContains(t, target.kbs, kbs.KBID("kb-030"))
+
+	checkpoint, err := checkpoints.LoadCheckpoint(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, migration.Checkpoint{Position: 44, LastID: "kb-044"}, checkpoint)
+}
+
+func TestCopyStartsOverIfTheSourceOrderChanged(t *testing.T) {
+	// Given
+	ctx := context.TODO()
+	source := newMemoryStore(newKBs(20)...)
+	target := newMemoryStore()
+	checkpoints := migration.NewFileCheckpoints(filepath.Join(t.TempDir(), "migration.checkpoint"))
+	require.NoError(t, checkpoints.SaveCheckpoint(ctx, migration.Checkpoint{Position: 10, LastID: "kb-999"}))
+
+	// When
+	report, err := newMigrator(source, target, checkpoints).Copy(ctx)
+
+	// Then
+	require.NoError(t, err)
+	assert.Equal(t, migration.CopyReport{KBsRead: 20, Copied: 20}, report)
+	assert.Equal(t, source.all(), target.all())
+}
+
+func TestVerifyFindsDifferentKBs(t *testing.T) {
+	// Given
+	ctx := context.TODO()
+	source := newMemoryStore(newKBs(3)...)
+	changed := source.kbs["kb-002"]
+	changed.Content = "changed"
+	target := newMemoryStore(source.kbs["kb-001"], changed, source.kbs["kb-003"])
+
+	// When
+	report, err := newMigrator(source, target, nil).Verify(ctx)
+
+	// Then
+	require.NoError(t, err)
+	assert.False(t, report.Match)
+	assert.Equal(t, 3, report.SourceKBs)
+	assert.Equal(t, 3, report.TargetKBs)
+	assert.NotEqual(t, report.SourceChecksum, report.TargetChecksum)
+}
+
+func newMigrator(source, target *memoryStore, checkpoints migration.Checkpointer) *migration.Migrator {
+	return migration.New(migration.Setup{
+		Source:          source,
+		Target:          target,
+		Checkpoints:     checkpoints,
+		Workers:         4,
+		CheckpointEvery: 10,
+		Logger:          newDummyLogger(),
+	})
+}
+
+func newKBs(count int) []kbs.KB {
+	newKBs := make([]kbs.KB, 0, count)
+
+	for i := 1; i <= count; i++ {
+		newKBs = append(newKBs, kbs.KB{
+			ID:           kbs.KBID(fmt.Sprintf("kb-%03d", i)),
+			UserID:       "user-1",
+			EventID:      "event-1",
+			CreationDate: 1700000000 + int64(i),
+			UpdateDate:   1700000100 + int64(i),
+			State:        kbs.Published,
+			Content:      fmt.Sprintf("runbook %d", i),
+		})
+	}
+
+	return newKBs
+}
+
+// memoryStore keeps the kbs in memory, saves of the failing kbs fail.
+type memoryStore struct {
+	mu      sync.Mutex
+	kbs     map[kbs.KBID]kbs.KB
+	failing map[kbs.KBID]bool
+}
+
+func newMemoryStore(stored ...kbs.KB) *memoryStore {
+	store := memoryStore{
+		kbs:     make(map[kbs.KBID]kbs.KB),
+		failing: make(map[kbs.KBID]bool),
+	}
+
+	for _, kb := range stored {
+		store.kbs[kb.ID] = kb
+	}
+
+	return &store
+}
+
+func (m *memoryStore) Save(ctx context.Context, newKB kbs.KB) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.failing[newKB.ID] {
+		return errors.New("unable to save kb")
+	}
+
+	m.kbs[newKB.ID] = newKB
+
+	return nil
+}
+
+func (m *memoryStore) QueryByID(ctx context.Context, id kbs.KBID) (*kbs.KB, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	kb, ok := m.kbs[id]
+	if !ok {
+		return nil, nil
+	}
+
+	return &kb, nil
+}
+
+// ScanKBs reads the kbs ordered by id.
+func (m *memoryStore) ScanKBs(ctx context.Context, fn func(kb kbs.KB) error) error {
+	for _, kb := range m.all() {
+		err := fn(kb)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *memoryStore) all() []kbs.KB {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	all := make([]kbs.KB, 0, len(m.kbs))
+	for _, kb := range m.kbs {
+		all = append(all, kb)
+	}
+
+	sort.Slice(all, func(i, j int) bool { return all[i].ID < all[j].ID })
+
+	return all
+}
+
+func newDummyLogger() *slog.Logger {
+	return slog.New(slog.NewJSONHandler(os.Stdout, nil))
+}
@@ -0,0 +1,83 @@
+package migration
+
+import (
+	"context"
+
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/kbs"
+)
+
+// KBScanner reads every kb of a store.
+type KBScanner interface {
+	// ScanKBs calls fn for each kb in the store until all kbs were read
+	// or fn returns an error.
+	ScanKBs(ctx context.Context, fn func(kb kbs.KB) error) error
+}
+
+// Target is the store kbs are copied to, it is scanned to verify the copy.
+type Target interface {
+	KBScanner
+	// Save replaces the kb with the same id if there is one.
+	Save(ctx context.Context, newKB kbs.KB) error
+	// QueryByID returns a nil kb if it does not exist.
+	QueryByID(ctx context.Context, id kbs.KBID) (*kbs.KB, error)
+}
+
+// Checkpointer keeps the progress of a copy, so an interrupted copy can be
+// resumed.
+type Checkpointer interface {
+	// LoadCheckpoint returns an empty checkpoint if there is none.
+	LoadCheckpoint(ctx context.Context) (Checkpoint, error)
+	SaveCheckpoint(ctx context.Context, checkpoint Checkpoint) error
+}
+
+// Checkpoint is the progress of a copy.
+type Checkpoint struct {
+	// Position is the number of kbs at the start of the source scan that
+	// were copied, a resumed copy doesn't copy them again.
+	Position int `json:"position"`
+	// LastID is the id of the kb at Position, it tells if the source is
+	// still scanned in the same order.
+	LastID kbs.KBID `json:"last_id,omitempty"`
+}
+
+// CopyReport contains the result of a copy.
+type CopyReport struct {
+	KBsRead int `json:"kbs_read"`
+	// Resumed kbs were copied by a previous run and were not read again
+	// from the target.
+	Resumed int `json:"resumed"`
+	Copied  int `json:"copied"`
+	// Replaced kbs existed in the target with older data.
+	Replaced int `json:"replaced"`
+	// Unchanged kbs existed in the target with the same data or with
+	// newer data, e.g. written by a dual write.
+	Unchanged int       `json:"unchanged"`
+	Failed    int       `json:"failed"`
+	Errors    []KBError `json:"errors,omitempty"`
+}
+
+// KBError is the reason a kb was not copied.
+type KBError struct {
+	ID    kbs.KBID `json:"id"`
+	Error string   `json:"error"`
+}
+
+// VerifyReport compares the kbs of the source and the target.
+type VerifyReport struct {
+	SourceKBs int `json:"source_kbs"`
+	TargetKBs int `json:"target_kbs"`
+	// SourceChecksum and TargetChecksum digest every field of every kb,
+	// they don't depend on the order the kbs are read.
+	SourceChecksum string `json:"source_checksum"`
+	TargetChecksum string `json:"target_checksum"`
+	Match          bool   `json:"match"`
+}
+
+// fail counts a kb that was not copied and keeps its error.
+func (r *CopyReport) fail(id kbs.KBID, err error) {
+	r.Failed++
+
+	if len(r.Errors) < maxKBErrors {
+		r.Errors = append(r.Errors, KBError{ID: id, Error: err.Error()})
+	}
+}
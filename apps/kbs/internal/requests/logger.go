@@ -0,0 +1,44 @@
+package requests
+
+import (
+	"context"
+	"log/slog"
+)
+
+// redacted replaces the value of sensitive attributes in the logs.
+const redacted = "[REDACTED]"
+
+// sensitiveKeys are the attributes whose value must never be logged, kb
+// content may contain personal or confidential data.
+var sensitiveKeys = map[string]bool{
+	"content": true,
+}
+
+type loggerKey struct{}
+
+// NewLoggerContext returns a copy of ctx that carries the given
+// request-scoped logger.
+func NewLoggerContext(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// Logger returns the request-scoped logger carried by ctx, or the given
+// one if there is none, e.g. in administrative commands.
+func Logger(ctx context.Context, fallback *slog.Logger) *slog.Logger {
+	logger, ok := ctx.Value(loggerKey{}).(*slog.Logger)
+	if !ok {
+		return fallback
+	}
+
+	return logger
+}
+
+// RedactSensitive hides the value of sensitive attributes, it is meant to
+// be used as the ReplaceAttr function of slog handlers.
+func RedactSensitive(groups []string, attr slog.Attr) slog.Attr {
+	if sensitiveKeys[attr.Key] {
+		return slog.String(attr.Key, redacted)
+	}
+
+	return attr
+}
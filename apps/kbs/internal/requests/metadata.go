@@ -0,0 +1,87 @@
+package requests
+
+import (
+	"context"
+	"sync"
+)
+
+// Metadata contains data about the request that is being served.
+type Metadata struct {
+	// RequestID identifies the request across logs and records.
+	RequestID string
+	// Principal is the user that made the request, if known.
+	Principal string
+	// ClientIP is the address of the client that made the request.
+	ClientIP string
+	// DryRun is true if the request must not change the store.
+	DryRun bool
+	// Plan collects the writes a dry run request would have made.
+	Plan *Plan
+}
+
+// Write is a change a dry run request would have made to the store.
+type Write struct {
+	Operation string `json:"operation"`
+	Data      any    `json:"data"`
+}
+
+// Plan collects the writes of a dry run request. It is safe for
+// concurrent use.
+type Plan struct {
+	mu     sync.Mutex
+	writes []Write
+}
+
+type metadataKey struct{}
+
+// NewContext returns a copy of ctx that carries the given metadata.
+func NewContext(ctx context.Context, metadata Metadata) context.Context {
+	return context.WithValue(ctx, metadataKey{}, metadata)
+}
+
+// FromContext returns the metadata carried by ctx. It returns empty
+// metadata if there is none, e.g. in administrative commands.
+func FromContext(ctx context.Context) Metadata {
+	metadata, _ := ctx.Value(metadataKey{}).(Metadata)
+
+	return metadata
+}
+
+// NewDryRunContext returns a copy of ctx whose metadata is marked as dry
+// run with an empty plan.
+func NewDryRunContext(ctx context.Context) context.Context {
+	metadata := FromContext(ctx)
+	metadata.DryRun = true
+	metadata.Plan = new(Plan)
+
+	return NewContext(ctx, metadata)
+}
+
+// IsDryRun returns true if the request carried by ctx must not change the store.
+func IsDryRun(ctx context.Context) bool {
+	return FromContext(ctx).DryRun
+}
+
+// Add records a write that was skipped.
+func (p *Plan) Add(operation string, data any) {
+	if p == nil {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.writes = append(p.writes, Write{Operation: operation, Data: data})
+}
+
+// Writes returns the writes recorded so far.
+func (p *Plan) Writes() []Write {
+	if p == nil {
+		return nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return append([]Write{}, p.writes...)
+}
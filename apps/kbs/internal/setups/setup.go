@@ -1,6 +1,8 @@
 package setups
 
 import (
+	"time"
+
 	"github.com/caarlos0/env"
 )
 
@@ -9,13 +11,224 @@ type Application struct {
 	DryRun          bool   `env:"KBS_DRY_RUN" envDefault:"false"`
 	ApplicationPort string `env:"KBS_APPLICATION_PORT" envDefault:":8080"`
 	LogLevel        string `env:"KBS_LOG_ENVIRONMENT" envDefault:"production"`
+	// AccessLogFormat format of the access log: json, combined or none.
+	AccessLogFormat string `env:"KBS_ACCESS_LOG_FORMAT" envDefault:"json"`
+	// UserNameCacheTTL how long user display names are cached, zero disables the cache.
+	UserNameCacheTTL time.Duration `env:"KBS_USER_NAME_CACHE_TTL" envDefault:"5m"`
+	// EventsBufferSize number of kb lifecycle events waiting to be delivered.
+	EventsBufferSize int `env:"KBS_EVENTS_BUFFER_SIZE" envDefault:"100"`
+	// IdempotencyTTL how long the responses of requests sent with an
+	// idempotency key are replayed.
+	IdempotencyTTL time.Duration `env:"KBS_IDEMPOTENCY_TTL" envDefault:"24h"`
+	// IdempotencyLockTimeout how long a request in progress blocks its
+	// idempotency key if it is never completed.
+	IdempotencyLockTimeout time.Duration `env:"KBS_IDEMPOTENCY_LOCK_TIMEOUT" envDefault:"1m"`
+	// HealthCheckTimeout maximum time a dependency check can take.
+	HealthCheckTimeout time.Duration `env:"KBS_HEALTH_CHECK_TIMEOUT" envDefault:"2s"`
+	// HealthCacheTTL how long a dependency check result is reused.
+	HealthCacheTTL time.Duration `env:"KBS_HEALTH_CACHE_TTL" envDefault:"5s"`
+	// HTTP server timeouts.
+	HTTPReadTimeout  time.Duration `env:"KBS_HTTP_READ_TIMEOUT" envDefault:"15s"`
+	HTTPWriteTimeout time.Duration `env:"KBS_HTTP_WRITE_TIMEOUT" envDefault:"30s"`
+	HTTPIdleTimeout  time.Duration `env:"KBS_HTTP_IDLE_TIMEOUT" envDefault:"60s"`
+	// ShutdownDelay how long readiness fails before the server stops
+	// accepting requests, so the load balancer stops sending traffic.
+	ShutdownDelay time.Duration `env:"KBS_SHUTDOWN_DELAY" envDefault:"5s"`
+	// ShutdownTimeout maximum time to drain requests and flush workers.
+	ShutdownTimeout time.Duration `env:"KBS_SHUTDOWN_TIMEOUT" envDefault:"20s"`
 	Repository      RepositoryParameters
+	Tracing         TracingParameters
+	Identity        IdentityParameters
+	RateLimit       RateLimitParameters
+	Cache           CacheParameters
+	Resilience      ResilienceParameters
+	Encryption      EncryptionParameters
+	Content         ContentParameters
+	SQL             SQLParameters
+	DualWrite       DualWriteParameters
+	Backup          BackupParameters
 }
 
 // RepositoryParameters contains data related to a repository.
 type RepositoryParameters struct {
 	Region   string `env:"KBS_AWS_REGION" envDefault:"us-east-1"`
 	Endpoint string `env:"KBS_AWS_ENDPOINT" envDefault:"5432"`
+	// MigrateOnStart applies the pending schema migrations at startup.
+	MigrateOnStart bool `env:"KBS_AWS_MIGRATE_ON_START" envDefault:"false"`
+	// BillingMode of the tables: PAY_PER_REQUEST or PROVISIONED.
+	BillingMode string `env:"KBS_AWS_BILLING_MODE" envDefault:"PAY_PER_REQUEST"`
+	// ReadCapacity and WriteCapacity units of provisioned tables and indexes.
+	ReadCapacity  int64 `env:"KBS_AWS_READ_CAPACITY" envDefault:"5"`
+	WriteCapacity int64 `env:"KBS_AWS_WRITE_CAPACITY" envDefault:"5"`
+	// SchemaTimeout how long a migration waits for a table to be active.
+	SchemaTimeout time.Duration `env:"KBS_AWS_SCHEMA_TIMEOUT" envDefault:"5m"`
+	// Environment name of the deployment, e.g. staging or test. If it is
+	// set the tables are named <environment>-<table>.
+	Environment string `env:"KBS_ENVIRONMENT"`
+	// TableName of the kbs table, it replaces the name of the environment.
+	TableName string `env:"KBS_AWS_TABLE_NAME"`
+	// KeyPrefix is added to the keys of the kbs followed by #, so several
+	// environments can share the kbs table. It cannot contain #.
+	KeyPrefix string `env:"KBS_AWS_KEY_PREFIX"`
+	// ValidateSchema checks the key schema of the kbs table at startup.
+	ValidateSchema bool `env:"KBS_AWS_VALIDATE_SCHEMA" envDefault:"true"`
+}
+
+// TracingParameters contains data related to the traces exporter.
+type TracingParameters struct {
+	// Exporter where spans are sent: none, stdout, file or otlp.
+	Exporter string `env:"KBS_TRACING_EXPORTER" envDefault:"none"`
+	// OTLPEndpoint host:port of the otlp http collector.
+	OTLPEndpoint string `env:"KBS_TRACING_OTLP_ENDPOINT" envDefault:"localhost:4318"`
+	OTLPInsecure bool   `env:"KBS_TRACING_OTLP_INSECURE" envDefault:"false"`
+	// File where spans are written with the file exporter.
+	File string `env:"KBS_TRACING_FILE" envDefault:"traces.json"`
+	// SampleRatio fraction of new traces that are sampled.
+	SampleRatio float64 `env:"KBS_TRACING_SAMPLE_RATIO" envDefault:"1"`
+}
+
+// IdentityParameters defines how the principal and the address of the
+// client that makes a request are verified.
+type IdentityParameters struct {
+	// APIKeys accepted api keys, principal:key pairs separated by commas.
+	APIKeys Secret `env:"KBS_API_KEYS"`
+	// TrustedProxies ip addresses or cidrs, separated by commas, of the
+	// proxies whose X-User-ID and X-Forwarded-For headers are trusted.
+	TrustedProxies string `env:"KBS_TRUSTED_PROXIES"`
+	// AdminPrincipals principals, separated by commas, allowed to use the
+	// admin routes, e.g. the export and import of kbs.
+	AdminPrincipals string `env:"KBS_ADMIN_PRINCIPALS"`
+}
+
+// RateLimitParameters contains the limits of the requests each client can
+// make, rates are requests per second.
+type RateLimitParameters struct {
+	Enabled bool `env:"KBS_RATE_LIMIT_ENABLED" envDefault:"true"`
+	// Backend keeps the counters of the clients: memory.
+	Backend    string  `env:"KBS_RATE_LIMIT_BACKEND" envDefault:"memory"`
+	ReadRate   float64 `env:"KBS_RATE_LIMIT_READ_RATE" envDefault:"20"`
+	ReadBurst  int     `env:"KBS_RATE_LIMIT_READ_BURST" envDefault:"40"`
+	WriteRate  float64 `env:"KBS_RATE_LIMIT_WRITE_RATE" envDefault:"5"`
+	WriteBurst int     `env:"KBS_RATE_LIMIT_WRITE_BURST" envDefault:"10"`
+	// Quotas replace the limits of specific clients, they look like
+	// client:class=rate/burst and are separated by commas.
+	Quotas string `env:"KBS_RATE_LIMIT_QUOTAS"`
+	// MaxClients maximum number of clients the memory backend counts
+	// separately, the rest share one limit.
+	MaxClients int `env:"KBS_RATE_LIMIT_MAX_CLIENTS" envDefault:"100000"`
+}
+
+// CacheParameters contains the settings of the kbs read cache.
+type CacheParameters struct {
+	Enabled bool `env:"KBS_CACHE_ENABLED" envDefault:"true"`
+	// Size maximum number of kbs and of query results kept in memory.
+	Size int `env:"KBS_CACHE_SIZE" envDefault:"10000"`
+	// TTL how long a kb or a query result is kept.
+	TTL time.Duration `env:"KBS_CACHE_TTL" envDefault:"1m"`
+	// NegativeTTL how long a missing kb is remembered.
+	NegativeTTL time.Duration `env:"KBS_CACHE_NEGATIVE_TTL" envDefault:"10s"`
+	// LoadTimeout bounds a store read shared by the requests that missed
+	// the same kb or query.
+	LoadTimeout time.Duration `env:"KBS_CACHE_LOAD_TIMEOUT" envDefault:"10s"`
+}
+
+// ResilienceParameters contains the retry policies and the circuit breaker
+// of the calls to the kbs store.
+type ResilienceParameters struct {
+	Enabled bool `env:"KBS_RESILIENCE_ENABLED" envDefault:"true"`
+	// ReadAttempts and WriteAttempts maximum calls made for each operation.
+	ReadAttempts  int `env:"KBS_RESILIENCE_READ_ATTEMPTS" envDefault:"3"`
+	WriteAttempts int `env:"KBS_RESILIENCE_WRITE_ATTEMPTS" envDefault:"2"`
+	// BaseDelay wait before the first retry, it doubles up to MaxDelay.
+	BaseDelay time.Duration `env:"KBS_RESILIENCE_BASE_DELAY" envDefault:"50ms"`
+	MaxDelay  time.Duration `env:"KBS_RESILIENCE_MAX_DELAY" envDefault:"1s"`
+	// ReadTimeout and WriteTimeout of each call to the store.
+	ReadTimeout  time.Duration `env:"KBS_RESILIENCE_READ_TIMEOUT" envDefault:"2s"`
+	WriteTimeout time.Duration `env:"KBS_RESILIENCE_WRITE_TIMEOUT" envDefault:"5s"`
+	// BreakerFailures consecutive failed calls that open the circuit.
+	BreakerFailures int `env:"KBS_RESILIENCE_BREAKER_FAILURES" envDefault:"5"`
+	// BreakerCooldown how long the circuit stays open.
+	BreakerCooldown time.Duration `env:"KBS_RESILIENCE_BREAKER_COOLDOWN" envDefault:"30s"`
+}
+
+// EncryptionParameters contains the settings of the encryption of the
+// content of the kbs at rest.
+type EncryptionParameters struct {
+	Enabled bool `env:"KBS_ENCRYPTION_ENABLED" envDefault:"false"`
+	// Provider of the master keys: local or kms.
+	Provider string `env:"KBS_ENCRYPTION_PROVIDER" envDefault:"local"`
+	// KeyFile contains the master keys of the local provider, one id:key
+	// per line, the last one is the current key.
+	KeyFile string `env:"KBS_ENCRYPTION_KEY_FILE"`
+	// KMSKeyID id, arn or alias of the kms master key.
+	KMSKeyID string `env:"KBS_ENCRYPTION_KMS_KEY_ID"`
+	// DataKeyTTL how long a data key encrypts the content of a tenant.
+	DataKeyTTL time.Duration `env:"KBS_ENCRYPTION_DATA_KEY_TTL" envDefault:"1h"`
+}
+
+// ContentParameters defines how the content of large kbs is stored,
+// dynamodb items can't be larger than 400KB.
+type ContentParameters struct {
+	// CompressAbove size in bytes above which content is compressed, before
+	// it is encrypted if encryption is enabled. Zero disables compression.
+	CompressAbove int `env:"KBS_CONTENT_COMPRESS_ABOVE" envDefault:"8192"`
+	// OffloadAbove size in bytes of the compressed content above which it
+	// is moved to the blob store.
+	OffloadAbove int `env:"KBS_CONTENT_OFFLOAD_ABOVE" envDefault:"307200"`
+	// ExcerptLength characters of offloaded content returned by queries.
+	ExcerptLength int `env:"KBS_CONTENT_EXCERPT_LENGTH" envDefault:"280"`
+	// BlobBackend keeps the offloaded content: none, file or s3.
+	BlobBackend string `env:"KBS_BLOB_BACKEND" envDefault:"none"`
+	// BlobDir directory of the file backend.
+	BlobDir string `env:"KBS_BLOB_DIR" envDefault:"blobs"`
+	// BlobBucket bucket of the s3 backend.
+	BlobBucket string `env:"KBS_BLOB_BUCKET"`
+}
+
+// SQLParameters contains the connection to the relational database of the
+// sql kbs store, the binary must register the driver. kbsd registers pgx.
+type SQLParameters struct {
+	Driver string `env:"KBS_SQL_DRIVER" envDefault:"pgx"`
+	// DSN may carry the password of the database.
+	DSN Secret `env:"KBS_SQL_DSN"`
+}
+
+// DualWriteParameters defines how kbs are written to the dynamodb and the
+// sql stores while they move from one to the other.
+type DualWriteParameters struct {
+	Enabled bool `env:"KBS_DUAL_WRITE_ENABLED" envDefault:"false"`
+	// Primary store whose failures fail the writes: dynamodb or sql.
+	Primary string `env:"KBS_DUAL_WRITE_PRIMARY" envDefault:"dynamodb"`
+	// ReadFrom store that serves the reads: dynamodb or sql.
+	ReadFrom string `env:"KBS_DUAL_WRITE_READ_FROM" envDefault:"dynamodb"`
+}
+
+// BackupParameters defines the snapshots of the kbs kept in the blob store.
+type BackupParameters struct {
+	// Enabled takes snapshots periodically, they can always be taken and
+	// restored with the admin commands.
+	Enabled  bool          `env:"KBS_BACKUP_ENABLED" envDefault:"false"`
+	Interval time.Duration `env:"KBS_BACKUP_INTERVAL" envDefault:"24h"`
+	// Store kbs store that is backed up: dynamodb or sql.
+	Store string `env:"KBS_BACKUP_STORE" envDefault:"dynamodb"`
+	// KeepLast and KeepFor are the retention, a snapshot is kept if any of
+	// them keeps it.
+	KeepLast int           `env:"KBS_BACKUP_KEEP_LAST" envDefault:"7"`
+	KeepFor  time.Duration `env:"KBS_BACKUP_KEEP_FOR" envDefault:"720h"`
+	// PartSize number of kbs per blob of a snapshot.
+	PartSize int `env:"KBS_BACKUP_PART_SIZE" envDefault:"1000"`
+}
+
+// Secret is a setting that must not be written to the logs.
+type Secret string
+
+// String hides the secret when the settings are printed.
+func (s Secret) String() string {
+	if s == "" {
+		return ""
+	}
+
+	return "[redacted]"
 }
 
 const (
@@ -40,5 +253,55 @@ func Load() (Application, error) {
 		return cfg, err
 	}
 	cfg.Repository = repository
+	tracing := TracingParameters{}
+	if err := env.Parse(&tracing); err != nil {
+		return cfg, err
+	}
+	cfg.Tracing = tracing
+	identity := IdentityParameters{}
+	if err := env.Parse(&identity); err != nil {
+		return cfg, err
+	}
+	cfg.Identity = identity
+	rateLimit := RateLimitParameters{}
+	if err := env.Parse(&rateLimit); err != nil {
+		return cfg, err
+	}
+	cfg.RateLimit = rateLimit
+	cache := CacheParameters{}
+	if err := env.Parse(&cache); err != nil {
+		return cfg, err
+	}
+	cfg.Cache = cache
+	resilience := ResilienceParameters{}
+	if err := env.Parse(&resilience); err != nil {
+		return cfg, err
+	}
+	cfg.Resilience = resilience
+	encryption := EncryptionParameters{}
+	if err := env.Parse(&encryption); err != nil {
+		return cfg, err
+	}
+	cfg.Encryption = encryption
+	content := ContentParameters{}
+	if err := env.Parse(&content); err != nil {
+		return cfg, err
+	}
+	cfg.Content = content
+	sql := SQLParameters{}
+	if err := env.Parse(&sql); err != nil {
+		return cfg, err
+	}
+	cfg.SQL = sql
+	dualWrite := DualWriteParameters{}
+	if err := env.Parse(&dualWrite); err != nil {
+		return cfg, err
+	}
+	cfg.DualWrite = dualWrite
+	backup := BackupParameters{}
+	if err := env.Parse(&backup); err != nil {
+		return cfg, err
+	}
+	cfg.Backup = backup
 	return cfg, nil
 }
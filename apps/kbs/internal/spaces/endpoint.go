@@ -0,0 +1,333 @@
+package spaces
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+)
+
+type CreateSpaceEndpoint struct {
+	service *Service
+	logger  *slog.Logger
+}
+
+type UpdateSpaceEndpoint struct {
+	service *Service
+	logger  *slog.Logger
+}
+
+type DeleteSpaceEndpoint struct {
+	service *Service
+	logger  *slog.Logger
+}
+
+type GetSpaceWithIDEndpoint struct {
+	service *Service
+	logger  *slog.Logger
+}
+
+type SearchSpacesEndpoint struct {
+	service *Service
+	logger  *slog.Logger
+}
+
+type CreateCollectionEndpoint struct {
+	service *Service
+	logger  *slog.Logger
+}
+
+type UpdateCollectionEndpoint struct {
+	service *Service
+	logger  *slog.Logger
+}
+
+type MoveCollectionEndpoint struct {
+	service *Service
+	logger  *slog.Logger
+}
+
+type DeleteCollectionEndpoint struct {
+	service *Service
+	logger  *slog.Logger
+}
+
+type GetCollectionWithIDEndpoint struct {
+	service *Service
+	logger  *slog.Logger
+}
+
+type SearchCollectionsEndpoint struct {
+	service *Service
+	logger  *slog.Logger
+}
+
+type MoveKBEndpoint struct {
+	service *Service
+	logger  *slog.Logger
+}
+
+type CopyKBEndpoint struct {
+	service *Service
+	logger  *slog.Logger
+}
+
+type SearchCollectionKBsEndpoint struct {
+	service *Service
+	logger  *slog.Logger
+}
+
+// Endpoints is a wrapper for spaces endpoints
+type Endpoints struct {
+	CreateSpaceEndpoint         *CreateSpaceEndpoint
+	UpdateSpaceEndpoint         *UpdateSpaceEndpoint
+	DeleteSpaceEndpoint         *DeleteSpaceEndpoint
+	GetSpaceWithIDEndpoint      *GetSpaceWithIDEndpoint
+	SearchSpacesEndpoint        *SearchSpacesEndpoint
+	CreateCollectionEndpoint    *CreateCollectionEndpoint
+	UpdateCollectionEndpoint    *UpdateCollectionEndpoint
+	MoveCollectionEndpoint      *MoveCollectionEndpoint
+	DeleteCollectionEndpoint    *DeleteCollectionEndpoint
+	GetCollectionWithIDEndpoint *GetCollectionWithIDEndpoint
+	SearchCollectionsEndpoint   *SearchCollectionsEndpoint
+	MoveKBEndpoint              *MoveKBEndpoint
+	CopyKBEndpoint              *CopyKBEndpoint
+	SearchCollectionKBsEndpoint *SearchCollectionKBsEndpoint
+}
+
+// NewEndpoints Create the endpoints for spaces application.
+func NewEndpoints(service *Service, logger *slog.Logger) Endpoints {
+	return Endpoints{
+		CreateSpaceEndpoint:         &CreateSpaceEndpoint{service: service, logger: logger},
+		UpdateSpaceEndpoint:         &UpdateSpaceEndpoint{service: service, logger: logger},
+		DeleteSpaceEndpoint:         &DeleteSpaceEndpoint{service: service, logger: logger},
+		GetSpaceWithIDEndpoint:      &GetSpaceWithIDEndpoint{service: service, logger: logger},
+		SearchSpacesEndpoint:        &SearchSpacesEndpoint{service: service, logger: logger},
+		CreateCollectionEndpoint:    &CreateCollectionEndpoint{service: service, logger: logger},
+		UpdateCollectionEndpoint:    &UpdateCollectionEndpoint{service: service, logger: logger},
+		MoveCollectionEndpoint:      &MoveCollectionEndpoint{service: service, logger: logger},
+		DeleteCollectionEndpoint:    &DeleteCollectionEndpoint{service: service, logger: logger},
+		GetCollectionWithIDEndpoint: &GetCollectionWithIDEndpoint{service: service, logger: logger},
+		SearchCollectionsEndpoint:   &SearchCollectionsEndpoint{service: service, logger: logger},
+		MoveKBEndpoint:              &MoveKBEndpoint{service: service, logger: logger},
+		CopyKBEndpoint:              &CopyKBEndpoint{service: service, logger: logger},
+		SearchCollectionKBsEndpoint: &SearchCollectionKBsEndpoint{service: service, logger: logger},
+	}
+}
+
+func (c *CreateSpaceEndpoint) Do(ctx context.Context, request any) (any, error) {
+	newSpace, ok := request.(*NewSpace)
+	if !ok {
+		c.logger.Error("invalid new space type", slog.String("request", fmt.Sprintf("%T", request)))
+
+		return nil, errors.New("invalid new space type")
+	}
+
+	newID, err := c.service.CreateSpace(ctx, *newSpace)
+	if err != nil {
+		c.logger.Error("something went wrong trying to create a space", slog.String("error", err.Error()))
+	}
+
+	return newCreateResult(newID.String(), err), nil
+}
+
+func (u *UpdateSpaceEndpoint) Do(ctx context.Context, request any) (any, error) {
+	updateSpace, ok := request.(*UpdateSpace)
+	if !ok {
+		u.logger.Error("invalid update space type", slog.String("request", fmt.Sprintf("%T", request)))
+
+		return nil, errors.New("invalid update space type")
+	}
+
+	err := u.service.UpdateSpace(ctx, *updateSpace)
+	if err != nil {
+		u.logger.Error("something went wrong trying to update a space", slog.String("error", err.Error()))
+	}
+
+	return newOperationResult(err), nil
+}
+
+func (d *DeleteSpaceEndpoint) Do(ctx context.Context, request any) (any, error) {
+	spaceID, ok := request.(SpaceID)
+	if !ok {
+		d.logger.Error("invalid delete space type", slog.String("request", fmt.Sprintf("%T", request)))
+
+		return nil, errors.New("invalid space id type")
+	}
+
+	err := d.service.DeleteSpace(ctx, spaceID)
+	if err != nil {
+		d.logger.Error("something went wrong trying to delete a space", slog.String("error", err.Error()))
+	}
+
+	return newOperationResult(err), nil
+}
+
+func (g *GetSpaceWithIDEndpoint) Do(ctx context.Context, request any) (any, error) {
+	spaceID, ok := request.(SpaceID)
+	if !ok {
+		g.logger.Error("invalid space id", slog.String("request", fmt.Sprintf("%T", request)))
+
+		return nil, errors.New("invalid space id")
+	}
+
+	space, err := g.service.QuerySpaceByID(ctx, spaceID)
+	if err != nil {
+		g.logger.Error("something went wrong trying to get a space with the given id", slog.String("error", err.Error()))
+	}
+
+	return newGetSpaceResult(space, err), nil
+}
+
+func (s *SearchSpacesEndpoint) Do(ctx context.Context, request any) (any, error) {
+	spacesFound, err := s.service.QuerySpaces(ctx)
+	if err != nil {
+		s.logger.Error("something went wrong trying to search spaces", slog.String("error", err.Error()))
+	}
+
+	return newSearchSpacesResult(spacesFound, err), nil
+}
+
+func (c *CreateCollectionEndpoint) Do(ctx context.Context, request any) (any, error) {
+	newCollection, ok := request.(*NewCollection)
+	if !ok {
+		c.logger.Error("invalid new collection type", slog.String("request", fmt.Sprintf("%T", request)))
+
+		return nil, errors.New("invalid new collection type")
+	}
+
+	newID, err := c.service.CreateCollection(ctx, *newCollection)
+	if err != nil {
+		c.logger.Error("something went wrong trying to create a collection", slog.String("error", err.Error()))
+	}
+
+	return newCreateResult(newID.String(), err), nil
+}
+
+func (u *UpdateCollectionEndpoint) Do(ctx context.Context, request any) (any, error) {
+	updateCollection, ok := request.(*UpdateCollection)
+	if !ok {
+		u.logger.Error("invalid update collection type", slog.String("request", fmt.Sprintf("%T", request)))
+
+		return nil, errors.New("invalid update collection type")
+	}
+
+	err := u.service.UpdateCollection(ctx, *updateCollection)
+	if err != nil {
+		u.logger.Error("something went wrong trying to update a collection", slog.String("error", err.Error()))
+	}
+
+	return newOperationResult(err), nil
+}
+
+func (m *MoveCollectionEndpoint) Do(ctx context.Context, request any) (any, error) {
+	moveCollection, ok := request.(*MoveCollection)
+	if !ok {
+		m.logger.Error("invalid move collection type", slog.String("request", fmt.Sprintf("%T", request)))
+
+		return nil, errors.New("invalid move collection type")
+	}
+
+	err := m.service.MoveCollection(ctx, *moveCollection)
+	if err != nil {
+		m.logger.Error("something went wrong trying to move a collection", slog.String("error", err.Error()))
+	}
+
+	return newOperationResult(err), nil
+}
+
+func (d *DeleteCollectionEndpoint) Do(ctx context.Context, request any) (any, error) {
+	collectionID, ok := request.(CollectionID)
+	if !ok {
+		d.logger.Error("invalid delete collection type", slog.String("request", fmt.Sprintf("%T", request)))
+
+		return nil, errors.New("invalid collection id type")
+	}
+
+	err := d.service.DeleteCollection(ctx, collectionID)
+	if err != nil {
+		d.logger.Error("something went wrong trying to delete a collection", slog.String("error", err.Error()))
+	}
+
+	return newOperationResult(err), nil
+}
+
+func (g *GetCollectionWithIDEndpoint) Do(ctx context.Context, request any) (any, error) {
+	collectionID, ok := request.(CollectionID)
+	if !ok {
+		g.logger.Error("invalid collection id", slog.String("request", fmt.Sprintf("%T", request)))
+
+		return nil, errors.New("invalid collection id")
+	}
+
+	collection, err := g.service.QueryCollectionByID(ctx, collectionID)
+	if err != nil {
+		g.logger.Error("something went wrong trying to get a collection with the given id", slog.String("error", err.Error()))
+	}
+
+	return newGetCollectionResult(collection, err), nil
+}
+
+func (s *SearchCollectionsEndpoint) Do(ctx context.Context, request any) (any, error) {
+	spaceID, ok := request.(SpaceID)
+	if !ok {
+		s.logger.Error("invalid space id", slog.String("request", fmt.Sprintf("%T", request)))
+
+		return nil, errors.New("invalid space id")
+	}
+
+	collections, err := s.service.QueryCollections(ctx, spaceID)
+	if err != nil {
+		s.logger.Error("something went wrong trying to search collections", slog.String("error", err.Error()))
+	}
+
+	return newSearchCollectionsResult(collections, err), nil
+}
+
+func (m *MoveKBEndpoint) Do(ctx context.Context, request any) (any, error) {
+	moveKB, ok := request.(*MoveKB)
+	if !ok {
+		m.logger.Error("invalid move kb type", slog.String("request", fmt.Sprintf("%T", request)))
+
+		return nil, errors.New("invalid move kb type")
+	}
+
+	err := m.service.MoveKB(ctx, *moveKB)
+	if err != nil {
+		m.logger.Error("something went wrong trying to move a kb", slog.String("error", err.Error()))
+	}
+
+	return newOperationResult(err), nil
+}
+
+func (c *CopyKBEndpoint) Do(ctx context.Context, request any) (any, error) {
+	copyKB, ok := request.(*CopyKB)
+	if !ok {
+		c.logger.Error("invalid copy kb type", slog.String("request", fmt.Sprintf("%T", request)))
+
+		return nil, errors.New("invalid copy kb type")
+	}
+
+	newID, err := c.service.CopyKB(ctx, *copyKB)
+	if err != nil {
+		c.logger.Error("something went wrong trying to copy a kb", slog.String("error", err.Error()))
+	}
+
+	return newCreateResult(newID.String(), err), nil
+}
+
+func (s *SearchCollectionKBsEndpoint) Do(ctx context.Context, request any) (any, error) {
+	collectionID, ok := request.(CollectionID)
+	if !ok {
+		s.logger.Error("invalid collection id", slog.String("request", fmt.Sprintf("%T", request)))
+
+		return nil, errors.New("invalid collection id")
+	}
+
+	placements, err := s.service.QueryPlacements(ctx, collectionID)
+	if err != nil {
+		s.logger.Error("something went wrong trying to search collection kbs", slog.String("error", err.Error()))
+	}
+
+	return newSearchPlacementsResult(placements, err), nil
+}
@@ -0,0 +1,394 @@
+package spaces
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/kbs"
+	"github.com/google/uuid"
+)
+
+// SpaceID defines space id.
+type SpaceID string
+
+// CollectionID defines collection id.
+type CollectionID string
+
+// Space is the top level container of collections.
+type Space struct {
+	ID           SpaceID `json:"id"`
+	Name         string  `json:"name"`
+	Description  string  `json:"description"`
+	CreationDate int64   `json:"creation_date"`
+	UpdateDate   int64   `json:"update_date"`
+}
+
+// NewSpace contains data to request the creation of a new space.
+type NewSpace struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// UpdateSpace contains data to request the update of a space.
+type UpdateSpace struct {
+	ID          SpaceID `json:"id"`
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+}
+
+// Collection groups kbs inside a space. Collections can be nested,
+// a collection without parent hangs directly from the space.
+type Collection struct {
+	ID           CollectionID `json:"id"`
+	SpaceID      SpaceID      `json:"space_id"`
+	ParentID     CollectionID `json:"parent_id"`
+	Name         string       `json:"name"`
+	Position     int          `json:"position"`
+	CreationDate int64        `json:"creation_date"`
+	UpdateDate   int64        `json:"update_date"`
+}
+
+// NewCollection contains data to request the creation of a new collection.
+type NewCollection struct {
+	SpaceID  SpaceID      `json:"space_id"`
+	ParentID CollectionID `json:"parent_id"`
+	Name     string       `json:"name"`
+	Position int          `json:"position"`
+}
+
+// UpdateCollection contains data to request the update of a collection.
+type UpdateCollection struct {
+	ID   CollectionID `json:"id"`
+	Name string       `json:"name"`
+}
+
+// MoveCollection contains data to request a collection to be moved under
+// another parent or to another position.
+type MoveCollection struct {
+	ID       CollectionID `json:"id"`
+	ParentID CollectionID `json:"parent_id"`
+	Position int          `json:"position"`
+}
+
+// Placement defines the collection a kb belongs to.
+type Placement struct {
+	KBID         kbs.KBID     `json:"kb_id"`
+	CollectionID CollectionID `json:"collection_id"`
+	Position     int          `json:"position"`
+}
+
+// MoveKB contains data to request a kb to be placed in a collection.
+type MoveKB struct {
+	KBID         kbs.KBID     `json:"kb_id"`
+	CollectionID CollectionID `json:"collection_id"`
+	Position     int          `json:"position"`
+}
+
+// CopyKB contains data to request a copy of a kb into a collection.
+type CopyKB struct {
+	KBID         kbs.KBID     `json:"kb_id"`
+	CollectionID CollectionID `json:"collection_id"`
+	Position     int          `json:"position"`
+}
+
+// ValidationError define spaces validation logic.
+type ValidationError struct {
+	Errors []string
+}
+
+// GetSpaceResult standard response for get a space with an ID.
+type GetSpaceResult struct {
+	Space *Space
+	Err   string
+}
+
+// SearchSpacesResult standard response for listing spaces.
+type SearchSpacesResult struct {
+	Spaces []Space
+	Err    string
+}
+
+// GetCollectionResult standard response for get a collection with an ID.
+type GetCollectionResult struct {
+	Collection *Collection
+	Err        string
+}
+
+// SearchCollectionsResult standard response for listing the collections of a space.
+type SearchCollectionsResult struct {
+	Collections []Collection
+	Err         string
+}
+
+// SearchPlacementsResult standard response for listing the kbs of a collection.
+type SearchPlacementsResult struct {
+	Placements []Placement
+	Err        string
+}
+
+// CreateResult standard response for create operations.
+type CreateResult struct {
+	ID  string
+	Err string
+}
+
+// OperationResult standard response for operations that don't return data.
+type OperationResult struct {
+	Err string
+}
+
+// breadcrumb kinds
+const (
+	SpaceKind      = "space"
+	CollectionKind = "collection"
+)
+
+const (
+	// EmptySpaceID is the space id that empty or nil.
+	EmptySpaceID = SpaceID("")
+	// EmptyCollectionID is the collection id that empty or nil.
+	EmptyCollectionID = CollectionID("")
+)
+
+func (e *ValidationError) add(message string) {
+	e.Errors = append(e.Errors, message)
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid spaces data: %+v", e.Errors)
+}
+
+func (e *ValidationError) hasErrors() bool {
+	return len(e.Errors) > 0
+}
+
+func newSpaceID() SpaceID {
+	return SpaceID(uuid.New().String())
+}
+
+func newCollectionID() CollectionID {
+	return CollectionID(uuid.New().String())
+}
+
+func now() int64 {
+	return time.Now().UTC().Unix()
+}
+
+func buildNewSpace(newSpace NewSpace) Space {
+	return Space{
+		ID:           newSpaceID(),
+		Name:         newSpace.Name,
+		Description:  newSpace.Description,
+		CreationDate: now(),
+	}
+}
+
+func buildNewCollection(newCollection NewCollection) Collection {
+	return Collection{
+		ID:           newCollectionID(),
+		SpaceID:      newCollection.SpaceID,
+		ParentID:     newCollection.ParentID,
+		Name:         newCollection.Name,
+		Position:     newCollection.Position,
+		CreationDate: now(),
+	}
+}
+
+func validNewSpace(newSpace NewSpace) error {
+	err := new(ValidationError)
+
+	if newSpace.Name == "" {
+		err.add("space name cannot be empty")
+	}
+
+	if err.hasErrors() {
+		return err
+	}
+
+	return nil
+}
+
+func validSpaceToUpdate(space UpdateSpace) error {
+	err := new(ValidationError)
+
+	if space.ID == EmptySpaceID {
+		err.add("space id cannot be empty")
+	}
+
+	if space.Name == "" {
+		err.add("space name cannot be empty")
+	}
+
+	if err.hasErrors() {
+		return err
+	}
+
+	return nil
+}
+
+func validNewCollection(newCollection NewCollection) error {
+	err := new(ValidationError)
+
+	if newCollection.SpaceID == EmptySpaceID {
+		err.add("space id cannot be empty")
+	}
+
+	if newCollection.Name == "" {
+		err.add("collection name cannot be empty")
+	}
+
+	if newCollection.Position < 0 {
+		err.add("collection position cannot be negative")
+	}
+
+	if err.hasErrors() {
+		return err
+	}
+
+	return nil
+}
+
+func validCollectionToUpdate(collection UpdateCollection) error {
+	err := new(ValidationError)
+
+	if collection.ID == EmptyCollectionID {
+		err.add("collection id cannot be empty")
+	}
+
+	if collection.Name == "" {
+		err.add("collection name cannot be empty")
+	}
+
+	if err.hasErrors() {
+		return err
+	}
+
+	return nil
+}
+
+func validMoveCollection(move MoveCollection) error {
+	err := new(ValidationError)
+
+	if move.ID == EmptyCollectionID {
+		err.add("collection id cannot be empty")
+	}
+
+	if move.ID == move.ParentID {
+		err.add("collection cannot be its own parent")
+	}
+
+	if move.Position < 0 {
+		err.add("collection position cannot be negative")
+	}
+
+	if err.hasErrors() {
+		return err
+	}
+
+	return nil
+}
+
+func validKBPlacement(kbID kbs.KBID, collectionID CollectionID, position int) error {
+	err := new(ValidationError)
+
+	if kbID == kbs.EmptyKBID {
+		err.add("kb id cannot be empty")
+	}
+
+	if collectionID == EmptyCollectionID {
+		err.add("collection id cannot be empty")
+	}
+
+	if position < 0 {
+		err.add("kb position cannot be negative")
+	}
+
+	if err.hasErrors() {
+		return err
+	}
+
+	return nil
+}
+
+// sortCollections orders collections by parent and then by position.
+func sortCollections(collections []Collection) {
+	sort.SliceStable(collections, func(i, j int) bool {
+		if collections[i].ParentID != collections[j].ParentID {
+			return collections[i].ParentID < collections[j].ParentID
+		}
+
+		return collections[i].Position < collections[j].Position
+	})
+}
+
+// sortPlacements orders placements by position.
+func sortPlacements(placements []Placement) {
+	sort.SliceStable(placements, func(i, j int) bool {
+		return placements[i].Position < placements[j].Position
+	})
+}
+
+func newGetSpaceResult(space *Space, err error) GetSpaceResult {
+	return GetSpaceResult{
+		Space: space,
+		Err:   errorMessage(err),
+	}
+}
+
+func newSearchSpacesResult(spaces []Space, err error) SearchSpacesResult {
+	return SearchSpacesResult{
+		Spaces: spaces,
+		Err:    errorMessage(err),
+	}
+}
+
+func newGetCollectionResult(collection *Collection, err error) GetCollectionResult {
+	return GetCollectionResult{
+		Collection: collection,
+		Err:        errorMessage(err),
+	}
+}
+
+func newSearchCollectionsResult(collections []Collection, err error) SearchCollectionsResult {
+	return SearchCollectionsResult{
+		Collections: collections,
+		Err:         errorMessage(err),
+	}
+}
+
+func newSearchPlacementsResult(placements []Placement, err error) SearchPlacementsResult {
+	return SearchPlacementsResult{
+		Placements: placements,
+		Err:        errorMessage(err),
+	}
+}
+
+func newCreateResult(id string, err error) CreateResult {
+	return CreateResult{
+		ID:  id,
+		Err: errorMessage(err),
+	}
+}
+
+func newOperationResult(err error) OperationResult {
+	return OperationResult{
+		Err: errorMessage(err),
+	}
+}
+
+func errorMessage(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	return err.Error()
+}
+
+func (s SpaceID) String() string {
+	return string(s)
+}
+
+func (c CollectionID) String() string {
+	return string(c)
+}
@@ -0,0 +1,716 @@
+package spaces
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/kbs"
+)
+
+// Storer defines persistence behavior for spaces, collections and kb placements.
+type Storer interface {
+	SaveSpace(ctx context.Context, space Space) error
+	UpdateSpace(ctx context.Context, space Space) error
+	DeleteSpace(ctx context.Context, id SpaceID) error
+	// QuerySpaceByID find and return a space with the given id.
+	// If space does not exist it returns a nil space and nil error.
+	QuerySpaceByID(ctx context.Context, id SpaceID) (*Space, error)
+	QuerySpaces(ctx context.Context) ([]Space, error)
+	SaveCollection(ctx context.Context, collection Collection) error
+	UpdateCollection(ctx context.Context, collection Collection) error
+	DeleteCollection(ctx context.Context, id CollectionID) error
+	// QueryCollectionByID find and return a collection with the given id.
+	// If collection does not exist it returns a nil collection and nil error.
+	QueryCollectionByID(ctx context.Context, id CollectionID) (*Collection, error)
+	QueryCollections(ctx context.Context, spaceID SpaceID) ([]Collection, error)
+	SavePlacement(ctx context.Context, placement Placement) error
+	// DeletePlacement removes the kb from its collection, it does nothing
+	// if the kb is not placed in any collection.
+	DeletePlacement(ctx context.Context, kbID kbs.KBID) error
+	// QueryPlacementsByKB find and return the placements of the given kbs.
+	// KBs that are not placed in any collection are not included in the result.
+	QueryPlacementsByKB(ctx context.Context, kbIDs []kbs.KBID) (map[kbs.KBID]Placement, error)
+	QueryPlacements(ctx context.Context, collectionID CollectionID) ([]Placement, error)
+}
+
+// KBService defines the kbs behavior spaces depend on.
+type KBService interface {
+	QueryByID(ctx context.Context, id kbs.KBID) (*kbs.KB, error)
+	Create(ctx context.Context, newKB kbs.NewKB) (kbs.KBID, error)
+}
+
+// ServiceSetup contains service metadata.
+type ServiceSetup struct {
+	Storer Storer
+	Logger *slog.Logger
+}
+
+// Service implements spaces business logic.
+type Service struct {
+	storer    Storer
+	kbService KBService
+	logger    *slog.Logger
+}
+
+var (
+	errSaveSpace           = errors.New("unable to save space in the repository")
+	errUpdateSpace         = errors.New("unable to update space in the repository")
+	errDeleteSpace         = errors.New("unable to delete space")
+	errQuerySpace          = errors.New("unable to query space")
+	errQuerySpaces         = errors.New("unable to query spaces")
+	errSaveCollection      = errors.New("unable to save collection in the repository")
+	errUpdateCollection    = errors.New("unable to update collection in the repository")
+	errDeleteCollection    = errors.New("unable to delete collection")
+	errQueryCollection     = errors.New("unable to query collection")
+	errQueryCollections    = errors.New("unable to query collections")
+	errPlaceKB             = errors.New("unable to place kb in the collection")
+	errCopyKB              = errors.New("unable to copy kb")
+	errQueryPlacements     = errors.New("unable to query collection kbs")
+	errRemovePlacement     = errors.New("unable to remove kb from its collection")
+	errFindPaths           = errors.New("unable to find kb paths")
+	errEmptySpaceID        = errors.New("space id cannot be empty")
+	errEmptyCollectionID   = errors.New("collection id cannot be empty")
+	errSpaceNotFound       = errors.New("space does not exist")
+	errCollectionNotFound  = errors.New("collection does not exist")
+	errParentNotFound      = errors.New("parent collection does not exist")
+	errParentInOtherSpace  = errors.New("parent collection belongs to another space")
+	errCollectionCycle     = errors.New("collection cannot be moved under one of its descendants")
+	errSpaceNotEmpty       = errors.New("space still contains collections")
+	errCollectionNotEmpty  = errors.New("collection still contains collections or kbs")
+	errKBNotFound          = errors.New("kb does not exist")
+	errKBServiceNotDefined = errors.New("kb service is not defined")
+)
+
+// NewService create a new spaces service.
+func NewService(settings ServiceSetup) *Service {
+	newService := Service{
+		storer: settings.Storer,
+		logger: settings.Logger,
+	}
+
+	return &newService
+}
+
+// WithKBService sets the kbs service used to copy kbs. It is set after
+// creation because kbs service depends on spaces to resolve kb paths.
+func (s *Service) WithKBService(kbService KBService) *Service {
+	s.kbService = kbService
+
+	return s
+}
+
+// CreateSpace create a space and store it in a database.
+func (s *Service) CreateSpace(ctx context.Context, newSpace NewSpace) (SpaceID, error) {
+	err := validNewSpace(newSpace)
+	if err != nil {
+		return EmptySpaceID, fmt.Errorf("unable to create space: %w", err)
+	}
+
+	space := buildNewSpace(newSpace)
+
+	err = s.storer.SaveSpace(ctx, space)
+	if err != nil {
+		s.logger.Error("unable to create space", slog.String("error", err.Error()))
+
+		return EmptySpaceID, errSaveSpace
+	}
+
+	s.logger.Debug("space was created", slog.String("id", space.ID.String()))
+
+	return space.ID, nil
+}
+
+// UpdateSpace update the name and description of a space.
+func (s *Service) UpdateSpace(ctx context.Context, updateSpace UpdateSpace) error {
+	err := validSpaceToUpdate(updateSpace)
+	if err != nil {
+		return fmt.Errorf("unable to update space: %w", err)
+	}
+
+	space, err := s.QuerySpaceByID(ctx, updateSpace.ID)
+	if err != nil {
+		return errUpdateSpace
+	}
+
+	if space == nil {
+		return errSpaceNotFound
+	}
+
+	space.Name = updateSpace.Name
+	space.Description = updateSpace.Description
+	space.UpdateDate = now()
+
+	err = s.storer.UpdateSpace(ctx, *space)
+	if err != nil {
+		s.logger.Error("unable to update space", slog.String("error", err.Error()))
+
+		return errUpdateSpace
+	}
+
+	return nil
+}
+
+// DeleteSpace delete an empty space.
+func (s *Service) DeleteSpace(ctx context.Context, id SpaceID) error {
+	if id == EmptySpaceID {
+		return errEmptySpaceID
+	}
+
+	collections, err := s.storer.QueryCollections(ctx, id)
+	if err != nil {
+		s.logger.Error("unable to query space collections",
+			slog.String("id", id.String()),
+			slog.String("error", err.Error()))
+
+		return errDeleteSpace
+	}
+
+	if len(collections) > 0 {
+		return errSpaceNotEmpty
+	}
+
+	err = s.storer.DeleteSpace(ctx, id)
+	if err != nil {
+		s.logger.Error("unable to delete space",
+			slog.String("id", id.String()),
+			slog.String("error", err.Error()))
+
+		return errDeleteSpace
+	}
+
+	return nil
+}
+
+// QuerySpaceByID find a space with the given id.
+func (s *Service) QuerySpaceByID(ctx context.Context, id SpaceID) (*Space, error) {
+	if id == EmptySpaceID {
+		return nil, errEmptySpaceID
+	}
+
+	space, err := s.storer.QuerySpaceByID(ctx, id)
+	if err != nil {
+		s.logger.Error("unable to query space by id",
+			slog.String("id", id.String()),
+			slog.String("error", err.Error()))
+
+		return nil, errQuerySpace
+	}
+
+	return space, nil
+}
+
+// QuerySpaces returns all spaces.
+func (s *Service) QuerySpaces(ctx context.Context) ([]Space, error) {
+	spaces, err := s.storer.QuerySpaces(ctx)
+	if err != nil {
+		s.logger.Error("unable to query spaces", slog.String("error", err.Error()))
+
+		return nil, errQuerySpaces
+	}
+
+	return spaces, nil
+}
+
+// CreateCollection create a collection inside a space or inside another collection.
+func (s *Service) CreateCollection(ctx context.Context, newCollection NewCollection) (CollectionID, error) {
+	err := validNewCollection(newCollection)
+	if err != nil {
+		return EmptyCollectionID, fmt.Errorf("unable to create collection: %w", err)
+	}
+
+	space, err := s.QuerySpaceByID(ctx, newCollection.SpaceID)
+	if err != nil {
+		return EmptyCollectionID, errSaveCollection
+	}
+
+	if space == nil {
+		return EmptyCollectionID, errSpaceNotFound
+	}
+
+	err = s.checkParent(ctx, newCollection.SpaceID, newCollection.ParentID)
+	if err != nil {
+		return EmptyCollectionID, err
+	}
+
+	collection := buildNewCollection(newCollection)
+
+	err = s.storer.SaveCollection(ctx, collection)
+	if err != nil {
+		s.logger.Error("unable to create collection", slog.String("error", err.Error()))
+
+		return EmptyCollectionID, errSaveCollection
+	}
+
+	s.logger.Debug("collection was created", slog.String("id", collection.ID.String()))
+
+	return collection.ID, nil
+}
+
+// UpdateCollection rename a collection.
+func (s *Service) UpdateCollection(ctx context.Context, updateCollection UpdateCollection) error {
+	err := validCollectionToUpdate(updateCollection)
+	if err != nil {
+		return fmt.Errorf("unable to update collection: %w", err)
+	}
+
+	collection, err := s.QueryCollectionByID(ctx, updateCollection.ID)
+	if err != nil {
+		return errUpdateCollection
+	}
+
+	if collection == nil {
+		return errCollectionNotFound
+	}
+
+	collection.Name = updateCollection.Name
+	collection.UpdateDate = now()
+
+	err = s.storer.UpdateCollection(ctx, *collection)
+	if err != nil {
+		s.logger.Error("unable to update collection", slog.String("error", err.Error()))
+
+		return errUpdateCollection
+	}
+
+	return nil
+}
+
+// MoveCollection moves a collection under another parent of the same space
+// and/or to another position.
+func (s *Service) MoveCollection(ctx context.Context, move MoveCollection) error {
+	err := validMoveCollection(move)
+	if err != nil {
+		return fmt.Errorf("unable to move collection: %w", err)
+	}
+
+	collection, err := s.QueryCollectionByID(ctx, move.ID)
+	if err != nil {
+		return errUpdateCollection
+	}
+
+	if collection == nil {
+		return errCollectionNotFound
+	}
+
+	err = s.checkParent(ctx, collection.SpaceID, move.ParentID)
+	if err != nil {
+		return err
+	}
+
+	err = s.checkCycle(ctx, collection.ID, move.ParentID)
+	if err != nil {
+		return err
+	}
+
+	collection.ParentID = move.ParentID
+	collection.Position = move.Position
+	collection.UpdateDate = now()
+
+	err = s.storer.UpdateCollection(ctx, *collection)
+	if err != nil {
+		s.logger.Error("unable to move collection", slog.String("error", err.Error()))
+
+		return errUpdateCollection
+	}
+
+	return nil
+}
+
+// DeleteCollection delete a collection without children and kbs.
+func (s *Service) DeleteCollection(ctx context.Context, id CollectionID) error {
+	collection, err := s.QueryCollectionByID(ctx, id)
+	if err != nil {
+		return errDeleteCollection
+	}
+
+	if collection == nil {
+		s.logger.Info("unable to delete collection cause it does not exist", slog.String("id", id.String()))
+
+		return nil
+	}
+
+	collections, err := s.storer.QueryCollections(ctx, collection.SpaceID)
+	if err != nil {
+		s.logger.Error("unable to query space collections", slog.String("error", err.Error()))
+
+		return errDeleteCollection
+	}
+
+	for _, v := range collections {
+		if v.ParentID == id {
+			return errCollectionNotEmpty
+		}
+	}
+
+	placements, err := s.storer.QueryPlacements(ctx, id)
+	if err != nil {
+		s.logger.Error("unable to query collection kbs", slog.String("error", err.Error()))
+
+		return errDeleteCollection
+	}
+
+	if len(placements) > 0 {
+		return errCollectionNotEmpty
+	}
+
+	err = s.storer.DeleteCollection(ctx, id)
+	if err != nil {
+		s.logger.Error("unable to delete collection",
+			slog.String("id", id.String()),
+			slog.String("error", err.Error()))
+
+		return errDeleteCollection
+	}
+
+	return nil
+}
+
+// QueryCollectionByID find a collection with the given id.
+func (s *Service) QueryCollectionByID(ctx context.Context, id CollectionID) (*Collection, error) {
+	if id == EmptyCollectionID {
+		return nil, errEmptyCollectionID
+	}
+
+	collection, err := s.storer.QueryCollectionByID(ctx, id)
+	if err != nil {
+		s.logger.Error("unable to query collection by id",
+			slog.String("id", id.String()),
+			slog.String("error", err.Error()))
+
+		return nil, errQueryCollection
+	}
+
+	return collection, nil
+}
+
+// QueryCollections returns the collections of a space ordered by parent and position.
+func (s *Service) QueryCollections(ctx context.Context, spaceID SpaceID) ([]Collection, error) {
+	if spaceID == EmptySpaceID {
+		return nil, errEmptySpaceID
+	}
+
+	collections, err := s.storer.QueryCollections(ctx, spaceID)
+	if err != nil {
+		s.logger.Error("unable to query collections",
+			slog.String("space_id", spaceID.String()),
+			slog.String("error", err.Error()))
+
+		return nil, errQueryCollections
+	}
+
+	sortCollections(collections)
+
+	return collections, nil
+}
+
+// MoveKB places a kb in the given collection, removing it from the
+// collection it belonged to before.
+func (s *Service) MoveKB(ctx context.Context, move MoveKB) error {
+	err := validKBPlacement(move.KBID, move.CollectionID, move.Position)
+	if err != nil {
+		return fmt.Errorf("unable to move kb: %w", err)
+	}
+
+	err = s.checkCollectionExists(ctx, move.CollectionID)
+	if err != nil {
+		return err
+	}
+
+	if s.kbService != nil {
+		kb, err := s.kbService.QueryByID(ctx, move.KBID)
+		if err != nil {
+			return errPlaceKB
+		}
+
+		if kb == nil {
+			return errKBNotFound
+		}
+	}
+
+	placement := Placement{
+		KBID:         move.KBID,
+		CollectionID: move.CollectionID,
+		Position:     move.Position,
+	}
+
+	err = s.storer.SavePlacement(ctx, placement)
+	if err != nil {
+		s.logger.Error("unable to place kb",
+			slog.String("kb_id", move.KBID.String()),
+			slog.String("error", err.Error()))
+
+		return errPlaceKB
+	}
+
+	return nil
+}
+
+// CopyKB creates a copy of a kb and places it in the given collection.
+func (s *Service) CopyKB(ctx context.Context, copyKB CopyKB) (kbs.KBID, error) {
+	err := validKBPlacement(copyKB.KBID, copyKB.CollectionID, copyKB.Position)
+	if err != nil {
+		return kbs.EmptyKBID, fmt.Errorf("unable to copy kb: %w", err)
+	}
+
+	if s.kbService == nil {
+		return kbs.EmptyKBID, errKBServiceNotDefined
+	}
+
+	err = s.checkCollectionExists(ctx, copyKB.CollectionID)
+	if err != nil {
+		return kbs.EmptyKBID, err
+	}
+
+	kb, err := s.kbService.QueryByID(ctx, copyKB.KBID)
+	if err != nil {
+		return kbs.EmptyKBID, errCopyKB
+	}
+
+	if kb == nil {
+		return kbs.EmptyKBID, errKBNotFound
+	}
+
+	newKBID, err := s.kbService.Create(ctx, kbs.NewKB{
+		UserID:   kb.UserID,
+		UserName: kb.UserName,
+		Content:  kb.Content,
+		EventID:  kb.EventID,
+	})
+	if err != nil {
+		return kbs.EmptyKBID, errCopyKB
+	}
+
+	placement := Placement{
+		KBID:         newKBID,
+		CollectionID: copyKB.CollectionID,
+		Position:     copyKB.Position,
+	}
+
+	err = s.storer.SavePlacement(ctx, placement)
+	if err != nil {
+		s.logger.Error("unable to place kb copy",
+			slog.String("kb_id", newKBID.String()),
+			slog.String("error", err.Error()))
+
+		return newKBID, errPlaceKB
+	}
+
+	return newKBID, nil
+}
+
+// QueryPlacements returns the kbs of a collection ordered by position.
+func (s *Service) QueryPlacements(ctx context.Context, collectionID CollectionID) ([]Placement, error) {
+	if collectionID == EmptyCollectionID {
+		return nil, errEmptyCollectionID
+	}
+
+	placements, err := s.storer.QueryPlacements(ctx, collectionID)
+	if err != nil {
+		s.logger.Error("unable to query collection kbs",
+			slog.String("collection_id", collectionID.String()),
+			slog.String("error", err.Error()))
+
+		return nil, errQueryPlacements
+	}
+
+	sortPlacements(placements)
+
+	return placements, nil
+}
+
+// FindPaths returns the breadcrumb path of each kb placed in a collection.
+// It implements kbs.PathFinder.
+func (s *Service) FindPaths(ctx context.Context, ids []kbs.KBID) (map[kbs.KBID][]kbs.Breadcrumb, error) {
+	paths := make(map[kbs.KBID][]kbs.Breadcrumb)
+	collections := make(map[CollectionID]*Collection)
+	spaces := make(map[SpaceID]*Space)
+
+	if len(ids) == 0 {
+		return paths, nil
+	}
+
+	placements, err := s.storer.QueryPlacementsByKB(ctx, ids)
+	if err != nil {
+		s.logger.Error("unable to query kb placements", slog.String("error", err.Error()))
+
+		return nil, errFindPaths
+	}
+
+	for _, id := range ids {
+		placement, ok := placements[id]
+		if !ok {
+			continue
+		}
+
+		path, err := s.buildPath(ctx, placement.CollectionID, collections, spaces)
+		if err != nil {
+			return nil, err
+		}
+
+		paths[id] = path
+	}
+
+	return paths, nil
+}
+
+// RemovePlacement removes a kb from its collection, it is called when the kb
+// is deleted so the collection doesn't keep a placement of a missing kb.
+// It implements kbs.PlacementRemover.
+func (s *Service) RemovePlacement(ctx context.Context, id kbs.KBID) error {
+	err := s.storer.DeletePlacement(ctx, id)
+	if err != nil {
+		s.logger.Error("unable to remove kb placement",
+			slog.String("kb_id", id.String()),
+			slog.String("error", err.Error()))
+
+		return errRemovePlacement
+	}
+
+	return nil
+}
+
+// buildPath walks the collection hierarchy up to the space. Collections and
+// spaces already visited are kept in the given maps to avoid reading them twice.
+func (s *Service) buildPath(ctx context.Context, collectionID CollectionID, collections map[CollectionID]*Collection, spaces map[SpaceID]*Space) ([]kbs.Breadcrumb, error) {
+	var path []kbs.Breadcrumb
+	var spaceID SpaceID
+
+	visited := make(map[CollectionID]bool)
+
+	for id := collectionID; id != EmptyCollectionID; {
+		if visited[id] {
+			s.logger.Error("collection hierarchy contains a cycle", slog.String("collection_id", id.String()))
+
+			return nil, errFindPaths
+		}
+
+		visited[id] = true
+
+		collection, ok := collections[id]
+		if !ok {
+			var err error
+
+			collection, err = s.storer.QueryCollectionByID(ctx, id)
+			if err != nil {
+				s.logger.Error("unable to query collection",
+					slog.String("collection_id", id.String()),
+					slog.String("error", err.Error()))
+
+				return nil, errFindPaths
+			}
+
+			collections[id] = collection
+		}
+
+		if collection == nil {
+			break
+		}
+
+		path = append(path, kbs.Breadcrumb{
+			ID:   collection.ID.String(),
+			Name: collection.Name,
+			Kind: CollectionKind,
+		})
+
+		spaceID = collection.SpaceID
+		id = collection.ParentID
+	}
+
+	if spaceID != EmptySpaceID {
+		space, ok := spaces[spaceID]
+		if !ok {
+			var err error
+
+			space, err = s.storer.QuerySpaceByID(ctx, spaceID)
+			if err != nil {
+				s.logger.Error("unable to query space",
+					slog.String("space_id", spaceID.String()),
+					slog.String("error", err.Error()))
+
+				return nil, errFindPaths
+			}
+
+			spaces[spaceID] = space
+		}
+
+		if space != nil {
+			path = append(path, kbs.Breadcrumb{
+				ID:   space.ID.String(),
+				Name: space.Name,
+				Kind: SpaceKind,
+			})
+		}
+	}
+
+	// path was built from the collection up to the space.
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+
+	return path, nil
+}
+
+// checkParent verifies that the parent collection exists in the same space.
+// An empty parent means the collection hangs directly from the space.
+func (s *Service) checkParent(ctx context.Context, spaceID SpaceID, parentID CollectionID) error {
+	if parentID == EmptyCollectionID {
+		return nil
+	}
+
+	parent, err := s.QueryCollectionByID(ctx, parentID)
+	if err != nil {
+		return errQueryCollection
+	}
+
+	if parent == nil {
+		return errParentNotFound
+	}
+
+	if parent.SpaceID != spaceID {
+		return errParentInOtherSpace
+	}
+
+	return nil
+}
+
+// checkCycle verifies that the new parent is not the collection itself or
+// one of its descendants.
+func (s *Service) checkCycle(ctx context.Context, id, parentID CollectionID) error {
+	visited := make(map[CollectionID]bool)
+
+	for current := parentID; current != EmptyCollectionID; {
+		if current == id || visited[current] {
+			return errCollectionCycle
+		}
+
+		visited[current] = true
+
+		collection, err := s.QueryCollectionByID(ctx, current)
+		if err != nil {
+			return errQueryCollection
+		}
+
+		if collection == nil {
+			return nil
+		}
+
+		current = collection.ParentID
+	}
+
+	return nil
+}
+
+func (s *Service) checkCollectionExists(ctx context.Context, id CollectionID) error {
+	collection, err := s.QueryCollectionByID(ctx, id)
+	if err != nil {
+		return errQueryCollection
+	}
+
+	if collection == nil {
+		return errCollectionNotFound
+	}
+
+	return nil
+}
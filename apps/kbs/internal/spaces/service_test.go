@@ -0,0 +1,236 @@
+package spaces_test
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/kbs"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/spaces"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindPaths(t *testing.T) {
+	// Given
+	ctx := context.TODO()
+	store := newMemoryStore()
+	service := spaces.NewService(spaces.ServiceSetup{
+		Storer: store,
+		Logger: newDummyLogger(),
+	})
+
+	spaceID, err := service.CreateSpace(ctx, spaces.NewSpace{Name: "engineering"})
+	require.NoError(t, err)
+	parentID, err := service.CreateCollection(ctx, spaces.NewCollection{SpaceID: spaceID, Name: "runbooks"})
+	require.NoError(t, err)
+	childID, err := service.CreateCollection(ctx, spaces.NewCollection{SpaceID: spaceID, ParentID: parentID, Name: "databases"})
+	require.NoError(t, err)
+	err = service.MoveKB(ctx, spaces.MoveKB{KBID: "kb-1", CollectionID: childID})
+	require.NoError(t, err)
+
+	expectedPaths := map[kbs.KBID][]kbs.Breadcrumb{
+		"kb-1": {
+			{ID: spaceID.String(), Name: "engineering", Kind: spaces.SpaceKind},
+			{ID: parentID.String(), Name: "runbooks", Kind: spaces.CollectionKind},
+			{ID: childID.String(), Name: "databases", Kind: spaces.CollectionKind},
+		},
+	}
+
+	// When
+	got, err := service.FindPaths(ctx, []kbs.KBID{"kb-1", "kb-2"})
+
+	// Then
+	assert.NoError(t, err)
+	assert.Equal(t, expectedPaths, got)
+}
+
+func TestMoveCollectionUnderDescendant(t *testing.T) {
+	// Given
+	ctx := context.TODO()
+	store := newMemoryStore()
+	service := spaces.NewService(spaces.ServiceSetup{
+		Storer: store,
+		Logger: newDummyLogger(),
+	})
+
+	spaceID, err := service.CreateSpace(ctx, spaces.NewSpace{Name: "engineering"})
+	require.NoError(t, err)
+	parentID, err := service.CreateCollection(ctx, spaces.NewCollection{SpaceID: spaceID, Name: "runbooks"})
+	require.NoError(t, err)
+	childID, err := service.CreateCollection(ctx, spaces.NewCollection{SpaceID: spaceID, ParentID: parentID, Name: "databases"})
+	require.NoError(t, err)
+
+	// When
+	err = service.MoveCollection(ctx, spaces.MoveCollection{ID: parentID, ParentID: childID})
+
+	// Then
+	assert.Error(t, err)
+	got, err := service.QueryCollectionByID(ctx, parentID)
+	assert.NoError(t, err)
+	assert.Equal(t, spaces.EmptyCollectionID, got.ParentID)
+}
+
+func TestDeleteNotEmptyCollection(t *testing.T) {
+	// Given
+	ctx := context.TODO()
+	store := newMemoryStore()
+	service := spaces.NewService(spaces.ServiceSetup{
+		Storer: store,
+		Logger: newDummyLogger(),
+	})
+
+	spaceID, err := service.CreateSpace(ctx, spaces.NewSpace{Name: "engineering"})
+	require.NoError(t, err)
+	collectionID, err := service.CreateCollection(ctx, spaces.NewCollection{SpaceID: spaceID, Name: "runbooks"})
+	require.NoError(t, err)
+	err = service.MoveKB(ctx, spaces.MoveKB{KBID: "kb-1", CollectionID: collectionID})
+	require.NoError(t, err)
+
+	// When
+	err = service.DeleteCollection(ctx, collectionID)
+
+	// Then
+	assert.Error(t, err)
+}
+
+func TestDeleteCollectionOfDeletedKBs(t *testing.T) {
+	// Given
+	ctx := context.TODO()
+	store := newMemoryStore()
+	service := spaces.NewService(spaces.ServiceSetup{
+		Storer: store,
+		Logger: newDummyLogger(),
+	})
+
+	spaceID, err := service.CreateSpace(ctx, spaces.NewSpace{Name: "engineering"})
+	require.NoError(t, err)
+	collectionID, err := service.CreateCollection(ctx, spaces.NewCollection{SpaceID: spaceID, Name: "runbooks"})
+	require.NoError(t, err)
+	err = service.MoveKB(ctx, spaces.MoveKB{KBID: "kb-1", CollectionID: collectionID})
+	require.NoError(t, err)
+	err = service.RemovePlacement(ctx, "kb-1")
+	require.NoError(t, err)
+
+	// When
+	err = service.DeleteCollection(ctx, collectionID)
+
+	// Then
+	assert.NoError(t, err)
+	got, err := service.QueryCollectionByID(ctx, collectionID)
+	assert.NoError(t, err)
+	assert.Nil(t, got)
+}
+
+type memoryStore struct {
+	spaces      map[spaces.SpaceID]spaces.Space
+	collections map[spaces.CollectionID]spaces.Collection
+	placements  map[kbs.KBID]spaces.Placement
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{
+		spaces:      make(map[spaces.SpaceID]spaces.Space),
+		collections: make(map[spaces.CollectionID]spaces.Collection),
+		placements:  make(map[kbs.KBID]spaces.Placement),
+	}
+}
+
+func (m *memoryStore) SaveSpace(ctx context.Context, space spaces.Space) error {
+	m.spaces[space.ID] = space
+	return nil
+}
+
+func (m *memoryStore) UpdateSpace(ctx context.Context, space spaces.Space) error {
+	m.spaces[space.ID] = space
+	return nil
+}
+
+func (m *memoryStore) DeleteSpace(ctx context.Context, id spaces.SpaceID) error {
+	delete(m.spaces, id)
+	return nil
+}
+
+func (m *memoryStore) QuerySpaceByID(ctx context.Context, id spaces.SpaceID) (*spaces.Space, error) {
+	space, ok := m.spaces[id]
+	if !ok {
+		return nil, nil
+	}
+	return &space, nil
+}
+
+func (m *memoryStore) QuerySpaces(ctx context.Context) ([]spaces.Space, error) {
+	var result []spaces.Space
+	for _, v := range m.spaces {
+		result = append(result, v)
+	}
+	return result, nil
+}
+
+func (m *memoryStore) SaveCollection(ctx context.Context, collection spaces.Collection) error {
+	m.collections[collection.ID] = collection
+	return nil
+}
+
+func (m *memoryStore) UpdateCollection(ctx context.Context, collection spaces.Collection) error {
+	m.collections[collection.ID] = collection
+	return nil
+}
+
+func (m *memoryStore) DeleteCollection(ctx context.Context, id spaces.CollectionID) error {
+	delete(m.collections, id)
+	return nil
+}
+
+func (m *memoryStore) QueryCollectionByID(ctx context.Context, id spaces.CollectionID) (*spaces.Collection, error) {
+	collection, ok := m.collections[id]
+	if !ok {
+		return nil, nil
+	}
+	return &collection, nil
+}
+
+func (m *memoryStore) QueryCollections(ctx context.Context, spaceID spaces.SpaceID) ([]spaces.Collection, error) {
+	var result []spaces.Collection
+	for _, v := range m.collections {
+		if v.SpaceID == spaceID {
+			result = append(result, v)
+		}
+	}
+	return result, nil
+}
+
+func (m *memoryStore) SavePlacement(ctx context.Context, placement spaces.Placement) error {
+	m.placements[placement.KBID] = placement
+	return nil
+}
+
+func (m *memoryStore) DeletePlacement(ctx context.Context, kbID kbs.KBID) error {
+	delete(m.placements, kbID)
+	return nil
+}
+
+func (m *memoryStore) QueryPlacementsByKB(ctx context.Context, kbIDs []kbs.KBID) (map[kbs.KBID]spaces.Placement, error) {
+	result := make(map[kbs.KBID]spaces.Placement)
+	for _, id := range kbIDs {
+		if placement, ok := m.placements[id]; ok {
+			result[id] = placement
+		}
+	}
+	return result, nil
+}
+
+func (m *memoryStore) QueryPlacements(ctx context.Context, collectionID spaces.CollectionID) ([]spaces.Placement, error) {
+	var result []spaces.Placement
+	for _, v := range m.placements {
+		if v.CollectionID == collectionID {
+			result = append(result, v)
+		}
+	}
+	return result, nil
+}
+
+func newDummyLogger() *slog.Logger {
+	return slog.New(slog.NewJSONHandler(os.Stdout, nil))
+}
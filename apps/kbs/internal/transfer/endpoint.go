@@ -0,0 +1,91 @@
+package transfer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+)
+
+type ExportKBsEndpoint struct {
+	service *Service
+	logger  *slog.Logger
+}
+
+type ImportKBsEndpoint struct {
+	service *Service
+	logger  *slog.Logger
+}
+
+// Endpoints is a wrapper for transfer endpoints
+type Endpoints struct {
+	ExportKBsEndpoint *ExportKBsEndpoint
+	ImportKBsEndpoint *ImportKBsEndpoint
+}
+
+// NewEndpoints Create the endpoints for the export and import of kbs.
+func NewEndpoints(service *Service, logger *slog.Logger) Endpoints {
+	return Endpoints{
+		ExportKBsEndpoint: MakeExportKBsEndpoint(service, logger),
+		ImportKBsEndpoint: MakeImportKBsEndpoint(service, logger),
+	}
+}
+
+// MakeExportKBsEndpoint transfer endpoint to export kbs.
+func MakeExportKBsEndpoint(srv *Service, logger *slog.Logger) *ExportKBsEndpoint {
+	return &ExportKBsEndpoint{
+		service: srv,
+		logger:  logger,
+	}
+}
+
+// MakeImportKBsEndpoint transfer endpoint to import kbs.
+func MakeImportKBsEndpoint(srv *Service, logger *slog.Logger) *ImportKBsEndpoint {
+	return &ImportKBsEndpoint{
+		service: srv,
+		logger:  logger,
+	}
+}
+
+// Do returns the export without running it, the encoder streams it to the
+// response once the headers are written.
+func (e *ExportKBsEndpoint) Do(ctx context.Context, request any) (any, error) {
+	filter, ok := request.(ExportFilter)
+	if !ok {
+		e.logger.Error("invalid export filter", slog.String("received", fmt.Sprintf("%T", request)))
+
+		return nil, errors.New("invalid export filter")
+	}
+
+	if !filter.Format.IsValid() {
+		return newExportKBsResult(filter, nil, errInvalidFormat), nil
+	}
+
+	write := func(ctx context.Context, w io.Writer) error {
+		_, err := e.service.Export(ctx, filter, w)
+
+		return err
+	}
+
+	return newExportKBsResult(filter, write, nil), nil
+}
+
+func (i *ImportKBsEndpoint) Do(ctx context.Context, request any) (any, error) {
+	importRequest, ok := request.(ImportRequest)
+	if !ok {
+		i.logger.Error("invalid import request", slog.String("received", fmt.Sprintf("%T", request)))
+
+		return nil, errors.New("invalid import request")
+	}
+
+	report, err := i.service.Import(ctx, importRequest)
+	if err != nil {
+		i.logger.Error(
+			"something went wrong trying to import kbs",
+			slog.String("error", err.Error()),
+		)
+	}
+
+	return newImportKBsResult(report, err), nil
+}
@@ -0,0 +1,243 @@
+package transfer
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// frontMatterDelimiter opens and closes the front-matter of markdown files.
+const frontMatterDelimiter = "---"
+
+var (
+	errInvalidRecord      = errors.New("invalid record")
+	errInvalidFrontMatter = errors.New("invalid front-matter")
+)
+
+// recordWriter writes records in a format, close must be called once all
+// the records were written.
+type recordWriter interface {
+	write(record Record) error
+	close() error
+}
+
+func newRecordWriter(format Format, w io.Writer) recordWriter {
+	if format == Markdown {
+		return &markdownWriter{archive: zip.NewWriter(w)}
+	}
+
+	return &ndjsonWriter{encoder: json.NewEncoder(w)}
+}
+
+type ndjsonWriter struct {
+	encoder *json.Encoder
+}
+
+func (n *ndjsonWriter) write(record Record) error {
+	return n.encoder.Encode(record)
+}
+
+func (n *ndjsonWriter) close() error {
+	return nil
+}
+
+type markdownWriter struct {
+	archive *zip.Writer
+}
+
+// write adds a file named after the kb id. The values of the front-matter
+// are json, which yaml parsers read as well.
+func (m *markdownWriter) write(record Record) error {
+	file, err := m.archive.Create(url.PathEscape(record.ID.String()) + ".md")
+	if err != nil {
+		return err
+	}
+
+	fields := []struct {
+		name  string
+		value any
+	}{
+		{"id", record.ID},
+		{"user_id", record.UserID},
+		{"username", record.UserName},
+		{"event_id", record.EventID},
+		{"creation_date", record.CreationDate},
+		{"update_date", record.UpdateDate},
+		{"state", record.State},
+		{"reviewer_id", record.ReviewerID},
+		{"reviews", record.Reviews},
+	}
+
+	var buf bytes.Buffer
+
+	buf.WriteString(frontMatterDelimiter + "\n")
+
+	for _, field := range fields {
+		value, err := json.Marshal(field.value)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(&buf, "%s: %s\n", field.name, value)
+	}
+
+	buf.WriteString(frontMatterDelimiter + "\n")
+	buf.WriteString(record.Content)
+
+	_, err = file.Write(buf.Bytes())
+
+	return err
+}
+
+func (m *markdownWriter) close() error {
+	return m.archive.Close()
+}
+
+// readRecords calls fn with each record of the body and its position. A
+// record that can't be decoded is given to fn with its error, errors
+// reading the body stop the read.
+func readRecords(format Format, body io.Reader, fn func(position int, record Record, err error) error) error {
+	if format == Markdown {
+		return readMarkdownRecords(body, fn)
+	}
+
+	return readNDJSONRecords(body, fn)
+}
+
+func readNDJSONRecords(body io.Reader, fn func(position int, record Record, err error) error) error {
+	reader := bufio.NewReader(body)
+	position := 0
+
+	for {
+		line, readErr := reader.ReadBytes('\n')
+		if readErr != nil && !errors.Is(readErr, io.EOF) {
+			return readErr
+		}
+
+		if len(bytes.TrimSpace(line)) > 0 {
+			position++
+
+			var record Record
+
+			err := json.Unmarshal(line, &record)
+			if err != nil {
+				err = fmt.Errorf("%w: %w", errInvalidRecord, err)
+			}
+
+			err = fn(position, record, err)
+			if err != nil {
+				return err
+			}
+		}
+
+		if errors.Is(readErr, io.EOF) {
+			return nil
+		}
+	}
+}
+
+// readMarkdownRecords reads the markdown files of a zip, which can't be
+// read as a stream, so the whole body is read first.
+func readMarkdownRecords(body io.Reader, fn func(position int, record Record, err error) error) error {
+	content, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return fmt.Errorf("%w: %w", errInvalidFormat, err)
+	}
+
+	position := 0
+
+	for _, file := range archive.File {
+		if file.FileInfo().IsDir() {
+			continue
+		}
+
+		position++
+
+		record, err := readMarkdownFile(file)
+		if err != nil {
+			err = fmt.Errorf("%w: %s: %w", errInvalidRecord, file.Name, err)
+		}
+
+		err = fn(position, record, err)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func readMarkdownFile(file *zip.File) (Record, error) {
+	if path.Ext(file.Name) != ".md" {
+		return Record{}, errors.New("not a markdown file")
+	}
+
+	reader, err := file.Open()
+	if err != nil {
+		return Record{}, err
+	}
+
+	defer reader.Close()
+
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return Record{}, err
+	}
+
+	return parseMarkdown(string(content))
+}
+
+// parseMarkdown reads a file written by markdownWriter. Every value of the
+// front-matter must be json.
+func parseMarkdown(content string) (Record, error) {
+	rest, ok := strings.CutPrefix(content, frontMatterDelimiter+"\n")
+	if !ok {
+		return Record{}, errInvalidFrontMatter
+	}
+
+	frontMatter, body, ok := strings.Cut(rest, "\n"+frontMatterDelimiter+"\n")
+	if !ok {
+		return Record{}, errInvalidFrontMatter
+	}
+
+	fields := make(map[string]json.RawMessage)
+
+	for _, line := range strings.Split(frontMatter, "\n") {
+		name, value, ok := strings.Cut(line, ":")
+		value = strings.TrimSpace(value)
+
+		if !ok || !json.Valid([]byte(value)) {
+			return Record{}, fmt.Errorf("%w: %q", errInvalidFrontMatter, line)
+		}
+
+		fields[strings.TrimSpace(name)] = json.RawMessage(value)
+	}
+
+	encoded, err := json.Marshal(fields)
+	if err != nil {
+		return Record{}, err
+	}
+
+	var record Record
+
+	err = json.Unmarshal(encoded, &record)
+	if err != nil {
+		return Record{}, fmt.Errorf("%w: %w", errInvalidFrontMatter, err)
+	}
+
+	record.Content = body
+
+	return record, nil
+}
@@ -0,0 +1,203 @@
+package transfer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/kbs"
+)
+
+// Format is the layout of exported kbs.
+type Format string
+
+const (
+	// NDJSON writes one json record per line.
+	NDJSON = Format("ndjson")
+	// Markdown writes a zip with one markdown file per kb, the fields of
+	// the kb are in its front-matter.
+	Markdown = Format("markdown")
+)
+
+// IsValid returns true if the format is known.
+func (f Format) IsValid() bool {
+	return f == NDJSON || f == Markdown
+}
+
+// ContentType returns the media type of the format.
+func (f Format) ContentType() string {
+	if f == Markdown {
+		return "application/zip"
+	}
+
+	return "application/x-ndjson"
+}
+
+// Extension returns the file extension of the format.
+func (f Format) Extension() string {
+	if f == Markdown {
+		return "zip"
+	}
+
+	return "ndjson"
+}
+
+// Record is an exported kb. Its fields don't change when the kb model does,
+// so exports of a version can be imported by the next ones.
+type Record struct {
+	ID           kbs.KBID     `json:"id"`
+	UserID       kbs.UserID   `json:"user_id"`
+	UserName     string       `json:"username"`
+	EventID      kbs.EventID  `json:"event_id"`
+	CreationDate int64        `json:"creation_date"`
+	UpdateDate   int64        `json:"update_date"`
+	State        kbs.State    `json:"state,omitempty"`
+	ReviewerID   kbs.UserID   `json:"reviewer_id,omitempty"`
+	Reviews      []kbs.Review `json:"reviews,omitempty"`
+	Content      string       `json:"content"`
+}
+
+// ExportFilter contains the kbs to export and how.
+type ExportFilter struct {
+	Format Format
+	// EventID exports only the kbs of the event, optional.
+	EventID kbs.EventID
+}
+
+// ImportRequest contains the kbs to import.
+type ImportRequest struct {
+	Format Format
+	Body   io.Reader
+}
+
+// ExportReport contains the result of an export.
+type ExportReport struct {
+	KBsRead     int `json:"kbs_read"`
+	KBsExported int `json:"kbs_exported"`
+}
+
+// ImportReport contains the result of an import. Records that fail don't
+// stop the import, their errors are reported.
+type ImportReport struct {
+	Read     int `json:"read"`
+	Imported int `json:"imported"`
+	// Skipped records have the id of a kb that already exists or of a
+	// record read before.
+	Skipped int           `json:"skipped"`
+	Failed  int           `json:"failed"`
+	Errors  []RecordError `json:"errors,omitempty"`
+}
+
+// RecordError is the reason a record was not imported.
+type RecordError struct {
+	// Record is the position of the record, starting at 1.
+	Record int      `json:"record"`
+	ID     kbs.KBID `json:"id,omitempty"`
+	Error  string   `json:"error"`
+}
+
+// ExportKBsResult standard response for exporting kbs. Write streams the
+// export, it is called once the response can be written.
+type ExportKBsResult struct {
+	Format Format
+	Write  func(ctx context.Context, w io.Writer) error
+	Err    string
+}
+
+// ImportKBsResult standard response for importing kbs.
+type ImportKBsResult struct {
+	Report ImportReport
+	Err    string
+}
+
+var (
+	errInvalidFormat = errors.New("invalid transfer format")
+	errEmptyID       = errors.New("id cannot be empty")
+	errEmptyUserID   = errors.New("user id cannot be empty")
+	errEmptyEventID  = errors.New("event id cannot be empty")
+	errInvalidDate   = errors.New("creation date must be a unix date")
+	errInvalidState  = errors.New("invalid state")
+)
+
+// validate returns the first reason the record cannot be imported.
+func (r Record) validate() error {
+	switch {
+	case r.ID == "":
+		return errEmptyID
+	case r.UserID == "":
+		return errEmptyUserID
+	case r.EventID == "":
+		return errEmptyEventID
+	case r.CreationDate <= 0:
+		return errInvalidDate
+	case r.State != kbs.EmptyState && !r.State.IsValid():
+		return fmt.Errorf("%w: %q", errInvalidState, r.State)
+	}
+
+	return nil
+}
+
+// NewRecord returns the record of the kb.
+func NewRecord(kb kbs.KB) Record {
+	return Record{
+		ID:           kb.ID,
+		UserID:       kb.UserID,
+		UserName:     kb.UserName,
+		EventID:      kb.EventID,
+		CreationDate: kb.CreationDate,
+		UpdateDate:   kb.UpdateDate,
+		State:        kb.State,
+		ReviewerID:   kb.ReviewerID,
+		Reviews:      kb.Reviews,
+		Content:      kb.Content,
+	}
+}
+
+// ToKB returns the kb of the record, kbs that were never updated are
+// updated when they were created.
+func (r Record) ToKB() kbs.KB {
+	kb := kbs.KB{
+		ID:           r.ID,
+		UserID:       r.UserID,
+		UserName:     r.UserName,
+		EventID:      r.EventID,
+		CreationDate: r.CreationDate,
+		UpdateDate:   r.UpdateDate,
+		State:        r.State,
+		ReviewerID:   r.ReviewerID,
+		Reviews:      r.Reviews,
+		Content:      r.Content,
+	}
+
+	if kb.UpdateDate == 0 {
+		kb.UpdateDate = kb.CreationDate
+	}
+
+	return kb
+}
+
+func newExportKBsResult(filter ExportFilter, write func(ctx context.Context, w io.Writer) error, err error) ExportKBsResult {
+	result := ExportKBsResult{
+		Format: filter.Format,
+		Write:  write,
+	}
+
+	if err != nil {
+		result.Err = err.Error()
+	}
+
+	return result
+}
+
+func newImportKBsResult(report ImportReport, err error) ImportKBsResult {
+	result := ImportKBsResult{
+		Report: report,
+	}
+
+	if err != nil {
+		result.Err = err.Error()
+	}
+
+	return result
+}
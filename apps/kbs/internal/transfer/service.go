@@ -0,0 +1,186 @@
+// Package transfer exports the kbs of the store and imports them back,
+// e.g. to back them up or to move them to another environment.
+package transfer
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/kbs"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/requests"
+)
+
+// KBScanner reads every kb of the store.
+type KBScanner interface {
+	// ScanKBs calls fn for each kb in the store until all kbs were read
+	// or fn returns an error.
+	ScanKBs(ctx context.Context, fn func(kb kbs.KB) error) error
+}
+
+// Importer stores the imported kbs, it validates and audits them like the
+// kbs created by the users.
+type Importer interface {
+	// Import stores the kb keeping its id and dates, it returns false if a
+	// kb with the same id already exists.
+	Import(ctx context.Context, kb kbs.KB) (bool, error)
+}
+
+// ServiceSetup contains service metadata.
+type ServiceSetup struct {
+	Scanner  KBScanner
+	Importer Importer
+	Logger   *slog.Logger
+}
+
+// Service implements the export and import of kbs.
+type Service struct {
+	scanner  KBScanner
+	importer Importer
+	logger   *slog.Logger
+}
+
+// maxRecordErrors is the number of record errors an import report keeps,
+// the rest are only counted.
+const maxRecordErrors = 100
+
+var (
+	errExport = errors.New("unable to export kbs")
+	errImport = errors.New("unable to import kbs")
+)
+
+// NewService create a new transfer service.
+func NewService(settings ServiceSetup) *Service {
+	newService := Service{
+		scanner:  settings.Scanner,
+		importer: settings.Importer,
+		logger:   settings.Logger,
+	}
+
+	return &newService
+}
+
+// Export writes the kbs that match the filter to w as they are read, so
+// the whole knowledge base is never kept in memory.
+func (s *Service) Export(ctx context.Context, filter ExportFilter, w io.Writer) (ExportReport, error) {
+	logger := requests.Logger(ctx, s.logger)
+
+	var report ExportReport
+
+	if !filter.Format.IsValid() {
+		return report, errInvalidFormat
+	}
+
+	writer := newRecordWriter(filter.Format, w)
+
+	err := s.scanner.ScanKBs(ctx, func(kb kbs.KB) error {
+		report.KBsRead++
+
+		if filter.EventID != "" && kb.EventID != filter.EventID {
+			return nil
+		}
+
+		err := writer.write(NewRecord(kb))
+		if err != nil {
+			return err
+		}
+
+		report.KBsExported++
+
+		return nil
+	})
+	if err == nil {
+		err = writer.close()
+	}
+
+	if err != nil {
+		logger.Error("unable to export kbs",
+			slog.Any("report", report),
+			slog.String("error", err.Error()))
+
+		return report, errExport
+	}
+
+	logger.Info("kbs export finished",
+		slog.String("format", string(filter.Format)),
+		slog.Any("report", report))
+
+	return report, nil
+}
+
+// Import stores the kbs of the body keeping their ids and dates. Records
+// that are invalid or can't be stored are reported and the import goes on,
+// records with the id of an existing kb or of a previous record are
+// skipped. Writes of dry run requests are not stored.
+func (s *Service) Import(ctx context.Context, request ImportRequest) (ImportReport, error) {
+	logger := requests.Logger(ctx, s.logger)
+
+	var report ImportReport
+
+	if !request.Format.IsValid() {
+		return report, errInvalidFormat
+	}
+
+	seen := make(map[kbs.KBID]bool)
+
+	err := readRecords(request.Format, request.Body, func(position int, record Record, err error) error {
+		report.Read++
+
+		if err == nil {
+			err = record.validate()
+		}
+
+		if err != nil {
+			report.fail(position, record.ID, err)
+
+			return nil
+		}
+
+		if seen[record.ID] {
+			report.Skipped++
+
+			return nil
+		}
+
+		seen[record.ID] = true
+
+		imported, err := s.importer.Import(ctx, record.ToKB())
+
+		switch {
+		case err != nil:
+			report.fail(position, record.ID, err)
+		case imported:
+			report.Imported++
+		default:
+			report.Skipped++
+		}
+
+		return nil
+	})
+	if err != nil {
+		logger.Error("unable to import kbs",
+			slog.Any("report", report),
+			slog.String("error", err.Error()))
+
+		return report, errImport
+	}
+
+	logger.Info("kbs import finished",
+		slog.String("format", string(request.Format)),
+		slog.Int("read", report.Read),
+		slog.Int("imported", report.Imported),
+		slog.Int("skipped", report.Skipped),
+		slog.Int("failed", report.Failed))
+
+	return report, nil
+}
+
+// fail counts a failed record and keeps its error.
+func (r *ImportReport) fail(position int, id kbs.KBID, err error) {
+	r.Failed++
+
+	if len(r.Errors) < maxRecordErrors {
+		r.Errors = append(r.Errors, RecordError{Record: position, ID: id, Error: err.Error()})
+	}
+}
@@ -0,0 +1,181 @@
+package transfer_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/kbs"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/transfer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportAndImportKBs(t *testing.T) {
+	for _, format := range []transfer.Format{transfer.NDJSON, transfer.Markdown} {
+		t.Run(string(format), func(t *testing.T) {
+			// Given
+			ctx := context.TODO()
+			source := newMemoryStore(
+				kbs.KB{
+					ID:           "kb-1",
+					UserID:       "user-1",
+					UserName:     "mono.mario",
+					EventID:      "event-1",
+					CreationDate: 1700000000,
+					UpdateDate:   1700000100,
+					State:        kbs.Published,
+					ReviewerID:   "user-2",
+					Reviews:      []kbs.Review{{ReviewerID: "user-2", Decision: kbs.Approved, Comment: "ok: ship it", Date: 1700000050}},
+					Content:      "# runbook\n---\nrestart: \"the service\"\n",
+				},
+				kbs.KB{ID: "kb-2", UserID: "user-1", EventID: "event-1", CreationDate: 1700000200, UpdateDate: 1700000200, State: kbs.Draft},
+				kbs.KB{ID: "kb-3", UserID: "user-3", EventID: "event-2", CreationDate: 1700000300, UpdateDate: 1700000300},
+			)
+			target := newMemoryStore()
+
+			var exported bytes.Buffer
+
+			// When
+			exportReport, err := newService(source).Export(ctx, transfer.ExportFilter{Format: format, EventID: "event-1"}, &exported)
+			require.NoError(t, err)
+			importReport, err := newService(target).Import(ctx, transfer.ImportRequest{Format: format, Body: &exported})
+			require.NoError(t, err)
+
+			// Then
+			assert.Equal(t, transfer.ExportReport{KBsRead: 3, KBsExported: 2}, exportReport)
+			assert.Equal(t, transfer.ImportReport{Read: 2, Imported: 2}, importReport)
+			assert.Equal(t, []kbs.KB{source.kbs["kb-1"], source.kbs["kb-2"]}, target.all())
+		})
+	}
+}
+
+func TestImportReportsRecordErrors(t *testing.T) {
+	// Given
+	ctx := context.TODO()
+	store := newMemoryStore(kbs.KB{ID: "kb-1", UserID: "user-1", EventID: "event-1", CreationDate: 1700000000, Content: "current"})
+	store.failing["kb-5"] = true
+	body := strings.Join([]string{
+		`{"id": "kb-1", "user_id": "user-1", "event_id": "event-1", "creation_date": 1700000000, "content": "older"}`,
+		`{"id": "kb-2", "user_id": "user-1", "event_id": "event-1", "creation_date": 1700000000}`,
+		`{"id": "kb-2", "user_id": "user-1", "event_id": "event-1", "creation_date": 1700000000}`,
+		`{"id": "kb-3", "user_id": "user-1", "creation_date": 1700000000}`,
+		`{"id": "kb-4", "user_id": "user-1", "event_id": "event-1", "creation_date": 1700000000, "state": "lost"}`,
+		`not json`,
+		``,
+		`{"id": "kb-5", "user_id": "user-1", "event_id": "event-1", "creation_date": 1700000000}`,
+		`{"id": "kb-6", "user_id": "user-1", "event_id": "event-1", "creation_date": 1700000000}`,
+	}, "\n")
+
+	// When
+	report, err := newService(store).Import(ctx, transfer.ImportRequest{Format: transfer.NDJSON, Body: strings.NewReader(body)})
+
+	// Then
+	require.NoError(t, err)
+	assert.Equal(t, 8, report.Read)
+	assert.Equal(t, 2, report.Imported)
+	assert.Equal(t, 2, report.Skipped)
+	assert.Equal(t, 4, report.Failed)
+	require.Len(t, report.Errors, 4)
+	assert.Equal(t, []int{4, 5, 6, 7}, []int{report.Errors[0].Record, report.Errors[1].Record, report.Errors[2].Record, report.Errors[3].Record})
+	assert.Equal(t, kbs.KBID("kb-3"), report.Errors[0].ID)
+	assert.Equal(t, kbs.KBID("kb-5"), report.Errors[3].ID)
+	assert.Equal(t, "current", store.kbs["kb-1"].Content)
+	assert.Equal(t, int64(1700000000), store.kbs["kb-2"].UpdateDate)
+	assert.Contains(t, store.kbs, kbs.KBID("kb-6"))
+}
+
+func TestTransferRejectsUnknownFormats(t *testing.T) {
+	// Given
+	ctx := context.TODO()
+	service := newService(newMemoryStore())
+
+	// When
+	_, exportErr := service.Export(ctx, transfer.ExportFilter{Format: "csv"}, &bytes.Buffer{})
+	_, importErr := service.Import(ctx, transfer.ImportRequest{Format: "csv", Body: strings.NewReader("")})
+
+	// Then
+	assert.Error(t, exportErr)
+	assert.Error(t, importErr)
+}
+
+func newService(store *memoryStore) *transfer.Service {
+	return transfer.NewService(transfer.ServiceSetup{
+		Scanner:  store,
+		Importer: store,
+		Logger:   newDummyLogger(),
+	})
+}
+
+// memoryStore keeps the kbs in memory, imports of the failing kbs fail.
+type memoryStore struct {
+	mu      sync.Mutex
+	kbs     map[kbs.KBID]kbs.KB
+	failing map[kbs.KBID]bool
+}
+
+func newMemoryStore(stored ...kbs.KB) *memoryStore {
+	store := memoryStore{
+		kbs:     make(map[kbs.KBID]kbs.KB),
+		failing: make(map[kbs.KBID]bool),
+	}
+
+	for _, kb := range stored {
+		store.kbs[kb.ID] = kb
+	}
+
+	return &store
+}
+
+func (m *memoryStore) Import(ctx context.Context, kb kbs.KB) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.failing[kb.ID] {
+		return false, errors.New("unable to save kb")
+	}
+
+	if _, ok := m.kbs[kb.ID]; ok {
+		return false, nil
+	}
+
+	m.kbs[kb.ID] = kb
+
+	return true, nil
+}
+
+// ScanKBs reads the kbs ordered by id.
+func (m *memoryStore) ScanKBs(ctx context.Context, fn func(kb kbs.KB) error) error {
+	for _, kb := range m.all() {
+		err := fn(kb)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *memoryStore) all() []kbs.KB {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	all := make([]kbs.KB, 0, len(m.kbs))
+	for _, kb := range m.kbs {
+		all = append(all, kb)
+	}
+
+	sort.Slice(all, func(i, j int) bool { return all[i].ID < all[j].ID })
+
+	return all
+}
+
+func newDummyLogger() *slog.Logger {
+	return slog.New(slog.NewJSONHandler(os.Stdout, nil))
+}
@@ -0,0 +1,65 @@
+package users
+
+import (
+	"sync"
+	"time"
+
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/kbs"
+)
+
+// nameEntry is a cached display name. Missing profiles are cached too, so
+// kbs of users without profile don't hit the store on every read.
+type nameEntry struct {
+	name    string
+	found   bool
+	expires time.Time
+}
+
+// nameCache keeps user display names in memory for a limited time.
+type nameCache struct {
+	ttl     time.Duration
+	mu      sync.RWMutex
+	entries map[kbs.UserID]nameEntry
+}
+
+func newNameCache(ttl time.Duration) *nameCache {
+	return &nameCache{
+		ttl:     ttl,
+		entries: make(map[kbs.UserID]nameEntry),
+	}
+}
+
+// get returns the cached entry of the given user if it has not expired.
+func (c *nameCache) get(id kbs.UserID, now time.Time) (nameEntry, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	entry, ok := c.entries[id]
+	if !ok || now.After(entry.expires) {
+		return nameEntry{}, false
+	}
+
+	return entry, true
+}
+
+func (c *nameCache) set(id kbs.UserID, name string, found bool, now time.Time) {
+	if c.ttl <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[id] = nameEntry{
+		name:    name,
+		found:   found,
+		expires: now.Add(c.ttl),
+	}
+}
+
+func (c *nameCache) remove(id kbs.UserID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.entries, id)
+}
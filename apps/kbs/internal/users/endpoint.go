@@ -0,0 +1,183 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/kbs"
+)
+
+type GetProfileWithIDEndpoint struct {
+	service *Service
+	logger  *slog.Logger
+}
+
+type CreateProfileEndpoint struct {
+	service *Service
+	logger  *slog.Logger
+}
+
+type UpdateProfileEndpoint struct {
+	service *Service
+	logger  *slog.Logger
+}
+
+type DeleteProfileEndpoint struct {
+	service *Service
+	logger  *slog.Logger
+}
+
+type SearchProfilesEndpoint struct {
+	service *Service
+	logger  *slog.Logger
+}
+
+// Endpoints is a wrapper for users endpoints
+type Endpoints struct {
+	GetProfileWithIDEndpoint *GetProfileWithIDEndpoint
+	CreateProfileEndpoint    *CreateProfileEndpoint
+	UpdateProfileEndpoint    *UpdateProfileEndpoint
+	DeleteProfileEndpoint    *DeleteProfileEndpoint
+	SearchProfilesEndpoint   *SearchProfilesEndpoint
+}
+
+// NewEndpoints Create the endpoints for users application.
+func NewEndpoints(service *Service, logger *slog.Logger) Endpoints {
+	return Endpoints{
+		CreateProfileEndpoint:    MakeCreateProfileEndpoint(service, logger),
+		UpdateProfileEndpoint:    MakeUpdateProfileEndpoint(service, logger),
+		DeleteProfileEndpoint:    MakeDeleteProfileEndpoint(service, logger),
+		GetProfileWithIDEndpoint: MakeGetProfileWithIDEndpoint(service, logger),
+		SearchProfilesEndpoint:   MakeSearchProfilesEndpoint(service, logger),
+	}
+}
+
+// MakeGetProfileWithIDEndpoint create endpoint for get a profile with ID service.
+func MakeGetProfileWithIDEndpoint(srv *Service, logger *slog.Logger) *GetProfileWithIDEndpoint {
+	return &GetProfileWithIDEndpoint{
+		service: srv,
+		logger:  logger,
+	}
+}
+
+// MakeCreateProfileEndpoint create endpoint for create profile service.
+func MakeCreateProfileEndpoint(srv *Service, logger *slog.Logger) *CreateProfileEndpoint {
+	return &CreateProfileEndpoint{
+		service: srv,
+		logger:  logger,
+	}
+}
+
+// MakeUpdateProfileEndpoint create endpoint for update profile service.
+func MakeUpdateProfileEndpoint(srv *Service, logger *slog.Logger) *UpdateProfileEndpoint {
+	return &UpdateProfileEndpoint{
+		service: srv,
+		logger:  logger,
+	}
+}
+
+// MakeDeleteProfileEndpoint create endpoint for the delete profile service.
+func MakeDeleteProfileEndpoint(srv *Service, logger *slog.Logger) *DeleteProfileEndpoint {
+	return &DeleteProfileEndpoint{
+		service: srv,
+		logger:  logger,
+	}
+}
+
+// MakeSearchProfilesEndpoint create endpoint to list profiles.
+func MakeSearchProfilesEndpoint(srv *Service, logger *slog.Logger) *SearchProfilesEndpoint {
+	return &SearchProfilesEndpoint{
+		service: srv,
+		logger:  logger,
+	}
+}
+
+func (g *GetProfileWithIDEndpoint) Do(ctx context.Context, request any) (any, error) {
+	userID, ok := request.(kbs.UserID)
+	if !ok {
+		g.logger.Error("invalid user id", slog.String("request", fmt.Sprintf("%T", request)))
+
+		return nil, errors.New("invalid user id")
+	}
+
+	profile, err := g.service.QueryByID(ctx, userID)
+	if err != nil {
+		g.logger.Error(
+			"something went wrong trying to get a profile with the given id",
+			slog.String("error", err.Error()),
+		)
+	}
+
+	return newGetProfileWithIDResult(profile, err), nil
+}
+
+func (c *CreateProfileEndpoint) Do(ctx context.Context, request any) (any, error) {
+	newProfile, ok := request.(*NewProfile)
+	if !ok {
+		c.logger.Error("invalid new profile type", slog.String("request", fmt.Sprintf("%T", request)))
+
+		return nil, errors.New("invalid new profile type")
+	}
+
+	newid, err := c.service.Create(ctx, *newProfile)
+	if err != nil {
+		c.logger.Error(
+			"something went wrong trying to create a profile",
+			slog.String("error", err.Error()),
+		)
+	}
+
+	return newCreateProfileResult(newid, err), nil
+}
+
+func (u *UpdateProfileEndpoint) Do(ctx context.Context, request any) (any, error) {
+	updateProfile, ok := request.(*UpdateProfile)
+	if !ok {
+		u.logger.Error("invalid update profile type", slog.String("request", fmt.Sprintf("%T", request)))
+
+		return nil, errors.New("invalid update profile type")
+	}
+
+	err := u.service.Update(ctx, *updateProfile)
+	if err != nil {
+		u.logger.Error(
+			"something went wrong trying to update a profile",
+			slog.String("error", err.Error()),
+		)
+	}
+
+	return newUpdateProfileResult(err), nil
+}
+
+func (d *DeleteProfileEndpoint) Do(ctx context.Context, request any) (any, error) {
+	userID, ok := request.(kbs.UserID)
+	if !ok {
+		d.logger.Error("invalid delete profile type", slog.String("received", fmt.Sprintf("%T", request)))
+
+		return nil, errors.New("invalid user id type")
+	}
+
+	err := d.service.Delete(ctx, userID)
+	if err != nil {
+		d.logger.Error(
+			"something went wrong trying to delete a profile",
+			slog.String("error", err.Error()),
+		)
+	}
+
+	return newDeleteProfileResult(err), nil
+}
+
+func (s *SearchProfilesEndpoint) Do(ctx context.Context, request any) (any, error) {
+	profiles, err := s.service.Query(ctx)
+	if err != nil {
+		s.logger.Error(
+			"something went wrong trying to search profiles",
+			slog.String("error", err.Error()),
+		)
+	}
+
+	return newSearchProfilesResult(profiles, err), nil
+}
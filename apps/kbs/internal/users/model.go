@@ -0,0 +1,244 @@
+package users
+
+import (
+	"fmt"
+	"net/mail"
+	"time"
+
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/kbs"
+)
+
+// Status defines the state of a user profile.
+type Status string
+
+// Profile contains user profile data.
+type Profile struct {
+	ID           kbs.UserID `json:"id"`
+	DisplayName  string     `json:"display_name"`
+	Email        string     `json:"email"`
+	AvatarURL    string     `json:"avatar_url"`
+	Status       Status     `json:"status"`
+	CreationDate int64      `json:"creation_date"`
+	UpdateDate   int64      `json:"update_date"`
+}
+
+// NewProfile contains data to request the creation of a new profile.
+type NewProfile struct {
+	ID          kbs.UserID `json:"id"`
+	DisplayName string     `json:"display_name"`
+	Email       string     `json:"email"`
+	AvatarURL   string     `json:"avatar_url"`
+	Status      Status     `json:"status"`
+}
+
+// UpdateProfile contains data to request the update of a profile.
+type UpdateProfile struct {
+	ID          kbs.UserID `json:"id"`
+	DisplayName string     `json:"display_name"`
+	Email       string     `json:"email"`
+	AvatarURL   string     `json:"avatar_url"`
+	Status      Status     `json:"status"`
+}
+
+// BackfillReport contains the outcome of a profiles backfill.
+type BackfillReport struct {
+	KBsRead         int
+	ProfilesCreated int
+	ProfilesSkipped int
+}
+
+// ValidationError define profile validation logic.
+type ValidationError struct {
+	Errors []string
+}
+
+// GetProfileWithIDResult standard response for get a profile with an ID.
+type GetProfileWithIDResult struct {
+	Profile *Profile
+	Err     string
+}
+
+// CreateProfileResult standard response for create profile.
+type CreateProfileResult struct {
+	ID  kbs.UserID
+	Err string
+}
+
+// UpdateProfileResult standard response for updating a profile.
+type UpdateProfileResult struct {
+	Err string
+}
+
+// DeleteProfileResult standard response for deleting a profile.
+type DeleteProfileResult struct {
+	Err string
+}
+
+// SearchProfilesResult standard response for listing profiles.
+type SearchProfilesResult struct {
+	Profiles []Profile
+	Err      string
+}
+
+// profile status possible values
+const (
+	Active   Status = "active"
+	Inactive Status = "inactive"
+)
+
+const (
+	// EmptyUserID is the user id that empty or nil.
+	EmptyUserID = kbs.UserID("")
+	// EmptyStatus is the status that empty or nil.
+	EmptyStatus = Status("")
+)
+
+func (e *ValidationError) add(message string) {
+	e.Errors = append(e.Errors, message)
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid profile data: %+v", e.Errors)
+}
+
+func buildNewProfile(newProfile NewProfile) Profile {
+	status := newProfile.Status
+	if status == EmptyStatus {
+		status = Active
+	}
+
+	return Profile{
+		ID:           newProfile.ID,
+		DisplayName:  newProfile.DisplayName,
+		Email:        newProfile.Email,
+		AvatarURL:    newProfile.AvatarURL,
+		Status:       status,
+		CreationDate: time.Now().UTC().Unix(),
+	}
+}
+
+func (u UpdateProfile) toProfile(current Profile) Profile {
+	return Profile{
+		ID:           current.ID,
+		DisplayName:  u.DisplayName,
+		Email:        u.Email,
+		AvatarURL:    u.AvatarURL,
+		Status:       u.Status,
+		CreationDate: current.CreationDate,
+		UpdateDate:   time.Now().UTC().Unix(),
+	}
+}
+
+func validNewProfile(newProfile NewProfile) error {
+	err := new(ValidationError)
+
+	validProfileData(err, newProfile.ID, newProfile.DisplayName, newProfile.Email, newProfile.Status)
+
+	if len(err.Errors) > 0 {
+		return err
+	}
+
+	return nil
+}
+
+func validProfileToUpdate(profile UpdateProfile) error {
+	err := new(ValidationError)
+
+	if profile.Status == EmptyStatus {
+		err.add("profile status cannot be empty")
+	}
+
+	validProfileData(err, profile.ID, profile.DisplayName, profile.Email, profile.Status)
+
+	if len(err.Errors) > 0 {
+		return err
+	}
+
+	return nil
+}
+
+func validProfileData(err *ValidationError, id kbs.UserID, displayName, email string, status Status) {
+	if id == EmptyUserID {
+		err.add("user id cannot be empty")
+	}
+
+	if displayName == "" {
+		err.add("display name cannot be empty")
+	}
+
+	if email != "" {
+		if _, errEmail := mail.ParseAddress(email); errEmail != nil {
+			err.add(fmt.Sprintf("email %q is not valid", email))
+		}
+	}
+
+	if status != EmptyStatus && !status.isValid() {
+		err.add(fmt.Sprintf("profile status %q is not valid", status))
+	}
+}
+
+func (s Status) isValid() bool {
+	return s == Active || s == Inactive
+}
+
+func (s Status) String() string {
+	return string(s)
+}
+
+// newGetProfileWithIDResult create a new GetProfileWithIDResult
+func newGetProfileWithIDResult(profile *Profile, err error) GetProfileWithIDResult {
+	var errProfile string
+	if err != nil {
+		errProfile = err.Error()
+	}
+	return GetProfileWithIDResult{
+		Profile: profile,
+		Err:     errProfile,
+	}
+}
+
+// newCreateProfileResult create a new CreateProfileResult
+func newCreateProfileResult(id kbs.UserID, err error) CreateProfileResult {
+	var errProfile string
+	if err != nil {
+		errProfile = err.Error()
+	}
+	return CreateProfileResult{
+		ID:  id,
+		Err: errProfile,
+	}
+}
+
+// newUpdateProfileResult create a new UpdateProfileResult
+func newUpdateProfileResult(err error) UpdateProfileResult {
+	var errProfile string
+	if err != nil {
+		errProfile = err.Error()
+	}
+	return UpdateProfileResult{
+		Err: errProfile,
+	}
+}
+
+// newDeleteProfileResult create a new DeleteProfileResult
+func newDeleteProfileResult(err error) DeleteProfileResult {
+	var errProfile string
+	if err != nil {
+		errProfile = err.Error()
+	}
+	return DeleteProfileResult{
+		Err: errProfile,
+	}
+}
+
+// newSearchProfilesResult create a new SearchProfilesResult
+func newSearchProfilesResult(profiles []Profile, err error) SearchProfilesResult {
+	var errProfile string
+	if err != nil {
+		errProfile = err.Error()
+	}
+	return SearchProfilesResult{
+		Profiles: profiles,
+		Err:      errProfile,
+	}
+}
@@ -0,0 +1,293 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/kbs"
+)
+
+// Storer defines persistence behavior for user profiles.
+type Storer interface {
+	SaveProfile(ctx context.Context, profile Profile) error
+	UpdateProfile(ctx context.Context, profile Profile) error
+	DeleteProfile(ctx context.Context, id kbs.UserID) error
+	// QueryProfileByID find and return a profile with the given id.
+	// If profile does not exist it returns a nil profile and nil error.
+	QueryProfileByID(ctx context.Context, id kbs.UserID) (*Profile, error)
+	// QueryProfilesByIDs returns the profiles that exist for the given ids.
+	QueryProfilesByIDs(ctx context.Context, ids []kbs.UserID) ([]Profile, error)
+	QueryProfiles(ctx context.Context) ([]Profile, error)
+}
+
+// KBScanner reads every kb in the store.
+type KBScanner interface {
+	// ScanKBs calls fn for each kb in the store until all kbs were read
+	// or fn returns an error.
+	ScanKBs(ctx context.Context, fn func(kb kbs.KB) error) error
+}
+
+// ServiceSetup contains service metadata.
+type ServiceSetup struct {
+	Storer Storer
+	Logger *slog.Logger
+	// NameCacheTTL is how long display names are kept in memory.
+	// Zero disables the cache.
+	NameCacheTTL time.Duration
+}
+
+// Service implements user profiles business logic.
+type Service struct {
+	storer Storer
+	cache  *nameCache
+	logger *slog.Logger
+}
+
+var (
+	errSaveProfile    = errors.New("unable to save profile in the repository")
+	errUpdateProfile  = errors.New("unable to update profile in the repository")
+	errDeleteProfile  = errors.New("unable to delete profile")
+	errQueryProfile   = errors.New("unable to query profile")
+	errQueryProfiles  = errors.New("unable to query profiles")
+	errResolveNames   = errors.New("unable to resolve user names")
+	errBackfill       = errors.New("unable to backfill profiles")
+	errEmptyUserID    = errors.New("user id cannot be empty")
+	errProfileExists  = errors.New("profile already exists")
+	errProfileMissing = errors.New("profile does not exist")
+)
+
+// NewService create a new users service.
+func NewService(settings ServiceSetup) *Service {
+	newService := Service{
+		storer: settings.Storer,
+		cache:  newNameCache(settings.NameCacheTTL),
+		logger: settings.Logger,
+	}
+
+	return &newService
+}
+
+// Create create a profile and store it in a database.
+func (s *Service) Create(ctx context.Context, newProfile NewProfile) (kbs.UserID, error) {
+	err := validNewProfile(newProfile)
+	if err != nil {
+		return EmptyUserID, fmt.Errorf("unable to create profile: %w", err)
+	}
+
+	current, err := s.QueryByID(ctx, newProfile.ID)
+	if err != nil {
+		return EmptyUserID, errSaveProfile
+	}
+
+	if current != nil {
+		return EmptyUserID, errProfileExists
+	}
+
+	profile := buildNewProfile(newProfile)
+
+	err = s.storer.SaveProfile(ctx, profile)
+	if err != nil {
+		s.logger.Error("unable to create profile", slog.String("error", err.Error()))
+
+		return EmptyUserID, errSaveProfile
+	}
+
+	s.cache.remove(profile.ID)
+
+	s.logger.Debug("profile was created", slog.String("id", profile.ID.String()))
+
+	return profile.ID, nil
+}
+
+// Update update a profile in a database.
+func (s *Service) Update(ctx context.Context, updateProfile UpdateProfile) error {
+	err := validProfileToUpdate(updateProfile)
+	if err != nil {
+		return fmt.Errorf("unable to update profile: %w", err)
+	}
+
+	current, err := s.QueryByID(ctx, updateProfile.ID)
+	if err != nil {
+		return errUpdateProfile
+	}
+
+	if current == nil {
+		return errProfileMissing
+	}
+
+	err = s.storer.UpdateProfile(ctx, updateProfile.toProfile(*current))
+	if err != nil {
+		s.logger.Error("unable to update profile", slog.String("error", err.Error()))
+
+		return errUpdateProfile
+	}
+
+	s.cache.remove(updateProfile.ID)
+
+	return nil
+}
+
+// Delete delete a profile from database.
+func (s *Service) Delete(ctx context.Context, id kbs.UserID) error {
+	if id == EmptyUserID {
+		return errEmptyUserID
+	}
+
+	err := s.storer.DeleteProfile(ctx, id)
+	if err != nil {
+		s.logger.Error("unable to delete profile",
+			slog.String("id", id.String()),
+			slog.String("error", err.Error()))
+
+		return errDeleteProfile
+	}
+
+	s.cache.remove(id)
+
+	return nil
+}
+
+// QueryByID find a profile with the given id.
+func (s *Service) QueryByID(ctx context.Context, id kbs.UserID) (*Profile, error) {
+	if id == EmptyUserID {
+		return nil, errEmptyUserID
+	}
+
+	profile, err := s.storer.QueryProfileByID(ctx, id)
+	if err != nil {
+		s.logger.Error("unable to query profile by id",
+			slog.String("id", id.String()),
+			slog.String("error", err.Error()))
+
+		return nil, errQueryProfile
+	}
+
+	return profile, nil
+}
+
+// Query returns all profiles.
+func (s *Service) Query(ctx context.Context) ([]Profile, error) {
+	profiles, err := s.storer.QueryProfiles(ctx)
+	if err != nil {
+		s.logger.Error("unable to query profiles", slog.String("error", err.Error()))
+
+		return nil, errQueryProfiles
+	}
+
+	return profiles, nil
+}
+
+// ResolveNames returns the display name of the given users. Users without
+// profile are not included in the result. It implements kbs.NameResolver.
+func (s *Service) ResolveNames(ctx context.Context, ids []kbs.UserID) (map[kbs.UserID]string, error) {
+	now := time.Now()
+	names := make(map[kbs.UserID]string)
+	missing := make([]kbs.UserID, 0)
+	seen := make(map[kbs.UserID]bool)
+
+	for _, id := range ids {
+		if id == EmptyUserID || seen[id] {
+			continue
+		}
+
+		seen[id] = true
+
+		entry, ok := s.cache.get(id, now)
+		if !ok {
+			missing = append(missing, id)
+
+			continue
+		}
+
+		if entry.found {
+			names[id] = entry.name
+		}
+	}
+
+	if len(missing) == 0 {
+		return names, nil
+	}
+
+	profiles, err := s.storer.QueryProfilesByIDs(ctx, missing)
+	if err != nil {
+		s.logger.Error("unable to query profiles by ids", slog.String("error", err.Error()))
+
+		return nil, errResolveNames
+	}
+
+	found := make(map[kbs.UserID]bool, len(profiles))
+
+	for _, profile := range profiles {
+		found[profile.ID] = true
+		names[profile.ID] = profile.DisplayName
+		s.cache.set(profile.ID, profile.DisplayName, true, now)
+	}
+
+	for _, id := range missing {
+		if !found[id] {
+			s.cache.set(id, "", false, now)
+		}
+	}
+
+	return names, nil
+}
+
+// Backfill creates a profile for every user that wrote a kb and doesn't
+// have one yet, using the user name stored in the kb as display name.
+func (s *Service) Backfill(ctx context.Context, scanner KBScanner) (BackfillReport, error) {
+	var report BackfillReport
+
+	visited := make(map[kbs.UserID]bool)
+
+	err := scanner.ScanKBs(ctx, func(kb kbs.KB) error {
+		report.KBsRead++
+
+		if kb.UserID == EmptyUserID || visited[kb.UserID] {
+			return nil
+		}
+
+		visited[kb.UserID] = true
+
+		current, err := s.storer.QueryProfileByID(ctx, kb.UserID)
+		if err != nil {
+			return err
+		}
+
+		if current != nil {
+			report.ProfilesSkipped++
+
+			return nil
+		}
+
+		displayName := kb.UserName
+		if displayName == "" {
+			displayName = kb.UserID.String()
+		}
+
+		err = s.storer.SaveProfile(ctx, buildNewProfile(NewProfile{
+			ID:          kb.UserID,
+			DisplayName: displayName,
+		}))
+		if err != nil {
+			return err
+		}
+
+		report.ProfilesCreated++
+
+		return nil
+	})
+	if err != nil {
+		s.logger.Error("unable to backfill profiles",
+			slog.Any("report", report),
+			slog.String("error", err.Error()))
+
+		return report, errBackfill
+	}
+
+	s.logger.Info("profiles backfill finished", slog.Any("report", report))
+
+	return report, nil
+}
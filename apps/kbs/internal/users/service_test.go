@@ -0,0 +1,168 @@
+package users_test
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/kbs"
+	"github.com/fernandoocampo/kb-store/apps/kbs/internal/users"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveNamesUsesCache(t *testing.T) {
+	// Given
+	ctx := context.TODO()
+	store := newMemoryStore()
+	store.profiles["drila"] = users.Profile{ID: "drila", DisplayName: "Drila Alird"}
+
+	service := users.NewService(users.ServiceSetup{
+		Storer:       store,
+		Logger:       newDummyLogger(),
+		NameCacheTTL: time.Minute,
+	})
+
+	expectedNames := map[kbs.UserID]string{
+		"drila": "Drila Alird",
+	}
+
+	// When
+	first, errFirst := service.ResolveNames(ctx, []kbs.UserID{"drila", "mono", "drila"})
+	second, errSecond := service.ResolveNames(ctx, []kbs.UserID{"drila", "mono"})
+
+	// Then
+	assert.NoError(t, errFirst)
+	assert.NoError(t, errSecond)
+	assert.Equal(t, expectedNames, first)
+	assert.Equal(t, expectedNames, second)
+	assert.Equal(t, 1, store.batchReads)
+}
+
+func TestResolveNamesAfterUpdate(t *testing.T) {
+	// Given
+	ctx := context.TODO()
+	store := newMemoryStore()
+	store.profiles["drila"] = users.Profile{ID: "drila", DisplayName: "Drila", Status: users.Active}
+
+	service := users.NewService(users.ServiceSetup{
+		Storer:       store,
+		Logger:       newDummyLogger(),
+		NameCacheTTL: time.Minute,
+	})
+
+	_, err := service.ResolveNames(ctx, []kbs.UserID{"drila"})
+	assert.NoError(t, err)
+
+	// When
+	err = service.Update(ctx, users.UpdateProfile{ID: "drila", DisplayName: "Drila Alird", Status: users.Active})
+
+	// Then
+	assert.NoError(t, err)
+	got, err := service.ResolveNames(ctx, []kbs.UserID{"drila"})
+	assert.NoError(t, err)
+	assert.Equal(t, "Drila Alird", got["drila"])
+}
+
+func TestBackfill(t *testing.T) {
+	// Given
+	ctx := context.TODO()
+	store := newMemoryStore()
+	store.profiles["mono"] = users.Profile{ID: "mono", DisplayName: "Mono Mario"}
+
+	service := users.NewService(users.ServiceSetup{
+		Storer: store,
+		Logger: newDummyLogger(),
+	})
+
+	scanner := kbScanner{
+		{ID: "1", UserID: "drila", UserName: "alird"},
+		{ID: "2", UserID: "drila", UserName: "alird"},
+		{ID: "3", UserID: "mono", UserName: "mario"},
+	}
+
+	expectedReport := users.BackfillReport{
+		KBsRead:         3,
+		ProfilesCreated: 1,
+		ProfilesSkipped: 1,
+	}
+
+	// When
+	got, err := service.Backfill(ctx, scanner)
+
+	// Then
+	assert.NoError(t, err)
+	assert.Equal(t, expectedReport, got)
+	assert.Equal(t, "alird", store.profiles["drila"].DisplayName)
+	assert.Equal(t, users.Active, store.profiles["drila"].Status)
+	assert.Equal(t, "Mono Mario", store.profiles["mono"].DisplayName)
+}
+
+type kbScanner []kbs.KB
+
+func (k kbScanner) ScanKBs(ctx context.Context, fn func(kb kbs.KB) error) error {
+	for _, kb := range k {
+		if err := fn(kb); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type memoryStore struct {
+	profiles   map[kbs.UserID]users.Profile
+	batchReads int
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{
+		profiles: make(map[kbs.UserID]users.Profile),
+	}
+}
+
+func (m *memoryStore) SaveProfile(ctx context.Context, profile users.Profile) error {
+	m.profiles[profile.ID] = profile
+	return nil
+}
+
+func (m *memoryStore) UpdateProfile(ctx context.Context, profile users.Profile) error {
+	m.profiles[profile.ID] = profile
+	return nil
+}
+
+func (m *memoryStore) DeleteProfile(ctx context.Context, id kbs.UserID) error {
+	delete(m.profiles, id)
+	return nil
+}
+
+func (m *memoryStore) QueryProfileByID(ctx context.Context, id kbs.UserID) (*users.Profile, error) {
+	profile, ok := m.profiles[id]
+	if !ok {
+		return nil, nil
+	}
+	return &profile, nil
+}
+
+func (m *memoryStore) QueryProfilesByIDs(ctx context.Context, ids []kbs.UserID) ([]users.Profile, error) {
+	m.batchReads++
+	var result []users.Profile
+	for _, id := range ids {
+		if profile, ok := m.profiles[id]; ok {
+			result = append(result, profile)
+		}
+	}
+	return result, nil
+}
+
+func (m *memoryStore) QueryProfiles(ctx context.Context) ([]users.Profile, error) {
+	var result []users.Profile
+	for _, v := range m.profiles {
+		result = append(result, v)
+	}
+	return result, nil
+}
+
+func newDummyLogger() *slog.Logger {
+	return slog.New(slog.NewJSONHandler(os.Stdout, nil))
+}